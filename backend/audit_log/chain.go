@@ -0,0 +1,230 @@
+package auditlog
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	zeroLogger "github.com/rs/zerolog/log"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
+)
+
+// Chain appends the audit log to the hash chain: it sets the chain sequence, previous hash, creation time and hash of
+// the audit log and moves the head of the chain to it. The head stays locked until the transaction of the persister
+// ends, so the audit log (or its chain fields, see persistence.AuditLogPersister.UpdateChain) must be stored in the same
+// transaction.
+func Chain(persister persistence.AuditLogChainPersister, auditLog *models.AuditLog) error {
+	head, err := persister.GetHead(true)
+	if err != nil {
+		return err
+	}
+
+	if auditLog.CreatedAt.IsZero() {
+		// not all databases store fractional seconds and some of them round instead of truncating, so the creation
+		// time is truncated before it is hashed
+		now := time.Now().UTC().Truncate(time.Second)
+		auditLog.CreatedAt = now
+		auditLog.UpdatedAt = now
+	}
+
+	sequence := head.ChainSequence + 1
+	previousHash := head.Hash
+	auditLog.ChainSequence = &sequence
+	auditLog.PreviousHash = &previousHash
+
+	hash, err := Hash(*auditLog)
+	if err != nil {
+		return err
+	}
+	auditLog.Hash = &hash
+
+	return persister.UpdateHead(models.AuditLogChainHead{
+		ID:            head.ID,
+		ChainSequence: sequence,
+		Hash:          hash,
+	})
+}
+
+// ChainStored appends an audit log which has been stored unchained (see models.AuditLog.ChainPending) to the hash
+// chain in a transaction of its own. The audit log is read again after the head of the chain has been locked, so an
+// audit log which has been chained or deleted in the meantime is skipped.
+func ChainStored(persister persistence.Persister, auditLog models.AuditLog) error {
+	return persister.Transaction(func(tx *pop.Connection) error {
+		chainPersister := persister.GetAuditLogChainPersister(tx)
+		auditLogPersister := persister.GetAuditLogPersisterWithConnection(tx)
+
+		_, err := chainPersister.GetHead(true)
+		if err != nil {
+			return err
+		}
+
+		stored, err := auditLogPersister.Get(auditLog.ID)
+		if err != nil {
+			return err
+		}
+		if stored == nil || stored.ChainSequence != nil {
+			return nil
+		}
+
+		err = Chain(chainPersister, stored)
+		if err != nil {
+			return err
+		}
+
+		return auditLogPersister.UpdateChain(*stored)
+	})
+}
+
+const (
+	// pendingChainInterval is the interval in which the PendingChainer looks for audit logs waiting to be chained.
+	pendingChainInterval = 1 * time.Minute
+	// PendingChainGracePeriod is the time an audit log may wait to be appended to the hash chain after it has been
+	// created. Audit logs are chained right after the transaction creating them has been committed, older audit logs
+	// waiting to be chained have been missed, e.g. because the instance has been stopped.
+	PendingChainGracePeriod = 1 * time.Minute
+	// pendingChainBatchSize is the maximum number of pending audit logs loaded at once.
+	pendingChainBatchSize = 100
+)
+
+// PendingChainer appends audit logs to the hash chain which have been stored to be chained after their transaction
+// has been committed, but which have not been chained within the PendingChainGracePeriod. Every audit log is chained
+// in a transaction of its own, so multiple instances can run it concurrently.
+type PendingChainer struct {
+	persister persistence.Persister
+}
+
+func NewPendingChainer(persister persistence.Persister) *PendingChainer {
+	return &PendingChainer{persister: persister}
+}
+
+// ChainPending chains all audit logs created before now minus the PendingChainGracePeriod which are still waiting to
+// be chained. It returns the number of chained audit logs.
+func (c *PendingChainer) ChainPending(now time.Time) (int, error) {
+	createdBefore := now.Add(-PendingChainGracePeriod)
+	count := 0
+	for {
+		auditLogs, err := c.persister.GetAuditLogPersister().ListChainPending(createdBefore, pendingChainBatchSize)
+		if err != nil {
+			return count, err
+		}
+
+		for _, auditLog := range auditLogs {
+			err = ChainStored(c.persister, auditLog)
+			if err != nil {
+				return count, fmt.Errorf("failed to chain audit log %s: %w", auditLog.ID, err)
+			}
+			count++
+		}
+
+		if len(auditLogs) < pendingChainBatchSize {
+			return count, nil
+		}
+	}
+}
+
+// Run chains pending audit logs periodically. Run blocks until the given channel is closed.
+func (c *PendingChainer) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(pendingChainInterval)
+	defer ticker.Stop()
+
+	for {
+		count, err := c.ChainPending(time.Now())
+		if err != nil {
+			zeroLogger.Error().Err(err).Msg("failed to chain pending audit logs")
+		}
+		if count > 0 {
+			zeroLogger.Warn().Int("count", count).Msg("chained audit logs which have not been chained after their creation")
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// canonicalAuditLog is the content of an audit log the hash is computed over. Fields are serialized in a fixed order.
+type canonicalAuditLog struct {
+	ChainSequence     int64           `json:"chain_sequence"`
+	PreviousHash      string          `json:"previous_hash"`
+	ID                string          `json:"id"`
+	Type              string          `json:"type"`
+	Error             *string         `json:"error"`
+	MetaHttpRequestId string          `json:"meta_http_request_id"`
+	MetaSourceIp      string          `json:"meta_source_ip"`
+	MetaUserAgent     string          `json:"meta_user_agent"`
+	ActorUserId       *string         `json:"actor_user_id"`
+	ActorEmail        *string         `json:"actor_email"`
+	Details           json.RawMessage `json:"details"`
+	// CreatedAt is the creation time in seconds since the epoch.
+	CreatedAt int64 `json:"created_at"`
+}
+
+// Hash returns the hex encoded SHA-256 hash over the canonical content of a chained audit log, which includes its
+// chain sequence and the hash of the previous audit log.
+func Hash(auditLog models.AuditLog) (string, error) {
+	if auditLog.ChainSequence == nil || auditLog.PreviousHash == nil {
+		return "", errors.New("audit log is not part of the hash chain")
+	}
+
+	details, err := canonicalDetails(auditLog)
+	if err != nil {
+		return "", err
+	}
+
+	content := canonicalAuditLog{
+		ChainSequence:     *auditLog.ChainSequence,
+		PreviousHash:      *auditLog.PreviousHash,
+		ID:                auditLog.ID.String(),
+		Type:              string(auditLog.Type),
+		Error:             auditLog.Error,
+		MetaHttpRequestId: auditLog.MetaHttpRequestId,
+		MetaSourceIp:      auditLog.MetaSourceIp,
+		MetaUserAgent:     auditLog.MetaUserAgent,
+		ActorEmail:        auditLog.ActorEmail,
+		Details:           details,
+		CreatedAt:         auditLog.CreatedAt.Unix(),
+	}
+	if auditLog.ActorUserId != nil {
+		actorUserId := auditLog.ActorUserId.String()
+		content.ActorUserId = &actorUserId
+	}
+
+	canonical, err := json.Marshal(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize audit log: %w", err)
+	}
+
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// canonicalDetails serializes the details the same way before and after they have been stored: values like structs
+// are read back as maps, so the details are decoded into generic values (keeping numbers as they are) and encoded
+// again, which sorts all keys.
+func canonicalDetails(auditLog models.AuditLog) (json.RawMessage, error) {
+	if len(auditLog.Details) == 0 {
+		return json.RawMessage("null"), nil
+	}
+
+	serialized, err := json.Marshal(auditLog.Details)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize audit log details: %w", err)
+	}
+
+	var generic interface{}
+	decoder := json.NewDecoder(bytes.NewReader(serialized))
+	decoder.UseNumber()
+	err = decoder.Decode(&generic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deserialize audit log details: %w", err)
+	}
+
+	return json.Marshal(generic)
+}
@@ -0,0 +1,403 @@
+package auditlog_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gobuffalo/pop/v6/slices"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	auditlog "github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/test"
+	"testing"
+	"time"
+)
+
+// chainPersister uses the given audit log and chain persisters, so tests can tamper with an existing chain.
+type chainPersister struct {
+	persistence.Persister
+	auditLogs persistence.AuditLogPersister
+	chain     persistence.AuditLogChainPersister
+}
+
+func newChainPersister(auditLogs []models.AuditLog, chain persistence.AuditLogChainPersister) *chainPersister {
+	return &chainPersister{
+		Persister: test.NewEmptyPersister(),
+		auditLogs: test.NewAuditLogPersister(auditLogs),
+		chain:     chain,
+	}
+}
+
+func (p *chainPersister) GetAuditLogPersister() persistence.AuditLogPersister {
+	return p.auditLogs
+}
+
+func (p *chainPersister) GetAuditLogPersisterWithConnection(_ *pop.Connection) persistence.AuditLogPersister {
+	return p.auditLogs
+}
+
+func (p *chainPersister) GetAuditLogChainPersister(_ *pop.Connection) persistence.AuditLogChainPersister {
+	return p.chain
+}
+
+type testKeyManager struct {
+	key jwk.Key
+}
+
+func newTestKeyManager(t *testing.T, keyId string) *testKeyManager {
+	rawKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	key, err := jwk.FromRaw(rawKey)
+	require.NoError(t, err)
+	require.NoError(t, key.Set(jwk.KeyIDKey, keyId))
+	require.NoError(t, key.Set(jwk.AlgorithmKey, jwa.ES256))
+	return &testKeyManager{key: key}
+}
+
+func (m *testKeyManager) GetPublicKeys() (jwk.Set, error) {
+	publicKey, err := m.key.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	set := jwk.NewSet()
+	err = set.AddKey(publicKey)
+	return set, err
+}
+
+func (m *testKeyManager) GetSigningKey() (jwk.Key, error) {
+	return m.key, nil
+}
+
+// testRetention allows audit logs older than 90 days to be pruned.
+var testRetention = config.AuditLogRetention{Enabled: true, MaxAge: 90 * 24 * time.Hour}
+
+// newTestChain chains count audit logs and returns them together with the chain persister.
+func newTestChain(t *testing.T, count int) ([]models.AuditLog, persistence.AuditLogChainPersister) {
+	chain := test.NewAuditLogChainPersister(nil)
+	return appendTestChain(t, chain, count), chain
+}
+
+// appendTestChain appends count audit logs to the chain.
+func appendTestChain(t *testing.T, chain persistence.AuditLogChainPersister, count int) []models.AuditLog {
+	auditLogs := make([]models.AuditLog, 0, count)
+	for i := 0; i < count; i++ {
+		auditLog := newTestAuditLog(models.AuditLogPasswordLoginSucceeded, time.Time{})
+		auditLog.Details = slices.Map{"attempt": i, "email": "test@example.com"}
+		require.NoError(t, auditlog.Chain(chain, &auditLog))
+		auditLogs = append(auditLogs, auditLog)
+	}
+
+	return auditLogs
+}
+
+func verifyTestChain(t *testing.T, auditLogs []models.AuditLog, chain persistence.AuditLogChainPersister, keys jwk.Set) *auditlog.VerificationResult {
+	return verifyTestChainWithRetention(t, auditLogs, chain, keys, testRetention)
+}
+
+func verifyTestChainWithRetention(t *testing.T, auditLogs []models.AuditLog, chain persistence.AuditLogChainPersister, keys jwk.Set, retention config.AuditLogRetention) *auditlog.VerificationResult {
+	if keys == nil {
+		keys = jwk.NewSet()
+	}
+
+	// a small batch size makes sure the chain is verified across batches
+	result, err := auditlog.NewVerifier(newChainPersister(auditLogs, chain), keys, retention, 2).Verify()
+	require.NoError(t, err)
+	return result
+}
+
+func TestChain(t *testing.T) {
+	auditLogs, chain := newTestChain(t, 3)
+
+	for i, auditLog := range auditLogs {
+		require.NotNil(t, auditLog.ChainSequence)
+		assert.Equal(t, int64(i+1), *auditLog.ChainSequence)
+		assert.False(t, auditLog.CreatedAt.IsZero())
+		if i == 0 {
+			assert.Equal(t, "", *auditLog.PreviousHash)
+		} else {
+			assert.Equal(t, *auditLogs[i-1].Hash, *auditLog.PreviousHash)
+		}
+	}
+
+	head, err := chain.GetHead(false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), head.ChainSequence)
+	assert.Equal(t, *auditLogs[2].Hash, head.Hash)
+}
+
+func TestHash_StoredDetails(t *testing.T) {
+	auditLogs, _ := newTestChain(t, 1)
+	auditLog := auditLogs[0]
+	auditLog.Details = slices.Map{"user": struct {
+		Name  string `json:"name"`
+		Admin bool   `json:"admin"`
+	}{Name: "test", Admin: true}}
+	hash, err := auditlog.Hash(auditLog)
+	require.NoError(t, err)
+
+	// details are read back from the database as generic values
+	serialized, err := json.Marshal(auditLog.Details)
+	require.NoError(t, err)
+	stored := auditLog
+	stored.Details = slices.Map{}
+	require.NoError(t, json.Unmarshal(serialized, &stored.Details))
+	storedHash, err := auditlog.Hash(stored)
+	require.NoError(t, err)
+
+	assert.Equal(t, hash, storedHash)
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	auditLogs, chain := newTestChain(t, 5)
+
+	result := verifyTestChain(t, auditLogs, chain, nil)
+	assert.True(t, result.Valid(), result.Issues)
+	assert.Equal(t, 5, result.Verified)
+	assert.Equal(t, int64(1), result.FirstSequence)
+	assert.Equal(t, int64(5), result.LastSequence)
+}
+
+func TestVerifier_Verify_Modified(t *testing.T) {
+	auditLogs, chain := newTestChain(t, 5)
+	auditLogs[2].Details = slices.Map{"attempt": 2, "email": "attacker@example.com"}
+
+	result := verifyTestChain(t, auditLogs, chain, nil)
+	require.Len(t, result.Issues, 1)
+	assert.Equal(t, int64(3), result.Issues[0].ChainSequence)
+	assert.Contains(t, result.Issues[0].Message, "has been modified")
+}
+
+func TestVerifier_Verify_Gap(t *testing.T) {
+	auditLogs, chain := newTestChain(t, 5)
+	auditLogs = append(auditLogs[:2], auditLogs[3:]...)
+
+	result := verifyTestChain(t, auditLogs, chain, nil)
+	require.Len(t, result.Issues, 1)
+	assert.Equal(t, int64(4), result.Issues[0].ChainSequence)
+	assert.Equal(t, "audit logs 3 to 3 are missing", result.Issues[0].Message)
+}
+
+func TestVerifier_Verify_Pruned(t *testing.T) {
+	auditLogs, chain := newTestChain(t, 5)
+
+	result := verifyTestChain(t, auditLogs[2:], chain, nil)
+	assert.True(t, result.Valid(), result.Issues)
+	assert.True(t, result.Pruned)
+	assert.Equal(t, int64(3), result.FirstSequence)
+	assert.Equal(t, 3, result.Verified)
+}
+
+func TestVerifier_Verify_PrunedWithoutRetention(t *testing.T) {
+	auditLogs, chain := newTestChain(t, 5)
+
+	// nothing can have been pruned without a maximum age
+	retention := testRetention
+	retention.MaxAge = 0
+	result := verifyTestChainWithRetention(t, auditLogs[2:], chain, nil, retention)
+	require.Len(t, result.Issues, 1)
+	assert.False(t, result.Pruned)
+	assert.Equal(t, int64(3), result.Issues[0].ChainSequence)
+	assert.Equal(t, "audit logs 1 to 2 at the beginning of the chain are missing", result.Issues[0].Message)
+}
+
+func TestVerifier_Verify_PrunedWithinMaxAge(t *testing.T) {
+	manager := newTestKeyManager(t, "key-1")
+	keys, err := manager.GetPublicKeys()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		checkpointAge  time.Duration
+		expectedIssue  string
+		expectedPruned bool
+	}{
+		{
+			name:           "audit logs after the checkpoint are older than the maximum age",
+			checkpointAge:  testRetention.MaxAge + time.Hour,
+			expectedPruned: true,
+		},
+		{
+			name:          "audit logs after the checkpoint are younger than the maximum age",
+			checkpointAge: time.Hour,
+			expectedIssue: "audit logs 3 to 4 at the beginning of the chain are missing, but have been created after checkpoint",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auditLogs, chain := newTestChain(t, 2)
+			_, err := auditlog.CreateCheckpoint(chain, manager, time.Now().Add(-tt.checkpointAge))
+			require.NoError(t, err)
+			auditLogs = append(auditLogs, appendTestChain(t, chain, 3)...)
+
+			result := verifyTestChain(t, auditLogs[4:], chain, keys)
+			assert.Equal(t, tt.expectedPruned, result.Pruned)
+			assert.Equal(t, 1, result.Checkpoints)
+			if tt.expectedIssue == "" {
+				assert.True(t, result.Valid(), result.Issues)
+			} else {
+				require.Len(t, result.Issues, 1)
+				assert.Contains(t, result.Issues[0].Message, tt.expectedIssue)
+			}
+		})
+	}
+}
+
+func TestVerifier_Verify_ChainPending(t *testing.T) {
+	auditLogs, chain := newTestChain(t, 2)
+
+	pending := newTestAuditLog(models.AuditLogPasswordLoginSucceeded, time.Now().Add(-time.Hour))
+	pending.ChainPending = true
+	recent := newTestAuditLog(models.AuditLogPasswordLoginSucceeded, time.Now())
+	recent.ChainPending = true
+	// created while the hash chain was disabled
+	unchained := newTestAuditLog(models.AuditLogPasswordLoginSucceeded, time.Now().Add(-time.Hour))
+
+	result := verifyTestChain(t, append(auditLogs, pending, recent, unchained), chain, nil)
+	require.Len(t, result.Issues, 1)
+	assert.Equal(t, "1 audit logs have not been appended to the chain", result.Issues[0].String())
+}
+
+func TestPendingChainer_ChainPending(t *testing.T) {
+	auditLogs, chain := newTestChain(t, 2)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	pending := newTestAuditLog(models.AuditLogPasswordLoginSucceeded, now.Add(-time.Hour))
+	pending.ChainPending = true
+	recent := newTestAuditLog(models.AuditLogPasswordLoginSucceeded, now)
+	recent.ChainPending = true
+	unchained := newTestAuditLog(models.AuditLogPasswordLoginSucceeded, now.Add(-time.Hour))
+
+	persister := newChainPersister(append(auditLogs, pending, recent, unchained), chain)
+	chainer := auditlog.NewPendingChainer(persister)
+
+	count, err := chainer.ChainPending(now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "audit logs are chained after the grace period")
+
+	count, err = chainer.ChainPending(now.Add(auditlog.PendingChainGracePeriod + time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	count, err = chainer.ChainPending(now.Add(auditlog.PendingChainGracePeriod + time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	stored, err := persister.auditLogs.Get(unchained.ID)
+	require.NoError(t, err)
+	assert.Nil(t, stored.ChainSequence, "audit logs created while the hash chain was disabled are not chained")
+
+	head, err := chain.GetHead(false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), head.ChainSequence)
+
+	result, err := auditlog.NewVerifier(persister, jwk.NewSet(), testRetention, 2).Verify()
+	require.NoError(t, err)
+	assert.True(t, result.Valid(), result.Issues)
+	assert.Equal(t, 4, result.Verified)
+}
+
+func TestChainStored_AlreadyChained(t *testing.T) {
+	auditLogs, chain := newTestChain(t, 2)
+	persister := newChainPersister(auditLogs, chain)
+
+	require.NoError(t, auditlog.ChainStored(persister, auditLogs[1]))
+
+	head, err := chain.GetHead(false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), head.ChainSequence, "a chained audit log must not be chained again")
+}
+
+func TestVerifier_Verify_MissingEnd(t *testing.T) {
+	auditLogs, chain := newTestChain(t, 5)
+
+	result := verifyTestChain(t, auditLogs[:4], chain, nil)
+	require.Len(t, result.Issues, 1)
+	assert.Equal(t, "audit logs 5 to 5 at the end of the chain are missing", result.Issues[0].Message)
+}
+
+func TestCreateCheckpoint(t *testing.T) {
+	manager := newTestKeyManager(t, "key-1")
+	keys, err := manager.GetPublicKeys()
+	require.NoError(t, err)
+
+	chain := test.NewAuditLogChainPersister(nil)
+	checkpoint, err := auditlog.CreateCheckpoint(chain, manager, time.Now())
+	require.NoError(t, err)
+	assert.Nil(t, checkpoint, "no checkpoint must be created for an empty chain")
+
+	auditLogs, chain := newTestChain(t, 3)
+	checkpoint, err = auditlog.CreateCheckpoint(chain, manager, time.Now())
+	require.NoError(t, err)
+	require.NotNil(t, checkpoint)
+	assert.Equal(t, int64(3), checkpoint.ChainSequence)
+	assert.Equal(t, *auditLogs[2].Hash, checkpoint.Hash)
+	assert.Equal(t, "key-1", checkpoint.KeyID)
+	assert.NoError(t, auditlog.VerifyCheckpoint(*checkpoint, keys))
+
+	again, err := auditlog.CreateCheckpoint(chain, manager, time.Now())
+	require.NoError(t, err)
+	assert.Nil(t, again, "no checkpoint must be created if the chain has not been extended")
+
+	forged := *checkpoint
+	forged.Hash = *auditLogs[1].Hash
+	assert.Error(t, auditlog.VerifyCheckpoint(forged, keys))
+}
+
+func TestVerifier_Verify_Checkpoints(t *testing.T) {
+	manager := newTestKeyManager(t, "key-1")
+	keys, err := manager.GetPublicKeys()
+	require.NoError(t, err)
+
+	auditLogs, chain := newTestChain(t, 3)
+	_, err = auditlog.CreateCheckpoint(chain, manager, time.Now())
+	require.NoError(t, err)
+
+	result := verifyTestChain(t, auditLogs, chain, keys)
+	assert.True(t, result.Valid(), result.Issues)
+	assert.Equal(t, 1, result.Checkpoints)
+
+	// keys which have signed checkpoints are retained, so a missing key is an issue
+	otherKeys, err := newTestKeyManager(t, "key-2").GetPublicKeys()
+	require.NoError(t, err)
+	result = verifyTestChain(t, auditLogs, chain, otherKeys)
+	require.Len(t, result.Issues, 1)
+	assert.Contains(t, result.Issues[0].Message, "key key-1 of checkpoint")
+	assert.Equal(t, 0, result.Checkpoints)
+}
+
+func TestVerifier_Verify_RecomputedChain(t *testing.T) {
+	manager := newTestKeyManager(t, "key-1")
+	keys, err := manager.GetPublicKeys()
+	require.NoError(t, err)
+
+	auditLogs, chain := newTestChain(t, 3)
+	_, err = auditlog.CreateCheckpoint(chain, manager, time.Now())
+	require.NoError(t, err)
+
+	// an attacker modifies an audit log and recomputes all hashes and the head of the chain
+	checkpoints, err := chain.ListCheckpoints()
+	require.NoError(t, err)
+	recomputed := test.NewAuditLogChainPersister(checkpoints)
+	for i := range auditLogs {
+		auditLogs[i].ChainSequence = nil
+		auditLogs[i].PreviousHash = nil
+		auditLogs[i].Hash = nil
+	}
+	auditLogs[1].Details = slices.Map{"attempt": 1, "email": "attacker@example.com"}
+	for i := range auditLogs {
+		require.NoError(t, auditlog.Chain(recomputed, &auditLogs[i]))
+	}
+
+	result := verifyTestChain(t, auditLogs, recomputed, keys)
+	require.Len(t, result.Issues, 1)
+	assert.Equal(t, int64(3), result.Issues[0].ChainSequence)
+	assert.Contains(t, result.Issues[0].Message, "does not match checkpoint")
+}
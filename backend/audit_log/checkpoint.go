@@ -0,0 +1,201 @@
+package auditlog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	zeroLogger "github.com/rs/zerolog/log"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
+)
+
+const (
+	// checkpointCheckInterval is the interval in which the CheckpointScheduler checks whether a checkpoint is due
+	checkpointCheckInterval = 1 * time.Minute
+	// checkpointLockName is the name of the scheduler lock making sure only one instance creates a checkpoint per
+	// interval
+	checkpointLockName = "audit_log_checkpoint"
+)
+
+// ErrCheckpointKeyNotFound is returned by VerifyCheckpoint if the key a checkpoint has been signed with is not in the
+// given set of keys.
+var ErrCheckpointKeyNotFound = errors.New("checkpoint key not found")
+
+// SigningKeyProvider provides the key checkpoints are signed with, e.g. a jwk.Manager.
+type SigningKeyProvider interface {
+	GetSigningKey() (jwk.Key, error)
+}
+
+// checkpointPayload is the signed payload of a checkpoint.
+type checkpointPayload struct {
+	ChainSequence int64  `json:"chain_sequence"`
+	Hash          string `json:"hash"`
+	CreatedAt     int64  `json:"created_at"`
+}
+
+// CreateCheckpoint signs the current head of the hash chain with the current signing key. The key must be retained
+// when keys are pruned (see jwk.WithRetainedKeys and AuditLogChainPersister.ListCheckpointKeyIDs), otherwise the
+// checkpoint cannot be verified anymore. No checkpoint is
+// created (and nil is returned) if the chain is empty or has not been extended since the latest checkpoint.
+func CreateCheckpoint(persister persistence.AuditLogChainPersister, keys SigningKeyProvider, now time.Time) (*models.AuditLogCheckpoint, error) {
+	head, err := persister.GetHead(false)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := persister.GetLatestCheckpoint()
+	if err != nil {
+		return nil, err
+	}
+
+	if head.ChainSequence == 0 || (latest != nil && latest.ChainSequence >= head.ChainSequence) {
+		return nil, nil
+	}
+
+	key, err := keys.GetSigningKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signing key: %w", err)
+	}
+
+	createdAt := now.UTC().Truncate(time.Second)
+	payload, err := json.Marshal(checkpointPayload{
+		ChainSequence: head.ChainSequence,
+		Hash:          head.Hash,
+		CreatedAt:     createdAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm := jwa.RS256
+	if alg, ok := key.Algorithm().(jwa.SignatureAlgorithm); ok && alg != "" {
+		algorithm = alg
+	}
+
+	headers := jws.NewHeaders()
+	_ = headers.Set(jws.KeyIDKey, key.KeyID())
+	signature, err := jws.Sign(payload, jws.WithKey(algorithm, key, jws.WithProtectedHeaders(headers)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign checkpoint: %w", err)
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoint := models.AuditLogCheckpoint{
+		ID:            id,
+		ChainSequence: head.ChainSequence,
+		Hash:          head.Hash,
+		KeyID:         key.KeyID(),
+		Signature:     string(signature),
+		CreatedAt:     createdAt,
+		UpdatedAt:     createdAt,
+	}
+
+	err = persister.CreateCheckpoint(checkpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &checkpoint, nil
+}
+
+// VerifyCheckpoint verifies the signature of the checkpoint with the given keys and makes sure the signed payload
+// matches the checkpoint. ErrCheckpointKeyNotFound is returned if the key of the checkpoint is not in the set.
+func VerifyCheckpoint(checkpoint models.AuditLogCheckpoint, keys jwk.Set) error {
+	if _, ok := keys.LookupKeyID(checkpoint.KeyID); !ok {
+		return ErrCheckpointKeyNotFound
+	}
+
+	publicKeys, err := jwk.PublicSetOf(keys)
+	if err != nil {
+		return err
+	}
+
+	payload, err := jws.Verify([]byte(checkpoint.Signature), jws.WithKeySet(publicKeys, jws.WithInferAlgorithmFromKey(true)))
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+
+	var signed checkpointPayload
+	err = json.Unmarshal(payload, &signed)
+	if err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+
+	if signed.ChainSequence != checkpoint.ChainSequence ||
+		signed.Hash != checkpoint.Hash ||
+		signed.CreatedAt != checkpoint.CreatedAt.Unix() {
+		return errors.New("checkpoint does not match its signed payload")
+	}
+
+	return nil
+}
+
+// CheckpointScheduler periodically creates checkpoints of the hash chain.
+type CheckpointScheduler struct {
+	persister persistence.Persister
+	keys      SigningKeyProvider
+	interval  time.Duration
+	// holder identifies this scheduler when acquiring the checkpoint lock
+	holder string
+}
+
+func NewCheckpointScheduler(persister persistence.Persister, keys SigningKeyProvider, cfg config.AuditLogCheckpoints) *CheckpointScheduler {
+	holder, _ := uuid.NewV4()
+	return &CheckpointScheduler{
+		persister: persister,
+		keys:      keys,
+		interval:  cfg.Interval,
+		holder:    holder.String(),
+	}
+}
+
+// Run checks periodically whether a checkpoint is due and creates one if so. Run blocks until the given channel is
+// closed.
+func (s *CheckpointScheduler) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(checkpointCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		s.checkpointIfDue()
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *CheckpointScheduler) checkpointIfDue() {
+	now := time.Now()
+	acquired, err := s.persister.GetSchedulerLockPersister(nil).Acquire(checkpointLockName, s.holder, now, now.Add(s.interval))
+	if err != nil {
+		zeroLogger.Error().Err(err).Msg("failed to acquire audit log checkpoint lock")
+		return
+	}
+
+	if !acquired {
+		// checkpoint created by this or another instance within the interval
+		return
+	}
+
+	checkpoint, err := CreateCheckpoint(s.persister.GetAuditLogChainPersister(nil), s.keys, now)
+	if err != nil {
+		zeroLogger.Error().Err(err).Msg("failed to create audit log checkpoint")
+		return
+	}
+
+	if checkpoint != nil {
+		zeroLogger.Info().Int64("chain_sequence", checkpoint.ChainSequence).Msg("created audit log checkpoint")
+	}
+}
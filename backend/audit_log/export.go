@@ -0,0 +1,177 @@
+package auditlog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"io"
+	"time"
+)
+
+// ExportFormat is the output format of an audit log export.
+type ExportFormat string
+
+const (
+	// ExportFormatNDJSON writes one JSON object per audit log and line.
+	ExportFormatNDJSON ExportFormat = "ndjson"
+	// ExportFormatCSV writes a header line followed by one line per audit log. The details are written as JSON.
+	ExportFormatCSV ExportFormat = "csv"
+)
+
+// defaultExportBatchSize is the number of audit logs fetched from the database at once.
+const defaultExportBatchSize = 500
+
+// IsValid reports whether the format is known.
+func (f ExportFormat) IsValid() bool {
+	return f == ExportFormatNDJSON || f == ExportFormatCSV
+}
+
+// ContentType returns the media type of the format.
+func (f ExportFormat) ContentType() string {
+	if f == ExportFormatCSV {
+		return "text/csv"
+	}
+
+	return "application/x-ndjson"
+}
+
+// ExportFilter restricts the exported audit logs. It supports the same filters as listing audit logs in the admin API.
+type ExportFilter struct {
+	StartTime    *time.Time
+	EndTime      *time.Time
+	Types        []string
+	UserId       string
+	Email        string
+	IP           string
+	SearchString string
+}
+
+var csvHeader = []string{
+	"id",
+	"type",
+	"created_at",
+	"error",
+	"actor_user_id",
+	"actor_email",
+	"meta_http_request_id",
+	"meta_source_ip",
+	"meta_user_agent",
+	"details",
+}
+
+// Flusher is implemented by writers which buffer data, e.g. a gzip.Writer. Export flushes such writers after every
+// batch, so the exported audit logs are streamed.
+type Flusher interface {
+	Flush() error
+}
+
+// Export writes all audit logs matching the filter in the given format to w, oldest first. The audit logs are fetched
+// in batches using keyset pagination, so the memory used does not depend on the number of exported audit logs. It
+// returns the number of exported audit logs.
+func Export(w io.Writer, persister persistence.AuditLogPersister, format ExportFormat, filter ExportFilter, batchSize int) (int, error) {
+	if !format.IsValid() {
+		return 0, fmt.Errorf("unknown export format: %s", format)
+	}
+
+	if batchSize < 1 {
+		batchSize = defaultExportBatchSize
+	}
+
+	var writeAuditLog func(models.AuditLog) error
+	var flush func() error
+	switch format {
+	case ExportFormatCSV:
+		csvWriter := csv.NewWriter(w)
+		err := csvWriter.Write(csvHeader)
+		if err != nil {
+			return 0, err
+		}
+		writeAuditLog = func(auditLog models.AuditLog) error {
+			record, err := toCsvRecord(auditLog)
+			if err != nil {
+				return err
+			}
+			return csvWriter.Write(record)
+		}
+		flush = func() error {
+			csvWriter.Flush()
+			return csvWriter.Error()
+		}
+	default:
+		encoder := json.NewEncoder(w)
+		writeAuditLog = func(auditLog models.AuditLog) error {
+			return encoder.Encode(auditLog)
+		}
+		flush = func() error {
+			return nil
+		}
+	}
+
+	count := 0
+	var cursor *models.AuditLog
+	for {
+		auditLogs, err := persister.ListAfter(cursor, batchSize, filter.StartTime, filter.EndTime, filter.Types, filter.UserId, filter.Email, filter.IP, filter.SearchString)
+		if err != nil {
+			return count, err
+		}
+
+		for _, auditLog := range auditLogs {
+			err = writeAuditLog(auditLog)
+			if err != nil {
+				return count, fmt.Errorf("failed to write audit log: %w", err)
+			}
+			count++
+		}
+
+		err = flush()
+		if err == nil {
+			if flusher, ok := w.(Flusher); ok {
+				err = flusher.Flush()
+			}
+		}
+		if err != nil {
+			return count, fmt.Errorf("failed to write audit logs: %w", err)
+		}
+
+		if len(auditLogs) < batchSize {
+			return count, nil
+		}
+
+		cursor = &auditLogs[len(auditLogs)-1]
+	}
+}
+
+func toCsvRecord(auditLog models.AuditLog) ([]string, error) {
+	var logError, actorUserId, actorEmail, details string
+	if auditLog.Error != nil {
+		logError = *auditLog.Error
+	}
+	if auditLog.ActorUserId != nil {
+		actorUserId = auditLog.ActorUserId.String()
+	}
+	if auditLog.ActorEmail != nil {
+		actorEmail = *auditLog.ActorEmail
+	}
+	if len(auditLog.Details) > 0 {
+		detailsJson, err := json.Marshal(auditLog.Details)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal details: %w", err)
+		}
+		details = string(detailsJson)
+	}
+
+	return []string{
+		auditLog.ID.String(),
+		string(auditLog.Type),
+		auditLog.CreatedAt.UTC().Format(time.RFC3339Nano),
+		logError,
+		actorUserId,
+		actorEmail,
+		auditLog.MetaHttpRequestId,
+		auditLog.MetaSourceIp,
+		auditLog.MetaUserAgent,
+		details,
+	}, nil
+}
@@ -0,0 +1,73 @@
+package auditlog_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	auditlog "github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/test"
+	"testing"
+	"time"
+)
+
+func newTestExportAuditLogs(now time.Time) []models.AuditLog {
+	return []models.AuditLog{
+		newTestAuditLog(models.AuditLogPasswordLoginSucceeded, now.Add(-1*time.Hour)),
+		newTestAuditLog(models.AuditLogPasswordLoginFailed, now.Add(-5*time.Hour)),
+		newTestAuditLog(models.AuditLogPasswordLoginSucceeded, now.Add(-3*time.Hour)),
+		newTestAuditLog(models.AuditLogUserDeleted, now.Add(-4*time.Hour)),
+		newTestAuditLog(models.AuditLogPasswordLoginSucceeded, now.Add(-2*time.Hour)),
+	}
+}
+
+func TestExport_NDJSON(t *testing.T) {
+	now := time.Now().UTC()
+	persister := test.NewAuditLogPersister(newTestExportAuditLogs(now))
+
+	var out bytes.Buffer
+	// a batch size smaller than the number of audit logs makes sure the cursor is used
+	count, err := auditlog.Export(&out, persister, auditlog.ExportFormatNDJSON, auditlog.ExportFilter{}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 5, count)
+
+	var createdAt []time.Time
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var auditLog models.AuditLog
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &auditLog))
+		createdAt = append(createdAt, auditLog.CreatedAt)
+	}
+	require.Len(t, createdAt, 5)
+	for i := 1; i < len(createdAt); i++ {
+		assert.True(t, createdAt[i-1].Before(createdAt[i]), "audit logs must be exported oldest first")
+	}
+}
+
+func TestExport_CSVWithFilter(t *testing.T) {
+	now := time.Now().UTC()
+	persister := test.NewAuditLogPersister(newTestExportAuditLogs(now))
+
+	var out bytes.Buffer
+	filter := auditlog.ExportFilter{Types: []string{string(models.AuditLogPasswordLoginSucceeded)}}
+	count, err := auditlog.Export(&out, persister, auditlog.ExportFormatCSV, filter, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	records, err := csv.NewReader(&out).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	assert.Equal(t, "id", records[0][0])
+	for _, record := range records[1:] {
+		assert.Equal(t, string(models.AuditLogPasswordLoginSucceeded), record[1])
+	}
+}
+
+func TestExport_InvalidFormat(t *testing.T) {
+	var out bytes.Buffer
+	_, err := auditlog.Export(&out, test.NewAuditLogPersister(nil), "xml", auditlog.ExportFilter{}, 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,221 @@
+package auditlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/teamhanko/hanko/backend/build_info"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	vendor  = "Hanko"
+	product = "Hanko"
+)
+
+// Formatter formats an audit log as a single line for a Sink.
+type Formatter interface {
+	Format(auditLog models.AuditLog) ([]byte, error)
+}
+
+// NewFormatter returns the Formatter for the given format. JSON is used if no format is given.
+func NewFormatter(format config.AuditLogSinkFormat) (Formatter, error) {
+	switch format {
+	case "", config.AuditLogSinkFormatJSON:
+		return JSONFormatter{}, nil
+	case config.AuditLogSinkFormatCEF:
+		return CEFFormatter{}, nil
+	case config.AuditLogSinkFormatLEEF:
+		return LEEFFormatter{}, nil
+	default:
+		return nil, fmt.Errorf("unknown audit log format: %s", format)
+	}
+}
+
+// JSONFormatter formats audit logs like the audit log API does.
+type JSONFormatter struct{}
+
+func (JSONFormatter) Format(auditLog models.AuditLog) ([]byte, error) {
+	return json.Marshal(auditLog)
+}
+
+// CEFFormatter formats audit logs in the ArcSight Common Event Format (CEF) version 0.
+type CEFFormatter struct{}
+
+var (
+	cefHeaderEscaper    = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\n", " ", "\r", " ")
+	cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`, "\r", `\r`)
+)
+
+func (CEFFormatter) Format(auditLog models.AuditLog) ([]byte, error) {
+	severity := 3
+	if isFailure(auditLog) {
+		severity = 6
+	}
+
+	var extension []string
+	for _, field := range eventFields(auditLog, cefKeys) {
+		extension = append(extension, field.key+"="+cefExtensionEscaper.Replace(field.value))
+	}
+	if auditLog.MetaHttpRequestId != "" {
+		extension = append(extension, "cs1Label=requestId")
+	}
+
+	line := fmt.Sprintf("CEF:0|%s|%s|%s|%s|%s|%d|%s",
+		cefHeaderEscaper.Replace(vendor),
+		cefHeaderEscaper.Replace(product),
+		cefHeaderEscaper.Replace(build_info.GetVersion()),
+		cefHeaderEscaper.Replace(string(auditLog.Type)),
+		cefHeaderEscaper.Replace(eventName(auditLog)),
+		severity,
+		strings.Join(extension, " "),
+	)
+
+	return []byte(line), nil
+}
+
+// LEEFFormatter formats audit logs in the IBM QRadar Log Event Extended Format (LEEF) version 1.0.
+type LEEFFormatter struct{}
+
+var (
+	leefHeaderEscaper    = strings.NewReplacer(`|`, `\|`, "\n", " ", "\r", " ")
+	leefAttributeEscaper = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+)
+
+func (LEEFFormatter) Format(auditLog models.AuditLog) ([]byte, error) {
+	attributes := []string{"devTimeFormat=yyyy-MM-dd'T'HH:mm:ss.SSSZ"}
+	for _, field := range eventFields(auditLog, leefKeys) {
+		attributes = append(attributes, field.key+"="+leefAttributeEscaper.Replace(field.value))
+	}
+	if isFailure(auditLog) {
+		attributes = append(attributes, "sev=6")
+	} else {
+		attributes = append(attributes, "sev=3")
+	}
+
+	line := fmt.Sprintf("LEEF:1.0|%s|%s|%s|%s|%s",
+		leefHeaderEscaper.Replace(vendor),
+		leefHeaderEscaper.Replace(product),
+		leefHeaderEscaper.Replace(build_info.GetVersion()),
+		leefHeaderEscaper.Replace(string(auditLog.Type)),
+		strings.Join(attributes, "\t"),
+	)
+
+	return []byte(line), nil
+}
+
+// fieldKeys are the keys the fields of an audit log are written under by a format.
+type fieldKeys struct {
+	time      string
+	ip        string
+	userId    string
+	email     string
+	userAgent string
+	id        string
+	requestId string
+	message   string
+}
+
+var (
+	cefKeys = fieldKeys{
+		time:      "rt",
+		ip:        "src",
+		userId:    "suid",
+		email:     "suser",
+		userAgent: "requestClientApplication",
+		id:        "externalId",
+		requestId: "cs1",
+		message:   "msg",
+	}
+	leefKeys = fieldKeys{
+		time:      "devTime",
+		ip:        "src",
+		userId:    "usrName",
+		email:     "identSrc",
+		userAgent: "userAgent",
+		id:        "externalId",
+		requestId: "requestId",
+		message:   "msg",
+	}
+)
+
+type eventField struct {
+	key   string
+	value string
+}
+
+// eventFields returns the non-empty fields of the audit log: time, source IP, user ID, user email, user agent, audit
+// log ID, HTTP request ID and message (error and details).
+func eventFields(auditLog models.AuditLog, keys fieldKeys) []eventField {
+	fields := []eventField{
+		{key: keys.time, value: formatEventTime(keys.time, auditLog.CreatedAt)},
+		{key: keys.ip, value: auditLog.MetaSourceIp},
+	}
+
+	if auditLog.ActorUserId != nil {
+		fields = append(fields, eventField{key: keys.userId, value: auditLog.ActorUserId.String()})
+	}
+	if auditLog.ActorEmail != nil {
+		fields = append(fields, eventField{key: keys.email, value: *auditLog.ActorEmail})
+	}
+
+	fields = append(fields,
+		eventField{key: keys.userAgent, value: auditLog.MetaUserAgent},
+		eventField{key: keys.id, value: auditLog.ID.String()},
+		eventField{key: keys.requestId, value: auditLog.MetaHttpRequestId},
+		eventField{key: keys.message, value: eventMessage(auditLog)},
+	)
+
+	nonEmpty := fields[:0]
+	for _, field := range fields {
+		if field.value != "" {
+			nonEmpty = append(nonEmpty, field)
+		}
+	}
+
+	return nonEmpty
+}
+
+func formatEventTime(key string, t time.Time) string {
+	if t.IsZero() {
+		t = time.Now()
+	}
+
+	if key == cefKeys.time {
+		// CEF: milliseconds since epoch
+		return fmt.Sprintf("%d", t.UnixMilli())
+	}
+
+	return t.Format("2006-01-02T15:04:05.000-0700")
+}
+
+// eventMessage contains the error and the details of the audit log.
+func eventMessage(auditLog models.AuditLog) string {
+	var parts []string
+	if auditLog.Error != nil && *auditLog.Error != "" {
+		parts = append(parts, "error: "+*auditLog.Error)
+	}
+
+	keys := make([]string, 0, len(auditLog.Details))
+	for key := range auditLog.Details {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s: %v", key, auditLog.Details[key]))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// eventName returns a human-readable name of the audit log type, e.g. "password login failed".
+func eventName(auditLog models.AuditLog) string {
+	return strings.ReplaceAll(string(auditLog.Type), "_", " ")
+}
+
+func isFailure(auditLog models.AuditLog) bool {
+	return auditLog.Error != nil || strings.HasSuffix(string(auditLog.Type), "_failed")
+}
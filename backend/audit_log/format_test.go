@@ -0,0 +1,86 @@
+package auditlog_test
+
+import (
+	"encoding/json"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	auditlog "github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestFormatAuditLog() models.AuditLog {
+	auditLog := newTestAuditLog(models.AuditLogPasswordLoginFailed, time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC))
+	userId := uuid.FromStringOrNil("b5dd5267-b462-48be-b70d-bcd6f1bbe7a5")
+	email := "test|user=1@example.com"
+	errorMessage := "password\nincorrect"
+	auditLog.ActorUserId = &userId
+	auditLog.ActorEmail = &email
+	auditLog.Error = &errorMessage
+	auditLog.MetaSourceIp = "127.0.0.1"
+	auditLog.MetaHttpRequestId = "request-1"
+	return auditLog
+}
+
+func TestNewFormatter(t *testing.T) {
+	formatter, err := auditlog.NewFormatter("")
+	require.NoError(t, err)
+	assert.IsType(t, auditlog.JSONFormatter{}, formatter)
+
+	formatter, err = auditlog.NewFormatter(config.AuditLogSinkFormatCEF)
+	require.NoError(t, err)
+	assert.IsType(t, auditlog.CEFFormatter{}, formatter)
+
+	_, err = auditlog.NewFormatter("xml")
+	assert.Error(t, err)
+}
+
+func TestJSONFormatter_Format(t *testing.T) {
+	auditLog := newTestFormatAuditLog()
+
+	line, err := auditlog.JSONFormatter{}.Format(auditLog)
+	require.NoError(t, err)
+
+	var decoded models.AuditLog
+	require.NoError(t, json.Unmarshal(line, &decoded))
+	assert.Equal(t, auditLog.ID, decoded.ID)
+	assert.Equal(t, auditLog.Type, decoded.Type)
+}
+
+func TestCEFFormatter_Format(t *testing.T) {
+	line, err := auditlog.CEFFormatter{}.Format(newTestFormatAuditLog())
+	require.NoError(t, err)
+
+	formatted := string(line)
+	assert.True(t, strings.HasPrefix(formatted, "CEF:0|Hanko|Hanko|"))
+	assert.Contains(t, formatted, "|password_login_failed|password login failed|6|")
+	assert.Contains(t, formatted, "rt=1727784000000")
+	assert.Contains(t, formatted, "src=127.0.0.1")
+	assert.Contains(t, formatted, "suid=b5dd5267-b462-48be-b70d-bcd6f1bbe7a5")
+	// '=' must be escaped in extension values, '|' must not
+	assert.Contains(t, formatted, `suser=test|user\=1@example.com`)
+	assert.Contains(t, formatted, `msg=error: password\nincorrect`)
+	assert.Contains(t, formatted, "cs1=request-1")
+	assert.Contains(t, formatted, "cs1Label=requestId")
+	assert.NotContains(t, formatted, "\n")
+}
+
+func TestLEEFFormatter_Format(t *testing.T) {
+	line, err := auditlog.LEEFFormatter{}.Format(newTestFormatAuditLog())
+	require.NoError(t, err)
+
+	formatted := string(line)
+	assert.True(t, strings.HasPrefix(formatted, "LEEF:1.0|Hanko|Hanko|"))
+	assert.Contains(t, formatted, "|password_login_failed|devTimeFormat=")
+
+	attributes := strings.Split(strings.SplitN(formatted, "|", 6)[5], "\t")
+	assert.Contains(t, attributes, "devTime=2024-10-01T12:00:00.000+0000")
+	assert.Contains(t, attributes, "usrName=b5dd5267-b462-48be-b70d-bcd6f1bbe7a5")
+	assert.Contains(t, attributes, "msg=error: password incorrect")
+	assert.Contains(t, attributes, "sev=6")
+	assert.NotContains(t, formatted, "\n")
+}
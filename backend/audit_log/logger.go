@@ -1,6 +1,8 @@
 package auditlog
 
 import (
+	"errors"
+	"fmt"
 	"github.com/gobuffalo/pop/v6"
 	"github.com/labstack/echo/v4"
 	zeroLog "github.com/rs/zerolog"
@@ -17,17 +19,23 @@ import (
 type Logger interface {
 	Create(echo.Context, models.AuditLogType, *models.User, error, ...DetailOption) error
 	CreateWithConnection(*pop.Connection, echo.Context, models.AuditLogType, *models.User, error, ...DetailOption) error
+	// Close writes the buffered audit logs to the sinks and closes them.
+	Close() error
 }
 
 type logger struct {
 	persister             persistence.Persister
 	storageEnabled        bool
+	integrityEnabled      bool
 	logger                zeroLog.Logger
 	consoleLoggingEnabled bool
 	mustMask              bool
+	sinks                 []Sink
 }
 
-func NewLogger(persister persistence.Persister, cfg config.AuditLog) Logger {
+// NewLogger returns a Logger writing audit logs to the database, the console and the sinks as configured. It fails if
+// a sink cannot be created.
+func NewLogger(persister persistence.Persister, cfg config.AuditLog) (Logger, error) {
 	var loggerOutput *os.File = nil
 	switch cfg.ConsoleOutput.OutputStream {
 	case config.OutputStreamStdOut:
@@ -38,13 +46,20 @@ func NewLogger(persister persistence.Persister, cfg config.AuditLog) Logger {
 		loggerOutput = os.Stdout
 	}
 
+	sinks, err := NewSinks(cfg.Sinks)
+	if err != nil {
+		return nil, err
+	}
+
 	return &logger{
 		persister:             persister,
 		storageEnabled:        cfg.Storage.Enabled,
+		integrityEnabled:      cfg.Integrity.Enabled,
 		logger:                zeroLog.New(loggerOutput),
 		consoleLoggingEnabled: cfg.ConsoleOutput.Enabled,
 		mustMask:              cfg.Mask,
-	}
+		sinks:                 sinks,
+	}, nil
 }
 
 type DetailOption func(map[string]interface{})
@@ -77,7 +92,7 @@ func (l *logger) CreateWithConnection(tx *pop.Connection, context echo.Context,
 	}
 
 	if l.storageEnabled {
-		err = l.store(tx, auditLog)
+		auditLog, err = l.store(tx, auditLog)
 		if err != nil {
 			return err
 		}
@@ -87,11 +102,94 @@ func (l *logger) CreateWithConnection(tx *pop.Connection, context echo.Context,
 		l.logToConsole(auditLog)
 	}
 
-	return nil
+	// the audit log is only written to the sinks once the transaction creating it has been committed, so the sinks
+	// do not contain audit logs of changes which have been rolled back
+	return persistence.AfterCommit(tx, func() {
+		l.writeToSinks(auditLog)
+	})
 }
 
-func (l *logger) store(tx *pop.Connection, auditLog models.AuditLog) error {
-	return l.persister.GetAuditLogPersisterWithConnection(tx).Create(auditLog)
+func (l *logger) store(tx *pop.Connection, auditLog models.AuditLog) (models.AuditLog, error) {
+	if !l.integrityEnabled {
+		return auditLog, l.persister.GetAuditLogPersisterWithConnection(tx).Create(auditLog)
+	}
+
+	if tx != nil && tx.TX != nil {
+		// appending to the chain locks the head of the chain until the end of the transaction. To not serialize the
+		// transactions creating audit logs across all instances, the audit log is stored unchained and appended to the
+		// chain in a separate transaction once the transaction creating it has been committed.
+		return l.storeAndChainAfterCommit(tx, auditLog)
+	}
+
+	chainAndCreate := func(tx *pop.Connection) error {
+		err := Chain(l.persister.GetAuditLogChainPersister(tx), &auditLog)
+		if err != nil {
+			return fmt.Errorf("failed to chain audit log: %w", err)
+		}
+
+		return l.persister.GetAuditLogPersisterWithConnection(tx).Create(auditLog)
+	}
+
+	// the head of the chain is locked until the end of the transaction, so a transaction is required
+	if tx != nil {
+		return auditLog, tx.Transaction(chainAndCreate)
+	}
+
+	return auditLog, chainAndCreate(tx)
+}
+
+func (l *logger) storeAndChainAfterCommit(tx *pop.Connection, auditLog models.AuditLog) (models.AuditLog, error) {
+	// the creation time is part of the hash, so it is set (like Chain does) before the audit log is stored
+	now := time.Now().UTC().Truncate(time.Second)
+	auditLog.CreatedAt = now
+	auditLog.UpdatedAt = now
+	// audit logs which are not chained after the commit, e.g. because chaining fails or the instance is stopped, are
+	// chained by the PendingChainer
+	auditLog.ChainPending = true
+
+	err := l.persister.GetAuditLogPersisterWithConnection(tx).Create(auditLog)
+	if err != nil {
+		return auditLog, err
+	}
+
+	return auditLog, persistence.AfterCommit(tx, func() {
+		err := ChainStored(l.persister, auditLog)
+		if err != nil {
+			zeroLogger.Error().Err(err).Str("id", auditLog.ID.String()).Msg("failed to chain audit log")
+		}
+	})
+}
+
+// writeToSinks writes the audit log to all configured sinks. A failing sink must not fail the request that created the
+// audit log, so errors are only logged.
+func (l *logger) writeToSinks(auditLog models.AuditLog) {
+	if len(l.sinks) == 0 {
+		return
+	}
+
+	if auditLog.CreatedAt.IsZero() {
+		// the timestamps are set by the persister on a copy of the audit log
+		auditLog.CreatedAt = time.Now().UTC()
+		auditLog.UpdatedAt = auditLog.CreatedAt
+	}
+
+	for _, sink := range l.sinks {
+		err := sink.Write(auditLog)
+		if err != nil {
+			zeroLogger.Error().Err(err).Str("type", string(auditLog.Type)).Msg("failed to write audit log to sink")
+		}
+	}
+}
+
+func (l *logger) Close() error {
+	var errs []error
+	for _, sink := range l.sinks {
+		if err := sink.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
 }
 
 func (l *logger) logToConsole(auditLog models.AuditLog) {
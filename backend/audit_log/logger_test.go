@@ -0,0 +1,43 @@
+package auditlog_test
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	auditlog "github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/test"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewLogger_InvalidSink(t *testing.T) {
+	cfg := config.DefaultConfig().AuditLog
+	cfg.Sinks = config.AuditLogSinks{{Type: "kafka"}}
+
+	_, err := auditlog.NewLogger(test.NewEmptyPersister(), cfg)
+	assert.Error(t, err)
+}
+
+func TestLogger_Sinks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	cfg := config.DefaultConfig().AuditLog
+	cfg.Storage.Enabled = false
+	cfg.ConsoleOutput.Enabled = false
+	cfg.Sinks = config.AuditLogSinks{{Type: config.AuditLogSinkTypeFile, File: config.AuditLogFileSink{Path: path}}}
+
+	logger, err := auditlog.NewLogger(test.NewEmptyPersister(), cfg)
+	require.NoError(t, err)
+
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/", nil), httptest.NewRecorder())
+	require.NoError(t, logger.CreateWithConnection(nil, c, models.AuditLogPasswordLoginFailed, nil, nil))
+	require.NoError(t, logger.Close())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), string(models.AuditLogPasswordLoginFailed))
+}
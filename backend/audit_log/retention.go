@@ -0,0 +1,188 @@
+package auditlog
+
+import (
+	"fmt"
+	"github.com/gofrs/uuid"
+	zeroLogger "github.com/rs/zerolog/log"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"sort"
+	"time"
+)
+
+const (
+	// pruneLockName is the name of the scheduler lock making sure only one instance prunes per interval.
+	pruneLockName = "audit_log_prune"
+	// pruneCheckInterval is the interval in which the PruneScheduler checks whether a pruning is due.
+	pruneCheckInterval = 1 * time.Minute
+)
+
+// PruneRule describes a set of audit logs which are deleted once they are older than the maximum age.
+type PruneRule struct {
+	// Types are the audit log types the rule applies to. The rule applies to all types except the ExcludedTypes if
+	// it is empty.
+	Types         []string
+	ExcludedTypes []string
+	MaxAge        time.Duration
+}
+
+func (r PruneRule) String() string {
+	if len(r.Types) > 0 {
+		return fmt.Sprintf("types %v older than %s", r.Types, r.MaxAge)
+	}
+
+	return fmt.Sprintf("all other types older than %s", r.MaxAge)
+}
+
+// PruneResult is the result of pruning the audit logs of a single rule.
+type PruneResult struct {
+	Rule PruneRule
+	// Before is the time before which audit logs have been created to be pruned.
+	Before time.Time
+	// Count is the number of deleted audit logs or, for a dry run, the number of audit logs which would be deleted.
+	Count int
+}
+
+// Pruner deletes persisted audit logs according to the configured retention.
+type Pruner struct {
+	persister persistence.Persister
+	cfg       config.AuditLogRetention
+}
+
+func NewPruner(persister persistence.Persister, cfg config.AuditLogRetention) *Pruner {
+	return &Pruner{
+		persister: persister,
+		cfg:       cfg,
+	}
+}
+
+// Rules returns the rules derived from the retention config: one rule per type with a maximum age of its own and one
+// rule for all other types if a general maximum age is configured.
+func (p *Pruner) Rules() []PruneRule {
+	overriddenTypes := make([]string, 0, len(p.cfg.Types))
+	for logType := range p.cfg.Types {
+		overriddenTypes = append(overriddenTypes, logType)
+	}
+	sort.Strings(overriddenTypes)
+
+	var rules []PruneRule
+	for _, logType := range overriddenTypes {
+		maxAge := p.cfg.Types[logType]
+		if maxAge > 0 {
+			rules = append(rules, PruneRule{Types: []string{logType}, MaxAge: maxAge})
+		}
+	}
+
+	if p.cfg.MaxAge > 0 {
+		rules = append(rules, PruneRule{ExcludedTypes: overriddenTypes, MaxAge: p.cfg.MaxAge})
+	}
+
+	return rules
+}
+
+// Prune deletes all audit logs older than the maximum age of their rule at the given time. The audit logs are deleted
+// in batches, every batch is deleted with a statement of its own. If dryRun is true, nothing is deleted and the
+// results contain the number of audit logs which would be deleted.
+func (p *Pruner) Prune(now time.Time, dryRun bool) ([]PruneResult, error) {
+	auditLogPersister := p.persister.GetAuditLogPersister()
+
+	batchSize := p.cfg.BatchSize
+	if batchSize < 1 {
+		batchSize = 1000
+	}
+
+	var results []PruneResult
+	for _, rule := range p.Rules() {
+		result := PruneResult{
+			Rule:   rule,
+			Before: now.Add(-rule.MaxAge),
+		}
+
+		if dryRun {
+			count, err := auditLogPersister.CountBefore(result.Before, rule.Types, rule.ExcludedTypes)
+			if err != nil {
+				return results, err
+			}
+			result.Count = count
+		} else {
+			for {
+				deleted, err := auditLogPersister.DeleteBefore(result.Before, rule.Types, rule.ExcludedTypes, batchSize)
+				if err != nil {
+					return results, err
+				}
+				result.Count += deleted
+
+				if deleted < batchSize {
+					break
+				}
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// PruneScheduler prunes the audit logs periodically. When multiple instances share a database, the audit logs are
+// pruned by only one of them per interval.
+type PruneScheduler struct {
+	pruner    *Pruner
+	persister persistence.Persister
+	interval  time.Duration
+	// holder identifies this scheduler when acquiring the prune lock
+	holder string
+}
+
+func NewPruneScheduler(persister persistence.Persister, cfg config.AuditLogRetention) *PruneScheduler {
+	holder, _ := uuid.NewV4()
+	return &PruneScheduler{
+		pruner:    NewPruner(persister, cfg),
+		persister: persister,
+		interval:  cfg.Interval,
+		holder:    holder.String(),
+	}
+}
+
+// Run checks periodically whether pruning is due and prunes the audit logs if so. Run blocks until the given channel
+// is closed.
+func (s *PruneScheduler) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(pruneCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		s.pruneIfDue()
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *PruneScheduler) pruneIfDue() {
+	now := time.Now()
+	acquired, err := s.persister.GetSchedulerLockPersister(nil).Acquire(pruneLockName, s.holder, now, now.Add(s.interval))
+	if err != nil {
+		zeroLogger.Error().Err(err).Msg("failed to acquire audit log prune lock")
+		return
+	}
+
+	if !acquired {
+		// pruned by this or another instance within the interval
+		return
+	}
+
+	results, err := s.pruner.Prune(now, false)
+	if err != nil {
+		zeroLogger.Error().Err(err).Msg("failed to prune audit logs")
+		return
+	}
+
+	for _, result := range results {
+		if result.Count > 0 {
+			zeroLogger.Info().Int("count", result.Count).Str("rule", result.Rule.String()).Msg("pruned audit logs")
+		}
+	}
+}
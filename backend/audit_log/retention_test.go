@@ -0,0 +1,119 @@
+package auditlog_test
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	auditlog "github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/test"
+	"testing"
+	"time"
+)
+
+func newTestAuditLog(logType models.AuditLogType, createdAt time.Time) models.AuditLog {
+	id, _ := uuid.NewV4()
+	return models.AuditLog{
+		ID:        id,
+		Type:      logType,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+}
+
+func TestPruner_Rules(t *testing.T) {
+	pruner := auditlog.NewPruner(nil, config.AuditLogRetention{
+		MaxAge: 90 * 24 * time.Hour,
+		Types: map[string]time.Duration{
+			"password_login_failed": 7 * 24 * time.Hour,
+			"user_deleted":          0,
+		},
+	})
+
+	rules := pruner.Rules()
+	require.Len(t, rules, 2)
+	assert.Equal(t, []string{"password_login_failed"}, rules[0].Types)
+	assert.Equal(t, 7*24*time.Hour, rules[0].MaxAge)
+	assert.Empty(t, rules[1].Types)
+	assert.Equal(t, []string{"password_login_failed", "user_deleted"}, rules[1].ExcludedTypes)
+	assert.Equal(t, 90*24*time.Hour, rules[1].MaxAge)
+
+	assert.Empty(t, auditlog.NewPruner(nil, config.AuditLogRetention{}).Rules())
+}
+
+func TestPruner_Prune(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	auditLogs := []models.AuditLog{
+		newTestAuditLog(models.AuditLogPasswordLoginFailed, now.Add(-10*day)),
+		newTestAuditLog(models.AuditLogPasswordLoginFailed, now.Add(-1*day)),
+		newTestAuditLog(models.AuditLogPasswordLoginSucceeded, now.Add(-10*day)),
+		newTestAuditLog(models.AuditLogPasswordLoginSucceeded, now.Add(-100*day)),
+		newTestAuditLog(models.AuditLogPasswordLoginSucceeded, now.Add(-200*day)),
+		newTestAuditLog(models.AuditLogUserDeleted, now.Add(-200*day)),
+	}
+	persister := test.NewPersister(nil, nil, nil, nil, nil, nil, auditLogs, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	pruner := auditlog.NewPruner(persister, config.AuditLogRetention{
+		MaxAge: 90 * day,
+		Types: map[string]time.Duration{
+			string(models.AuditLogPasswordLoginFailed): 7 * day,
+			string(models.AuditLogUserDeleted):         0,
+		},
+		BatchSize: 1,
+	})
+
+	results, err := pruner.Prune(now, true)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, 1, results[0].Count)
+	assert.Equal(t, 2, results[1].Count)
+
+	count, err := persister.GetAuditLogPersister().Count(nil, nil, nil, "", "", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, 6, count, "dry run must not delete audit logs")
+
+	results, err = pruner.Prune(now, false)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, 1, results[0].Count)
+	assert.Equal(t, 2, results[1].Count)
+
+	count, err = persister.GetAuditLogPersister().Count(nil, nil, nil, "", "", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+}
+
+func TestPruneScheduler_Run(t *testing.T) {
+	now := time.Now()
+	auditLogs := []models.AuditLog{
+		newTestAuditLog(models.AuditLogPasswordLoginFailed, now.Add(-2*time.Hour)),
+	}
+	persister := test.NewPersister(nil, nil, nil, nil, nil, nil, auditLogs, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	cfg := config.AuditLogRetention{
+		Enabled:   true,
+		MaxAge:    time.Hour,
+		Interval:  time.Hour,
+		BatchSize: 100,
+	}
+
+	first := auditlog.NewPruneScheduler(persister, cfg)
+	second := auditlog.NewPruneScheduler(persister, cfg)
+
+	// a closed channel stops the schedulers after their first check
+	stop := make(chan struct{})
+	close(stop)
+
+	first.Run(stop)
+	count, err := persister.GetAuditLogPersister().Count(nil, nil, nil, "", "", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	require.NoError(t, persister.GetAuditLogPersister().Create(newTestAuditLog(models.AuditLogPasswordLoginFailed, now.Add(-2*time.Hour))))
+
+	second.Run(stop)
+	count, err = persister.GetAuditLogPersister().Count(nil, nil, nil, "", "", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "audit logs must only be pruned once per interval")
+}
@@ -0,0 +1,48 @@
+package auditlog
+
+import (
+	"fmt"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+)
+
+// Sink is an additional destination audit logs are written to, besides the database and the console.
+type Sink interface {
+	Write(auditLog models.AuditLog) error
+	Close() error
+}
+
+// NewSinks creates the sinks configured in cfg. Sinks already created are closed if a sink cannot be created.
+func NewSinks(cfg []config.AuditLogSink) ([]Sink, error) {
+	sinks := make([]Sink, 0, len(cfg))
+	for i, sinkConfig := range cfg {
+		sink, err := newSink(sinkConfig)
+		if err != nil {
+			for _, s := range sinks {
+				_ = s.Close()
+			}
+			return nil, fmt.Errorf("failed to create audit log sink %d: %w", i, err)
+		}
+		sinks = append(sinks, sink)
+	}
+
+	return sinks, nil
+}
+
+func newSink(cfg config.AuditLogSink) (Sink, error) {
+	formatter, err := NewFormatter(cfg.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.Type {
+	case config.AuditLogSinkTypeFile:
+		return NewFileSink(cfg.File, formatter)
+	case config.AuditLogSinkTypeSyslog:
+		return NewSyslogSink(cfg.Syslog, formatter)
+	case config.AuditLogSinkTypeHTTP:
+		return NewHTTPSink(cfg.HTTP, formatter)
+	default:
+		return nil, fmt.Errorf("unknown sink type: %s", cfg.Type)
+	}
+}
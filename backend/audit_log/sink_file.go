@@ -0,0 +1,155 @@
+package auditlog
+
+import (
+	"fmt"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileSink appends audit logs to a file, one per line. The file is rotated once it would exceed its maximum size:
+// the current file becomes `<path>.1`, `<path>.1` becomes `<path>.2` and so on; files beyond the maximum number of
+// backups are removed.
+type FileSink struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	formatter  Formatter
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+func NewFileSink(cfg config.AuditLogFileSink, formatter Formatter) (*FileSink, error) {
+	maxSize := cfg.MaxSize
+	if maxSize == 0 {
+		maxSize = 100
+	}
+
+	s := &FileSink{
+		path:       cfg.Path,
+		maxSize:    int64(maxSize) * 1024 * 1024,
+		maxBackups: cfg.MaxBackups,
+		formatter:  formatter,
+	}
+
+	err := os.MkdirAll(filepath.Dir(s.path), 0750)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create directory for audit log file: %w", err)
+	}
+
+	err = s.open()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *FileSink) Write(auditLog models.AuditLog) error {
+	line, err := s.formatter.Format(auditLog)
+	if err != nil {
+		return fmt.Errorf("failed to format audit log: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return fmt.Errorf("audit log file %s is closed", s.path)
+	}
+
+	if s.size > 0 && s.size+int64(len(line)) > s.maxSize {
+		err = s.rotate()
+		if err != nil {
+			return err
+		}
+	}
+
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write audit log file: %w", err)
+	}
+
+	return nil
+}
+
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func (s *FileSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to stat audit log file: %w", err)
+	}
+
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+// rotate moves the current file to the first backup and opens a new file. The file is reopened even if the rotation
+// fails, so that subsequent audit logs are not lost.
+func (s *FileSink) rotate() error {
+	err := s.file.Close()
+	s.file = nil
+	if err == nil {
+		err = s.shiftBackups()
+	}
+
+	openErr := s.open()
+	if err != nil {
+		return fmt.Errorf("failed to rotate audit log file: %w", err)
+	}
+
+	return openErr
+}
+
+func (s *FileSink) shiftBackups() error {
+	if s.maxBackups == 0 {
+		err := os.Remove(s.path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	err := os.Remove(s.backupPath(s.maxBackups))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for i := s.maxBackups - 1; i >= 1; i-- {
+		err = os.Rename(s.backupPath(i), s.backupPath(i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return os.Rename(s.path, s.backupPath(1))
+}
+
+func (s *FileSink) backupPath(i int) string {
+	return fmt.Sprintf("%s.%d", s.path, i)
+}
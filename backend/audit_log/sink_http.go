@@ -0,0 +1,237 @@
+package auditlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	zeroLogger "github.com/rs/zerolog/log"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var errSinkClosed = errors.New("audit log sink is closed")
+
+// HTTPSink sends audit logs in batches to an HTTP collector. Audit logs are buffered and sent by a background worker
+// once a batch is full or the flush interval has passed, so that a slow collector does not slow down requests. Failed
+// requests are retried with an exponential backoff. While the collector is unavailable the buffer fills up; once it
+// is full audit logs are either dropped or the callers are blocked, depending on the configuration.
+type HTTPSink struct {
+	url           string
+	headers       map[string]string
+	contentType   string
+	batchSize     int
+	flushInterval time.Duration
+	onFull        config.AuditLogHTTPSinkOnFull
+	maxRetries    int
+	backoff       time.Duration
+	client        *http.Client
+	formatter     Formatter
+
+	buffer    chan []byte
+	dropped   atomic.Int64
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewHTTPSink(cfg config.AuditLogHTTPSink, formatter Formatter) (*HTTPSink, error) {
+	s := &HTTPSink{
+		url:           cfg.URL,
+		headers:       cfg.Headers,
+		contentType:   "text/plain",
+		batchSize:     cfg.BatchSize,
+		flushInterval: cfg.FlushInterval,
+		onFull:        cfg.OnFull,
+		maxRetries:    cfg.MaxRetries,
+		backoff:       time.Second,
+		formatter:     formatter,
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+
+	if _, ok := formatter.(JSONFormatter); ok {
+		s.contentType = "application/x-ndjson"
+	}
+	if s.batchSize == 0 {
+		s.batchSize = 100
+	}
+	if s.flushInterval == 0 {
+		s.flushInterval = 5 * time.Second
+	}
+	if s.onFull == "" {
+		s.onFull = config.AuditLogHTTPSinkOnFullDrop
+	}
+
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	s.client = &http.Client{Timeout: timeout}
+
+	bufferSize := cfg.BufferSize
+	if bufferSize == 0 {
+		bufferSize = 10000
+	}
+	s.buffer = make(chan []byte, bufferSize)
+
+	go s.run()
+
+	return s, nil
+}
+
+func (s *HTTPSink) Write(auditLog models.AuditLog) error {
+	line, err := s.formatter.Format(auditLog)
+	if err != nil {
+		return fmt.Errorf("failed to format audit log: %w", err)
+	}
+
+	select {
+	case <-s.stop:
+		return errSinkClosed
+	default:
+	}
+
+	if s.onFull == config.AuditLogHTTPSinkOnFullBlock {
+		select {
+		case s.buffer <- line:
+			return nil
+		case <-s.stop:
+			return errSinkClosed
+		}
+	}
+
+	select {
+	case s.buffer <- line:
+	default:
+		s.dropped.Add(1)
+	}
+
+	return nil
+}
+
+// Close stops accepting audit logs and sends the buffered ones. Failed requests are not retried anymore.
+func (s *HTTPSink) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+	})
+	<-s.done
+
+	return nil
+}
+
+func (s *HTTPSink) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([][]byte, 0, s.batchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			s.send(batch)
+			batch = batch[:0]
+		}
+	}
+
+	for {
+		select {
+		case line := <-s.buffer:
+			batch = append(batch, line)
+			if len(batch) >= s.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			s.reportDropped()
+			flush()
+		case <-s.stop:
+			for {
+				select {
+				case line := <-s.buffer:
+					batch = append(batch, line)
+					if len(batch) >= s.batchSize {
+						flush()
+					}
+				default:
+					flush()
+					s.reportDropped()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *HTTPSink) reportDropped() {
+	if dropped := s.dropped.Swap(0); dropped > 0 {
+		zeroLogger.Warn().
+			Int64("dropped", dropped).
+			Str("url", s.url).
+			Msg("audit log buffer of http sink is full, audit logs have been dropped")
+	}
+}
+
+// send sends the batch and retries failed requests. The batch is dropped if all attempts fail or the collector rejects
+// it with a client error.
+func (s *HTTPSink) send(batch [][]byte) {
+	body := bytes.Join(batch, []byte("\n"))
+	body = append(body, '\n')
+
+	backoff := s.backoff
+	for attempt := 0; ; attempt++ {
+		retryable, err := s.post(body)
+		if err == nil {
+			return
+		}
+
+		if !retryable || attempt >= s.maxRetries {
+			zeroLogger.Error().Err(err).
+				Int("audit_logs", len(batch)).
+				Str("url", s.url).
+				Msg("failed to send audit logs to http sink, audit logs have been dropped")
+			return
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-s.stop:
+			zeroLogger.Error().Err(err).
+				Int("audit_logs", len(batch)).
+				Str("url", s.url).
+				Msg("failed to send audit logs to http sink before shutdown, audit logs have been dropped")
+			return
+		}
+	}
+}
+
+// post sends the body to the collector and reports whether a failed request should be retried.
+func (s *HTTPSink) post(body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", s.contentType)
+	for key, value := range s.headers {
+		req.Header.Set(key, value)
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return false, nil
+	}
+
+	err = fmt.Errorf("collector responded with status %d", res.StatusCode)
+	retryable := res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusRequestTimeout
+	return retryable, err
+}
@@ -0,0 +1,255 @@
+package auditlog
+
+import (
+	"fmt"
+	zeroLogger "github.com/rs/zerolog/log"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	syslogSeverityWarning = 4
+	syslogSeverityInfo    = 6
+
+	// syslogBufferSize is the number of messages buffered while the syslog server is slow or unavailable. Further
+	// messages are dropped.
+	syslogBufferSize = 10000
+	// syslogDroppedReportInterval is the interval in which dropped messages are reported.
+	syslogDroppedReportInterval = 5 * time.Second
+)
+
+// SyslogSink sends audit logs as RFC 5424 messages to a syslog server. Over UDP every message is sent as a single
+// datagram, over TCP messages are framed with octet counting (RFC 6587). A broken TCP connection is re-established on
+// the next message. The connection is established with the first message, so that an unavailable server does not
+// prevent Hanko from starting.
+//
+// Messages are buffered and sent by a background worker, so that a slow or unavailable server does not slow down
+// requests. Messages are dropped once the buffer is full.
+type SyslogSink struct {
+	network   string
+	address   string
+	facility  int
+	appName   string
+	hostname  string
+	procId    string
+	timeout   time.Duration
+	formatter Formatter
+
+	conn net.Conn
+
+	buffer    chan []byte
+	dropped   atomic.Int64
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewSyslogSink(cfg config.AuditLogSyslogSink, formatter Formatter) (*SyslogSink, error) {
+	network := cfg.Network
+	if network == "" {
+		network = "udp"
+	}
+
+	facility, ok := config.SyslogFacilities[cfg.Facility]
+	if !ok {
+		facility = config.SyslogFacilities["authpriv"]
+	}
+
+	appName := cfg.AppName
+	if appName == "" {
+		appName = "hanko"
+	}
+
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	s := &SyslogSink{
+		network:   network,
+		address:   cfg.Address,
+		facility:  facility,
+		appName:   appName,
+		hostname:  hostname,
+		procId:    strconv.Itoa(os.Getpid()),
+		timeout:   timeout,
+		formatter: formatter,
+		buffer:    make(chan []byte, syslogBufferSize),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	go s.run()
+
+	return s, nil
+}
+
+func (s *SyslogSink) Write(auditLog models.AuditLog) error {
+	message, err := s.message(auditLog)
+	if err != nil {
+		return err
+	}
+
+	if s.network == "tcp" {
+		message = append([]byte(strconv.Itoa(len(message))+" "), message...)
+	}
+
+	select {
+	case <-s.stop:
+		return errSinkClosed
+	default:
+	}
+
+	select {
+	case s.buffer <- message:
+	default:
+		s.dropped.Add(1)
+	}
+
+	return nil
+}
+
+// Close stops accepting audit logs, sends the buffered ones and closes the connection.
+func (s *SyslogSink) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+	})
+	<-s.done
+
+	return nil
+}
+
+func (s *SyslogSink) run() {
+	defer close(s.done)
+	defer s.disconnect()
+
+	ticker := time.NewTicker(syslogDroppedReportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case message := <-s.buffer:
+			s.write(message)
+		case <-ticker.C:
+			s.reportDropped()
+		case <-s.stop:
+			for {
+				select {
+				case message := <-s.buffer:
+					s.write(message)
+				default:
+					s.reportDropped()
+					return
+				}
+			}
+		}
+	}
+}
+
+// write sends the message to the server. The message is dropped if it cannot be sent.
+func (s *SyslogSink) write(message []byte) {
+	err := s.send(message)
+	if err != nil && s.network == "tcp" {
+		// the server might have closed the connection, try again with a new one
+		s.disconnect()
+		err = s.send(message)
+	}
+	if err != nil {
+		s.disconnect()
+		zeroLogger.Error().Err(err).
+			Str("address", s.address).
+			Msg("failed to send audit log to syslog server, the audit log has been dropped")
+	}
+}
+
+func (s *SyslogSink) reportDropped() {
+	if dropped := s.dropped.Swap(0); dropped > 0 {
+		zeroLogger.Warn().
+			Int64("dropped", dropped).
+			Str("address", s.address).
+			Msg("audit log buffer of syslog sink is full, audit logs have been dropped")
+	}
+}
+
+// message returns the RFC 5424 message for the audit log:
+// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID - MSG
+func (s *SyslogSink) message(auditLog models.AuditLog) ([]byte, error) {
+	msg, err := s.formatter.Format(auditLog)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format audit log: %w", err)
+	}
+
+	severity := syslogSeverityInfo
+	if isFailure(auditLog) {
+		severity = syslogSeverityWarning
+	}
+
+	timestamp := auditLog.CreatedAt
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	msgId := string(auditLog.Type)
+	if len(msgId) > 32 {
+		msgId = msgId[:32]
+	}
+	if msgId == "" {
+		msgId = "-"
+	}
+
+	header := fmt.Sprintf("<%d>1 %s %s %s %s %s - ",
+		s.facility*8+severity,
+		timestamp.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		s.hostname,
+		s.appName,
+		s.procId,
+		msgId,
+	)
+
+	return append([]byte(header), msg...), nil
+}
+
+func (s *SyslogSink) send(message []byte) error {
+	if s.conn == nil {
+		err := s.connect()
+		if err != nil {
+			return err
+		}
+	}
+
+	err := s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+	if err != nil {
+		return err
+	}
+
+	_, err = s.conn.Write(message)
+	return err
+}
+
+func (s *SyslogSink) connect() error {
+	conn, err := net.DialTimeout(s.network, s.address, s.timeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to syslog server: %w", err)
+	}
+
+	s.conn = conn
+	return nil
+}
+
+func (s *SyslogSink) disconnect() {
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+}
@@ -0,0 +1,273 @@
+package auditlog_test
+
+import (
+	"bufio"
+	"github.com/gobuffalo/pop/v6/slices"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	auditlog "github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewSinks(t *testing.T) {
+	sinks, err := auditlog.NewSinks([]config.AuditLogSink{
+		{Type: config.AuditLogSinkTypeFile, File: config.AuditLogFileSink{Path: filepath.Join(t.TempDir(), "audit.log")}},
+		{Type: config.AuditLogSinkTypeSyslog, Format: config.AuditLogSinkFormatCEF, Syslog: config.AuditLogSyslogSink{Address: "127.0.0.1:514"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, sinks, 2)
+	assert.IsType(t, &auditlog.FileSink{}, sinks[0])
+	assert.IsType(t, &auditlog.SyslogSink{}, sinks[1])
+	for _, sink := range sinks {
+		assert.NoError(t, sink.Close())
+	}
+
+	_, err = auditlog.NewSinks([]config.AuditLogSink{{Type: "kafka"}})
+	assert.Error(t, err)
+}
+
+func TestFileSink_Rotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "audit.log")
+	sink, err := auditlog.NewFileSink(config.AuditLogFileSink{Path: path, MaxSize: 1, MaxBackups: 2}, auditlog.JSONFormatter{})
+	require.NoError(t, err)
+	defer sink.Close()
+
+	auditLog := newTestAuditLog(models.AuditLogPasswordLoginSucceeded, time.Now().UTC())
+	auditLog.Details = slices.Map{"padding": strings.Repeat("a", 400*1024)}
+
+	// every file holds two audit logs, so seven audit logs need four files of which the oldest one is removed
+	for i := 0; i < 7; i++ {
+		require.NoError(t, sink.Write(auditLog))
+	}
+
+	for _, p := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(p)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, info.Size(), int64(1024*1024))
+	}
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(content), "\n"))
+}
+
+func TestSyslogSink_UDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	sink, err := auditlog.NewSyslogSink(config.AuditLogSyslogSink{
+		Network:  "udp",
+		Address:  conn.LocalAddr().String(),
+		Facility: "local0",
+	}, auditlog.CEFFormatter{})
+	require.NoError(t, err)
+	defer sink.Close()
+
+	require.NoError(t, sink.Write(newTestAuditLog(models.AuditLogPasswordLoginFailed, time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC))))
+
+	buf := make([]byte, 4096)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+
+	message := string(buf[:n])
+	// local0 (16) * 8 + warning (4)
+	assert.True(t, strings.HasPrefix(message, "<132>1 2024-10-01T12:00:00.000000Z "), message)
+	assert.Contains(t, message, " hanko "+strconv.Itoa(os.Getpid())+" password_login_failed - CEF:0|")
+}
+
+func TestSyslogSink_TCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	messages := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		for {
+			length, err := reader.ReadString(' ')
+			if err != nil {
+				return
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(length))
+			if err != nil {
+				return
+			}
+			message := make([]byte, n)
+			if _, err = io.ReadFull(reader, message); err != nil {
+				return
+			}
+			messages <- string(message)
+		}
+	}()
+
+	sink, err := auditlog.NewSyslogSink(config.AuditLogSyslogSink{
+		Network: "tcp",
+		Address: listener.Addr().String(),
+	}, auditlog.JSONFormatter{})
+	require.NoError(t, err)
+	defer sink.Close()
+
+	require.NoError(t, sink.Write(newTestAuditLog(models.AuditLogPasswordLoginSucceeded, time.Now().UTC())))
+	require.NoError(t, sink.Write(newTestAuditLog(models.AuditLogUserDeleted, time.Now().UTC())))
+
+	for _, expected := range []string{"password_login_succeeded", "user_deleted"} {
+		select {
+		case message := <-messages:
+			// authpriv (10) * 8 + info (6)
+			assert.True(t, strings.HasPrefix(message, "<86>1 "), message)
+			assert.Contains(t, message, " "+expected+" - {")
+		case <-time.After(5 * time.Second):
+			t.Fatal("syslog message not received")
+		}
+	}
+}
+
+type testCollector struct {
+	mu       sync.Mutex
+	batches  [][]string
+	statuses []int
+}
+
+func (c *testCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := http.StatusOK
+	if len(c.statuses) > 0 {
+		status = c.statuses[0]
+		c.statuses = c.statuses[1:]
+	}
+	if status == http.StatusOK {
+		c.batches = append(c.batches, strings.Split(strings.TrimSuffix(string(body), "\n"), "\n"))
+	}
+	w.WriteHeader(status)
+}
+
+func (c *testCollector) received() [][]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.batches
+}
+
+func TestHTTPSink_Batches(t *testing.T) {
+	collector := &testCollector{}
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	sink, err := auditlog.NewHTTPSink(config.AuditLogHTTPSink{
+		URL:           server.URL,
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+	}, auditlog.JSONFormatter{})
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, sink.Write(newTestAuditLog(models.AuditLogPasswordLoginSucceeded, time.Now().UTC())))
+	}
+	// the last, incomplete batch is sent on close
+	require.NoError(t, sink.Close())
+
+	batches := collector.received()
+	require.Len(t, batches, 3)
+	assert.Len(t, batches[0], 2)
+	assert.Len(t, batches[1], 2)
+	assert.Len(t, batches[2], 1)
+
+	assert.Error(t, sink.Write(newTestAuditLog(models.AuditLogPasswordLoginSucceeded, time.Now().UTC())))
+}
+
+func TestHTTPSink_FlushInterval(t *testing.T) {
+	collector := &testCollector{}
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	sink, err := auditlog.NewHTTPSink(config.AuditLogHTTPSink{
+		URL:           server.URL,
+		FlushInterval: 50 * time.Millisecond,
+	}, auditlog.JSONFormatter{})
+	require.NoError(t, err)
+	defer sink.Close()
+
+	require.NoError(t, sink.Write(newTestAuditLog(models.AuditLogPasswordLoginSucceeded, time.Now().UTC())))
+
+	assert.Eventually(t, func() bool {
+		return len(collector.received()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestHTTPSink_Retry(t *testing.T) {
+	collector := &testCollector{statuses: []int{http.StatusServiceUnavailable}}
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	sink, err := auditlog.NewHTTPSink(config.AuditLogHTTPSink{
+		URL:        server.URL,
+		BatchSize:  1,
+		MaxRetries: 1,
+	}, auditlog.JSONFormatter{})
+	require.NoError(t, err)
+	defer sink.Close()
+
+	require.NoError(t, sink.Write(newTestAuditLog(models.AuditLogPasswordLoginSucceeded, time.Now().UTC())))
+
+	assert.Eventually(t, func() bool {
+		return len(collector.received()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestHTTPSink_DropWhenFull(t *testing.T) {
+	requests := make(chan struct{}, 10)
+	unblock := make(chan struct{})
+	collector := &testCollector{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- struct{}{}
+		<-unblock
+		collector.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	sink, err := auditlog.NewHTTPSink(config.AuditLogHTTPSink{
+		URL:        server.URL,
+		BatchSize:  1,
+		BufferSize: 1,
+		OnFull:     config.AuditLogHTTPSinkOnFullDrop,
+	}, auditlog.JSONFormatter{})
+	require.NoError(t, err)
+
+	// the first audit log is sent, the worker is blocked by the collector
+	require.NoError(t, sink.Write(newTestAuditLog(models.AuditLogPasswordLoginSucceeded, time.Now().UTC())))
+	<-requests
+
+	// the second audit log is buffered, the third one is dropped
+	require.NoError(t, sink.Write(newTestAuditLog(models.AuditLogPasswordLoginSucceeded, time.Now().UTC())))
+	require.NoError(t, sink.Write(newTestAuditLog(models.AuditLogPasswordLoginSucceeded, time.Now().UTC())))
+
+	close(unblock)
+	require.NoError(t, sink.Close())
+
+	assert.Len(t, collector.received(), 2)
+}
@@ -0,0 +1,243 @@
+package auditlog
+
+import (
+	"errors"
+	"fmt"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
+)
+
+// VerificationIssue describes a gap or modification found in the hash chain.
+type VerificationIssue struct {
+	// ChainSequence is the position in the hash chain the issue has been found at. It is 0 for issues of audit logs
+	// which are not part of the chain.
+	ChainSequence int64
+	Message       string
+}
+
+func (i VerificationIssue) String() string {
+	if i.ChainSequence == 0 {
+		return i.Message
+	}
+
+	return fmt.Sprintf("%d: %s", i.ChainSequence, i.Message)
+}
+
+type VerificationResult struct {
+	// Verified is the number of verified audit logs.
+	Verified int
+	// FirstSequence is the chain sequence of the oldest audit log in the chain.
+	FirstSequence int64
+	// Pruned reports whether audit logs missing before the FirstSequence have been accepted as pruned.
+	Pruned bool
+	// LastSequence is the chain sequence of the most recent audit log in the chain.
+	LastSequence int64
+	// Checkpoints is the number of verified checkpoints.
+	Checkpoints int
+	Issues      []VerificationIssue
+}
+
+// Valid reports whether no gaps or modifications have been found.
+func (r *VerificationResult) Valid() bool {
+	return len(r.Issues) == 0
+}
+
+func (r *VerificationResult) addIssue(sequence int64, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, VerificationIssue{ChainSequence: sequence, Message: fmt.Sprintf(format, args...)})
+}
+
+// Verifier verifies the hash chain of the persisted audit logs and its checkpoints.
+type Verifier struct {
+	persister persistence.Persister
+	keys      jwk.Set
+	retention config.AuditLogRetention
+	batchSize int
+}
+
+// NewVerifier returns a Verifier which verifies checkpoint signatures with the given keys. The keys must include
+// retired keys, see jwk.DefaultManager.GetAllPublicKeys. The retention determines whether audit logs missing at the
+// beginning of the chain may have been pruned.
+func NewVerifier(persister persistence.Persister, keys jwk.Set, retention config.AuditLogRetention, batchSize int) *Verifier {
+	if batchSize < 1 {
+		batchSize = 1000
+	}
+
+	return &Verifier{
+		persister: persister,
+		keys:      keys,
+		retention: retention,
+		batchSize: batchSize,
+	}
+}
+
+// Verify walks the hash chain from the oldest to the most recent audit log. It recomputes the hash of every audit log
+// and checks that it links to its predecessor, that the chain has no gaps, that it ends at the head of the chain and
+// that it contains the hashes of all checkpoints. Audit logs missing at the beginning of the chain are considered
+// pruned if they can have been pruned according to the retention (see verifyPruned). Audit logs which have not been
+// appended to the chain within the PendingChainGracePeriod are reported as well.
+func (v *Verifier) Verify() (*VerificationResult, error) {
+	now := time.Now()
+
+	chainPersister := v.persister.GetAuditLogChainPersister(nil)
+
+	// the head is read first, so audit logs appended while verifying are not reported as unexpected
+	head, err := chainPersister.GetHead(false)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoints, err := chainPersister.ListCheckpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &VerificationResult{}
+	var previous *models.AuditLog
+	headFound := head.ChainSequence == 0
+	nextCheckpoint := 0
+
+	for {
+		auditLogs, err := v.persister.GetAuditLogPersister().ListChained(result.LastSequence, v.batchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range auditLogs {
+			auditLog := auditLogs[i]
+			sequence := *auditLog.ChainSequence
+
+			v.verifyAuditLog(result, auditLog, previous)
+
+			for nextCheckpoint < len(checkpoints) && checkpoints[nextCheckpoint].ChainSequence <= sequence {
+				checkpoint := checkpoints[nextCheckpoint]
+				if checkpoint.ChainSequence == sequence {
+					v.verifyCheckpoint(result, checkpoint, &auditLog)
+				} else {
+					// pruned or already reported as missing
+					v.verifyCheckpoint(result, checkpoint, nil)
+				}
+				nextCheckpoint++
+			}
+
+			if sequence == head.ChainSequence {
+				headFound = true
+				if auditLog.Hash == nil || *auditLog.Hash != head.Hash {
+					result.addIssue(sequence, "audit log does not match the head of the chain")
+				}
+			}
+
+			if previous == nil {
+				result.FirstSequence = sequence
+			}
+			result.LastSequence = sequence
+			previous = &auditLog
+		}
+
+		if len(auditLogs) < v.batchSize {
+			break
+		}
+	}
+
+	if result.FirstSequence > 1 {
+		v.verifyPruned(result, checkpoints, now)
+	}
+
+	if !headFound {
+		result.addIssue(head.ChainSequence, "audit logs %d to %d at the end of the chain are missing", result.LastSequence+1, head.ChainSequence)
+	}
+
+	for _, checkpoint := range checkpoints[nextCheckpoint:] {
+		v.verifyCheckpoint(result, checkpoint, nil)
+		if checkpoint.ChainSequence > head.ChainSequence {
+			result.addIssue(checkpoint.ChainSequence, "checkpoint is ahead of the head of the chain")
+		}
+	}
+
+	pending, err := v.persister.GetAuditLogPersister().CountChainPending(now.Add(-PendingChainGracePeriod))
+	if err != nil {
+		return nil, err
+	}
+	if pending > 0 {
+		result.addIssue(0, "%d audit logs have not been appended to the chain", pending)
+	}
+
+	return result, nil
+}
+
+// verifyPruned checks whether the audit logs missing at the beginning of the chain can have been pruned, i.e. a
+// maximum age is configured and they have been older than the maximum age. The creation time of the missing audit
+// logs is not known, but audit logs are chained after their creation, so the audit logs following a checkpoint have
+// been created after it. A checkpoint of the missing audit logs created within the maximum age therefore shows that
+// the audit logs following it have been deleted before they could have been pruned.
+func (v *Verifier) verifyPruned(result *VerificationResult, checkpoints []models.AuditLogCheckpoint, now time.Time) {
+	lastMissing := result.FirstSequence - 1
+	if v.retention.MaxAge <= 0 {
+		result.addIssue(result.FirstSequence, "audit logs 1 to %d at the beginning of the chain are missing", lastMissing)
+		return
+	}
+
+	var latest *models.AuditLogCheckpoint
+	for i := range checkpoints {
+		if checkpoints[i].ChainSequence < lastMissing {
+			latest = &checkpoints[i]
+		}
+	}
+
+	if latest != nil && latest.CreatedAt.After(now.Add(-v.retention.MaxAge)) {
+		result.addIssue(result.FirstSequence, "audit logs %d to %d at the beginning of the chain are missing, but have been created after checkpoint %s within the maximum age", latest.ChainSequence+1, lastMissing, latest.ID)
+		return
+	}
+
+	result.Pruned = true
+}
+
+func (v *Verifier) verifyAuditLog(result *VerificationResult, auditLog models.AuditLog, previous *models.AuditLog) {
+	sequence := *auditLog.ChainSequence
+
+	if previous != nil {
+		previousSequence := *previous.ChainSequence
+		if sequence != previousSequence+1 {
+			result.addIssue(sequence, "audit logs %d to %d are missing", previousSequence+1, sequence-1)
+		} else if auditLog.PreviousHash == nil || previous.Hash == nil || *auditLog.PreviousHash != *previous.Hash {
+			result.addIssue(sequence, "previous hash does not match the hash of audit log %d", previousSequence)
+		}
+	}
+
+	if auditLog.Hash == nil {
+		result.addIssue(sequence, "audit log %s has no hash", auditLog.ID)
+		return
+	}
+
+	hash, err := Hash(auditLog)
+	if err != nil || hash != *auditLog.Hash {
+		result.addIssue(sequence, "audit log %s has been modified", auditLog.ID)
+		return
+	}
+
+	result.Verified++
+}
+
+// verifyCheckpoint verifies the signature of the checkpoint and, if given, that the hash of the audit log at the
+// position of the checkpoint matches.
+func (v *Verifier) verifyCheckpoint(result *VerificationResult, checkpoint models.AuditLogCheckpoint, auditLog *models.AuditLog) {
+	err := VerifyCheckpoint(checkpoint, v.keys)
+	if errors.Is(err, ErrCheckpointKeyNotFound) {
+		// keys which have signed checkpoints are retained, so a missing key has been deleted deliberately
+		result.addIssue(checkpoint.ChainSequence, "key %s of checkpoint %s is missing", checkpoint.KeyID, checkpoint.ID)
+		return
+	}
+	if err != nil {
+		result.addIssue(checkpoint.ChainSequence, "checkpoint %s is not valid: %s", checkpoint.ID, err)
+		return
+	}
+
+	if auditLog != nil && (auditLog.Hash == nil || *auditLog.Hash != checkpoint.Hash) {
+		result.addIssue(checkpoint.ChainSequence, "audit log %s does not match checkpoint %s", auditLog.ID, checkpoint.ID)
+		return
+	}
+
+	result.Checkpoints++
+}
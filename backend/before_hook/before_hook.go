@@ -0,0 +1,221 @@
+package before_hook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/telemetry"
+	"github.com/teamhanko/hanko/backend/webhooks"
+	"io"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// Event is the operation a before hook is called for.
+type Event string
+
+const (
+	EventUserCreate   Event = "user.create"
+	EventSessionIssue Event = "session.issue"
+)
+
+// DefaultErrorCode is the error code returned to the user if a hook denies an operation without providing a valid
+// error code.
+const DefaultErrorCode = "before_hook_denied"
+
+// maxResponseBodyLength is the maximum number of bytes read from a hook response.
+const maxResponseBodyLength = 64 * 1024
+
+var errorCodePattern = regexp.MustCompile(`^[a-z0-9_]{1,64}$`)
+
+// httpClient is shared by all callers, so connections to the hooks are reused. Timeouts are set per request.
+var httpClient = &http.Client{Transport: telemetry.NewTransport(nil)}
+
+// Request is the JSON body sent to a before hook.
+type Request struct {
+	Event Event `json:"event"`
+	// FlowID is the ID of the flow the hook is called from.
+	FlowID string `json:"flow_id,omitempty"`
+	// User describes the user the operation is performed for. For EventUserCreate this is a User, because the user
+	// does not exist yet, for EventSessionIssue the admin representation of the user.
+	User interface{} `json:"user"`
+	// LoginMethod is the method used to log in (only for EventSessionIssue and only for logins).
+	LoginMethod string `json:"login_method,omitempty"`
+	// Client describes the client performing the request.
+	Client Client `json:"client"`
+}
+
+// User describes a user which is about to be created.
+type User struct {
+	ID          uuid.UUID `json:"id"`
+	Email       string    `json:"email,omitempty"`
+	Username    string    `json:"username,omitempty"`
+	HasPassword bool      `json:"has_password"`
+	HasPasskey  bool      `json:"has_passkey"`
+}
+
+type Client struct {
+	IpAddress string `json:"ip_address"`
+	UserAgent string `json:"user_agent"`
+}
+
+// Response is the JSON body expected from a before hook.
+type Response struct {
+	// Allow denies the operation if set to false. The operation is allowed if it is not set.
+	Allow *bool          `json:"allow,omitempty"`
+	Error *ResponseError `json:"error,omitempty"`
+	// Claims are added to the session token.
+	Claims map[string]interface{} `json:"claims,omitempty"`
+	// Metadata is merged into the metadata of the user.
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+type ResponseError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Result is the outcome of a before hook call.
+type Result struct {
+	Denied       bool
+	ErrorCode    string
+	ErrorMessage string
+	Claims       map[string]interface{}
+	Metadata     map[string]interface{}
+}
+
+// FailedError is returned if a hook could not be called successfully, e.g. due to a timeout or an invalid response.
+type FailedError struct {
+	Event Event
+	Cause error
+}
+
+func (e *FailedError) Error() string {
+	return fmt.Sprintf("before hook for event '%s' failed: %s", e.Event, e.Cause)
+}
+
+func (e *FailedError) Unwrap() error {
+	return e.Cause
+}
+
+// Caller calls a single configured before hook.
+type Caller struct {
+	event Event
+	cfg   config.BeforeHook
+}
+
+func NewCaller(event Event, cfg config.BeforeHook) *Caller {
+	return &Caller{
+		event: event,
+		cfg:   cfg,
+	}
+}
+
+// Enabled reports whether the hook is configured to be called.
+func (c *Caller) Enabled() bool {
+	return c.cfg.Enabled
+}
+
+// Call sends the given request to the hook and returns the result. If the hook is disabled an empty (allowing) result
+// is returned. If the call fails, the result depends on the failure policy of the hook: with the "open" policy an
+// allowing result is returned together with the error, which should be logged; with the "closed" policy a nil result
+// and a FailedError are returned.
+func (c *Caller) Call(ctx context.Context, request Request) (*Result, error) {
+	if !c.cfg.Enabled {
+		return &Result{}, nil
+	}
+
+	request.Event = c.event
+	result, err := c.call(ctx, request)
+	if err != nil {
+		failedErr := &FailedError{Event: c.event, Cause: err}
+		if c.cfg.FailurePolicy == config.BeforeHookFailOpen {
+			return &Result{}, failedErr
+		}
+		return nil, failedErr
+	}
+
+	return result, nil
+}
+
+func (c *Caller) call(ctx context.Context, request Request) (*Result, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+
+	if c.cfg.Secret != "" {
+		messageID, _ := uuid.NewV4()
+		now := time.Now()
+		signature, err := webhooks.Sign([]string{c.cfg.Secret}, messageID.String(), now, body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign request: %w", err)
+		}
+		httpRequest.Header.Set(webhooks.HeaderWebhookID, messageID.String())
+		httpRequest.Header.Set(webhooks.HeaderWebhookTimestamp, fmt.Sprintf("%d", now.Unix()))
+		httpRequest.Header.Set(webhooks.HeaderWebhookSignature, signature)
+	}
+
+	httpResponse, err := httpClient.Do(httpRequest)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", httpResponse.StatusCode)
+	}
+
+	responseBody, err := io.ReadAll(io.LimitReader(httpResponse.Body, maxResponseBodyLength+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if len(responseBody) > maxResponseBodyLength {
+		return nil, errors.New("response body too large")
+	}
+
+	var response Response
+	if len(bytes.TrimSpace(responseBody)) > 0 {
+		err = json.Unmarshal(responseBody, &response)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+	}
+
+	return newResult(response), nil
+}
+
+func newResult(response Response) *Result {
+	if response.Allow != nil && !*response.Allow {
+		result := &Result{
+			Denied:    true,
+			ErrorCode: DefaultErrorCode,
+		}
+		if response.Error != nil {
+			if errorCodePattern.MatchString(response.Error.Code) {
+				result.ErrorCode = response.Error.Code
+			}
+			result.ErrorMessage = response.Error.Message
+		}
+		return result
+	}
+
+	return &Result{
+		Claims:   response.Claims,
+		Metadata: response.Metadata,
+	}
+}
@@ -0,0 +1,135 @@
+package before_hook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/webhooks"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestCaller(url string, policy config.BeforeHookFailurePolicy) *Caller {
+	return NewCaller(EventUserCreate, config.BeforeHook{
+		Enabled:       true,
+		URL:           url,
+		Timeout:       time.Second,
+		FailurePolicy: policy,
+	})
+}
+
+func newTestServer(t *testing.T, status int, body string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCaller_Call_Disabled(t *testing.T) {
+	caller := NewCaller(EventUserCreate, config.BeforeHook{})
+
+	result, err := caller.Call(context.Background(), Request{})
+	assert.NoError(t, err)
+	require.NotNil(t, result)
+	assert.False(t, result.Denied)
+}
+
+func TestCaller_Call_Allow(t *testing.T) {
+	var request Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		_, _ = w.Write([]byte(`{"claims": {"customer_id": "cus_123"}, "metadata": {"plan": "free"}}`))
+	}))
+	defer server.Close()
+
+	result, err := newTestCaller(server.URL, config.BeforeHookFailClosed).Call(context.Background(), Request{FlowID: "flow"})
+	assert.NoError(t, err)
+	require.NotNil(t, result)
+	assert.False(t, result.Denied)
+	assert.Equal(t, map[string]interface{}{"customer_id": "cus_123"}, result.Claims)
+	assert.Equal(t, map[string]interface{}{"plan": "free"}, result.Metadata)
+	assert.Equal(t, EventUserCreate, request.Event)
+	assert.Equal(t, "flow", request.FlowID)
+}
+
+func TestCaller_Call_EmptyBody(t *testing.T) {
+	server := newTestServer(t, http.StatusOK, "")
+
+	result, err := newTestCaller(server.URL, config.BeforeHookFailClosed).Call(context.Background(), Request{})
+	assert.NoError(t, err)
+	require.NotNil(t, result)
+	assert.False(t, result.Denied)
+}
+
+func TestCaller_Call_Deny(t *testing.T) {
+	server := newTestServer(t, http.StatusOK, `{"allow": false, "error": {"code": "domain_not_allowed", "message": "Domain not allowed"}}`)
+
+	result, err := newTestCaller(server.URL, config.BeforeHookFailClosed).Call(context.Background(), Request{})
+	assert.NoError(t, err)
+	require.NotNil(t, result)
+	assert.True(t, result.Denied)
+	assert.Equal(t, "domain_not_allowed", result.ErrorCode)
+	assert.Equal(t, "Domain not allowed", result.ErrorMessage)
+}
+
+func TestCaller_Call_DenyWithInvalidCode(t *testing.T) {
+	server := newTestServer(t, http.StatusOK, `{"allow": false, "error": {"code": "Not Allowed!"}}`)
+
+	result, err := newTestCaller(server.URL, config.BeforeHookFailClosed).Call(context.Background(), Request{})
+	assert.NoError(t, err)
+	require.NotNil(t, result)
+	assert.True(t, result.Denied)
+	assert.Equal(t, DefaultErrorCode, result.ErrorCode)
+}
+
+func TestCaller_Call_UnexpectedStatus(t *testing.T) {
+	server := newTestServer(t, http.StatusInternalServerError, "")
+
+	result, err := newTestCaller(server.URL, config.BeforeHookFailClosed).Call(context.Background(), Request{})
+	assert.Nil(t, result)
+	var failedErr *FailedError
+	assert.True(t, errors.As(err, &failedErr))
+
+	result, err = newTestCaller(server.URL, config.BeforeHookFailOpen).Call(context.Background(), Request{})
+	assert.Error(t, err)
+	require.NotNil(t, result)
+	assert.False(t, result.Denied)
+}
+
+func TestCaller_Call_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	caller := newTestCaller(server.URL, config.BeforeHookFailClosed)
+	caller.cfg.Timeout = 50 * time.Millisecond
+
+	result, err := caller.Call(context.Background(), Request{})
+	assert.Nil(t, result)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestCaller_Call_Signed(t *testing.T) {
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	caller := newTestCaller(server.URL, config.BeforeHookFailClosed)
+	caller.cfg.Secret = "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw"
+
+	_, err := caller.Call(context.Background(), Request{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, headers.Get(webhooks.HeaderWebhookID))
+	assert.NotEmpty(t, headers.Get(webhooks.HeaderWebhookTimestamp))
+	assert.NotEmpty(t, headers.Get(webhooks.HeaderWebhookSignature))
+}
@@ -0,0 +1,125 @@
+package auditlog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"github.com/spf13/cobra"
+	hankoAuditLog "github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+func NewExportCommand() *cobra.Command {
+	var (
+		configFile string
+		outputFile string
+		format     string
+		compress   bool
+		startTime  string
+		endTime    string
+		filter     hankoAuditLog.ExportFilter
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export persisted audit logs as NDJSON or CSV",
+		Long: `Exports all persisted audit logs matching the given filters, oldest first. The audit logs are read in
+batches, so exports of large tables use constant memory.
+
+The output is written to stdout unless an output file is given. Use --gzip to compress the output.
+
+Example:
+
+  hanko audit-log export --config config.yaml --format csv --gzip \
+    --start-time 2024-09-01T00:00:00Z --end-time 2024-10-01T00:00:00Z -o audit_logs_2024_09.csv.gz`,
+		Run: func(cmd *cobra.Command, args []string) {
+			exportFormat := hankoAuditLog.ExportFormat(format)
+			if !exportFormat.IsValid() {
+				log.Fatalf("format must be one of '%s' or '%s'", hankoAuditLog.ExportFormatNDJSON, hankoAuditLog.ExportFormatCSV)
+			}
+
+			var err error
+			filter.StartTime, err = parseTime(startTime)
+			if err != nil {
+				log.Fatal(fmt.Errorf("invalid start time: %w", err))
+			}
+			filter.EndTime, err = parseTime(endTime)
+			if err != nil {
+				log.Fatal(fmt.Errorf("invalid end time: %w", err))
+			}
+
+			cfg, err := config.Load(&configFile)
+			if err != nil {
+				log.Fatal(err)
+			}
+			persister, err := persistence.New(cfg.Database)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			var out io.Writer = os.Stdout
+			if outputFile != "" {
+				file, err := os.OpenFile(outputFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+				if err != nil {
+					log.Fatal(err)
+				}
+				defer file.Close()
+				out = file
+			}
+
+			count, err := export(out, persister, exportFormat, filter, compress)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			if outputFile != "" {
+				log.Printf("Successfully exported %d audit logs to %s", count, outputFile)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&configFile, "config", config.DefaultConfigFilePath, "config file")
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "the path of the output file (default stdout)")
+	cmd.Flags().StringVar(&format, "format", string(hankoAuditLog.ExportFormatNDJSON), "the output format, either 'ndjson' or 'csv'")
+	cmd.Flags().BoolVar(&compress, "gzip", false, "compress the output with gzip")
+	cmd.Flags().StringVar(&startTime, "start-time", "", "only export audit logs created after this time (RFC 3339)")
+	cmd.Flags().StringVar(&endTime, "end-time", "", "only export audit logs created before this time (RFC 3339)")
+	cmd.Flags().StringSliceVar(&filter.Types, "type", nil, "only export audit logs of these types")
+	cmd.Flags().StringVar(&filter.UserId, "actor-user-id", "", "only export audit logs whose actor user ID contains this value")
+	cmd.Flags().StringVar(&filter.Email, "actor-email", "", "only export audit logs whose actor email contains this value")
+	cmd.Flags().StringVar(&filter.IP, "meta-source-ip", "", "only export audit logs whose source IP contains this value")
+	cmd.Flags().StringVar(&filter.SearchString, "q", "", "only export audit logs whose actor email, source IP or actor user ID contains this value")
+
+	return cmd
+}
+
+func export(out io.Writer, persister persistence.Persister, format hankoAuditLog.ExportFormat, filter hankoAuditLog.ExportFilter, compress bool) (int, error) {
+	if !compress {
+		return hankoAuditLog.Export(out, persister.GetAuditLogPersister(), format, filter, 0)
+	}
+
+	gzipWriter := gzip.NewWriter(out)
+	count, err := hankoAuditLog.Export(gzipWriter, persister.GetAuditLogPersister(), format, filter, 0)
+	if err != nil {
+		return count, err
+	}
+
+	return count, gzipWriter.Close()
+}
+
+func parseTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
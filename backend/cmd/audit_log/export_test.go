@@ -0,0 +1,48 @@
+package auditlog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hankoAuditLog "github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/test"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExport_Gzip(t *testing.T) {
+	id, _ := uuid.NewV4()
+	auditLogs := []models.AuditLog{
+		{ID: id, Type: models.AuditLogUserDeleted, CreatedAt: time.Now()},
+	}
+	persister := test.NewPersister(nil, nil, nil, nil, nil, nil, auditLogs, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	var out bytes.Buffer
+	count, err := export(&out, persister, hankoAuditLog.ExportFormatNDJSON, hankoAuditLog.ExportFilter{}, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	reader, err := gzip.NewReader(&out)
+	require.NoError(t, err)
+	content, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.True(t, strings.Contains(string(content), id.String()))
+}
+
+func TestParseTime(t *testing.T) {
+	parsed, err := parseTime("")
+	assert.NoError(t, err)
+	assert.Nil(t, parsed)
+
+	parsed, err = parseTime("2024-10-01T00:00:00Z")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC), *parsed)
+
+	_, err = parseTime("yesterday")
+	assert.Error(t, err)
+}
@@ -0,0 +1,77 @@
+package auditlog
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	hankoAuditLog "github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"log"
+	"time"
+)
+
+func NewPruneCommand() *cobra.Command {
+	var (
+		configFile string
+		dryRun     bool
+		batchSize  int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete persisted audit logs older than the configured retention",
+		Long: `Deletes all persisted audit logs older than the maximum age configured in audit_log.retention (max_age and
+the per type maximum ages in types). The audit logs are deleted regardless of whether the periodic pruning is
+enabled (audit_log.retention.enabled).
+
+Audit logs are deleted in batches of audit_log.retention.batch_size, so large tables are not locked for a long
+time.
+
+Per type maximum ages are refused when audit_log.integrity is enabled, as pruning types individually would leave
+gaps in the hash chain.
+
+Use --dry-run to print the number of audit logs which would be deleted without deleting them.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := config.Load(&configFile)
+			if err != nil {
+				log.Fatal(err)
+			}
+			persister, err := persistence.New(cfg.Database)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			retention := cfg.AuditLog.Retention
+			if cfg.AuditLog.Integrity.Enabled && len(retention.Types) > 0 {
+				log.Fatal("audit_log.retention.types must not be set when audit_log.integrity is enabled")
+			}
+			if batchSize > 0 {
+				retention.BatchSize = batchSize
+			}
+
+			pruner := hankoAuditLog.NewPruner(persister, retention)
+			if len(pruner.Rules()) == 0 {
+				fmt.Println("no maximum age configured, nothing to prune")
+				return
+			}
+
+			results, err := pruner.Prune(time.Now(), dryRun)
+			for _, result := range results {
+				if dryRun {
+					fmt.Printf("would delete %d audit logs (%s, created before %s)\n", result.Count, result.Rule, result.Before.UTC().Format(time.RFC3339))
+				} else {
+					fmt.Printf("deleted %d audit logs (%s, created before %s)\n", result.Count, result.Rule, result.Before.UTC().Format(time.RFC3339))
+				}
+			}
+			if err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&configFile, "config", config.DefaultConfigFilePath, "config file")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only print the number of audit logs which would be deleted")
+	cmd.Flags().IntVar(&batchSize, "batch-size", 0, "maximum number of audit logs deleted per statement (overrides audit_log.retention.batch_size)")
+
+	return cmd
+}
@@ -0,0 +1,21 @@
+package auditlog
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewAuditLogCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "audit-log",
+		Short: "Tools for handling persisted audit logs",
+		Long:  ``,
+	}
+}
+
+func RegisterCommands(parent *cobra.Command) {
+	cmd := NewAuditLogCommand()
+	parent.AddCommand(cmd)
+	cmd.AddCommand(NewPruneCommand())
+	cmd.AddCommand(NewExportCommand())
+	cmd.AddCommand(NewVerifyCommand())
+}
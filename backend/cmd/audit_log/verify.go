@@ -0,0 +1,92 @@
+package auditlog
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	hankoAuditLog "github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/crypto/jwk"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"log"
+	"os"
+)
+
+func NewVerifyCommand() *cobra.Command {
+	var (
+		configFile string
+		batchSize  int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify the hash chain of the persisted audit logs",
+		Long: `Verifies the hash chain of the persisted audit logs (see audit_log.integrity). The hash of every audit log is
+recomputed and compared to the stored one and to the hash stored in its successor. Missing audit logs, modified
+audit logs and checkpoints which do not match the chain, whose signature is invalid or whose signing key is missing
+are reported. Keys which have signed checkpoints are retained when keys are pruned after a rotation (see secrets.jwk).
+
+Audit logs missing at the beginning of the chain are considered pruned if audit_log.retention.max_age is set and
+no checkpoint shows that they have been deleted before they were older than the maximum age. Audit logs which have
+not been appended to the chain a minute after their creation are reported as well.
+
+The command exits with status 1 if any issue has been found.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := config.Load(&configFile)
+			if err != nil {
+				log.Fatal(err)
+			}
+			persister, err := persistence.New(cfg.Database)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			jwkManager, err := jwk.NewDefaultManager(cfg.Secrets.Keys, persister.GetJwkPersister(), jwk.WithKeyAlgorithm(cfg.Secrets.Jwk.Algorithm), jwk.WithGracePeriod(cfg.Secrets.Jwk.GracePeriod), jwk.WithLock(persister.GetSchedulerLockPersister(nil)))
+			if err != nil {
+				log.Fatal(err)
+			}
+			keys, err := jwkManager.GetAllPublicKeys()
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			result, err := hankoAuditLog.NewVerifier(persister, keys, cfg.AuditLog.Retention, batchSize).Verify()
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			printVerificationResult(cmd, result)
+			if !result.Valid() {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&configFile, "config", config.DefaultConfigFilePath, "config file")
+	cmd.Flags().IntVar(&batchSize, "batch-size", 1000, "number of audit logs loaded per query")
+
+	return cmd
+}
+
+func printVerificationResult(cmd *cobra.Command, result *hankoAuditLog.VerificationResult) {
+	out := cmd.OutOrStdout()
+	if result.LastSequence == 0 {
+		fmt.Fprintln(out, "no chained audit logs found")
+	} else {
+		fmt.Fprintf(out, "verified %d audit logs (chain sequence %d to %d)\n", result.Verified, result.FirstSequence, result.LastSequence)
+	}
+	if result.Pruned {
+		fmt.Fprintf(out, "audit logs before chain sequence %d have been pruned\n", result.FirstSequence)
+	}
+
+	fmt.Fprintf(out, "verified %d checkpoints\n", result.Checkpoints)
+
+	if result.Valid() {
+		fmt.Fprintln(out, "no issues found")
+		return
+	}
+
+	fmt.Fprintf(out, "found %d issues:\n", len(result.Issues))
+	for _, issue := range result.Issues {
+		fmt.Fprintf(out, "  %s\n", issue)
+	}
+}
@@ -0,0 +1,160 @@
+package email
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/mail"
+	"golang.org/x/exp/slices"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+func NewPreviewCommand() *cobra.Command {
+	var (
+		configFile string
+		lang       string
+		templates  []string
+		outputDir  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "preview",
+		Short: "Render the email templates with sample data",
+		Long: `Renders the subject, the plain text body and, if available, the HTML body of every email template for the
+given language with sample data. Templates and locale files in the configured template directory
+(see email_delivery.template_directory) override the built-in ones.
+
+Without an output directory the rendered emails are printed. With an output directory the subject and the plain
+text body are written to '<template>.<lang>.txt' and the HTML body is written to '<template>.<lang>.html', so it
+can be opened in a browser.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := config.Load(&configFile)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			renderer, err := mail.NewRenderer(cfg.EmailDelivery.TemplateDirectory)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			names := renderer.TemplateNames()
+			for _, name := range templates {
+				if !slices.Contains(names, name) {
+					log.Fatalf("unknown template '%s', available templates: %v", name, names)
+				}
+			}
+			if len(templates) > 0 {
+				names = templates
+			}
+
+			for _, name := range names {
+				preview, err := renderPreview(renderer, name, lang, cfg.Service.Name)
+				if err != nil {
+					log.Fatal(err)
+				}
+
+				if outputDir == "" {
+					printPreview(cmd, preview)
+					continue
+				}
+
+				err = writePreview(outputDir, lang, preview)
+				if err != nil {
+					log.Fatal(err)
+				}
+				cmd.Printf("rendered %s\n", name)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&configFile, "config", config.DefaultConfigFilePath, "config file")
+	cmd.Flags().StringVar(&lang, "lang", "en", "language to render the templates in, can be the contents of an Accept-Language header")
+	cmd.Flags().StringSliceVar(&templates, "template", nil, "render only the given templates, e.g. 'login' (can be repeated)")
+	cmd.Flags().StringVar(&outputDir, "output-dir", "", "write the rendered emails to the given directory instead of printing them")
+
+	return cmd
+}
+
+type preview struct {
+	Template string
+	Subject  string
+	Body     string
+	HTMLBody string
+}
+
+// previewData returns sample values for all data the templates are rendered with.
+func previewData(serviceName string) map[string]interface{} {
+	return map[string]interface{}{
+		"Code":                 "123456",
+		"ServiceName":          serviceName,
+		"TTL":                  "5",
+		"UserAgent":            "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Safari/537.36",
+		"IpAddress":            "203.0.113.42",
+		"LoginTime":            time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC).Format(time.RFC1123),
+		"RevocationURL":        "https://example.com/sessions/revoke?token=preview",
+		"VerificationURL":      "https://example.com/emails/verify?token=preview",
+		"PasskeyName":          "MacBook Pro",
+		"EmailAddress":         "new@example.com",
+		"PreviousEmailAddress": "old@example.com",
+	}
+}
+
+func renderPreview(renderer *mail.Renderer, name string, lang string, serviceName string) (*preview, error) {
+	result := &preview{
+		Template: name,
+		Subject:  renderer.Translate(lang, fmt.Sprintf("subject_%s", name), previewData(serviceName)),
+	}
+
+	body, err := renderer.Render(fmt.Sprintf("%s_text.tmpl", name), lang, previewData(serviceName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to render template '%s': %w", name, err)
+	}
+	result.Body = body
+
+	htmlTemplate := fmt.Sprintf("%s_html.tmpl", name)
+	if renderer.HasTemplate(htmlTemplate) {
+		htmlBody, err := renderer.Render(htmlTemplate, lang, previewData(serviceName))
+		if err != nil {
+			return nil, fmt.Errorf("failed to render template '%s': %w", htmlTemplate, err)
+		}
+		result.HTMLBody = htmlBody
+	}
+
+	return result, nil
+}
+
+func printPreview(cmd *cobra.Command, p *preview) {
+	cmd.Printf("=== %s\n", p.Template)
+	cmd.Printf("Subject: %s\n\n", p.Subject)
+	cmd.Printf("--- text/plain\n%s\n\n", p.Body)
+	if p.HTMLBody != "" {
+		cmd.Printf("--- text/html\n%s\n\n", p.HTMLBody)
+	}
+}
+
+func writePreview(outputDir string, lang string, p *preview) error {
+	err := os.MkdirAll(outputDir, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	textFile := filepath.Join(outputDir, fmt.Sprintf("%s.%s.txt", p.Template, lang))
+	err = os.WriteFile(textFile, []byte(fmt.Sprintf("Subject: %s\n\n%s\n", p.Subject, p.Body)), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write preview: %w", err)
+	}
+
+	if p.HTMLBody != "" {
+		htmlFile := filepath.Join(outputDir, fmt.Sprintf("%s.%s.html", p.Template, lang))
+		err = os.WriteFile(htmlFile, []byte(p.HTMLBody), 0644)
+		if err != nil {
+			return fmt.Errorf("failed to write preview: %w", err)
+		}
+	}
+
+	return nil
+}
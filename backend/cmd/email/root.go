@@ -0,0 +1,19 @@
+package email
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewEmailCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "email",
+		Short: "Tools for handling email templates",
+		Long:  ``,
+	}
+}
+
+func RegisterCommands(parent *cobra.Command) {
+	cmd := NewEmailCommand()
+	parent.AddCommand(cmd)
+	cmd.AddCommand(NewPreviewCommand())
+}
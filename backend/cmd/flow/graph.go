@@ -0,0 +1,346 @@
+package flow
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/teamhanko/hanko/backend/config"
+	flowAPI "github.com/teamhanko/hanko/backend/flow_api/flow"
+	"github.com/teamhanko/hanko/backend/flowpilot"
+	"log"
+	"sort"
+	"strings"
+)
+
+const (
+	formatMermaid = "mermaid"
+	formatDot     = "dot"
+
+	startNode = "start"
+)
+
+var flows = map[string]func(cfg config.Config) flowpilot.Flow{
+	"login":        flowAPI.NewLoginFlow,
+	"registration": flowAPI.NewRegistrationFlow,
+	"profile":      flowAPI.NewProfileFlow,
+}
+
+func NewGraphCommand() *cobra.Command {
+	var (
+		flowName   string
+		format     string
+		configFile string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "graph",
+		Short: "Render a diagram of a flow",
+		Long: `Renders the states, actions, hooks and sub-flows of a flow as a Mermaid flowchart or a Graphviz (DOT) graph.
+
+Solid edges are labeled with the action causing the transition. Dashed edges lead to states which are scheduled, i.e.
+which follow once the previous state or sub-flow has been completed. Actions without a declared transition, e.g.
+actions completing a sub-flow or the 'back' action, are listed within their state.
+
+Without a config file the flows are rendered with the default configuration. With a config file the login flow
+contains the configured onboarding steps (see flow.login_onboarding).`,
+		Run: func(cmd *cobra.Command, args []string) {
+			newFlow, ok := flows[flowName]
+			if !ok {
+				log.Fatalf("unknown flow '%s', available flows: %v", flowName, flowNames())
+			}
+
+			cfg := config.DefaultConfig()
+			if configFile != "" {
+				var err error
+				cfg, err = config.Load(&configFile)
+				if err != nil {
+					log.Fatal(err)
+				}
+			}
+
+			graph := newFlowGraph(newFlow(*cfg).Describe())
+
+			switch format {
+			case formatMermaid:
+				cmd.Print(graph.mermaid())
+			case formatDot:
+				cmd.Print(graph.dot())
+			default:
+				log.Fatalf("unknown format '%s', available formats: [%s %s]", format, formatMermaid, formatDot)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&flowName, "flow", "login", fmt.Sprintf("the flow to render, one of %v", flowNames()))
+	cmd.Flags().StringVar(&format, "format", formatMermaid, "the output format, 'mermaid' or 'dot'")
+	cmd.Flags().StringVar(&configFile, "config", "", "config file, the default configuration is used if not set")
+
+	return cmd
+}
+
+func flowNames() []string {
+	names := make([]string, 0, len(flows))
+	for name := range flows {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type graphEdge struct {
+	from      string
+	to        string
+	label     string
+	scheduled bool
+	// fromFlow is set if the edge starts at the sub-flow given in from instead of a state.
+	fromFlow bool
+}
+
+type flowGraph struct {
+	description flowpilot.FlowDescription
+	edges       []graphEdge
+}
+
+func newFlowGraph(description flowpilot.FlowDescription) *flowGraph {
+	g := &flowGraph{description: description}
+
+	if len(description.InitialStates) > 0 {
+		g.addTransition(graphEdge{from: startNode}, description.InitialStates)
+	}
+
+	g.collectEdges(description)
+
+	return g
+}
+
+func (g *flowGraph) collectEdges(flow flowpilot.FlowDescription) {
+	for _, state := range flow.States {
+		for _, action := range state.Actions {
+			for _, transition := range action.Transitions {
+				g.addTransition(graphEdge{from: string(state.Name), label: string(action.Name)}, transition)
+			}
+		}
+
+		for _, hook := range append(state.BeforeHooks, state.AfterHooks...) {
+			for _, transition := range hook.Transitions {
+				g.addTransition(graphEdge{from: string(state.Name), label: hook.Name, scheduled: true}, transition)
+			}
+		}
+	}
+
+	for _, hook := range flow.AfterFlowHooks {
+		for _, transition := range hook.Transitions {
+			g.addTransition(graphEdge{from: string(flow.Name), label: hook.Name, scheduled: true, fromFlow: true}, transition)
+		}
+	}
+
+	for _, subFlow := range flow.SubFlows {
+		g.collectEdges(subFlow)
+	}
+}
+
+// addTransition adds an edge from the given edge origin to the first state of the transition and dashed edges between
+// the following states, which are scheduled.
+func (g *flowGraph) addTransition(origin graphEdge, transition flowpilot.Transition) {
+	origin.to = string(transition[0])
+	g.addEdge(origin)
+
+	for i := 1; i < len(transition); i++ {
+		g.addEdge(graphEdge{from: string(transition[i-1]), to: string(transition[i]), scheduled: true})
+	}
+}
+
+func (g *flowGraph) addEdge(edge graphEdge) {
+	for _, existing := range g.edges {
+		if existing == edge {
+			return
+		}
+	}
+
+	g.edges = append(g.edges, edge)
+}
+
+// stateLines returns the lines of the label of a state node: the state name, the actions without declared transitions
+// and the hooks.
+func stateLines(state flowpilot.StateDescription) []string {
+	lines := []string{string(state.Name)}
+
+	var actions []string
+	for _, action := range state.Actions {
+		if len(action.Transitions) == 0 {
+			actions = append(actions, string(action.Name))
+		}
+	}
+
+	if len(actions) > 0 {
+		lines = append(lines, fmt.Sprintf("actions: %s", strings.Join(actions, ", ")))
+	}
+
+	if len(state.BeforeHooks) > 0 {
+		lines = append(lines, fmt.Sprintf("before: %s", hookNames(state.BeforeHooks)))
+	}
+
+	if len(state.AfterHooks) > 0 {
+		lines = append(lines, fmt.Sprintf("after: %s", hookNames(state.AfterHooks)))
+	}
+
+	return lines
+}
+
+// flowLines returns the lines of the label of a flow, i.e. the flow name and the hooks of the flow.
+func flowLines(flow flowpilot.FlowDescription) []string {
+	lines := []string{string(flow.Name)}
+
+	if len(flow.BeforeEachActionHooks) > 0 {
+		lines = append(lines, fmt.Sprintf("before each action: %s", hookNames(flow.BeforeEachActionHooks)))
+	}
+
+	if len(flow.AfterEachActionHooks) > 0 {
+		lines = append(lines, fmt.Sprintf("after each action: %s", hookNames(flow.AfterEachActionHooks)))
+	}
+
+	return lines
+}
+
+func hookNames(hooks []flowpilot.HookDescription) string {
+	names := make([]string, len(hooks))
+	for i, hook := range hooks {
+		names[i] = hook.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+func (g *flowGraph) mermaid() string {
+	var sb strings.Builder
+
+	sb.WriteString("flowchart TD\n")
+	fmt.Fprintf(&sb, "    %s((%s))\n", startNode, startNode)
+	g.writeMermaidFlow(&sb, g.description, 1)
+
+	for _, edge := range g.edges {
+		from := edge.from
+		if edge.fromFlow {
+			from = flowID(edge.from)
+		}
+
+		arrow := "-->"
+		if edge.scheduled {
+			arrow = "-.->"
+		}
+
+		if edge.label != "" {
+			fmt.Fprintf(&sb, "    %s %s|%s| %s\n", from, arrow, edge.label, edge.to)
+		} else {
+			fmt.Fprintf(&sb, "    %s %s %s\n", from, arrow, edge.to)
+		}
+	}
+
+	if g.description.ErrorState != "" {
+		sb.WriteString("    classDef errorState stroke:#d33\n")
+		fmt.Fprintf(&sb, "    class %s errorState\n", g.description.ErrorState)
+	}
+
+	return sb.String()
+}
+
+func (g *flowGraph) writeMermaidFlow(sb *strings.Builder, flow flowpilot.FlowDescription, depth int) {
+	indent := strings.Repeat("    ", depth)
+
+	fmt.Fprintf(sb, "%ssubgraph %s [\"%s\"]\n", indent, flowID(string(flow.Name)), strings.Join(flowLines(flow), "<br/>"))
+
+	for _, state := range flow.States {
+		fmt.Fprintf(sb, "%s    %s[\"%s\"]\n", indent, state.Name, strings.Join(stateLines(state), "<br/>"))
+	}
+
+	for _, subFlow := range flow.SubFlows {
+		g.writeMermaidFlow(sb, subFlow, depth+1)
+	}
+
+	fmt.Fprintf(sb, "%send\n", indent)
+}
+
+func (g *flowGraph) dot() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "digraph %q {\n", g.description.Name)
+	sb.WriteString("    compound=true;\n")
+	sb.WriteString("    node [shape=box, style=rounded];\n")
+	fmt.Fprintf(&sb, "    %q [shape=circle];\n", startNode)
+	g.writeDotFlow(&sb, g.description, 1)
+
+	for _, edge := range g.edges {
+		var attributes []string
+
+		from := edge.from
+		if edge.fromFlow {
+			// Graphviz does not support edges between clusters, so the edge starts at a state of the cluster and is
+			// clipped at the cluster boundary.
+			from = string(g.anyState(edge.from))
+			attributes = append(attributes, fmt.Sprintf("ltail=%q", "cluster_"+edge.from))
+		}
+
+		if edge.label != "" {
+			attributes = append(attributes, fmt.Sprintf("label=%q", edge.label))
+		}
+
+		if edge.scheduled {
+			attributes = append(attributes, "style=dashed")
+		}
+
+		if len(attributes) > 0 {
+			fmt.Fprintf(&sb, "    %q -> %q [%s];\n", from, edge.to, strings.Join(attributes, ", "))
+		} else {
+			fmt.Fprintf(&sb, "    %q -> %q;\n", from, edge.to)
+		}
+	}
+
+	sb.WriteString("}\n")
+
+	return sb.String()
+}
+
+func (g *flowGraph) writeDotFlow(sb *strings.Builder, flow flowpilot.FlowDescription, depth int) {
+	indent := strings.Repeat("    ", depth)
+
+	fmt.Fprintf(sb, "%ssubgraph %q {\n", indent, "cluster_"+string(flow.Name))
+	fmt.Fprintf(sb, "%s    label=%q;\n", indent, strings.Join(flowLines(flow), "\n"))
+
+	for _, state := range flow.States {
+		attributes := fmt.Sprintf("label=%q", strings.Join(stateLines(state), "\n"))
+		if state.Name == g.description.ErrorState {
+			attributes += ", color=red"
+		}
+
+		fmt.Fprintf(sb, "%s    %q [%s];\n", indent, state.Name, attributes)
+	}
+
+	for _, subFlow := range flow.SubFlows {
+		g.writeDotFlow(sb, subFlow, depth+1)
+	}
+
+	fmt.Fprintf(sb, "%s}\n", indent)
+}
+
+// anyState returns a state of the flow with the given name or of one of its sub-flows.
+func (g *flowGraph) anyState(flowName string) flowpilot.StateName {
+	var find func(flow flowpilot.FlowDescription, inFlow bool) flowpilot.StateName
+	find = func(flow flowpilot.FlowDescription, inFlow bool) flowpilot.StateName {
+		inFlow = inFlow || string(flow.Name) == flowName
+		if inFlow && len(flow.States) > 0 {
+			return flow.States[0].Name
+		}
+
+		for _, subFlow := range flow.SubFlows {
+			if state := find(subFlow, inFlow); state != "" {
+				return state
+			}
+		}
+
+		return ""
+	}
+
+	return find(g.description, false)
+}
+
+func flowID(flowName string) string {
+	return "flow_" + flowName
+}
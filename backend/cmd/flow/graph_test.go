@@ -0,0 +1,101 @@
+package flow
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/teamhanko/hanko/backend/config"
+	flowAPI "github.com/teamhanko/hanko/backend/flow_api/flow"
+	"github.com/teamhanko/hanko/backend/flowpilot"
+)
+
+func testFlowDescription() flowpilot.FlowDescription {
+	return flowpilot.FlowDescription{
+		Name:          "login",
+		InitialStates: []flowpilot.StateName{"preflight", "login_init"},
+		ErrorState:    "error",
+		States: []flowpilot.StateDescription{
+			{Name: "error"},
+			{Name: "success", BeforeHooks: []flowpilot.HookDescription{{Name: "shared.IssueSession"}}},
+		},
+		SubFlows: []flowpilot.FlowDescription{
+			{
+				Name: "credential_usage",
+				States: []flowpilot.StateDescription{
+					{
+						Name: "login_init",
+						Actions: []flowpilot.ActionDescription{
+							{Name: "continue_with_login_identifier", Transitions: []flowpilot.Transition{{"passcode_confirmation"}}},
+						},
+					},
+					{
+						Name: "passcode_confirmation",
+						Actions: []flowpilot.ActionDescription{
+							{Name: "verify_passcode"},
+							{Name: "back"},
+						},
+					},
+					{Name: "preflight"},
+				},
+				AfterFlowHooks: []flowpilot.HookDescription{
+					{Name: "login.ScheduleOnboardingStates", Transitions: []flowpilot.Transition{{"success"}}},
+				},
+			},
+		},
+	}
+}
+
+func TestFlowGraph_Mermaid(t *testing.T) {
+	result := newFlowGraph(testFlowDescription()).mermaid()
+
+	assert.True(t, strings.HasPrefix(result, "flowchart TD\n"))
+	assert.Contains(t, result, "start --> preflight\n")
+	assert.Contains(t, result, "preflight -.-> login_init\n")
+	assert.Contains(t, result, "login_init -->|continue_with_login_identifier| passcode_confirmation\n")
+	assert.Contains(t, result, "flow_credential_usage -.->|login.ScheduleOnboardingStates| success\n")
+	assert.Contains(t, result, `passcode_confirmation["passcode_confirmation<br/>actions: verify_passcode, back"]`)
+	assert.Contains(t, result, `success["success<br/>before: shared.IssueSession"]`)
+	assert.Contains(t, result, "class error errorState\n")
+	assert.Equal(t, strings.Count(result, "subgraph "), strings.Count(result, "end\n"))
+}
+
+func TestFlowGraph_Dot(t *testing.T) {
+	result := newFlowGraph(testFlowDescription()).dot()
+
+	assert.True(t, strings.HasPrefix(result, "digraph \"login\" {\n"))
+	assert.Contains(t, result, `"start" -> "preflight";`)
+	assert.Contains(t, result, `"preflight" -> "login_init" [style=dashed];`)
+	assert.Contains(t, result, `"login_init" -> "passcode_confirmation" [label="continue_with_login_identifier"];`)
+	assert.Contains(t, result, `"login_init" -> "success" [ltail="cluster_credential_usage", label="login.ScheduleOnboardingStates", style=dashed];`)
+	assert.Contains(t, result, `"error" [label="error", color=red];`)
+	assert.Equal(t, strings.Count(result, "{"), strings.Count(result, "}"))
+}
+
+func TestFlowGraph_Flows(t *testing.T) {
+	for name, newFlow := range flows {
+		t.Run(name, func(t *testing.T) {
+			description := newFlow(*config.DefaultConfig()).Describe()
+
+			assert.Equal(t, flowpilot.FlowName(name), description.Name)
+			assert.NotEmpty(t, description.InitialStates)
+			assert.NotEmpty(t, newFlowGraph(description).edges)
+		})
+	}
+}
+
+func TestFlowGraph_LoginOnboarding(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Flow.LoginOnboarding.Steps = []config.FlowOnboardingStep{config.FlowOnboardingStepPasskey, config.FlowOnboardingStepUsername}
+
+	description := flowAPI.NewLoginFlow(*cfg).Describe()
+	assert.NoError(t, description.Validate())
+
+	result := newFlowGraph(description).mermaid()
+
+	assert.Contains(t, result, "flow_credential_usage -.->|login.ScheduleOnboardingStates| onboarding_create_passkey\n")
+	assert.Contains(t, result, "onboarding_create_passkey -.-> onboarding_username\n")
+	assert.NotContains(t, result, "password_creation")
+	assert.NotContains(t, result, "onboarding_email")
+	assert.NotContains(t, result, "credential_onboarding_chooser")
+}
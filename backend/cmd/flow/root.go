@@ -0,0 +1,19 @@
+package flow
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewFlowCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "flow",
+		Short: "Tools for inspecting the flows of the flow API",
+		Long:  ``,
+	}
+}
+
+func RegisterCommands(parent *cobra.Command) {
+	cmd := NewFlowCommand()
+	parent.AddCommand(cmd)
+	cmd.AddCommand(NewGraphCommand())
+}
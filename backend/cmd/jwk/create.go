@@ -9,13 +9,20 @@ import (
 )
 
 func NewCreateCommand() *cobra.Command {
+	var (
+		algorithm string
+	)
+
 	cmd := &cobra.Command{
 		Use:   "create",
 		Short: "create JSON Web Key and print them in the console",
 		Long:  ``,
 		Run: func(cmd *cobra.Command, args []string) {
 			fmt.Println("create called")
-			generator := jwk.RSAKeyGenerator{}
+			generator, err := jwk.NewKeyGenerator(algorithm)
+			if err != nil {
+				log.Panicln(err)
+			}
 			key, err := generator.Generate("key1")
 			if err != nil {
 				log.Panicln(err)
@@ -27,5 +34,8 @@ func NewCreateCommand() *cobra.Command {
 			fmt.Println(string(j))
 		},
 	}
+
+	cmd.Flags().StringVar(&algorithm, "algorithm", "RS256", "signature algorithm of the key (RS256, ES256 or EdDSA)")
+
 	return cmd
 }
@@ -17,4 +17,5 @@ func RegisterCommands(parent *cobra.Command) {
 	cmd := NewMigrateCmd()
 	parent.AddCommand(cmd)
 	cmd.AddCommand(NewCreateCommand())
+	cmd.AddCommand(NewRotateCommand())
 }
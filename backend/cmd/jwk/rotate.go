@@ -0,0 +1,50 @@
+package jwk
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/crypto/jwk"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"log"
+)
+
+func NewRotateCommand() *cobra.Command {
+	var (
+		configFile string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "rotate",
+		Short: "rotate the JSON Web Keys used for signing JWTs",
+		Long: `Generates a new JSON Web Key using the configured algorithm and retires all other keys. The new key is
+used for signing after a short activation delay. Retired keys are still published for the configured
+grace period and pruned afterwards. Keys which have signed audit log checkpoints are retained, so the
+checkpoints can still be verified.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := config.Load(&configFile)
+			if err != nil {
+				log.Fatal(err)
+			}
+			persister, err := persistence.New(cfg.Database)
+			if err != nil {
+				log.Fatal(err)
+			}
+			jwkManager, err := jwk.NewDefaultManager(cfg.Secrets.Keys, persister.GetJwkPersister(), jwk.WithKeyAlgorithm(cfg.Secrets.Jwk.Algorithm), jwk.WithGracePeriod(cfg.Secrets.Jwk.GracePeriod), jwk.WithLock(persister.GetSchedulerLockPersister(nil)), jwk.WithRetainedKeys(persister.GetAuditLogChainPersister(nil).ListCheckpointKeyIDs))
+			if err != nil {
+				log.Fatalf("failed to create jwk manager: %s", err)
+			}
+
+			key, err := jwkManager.Rotate()
+			if err != nil {
+				log.Fatalf("failed to rotate jwks: %s", err)
+			}
+
+			fmt.Printf("generated %s key '%s', it will be used for signing in %s\n", key.Algorithm(), key.KeyID(), jwk.KeyActivationDelay)
+		},
+	}
+
+	cmd.Flags().StringVar(&configFile, "config", config.DefaultConfigFilePath, "config file")
+
+	return cmd
+}
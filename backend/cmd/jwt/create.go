@@ -42,7 +42,7 @@ func NewCreateCommand() *cobra.Command {
 				log.Fatal(err)
 			}
 			jwkPersister := persister.GetJwkPersister()
-			jwkManager, err := jwk.NewDefaultManager(cfg.Secrets.Keys, jwkPersister)
+			jwkManager, err := jwk.NewDefaultManager(cfg.Secrets.Keys, jwkPersister, jwk.WithKeyAlgorithm(cfg.Secrets.Jwk.Algorithm), jwk.WithGracePeriod(cfg.Secrets.Jwk.GracePeriod), jwk.WithLock(persister.GetSchedulerLockPersister(nil)))
 			if err != nil {
 				fmt.Printf("failed to create jwk persister: %s", err)
 				return
@@ -5,11 +5,15 @@ package cmd
 
 import (
 	"github.com/spf13/cobra"
+	auditlog "github.com/teamhanko/hanko/backend/cmd/audit_log"
+	"github.com/teamhanko/hanko/backend/cmd/email"
+	"github.com/teamhanko/hanko/backend/cmd/flow"
 	"github.com/teamhanko/hanko/backend/cmd/isready"
 	"github.com/teamhanko/hanko/backend/cmd/jwk"
 	"github.com/teamhanko/hanko/backend/cmd/jwt"
 	"github.com/teamhanko/hanko/backend/cmd/migrate"
 	"github.com/teamhanko/hanko/backend/cmd/schema"
+	"github.com/teamhanko/hanko/backend/cmd/secrets"
 	"github.com/teamhanko/hanko/backend/cmd/serve"
 	"github.com/teamhanko/hanko/backend/cmd/siwa"
 	"github.com/teamhanko/hanko/backend/cmd/user"
@@ -31,6 +35,10 @@ func NewRootCmd() *cobra.Command {
 	user.RegisterCommands(cmd)
 	siwa.RegisterCommands(cmd)
 	schema.RegisterCommands(cmd)
+	secrets.RegisterCommands(cmd)
+	auditlog.RegisterCommands(cmd)
+	email.RegisterCommands(cmd)
+	flow.RegisterCommands(cmd)
 
 	return cmd
 }
@@ -0,0 +1,19 @@
+package secrets
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewSecretsCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "secrets",
+		Short: "Tools for handling the secrets used to encrypt data at rest",
+		Long:  ``,
+	}
+}
+
+func RegisterCommands(parent *cobra.Command) {
+	cmd := NewSecretsCommand()
+	parent.AddCommand(cmd)
+	cmd.AddCommand(NewRotateCommand())
+}
@@ -0,0 +1,347 @@
+package secrets
+
+import (
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/spf13/cobra"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/crypto/aes_gcm"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"io"
+	"log"
+	"os"
+)
+
+func NewRotateCommand() *cobra.Command {
+	var (
+		configFile string
+		verify     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "rotate",
+		Short: "Re-encrypt all encrypted records with the first of the configured secrets",
+		Long: `Decrypts all JWKs, webhook signing secrets and the content of queued emails with any of the configured
+secrets.keys and re-encrypts them with the first key. All
+records are re-encrypted in a single transaction, so either all or none of them are updated. Once the command
+has completed successfully, all keys except the first one can be removed from secrets.keys.
+
+SAML certificates are encrypted with a key of their own, which is rotated as well.
+
+Third party and SAML states are short-lived and are not persisted, they do not need to be re-encrypted.
+
+Use --verify to check whether all records can be decrypted with the first key without changing them.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := config.Load(&configFile)
+			if err != nil {
+				log.Fatal(err)
+			}
+			persister, err := persistence.New(cfg.Database)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			r, err := newRotator(cfg.Secrets.Keys, persister, os.Stdout)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			if verify {
+				err = r.verify()
+				if err != nil {
+					log.Fatal(err)
+				}
+				fmt.Println("all records are encrypted with the first key")
+				return
+			}
+
+			err = r.rotate()
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println("successfully re-encrypted all records with the first key")
+		},
+	}
+
+	cmd.Flags().StringVar(&configFile, "config", config.DefaultConfigFilePath, "config file")
+	cmd.Flags().BoolVar(&verify, "verify", false, "only verify that all records can be decrypted with the first key")
+
+	return cmd
+}
+
+type rotator struct {
+	persister persistence.Persister
+	// encrypter decrypts with any of the configured keys and encrypts with the first one
+	encrypter *aes_gcm.AESGCM
+	// verifier decrypts with the first of the configured keys only
+	verifier *aes_gcm.AESGCM
+	out      io.Writer
+}
+
+func newRotator(keys []string, persister persistence.Persister, out io.Writer) (*rotator, error) {
+	encrypter, err := aes_gcm.NewAESGCM(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	verifier, err := aes_gcm.NewAESGCM(keys[:1])
+	if err != nil {
+		return nil, err
+	}
+
+	return &rotator{
+		persister: persister,
+		encrypter: encrypter,
+		verifier:  verifier,
+		out:       out,
+	}, nil
+}
+
+// rotate re-encrypts all records within a single transaction. The re-encrypted records are verified before the
+// transaction is committed.
+func (r *rotator) rotate() error {
+	return r.persister.Transaction(func(tx *pop.Connection) error {
+		err := r.rotateJwks(tx)
+		if err != nil {
+			return err
+		}
+
+		err = r.rotateSamlCertificates(tx)
+		if err != nil {
+			return err
+		}
+
+		err = r.rotateWebhookSecrets(tx)
+		if err != nil {
+			return err
+		}
+
+		err = r.rotateEmailMessages(tx)
+		if err != nil {
+			return err
+		}
+
+		return r.verifyWithConnection(tx)
+	})
+}
+
+func (r *rotator) rotateJwks(tx *pop.Connection) error {
+	jwkPersister := r.persister.GetJwkPersisterWithConnection(tx)
+	jwks, err := jwkPersister.GetAll()
+	if err != nil {
+		return err
+	}
+
+	for i, model := range jwks {
+		plaintext, err := r.encrypter.Decrypt(model.KeyData)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt jwk %d with any of the configured keys: %w", model.ID, err)
+		}
+
+		model.KeyData, err = r.encrypter.Encrypt(plaintext)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt jwk %d: %w", model.ID, err)
+		}
+
+		err = jwkPersister.Update(model)
+		if err != nil {
+			return err
+		}
+
+		_, _ = fmt.Fprintf(r.out, "jwks: re-encrypted %d/%d\n", i+1, len(jwks))
+	}
+
+	return nil
+}
+
+func (r *rotator) rotateSamlCertificates(tx *pop.Connection) error {
+	certificatePersister := r.persister.GetSamlCertificatePersisterWithConnection(tx)
+	certificates, err := certificatePersister.GetAll()
+	if err != nil {
+		return err
+	}
+
+	for i, certificate := range certificates {
+		err = certificate.RotateEncryptionKey()
+		if err != nil {
+			return fmt.Errorf("failed to rotate encryption key of saml certificate %s: %w", certificate.ID, err)
+		}
+
+		err = certificatePersister.Update(certificate)
+		if err != nil {
+			return err
+		}
+
+		_, _ = fmt.Fprintf(r.out, "saml certificates: re-encrypted %d/%d\n", i+1, len(certificates))
+	}
+
+	return nil
+}
+
+func (r *rotator) rotateWebhookSecrets(tx *pop.Connection) error {
+	webhookPersister := r.persister.GetWebhookPersister(tx)
+	webhooks, err := webhookPersister.List(true)
+	if err != nil {
+		return err
+	}
+
+	for i, webhook := range webhooks {
+		for _, secret := range []*string{webhook.Secret, webhook.PreviousSecret} {
+			if secret == nil || *secret == "" {
+				continue
+			}
+
+			plaintext, err := r.encrypter.Decrypt(*secret)
+			if err != nil {
+				return fmt.Errorf("failed to decrypt secret of webhook %s with any of the configured keys: %w", webhook.ID, err)
+			}
+
+			*secret, err = r.encrypter.Encrypt(plaintext)
+			if err != nil {
+				return fmt.Errorf("failed to encrypt secret of webhook %s: %w", webhook.ID, err)
+			}
+		}
+
+		err = webhookPersister.Update(webhook)
+		if err != nil {
+			return err
+		}
+
+		_, _ = fmt.Fprintf(r.out, "webhooks: re-encrypted %d/%d\n", i+1, len(webhooks))
+	}
+
+	return nil
+}
+
+// rotateEmailMessages re-encrypts the content of pending email messages. The content of sent and failed messages has
+// been removed already.
+func (r *rotator) rotateEmailMessages(tx *pop.Connection) error {
+	messagePersister := r.persister.GetEmailMessagePersister(tx)
+	messages, err := messagePersister.ListPending()
+	if err != nil {
+		return err
+	}
+
+	for i, message := range messages {
+		for _, content := range []*string{&message.Subject, &message.BodyText, message.BodyHTML} {
+			if content == nil || *content == "" {
+				continue
+			}
+
+			plaintext, err := r.encrypter.Decrypt(*content)
+			if err != nil {
+				return fmt.Errorf("failed to decrypt email message %s with any of the configured keys: %w", message.ID, err)
+			}
+
+			*content, err = r.encrypter.Encrypt(plaintext)
+			if err != nil {
+				return fmt.Errorf("failed to encrypt email message %s: %w", message.ID, err)
+			}
+		}
+
+		err = messagePersister.UpdateContent(message)
+		if err != nil {
+			return err
+		}
+
+		_, _ = fmt.Fprintf(r.out, "email messages: re-encrypted %d/%d\n", i+1, len(messages))
+	}
+
+	return nil
+}
+
+// verify checks that all jwks, webhook secrets and email messages can be decrypted with the first key and that all saml
+// certificate keys can be decrypted. Every record failing verification is reported.
+func (r *rotator) verify() error {
+	return r.persister.Transaction(func(tx *pop.Connection) error {
+		return r.verifyWithConnection(tx)
+	})
+}
+
+func (r *rotator) verifyWithConnection(tx *pop.Connection) error {
+	failed := 0
+
+	jwks, err := r.persister.GetJwkPersisterWithConnection(tx).GetAll()
+	if err != nil {
+		return err
+	}
+
+	for _, model := range jwks {
+		plaintext, err := r.verifier.Decrypt(model.KeyData)
+		if err == nil {
+			_, err = jwk.ParseKey(plaintext)
+		}
+		if err != nil {
+			failed++
+			_, _ = fmt.Fprintf(r.out, "jwks: %d cannot be decrypted with the first key: %s\n", model.ID, err)
+		}
+	}
+	_, _ = fmt.Fprintf(r.out, "jwks: verified %d\n", len(jwks))
+
+	certificates, err := r.persister.GetSamlCertificatePersisterWithConnection(tx).GetAll()
+	if err != nil {
+		return err
+	}
+
+	for _, certificate := range certificates {
+		privateKey, err := certificate.DecryptCertKey()
+		if err == nil {
+			if block, _ := pem.Decode(privateKey); block == nil {
+				err = errors.New("private key is not pem encoded")
+			}
+		}
+		if err != nil {
+			failed++
+			_, _ = fmt.Fprintf(r.out, "saml certificates: %s cannot be decrypted: %s\n", certificate.ID, err)
+		}
+	}
+	_, _ = fmt.Fprintf(r.out, "saml certificates: verified %d\n", len(certificates))
+
+	webhooks, err := r.persister.GetWebhookPersister(tx).List(true)
+	if err != nil {
+		return err
+	}
+
+	for _, webhook := range webhooks {
+		for _, secret := range []*string{webhook.Secret, webhook.PreviousSecret} {
+			if secret == nil || *secret == "" {
+				continue
+			}
+
+			if _, err := r.verifier.Decrypt(*secret); err != nil {
+				failed++
+				_, _ = fmt.Fprintf(r.out, "webhooks: secret of %s cannot be decrypted with the first key: %s\n", webhook.ID, err)
+			}
+		}
+	}
+	_, _ = fmt.Fprintf(r.out, "webhooks: verified %d\n", len(webhooks))
+
+	messages, err := r.persister.GetEmailMessagePersister(tx).ListPending()
+	if err != nil {
+		return err
+	}
+
+	for _, message := range messages {
+		for _, content := range []*string{&message.Subject, &message.BodyText, message.BodyHTML} {
+			if content == nil || *content == "" {
+				continue
+			}
+
+			if _, err := r.verifier.Decrypt(*content); err != nil {
+				failed++
+				_, _ = fmt.Fprintf(r.out, "email messages: %s cannot be decrypted with the first key: %s\n", message.ID, err)
+				break
+			}
+		}
+	}
+	_, _ = fmt.Fprintf(r.out, "email messages: verified %d\n", len(messages))
+
+	if failed > 0 {
+		return fmt.Errorf("verification failed for %d record(s)", failed)
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package secrets
+
+import (
+	"bytes"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamhanko/hanko/backend/crypto/aes_gcm"
+	"github.com/teamhanko/hanko/backend/crypto/jwk"
+	"github.com/teamhanko/hanko/backend/mail"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/test"
+	"testing"
+	"time"
+)
+
+const (
+	oldKey = "oldKeyWhichIsLongEnough"
+	newKey = "newKeyWhichIsLongEnough"
+)
+
+func TestRotator(t *testing.T) {
+	certificate, err := models.NewSamlCertificate("Test Service")
+	require.NoError(t, err)
+
+	secret, err := models.EncryptWebhookSecret("whsec_secret", []string{oldKey})
+	require.NoError(t, err)
+	webhook := models.Webhook{ID: uuid.Must(uuid.NewV4()), Secret: secret}
+
+	persister := test.NewPersister(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, []*models.SamlCertificate{certificate}, models.Webhooks{webhook}, nil, nil)
+
+	// create jwks encrypted with the old key only
+	_, err = jwk.NewDefaultManager([]string{oldKey}, persister.GetJwkPersister())
+	require.NoError(t, err)
+
+	mailer, err := mail.NewQueueMailer(persister, []string{oldKey})
+	require.NoError(t, err)
+	require.NoError(t, mailer.Send(mail.Message{To: "user@example.com", Subject: "Subject", Text: "Text", HTML: "<p>HTML</p>"}))
+
+	encryptionKey := certificate.EncryptionKey
+
+	out := &bytes.Buffer{}
+	r, err := newRotator([]string{newKey, oldKey}, persister, out)
+	require.NoError(t, err)
+
+	assert.Error(t, r.verify(), "jwks are not yet encrypted with the new key")
+
+	err = r.rotate()
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "jwks: re-encrypted 1/1")
+
+	assert.NoError(t, r.verify())
+	assert.NotEqual(t, encryptionKey, certificate.EncryptionKey)
+
+	jwks, err := persister.GetJwkPersister().GetAll()
+	require.NoError(t, err)
+	require.Len(t, jwks, 1)
+
+	encrypter, err := aes_gcm.NewAESGCM([]string{newKey})
+	require.NoError(t, err)
+	_, err = encrypter.Decrypt(jwks[0].KeyData)
+	assert.NoError(t, err)
+
+	assert.Contains(t, out.String(), "webhooks: re-encrypted 1/1")
+	rotatedWebhook, err := persister.GetWebhookPersister(nil).Get(webhook.ID)
+	require.NoError(t, err)
+	secrets, err := rotatedWebhook.SigningSecrets([]string{newKey}, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"whsec_secret"}, secrets)
+
+	assert.Contains(t, out.String(), "email messages: re-encrypted 1/1")
+	message := test.OnlyDue(t, persister.GetEmailMessagePersister(nil).ListDue)
+	subject, err := encrypter.Decrypt(message.Subject)
+	require.NoError(t, err)
+	assert.Equal(t, "Subject", string(subject))
+	require.NotNil(t, message.BodyHTML)
+	html, err := encrypter.Decrypt(*message.BodyHTML)
+	require.NoError(t, err)
+	assert.Equal(t, "<p>HTML</p>", string(html))
+}
+
+func TestRotator_UnknownKey(t *testing.T) {
+	persister := test.NewEmptyPersister()
+
+	_, err := jwk.NewDefaultManager([]string{oldKey}, persister.GetJwkPersister())
+	require.NoError(t, err)
+
+	r, err := newRotator([]string{newKey}, persister, &bytes.Buffer{})
+	require.NoError(t, err)
+
+	assert.Error(t, r.rotate())
+}
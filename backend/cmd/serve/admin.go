@@ -4,12 +4,17 @@ Copyright © 2022 Hanko GmbH <developers@hanko.io>
 package serve
 
 import (
+	"context"
 	"github.com/spf13/cobra"
 	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/server"
+	"github.com/teamhanko/hanko/backend/telemetry"
 	"log"
+	"os"
+	"os/signal"
 	"sync"
+	"syscall"
 )
 
 func NewServeAdminCommand() *cobra.Command {
@@ -27,14 +32,25 @@ func NewServeAdminCommand() *cobra.Command {
 				log.Fatal(err)
 			}
 
+			shutdownTracing, err := telemetry.InitTracing(cfg.Tracing)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer shutdownTracing(context.Background())
+
 			persister, err := persistence.New(cfg.Database)
 			if err != nil {
 				log.Fatal(err)
 			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
 			var wg sync.WaitGroup
 			wg.Add(1)
 
-			go server.StartAdmin(cfg, &wg, persister, nil)
+			go server.StartAdmin(ctx, cfg, &wg, persister, nil)
+
+			go server.StartWebhookDispatcher(cfg, persister)
 
 			wg.Wait()
 		},
@@ -4,14 +4,20 @@ Copyright © 2022 Hanko GmbH <developers@hanko.io>
 package serve
 
 import (
+	"context"
 	"github.com/labstack/echo-contrib/echoprometheus"
 	"github.com/spf13/cobra"
+	auditlog "github.com/teamhanko/hanko/backend/audit_log"
 	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/mapper"
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/server"
+	"github.com/teamhanko/hanko/backend/telemetry"
 	"log"
+	"os"
+	"os/signal"
 	"sync"
+	"syscall"
 )
 
 func NewServeAllCommand() *cobra.Command {
@@ -30,19 +36,43 @@ func NewServeAllCommand() *cobra.Command {
 				log.Fatal(err)
 			}
 
+			shutdownTracing, err := telemetry.InitTracing(cfg.Tracing)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer shutdownTracing(context.Background())
+
 			authenticatorMetadata := mapper.LoadAuthenticatorMetadata(&authenticatorMetadataFile)
 
 			persister, err := persistence.New(cfg.Database)
 			if err != nil {
 				log.Fatal(err)
 			}
+
+			auditLogger, err := auditlog.NewLogger(persister, cfg.AuditLog)
+			if err != nil {
+				log.Fatalf("failed to create audit logger: %s", err)
+			}
+			// the audit logs buffered by the sinks are written when the servers have been shut down
+			defer auditLogger.Close()
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
 			var wg sync.WaitGroup
 			wg.Add(2)
 
 			prometheus := echoprometheus.NewMiddleware("hanko")
 
-			go server.StartPublic(cfg, &wg, persister, prometheus, authenticatorMetadata)
-			go server.StartAdmin(cfg, &wg, persister, prometheus)
+			go server.StartPublic(ctx, cfg, &wg, persister, auditLogger, prometheus, authenticatorMetadata)
+			go server.StartAdmin(ctx, cfg, &wg, persister, prometheus)
+
+			go server.StartWebhookDispatcher(cfg, persister)
+			go server.StartEmailDispatcher(cfg, persister)
+			go server.StartAuditLogPruner(cfg, persister)
+			go server.StartFlowCleanup(cfg, persister)
+			go server.StartAuditLogCheckpointer(cfg, persister)
+			go server.StartAuditLogChainer(cfg, persister)
 
 			wg.Wait()
 		},
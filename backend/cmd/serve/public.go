@@ -4,13 +4,19 @@ Copyright © 2022 Hanko GmbH <developers@hanko.io>
 package serve
 
 import (
+	"context"
 	"github.com/spf13/cobra"
+	auditlog "github.com/teamhanko/hanko/backend/audit_log"
 	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/mapper"
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/server"
+	"github.com/teamhanko/hanko/backend/telemetry"
 	"log"
+	"os"
+	"os/signal"
 	"sync"
+	"syscall"
 )
 
 func NewServePublicCommand() *cobra.Command {
@@ -29,16 +35,40 @@ func NewServePublicCommand() *cobra.Command {
 				log.Fatal(err)
 			}
 
+			shutdownTracing, err := telemetry.InitTracing(cfg.Tracing)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer shutdownTracing(context.Background())
+
 			authenticatorMetadata := mapper.LoadAuthenticatorMetadata(&authenticatorMetadataFile)
 
 			persister, err := persistence.New(cfg.Database)
 			if err != nil {
 				log.Fatal(err)
 			}
+
+			auditLogger, err := auditlog.NewLogger(persister, cfg.AuditLog)
+			if err != nil {
+				log.Fatalf("failed to create audit logger: %s", err)
+			}
+			// the audit logs buffered by the sinks are written when the servers have been shut down
+			defer auditLogger.Close()
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
 			var wg sync.WaitGroup
 			wg.Add(1)
 
-			go server.StartPublic(cfg, &wg, persister, nil, authenticatorMetadata)
+			go server.StartPublic(ctx, cfg, &wg, persister, auditLogger, nil, authenticatorMetadata)
+
+			go server.StartWebhookDispatcher(cfg, persister)
+			go server.StartEmailDispatcher(cfg, persister)
+			go server.StartAuditLogPruner(cfg, persister)
+			go server.StartFlowCleanup(cfg, persister)
+			go server.StartAuditLogCheckpointer(cfg, persister)
+			go server.StartAuditLogChainer(cfg, persister)
 
 			wg.Wait()
 		},
@@ -16,6 +16,9 @@ type Config struct {
 	Account Account `yaml:"account" json:"account,omitempty" koanf:"account" jsonschema:"title=account"`
 	// `audit_log` configures output and storage modalities of audit logs.
 	AuditLog AuditLog `yaml:"audit_log" json:"audit_log,omitempty" koanf:"audit_log" split_words:"true" jsonschema:"title=audit_log"`
+	// `before_hooks` configures synchronous HTTP hooks which are called before specific operations of the
+	// registration and login flows and which can deny or enrich these operations.
+	BeforeHooks BeforeHooks `yaml:"before_hooks" json:"before_hooks,omitempty" koanf:"before_hooks" split_words:"true" jsonschema:"title=before_hooks"`
 	// `convert_legacy_config`, if set to `true`, automatically copies the set values of deprecated configuration
 	// options, to new ones. If set to `false`, these values have to be set manually if non-default values should be
 	// used.
@@ -30,6 +33,8 @@ type Config struct {
 	EmailDelivery EmailDelivery `yaml:"email_delivery" json:"email_delivery,omitempty" koanf:"email_delivery" split_words:"true" jsonschema:"title=email_delivery"`
 	// Deprecated. See child properties for suggested replacements.
 	Emails Emails `yaml:"emails" json:"emails,omitempty" koanf:"emails" jsonschema:"title=emails"`
+	// `flow` configures the storage of the flows of the flow API.
+	Flow Flow `yaml:"flow" json:"flow,omitempty" koanf:"flow" jsonschema:"title=flow"`
 	// `log` configures application logging.
 	Log LoggerConfig `yaml:"log" json:"log,omitempty" koanf:"log" jsonschema:"title=log"`
 	// Deprecated. See child properties for suggested replacements.
@@ -45,6 +50,9 @@ type Config struct {
 	Saml config.Saml `yaml:"saml" json:"saml,omitempty" koanf:"saml" jsonschema:"title=saml"`
 	// `secrets` configures the keys used for cryptographically signing tokens issued by the API.
 	Secrets Secrets `yaml:"secrets" json:"secrets,omitempty" koanf:"secrets" jsonschema:"title=secrets"`
+	// `security_notifications` configures emails notifying users about security relevant events concerning their
+	// account.
+	SecurityNotifications SecurityNotifications `yaml:"security_notifications" json:"security_notifications,omitempty" koanf:"security_notifications" split_words:"true" jsonschema:"title=security_notifications"`
 	// `server` configures address and CORS settings of the public and admin API.
 	Server Server `yaml:"server" json:"server,omitempty" koanf:"server" jsonschema:"title=server"`
 	// `service` configures general service information.
@@ -56,6 +64,8 @@ type Config struct {
 	// `third_party` configures the modalities of third party OAuth/OIDC based authentication and available identity
 	// providers.
 	ThirdParty ThirdParty `yaml:"third_party" json:"third_party,omitempty" koanf:"third_party" split_words:"true" jsonschema:"title=third_party"`
+	// `tracing` configures the export of OpenTelemetry traces.
+	Tracing Tracing `yaml:"tracing" json:"tracing,omitempty" koanf:"tracing" jsonschema:"title=tracing"`
 	// `username` configures how usernames of user accounts are acquired and used.
 	Username Username `yaml:"username" json:"username,omitempty" koanf:"username" jsonschema:"title=username"`
 	// `webauthn` configures general settings for communication with the WebAuthentication API.
@@ -98,6 +108,12 @@ func Load(cfgFile *string) (*Config, error) {
 	}
 
 	c := DefaultConfig()
+	// configured lists are decoded into the default lists element by element, so the default onboarding steps would
+	// remain if fewer steps are configured
+	if k.Exists("flow.login_onboarding.steps") {
+		c.Flow.LoginOnboarding.Steps = nil
+	}
+
 	err = k.Unmarshal("", c)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
@@ -130,16 +146,26 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("failed to validate webauthn settings: %w", err)
 	}
 	if c.EmailDelivery.Enabled {
-		err = c.Smtp.Validate()
+		if c.EmailDelivery.Provider == "" || c.EmailDelivery.Provider == EmailDeliveryProviderSMTP {
+			err = c.Smtp.Validate()
+			if err != nil {
+				return fmt.Errorf("failed to validate smtp settings: %w", err)
+			}
+		}
+		err = c.EmailDelivery.Validate()
 		if err != nil {
-			return fmt.Errorf("failed to validate smtp settings: %w", err)
+			return fmt.Errorf("failed to validate email_delivery settings: %w", err)
 		}
 	}
+	err = c.Email.Validate(c.Service)
+	if err != nil {
+		return fmt.Errorf("failed to validate email settings: %w", err)
+	}
 	err = c.Database.Validate()
 	if err != nil {
 		return fmt.Errorf("failed to validate database settings: %w", err)
 	}
-	err = c.Secrets.Validate()
+	err = c.Secrets.Validate(c.Session)
 	if err != nil {
 		return fmt.Errorf("failed to validate secrets: %w", err)
 	}
@@ -167,6 +193,26 @@ func (c *Config) Validate() error {
 	if err != nil {
 		return fmt.Errorf("failed to validate webhook settings: %w", err)
 	}
+	err = c.BeforeHooks.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate before_hooks settings: %w", err)
+	}
+	err = c.AuditLog.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate audit_log settings: %w", err)
+	}
+	err = c.Flow.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate flow settings: %w", err)
+	}
+	err = c.Tracing.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate tracing settings: %w", err)
+	}
+	err = c.SecurityNotifications.Validate(c.Session, c.Service)
+	if err != nil {
+		return fmt.Errorf("failed to validate security_notifications settings: %w", err)
+	}
 	return nil
 }
 
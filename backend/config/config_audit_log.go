@@ -1,5 +1,13 @@
 package config
 
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
 type AuditLog struct {
 	// `console_output` controls audit log console output.
 	ConsoleOutput AuditLogConsole `yaml:"console_output" json:"console_output,omitempty" koanf:"console_output" split_words:"true" jsonschema:"title=console_output"`
@@ -9,6 +17,179 @@ type AuditLog struct {
 	Mask bool `yaml:"mask" json:"mask,omitempty" koanf:"mask" jsonschema:"default=true"`
 	// `storage` controls audit log retention.
 	Storage AuditLogStorage `yaml:"storage" json:"storage,omitempty" koanf:"storage"`
+	// `retention` controls how long persisted audit logs are kept. Audit logs are kept forever if retention is
+	// disabled.
+	Retention AuditLogRetention `yaml:"retention" json:"retention,omitempty" koanf:"retention" jsonschema:"title=retention"`
+	// `sinks` is a list of additional destinations audit logs are written to, e.g. a file, a syslog server or an
+	// HTTP collector. Masking (see `mask`) applies to all sinks.
+	//
+	// When using environment variables the value for the `AUDIT_LOG_SINKS` key must be specified in the following
+	// format:
+	// `{"type":"file","file":{"path":"/var/log/hanko/audit.log"}};{"type":"syslog","format":"cef","syslog":{"address":"siem:514"}}`
+	Sinks AuditLogSinks `yaml:"sinks" json:"sinks,omitempty" koanf:"sinks" jsonschema:"title=sinks"`
+	// `integrity` makes persisted audit logs tamper-evident.
+	Integrity AuditLogIntegrity `yaml:"integrity" json:"integrity,omitempty" koanf:"integrity" jsonschema:"title=integrity"`
+	// `flow_actions` controls audit logs for the actions executed in flows of the flow API.
+	FlowActions AuditLogFlowActions `yaml:"flow_actions" json:"flow_actions,omitempty" koanf:"flow_actions" split_words:"true" jsonschema:"title=flow_actions"`
+}
+
+func (a *AuditLog) Validate() error {
+	err := a.Retention.Validate()
+	if err != nil {
+		return fmt.Errorf("retention: %w", err)
+	}
+
+	for i, sink := range a.Sinks {
+		err = sink.Validate()
+		if err != nil {
+			return fmt.Errorf("sinks[%d]: %w", i, err)
+		}
+	}
+
+	err = a.Integrity.Validate()
+	if err != nil {
+		return fmt.Errorf("integrity: %w", err)
+	}
+
+	err = a.FlowActions.Validate()
+	if err != nil {
+		return fmt.Errorf("flow_actions: %w", err)
+	}
+
+	if a.Integrity.Enabled && len(a.Retention.Types) > 0 {
+		// pruning types individually would leave gaps in the hash chain which cannot be told apart from deleted
+		// audit logs. This applies regardless of whether the periodic pruning is enabled, as the audit logs can also
+		// be pruned manually.
+		return errors.New("retention: types must not be set when integrity is enabled")
+	}
+
+	return nil
+}
+
+type AuditLogRetention struct {
+	// `enabled` determines whether persisted audit logs older than their maximum age are deleted periodically.
+	// When multiple instances share a database, only one of them prunes the audit logs per `interval`.
+	//
+	// Audit logs can also be pruned manually with the `hanko audit-log prune` command.
+	Enabled bool `yaml:"enabled" json:"enabled,omitempty" koanf:"enabled" jsonschema:"default=false"`
+	// `max_age` is the maximum age of audit logs, e.g. `2160h` (90 days). It applies to all types without an entry
+	// in `types`. Audit logs of these types are kept forever if it is `0`.
+	MaxAge time.Duration `yaml:"max_age" json:"max_age,omitempty" koanf:"max_age" split_words:"true" jsonschema:"default=0s,type=string"`
+	// `types` maps audit log types (e.g. `password_login_failed`) to the maximum age of audit logs of this type,
+	// overriding `max_age`. A maximum age of `0` keeps the audit logs of the type forever.
+	//
+	// `types` must not be set when `integrity` is enabled, as pruning types individually would leave gaps in the
+	// hash chain.
+	Types map[string]time.Duration `yaml:"types" json:"types,omitempty" koanf:"types" jsonschema:"title=types"`
+	// `interval` is the interval in which audit logs are pruned.
+	Interval time.Duration `yaml:"interval" json:"interval,omitempty" koanf:"interval" jsonschema:"default=1h,type=string"`
+	// `batch_size` is the maximum number of audit logs deleted per statement. Audit logs are deleted in batches,
+	// so large tables are not locked for a long time.
+	BatchSize int `yaml:"batch_size" json:"batch_size,omitempty" koanf:"batch_size" split_words:"true" jsonschema:"default=1000,minimum=1"`
+}
+
+func (r *AuditLogRetention) Validate() error {
+	if r.MaxAge < 0 {
+		return errors.New("max_age must not be negative")
+	}
+
+	for logType, maxAge := range r.Types {
+		if maxAge < 0 {
+			return fmt.Errorf("max age of type '%s' must not be negative", logType)
+		}
+	}
+
+	if r.Enabled && r.Interval <= 0 {
+		return errors.New("interval must be greater than 0")
+	}
+
+	if r.BatchSize < 1 {
+		return errors.New("batch_size must be at least 1")
+	}
+
+	return nil
+}
+
+type AuditLogIntegrity struct {
+	// `enabled` determines whether persisted audit logs are hash-chained: every audit log carries a hash over its
+	// content and the hash of the previous audit log, so modified or deleted audit logs can be detected with the
+	// `hanko audit-log verify` command. Audit logs created while the hash chain was disabled are not part of the chain.
+	//
+	// Audit logs created in a transaction are appended to the chain once the transaction has been committed. Audit
+	// logs which could not be appended then are appended by a background job within a few minutes.
+	Enabled bool `yaml:"enabled" json:"enabled,omitempty" koanf:"enabled" jsonschema:"default=false"`
+	// `checkpoints` controls signed checkpoints of the hash chain.
+	Checkpoints AuditLogCheckpoints `yaml:"checkpoints" json:"checkpoints,omitempty" koanf:"checkpoints" jsonschema:"title=checkpoints"`
+}
+
+func (i *AuditLogIntegrity) Validate() error {
+	if i.Checkpoints.Enabled && i.Checkpoints.Interval <= 0 {
+		return errors.New("checkpoints: interval must be greater than 0")
+	}
+
+	return nil
+}
+
+type AuditLogCheckpoints struct {
+	// `enabled` determines whether the head of the hash chain is periodically signed with the current JWK (see
+	// `secrets`). Unlike the hash chain alone, checkpoints also reveal audit logs deleted from the end of the chain
+	// and a chain which has been recomputed entirely.
+	Enabled bool `yaml:"enabled" json:"enabled,omitempty" koanf:"enabled" jsonschema:"default=false"`
+	// `interval` is the interval in which checkpoints are created. No checkpoint is created if no audit logs have
+	// been created since the last one.
+	Interval time.Duration `yaml:"interval" json:"interval,omitempty" koanf:"interval" jsonschema:"default=1h,type=string"`
+}
+
+type AuditLogFlowActions struct {
+	// `enabled` determines whether an audit log is created for every action executed in a flow (e.g. `login`,
+	// `registration` or `profile`), including failed ones, e.g. due to an invalid passcode or a CSRF token mismatch.
+	//
+	// Audit logs have the type `flow_action_succeeded` or `flow_action_failed` and contain the flow name, flow ID,
+	// state, action, next state, outcome and, if the action failed, the error code.
+	Enabled bool `yaml:"enabled" json:"enabled,omitempty" koanf:"enabled" jsonschema:"default=false"`
+	// `include` is a list of actions audit logs are created for. Entries are either action names
+	// (e.g. `verify_passcode`) or a flow name and an action name separated by a slash (e.g. `login/verify_passcode`).
+	// Both may contain `*` wildcards, e.g. `login/*` or `*passcode*`.
+	//
+	// Audit logs are created for all actions if empty.
+	Include []string `yaml:"include" json:"include,omitempty" koanf:"include" jsonschema:"title=include"`
+	// `exclude` is a list of actions no audit logs are created for, in the same format as `include`. It takes
+	// precedence over `include`.
+	Exclude []string `yaml:"exclude" json:"exclude,omitempty" koanf:"exclude" jsonschema:"title=exclude"`
+}
+
+func (f *AuditLogFlowActions) Validate() error {
+	for _, pattern := range append(append([]string{}, f.Include...), f.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid action pattern '%s': %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// Matches reports whether audit logs are created for the action of the given flow.
+func (f *AuditLogFlowActions) Matches(flowName string, actionName string) bool {
+	if matchesAnyFlowAction(f.Exclude, flowName, actionName) {
+		return false
+	}
+
+	return len(f.Include) == 0 || matchesAnyFlowAction(f.Include, flowName, actionName)
+}
+
+func matchesAnyFlowAction(patterns []string, flowName string, actionName string) bool {
+	for _, pattern := range patterns {
+		name := actionName
+		if strings.Contains(pattern, "/") {
+			name = flowName + "/" + actionName
+		}
+
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+
+	return false
 }
 
 type AuditLogStorage struct {
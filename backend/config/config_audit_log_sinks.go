@@ -0,0 +1,201 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type AuditLogSinkType string
+
+const (
+	AuditLogSinkTypeFile   AuditLogSinkType = "file"
+	AuditLogSinkTypeSyslog AuditLogSinkType = "syslog"
+	AuditLogSinkTypeHTTP   AuditLogSinkType = "http"
+)
+
+type AuditLogSinkFormat string
+
+const (
+	AuditLogSinkFormatJSON AuditLogSinkFormat = "json"
+	AuditLogSinkFormatCEF  AuditLogSinkFormat = "cef"
+	AuditLogSinkFormatLEEF AuditLogSinkFormat = "leef"
+)
+
+type AuditLogHTTPSinkOnFull string
+
+const (
+	// AuditLogHTTPSinkOnFullDrop drops audit logs if the buffer of the sink is full.
+	AuditLogHTTPSinkOnFullDrop AuditLogHTTPSinkOnFull = "drop"
+	// AuditLogHTTPSinkOnFullBlock blocks the request creating the audit log until there is space in the buffer.
+	AuditLogHTTPSinkOnFullBlock AuditLogHTTPSinkOnFull = "block"
+)
+
+type AuditLogSinks []AuditLogSink
+
+// Decode is an implementation of the envconfig.Decoder interface.
+// Assumes that environment variables (for the AUDIT_LOG_SINKS key) have the following format:
+// {"type":"file","file":{"path":"/var/log/hanko/audit.log"}};{"type":"syslog","syslog":{"address":"localhost:514"}}
+func (s *AuditLogSinks) Decode(value string) error {
+	sinks := AuditLogSinks{}
+	for _, sinkJson := range strings.Split(value, ";") {
+		sink := AuditLogSink{}
+		err := json.Unmarshal([]byte(sinkJson), &sink)
+		if err != nil {
+			return fmt.Errorf("invalid map json: %w", err)
+		}
+		sinks = append(sinks, sink)
+	}
+	*s = sinks
+	return nil
+}
+
+type AuditLogSink struct {
+	// `type` determines where audit logs are written to. The sink is configured in the property with the same name.
+	Type AuditLogSinkType `yaml:"type" json:"type,omitempty" koanf:"type" jsonschema:"enum=file,enum=syslog,enum=http"`
+	// `format` determines the format audit logs are written in.
+	//
+	// `json` writes the audit log as a JSON object, `cef` in the ArcSight Common Event Format and `leef` in the IBM
+	// QRadar Log Event Extended Format.
+	Format AuditLogSinkFormat `yaml:"format" json:"format,omitempty" koanf:"format" jsonschema:"default=json,enum=json,enum=cef,enum=leef"`
+	// `file` configures a sink writing to a file which is rotated once it reaches its maximum size.
+	File AuditLogFileSink `yaml:"file" json:"file,omitempty" koanf:"file" jsonschema:"title=file"`
+	// `syslog` configures a sink sending RFC 5424 messages to a syslog server.
+	Syslog AuditLogSyslogSink `yaml:"syslog" json:"syslog,omitempty" koanf:"syslog" jsonschema:"title=syslog"`
+	// `http` configures a sink sending batches of audit logs to an HTTP collector.
+	HTTP AuditLogHTTPSink `yaml:"http" json:"http,omitempty" koanf:"http" jsonschema:"title=http"`
+}
+
+func (s *AuditLogSink) Validate() error {
+	switch s.Format {
+	case "", AuditLogSinkFormatJSON, AuditLogSinkFormatCEF, AuditLogSinkFormatLEEF:
+	default:
+		return fmt.Errorf("unknown format '%s'", s.Format)
+	}
+
+	switch s.Type {
+	case AuditLogSinkTypeFile:
+		return s.File.Validate()
+	case AuditLogSinkTypeSyslog:
+		return s.Syslog.Validate()
+	case AuditLogSinkTypeHTTP:
+		return s.HTTP.Validate()
+	default:
+		return fmt.Errorf("unknown type '%s'", s.Type)
+	}
+}
+
+type AuditLogFileSink struct {
+	// `path` is the path of the file. Rotated files get the suffixes `.1` (most recent) to `.<max_backups>`.
+	Path string `yaml:"path" json:"path,omitempty" koanf:"path"`
+	// `max_size` is the maximum size of the file in megabytes before it is rotated.
+	MaxSize int `yaml:"max_size" json:"max_size,omitempty" koanf:"max_size" split_words:"true" jsonschema:"default=100,minimum=1"`
+	// `max_backups` is the number of rotated files kept.
+	MaxBackups int `yaml:"max_backups" json:"max_backups,omitempty" koanf:"max_backups" split_words:"true" jsonschema:"default=5,minimum=0"`
+}
+
+func (s *AuditLogFileSink) Validate() error {
+	if s.Path == "" {
+		return errors.New("file: path must not be empty")
+	}
+	if s.MaxSize < 0 {
+		return errors.New("file: max_size must not be negative")
+	}
+	if s.MaxBackups < 0 {
+		return errors.New("file: max_backups must not be negative")
+	}
+
+	return nil
+}
+
+type AuditLogSyslogSink struct {
+	// `network` is the transport protocol used to send messages. Messages sent over `tcp` are framed with octet
+	// counting (RFC 6587).
+	Network string `yaml:"network" json:"network,omitempty" koanf:"network" jsonschema:"default=udp,enum=udp,enum=tcp"`
+	// `address` is the address (`host:port`) of the syslog server.
+	Address string `yaml:"address" json:"address,omitempty" koanf:"address"`
+	// `facility` is the syslog facility of the messages.
+	Facility string `yaml:"facility" json:"facility,omitempty" koanf:"facility" jsonschema:"default=authpriv,enum=auth,enum=authpriv,enum=local0,enum=local1,enum=local2,enum=local3,enum=local4,enum=local5,enum=local6,enum=local7"`
+	// `app_name` is the APP-NAME of the messages.
+	AppName string `yaml:"app_name" json:"app_name,omitempty" koanf:"app_name" split_words:"true" jsonschema:"default=hanko"`
+	// `timeout` is the maximum time to connect to the server and to send a message.
+	Timeout time.Duration `yaml:"timeout" json:"timeout,omitempty" koanf:"timeout" jsonschema:"default=5s,type=string"`
+}
+
+// SyslogFacilities maps the supported facility names to their numerical codes.
+var SyslogFacilities = map[string]int{
+	"auth":     4,
+	"authpriv": 10,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+func (s *AuditLogSyslogSink) Validate() error {
+	if s.Network != "" && s.Network != "udp" && s.Network != "tcp" {
+		return errors.New("syslog: network must be one of 'udp' or 'tcp'")
+	}
+	if _, _, err := net.SplitHostPort(s.Address); err != nil {
+		return fmt.Errorf("syslog: address is not valid: %w", err)
+	}
+	if _, ok := SyslogFacilities[s.Facility]; s.Facility != "" && !ok {
+		return fmt.Errorf("syslog: unknown facility '%s'", s.Facility)
+	}
+	if s.Timeout < 0 {
+		return errors.New("syslog: timeout must not be negative")
+	}
+
+	return nil
+}
+
+type AuditLogHTTPSink struct {
+	// `url` is the URL of the collector. Batches are sent as `POST` requests with one audit log per line.
+	URL string `yaml:"url" json:"url,omitempty" koanf:"url"`
+	// `headers` are added to every request, e.g. to authenticate with the collector.
+	Headers map[string]string `yaml:"headers" json:"headers,omitempty" koanf:"headers"`
+	// `batch_size` is the maximum number of audit logs sent per request.
+	BatchSize int `yaml:"batch_size" json:"batch_size,omitempty" koanf:"batch_size" split_words:"true" jsonschema:"default=100,minimum=1"`
+	// `flush_interval` is the maximum time audit logs are buffered before they are sent.
+	FlushInterval time.Duration `yaml:"flush_interval" json:"flush_interval,omitempty" koanf:"flush_interval" split_words:"true" jsonschema:"default=5s,type=string"`
+	// `buffer_size` is the maximum number of audit logs buffered while the collector is slow or unavailable.
+	BufferSize int `yaml:"buffer_size" json:"buffer_size,omitempty" koanf:"buffer_size" split_words:"true" jsonschema:"default=10000,minimum=1"`
+	// `on_full` determines what happens when the buffer is full. `drop` drops new audit logs (a warning with the
+	// number of dropped audit logs is logged), `block` makes the request creating the audit log wait until there is
+	// space in the buffer.
+	OnFull AuditLogHTTPSinkOnFull `yaml:"on_full" json:"on_full,omitempty" koanf:"on_full" split_words:"true" jsonschema:"default=drop,enum=drop,enum=block"`
+	// `max_retries` is the number of times a failed request is retried with an exponential backoff before the batch
+	// is dropped.
+	MaxRetries int `yaml:"max_retries" json:"max_retries,omitempty" koanf:"max_retries" split_words:"true" jsonschema:"default=5,minimum=0"`
+	// `timeout` is the maximum time for a single request.
+	Timeout time.Duration `yaml:"timeout" json:"timeout,omitempty" koanf:"timeout" jsonschema:"default=10s,type=string"`
+}
+
+func (s *AuditLogHTTPSink) Validate() error {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return fmt.Errorf("http: url is not a valid URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("http: url must be an absolute http(s) URL")
+	}
+	if s.BatchSize < 0 || s.BufferSize < 0 || s.MaxRetries < 0 {
+		return errors.New("http: batch_size, buffer_size and max_retries must not be negative")
+	}
+	if s.FlushInterval < 0 || s.Timeout < 0 {
+		return errors.New("http: flush_interval and timeout must not be negative")
+	}
+	if s.OnFull != "" && s.OnFull != AuditLogHTTPSinkOnFullDrop && s.OnFull != AuditLogHTTPSinkOnFullBlock {
+		return errors.New("http: on_full must be one of 'drop' or 'block'")
+	}
+
+	return nil
+}
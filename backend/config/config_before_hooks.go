@@ -0,0 +1,86 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+type BeforeHooks struct {
+	// `session_issue` configures the hook called before a session is issued at the end of a registration or login
+	// flow. The hook can deny the login or return claims to add to the session token and metadata to merge into the
+	// metadata of the user.
+	SessionIssue BeforeHook `yaml:"session_issue" json:"session_issue,omitempty" koanf:"session_issue" split_words:"true" jsonschema:"title=session_issue"`
+	// `user_create` configures the hook called before a user is created in a registration flow. The hook can deny the
+	// registration or return claims to add to the session token and metadata to store with the new user.
+	UserCreate BeforeHook `yaml:"user_create" json:"user_create,omitempty" koanf:"user_create" split_words:"true" jsonschema:"title=user_create"`
+}
+
+func (b *BeforeHooks) Validate() error {
+	err := b.SessionIssue.Validate()
+	if err != nil {
+		return fmt.Errorf("session_issue: %w", err)
+	}
+
+	err = b.UserCreate.Validate()
+	if err != nil {
+		return fmt.Errorf("user_create: %w", err)
+	}
+
+	return nil
+}
+
+type BeforeHookFailurePolicy string
+
+const (
+	// BeforeHookFailOpen continues the flow if the hook cannot be called or returns an invalid response.
+	BeforeHookFailOpen BeforeHookFailurePolicy = "open"
+	// BeforeHookFailClosed aborts the flow if the hook cannot be called or returns an invalid response.
+	BeforeHookFailClosed BeforeHookFailurePolicy = "closed"
+)
+
+type BeforeHook struct {
+	// `enabled` determines whether the hook is called.
+	Enabled bool `yaml:"enabled" json:"enabled,omitempty" koanf:"enabled" jsonschema:"default=false"`
+	// `url` is the URL the hook request is sent to. The request is a `POST` request with a JSON body describing the
+	// event. The hook must respond with status code `200` and a JSON body, e.g.
+	// `{"allow": false, "error": {"code": "domain_not_allowed", "message": "..."}}` to deny the operation or
+	// `{"claims": {"customer_id": "..."}, "metadata": {"plan": "free"}}` to allow it and enrich the session and the
+	// user.
+	URL string `yaml:"url" json:"url,omitempty" koanf:"url"`
+	// `timeout` is the maximum time to wait for the response of the hook.
+	Timeout time.Duration `yaml:"timeout" json:"timeout,omitempty" koanf:"timeout" jsonschema:"default=5s,type=string"`
+	// `failure_policy` determines how failed hook calls (e.g. timeouts, unexpected status codes or invalid responses)
+	// are handled. `open` continues the flow as if the hook allowed the operation, `closed` aborts the flow with a
+	// `before_hook_failed` error.
+	FailurePolicy BeforeHookFailurePolicy `yaml:"failure_policy" json:"failure_policy,omitempty" koanf:"failure_policy" split_words:"true" jsonschema:"default=closed,enum=open,enum=closed"`
+	// `secret` is used to sign the hook requests following the Standard Webhooks specification. It must be a base64
+	// encoded random value of at least 24 bytes, optionally prefixed with `whsec_`. Requests are not signed if no
+	// secret is set.
+	Secret string `yaml:"secret" json:"secret,omitempty" koanf:"secret"`
+}
+
+func (b *BeforeHook) Validate() error {
+	if !b.Enabled {
+		return nil
+	}
+
+	u, err := url.Parse(b.URL)
+	if err != nil {
+		return fmt.Errorf("url is not a valid URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an absolute http(s) URL")
+	}
+
+	if b.Timeout <= 0 {
+		return errors.New("timeout must be greater than 0")
+	}
+
+	if b.FailurePolicy != BeforeHookFailOpen && b.FailurePolicy != BeforeHookFailClosed {
+		return fmt.Errorf("failure_policy must be one of '%s' or '%s'", BeforeHookFailOpen, BeforeHookFailClosed)
+	}
+
+	return validateSigningSecret(b.Secret)
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBeforeHook_Validate(t *testing.T) {
+	hook := BeforeHook{}
+	assert.NoError(t, hook.Validate(), "disabled hooks are not validated")
+
+	hook = BeforeHook{
+		Enabled:       true,
+		URL:           "https://app.com/hooks/before",
+		Timeout:       5 * time.Second,
+		FailurePolicy: BeforeHookFailClosed,
+	}
+	assert.NoError(t, hook.Validate())
+
+	hook.URL = "/hooks/before"
+	assert.Error(t, hook.Validate(), "url must be absolute")
+
+	hook.URL = "ftp://app.com/hooks/before"
+	assert.Error(t, hook.Validate(), "url must use http(s)")
+
+	hook.URL = "https://app.com/hooks/before"
+	hook.Timeout = 0
+	assert.Error(t, hook.Validate(), "timeout must be greater than 0")
+
+	hook.Timeout = time.Second
+	hook.FailurePolicy = "ignore"
+	assert.Error(t, hook.Validate(), "failure policy must be valid")
+
+	hook.FailurePolicy = BeforeHookFailOpen
+	hook.Secret = "whsec_c2hvcnQ="
+	assert.Error(t, hook.Validate(), "secret is too short")
+}
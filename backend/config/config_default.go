@@ -10,6 +10,10 @@ func DefaultConfig() *Config {
 		},
 		Secrets: Secrets{
 			Keys: []string{"abcedfghijklmnopqrstuvwxyz"},
+			Jwk: Jwk{
+				Algorithm:   "RS256",
+				GracePeriod: 24 * time.Hour,
+			},
 		},
 		Server: Server{
 			Public: ServerSettings{
@@ -39,12 +43,34 @@ func DefaultConfig() *Config {
 		Smtp: SMTP{
 			Host: "localhost",
 			Port: "465",
+			Pool: SMTPPool{
+				Enabled:        true,
+				MaxConnections: 2,
+				IdleTimeout:    30 * time.Second,
+			},
 		},
 		EmailDelivery: EmailDelivery{
-			Enabled: true,
+			Enabled:  true,
+			Provider: EmailDeliveryProviderSMTP,
 			SMTP: SMTP{
 				Host: "localhost",
 				Port: "465",
+				Pool: SMTPPool{
+					Enabled:        true,
+					MaxConnections: 2,
+					IdleTimeout:    30 * time.Second,
+				},
+			},
+			HTTP: EmailDeliveryHTTP{
+				Timeout: 10 * time.Second,
+			},
+			File: EmailDeliveryFile{
+				Directory: "./emails",
+			},
+			Queue: EmailQueue{
+				Enabled:     true,
+				MaxAttempts: 5,
+				Retention:   7 * 24 * time.Hour,
 			},
 			FromAddress: "noreply@hanko.io",
 			FromName:    "Hanko",
@@ -86,6 +112,36 @@ func DefaultConfig() *Config {
 				OutputStream: OutputStreamStdOut,
 			},
 			Mask: true,
+			Retention: AuditLogRetention{
+				Interval:  time.Hour,
+				BatchSize: 1000,
+			},
+			Integrity: AuditLogIntegrity{
+				Checkpoints: AuditLogCheckpoints{
+					Interval: time.Hour,
+				},
+			},
+		},
+		Flow: Flow{
+			Store: FlowStore{
+				Type: FlowStoreTypeDatabase,
+			},
+			Cleanup: FlowCleanup{
+				Enabled:   true,
+				Interval:  time.Hour,
+				BatchSize: 1000,
+			},
+			LoginOnboarding: FlowOnboarding{
+				Steps: []FlowOnboardingStep{
+					FlowOnboardingStepUsername,
+					FlowOnboardingStepEmail,
+					FlowOnboardingStepCredentials,
+				},
+			},
+		},
+		Tracing: Tracing{
+			SampleRatio: 1,
+			ServiceName: "hanko",
 		},
 		Emails: Emails{
 			RequireVerification: true,
@@ -151,6 +207,14 @@ func DefaultConfig() *Config {
 			MaxLength:             120,
 			UseForAuthentication:  true,
 			PasscodeTtl:           300,
+			PasscodeMode:          PasscodeModeCode,
+			PasscodeLink: PasscodeLink{
+				IncludeCode:        true,
+				RequireSameBrowser: true,
+			},
+			VerificationLink: EmailVerificationLink{
+				LinkTtl: 24 * time.Hour,
+			},
 		},
 		Username: Username{
 			Enabled:               false,
@@ -161,6 +225,26 @@ func DefaultConfig() *Config {
 			MinLength:             3,
 			MaxLength:             32,
 		},
+		SecurityNotifications: SecurityNotifications{
+			NewDeviceLogin: NewDeviceLoginNotification{
+				Enabled:           false,
+				RevocationLinkTtl: 168 * time.Hour,
+			},
+		},
+		Webhooks: WebhookSettings{
+			MaxAttempts: 8,
+			Workers:     4,
+		},
+		BeforeHooks: BeforeHooks{
+			SessionIssue: BeforeHook{
+				Timeout:       5 * time.Second,
+				FailurePolicy: BeforeHookFailClosed,
+			},
+			UserCreate: BeforeHook{
+				Timeout:       5 * time.Second,
+				FailurePolicy: BeforeHookFailClosed,
+			},
+		},
 		Debug: false,
 	}
 }
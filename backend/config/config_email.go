@@ -1,5 +1,12 @@
 package config
 
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
 type Email struct {
 	// `acquire_on_login` determines whether users, provided that they do not already have registered an email,
 	//	are prompted to provide an email on login.
@@ -16,6 +23,14 @@ type Email struct {
 	// There must always be at least one email address associated with an account. The primary email address cannot be
 	// deleted if emails are required (`optional`: false`).
 	Optional bool `yaml:"optional" json:"optional,omitempty" koanf:"optional" jsonschema:"default=false"`
+	// `passcode_link` configures the links sent if `passcode_mode` is `link`.
+	PasscodeLink PasscodeLink `yaml:"passcode_link" json:"passcode_link,omitempty" koanf:"passcode_link" split_words:"true" jsonschema:"title=passcode_link"`
+	// `passcode_mode` determines how users confirm an email address on login, registration and account recovery:
+	//
+	// - `code`: the email contains a six-digit passcode which must be entered in the flow.
+	// - `link`: the email contains a one-time link which completes the passcode step of the flow the passcode has been
+	//   requested in. Optionally, the passcode is included as a fallback (see `passcode_link.include_code`).
+	PasscodeMode PasscodeMode `yaml:"passcode_mode" json:"passcode_mode,omitempty" koanf:"passcode_mode" split_words:"true" jsonschema:"default=code,enum=code,enum=link"`
 	// `passcode_ttl` specifies, in seconds, how long a passcode is valid for.
 	PasscodeTtl int `yaml:"passcode_ttl" json:"passcode_ttl,omitempty" koanf:"passcode_ttl" jsonschema:"default=300"`
 	// `require_verification` determines whether newly created emails must be verified by providing a passcode sent
@@ -23,7 +38,106 @@ type Email struct {
 	RequireVerification bool `yaml:"require_verification" json:"require_verification,omitempty" koanf:"require_verification" split_words:"true" jsonschema:"default=true"`
 	// `use_as_login_identifier` determines whether emails can be used as an identifier on login.
 	UseAsLoginIdentifier bool `yaml:"use_as_login_identifier" json:"use_as_login_identifier,omitempty" koanf:"use_as_login_identifier" jsonschema:"default=true"`
+	// `verification_link` configures the verification of email addresses added or verified in the profile by link
+	// instead of by passcode.
+	VerificationLink EmailVerificationLink `yaml:"verification_link" json:"verification_link,omitempty" koanf:"verification_link" split_words:"true" jsonschema:"title=verification_link"`
 	// `user_for_authentication` determines whether users can log in by providing an email address and subsequently
 	// providing a passcode sent to the given email address.
 	UseForAuthentication bool `yaml:"use_for_authentication" json:"use_for_authentication,omitempty" koanf:"use_for_authentication" jsonschema:"default=true"`
 }
+
+func (e *Email) Validate(service Service) error {
+	switch e.PasscodeMode {
+	case "", PasscodeModeCode:
+	case PasscodeModeLink:
+		if service.ApiURL == "" {
+			return errors.New("service.api_url must be set if passcode_mode is 'link'")
+		}
+		err := e.PasscodeLink.Validate()
+		if err != nil {
+			return fmt.Errorf("failed to validate passcode_link settings: %w", err)
+		}
+	default:
+		return fmt.Errorf("passcode_mode must be one of '%s' or '%s'", PasscodeModeCode, PasscodeModeLink)
+	}
+	err := e.VerificationLink.Validate(service)
+	if err != nil {
+		return fmt.Errorf("failed to validate verification_link settings: %w", err)
+	}
+	return nil
+}
+
+// PasscodeMode determines how passcodes are sent to users.
+type PasscodeMode string
+
+const (
+	// PasscodeModeCode sends a six-digit passcode which must be entered in the flow.
+	PasscodeModeCode PasscodeMode = "code"
+	// PasscodeModeLink sends a one-time link which confirms the passcode step of the flow.
+	PasscodeModeLink PasscodeMode = "link"
+)
+
+type PasscodeLink struct {
+	// `include_code` determines whether the six-digit passcode is included in the email as a fallback, e.g. for users
+	// opening the email on another device. If disabled, the passcode step can only be completed through the link.
+	IncludeCode bool `yaml:"include_code" json:"include_code,omitempty" koanf:"include_code" split_words:"true" jsonschema:"default=true"`
+	// `redirect_url` is the URL the user is redirected to after confirming the link. The query parameter `passcode_link`
+	// indicates the result and is one of `confirmed`, `invalid` or `browser_mismatch`.
+	//
+	// If not set, a JSON response is returned instead.
+	RedirectURL string `yaml:"redirect_url" json:"redirect_url,omitempty" koanf:"redirect_url" split_words:"true"`
+	// `require_same_browser` determines whether the link must be opened in the browser the passcode has been requested
+	// in. The browser is recognized by a cookie set when the passcode is sent.
+	//
+	// Disabling it allows to open the link on another device, e.g. a phone. The link then confirms the passcode step
+	// of a flow which may have been started by someone else, so it should only be disabled if users are aware of this.
+	RequireSameBrowser bool `yaml:"require_same_browser" json:"require_same_browser,omitempty" koanf:"require_same_browser" split_words:"true" jsonschema:"default=true"`
+}
+
+func (l *PasscodeLink) Validate() error {
+	if l.RedirectURL != "" {
+		redirectURL, err := url.Parse(l.RedirectURL)
+		if err != nil || redirectURL.Scheme == "" || redirectURL.Host == "" {
+			return errors.New("redirect_url must be an absolute URL")
+		}
+	}
+	return nil
+}
+
+type EmailVerificationLink struct {
+	// `enabled` determines whether email addresses added or verified in the profile are verified by a link sent to the
+	// email address instead of a passcode. The link can be opened on any device, the user does not need to stay in the
+	// profile.
+	//
+	// Email addresses added in the profile are only created when the link has been opened. Requires `service.api_url`
+	// to be set.
+	Enabled bool `yaml:"enabled" json:"enabled,omitempty" koanf:"enabled" jsonschema:"default=false"`
+	// `link_ttl` determines how long the link is valid. Must be at least one hour.
+	// It must be a (possibly signed) sequence of decimal numbers, each with optional fraction and a unit suffix,
+	// such as "300ms", "-1.5h" or "2h45m". Valid time units are "ns", "us" (or "µs"), "ms", "s", "m", "h".
+	LinkTtl time.Duration `yaml:"link_ttl" json:"link_ttl,omitempty" koanf:"link_ttl" split_words:"true" jsonschema:"default=24h,type=string"`
+	// `redirect_url` is the URL the user is redirected to after opening the link. The query parameter `email_verified`
+	// indicates whether the email address has been verified.
+	//
+	// If not set, a JSON response is returned instead.
+	RedirectURL string `yaml:"redirect_url" json:"redirect_url,omitempty" koanf:"redirect_url" split_words:"true"`
+}
+
+func (l *EmailVerificationLink) Validate(service Service) error {
+	if !l.Enabled {
+		return nil
+	}
+	if service.ApiURL == "" {
+		return errors.New("service.api_url must be set")
+	}
+	if l.LinkTtl < time.Hour {
+		return errors.New("link_ttl must be at least one hour")
+	}
+	if l.RedirectURL != "" {
+		redirectURL, err := url.Parse(l.RedirectURL)
+		if err != nil || redirectURL.Scheme == "" || redirectURL.Host == "" {
+			return errors.New("redirect_url must be an absolute URL")
+		}
+	}
+	return nil
+}
@@ -2,7 +2,10 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"net/url"
 	"strings"
+	"time"
 )
 
 type EmailDelivery struct {
@@ -13,8 +16,140 @@ type EmailDelivery struct {
 	FromAddress string `yaml:"from_address" json:"from_address,omitempty" koanf:"from_address" split_words:"true" jsonschema:"default=noreply@hanko.io"`
 	// `from_name` configures the sender name of emails sent to users.
 	FromName string `yaml:"from_name" json:"from_name,omitempty" koanf:"from_name" split_words:"true" jsonschema:"default=Hanko"`
+	// `provider` determines how emails are delivered.
+	//
+	// `smtp` sends emails through the SMTP server configured in `smtp`, `http` posts emails as JSON to the API
+	// configured in `http` and `file` writes emails to the directory configured in `file`, e.g. for local development.
+	Provider EmailDeliveryProvider `yaml:"provider" json:"provider,omitempty" koanf:"provider" jsonschema:"default=smtp,enum=smtp,enum=http,enum=file"`
 	// `SMTP` contains the SMTP server settings for sending mails.
 	SMTP SMTP `yaml:"smtp" json:"smtp,omitempty" koanf:"smtp" jsonschema:"title=smtp"`
+	// `http` configures the `http` provider.
+	HTTP EmailDeliveryHTTP `yaml:"http" json:"http,omitempty" koanf:"http" jsonschema:"title=http"`
+	// `file` configures the `file` provider.
+	File EmailDeliveryFile `yaml:"file" json:"file,omitempty" koanf:"file" jsonschema:"title=file"`
+	// `queue` configures the persisted send queue.
+	Queue EmailQueue `yaml:"queue" json:"queue,omitempty" koanf:"queue" jsonschema:"title=queue"`
+	// `template_directory` is the path to a directory containing email templates and locale files that override the
+	// built-in ones.
+	//
+	// Templates must be placed in a `templates` subdirectory and named `<template_name>_text.tmpl` for the plain text
+	// part or `<template_name>_html.tmpl` for the HTML part of an email. A template replaces the built-in template with
+	// the same file name.
+	//
+	// Locale files must be placed in a `locales` subdirectory and named `<name>.<language>.yaml`. A message replaces
+	// the built-in message with the same ID and language.
+	//
+	// All message IDs referenced in templates are validated on startup. Use the `email preview` command to check
+	// the rendered emails.
+	TemplateDirectory string `yaml:"template_directory" json:"template_directory,omitempty" koanf:"template_directory" split_words:"true"`
+}
+
+func (e *EmailDelivery) Validate() error {
+	switch e.Provider {
+	case "", EmailDeliveryProviderSMTP:
+		err := e.SMTP.Validate()
+		if err != nil {
+			return fmt.Errorf("failed to validate smtp settings: %w", err)
+		}
+	case EmailDeliveryProviderHTTP:
+		err := e.HTTP.Validate()
+		if err != nil {
+			return fmt.Errorf("failed to validate http settings: %w", err)
+		}
+	case EmailDeliveryProviderFile:
+		err := e.File.Validate()
+		if err != nil {
+			return fmt.Errorf("failed to validate file settings: %w", err)
+		}
+	default:
+		return fmt.Errorf("provider must be one of '%s', '%s' or '%s'", EmailDeliveryProviderSMTP, EmailDeliveryProviderHTTP, EmailDeliveryProviderFile)
+	}
+
+	err := e.Queue.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate queue settings: %w", err)
+	}
+
+	return nil
+}
+
+// EmailDeliveryProvider is the backend used to deliver emails.
+type EmailDeliveryProvider string
+
+const (
+	EmailDeliveryProviderSMTP EmailDeliveryProvider = "smtp"
+	EmailDeliveryProviderHTTP EmailDeliveryProvider = "http"
+	EmailDeliveryProviderFile EmailDeliveryProvider = "file"
+)
+
+type EmailDeliveryHTTP struct {
+	// `url` is the URL emails are posted to. The request body is a JSON object containing the `from` (`address` and
+	// `name`), `to`, `subject`, `text` and `html` of the email. Any response with a status code other than 2xx is
+	// considered a failed delivery.
+	URL string `yaml:"url" json:"url,omitempty" koanf:"url"`
+	// `headers` are added to every request, e.g. to authenticate at the API.
+	Headers map[string]string `yaml:"headers" json:"headers,omitempty" koanf:"headers"`
+	// `timeout` is the maximum time a request may take.
+	Timeout time.Duration `yaml:"timeout" json:"timeout,omitempty" koanf:"timeout" jsonschema:"default=10s,type=string"`
+}
+
+func (h *EmailDeliveryHTTP) Validate() error {
+	if len(strings.TrimSpace(h.URL)) == 0 {
+		return errors.New("url must not be empty")
+	}
+	u, err := url.Parse(h.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url '%s' must be an absolute http(s) URL", h.URL)
+	}
+	if h.Timeout < 0 {
+		return errors.New("timeout must not be negative")
+	}
+	return nil
+}
+
+type EmailDeliveryFile struct {
+	// `directory` is the directory emails are written to. It is created if it does not exist.
+	Directory string `yaml:"directory" json:"directory,omitempty" koanf:"directory" jsonschema:"default=./emails"`
+	// `maildir` determines whether emails are written in the Maildir format, so the directory can be opened with a
+	// mail client. Otherwise every email is written to a separate `.eml` file.
+	Maildir bool `yaml:"maildir" json:"maildir,omitempty" koanf:"maildir" jsonschema:"default=false"`
+}
+
+func (f *EmailDeliveryFile) Validate() error {
+	if len(strings.TrimSpace(f.Directory)) == 0 {
+		return errors.New("directory must not be empty")
+	}
+	return nil
+}
+
+type EmailQueue struct {
+	// `enabled` determines whether emails are added to a persisted send queue and delivered in the background instead
+	// of being sent while handling the request. Failed deliveries are retried with an exponential backoff, so a
+	// temporarily unavailable mail server does not fail the request.
+	//
+	// Messages which could not be delivered within the maximum number of attempts are marked as failed and trigger
+	// the `email.failed` webhook event.
+	//
+	// The subject and bodies of queued messages are stored encrypted with the configured `secrets.keys`.
+	Enabled bool `yaml:"enabled" json:"enabled,omitempty" koanf:"enabled" jsonschema:"default=true"`
+	// `max_attempts` is the maximum number of delivery attempts for an email.
+	MaxAttempts int `yaml:"max_attempts" json:"max_attempts,omitempty" koanf:"max_attempts" split_words:"true" jsonschema:"default=5,minimum=1"`
+	// `retention` is the time sent and failed emails are kept in the queue before they are deleted. The content of
+	// an email is removed as soon as it has been sent or has failed, because it may contain passcodes.
+	Retention time.Duration `yaml:"retention" json:"retention,omitempty" koanf:"retention" jsonschema:"default=168h,type=string"`
+}
+
+func (q *EmailQueue) Validate() error {
+	if !q.Enabled {
+		return nil
+	}
+	if q.MaxAttempts < 1 {
+		return errors.New("max_attempts must be at least 1")
+	}
+	if q.Retention <= 0 {
+		return errors.New("retention must be greater than 0")
+	}
+	return nil
 }
 
 // SMTP Server Settings for sending passcodes
@@ -23,6 +158,12 @@ type SMTP struct {
 	Port     string `yaml:"port" json:"port,omitempty" koanf:"port" jsonschema:"default=465"`
 	User     string `yaml:"user" json:"user,omitempty" koanf:"user"`
 	Password string `yaml:"password" json:"password,omitempty" koanf:"password"`
+	// `tls` configures the TLS connection to the SMTP server.
+	TLS SMTPTLS `yaml:"tls" json:"tls,omitempty" koanf:"tls" jsonschema:"title=tls"`
+	// `pool` configures the reuse of connections to the SMTP server.
+	Pool SMTPPool `yaml:"pool" json:"pool,omitempty" koanf:"pool" jsonschema:"title=pool"`
+	// `dkim` configures the DKIM signing of outgoing emails.
+	DKIM DKIM `yaml:"dkim" json:"dkim,omitempty" koanf:"dkim" jsonschema:"title=dkim"`
 }
 
 func (s *SMTP) Validate() error {
@@ -32,5 +173,119 @@ func (s *SMTP) Validate() error {
 	if len(strings.TrimSpace(s.Port)) == 0 {
 		return errors.New("smtp port must not be empty")
 	}
+	err := s.TLS.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate smtp tls settings: %w", err)
+	}
+	err = s.Pool.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate smtp pool settings: %w", err)
+	}
+	err = s.DKIM.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate smtp dkim settings: %w", err)
+	}
+	return nil
+}
+
+// SMTPTLSMode determines how TLS is used for connections to the SMTP server.
+type SMTPTLSMode string
+
+const (
+	// SMTPTLSModeNone uses unencrypted connections. Credentials are only sent over unencrypted connections to
+	// localhost.
+	SMTPTLSModeNone SMTPTLSMode = "none"
+	// SMTPTLSModeStartTLS upgrades the connection using the STARTTLS command and fails if the server does not support
+	// it.
+	SMTPTLSModeStartTLS SMTPTLSMode = "starttls"
+	// SMTPTLSModeImplicit uses TLS from the start of the connection (also known as SMTPS), usually on port 465.
+	SMTPTLSModeImplicit SMTPTLSMode = "implicit"
+)
+
+type SMTPTLS struct {
+	// `mode` determines how TLS is used:
+	//
+	// - `none`: connections are not encrypted. Credentials are only sent over unencrypted connections to localhost.
+	// - `starttls`: connections are upgraded using STARTTLS. Sending fails if the server does not support STARTTLS.
+	// - `implicit`: TLS is used from the start of the connection, usually on port 465.
+	//
+	// If not set, `implicit` is used for port 465, otherwise STARTTLS is used if the server supports it.
+	Mode SMTPTLSMode `yaml:"mode" json:"mode,omitempty" koanf:"mode" jsonschema:"enum=none,enum=starttls,enum=implicit"`
+	// `ca_file` is the path to a PEM file containing the certificate authorities used to verify the certificate of
+	// the SMTP server. If not set, the certificate authorities of the system are used.
+	CAFile string `yaml:"ca_file" json:"ca_file,omitempty" koanf:"ca_file" split_words:"true"`
+	// `cert_file` is the path to a PEM file containing the client certificate presented to the SMTP server. Must be
+	// set together with `key_file`.
+	CertFile string `yaml:"cert_file" json:"cert_file,omitempty" koanf:"cert_file" split_words:"true"`
+	// `key_file` is the path to a PEM file containing the private key of the client certificate.
+	KeyFile string `yaml:"key_file" json:"key_file,omitempty" koanf:"key_file" split_words:"true"`
+	// `server_name` is the name used to verify the certificate of the SMTP server. Defaults to the `host`.
+	ServerName string `yaml:"server_name" json:"server_name,omitempty" koanf:"server_name" split_words:"true"`
+}
+
+func (t *SMTPTLS) Validate() error {
+	switch t.Mode {
+	case "", SMTPTLSModeNone, SMTPTLSModeStartTLS, SMTPTLSModeImplicit:
+	default:
+		return fmt.Errorf("mode must be one of '%s', '%s' or '%s'", SMTPTLSModeNone, SMTPTLSModeStartTLS, SMTPTLSModeImplicit)
+	}
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return errors.New("cert_file and key_file must be set together")
+	}
+	return nil
+}
+
+type SMTPPool struct {
+	// `enabled` determines whether connections to the SMTP server are kept open and reused for subsequent emails.
+	// Otherwise a new connection is established for every email.
+	Enabled bool `yaml:"enabled" json:"enabled,omitempty" koanf:"enabled" jsonschema:"default=true"`
+	// `max_connections` is the maximum number of concurrent connections to the SMTP server.
+	MaxConnections int `yaml:"max_connections" json:"max_connections,omitempty" koanf:"max_connections" split_words:"true" jsonschema:"default=2,minimum=1"`
+	// `idle_timeout` is the time after which an unused connection is closed. It should be lower than the idle
+	// timeout of the SMTP server.
+	IdleTimeout time.Duration `yaml:"idle_timeout" json:"idle_timeout,omitempty" koanf:"idle_timeout" split_words:"true" jsonschema:"default=30s,type=string"`
+}
+
+func (p *SMTPPool) Validate() error {
+	if !p.Enabled {
+		return nil
+	}
+	if p.MaxConnections < 1 {
+		return errors.New("max_connections must be at least 1")
+	}
+	if p.IdleTimeout <= 0 {
+		return errors.New("idle_timeout must be greater than 0")
+	}
+	return nil
+}
+
+type DKIM struct {
+	// `enabled` determines whether outgoing emails are signed using DKIM (RFC 6376).
+	Enabled bool `yaml:"enabled" json:"enabled,omitempty" koanf:"enabled" jsonschema:"default=false"`
+	// `domain` is the signing domain (`d=` tag), usually the domain of the `from_address`.
+	Domain string `yaml:"domain" json:"domain,omitempty" koanf:"domain"`
+	// `selector` is the selector (`s=` tag) of the public key published in DNS at
+	// `<selector>._domainkey.<domain>`.
+	Selector string `yaml:"selector" json:"selector,omitempty" koanf:"selector"`
+	// `private_key` is the PEM encoded RSA or Ed25519 private key used to sign emails. Either `private_key` or
+	// `private_key_file` must be set.
+	PrivateKey string `yaml:"private_key" json:"private_key,omitempty" koanf:"private_key" split_words:"true"`
+	// `private_key_file` is the path to a PEM file containing the RSA or Ed25519 private key used to sign emails.
+	PrivateKeyFile string `yaml:"private_key_file" json:"private_key_file,omitempty" koanf:"private_key_file" split_words:"true"`
+}
+
+func (d *DKIM) Validate() error {
+	if !d.Enabled {
+		return nil
+	}
+	if len(strings.TrimSpace(d.Domain)) == 0 {
+		return errors.New("domain must not be empty")
+	}
+	if len(strings.TrimSpace(d.Selector)) == 0 {
+		return errors.New("selector must not be empty")
+	}
+	if (d.PrivateKey == "") == (d.PrivateKeyFile == "") {
+		return errors.New("either private_key or private_key_file must be set")
+	}
 	return nil
 }
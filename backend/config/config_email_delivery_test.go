@@ -0,0 +1,91 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestEmailDelivery_Validate(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Modify        func(delivery *EmailDelivery)
+		ExpectedError string
+	}{
+		{
+			Name:   "default config",
+			Modify: func(delivery *EmailDelivery) {},
+		},
+		{
+			Name: "http provider",
+			Modify: func(delivery *EmailDelivery) {
+				delivery.Provider = EmailDeliveryProviderHTTP
+				delivery.HTTP.URL = "https://api.example.com/emails"
+			},
+		},
+		{
+			Name: "http provider without url",
+			Modify: func(delivery *EmailDelivery) {
+				delivery.Provider = EmailDeliveryProviderHTTP
+			},
+			ExpectedError: "url must not be empty",
+		},
+		{
+			Name: "http provider with relative url",
+			Modify: func(delivery *EmailDelivery) {
+				delivery.Provider = EmailDeliveryProviderHTTP
+				delivery.HTTP.URL = "/emails"
+			},
+			ExpectedError: "must be an absolute http(s) URL",
+		},
+		{
+			Name: "file provider without directory",
+			Modify: func(delivery *EmailDelivery) {
+				delivery.Provider = EmailDeliveryProviderFile
+				delivery.File.Directory = " "
+			},
+			ExpectedError: "directory must not be empty",
+		},
+		{
+			Name: "unknown provider",
+			Modify: func(delivery *EmailDelivery) {
+				delivery.Provider = "sendmail"
+			},
+			ExpectedError: "provider must be one of",
+		},
+		{
+			Name: "queue without attempts",
+			Modify: func(delivery *EmailDelivery) {
+				delivery.Queue.MaxAttempts = 0
+			},
+			ExpectedError: "max_attempts must be at least 1",
+		},
+		{
+			Name: "queue without retention",
+			Modify: func(delivery *EmailDelivery) {
+				delivery.Queue.Retention = 0 * time.Hour
+			},
+			ExpectedError: "retention must be greater than 0",
+		},
+		{
+			Name: "disabled queue is not validated",
+			Modify: func(delivery *EmailDelivery) {
+				delivery.Queue = EmailQueue{Enabled: false}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			delivery := DefaultConfig().EmailDelivery
+			test.Modify(&delivery)
+
+			err := delivery.Validate()
+			if test.ExpectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, test.ExpectedError)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/exp/slices"
+	"time"
+)
+
+type Flow struct {
+	// `store` configures where the state of the flows of the flow API is stored.
+	Store FlowStore `yaml:"store" json:"store,omitempty" koanf:"store" jsonschema:"title=store"`
+	// `cleanup` configures the periodic deletion of expired flows from the database. It does not apply to the `redis`
+	// store, where flows expire automatically.
+	Cleanup FlowCleanup `yaml:"cleanup" json:"cleanup,omitempty" koanf:"cleanup" jsonschema:"title=cleanup"`
+	// `login_onboarding` configures the onboarding steps of the login flow, e.g. acquiring a username or a
+	// credential after the user has authenticated.
+	//
+	// It does not apply to the registration flow, whose onboarding is configured with the `acquire_on_registration`
+	// options of `passkey`, `password`, `username` and `email`.
+	LoginOnboarding FlowOnboarding `yaml:"login_onboarding" json:"login_onboarding,omitempty" koanf:"login_onboarding" split_words:"true" jsonschema:"title=login_onboarding"`
+}
+
+func (f *Flow) Validate() error {
+	err := f.Store.Validate()
+	if err != nil {
+		return fmt.Errorf("store: %w", err)
+	}
+
+	err = f.Cleanup.Validate()
+	if err != nil {
+		return fmt.Errorf("cleanup: %w", err)
+	}
+
+	err = f.LoginOnboarding.Validate()
+	if err != nil {
+		return fmt.Errorf("login_onboarding: %w", err)
+	}
+
+	return nil
+}
+
+type FlowStoreType string
+
+const (
+	FlowStoreTypeDatabase FlowStoreType = "database"
+	FlowStoreTypeRedis    FlowStoreType = "redis"
+)
+
+type FlowStore struct {
+	// `type` determines where flows are stored.
+	//
+	// With `database`, flows are stored in the database, in the transaction of the flow step.
+	//
+	// With `redis`, flows are stored in Redis and expire automatically. Concurrent steps of the same flow are detected
+	// by the version of the flow, like with `database`. If the database transaction of a step fails, the flow is reset
+	// to its previous version.
+	Type FlowStoreType `yaml:"type" json:"type,omitempty" koanf:"type" jsonschema:"default=database,enum=database,enum=redis"`
+	// `redis_config` configures the connection to the Redis instance.
+	// Required if `type` is set to `redis`.
+	Redis *RedisConfig `yaml:"redis_config" json:"redis_config,omitempty" koanf:"redis_config"`
+}
+
+func (s *FlowStore) Validate() error {
+	switch s.Type {
+	case FlowStoreTypeDatabase:
+		return nil
+	case FlowStoreTypeRedis:
+		if s.Redis == nil || s.Redis.Address == "" {
+			return errors.New("redis_config with an address must be set when using the redis store")
+		}
+		return nil
+	default:
+		return fmt.Errorf("'%s' is not a valid flow store type", s.Type)
+	}
+}
+
+type FlowCleanup struct {
+	// `enabled` determines whether expired flows are deleted from the database periodically. When multiple instances
+	// share a database, only one of them deletes the expired flows per `interval`.
+	Enabled bool `yaml:"enabled" json:"enabled,omitempty" koanf:"enabled" jsonschema:"default=true"`
+	// `interval` is the interval in which expired flows are deleted.
+	Interval time.Duration `yaml:"interval" json:"interval,omitempty" koanf:"interval" jsonschema:"default=1h,type=string"`
+	// `batch_size` is the maximum number of flows deleted per statement.
+	BatchSize int `yaml:"batch_size" json:"batch_size,omitempty" koanf:"batch_size" split_words:"true" jsonschema:"default=1000,minimum=1"`
+}
+
+func (c *FlowCleanup) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.Interval <= 0 {
+		return errors.New("interval must be greater than 0")
+	}
+
+	if c.BatchSize < 1 {
+		return errors.New("batch_size must be at least 1")
+	}
+
+	return nil
+}
+
+type FlowOnboardingStep string
+
+const (
+	FlowOnboardingStepUsername    FlowOnboardingStep = "username"
+	FlowOnboardingStepEmail       FlowOnboardingStep = "email"
+	FlowOnboardingStepCredentials FlowOnboardingStep = "credentials"
+	FlowOnboardingStepPasskey     FlowOnboardingStep = "passkey"
+	FlowOnboardingStepPassword    FlowOnboardingStep = "password"
+)
+
+type FlowOnboarding struct {
+	// `steps` lists the onboarding steps in the order they are presented to the user. Steps not listed are removed
+	// from the flow.
+	//
+	// `username` and `email` acquire the username or email address if the user has none and the respective
+	// `acquire_on_login` option is enabled.
+	//
+	// `credentials` acquires a passkey and/or a password according to the `acquire_on_login` options of `passkey` and
+	// `password`, including the choice between both.
+	//
+	// `passkey` and `password` acquire a single credential if the user has none of that kind, regardless of the
+	// `acquire_on_login` options. They cannot be combined with `credentials`.
+	Steps []FlowOnboardingStep `yaml:"steps" json:"steps,omitempty" koanf:"steps" jsonschema:"default=username,default=email,default=credentials,enum=username,enum=email,enum=credentials,enum=passkey,enum=password"`
+}
+
+func (o *FlowOnboarding) Validate() error {
+	for i, step := range o.Steps {
+		switch step {
+		case FlowOnboardingStepUsername, FlowOnboardingStepEmail, FlowOnboardingStepCredentials,
+			FlowOnboardingStepPasskey, FlowOnboardingStepPassword:
+		default:
+			return fmt.Errorf("'%s' is not a valid onboarding step", step)
+		}
+
+		if slices.Contains(o.Steps[:i], step) {
+			return fmt.Errorf("onboarding step '%s' is listed more than once", step)
+		}
+	}
+
+	if o.HasStep(FlowOnboardingStepCredentials) &&
+		(o.HasStep(FlowOnboardingStepPasskey) || o.HasStep(FlowOnboardingStepPassword)) {
+		return errors.New("onboarding step 'credentials' cannot be combined with 'passkey' or 'password'")
+	}
+
+	return nil
+}
+
+// HasStep returns whether the given step is enabled.
+func (o *FlowOnboarding) HasStep(step FlowOnboardingStep) bool {
+	return slices.Contains(o.Steps, step)
+}
@@ -2,7 +2,9 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"github.com/invopop/jsonschema"
+	"time"
 )
 
 type Secrets struct {
@@ -13,8 +15,31 @@ type Secrets struct {
 	// JWK will then be used for signing JWTs. All tokens signed with the previous JWK(s) will still
 	// be valid until they expire. Removing a key from the list does not remove the corresponding
 	// database record. If you remove a key, you also have to remove the database record, otherwise
-	// application startup will fail.
+	// application startup will fail. To retire a key without removing the database records, first re-encrypt all
+	// records with the first key using the `hanko secrets rotate` command and then remove it from the list.
+	//
+	// Alternatively, JWKs can be rotated without changing this list, either using the `hanko jwk rotate` command
+	// or automatically by configuring a `jwk.rotation_interval`. Keys added to this list after a JWK rotation
+	// are used for encryption only, no additional JWK is generated for them.
 	Keys []string `yaml:"keys" json:"keys,omitempty" koanf:"keys" jsonschema:"minItems=1"`
+	// `jwk` configures the generation and rotation of the JWKs used to sign JWTs.
+	Jwk Jwk `yaml:"jwk" json:"jwk,omitempty" koanf:"jwk" jsonschema:"title=jwk"`
+}
+
+type Jwk struct {
+	// `algorithm` is the signature algorithm of newly generated JWKs. Changing the algorithm does not affect existing
+	// JWKs, rotate the JWKs to start signing with a key of the new type.
+	Algorithm string `yaml:"algorithm" json:"algorithm,omitempty" koanf:"algorithm" jsonschema:"default=RS256,enum=RS256,enum=ES256,enum=EdDSA"`
+	// `grace_period` determines how long a JWK is still published in the `/.well-known/jwks.json` after it has been
+	// retired by a rotation, so that tokens signed with it can still be verified. A JWK is retired once the JWK
+	// generated by the rotation is used for signing by all instances, i.e. a few minutes after the rotation. Once the
+	// grace period has passed the JWK is pruned from the database.
+	//
+	// Must be at least as long as the `session.lifespan`.
+	GracePeriod time.Duration `yaml:"grace_period" json:"grace_period,omitempty" koanf:"grace_period" split_words:"true" jsonschema:"default=24h,type=string"`
+	// `rotation_interval` enables scheduled JWK rotation. If set, the public API generates a new JWK as soon as the
+	// current signing key is older than the interval. A value of `0` disables scheduled rotation.
+	RotationInterval time.Duration `yaml:"rotation_interval" json:"rotation_interval,omitempty" koanf:"rotation_interval" split_words:"true" jsonschema:"default=0s,type=string"`
 }
 
 func (Secrets) JSONSchemaExtend(schema *jsonschema.Schema) {
@@ -27,9 +52,25 @@ func (Secrets) JSONSchemaExtend(schema *jsonschema.Schema) {
 	}
 }
 
-func (s *Secrets) Validate() error {
+func (s *Secrets) Validate(session Session) error {
 	if len(s.Keys) == 0 {
 		return errors.New("at least one key must be defined")
 	}
+
+	switch s.Jwk.Algorithm {
+	case "", "RS256", "ES256", "EdDSA":
+	default:
+		return fmt.Errorf("unsupported jwk algorithm: %s", s.Jwk.Algorithm)
+	}
+
+	if s.Jwk.RotationInterval < 0 {
+		return errors.New("jwk rotation_interval must not be negative")
+	}
+
+	lifespan, _ := time.ParseDuration(session.Lifespan) // error can be ignored, value is checked in session validation
+	if s.Jwk.GracePeriod < lifespan {
+		return fmt.Errorf("jwk grace_period must be at least as long as the session lifespan (%s)", session.Lifespan)
+	}
+
 	return nil
 }
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type SecurityNotifications struct {
+	// `email_deleted` configures notifications sent to the primary email address and the deleted email address of a
+	// user when an email address has been removed from their account.
+	EmailDeleted SecurityNotification `yaml:"email_deleted" json:"email_deleted,omitempty" koanf:"email_deleted" split_words:"true" jsonschema:"title=email_deleted"`
+	// `new_device_login` configures notifications sent to the primary email address of a user when their account is
+	// signed in from a device or IP address that does not match any of their recent sessions.
+	NewDeviceLogin NewDeviceLoginNotification `yaml:"new_device_login" json:"new_device_login,omitempty" koanf:"new_device_login" split_words:"true" jsonschema:"title=new_device_login"`
+	// `passkey_created` configures notifications sent to the primary email address of a user when a passkey has been
+	// added to their account.
+	PasskeyCreated SecurityNotification `yaml:"passkey_created" json:"passkey_created,omitempty" koanf:"passkey_created" split_words:"true" jsonschema:"title=passkey_created"`
+	// `passkey_deleted` configures notifications sent to the primary email address of a user when a passkey has been
+	// removed from their account.
+	PasskeyDeleted SecurityNotification `yaml:"passkey_deleted" json:"passkey_deleted,omitempty" koanf:"passkey_deleted" split_words:"true" jsonschema:"title=passkey_deleted"`
+	// `password_changed` configures notifications sent to the primary email address of a user when their password has
+	// been set, changed or recovered.
+	PasswordChanged SecurityNotification `yaml:"password_changed" json:"password_changed,omitempty" koanf:"password_changed" split_words:"true" jsonschema:"title=password_changed"`
+	// `primary_email_changed` configures notifications sent to the new and the previous primary email address of a
+	// user when their primary email address has been changed.
+	PrimaryEmailChanged SecurityNotification `yaml:"primary_email_changed" json:"primary_email_changed,omitempty" koanf:"primary_email_changed" split_words:"true" jsonschema:"title=primary_email_changed"`
+}
+
+func (s *SecurityNotifications) Validate(session Session, service Service) error {
+	err := s.NewDeviceLogin.Validate(session, service)
+	if err != nil {
+		return fmt.Errorf("failed to validate new_device_login settings: %w", err)
+	}
+	return nil
+}
+
+type SecurityNotification struct {
+	// `enabled` determines whether the notification is sent.
+	Enabled bool `yaml:"enabled" json:"enabled,omitempty" koanf:"enabled" jsonschema:"default=false"`
+}
+
+type NewDeviceLoginNotification struct {
+	// `enabled` determines whether a notification is sent when a user signs in from a new device or IP address.
+	//
+	// Requires server-side sessions (`session.server_side.enabled`) because the user agent and IP address of a login
+	// are compared against the stored sessions of the user. Also requires `service.api_url` to be set, because the
+	// notification contains a link to revoke the new session.
+	Enabled bool `yaml:"enabled" json:"enabled,omitempty" koanf:"enabled" jsonschema:"default=false"`
+	// `revocation_link_ttl` determines how long the session revocation link in the notification is valid.
+	// It must be a (possibly signed) sequence of decimal numbers, each with optional fraction and a unit suffix,
+	// such as "300ms", "-1.5h" or "2h45m". Valid time units are "ns", "us" (or "µs"), "ms", "s", "m", "h".
+	RevocationLinkTtl time.Duration `yaml:"revocation_link_ttl" json:"revocation_link_ttl,omitempty" koanf:"revocation_link_ttl" split_words:"true" jsonschema:"default=168h,type=string"`
+	// `revocation_redirect_url` is the URL the user is redirected to after a session has been revoked through the link
+	// in the notification. The link leads to a page on which the user must confirm the revocation first. The query
+	// parameter `session_revoked` indicates whether the session has been revoked.
+	//
+	// If not set, a JSON response is returned instead.
+	RevocationRedirectURL string `yaml:"revocation_redirect_url" json:"revocation_redirect_url,omitempty" koanf:"revocation_redirect_url" split_words:"true"`
+}
+
+func (n *NewDeviceLoginNotification) Validate(session Session, service Service) error {
+	if !n.Enabled {
+		return nil
+	}
+	if !session.ServerSide.Enabled {
+		return errors.New("server-side sessions must be enabled")
+	}
+	if service.ApiURL == "" {
+		return errors.New("service.api_url must be set")
+	}
+	if n.RevocationLinkTtl <= 0 {
+		return errors.New("revocation_link_ttl must be greater than zero")
+	}
+	return nil
+}
@@ -2,10 +2,16 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"net/url"
 	"strings"
 )
 
 type Service struct {
+	// `api_url` is the base URL under which the public API is reachable from the outside, e.g.
+	// `https://auth.example.com`. It is used to build links contained in emails sent to users (e.g. links to revoke a
+	// session).
+	ApiURL string `yaml:"api_url" json:"api_url,omitempty" koanf:"api_url" split_words:"true" jsonschema:"example=https://auth.example.com"`
 	// `name` determines the name of the service.
 	// This value is used, e.g. in the subject header of outgoing emails.
 	Name string `yaml:"name" json:"name,omitempty" koanf:"name"`
@@ -15,5 +21,10 @@ func (s *Service) Validate() error {
 	if len(strings.TrimSpace(s.Name)) == 0 {
 		return errors.New("field name must not be empty")
 	}
+	if s.ApiURL != "" {
+		if _, err := url.ParseRequestURI(s.ApiURL); err != nil {
+			return fmt.Errorf("api_url is not a valid URL: %w", err)
+		}
+	}
 	return nil
 }
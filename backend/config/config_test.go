@@ -6,6 +6,7 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestDefaultConfigAccountParameters(t *testing.T) {
@@ -93,3 +94,277 @@ func TestEnvironmentVariables(t *testing.T) {
 	assert.Equal(t, "valueFromEnvVars", cfg.Smtp.Host)
 	assert.True(t, reflect.DeepEqual([]string{"https://hanko.io", "https://auth.hanko.io"}, cfg.Webauthn.RelyingParty.Origins))
 }
+
+func TestNewDeviceLoginNotificationConfig(t *testing.T) {
+	configPath := "./minimal-config.yaml"
+	cfg, err := Load(&configPath)
+	require.NoError(t, err)
+
+	cfg.SecurityNotifications.NewDeviceLogin.Enabled = true
+	assert.Error(t, cfg.Validate(), "server-side sessions must be enabled")
+
+	cfg.Session.ServerSide.Enabled = true
+	assert.Error(t, cfg.Validate(), "service.api_url must be set")
+
+	cfg.Service.ApiURL = "https://auth.example.com"
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestJwkConfig(t *testing.T) {
+	configPath := "./minimal-config.yaml"
+	cfg, err := Load(&configPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, "RS256", cfg.Secrets.Jwk.Algorithm)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Secrets.Jwk.Algorithm = "HS256"
+	assert.Error(t, cfg.Validate(), "algorithm is not supported")
+
+	cfg.Secrets.Jwk.Algorithm = "EdDSA"
+	cfg.Secrets.Jwk.GracePeriod = time.Hour
+	assert.Error(t, cfg.Validate(), "grace period is shorter than the session lifespan")
+
+	cfg.Secrets.Jwk.GracePeriod = 12 * time.Hour
+	cfg.Secrets.Jwk.RotationInterval = 7 * 24 * time.Hour
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestAuditLogRetention_Validate(t *testing.T) {
+	retention := DefaultConfig().AuditLog.Retention
+	assert.NoError(t, retention.Validate())
+
+	retention.Enabled = true
+	retention.MaxAge = 90 * 24 * time.Hour
+	retention.Types = map[string]time.Duration{"password_login_failed": 7 * 24 * time.Hour}
+	assert.NoError(t, retention.Validate())
+
+	retention.Types["password_login_failed"] = -time.Hour
+	assert.Error(t, retention.Validate())
+
+	retention.Types = nil
+	retention.BatchSize = 0
+	assert.Error(t, retention.Validate())
+
+	retention.BatchSize = 1000
+	retention.Interval = 0
+	assert.Error(t, retention.Validate())
+}
+
+func TestAuditLogSink_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		sink    AuditLogSink
+		wantErr bool
+	}{
+		{
+			name: "valid file sink",
+			sink: AuditLogSink{Type: AuditLogSinkTypeFile, File: AuditLogFileSink{Path: "/var/log/hanko/audit.log"}},
+		},
+		{
+			name:    "file sink without path",
+			sink:    AuditLogSink{Type: AuditLogSinkTypeFile},
+			wantErr: true,
+		},
+		{
+			name: "valid syslog sink",
+			sink: AuditLogSink{Type: AuditLogSinkTypeSyslog, Format: AuditLogSinkFormatCEF, Syslog: AuditLogSyslogSink{Network: "tcp", Address: "localhost:514", Facility: "local4"}},
+		},
+		{
+			name:    "syslog sink without port",
+			sink:    AuditLogSink{Type: AuditLogSinkTypeSyslog, Syslog: AuditLogSyslogSink{Address: "localhost"}},
+			wantErr: true,
+		},
+		{
+			name:    "syslog sink with unknown facility",
+			sink:    AuditLogSink{Type: AuditLogSinkTypeSyslog, Syslog: AuditLogSyslogSink{Address: "localhost:514", Facility: "kern"}},
+			wantErr: true,
+		},
+		{
+			name: "valid http sink",
+			sink: AuditLogSink{Type: AuditLogSinkTypeHTTP, Format: AuditLogSinkFormatLEEF, HTTP: AuditLogHTTPSink{URL: "https://collector.example.com/ingest", OnFull: AuditLogHTTPSinkOnFullBlock}},
+		},
+		{
+			name:    "http sink with relative url",
+			sink:    AuditLogSink{Type: AuditLogSinkTypeHTTP, HTTP: AuditLogHTTPSink{URL: "/ingest"}},
+			wantErr: true,
+		},
+		{
+			name:    "http sink with unknown on_full",
+			sink:    AuditLogSink{Type: AuditLogSinkTypeHTTP, HTTP: AuditLogHTTPSink{URL: "https://collector.example.com", OnFull: "retry"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown format",
+			sink:    AuditLogSink{Type: AuditLogSinkTypeFile, Format: "xml", File: AuditLogFileSink{Path: "audit.log"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown type",
+			sink:    AuditLogSink{Type: "kafka"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.sink.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAuditLogSinks_Decode(t *testing.T) {
+	sinks := AuditLogSinks{}
+	err := sinks.Decode(`{"type":"file","file":{"path":"/var/log/hanko/audit.log"}};{"type":"syslog","format":"cef","syslog":{"address":"localhost:514"}}`)
+	require.NoError(t, err)
+	require.Len(t, sinks, 2)
+	assert.Equal(t, AuditLogSinkTypeFile, sinks[0].Type)
+	assert.Equal(t, "/var/log/hanko/audit.log", sinks[0].File.Path)
+	assert.Equal(t, AuditLogSinkFormatCEF, sinks[1].Format)
+	assert.Equal(t, "localhost:514", sinks[1].Syslog.Address)
+}
+
+func TestAuditLog_Validate_Integrity(t *testing.T) {
+	auditLog := DefaultConfig().AuditLog
+	auditLog.Integrity.Enabled = true
+	auditLog.Integrity.Checkpoints.Enabled = true
+	assert.NoError(t, auditLog.Validate())
+
+	auditLog.Integrity.Checkpoints.Interval = 0
+	assert.Error(t, auditLog.Validate())
+
+	auditLog.Integrity.Checkpoints.Interval = time.Hour
+	auditLog.Retention.Enabled = true
+	auditLog.Retention.MaxAge = 90 * 24 * time.Hour
+	assert.NoError(t, auditLog.Validate())
+
+	// per type retention would leave gaps in the hash chain
+	auditLog.Retention.Types = map[string]time.Duration{"password_login_failed": 7 * 24 * time.Hour}
+	assert.Error(t, auditLog.Validate())
+
+	// audit logs can be pruned manually even if the periodic pruning is disabled
+	auditLog.Retention.Enabled = false
+	assert.Error(t, auditLog.Validate())
+}
+
+func TestAuditLogFlowActions_Matches(t *testing.T) {
+	flowActions := AuditLogFlowActions{Enabled: true}
+	assert.True(t, flowActions.Matches("login", "verify_passcode"))
+
+	flowActions.Include = []string{"login/*", "*passcode*"}
+	assert.True(t, flowActions.Matches("login", "continue_with_login_identifier"))
+	assert.True(t, flowActions.Matches("registration", "verify_passcode"))
+	assert.False(t, flowActions.Matches("registration", "register_login_identifier"))
+
+	flowActions.Exclude = []string{"login/back"}
+	assert.False(t, flowActions.Matches("login", "back"))
+	assert.True(t, flowActions.Matches("login", "skip"))
+
+	assert.NoError(t, flowActions.Validate())
+	flowActions.Exclude = []string{"login/[back"}
+	assert.Error(t, flowActions.Validate())
+}
+
+func TestEmail_Validate_PasscodeMode(t *testing.T) {
+	email := DefaultConfig().Email
+	assert.Equal(t, PasscodeModeCode, email.PasscodeMode)
+	assert.NoError(t, email.Validate(Service{}))
+
+	email.PasscodeMode = PasscodeModeLink
+	assert.Error(t, email.Validate(Service{}))
+	assert.NoError(t, email.Validate(Service{ApiURL: "https://auth.example.com"}))
+
+	email.PasscodeLink.RedirectURL = "/login"
+	assert.Error(t, email.Validate(Service{ApiURL: "https://auth.example.com"}))
+	email.PasscodeLink.RedirectURL = "https://example.com/login"
+	assert.NoError(t, email.Validate(Service{ApiURL: "https://auth.example.com"}))
+
+	email.PasscodeMode = "magic"
+	assert.Error(t, email.Validate(Service{ApiURL: "https://auth.example.com"}))
+}
+
+func TestEmailVerificationLink_Validate(t *testing.T) {
+	verificationLink := DefaultConfig().Email.VerificationLink
+	assert.NoError(t, verificationLink.Validate(Service{}))
+
+	verificationLink.Enabled = true
+	assert.Error(t, verificationLink.Validate(Service{}))
+	assert.NoError(t, verificationLink.Validate(Service{ApiURL: "https://auth.example.com"}))
+
+	verificationLink.LinkTtl = 30 * time.Minute
+	assert.Error(t, verificationLink.Validate(Service{ApiURL: "https://auth.example.com"}))
+}
+
+func TestFlow_Validate(t *testing.T) {
+	flow := DefaultConfig().Flow
+	assert.NoError(t, flow.Validate())
+
+	flow.Store.Type = FlowStoreTypeRedis
+	assert.Error(t, flow.Validate())
+
+	flow.Store.Redis = &RedisConfig{Address: "localhost:6379"}
+	assert.NoError(t, flow.Validate())
+
+	flow.Store.Type = "memcached"
+	assert.Error(t, flow.Validate())
+
+	flow.Store.Type = FlowStoreTypeDatabase
+	flow.Cleanup.BatchSize = 0
+	assert.Error(t, flow.Validate())
+
+	flow.Cleanup.Enabled = false
+	assert.NoError(t, flow.Validate())
+}
+
+func TestFlowOnboarding_Validate(t *testing.T) {
+	onboarding := DefaultConfig().Flow.LoginOnboarding
+	assert.NoError(t, onboarding.Validate())
+	assert.True(t, onboarding.HasStep(FlowOnboardingStepCredentials))
+	assert.False(t, onboarding.HasStep(FlowOnboardingStepPasskey))
+
+	onboarding.Steps = []FlowOnboardingStep{FlowOnboardingStepPasskey, FlowOnboardingStepUsername}
+	assert.NoError(t, onboarding.Validate())
+
+	onboarding.Steps = nil
+	assert.NoError(t, onboarding.Validate())
+
+	onboarding.Steps = []FlowOnboardingStep{"phone"}
+	assert.Error(t, onboarding.Validate())
+
+	onboarding.Steps = []FlowOnboardingStep{FlowOnboardingStepEmail, FlowOnboardingStepEmail}
+	assert.Error(t, onboarding.Validate())
+
+	onboarding.Steps = []FlowOnboardingStep{FlowOnboardingStepCredentials, FlowOnboardingStepPassword}
+	assert.Error(t, onboarding.Validate())
+}
+
+func TestFlowOnboarding_Load(t *testing.T) {
+	configPath := "./flow-onboarding-config.yaml"
+	cfg, err := Load(&configPath)
+	assert.NoError(t, err)
+	assert.Equal(t, []FlowOnboardingStep{FlowOnboardingStepPasskey, FlowOnboardingStepUsername}, cfg.Flow.LoginOnboarding.Steps)
+
+	configPath = "./minimal-config.yaml"
+	cfg, err = Load(&configPath)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultConfig().Flow.LoginOnboarding.Steps, cfg.Flow.LoginOnboarding.Steps)
+}
+
+func TestTracing_Validate(t *testing.T) {
+	tracing := DefaultConfig().Tracing
+	assert.NoError(t, tracing.Validate())
+
+	tracing.Enabled = true
+	assert.Error(t, tracing.Validate())
+
+	tracing.Endpoint = "localhost:4318"
+	assert.NoError(t, tracing.Validate())
+
+	tracing.SampleRatio = 1.5
+	assert.Error(t, tracing.Validate())
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"errors"
+)
+
+type Tracing struct {
+	// `enabled` determines whether traces of requests, flow executions and outgoing calls are exported via the
+	// OpenTelemetry protocol (OTLP).
+	Enabled bool `yaml:"enabled" json:"enabled,omitempty" koanf:"enabled" jsonschema:"default=false"`
+	// `endpoint` is the host and port of the OTLP/HTTP collector the traces are sent to, e.g. `localhost:4318`.
+	// Required if `enabled` is set to `true`.
+	Endpoint string `yaml:"endpoint" json:"endpoint,omitempty" koanf:"endpoint" jsonschema:"example=localhost:4318"`
+	// `insecure` determines whether traces are sent via HTTP instead of HTTPS.
+	Insecure bool `yaml:"insecure" json:"insecure,omitempty" koanf:"insecure" jsonschema:"default=false"`
+	// `headers` are added to every export request, e.g. to authenticate with the collector.
+	Headers map[string]string `yaml:"headers" json:"headers,omitempty" koanf:"headers"`
+	// `sample_ratio` is the ratio of traces which are sampled, between `0` and `1`. Traces continued from a caller
+	// keep the sampling decision of the caller.
+	SampleRatio float64 `yaml:"sample_ratio" json:"sample_ratio,omitempty" koanf:"sample_ratio" split_words:"true" jsonschema:"default=1,minimum=0,maximum=1"`
+	// `service_name` is the name of the service the traces are reported for.
+	ServiceName string `yaml:"service_name" json:"service_name,omitempty" koanf:"service_name" split_words:"true" jsonschema:"default=hanko"`
+}
+
+func (t *Tracing) Validate() error {
+	if !t.Enabled {
+		return nil
+	}
+
+	if t.Endpoint == "" {
+		return errors.New("endpoint must be set when tracing is enabled")
+	}
+
+	if t.SampleRatio < 0 || t.SampleRatio > 1 {
+		return errors.New("sample_ratio must be between 0 and 1")
+	}
+
+	if t.ServiceName == "" {
+		return errors.New("service_name must not be empty")
+	}
+
+	return nil
+}
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/invopop/jsonschema"
 	"github.com/teamhanko/hanko/backend/webhooks/events"
@@ -15,6 +17,12 @@ type WebhookSettings struct {
 	AllowTimeExpiration bool `yaml:"allow_time_expiration" json:"allow_time_expiration,omitempty" koanf:"allow_time_expiration" jsonschema:"default=false"`
 	// `enabled` enables the webhook feature.
 	Enabled bool `yaml:"enabled" json:"enabled,omitempty" koanf:"enabled" jsonschema:"default=false"`
+	// `max_attempts` is the maximum number of delivery attempts for a webhook message. Failed deliveries are
+	// retried with an exponential backoff. Messages which could not be delivered within the maximum number of
+	// attempts are marked as failed and the failure counter of the webhook is increased.
+	MaxAttempts int `yaml:"max_attempts" json:"max_attempts,omitempty" koanf:"max_attempts" split_words:"true" jsonschema:"default=8,minimum=1"`
+	// `workers` is the number of webhook messages delivered concurrently by every instance.
+	Workers int `yaml:"workers" json:"workers,omitempty" koanf:"workers" jsonschema:"default=4,minimum=1"`
 	// `hooks` is a list of Webhook configurations.
 	//
 	// When using environment variables the value for the `WEBHOOKS_HOOKS` key must be specified in the following
@@ -24,6 +32,14 @@ type WebhookSettings struct {
 }
 
 func (ws *WebhookSettings) Validate() error {
+	if ws.MaxAttempts < 1 {
+		return errors.New("max_attempts must be at least 1")
+	}
+
+	if ws.Workers < 1 {
+		return errors.New("workers must be at least 1")
+	}
+
 	if ws.Enabled {
 		for _, hook := range ws.Hooks {
 			err := hook.Validate()
@@ -57,11 +73,55 @@ func (wd *Webhooks) Decode(value string) error {
 	return nil
 }
 
+// WebhookFormat is the format of the body of webhook requests.
+type WebhookFormat string
+
+const (
+	// WebhookFormatHankoJWT sends a `{"token": "...", "event": "..."}` body. The event data is contained in the
+	// signed JWT.
+	WebhookFormatHankoJWT WebhookFormat = "hanko-jwt"
+	// WebhookFormatCloudEvents sends a CloudEvents 1.0 event in structured content mode with the plain event data.
+	WebhookFormatCloudEvents WebhookFormat = "cloudevents"
+)
+
+// IsValid reports whether the format is known. An empty format is valid and equals WebhookFormatHankoJWT.
+func (f WebhookFormat) IsValid() bool {
+	switch f {
+	case "", WebhookFormatHankoJWT, WebhookFormatCloudEvents:
+		return true
+	default:
+		return false
+	}
+}
+
 type Webhook struct {
 	// `callback` specifies the URL to which the change data will be sent.
 	Callback string `yaml:"callback" json:"callback,omitempty" koanf:"callback"`
 	// `events` is a list of events this hook listens for.
 	Events events.Events `yaml:"events" json:"events,omitempty" koanf:"events" jsonschema:"title=events"`
+	// `format` determines the format of the request body.
+	//
+	// `hanko-jwt` sends a JSON object with the `event` and a `token`, a JWT signed with the keys of the JWKS
+	// containing the event data in its `data` claim.
+	//
+	// `cloudevents` sends a CloudEvents 1.0 event in structured content mode (`application/cloudevents+json`)
+	// containing the plain event data.
+	Format WebhookFormat `yaml:"format" json:"format,omitempty" koanf:"format" jsonschema:"default=hanko-jwt,enum=hanko-jwt,enum=cloudevents"`
+	// `secret` is used to sign the requests of the hook following the Standard Webhooks specification. It must be
+	// a base64 encoded random value of at least 24 bytes, optionally prefixed with `whsec_`. Requests are not
+	// signed if no secret is set.
+	//
+	// A secret is required for the `cloudevents` format, as its requests do not contain a signed JWT and could not
+	// be authenticated otherwise.
+	Secret string `yaml:"secret" json:"secret,omitempty" koanf:"secret"`
+}
+
+// SigningSecrets returns the secrets used to sign requests of the hook.
+func (w *Webhook) SigningSecrets() []string {
+	if w.Secret == "" {
+		return nil
+	}
+	return []string{w.Secret}
 }
 
 func (Webhook) JSONSchemaExtend(schema *jsonschema.Schema) {
@@ -84,18 +144,46 @@ func (Webhook) JSONSchemaExtend(schema *jsonschema.Schema) {
 		"user.update.email.create",
 		"user.update.email.delete",
 		"user.update.email.primary",
+		"user.update.password",
+		"user.update.passkey",
+		"user.update.passkey.create",
+		"user.update.passkey.delete",
+		"user.update.username",
+		"user.update.identity",
+		"user.update.identity.link",
+		"user.update.identity.unlink",
+		"user.login",
+		"user.login.failed",
+		"session",
+		"session.create",
+		"session.revoke",
 		"email.send",
+		"email.failed",
 	}
 	evts.Items.Extras = map[string]any{"meta:enum": map[string]string{
-		"user":                      "Triggers on: user creation, user deletion, user update, email creation, email deletion, change of primary email",
-		"user.create":               "Triggers on: user creation",
-		"user.delete":               "Triggers on: user deletion",
-		"user.update":               "Triggers on: user update, email creation, email deletion, change of primary email",
-		"user.update.email":         "Triggers on: email creation, email deletion, change of primary email",
-		"user.update.email.create":  "Triggers on: email creation",
-		"user.update.email.delete":  "Triggers on: email deletion",
-		"user.update.email.primary": "Triggers on: change of primary email",
-		"email.send":                "Triggers on: an email was sent or should be sent",
+		"user":                        "Triggers on: user creation, user deletion, user update, email creation, email deletion, change of primary email, password change, passkey creation, passkey deletion, username change, identity linking, identity unlinking",
+		"user.create":                 "Triggers on: user creation",
+		"user.delete":                 "Triggers on: user deletion",
+		"user.update":                 "Triggers on: user update, email creation, email deletion, change of primary email, password change, passkey creation, passkey deletion, username change, identity linking, identity unlinking",
+		"user.update.email":           "Triggers on: email creation, email deletion, change of primary email",
+		"user.update.email.create":    "Triggers on: email creation",
+		"user.update.email.delete":    "Triggers on: email deletion",
+		"user.update.email.primary":   "Triggers on: change of primary email",
+		"user.update.password":        "Triggers on: password creation, password change, password deletion",
+		"user.update.passkey":         "Triggers on: passkey creation, passkey deletion",
+		"user.update.passkey.create":  "Triggers on: passkey creation",
+		"user.update.passkey.delete":  "Triggers on: passkey deletion",
+		"user.update.username":        "Triggers on: username creation, username change, username deletion",
+		"user.update.identity":        "Triggers on: identity linking, identity unlinking",
+		"user.update.identity.link":   "Triggers on: a third party identity was linked to a user",
+		"user.update.identity.unlink": "Triggers on: a third party identity was removed from a user",
+		"user.login":                  "Triggers on: login, failed login",
+		"user.login.failed":           "Triggers on: failed login",
+		"session":                     "Triggers on: session creation, session revocation",
+		"session.create":              "Triggers on: session creation",
+		"session.revoke":              "Triggers on: session revocation",
+		"email.send":                  "Triggers on: an email was sent or should be sent",
+		"email.failed":                "Triggers on: an email from the send queue could not be delivered",
 	}}
 }
 
@@ -105,6 +193,19 @@ func (w *Webhook) Validate() error {
 		return fmt.Errorf("callback is not a valid URL: %w", err)
 	}
 
+	err = validateSigningSecret(w.Secret)
+	if err != nil {
+		return err
+	}
+
+	if !w.Format.IsValid() {
+		return fmt.Errorf("format must be one of '%s' or '%s'", WebhookFormatHankoJWT, WebhookFormatCloudEvents)
+	}
+
+	if w.Format == WebhookFormatCloudEvents && w.Secret == "" {
+		return fmt.Errorf("a secret is required for the '%s' format", WebhookFormatCloudEvents)
+	}
+
 	if len(w.Events) > 0 {
 		for i, e := range w.Events {
 			isValid := events.IsValidEvent(e)
@@ -116,3 +217,21 @@ func (w *Webhook) Validate() error {
 
 	return nil
 }
+
+// validateSigningSecret validates a secret used to sign requests following the Standard Webhooks specification. An
+// empty secret is valid.
+func validateSigningSecret(secret string) error {
+	if secret == "" {
+		return nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(secret, "whsec_"))
+	if err != nil {
+		return fmt.Errorf("secret is not base64 encoded: %w", err)
+	}
+	if len(key) < 24 {
+		return errors.New("secret must be at least 24 bytes long")
+	}
+
+	return nil
+}
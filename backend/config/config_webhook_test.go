@@ -2,6 +2,7 @@ package config
 
 import (
 	"github.com/stretchr/testify/assert"
+	"github.com/teamhanko/hanko/backend/webhooks/events"
 	"testing"
 )
 
@@ -16,3 +17,37 @@ func TestWebhooks_Decode(t *testing.T) {
 		assert.IsType(t, Webhook{}, webhook)
 	}
 }
+
+func TestWebhook_ValidateSecret(t *testing.T) {
+	webhook := Webhook{
+		Callback: "http://app.com/usercb",
+		Events:   events.Events{events.User},
+	}
+	assert.NoError(t, webhook.Validate())
+
+	webhook.Secret = "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw"
+	assert.NoError(t, webhook.Validate())
+
+	webhook.Secret = "whsec_not base64"
+	assert.Error(t, webhook.Validate())
+
+	webhook.Secret = "whsec_c2hvcnQ="
+	assert.Error(t, webhook.Validate(), "secret is too short")
+}
+
+func TestWebhook_ValidateFormat(t *testing.T) {
+	webhook := Webhook{
+		Callback: "http://app.com/usercb",
+		Events:   events.Events{events.User},
+	}
+	assert.NoError(t, webhook.Validate())
+
+	webhook.Format = WebhookFormatCloudEvents
+	assert.Error(t, webhook.Validate(), "cloudevents requests must be signed")
+
+	webhook.Secret = "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw"
+	assert.NoError(t, webhook.Validate())
+
+	webhook.Format = "xml"
+	assert.Error(t, webhook.Validate())
+}
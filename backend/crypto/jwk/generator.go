@@ -1,9 +1,48 @@
 package jwk
 
-import "github.com/lestrrat-go/jwx/v2/jwk"
+import (
+	"fmt"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+)
 
 // KeyGenerator Interface for JSON Web Key Generation
 type KeyGenerator interface {
 	// Generate a new JWK with a given id
 	Generate(id string) (jwk.Key, error)
 }
+
+// NewKeyGenerator returns a KeyGenerator for keys which can be used with the given signature algorithm.
+// Supported algorithms are "RS256", "ES256" and "EdDSA".
+func NewKeyGenerator(algorithm string) (KeyGenerator, error) {
+	switch jwa.SignatureAlgorithm(algorithm) {
+	case jwa.RS256:
+		return &RSAKeyGenerator{}, nil
+	case jwa.ES256:
+		return &ECDSAKeyGenerator{}, nil
+	case jwa.EdDSA:
+		return &Ed25519KeyGenerator{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key algorithm: %s", algorithm)
+	}
+}
+
+// withSignatureKeyAttributes sets the key ID, the algorithm and the usage of the given signature key
+func withSignatureKeyAttributes(key jwk.Key, id string, algorithm jwa.SignatureAlgorithm) (jwk.Key, error) {
+	err := key.Set(jwk.KeyIDKey, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = key.Set(jwk.AlgorithmKey, algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	err = key.Set(jwk.KeyUsageKey, jwk.ForSignature)
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
@@ -0,0 +1,27 @@
+package jwk
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+)
+
+// ECDSAKeyGenerator generates P-256 keys for signing with ES256
+type ECDSAKeyGenerator struct {
+}
+
+func (g *ECDSAKeyGenerator) Generate(id string) (jwk.Key, error) {
+	rawKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := jwk.FromRaw(rawKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return withSignatureKeyAttributes(key, id, jwa.ES256)
+}
@@ -0,0 +1,26 @@
+package jwk
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+)
+
+// Ed25519KeyGenerator generates Ed25519 keys for signing with EdDSA
+type Ed25519KeyGenerator struct {
+}
+
+func (g *Ed25519KeyGenerator) Generate(id string) (jwk.Key, error) {
+	_, rawKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := jwk.FromRaw(rawKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return withSignatureKeyAttributes(key, id, jwa.EdDSA)
+}
@@ -24,20 +24,5 @@ func (g *RSAKeyGenerator) Generate(id string) (jwk.Key, error) {
 		return nil, err
 	}
 
-	err = key.Set(jwk.KeyIDKey, id)
-	if err != nil {
-		return nil, err
-	}
-
-	err = key.Set(jwk.AlgorithmKey, jwa.RS256)
-	if err != nil {
-		return nil, err
-	}
-
-	err = key.Set(jwk.KeyUsageKey, jwk.ForSignature)
-	if err != nil {
-		return nil, err
-	}
-
-	return key, nil
+	return withSignatureKeyAttributes(key, id, jwa.RS256)
 }
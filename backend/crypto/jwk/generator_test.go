@@ -12,8 +12,8 @@ import (
 
 func TestGenerator(t *testing.T) {
 	for k, c := range []struct {
-		g    KeyGenerator
-		name string
+		g     KeyGenerator
+		name  string
 		check func(ks jwk.Key)
 	}{
 		{
@@ -33,6 +33,30 @@ func TestGenerator(t *testing.T) {
 				t.Logf("%s\n", buf)
 			},
 		},
+		{
+			g:    &ECDSAKeyGenerator{},
+			name: "generate_ecdsa_jwk",
+			check: func(ks jwk.Key) {
+				ecdsaKey, ok := (ks).(jwk.ECDSAPrivateKey)
+				require.True(t, ok)
+				assert.Equal(t, "my_key_id", ecdsaKey.KeyID())
+				assert.Equal(t, jwa.EC, ecdsaKey.KeyType())
+				assert.Equal(t, jwa.P256, ecdsaKey.Crv())
+				assert.Equal(t, jwa.ES256, ecdsaKey.Algorithm())
+			},
+		},
+		{
+			g:    &Ed25519KeyGenerator{},
+			name: "generate_ed25519_jwk",
+			check: func(ks jwk.Key) {
+				okpKey, ok := (ks).(jwk.OKPPrivateKey)
+				require.True(t, ok)
+				assert.Equal(t, "my_key_id", okpKey.KeyID())
+				assert.Equal(t, jwa.OKP, okpKey.KeyType())
+				assert.Equal(t, jwa.Ed25519, okpKey.Crv())
+				assert.Equal(t, jwa.EdDSA, okpKey.Algorithm())
+			},
+		},
 	} {
 		t.Run(fmt.Sprintf("case=%d - %v", k, c.name), func(t *testing.T) {
 			keys, err := c.g.Generate("my_key_id")
@@ -43,3 +67,14 @@ func TestGenerator(t *testing.T) {
 		})
 	}
 }
+
+func TestNewKeyGenerator(t *testing.T) {
+	for _, algorithm := range []string{"RS256", "ES256", "EdDSA"} {
+		g, err := NewKeyGenerator(algorithm)
+		assert.NoError(t, err)
+		assert.NotNil(t, g)
+	}
+
+	_, err := NewKeyGenerator("HS256")
+	assert.Error(t, err)
+}
@@ -2,98 +2,392 @@ package jwk
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/gofrs/uuid"
 	"github.com/lestrrat-go/jwx/v2/jwk"
 	"github.com/teamhanko/hanko/backend/crypto/aes_gcm"
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"golang.org/x/exp/slices"
+	"sync"
 	"time"
 )
 
+const (
+	// keyCacheTTL is the maximum time a DefaultManager uses its cached keys before reloading them from the database.
+	keyCacheTTL = 1 * time.Minute
+	// KeyActivationDelay is the time a newly generated JWK is only published before it is used for signing. The delay
+	// ensures all instances have picked up the new key (see keyCacheTTL) before the first token signed with it is
+	// issued.
+	KeyActivationDelay = 2 * keyCacheTTL
+	// lockName is the name of the scheduler lock serializing the creation and rotation of keys across instances
+	lockName = "jwk"
+	// lockDuration is the time a manager holds the lock after acquiring it
+	lockDuration = 30 * time.Second
+	// lockPollInterval is the interval in which a manager waiting for the initial keys checks whether they have
+	// been created by the instance holding the lock
+	lockPollInterval = 500 * time.Millisecond
+)
+
+// ErrLocked is returned when the keys are being created or rotated by another instance.
+var ErrLocked = errors.New("jwks are being changed by another instance")
+
 type Manager interface {
 	// GenerateKey is used to generate a jwk Key
 	GenerateKey() (jwk.Key, error)
-	// GetPublicKeys returns all Public keys that are persisted
+	// GetPublicKeys returns all Public keys that are persisted and not yet retired for longer than the grace period
 	GetPublicKeys() (jwk.Set, error)
-	// GetSigningKey returns the last added private key that is used for signing
+	// GetSigningKey returns the most recently added private key that is active and used for signing
 	GetSigningKey() (jwk.Key, error)
 }
 
 type DefaultManager struct {
-	encrypter *aes_gcm.AESGCM
-	persister persistence.JwkPersister
+	encrypter   *aes_gcm.AESGCM
+	persister   persistence.JwkPersister
+	generator   KeyGenerator
+	gracePeriod time.Duration
+	// retainedKeys returns the IDs of retired keys which must not be pruned
+	retainedKeys func() ([]string, error)
+
+	lock        persistence.SchedulerLockPersister
+	lockHolder  string
+	lockedUntil time.Time
+	lockMutex   sync.Mutex
+
+	mutex    sync.Mutex
+	cache    *keyCache
+	cachedAt time.Time
+}
+
+type keyCache struct {
+	signingKey jwk.Key
+	publicKeys jwk.Set
+}
+
+type ManagerOption func(*DefaultManager) error
+
+// WithKeyAlgorithm sets the signature algorithm of the keys generated by the manager. Defaults to "RS256".
+func WithKeyAlgorithm(algorithm string) ManagerOption {
+	return func(m *DefaultManager) error {
+		if algorithm == "" {
+			return nil
+		}
+		generator, err := NewKeyGenerator(algorithm)
+		if err != nil {
+			return err
+		}
+		m.generator = generator
+		return nil
+	}
+}
+
+// WithGracePeriod sets how long retired keys are still published and used for verification before they are pruned.
+func WithGracePeriod(gracePeriod time.Duration) ManagerOption {
+	return func(m *DefaultManager) error {
+		m.gracePeriod = gracePeriod
+		return nil
+	}
+}
+
+// WithLock sets the scheduler lock used to serialize the creation of the initial keys and rotations across
+// instances. Without a lock, managers created concurrently on an empty database can create duplicate initial keys and
+// concurrent rotations can leave multiple active keys.
+func WithLock(lock persistence.SchedulerLockPersister) ManagerOption {
+	return func(m *DefaultManager) error {
+		m.lock = lock
+		return nil
+	}
+}
+
+// WithRetainedKeys sets a function returning the IDs of keys which must not be pruned, because they are still needed to
+// verify signatures other than those of tokens (e.g. audit log checkpoints). Retained keys are not published after
+// the grace period, see GetAllPublicKeys.
+func WithRetainedKeys(retainedKeys func() ([]string, error)) ManagerOption {
+	return func(m *DefaultManager) error {
+		m.retainedKeys = retainedKeys
+		return nil
+	}
 }
 
 // Returns a DefaultManager that reads and persists the jwks to database and generates jwks if a new secret gets added to the config.
-func NewDefaultManager(keys []string, persister persistence.JwkPersister) (*DefaultManager, error) {
+func NewDefaultManager(keys []string, persister persistence.JwkPersister, options ...ManagerOption) (*DefaultManager, error) {
 	encrypter, err := aes_gcm.NewAESGCM(keys)
 	if err != nil {
 		return nil, err
 	}
+	holder, _ := uuid.NewV4()
 	manager := &DefaultManager{
-		encrypter: encrypter,
-		persister: persister,
-	}
-	// for every key we should check if a jwk with index exists and create one if not.
-	for i := range keys {
-		j, err := persister.Get(i + 1)
-		if j == nil && err == nil {
-			_, err := manager.GenerateKey()
-			if err != nil {
-				return nil, err
-			}
-		} else if err != nil {
+		encrypter:  encrypter,
+		persister:  persister,
+		generator:  &RSAKeyGenerator{},
+		lockHolder: holder.String(),
+	}
+	for _, option := range options {
+		err = option(manager)
+		if err != nil {
 			return nil, err
 		}
 	}
 
+	err = manager.createMissingKeys(len(keys))
+	if err != nil {
+		return nil, err
+	}
+
 	return manager, nil
 }
 
+// createMissingKeys makes sure a jwk exists for each of the given number of keys. If the manager has a lock, the keys
+// are only created by the instance holding the lock, all other instances wait until the keys have been created.
+func (m *DefaultManager) createMissingKeys(count int) error {
+	deadline := time.Now().Add(2 * lockDuration)
+	for {
+		missing, err := m.countMissingKeys(count)
+		if err != nil || missing == 0 {
+			return err
+		}
+
+		err = m.acquireLock()
+		if errors.Is(err, ErrLocked) && time.Now().Before(deadline) {
+			time.Sleep(lockPollInterval)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		// another instance might have created the keys before the lock has been acquired
+		missing, err = m.countMissingKeys(count)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < missing; i++ {
+			_, err = m.GenerateKey()
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// countMissingKeys returns the number of jwks to create for the given number of keys. Jwks are numbered
+// consecutively, so a new jwk must only be created for keys whose index exceeds the id of the last jwk. Jwks created by
+// a rotation count as well, which ensures that pruning retired jwks does not lead to new ones being generated on
+// startup.
+func (m *DefaultManager) countMissingKeys(count int) (int, error) {
+	last, err := m.persister.GetLast()
+	if err != nil {
+		return 0, err
+	}
+	lastID := 0
+	if last != nil {
+		lastID = last.ID
+	}
+	if lastID >= count {
+		return 0, nil
+	}
+	return count - lastID, nil
+}
+
+// acquireLock acquires the lock of the manager, unless the manager holds it already. It returns ErrLocked when the
+// lock is held by another instance.
+func (m *DefaultManager) acquireLock() error {
+	if m.lock == nil {
+		return nil
+	}
+
+	m.lockMutex.Lock()
+	defer m.lockMutex.Unlock()
+
+	now := time.Now()
+	// keep using the lock only while it is held long enough for the caller to finish
+	if now.Add(lockDuration / 2).Before(m.lockedUntil) {
+		return nil
+	}
+
+	lockedUntil := now.Add(lockDuration)
+	acquired, err := m.lock.Acquire(lockName, m.lockHolder, now, lockedUntil)
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return ErrLocked
+	}
+	m.lockedUntil = lockedUntil
+
+	return nil
+}
+
 func (m *DefaultManager) GenerateKey() (jwk.Key, error) {
-	rsa := &RSAKeyGenerator{}
-	id, _ := uuid.NewV4()
-	key, err := rsa.Generate(id.String())
+	key, model, err := m.generateKey()
 	if err != nil {
 		return nil, err
 	}
-	marshalled, err := json.Marshal(key)
+	err = m.persister.Create(*model)
 	if err != nil {
 		return nil, err
 	}
+	m.invalidateCache()
+	return key, nil
+}
+
+func (m *DefaultManager) generateKey() (jwk.Key, *models.Jwk, error) {
+	id, _ := uuid.NewV4()
+	key, err := m.generator.Generate(id.String())
+	if err != nil {
+		return nil, nil, err
+	}
+	marshalled, err := json.Marshal(key)
+	if err != nil {
+		return nil, nil, err
+	}
 	encryptedKey, err := m.encrypter.Encrypt(marshalled)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	model := models.Jwk{
+	model := &models.Jwk{
 		KeyData:   encryptedKey,
 		CreatedAt: time.Now(),
 	}
-	err = m.persister.Create(model)
+	return key, model, nil
+}
+
+// Rotate generates a new key and retires all other keys. The new key is used for signing once the
+// KeyActivationDelay has passed, so the other keys are retired as of the time the last token is signed with them,
+// i.e. once the new key is active and all instances have reloaded their keys. Retired keys are still published for
+// the grace period after that time, so tokens signed with them can still be verified. Keys whose grace period has
+// passed are pruned.
+//
+// Concurrent rotations would leave multiple active keys. If the manager has a lock (see WithLock), Rotate returns
+// ErrLocked while another instance creates or rotates the keys.
+func (m *DefaultManager) Rotate() (jwk.Key, error) {
+	err := m.acquireLock()
 	if err != nil {
 		return nil, err
 	}
-	return key, nil
-}
 
-func (m *DefaultManager) GetSigningKey() (jwk.Key, error) {
-	sigModel, err := m.persister.GetLast()
+	key, model, err := m.generateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	modelList, err := m.persister.GetAll()
 	if err != nil {
 		return nil, err
 	}
-	k, err := m.encrypter.Decrypt(sigModel.KeyData)
+
+	now := time.Now()
+	retiredAt := now.Add(KeyActivationDelay + keyCacheTTL)
+	for _, existing := range modelList {
+		if existing.RetiredAt == nil {
+			existing.RetiredAt = &retiredAt
+			err = m.persister.Update(existing)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	model.CreatedAt = now
+	err = m.persister.Create(*model)
 	if err != nil {
 		return nil, err
 	}
+	m.invalidateCache()
 
-	key, err := jwk.ParseKey(k)
+	_, err = m.Prune()
 	if err != nil {
 		return nil, err
 	}
+
 	return key, nil
 }
 
+// Prune deletes all keys which have been retired for longer than the grace period, except for retained keys (see
+// WithRetainedKeys), and returns the number of deleted keys.
+func (m *DefaultManager) Prune() (int, error) {
+	modelList, err := m.persister.GetAll()
+	if err != nil {
+		return 0, err
+	}
+
+	var retained []string
+	if m.retainedKeys != nil {
+		retained, err = m.retainedKeys()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	now := time.Now()
+	pruned := 0
+	for _, model := range modelList {
+		if model.IsPublished(m.gracePeriod, now) {
+			continue
+		}
+
+		if len(retained) > 0 {
+			key, err := m.decryptKey(model)
+			if err != nil {
+				return pruned, err
+			}
+			if slices.Contains(retained, key.KeyID()) {
+				continue
+			}
+		}
+
+		err = m.persister.Delete(model)
+		if err != nil {
+			return pruned, err
+		}
+		pruned++
+	}
+
+	if pruned > 0 {
+		m.invalidateCache()
+	}
+
+	return pruned, nil
+}
+
+// IsRotationDue reports whether the newest active key is older than the given rotation interval.
+func (m *DefaultManager) IsRotationDue(interval time.Duration) (bool, error) {
+	modelList, err := m.persister.GetAll()
+	if err != nil {
+		return false, err
+	}
+
+	var newest *models.Jwk
+	for i := range modelList {
+		if modelList[i].RetiredAt == nil && (newest == nil || !modelList[i].CreatedAt.Before(newest.CreatedAt)) {
+			newest = &modelList[i]
+		}
+	}
+
+	return newest == nil || newest.CreatedAt.Add(interval).Before(time.Now()), nil
+}
+
+func (m *DefaultManager) GetSigningKey() (jwk.Key, error) {
+	cache, err := m.getKeys()
+	if err != nil {
+		return nil, err
+	}
+	return cache.signingKey, nil
+}
+
 func (m *DefaultManager) GetPublicKeys() (jwk.Set, error) {
+	cache, err := m.getKeys()
+	if err != nil {
+		return nil, err
+	}
+	return cache.publicKeys, nil
+}
+
+// GetAllPublicKeys returns the public keys of all persisted keys, including retired keys which are not published
+// anymore but have not been pruned yet or have been retained (see WithRetainedKeys).
+func (m *DefaultManager) GetAllPublicKeys() (jwk.Set, error) {
 	modelList, err := m.persister.GetAll()
 	if err != nil {
 		return nil, err
@@ -101,13 +395,67 @@ func (m *DefaultManager) GetPublicKeys() (jwk.Set, error) {
 
 	publicKeys := jwk.NewSet()
 	for _, model := range modelList {
-		k, err := m.encrypter.Decrypt(model.KeyData)
+		key, err := m.decryptKey(model)
+		if err != nil {
+			return nil, err
+		}
+
+		publicKey, err := jwk.PublicKeyOf(key)
 		if err != nil {
 			return nil, err
 		}
+		err = publicKeys.AddKey(publicKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return publicKeys, nil
+}
+
+func (m *DefaultManager) invalidateCache() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.cache = nil
+}
+
+func (m *DefaultManager) getKeys() (*keyCache, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.cache != nil && time.Since(m.cachedAt) < keyCacheTTL {
+		return m.cache, nil
+	}
+
+	cache, err := m.loadKeys()
+	if err != nil {
+		return nil, err
+	}
 
-		key, err := jwk.ParseKey(k)
+	m.cache = cache
+	m.cachedAt = time.Now()
 
+	return cache, nil
+}
+
+// loadKeys loads all published keys from the database. The signing key is the most recently created key which
+// has passed the KeyActivationDelay or, if there is none, the most recently created key.
+func (m *DefaultManager) loadKeys() (*keyCache, error) {
+	modelList, err := m.persister.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	publicKeys := jwk.NewSet()
+	var signingKey jwk.Key
+	var signingKeyCreatedAt time.Time
+	for _, model := range modelList {
+		if !model.IsPublished(m.gracePeriod, now) {
+			continue
+		}
+
+		key, err := m.decryptKey(model)
 		if err != nil {
 			return nil, err
 		}
@@ -120,7 +468,31 @@ func (m *DefaultManager) GetPublicKeys() (jwk.Set, error) {
 		if err != nil {
 			return nil, err
 		}
+
+		isActive := model.CreatedAt.Add(KeyActivationDelay).Before(now)
+		isSigningKeyActive := signingKey != nil && signingKeyCreatedAt.Add(KeyActivationDelay).Before(now)
+		isNewer := !model.CreatedAt.Before(signingKeyCreatedAt)
+		if signingKey == nil || (isActive && !isSigningKeyActive) || (isActive == isSigningKeyActive && isNewer) {
+			signingKey = key
+			signingKeyCreatedAt = model.CreatedAt
+		}
 	}
 
-	return publicKeys, nil
+	if signingKey == nil {
+		return nil, errors.New("no signing key available")
+	}
+
+	return &keyCache{
+		signingKey: signingKey,
+		publicKeys: publicKeys,
+	}, nil
+}
+
+func (m *DefaultManager) decryptKey(model models.Jwk) (jwk.Key, error) {
+	k, err := m.encrypter.Decrypt(model.KeyData)
+	if err != nil {
+		return nil, err
+	}
+
+	return jwk.ParseKey(k)
 }
@@ -7,7 +7,9 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/teamhanko/hanko/backend/persistence/models"
 	"github.com/teamhanko/hanko/backend/test"
+	"sync"
 	"testing"
+	"time"
 )
 
 type mockJwkPersister struct {
@@ -74,3 +76,207 @@ func TestDefaultManager(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, token, tokenParsed)
 }
+
+func TestDefaultManager_WithKeyAlgorithm(t *testing.T) {
+	keys := []string{"asfnoadnfoaegnq3094intoaegjnoadjgnoadng"}
+	persister := test.NewJwkPersister(nil)
+
+	dm, err := NewDefaultManager(keys, persister, WithKeyAlgorithm("ES256"))
+	require.NoError(t, err)
+
+	sk, err := dm.GetSigningKey()
+	require.NoError(t, err)
+	assert.Equal(t, jwa.EC, sk.KeyType())
+	assert.Equal(t, jwa.ES256, sk.Algorithm())
+
+	_, err = NewDefaultManager(keys, persister, WithKeyAlgorithm("HS256"))
+	assert.Error(t, err)
+}
+
+func TestDefaultManager_Rotate(t *testing.T) {
+	keys := []string{"asfnoadnfoaegnq3094intoaegjnoadjgnoadng"}
+	persister := test.NewJwkPersister(nil)
+
+	dm, err := NewDefaultManager(keys, persister, WithKeyAlgorithm("EdDSA"), WithGracePeriod(time.Hour))
+	require.NoError(t, err)
+
+	// pretend the initial key has been created a while ago, so it has been activated
+	all, err := persister.GetAll()
+	require.NoError(t, err)
+	initial := all[0]
+	initial.CreatedAt = time.Now().Add(-24 * time.Hour)
+	require.NoError(t, persister.Update(initial))
+
+	due, err := dm.IsRotationDue(12 * time.Hour)
+	require.NoError(t, err)
+	assert.True(t, due)
+
+	initialKey, err := dm.GetSigningKey()
+	require.NoError(t, err)
+
+	rotatedKey, err := dm.Rotate()
+	require.NoError(t, err)
+	assert.NotEqual(t, initialKey.KeyID(), rotatedKey.KeyID())
+
+	due, err = dm.IsRotationDue(12 * time.Hour)
+	require.NoError(t, err)
+	assert.False(t, due)
+
+	// both keys are published, the initial key keeps signing until the rotated key has been activated
+	js, err := dm.GetPublicKeys()
+	require.NoError(t, err)
+	assert.Equal(t, 2, js.Len())
+
+	sk, err := dm.GetSigningKey()
+	require.NoError(t, err)
+	assert.Equal(t, initialKey.KeyID(), sk.KeyID())
+
+	all, err = persister.GetAll()
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	require.NotNil(t, all[0].RetiredAt)
+	assert.Nil(t, all[1].RetiredAt)
+
+	// the initial key is retired once the rotated key is used for signing by all instances, so tokens signed with the
+	// initial key until then can be verified for the whole grace period
+	lastSignedAt := time.Now().Add(KeyActivationDelay + keyCacheTTL)
+	assert.False(t, all[0].RetiredAt.Before(lastSignedAt.Add(-time.Second)))
+	assert.True(t, all[0].IsPublished(time.Hour, lastSignedAt.Add(time.Hour-time.Second)))
+
+	rotated := all[1]
+	rotated.CreatedAt = time.Now().Add(-KeyActivationDelay - time.Second)
+	require.NoError(t, persister.Update(rotated))
+	dm.invalidateCache()
+
+	sk, err = dm.GetSigningKey()
+	require.NoError(t, err)
+	assert.Equal(t, rotatedKey.KeyID(), sk.KeyID())
+
+	// once the grace period has passed, the initial key is no longer published and gets pruned
+	retiredAt := time.Now().Add(-2 * time.Hour)
+	initial = all[0]
+	initial.RetiredAt = &retiredAt
+	require.NoError(t, persister.Update(initial))
+	dm.invalidateCache()
+
+	js, err = dm.GetPublicKeys()
+	require.NoError(t, err)
+	assert.Equal(t, 1, js.Len())
+
+	pruned, err := dm.Prune()
+	require.NoError(t, err)
+	assert.Equal(t, 1, pruned)
+
+	// pruning does not lead to a new key being generated for the configured secret
+	_, err = NewDefaultManager(keys, persister, WithKeyAlgorithm("EdDSA"), WithGracePeriod(time.Hour))
+	require.NoError(t, err)
+	all, err = persister.GetAll()
+	require.NoError(t, err)
+	assert.Len(t, all, 1)
+}
+
+func TestDefaultManager_WithLock_ConcurrentInitialization(t *testing.T) {
+	keys := []string{"asfnoadnfoaegnq3094intoaegjnoadjgnoadng", "lsdjvnosedfngoiegnoaiengoiaengoibaenoib"}
+	persister := test.NewJwkPersister(nil)
+	lock := test.NewSchedulerLockPersister()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := NewDefaultManager(keys, persister, WithKeyAlgorithm("EdDSA"), WithLock(lock))
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	all, err := persister.GetAll()
+	require.NoError(t, err)
+	assert.Len(t, all, len(keys))
+}
+
+func TestDefaultManager_WithLock_Rotate(t *testing.T) {
+	keys := []string{"asfnoadnfoaegnq3094intoaegjnoadjgnoadng"}
+	persister := test.NewJwkPersister(nil)
+	lock := test.NewSchedulerLockPersister()
+
+	dm, err := NewDefaultManager(keys, persister, WithKeyAlgorithm("EdDSA"), WithGracePeriod(time.Hour), WithLock(lock))
+	require.NoError(t, err)
+
+	// the manager keeps the lock it acquired for creating the initial key
+	_, err = dm.Rotate()
+	require.NoError(t, err)
+
+	other, err := NewDefaultManager(keys, persister, WithKeyAlgorithm("EdDSA"), WithGracePeriod(time.Hour), WithLock(lock))
+	require.NoError(t, err)
+
+	_, err = other.Rotate()
+	assert.ErrorIs(t, err, ErrLocked)
+
+	all, err := persister.GetAll()
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.NotNil(t, all[0].RetiredAt)
+	assert.Nil(t, all[1].RetiredAt)
+}
+
+func TestDefaultManager_WithRetainedKeys(t *testing.T) {
+	keys := []string{"asfnoadnfoaegnq3094intoaegjnoadjgnoadng"}
+	persister := test.NewJwkPersister(nil)
+
+	var retained []string
+	dm, err := NewDefaultManager(keys, persister, WithKeyAlgorithm("EdDSA"), WithRetainedKeys(func() ([]string, error) {
+		return retained, nil
+	}))
+	require.NoError(t, err)
+
+	initialKey, err := dm.GetSigningKey()
+	require.NoError(t, err)
+	retained = []string{initialKey.KeyID()}
+
+	rotatedKey, err := dm.Rotate()
+	require.NoError(t, err)
+
+	// pretend the initial key has been retired a while ago, the grace period is 0, so it is pruned unless it is
+	// retained
+	all, err := persister.GetAll()
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	retiredAt := time.Now().Add(-time.Minute)
+	initial := all[0]
+	initial.RetiredAt = &retiredAt
+	require.NoError(t, persister.Update(initial))
+	dm.invalidateCache()
+
+	pruned, err := dm.Prune()
+	require.NoError(t, err)
+	assert.Equal(t, 0, pruned)
+
+	js, err := dm.GetPublicKeys()
+	require.NoError(t, err)
+	assert.Equal(t, 1, js.Len(), "retained keys must not be published")
+
+	js, err = dm.GetAllPublicKeys()
+	require.NoError(t, err)
+	assert.Equal(t, 2, js.Len())
+	_, found := js.LookupKeyID(initialKey.KeyID())
+	assert.True(t, found)
+
+	retained = nil
+	pruned, err = dm.Prune()
+	require.NoError(t, err)
+	assert.Equal(t, 1, pruned)
+
+	js, err = dm.GetAllPublicKeys()
+	require.NoError(t, err)
+	require.Equal(t, 1, js.Len())
+	_, found = js.LookupKeyID(rotatedKey.KeyID())
+	assert.True(t, found)
+}
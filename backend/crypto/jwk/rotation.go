@@ -0,0 +1,69 @@
+package jwk
+
+import (
+	"errors"
+	zeroLogger "github.com/rs/zerolog/log"
+	"time"
+)
+
+// rotationCheckInterval is the interval in which the RotationScheduler checks whether a rotation is due
+const rotationCheckInterval = 5 * time.Minute
+
+// RotationScheduler rotates the keys of a DefaultManager once the current signing key is older than the
+// rotation interval.
+type RotationScheduler struct {
+	manager  *DefaultManager
+	interval time.Duration
+}
+
+func NewRotationScheduler(manager *DefaultManager, interval time.Duration) *RotationScheduler {
+	return &RotationScheduler{
+		manager:  manager,
+		interval: interval,
+	}
+}
+
+// Run checks periodically whether a rotation is due and rotates the keys if so. Run blocks until the given channel is
+// closed.
+func (s *RotationScheduler) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(rotationCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		s.rotateIfDue()
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *RotationScheduler) rotateIfDue() {
+	due, err := s.manager.IsRotationDue(s.interval)
+	if err != nil {
+		zeroLogger.Error().Err(err).Msg("failed to check whether a jwk rotation is due")
+		return
+	}
+
+	if !due {
+		_, err = s.manager.Prune()
+		if err != nil {
+			zeroLogger.Error().Err(err).Msg("failed to prune retired jwks")
+		}
+		return
+	}
+
+	key, err := s.manager.Rotate()
+	if errors.Is(err, ErrLocked) {
+		// the keys are rotated by another instance
+		return
+	}
+	if err != nil {
+		zeroLogger.Error().Err(err).Msg("failed to rotate jwks")
+		return
+	}
+
+	zeroLogger.Info().Str("kid", key.KeyID()).Msg("rotated jwks")
+}
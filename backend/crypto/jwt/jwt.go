@@ -37,9 +37,14 @@ func NewGenerator(signatureKey jwk.Key, verificationKeys jwk.Set) (Generator, er
 	}, nil
 }
 
-// Sign a JWT with the signing key and returns it
+// Sign a JWT with the signing key, using the algorithm of the key (RS256 if the key has none), and returns it
 func (g *generator) Sign(token jwt.Token) ([]byte, error) {
-	signed, err := jwt.Sign(token, jwt.WithKey(jwa.RS256, g.signatureKey))
+	algorithm := jwa.RS256
+	if alg, ok := g.signatureKey.Algorithm().(jwa.SignatureAlgorithm); ok && alg != "" {
+		algorithm = alg
+	}
+
+	signed, err := jwt.Sign(token, jwt.WithKey(algorithm, g.signatureKey))
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign jwt: %w", err)
 	}
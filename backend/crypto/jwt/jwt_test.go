@@ -1,10 +1,13 @@
 package jwt
 
 import (
+	"github.com/lestrrat-go/jwx/v2/jwa"
 	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
 	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	hankoJwk "github.com/teamhanko/hanko/backend/crypto/jwk"
 	"testing"
 )
 
@@ -38,6 +41,37 @@ func TestGenerator_Sign(t *testing.T) {
 	require.NotEmpty(t, signedTokenBytes)
 }
 
+func TestGenerator_SignAndVerifyWithKeyAlgorithm(t *testing.T) {
+	for _, algorithm := range []jwa.SignatureAlgorithm{jwa.ES256, jwa.EdDSA} {
+		t.Run(algorithm.String(), func(t *testing.T) {
+			keyGenerator, err := hankoJwk.NewKeyGenerator(algorithm.String())
+			require.NoError(t, err)
+			signatureKey, err := keyGenerator.Generate("key")
+			require.NoError(t, err)
+
+			verificationKeys := jwk.NewSet()
+			require.NoError(t, verificationKeys.AddKey(signatureKey))
+
+			jwtGenerator, err := NewGenerator(signatureKey, verificationKeys)
+			require.NoError(t, err)
+
+			token := jwt.New()
+			require.NoError(t, token.Set(jwt.SubjectKey, subject))
+
+			signed, err := jwtGenerator.Sign(token)
+			require.NoError(t, err)
+
+			message, err := jws.Parse(signed)
+			require.NoError(t, err)
+			assert.Equal(t, algorithm, message.Signatures()[0].ProtectedHeaders().Algorithm())
+
+			verified, err := jwtGenerator.Verify(signed)
+			require.NoError(t, err)
+			assert.Equal(t, subject, verified.Subject())
+		})
+	}
+}
+
 func TestGenerator_Verify(t *testing.T) {
 	signatureKey := getSignatureJwk(t, key1)
 	require.NotEmpty(t, signatureKey)
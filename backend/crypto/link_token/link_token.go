@@ -0,0 +1,111 @@
+package link_token
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"time"
+)
+
+// Purpose identifies what a link token may be used for. A token issued for one purpose is never accepted for another.
+type Purpose string
+
+const (
+	PurposeSessionRevocation Purpose = "session_revocation"
+	PurposePasscodeLink      Purpose = "passcode_link"
+	PurposeEmailVerification Purpose = "email_verification"
+)
+
+const purposeClaim = "purpose"
+
+var (
+	ErrorInvalidToken    = errors.New("link token is invalid")
+	ErrorPurposeMismatch = errors.New("link token was issued for a different purpose")
+)
+
+// Signer issues and verifies signed, expiring tokens which are embedded in links sent to users (e.g. by email).
+type Signer interface {
+	// Sign issues a token for the given purpose and subject that is valid for the given ttl. Additional claims are
+	// added to the token as is. Every token gets a unique id ("jti" claim), so single-use links can be tracked.
+	Sign(purpose Purpose, subject string, ttl time.Duration, claims map[string]interface{}) (string, error)
+	// Verify verifies the signature, expiry and purpose of the given token and returns the parsed token.
+	Verify(purpose Purpose, token string) (jwt.Token, error)
+}
+
+type signer struct {
+	keys [][32]byte
+}
+
+// NewSigner returns a Signer which signs tokens with a key derived from the first of the given secrets and accepts
+// tokens signed with keys derived from any of them. The keys are HMAC keys and are distinct from the keys used for
+// encrypting JWKs, so link tokens can never be mistaken for session tokens.
+func NewSigner(secrets []string) (Signer, error) {
+	if len(secrets) < 1 {
+		return nil, errors.New("at least one secret must be provided")
+	}
+
+	keys := make([][32]byte, len(secrets))
+	for i, secret := range secrets {
+		keys[i] = deriveKey(secret)
+	}
+
+	return &signer{keys: keys}, nil
+}
+
+// deriveKey derives a fixed size HMAC key from the given secret
+func deriveKey(secret string) [32]byte {
+	return sha256.Sum256([]byte("link_token:" + secret))
+}
+
+func (s *signer) Sign(purpose Purpose, subject string, ttl time.Duration, claims map[string]interface{}) (string, error) {
+	issuedAt := time.Now()
+	jwtID, err := uuid.NewV4()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate link token id: %w", err)
+	}
+
+	token := jwt.New()
+	for key, value := range claims {
+		err := token.Set(key, value)
+		if err != nil {
+			return "", fmt.Errorf("failed to set claim '%s': %w", key, err)
+		}
+	}
+	_ = token.Set(jwt.JwtIDKey, jwtID.String())
+	_ = token.Set(jwt.SubjectKey, subject)
+	_ = token.Set(jwt.IssuedAtKey, issuedAt)
+	_ = token.Set(jwt.ExpirationKey, issuedAt.Add(ttl))
+	_ = token.Set(purposeClaim, string(purpose))
+
+	signed, err := jwt.Sign(token, jwt.WithKey(jwa.HS256, s.keys[0][:]))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign link token: %w", err)
+	}
+
+	return string(signed), nil
+}
+
+func (s *signer) Verify(purpose Purpose, signed string) (jwt.Token, error) {
+	var token jwt.Token
+	var err error
+	for _, key := range s.keys {
+		token, err = jwt.Parse([]byte(signed), jwt.WithKey(jwa.HS256, key[:]), jwt.WithValidate(true))
+		if err == nil {
+			break
+		}
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorInvalidToken, err)
+	}
+
+	tokenPurpose, ok := token.Get(purposeClaim)
+	if !ok || tokenPurpose != string(purpose) {
+		return nil, ErrorPurposeMismatch
+	}
+
+	return token, nil
+}
@@ -0,0 +1,82 @@
+package link_token
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+const (
+	secret1 = "needsToBeAtLeast16"
+	secret2 = "anotherSecretWith16"
+)
+
+func TestSigner_SignAndVerify(t *testing.T) {
+	s, err := NewSigner([]string{secret1})
+	require.NoError(t, err)
+
+	signed, err := s.Sign(PurposeSessionRevocation, "subject", time.Minute, map[string]interface{}{"session_id": "abc"})
+	require.NoError(t, err)
+	require.NotEmpty(t, signed)
+
+	token, err := s.Verify(PurposeSessionRevocation, signed)
+	require.NoError(t, err)
+	assert.Equal(t, "subject", token.Subject())
+
+	sessionID, ok := token.Get("session_id")
+	assert.True(t, ok)
+	assert.Equal(t, "abc", sessionID)
+
+	other, err := s.Sign(PurposeSessionRevocation, "subject", time.Minute, nil)
+	require.NoError(t, err)
+	otherToken, err := s.Verify(PurposeSessionRevocation, other)
+	require.NoError(t, err)
+	assert.NotEmpty(t, token.JwtID())
+	assert.NotEqual(t, token.JwtID(), otherToken.JwtID(), "every token has a unique id")
+}
+
+func TestSigner_Verify_OldKey(t *testing.T) {
+	oldSigner, err := NewSigner([]string{secret1})
+	require.NoError(t, err)
+
+	signed, err := oldSigner.Sign(PurposeSessionRevocation, "subject", time.Minute, nil)
+	require.NoError(t, err)
+
+	newSigner, err := NewSigner([]string{secret2, secret1})
+	require.NoError(t, err)
+
+	_, err = newSigner.Verify(PurposeSessionRevocation, signed)
+	assert.NoError(t, err)
+}
+
+func TestSigner_Verify_Errors(t *testing.T) {
+	s, err := NewSigner([]string{secret1})
+	require.NoError(t, err)
+
+	expired, err := s.Sign(PurposeSessionRevocation, "subject", -time.Minute, nil)
+	require.NoError(t, err)
+
+	_, err = s.Verify(PurposeSessionRevocation, expired)
+	assert.ErrorIs(t, err, ErrorInvalidToken)
+
+	other, err := NewSigner([]string{secret2})
+	require.NoError(t, err)
+
+	signed, err := other.Sign(PurposeSessionRevocation, "subject", time.Minute, nil)
+	require.NoError(t, err)
+
+	_, err = s.Verify(PurposeSessionRevocation, signed)
+	assert.ErrorIs(t, err, ErrorInvalidToken)
+
+	signed, err = s.Sign(Purpose("other"), "subject", time.Minute, nil)
+	require.NoError(t, err)
+
+	_, err = s.Verify(PurposeSessionRevocation, signed)
+	assert.ErrorIs(t, err, ErrorPurposeMismatch)
+}
+
+func TestNewSigner_NoSecrets(t *testing.T) {
+	_, err := NewSigner([]string{})
+	assert.Error(t, err)
+}
@@ -16,6 +16,8 @@ type User struct {
 	UpdatedAt           time.Time                        `json:"updated_at"`
 	Password            *PasswordCredential              `json:"password,omitempty"`
 	Identities          []Identity                       `json:"identities,omitempty"`
+	Metadata            map[string]interface{}           `json:"metadata,omitempty"`
+	PreferredLanguage   *string                          `json:"preferred_language,omitempty"`
 }
 
 // FromUserModel Converts the DB model to a DTO object
@@ -55,6 +57,8 @@ func FromUserModel(model models.User) User {
 		UpdatedAt:           model.UpdatedAt,
 		Password:            passwordCredential,
 		Identities:          identities,
+		Metadata:            model.Metadata,
+		PreferredLanguage:   model.PreferredLanguage,
 	}
 }
 
@@ -14,6 +14,15 @@ type WebhookListResponseDto struct {
 type CreateWebhookRequestDto struct {
 	Callback string        `json:"callback" validate:"required,url"`
 	Events   events.Events `json:"events" validate:"required,min=1,dive,hanko_event"`
+	// Format is the format of the request body, either "hanko-jwt" (default) or "cloudevents".
+	Format string `json:"format" validate:"omitempty,oneof=hanko-jwt cloudevents"`
+}
+
+// WebhookWithSecretResponseDto is returned when a webhook is created or its secret is rotated. It is the only time
+// the signing secret of a webhook is handed out.
+type WebhookWithSecretResponseDto struct {
+	models.Webhook
+	Secret string `json:"secret"`
 }
 
 type GetWebhookRequestDto struct {
@@ -25,3 +34,14 @@ type UpdateWebhookRequestDto struct {
 	CreateWebhookRequestDto
 	Enabled bool `json:"enabled" validate:"required,boolean"`
 }
+
+type ListWebhookDeliveriesRequestDto struct {
+	GetWebhookRequestDto
+	Page    int `query:"page"`
+	PerPage int `query:"per_page"`
+}
+
+type RedeliverWebhookRequestDto struct {
+	GetWebhookRequestDto
+	DeliveryID string `param:"delivery_id" validate:"required,uuid4"`
+}
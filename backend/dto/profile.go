@@ -11,6 +11,7 @@ type ProfileData struct {
 	WebauthnCredentials []WebauthnCredentialResponse `json:"passkeys,omitempty"`
 	Emails              []EmailResponse              `json:"emails,omitempty"`
 	Username            *Username                    `json:"username,omitempty"`
+	PreferredLanguage   *string                      `json:"preferred_language,omitempty"`
 	CreatedAt           time.Time                    `json:"created_at"`
 	UpdatedAt           time.Time                    `json:"updated_at"`
 }
@@ -33,6 +34,7 @@ func ProfileDataFromUserModel(user *models.User) *ProfileData {
 		WebauthnCredentials: webauthnCredentials,
 		Emails:              emails,
 		Username:            FromUsernameModel(user.Username),
+		PreferredLanguage:   user.PreferredLanguage,
 		CreatedAt:           user.CreatedAt,
 		UpdatedAt:           user.UpdatedAt,
 	}
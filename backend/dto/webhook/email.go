@@ -1,5 +1,7 @@
 package webhook
 
+import "time"
+
 type EmailSend struct {
 	Subject          string    `json:"subject"`        // subject
 	BodyPlain        string    `json:"body_plain"`     // used for string templates
@@ -7,20 +9,64 @@ type EmailSend struct {
 	ToEmailAddress   string    `json:"to_email_address"`
 	DeliveredByHanko bool      `json:"delivered_by_hanko"`
 	AcceptLanguage   string    `json:"accept_language"` // accept_language header from http request
-	Type             EmailType `json:"type"`            // type of the email, currently only "passcode", but other could be added later
+	Type             EmailType `json:"type"`            // type of the email, e.g. "passcode" or "new_device_login"
 
 	Data interface{} `json:"data"`
 }
 
+// EmailFailed is the data of the "email.failed" event, triggered for emails from the send queue which could not be
+// delivered.
+type EmailFailed struct {
+	MessageID      string    `json:"message_id"`
+	ToEmailAddress string    `json:"to_email_address"`
+	Subject        string    `json:"subject"`
+	Attempts       int       `json:"attempts"`
+	Error          string    `json:"error"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
 type PasscodeData struct {
 	ServiceName string `json:"service_name"`
 	OtpCode     string `json:"otp_code"`
 	TTL         int    `json:"ttl"`
 	ValidUntil  int64  `json:"valid_until"` // UnixTimestamp
+	Link        string `json:"link,omitempty"`
+}
+
+type NewDeviceLoginData struct {
+	ServiceName   string `json:"service_name"`
+	SessionID     string `json:"session_id"`
+	UserAgent     string `json:"user_agent"`
+	IpAddress     string `json:"ip_address"`
+	LoginTime     int64  `json:"login_time"` // UnixTimestamp
+	RevocationURL string `json:"revocation_url"`
+}
+
+type EmailVerificationLinkData struct {
+	ServiceName  string `json:"service_name"`
+	EmailAddress string `json:"email_address"`
+	Link         string `json:"link"`
+	ValidUntil   int64  `json:"valid_until"` // UnixTimestamp
+}
+
+type SecurityNotificationData struct {
+	ServiceName          string `json:"service_name"`
+	EmailAddress         string `json:"email_address,omitempty"`          // the deleted or new primary email address
+	PreviousEmailAddress string `json:"previous_email_address,omitempty"` // the previous primary email address
+	PasskeyID            string `json:"passkey_id,omitempty"`
+	PasskeyName          string `json:"passkey_name,omitempty"`
 }
 
 type EmailType string
 
 var (
-	EmailTypePasscode EmailType = "passcode"
+	EmailTypePasscode                   EmailType = "passcode"
+	EmailTypeNewDeviceLogin             EmailType = "new_device_login"
+	EmailTypePasswordChanged            EmailType = "password_changed"
+	EmailTypePasskeyCreated             EmailType = "passkey_created"
+	EmailTypePasskeyDeleted             EmailType = "passkey_deleted"
+	EmailTypeEmailDeleted               EmailType = "email_deleted"
+	EmailTypePrimaryEmailChanged        EmailType = "primary_email_changed"
+	EmailTypeEmailVerificationLink      EmailType = "email_verification_link"
+	EmailTypeEmailRegistrationAttempted EmailType = "email_registration_attempted"
 )
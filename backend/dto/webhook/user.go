@@ -0,0 +1,37 @@
+package webhook
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/dto/admin"
+	"time"
+)
+
+type LoginMethod string
+
+var (
+	LoginMethodPasscode   LoginMethod = "passcode"
+	LoginMethodPassword   LoginMethod = "password"
+	LoginMethodPasskey    LoginMethod = "passkey"
+	LoginMethodThirdParty LoginMethod = "third_party"
+)
+
+type Login struct {
+	User      admin.User  `json:"user"`
+	Method    LoginMethod `json:"method"`
+	SessionID string      `json:"session_id,omitempty"`
+}
+
+type LoginFailed struct {
+	UserID *uuid.UUID  `json:"user_id,omitempty"` // not set if the user is unknown
+	Method LoginMethod `json:"method,omitempty"`  // not set if the login failed before a login method was chosen
+	Reason string      `json:"reason"`
+}
+
+type Session struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	UserAgent string     `json:"user_agent,omitempty"`
+	IpAddress string     `json:"ip_address,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
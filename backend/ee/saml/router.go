@@ -6,11 +6,11 @@ import (
 	"github.com/teamhanko/hanko/backend/session"
 )
 
-func CreateSamlRoutes(e *echo.Echo, sessionManager session.Manager, auditLogger auditlog.Logger, samlService Service) {
+func CreateSamlRoutes(e *echo.Echo, sessionManager session.Manager, auditLogger auditlog.Logger, samlService Service, webhookMiddleware echo.MiddlewareFunc) {
 	handler := NewSamlHandler(sessionManager, auditLogger, samlService)
 	routingGroup := e.Group("saml")
 	routingGroup.GET("/provider", handler.GetProvider)
 	routingGroup.GET("/metadata", handler.Metadata)
 	routingGroup.GET("/auth", handler.Auth)
-	routingGroup.POST("/callback", handler.CallbackPost)
+	routingGroup.POST("/callback", handler.CallbackPost, webhookMiddleware)
 }
@@ -0,0 +1,69 @@
+package flow_api
+
+import (
+	"errors"
+	"github.com/gofrs/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	zeroLogger "github.com/rs/zerolog/log"
+	auditlog "github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/flowpilot"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+)
+
+// auditFlowAction creates an audit log for the executed flow action, if enabled for the action. The error is the error
+// of the transaction the action has been executed in, the action is logged as failed if it is set.
+func (h *FlowPilotHandler) auditFlowAction(c echo.Context, event flowpilot.ActionEvent, err error) {
+	cfg := h.Cfg.AuditLog.FlowActions
+	if !cfg.Enabled || !cfg.Matches(string(event.FlowName), string(event.ActionName)) {
+		return
+	}
+
+	if err != nil && event.Outcome != flowpilot.ActionOutcomeFailed {
+		// e.g. the transaction could not be committed, so the changes of the action have not been stored
+		event.Outcome = flowpilot.ActionOutcomeFailed
+		event.ErrorCode = flowpilot.ErrorTechnical.Code()
+		event.Error = err
+	}
+
+	auditLogType := models.AuditLogFlowActionSucceeded
+	var logError error
+	details := []auditlog.DetailOption{
+		auditlog.Detail("flow_name", string(event.FlowName)),
+		auditlog.Detail("flow_id", event.FlowID.String()),
+		auditlog.Detail("state", string(event.StateName)),
+		auditlog.Detail("action", string(event.ActionName)),
+		auditlog.Detail("next_state", string(event.NextStateName)),
+		auditlog.Detail("outcome", string(event.Outcome)),
+	}
+
+	if event.Outcome == flowpilot.ActionOutcomeFailed {
+		auditLogType = models.AuditLogFlowActionFailed
+		details = append(details, auditlog.Detail("error_code", event.ErrorCode))
+
+		logError = event.Error
+		if logError == nil {
+			logError = errors.New(event.ErrorCode)
+		}
+	}
+
+	err = h.AuditLogger.Create(c, auditLogType, sessionUser(c), logError, details...)
+	if err != nil {
+		zeroLogger.Error().Err(err).Msg("failed to create flow action audit log")
+	}
+}
+
+// sessionUser returns the user of the session validated for the request (e.g. in the profile flow), if any.
+func sessionUser(c echo.Context) *models.User {
+	token, ok := c.Get("session").(jwt.Token)
+	if !ok {
+		return nil
+	}
+
+	userId, err := uuid.FromString(token.Subject())
+	if err != nil {
+		return nil
+	}
+
+	return &models.User{ID: userId}
+}
@@ -0,0 +1,175 @@
+package flow_api
+
+import (
+	"errors"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	auditlog "github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/flowpilot"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// recordedAuditLog is an audit log created with the recordingAuditLogger.
+type recordedAuditLog struct {
+	logType models.AuditLogType
+	err     error
+	details map[string]interface{}
+}
+
+// recordingAuditLogger records the created audit logs.
+type recordingAuditLogger struct {
+	auditLogs []recordedAuditLog
+}
+
+func (l *recordingAuditLogger) Create(c echo.Context, logType models.AuditLogType, user *models.User, err error, opts ...auditlog.DetailOption) error {
+	return l.CreateWithConnection(nil, c, logType, user, err, opts...)
+}
+
+func (l *recordingAuditLogger) CreateWithConnection(_ *pop.Connection, _ echo.Context, logType models.AuditLogType, _ *models.User, err error, opts ...auditlog.DetailOption) error {
+	details := make(map[string]interface{})
+	for _, opt := range opts {
+		opt(details)
+	}
+
+	l.auditLogs = append(l.auditLogs, recordedAuditLog{logType: logType, err: err, details: details})
+	return nil
+}
+
+func (l *recordingAuditLogger) Close() error {
+	return nil
+}
+
+func TestFlowPilotHandler_AuditFlowAction(t *testing.T) {
+	commitErr := errors.New("commit failed")
+
+	tests := []struct {
+		name              string
+		cfg               config.AuditLogFlowActions
+		event             flowpilot.ActionEvent
+		err               error
+		expectedType      models.AuditLogType
+		expectedErrorCode string
+		expectedError     string
+	}{
+		{
+			name: "success",
+			cfg:  config.AuditLogFlowActions{Enabled: true},
+			event: flowpilot.ActionEvent{
+				Outcome:       flowpilot.ActionOutcomeSucceeded,
+				NextStateName: "success",
+			},
+			expectedType: models.AuditLogFlowActionSucceeded,
+		},
+		{
+			name: "flow error",
+			cfg:  config.AuditLogFlowActions{Enabled: true},
+			event: flowpilot.ActionEvent{
+				Outcome:   flowpilot.ActionOutcomeFailed,
+				ErrorCode: flowpilot.ErrorFormDataInvalid.Code(),
+			},
+			expectedType:      models.AuditLogFlowActionFailed,
+			expectedErrorCode: flowpilot.ErrorFormDataInvalid.Code(),
+			expectedError:     flowpilot.ErrorFormDataInvalid.Code(),
+		},
+		{
+			name: "technical error",
+			cfg:  config.AuditLogFlowActions{Enabled: true},
+			event: flowpilot.ActionEvent{
+				Outcome:   flowpilot.ActionOutcomeFailed,
+				ErrorCode: flowpilot.ErrorTechnical.Code(),
+				Error:     errors.New("database unavailable"),
+			},
+			err:               errors.New("database unavailable"),
+			expectedType:      models.AuditLogFlowActionFailed,
+			expectedErrorCode: flowpilot.ErrorTechnical.Code(),
+			expectedError:     "database unavailable",
+		},
+		{
+			name: "commit failure",
+			cfg:  config.AuditLogFlowActions{Enabled: true},
+			event: flowpilot.ActionEvent{
+				Outcome:       flowpilot.ActionOutcomeSucceeded,
+				NextStateName: "success",
+			},
+			err:               commitErr,
+			expectedType:      models.AuditLogFlowActionFailed,
+			expectedErrorCode: flowpilot.ErrorTechnical.Code(),
+			expectedError:     commitErr.Error(),
+		},
+		{
+			name: "included",
+			cfg:  config.AuditLogFlowActions{Enabled: true, Include: []string{"login/*"}},
+			event: flowpilot.ActionEvent{
+				Outcome: flowpilot.ActionOutcomeSucceeded,
+			},
+			expectedType: models.AuditLogFlowActionSucceeded,
+		},
+		{
+			name: "not included",
+			cfg:  config.AuditLogFlowActions{Enabled: true, Include: []string{"registration/*"}},
+			event: flowpilot.ActionEvent{
+				Outcome: flowpilot.ActionOutcomeSucceeded,
+			},
+		},
+		{
+			name: "excluded",
+			cfg:  config.AuditLogFlowActions{Enabled: true, Include: []string{"login/*"}, Exclude: []string{"*passcode*"}},
+			event: flowpilot.ActionEvent{
+				Outcome: flowpilot.ActionOutcomeFailed,
+			},
+		},
+		{
+			name: "disabled",
+			cfg:  config.AuditLogFlowActions{Enabled: false},
+			event: flowpilot.ActionEvent{
+				Outcome: flowpilot.ActionOutcomeSucceeded,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &recordingAuditLogger{}
+			h := &FlowPilotHandler{AuditLogger: logger}
+			h.Cfg.AuditLog.FlowActions = tt.cfg
+
+			event := tt.event
+			event.FlowName = "login"
+			event.FlowID = uuid.Must(uuid.NewV4())
+			event.StateName = "passcode_confirmation"
+			event.ActionName = "verify_passcode"
+
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/login", nil), httptest.NewRecorder())
+			h.auditFlowAction(c, event, tt.err)
+
+			if tt.expectedType == "" {
+				assert.Empty(t, logger.auditLogs)
+				return
+			}
+
+			require.Len(t, logger.auditLogs, 1)
+			auditLog := logger.auditLogs[0]
+			assert.Equal(t, tt.expectedType, auditLog.logType)
+			assert.Equal(t, "login", auditLog.details["flow_name"])
+			assert.Equal(t, event.FlowID.String(), auditLog.details["flow_id"])
+			assert.Equal(t, "verify_passcode", auditLog.details["action"])
+
+			if tt.expectedType == models.AuditLogFlowActionSucceeded {
+				assert.Equal(t, string(flowpilot.ActionOutcomeSucceeded), auditLog.details["outcome"])
+				assert.NotContains(t, auditLog.details, "error_code")
+				assert.NoError(t, auditLog.err)
+			} else {
+				assert.Equal(t, string(flowpilot.ActionOutcomeFailed), auditLog.details["outcome"])
+				assert.Equal(t, tt.expectedErrorCode, auditLog.details["error_code"])
+				assert.EqualError(t, auditLog.err, tt.expectedError)
+			}
+		})
+	}
+}
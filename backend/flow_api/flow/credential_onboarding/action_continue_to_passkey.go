@@ -17,6 +17,12 @@ func (a ContinueToPasskey) GetDescription() string {
 	return "Register a WebAuthn credential"
 }
 
+func (a ContinueToPasskey) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StateOnboardingCreatePasskey},
+	}
+}
+
 func (a ContinueToPasskey) Initialize(_ flowpilot.InitializationContext) {}
 
 func (a ContinueToPasskey) Execute(c flowpilot.ExecutionContext) error {
@@ -17,6 +17,12 @@ func (a ContinueToPassword) GetDescription() string {
 	return "Register a password credential"
 }
 
+func (a ContinueToPassword) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StatePasswordCreation},
+	}
+}
+
 func (a ContinueToPassword) Initialize(_ flowpilot.InitializationContext) {}
 
 func (a ContinueToPassword) Execute(c flowpilot.ExecutionContext) error {
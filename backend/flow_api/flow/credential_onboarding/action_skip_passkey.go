@@ -1,6 +1,7 @@
 package credential_onboarding
 
 import (
+	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/flow_api/flow/shared"
 	"github.com/teamhanko/hanko/backend/flowpilot"
 )
@@ -17,6 +18,12 @@ func (a SkipPasskey) GetDescription() string {
 	return "Skip"
 }
 
+func (a SkipPasskey) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StatePasswordCreation},
+	}
+}
+
 func (a SkipPasskey) Initialize(c flowpilot.InitializationContext) {
 	deps := a.GetDeps(c)
 	emailExists := c.Stash().Get(shared.StashPathEmail).Exists()
@@ -47,7 +54,14 @@ func (a SkipPasskey) Initialize(c flowpilot.InitializationContext) {
 
 }
 func (a SkipPasskey) Execute(c flowpilot.ExecutionContext) error {
-	if a.acquirePassword(c, "conditional") &&
+	deps := a.GetDeps(c)
+
+	// with separate onboarding steps, the password is acquired by its own step in the login flow
+	credentialsStep := !c.IsFlow(shared.FlowLogin) ||
+		deps.Cfg.Flow.LoginOnboarding.HasStep(config.FlowOnboardingStepCredentials)
+
+	if credentialsStep &&
+		a.acquirePassword(c, "conditional") &&
 		!c.Stash().Get(shared.StashPathUserHasPassword).Bool() {
 		return c.Continue(shared.StatePasswordCreation)
 	}
@@ -1,6 +1,7 @@
 package credential_onboarding
 
 import (
+	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/flow_api/flow/shared"
 	"github.com/teamhanko/hanko/backend/flowpilot"
 )
@@ -17,6 +18,12 @@ func (a SkipPassword) GetDescription() string {
 	return "Skip"
 }
 
+func (a SkipPassword) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StateOnboardingCreatePasskey},
+	}
+}
+
 func (a SkipPassword) Initialize(c flowpilot.InitializationContext) {
 	deps := a.GetDeps(c)
 	emailExists := c.Stash().Get(shared.StashPathEmail).Exists()
@@ -47,7 +54,14 @@ func (a SkipPassword) Initialize(c flowpilot.InitializationContext) {
 }
 
 func (a SkipPassword) Execute(c flowpilot.ExecutionContext) error {
-	if a.acquirePasskey(c, "conditional") &&
+	deps := a.GetDeps(c)
+
+	// with separate onboarding steps, the passkey is acquired by its own step in the login flow
+	credentialsStep := !c.IsFlow(shared.FlowLogin) ||
+		deps.Cfg.Flow.LoginOnboarding.HasStep(config.FlowOnboardingStepCredentials)
+
+	if credentialsStep &&
+		a.acquirePasskey(c, "conditional") &&
 		!c.Stash().Get(shared.StashPathUserHasWebauthnCredential).Bool() &&
 		c.Stash().Get(shared.StashPathWebauthnAvailable).Bool() {
 		return c.Continue(shared.StateOnboardingCreatePasskey)
@@ -21,6 +21,12 @@ func (a WebauthnGenerateCreationOptions) GetDescription() string {
 	return "Get creation options to create a webauthn credential."
 }
 
+func (a WebauthnGenerateCreationOptions) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StateOnboardingVerifyPasskeyAttestation},
+	}
+}
+
 func (a WebauthnGenerateCreationOptions) Initialize(c flowpilot.InitializationContext) {
 	if !c.Stash().Get(shared.StashPathWebauthnAvailable).Bool() {
 		c.SuspendAction()
@@ -18,6 +18,12 @@ func (a ContinueToPasscodeConfirmation) GetDescription() string {
 	return "Send a login passcode code via email."
 }
 
+func (a ContinueToPasscodeConfirmation) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StatePasscodeConfirmation},
+	}
+}
+
 func (a ContinueToPasscodeConfirmation) Initialize(c flowpilot.InitializationContext) {}
 
 func (a ContinueToPasscodeConfirmation) Execute(c flowpilot.ExecutionContext) error {
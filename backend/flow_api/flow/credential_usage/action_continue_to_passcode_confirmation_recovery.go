@@ -18,6 +18,12 @@ func (a ContinueToPasscodeConfirmationRecovery) GetDescription() string {
 	return "Send a recovery passcode code via email."
 }
 
+func (a ContinueToPasscodeConfirmationRecovery) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StatePasscodeConfirmation, shared.StateLoginPasswordRecovery},
+	}
+}
+
 func (a ContinueToPasscodeConfirmationRecovery) Initialize(c flowpilot.InitializationContext) {
 	deps := a.GetDeps(c)
 
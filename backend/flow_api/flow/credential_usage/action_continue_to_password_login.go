@@ -17,6 +17,12 @@ func (a ContinueToPasswordLogin) GetDescription() string {
 	return "Continue to the password login."
 }
 
+func (a ContinueToPasswordLogin) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StateLoginPassword},
+	}
+}
+
 func (a ContinueToPasswordLogin) Initialize(c flowpilot.InitializationContext) {}
 
 func (a ContinueToPasswordLogin) Execute(c flowpilot.ExecutionContext) error {
@@ -7,6 +7,7 @@ import (
 	"github.com/teamhanko/hanko/backend/flow_api/flow/shared"
 	"github.com/teamhanko/hanko/backend/flowpilot"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/webhooks/utils"
 	"regexp"
 	"strings"
 )
@@ -23,6 +24,15 @@ func (a ContinueWithLoginIdentifier) GetDescription() string {
 	return "Enter an identifier to login."
 }
 
+func (a ContinueWithLoginIdentifier) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StateThirdParty},
+		{shared.StateLoginMethodChooser},
+		{shared.StateLoginPassword},
+		{shared.StatePasscodeConfirmation},
+	}
+}
+
 func (a ContinueWithLoginIdentifier) Initialize(c flowpilot.InitializationContext) {
 	deps := a.GetDeps(c)
 
@@ -142,6 +152,8 @@ func (a ContinueWithLoginIdentifier) Execute(c flowpilot.ExecutionContext) error
 				return fmt.Errorf("could not create audit log: %w", err)
 			}
 
+			utils.NotifyLoginFailed(deps.HttpContext, deps.Tx, nil, "", flowInputError.Code())
+
 			c.Input().SetError(identifierInputName, flowInputError)
 			return c.Error(flowpilot.ErrorFormDataInvalid)
 		}
@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"github.com/gofrs/uuid"
 	auditlog "github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/dto/webhook"
 	"github.com/teamhanko/hanko/backend/flow_api/flow/shared"
 	"github.com/teamhanko/hanko/backend/flow_api/services"
 	"github.com/teamhanko/hanko/backend/flowpilot"
 	"github.com/teamhanko/hanko/backend/persistence/models"
 	"github.com/teamhanko/hanko/backend/rate_limiter"
+	"github.com/teamhanko/hanko/backend/webhooks/utils"
 )
 
 type PasswordLogin struct {
@@ -101,6 +103,8 @@ func (a PasswordLogin) Execute(c flowpilot.ExecutionContext) error {
 				return fmt.Errorf("could not create audit log: %w", err)
 			}
 
+			utils.NotifyLoginFailed(deps.HttpContext, deps.Tx, &userID, webhook.LoginMethodPassword, "password_invalid")
+
 			return a.wrongCredentialsError(c)
 		}
 
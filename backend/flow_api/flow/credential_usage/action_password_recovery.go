@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"github.com/gofrs/uuid"
 	auditlog "github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/dto/webhook"
 	"github.com/teamhanko/hanko/backend/flow_api/flow/shared"
 	"github.com/teamhanko/hanko/backend/flow_api/services"
 	"github.com/teamhanko/hanko/backend/flowpilot"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/webhooks/events"
+	"github.com/teamhanko/hanko/backend/webhooks/utils"
 )
 
 type PasswordRecovery struct {
@@ -71,6 +74,12 @@ func (a PasswordRecovery) Execute(c flowpilot.ExecutionContext) error {
 		return fmt.Errorf("could not create audit log: %w", err)
 	}
 
+	if deps.Cfg.SecurityNotifications.PasswordChanged.Enabled {
+		shared.SendSecurityNotification(deps, uuid.FromStringOrNil(authUserID), webhook.EmailTypePasswordChanged, webhook.SecurityNotificationData{})
+	}
+
+	utils.NotifyUserChange(deps.HttpContext, deps.Tx, deps.Persister, events.UserPassword, uuid.FromStringOrNil(authUserID))
+
 	err = c.Stash().Set(shared.StashPathUserHasPassword, true)
 	if err != nil {
 		return fmt.Errorf("failed to set user_has_password to the stash: %w", err)
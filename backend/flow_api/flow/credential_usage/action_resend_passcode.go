@@ -25,6 +25,12 @@ func (a ReSendPasscode) GetDescription() string {
 	return "Send the passcode email again."
 }
 
+func (a ReSendPasscode) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StatePasscodeConfirmation},
+	}
+}
+
 func (a ReSendPasscode) Initialize(_ flowpilot.InitializationContext) {}
 
 func (a ReSendPasscode) Execute(c flowpilot.ExecutionContext) error {
@@ -54,10 +60,18 @@ func (a ReSendPasscode) Execute(c flowpilot.ExecutionContext) error {
 		}
 	}
 
+	browserBinding, err := shared.SetPasscodeLinkCookie(deps)
+	if err != nil {
+		return err
+	}
+
+	lang := shared.EmailLanguage(deps, c.Stash().Get(shared.StashPathPreferredLanguage).String(), c.Stash().Get(shared.StashPathUserID).String())
 	sendParams := services.SendPasscodeParams{
-		Template:     c.Stash().Get(shared.StashPathPasscodeTemplate).String(),
-		EmailAddress: c.Stash().Get(shared.StashPathEmail).String(),
-		Language:     deps.HttpContext.Request().Header.Get("Accept-Language"),
+		Template:       c.Stash().Get(shared.StashPathPasscodeTemplate).String(),
+		EmailAddress:   c.Stash().Get(shared.StashPathEmail).String(),
+		Language:       lang,
+		FlowID:         c.GetFlowID(),
+		BrowserBinding: browserBinding,
 	}
 	passcodeResult, err := deps.PasscodeService.SendPasscode(deps.Tx, sendParams)
 	if err != nil {
@@ -67,6 +81,7 @@ func (a ReSendPasscode) Execute(c flowpilot.ExecutionContext) error {
 	webhookData := webhook.EmailSend{
 		Subject:          passcodeResult.Subject,
 		BodyPlain:        passcodeResult.Body,
+		Body:             passcodeResult.HTMLBody,
 		ToEmailAddress:   sendParams.EmailAddress,
 		DeliveredByHanko: deps.Cfg.EmailDelivery.Enabled,
 		AcceptLanguage:   sendParams.Language,
@@ -74,6 +89,7 @@ func (a ReSendPasscode) Execute(c flowpilot.ExecutionContext) error {
 		Data: webhook.PasscodeData{
 			ServiceName: deps.Cfg.Service.Name,
 			OtpCode:     passcodeResult.Code,
+			Link:        passcodeResult.Link,
 			TTL:         deps.Cfg.Email.PasscodeTtl,
 			ValidUntil:  passcodeResult.PasscodeModel.CreatedAt.Add(time.Duration(deps.Cfg.Email.PasscodeTtl) * time.Second).UTC().Unix(),
 		},
@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"github.com/gofrs/uuid"
 	auditlog "github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/dto/webhook"
 	"github.com/teamhanko/hanko/backend/flow_api/flow/shared"
 	"github.com/teamhanko/hanko/backend/flow_api/services"
 	"github.com/teamhanko/hanko/backend/flowpilot"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/webhooks/utils"
 )
 
 type VerifyPasscode struct {
@@ -24,6 +27,13 @@ func (a VerifyPasscode) GetDescription() string {
 }
 
 func (a VerifyPasscode) Initialize(c flowpilot.InitializationContext) {
+	deps := a.GetDeps(c)
+
+	// the code is not part of the email if only the link can be used
+	if deps.Cfg.Email.PasscodeMode == config.PasscodeModeLink && !deps.Cfg.Email.PasscodeLink.IncludeCode {
+		c.SuspendAction()
+	}
+
 	c.AddInputs(flowpilot.StringInput("code").Required(true))
 }
 
@@ -58,6 +68,9 @@ func (a VerifyPasscode) Execute(c flowpilot.ExecutionContext) error {
 				if err != nil {
 					return fmt.Errorf("could not create audit log: %w", err)
 				}
+
+				userID := uuid.FromStringOrNil(c.Stash().Get(shared.StashPathUserID).String())
+				utils.NotifyLoginFailed(deps.HttpContext, deps.Tx, &userID, webhook.LoginMethodPasscode, shared.ErrorPasscodeInvalid.Code())
 			}
 
 			return c.Error(shared.ErrorPasscodeInvalid)
@@ -77,6 +90,9 @@ func (a VerifyPasscode) Execute(c flowpilot.ExecutionContext) error {
 				if err != nil {
 					return fmt.Errorf("could not create audit log: %w", err)
 				}
+
+				userID := uuid.FromStringOrNil(c.Stash().Get(shared.StashPathUserID).String())
+				utils.NotifyLoginFailed(deps.HttpContext, deps.Tx, &userID, webhook.LoginMethodPasscode, shared.ErrorPasscodeMaxAttemptsReached.Code())
 			}
 
 			return c.Error(shared.ErrorPasscodeMaxAttemptsReached)
@@ -85,7 +101,13 @@ func (a VerifyPasscode) Execute(c flowpilot.ExecutionContext) error {
 		return fmt.Errorf("failed to verify passcode: %w", err)
 	}
 
-	err = c.Stash().Delete("passcode_id")
+	return completePasscodeConfirmation(c)
+}
+
+// completePasscodeConfirmation removes the verified passcode from the stash and continues the flow after the passcode
+// has been verified by entering the code or by opening the link.
+func completePasscodeConfirmation(c flowpilot.ExecutionContext) error {
+	err := c.Stash().Delete("passcode_id")
 	if err != nil {
 		return fmt.Errorf("failed to delete passcode_id from stash: %w", err)
 	}
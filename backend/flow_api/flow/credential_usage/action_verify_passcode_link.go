@@ -0,0 +1,82 @@
+package credential_usage
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gofrs/uuid"
+	auditlog "github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/dto/webhook"
+	"github.com/teamhanko/hanko/backend/flow_api/flow/shared"
+	"github.com/teamhanko/hanko/backend/flow_api/services"
+	"github.com/teamhanko/hanko/backend/flowpilot"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/webhooks/utils"
+)
+
+type VerifyPasscodeLink struct {
+	shared.Action
+}
+
+func (a VerifyPasscodeLink) GetName() flowpilot.ActionName {
+	return shared.ActionVerifyPasscodeLink
+}
+
+func (a VerifyPasscodeLink) GetDescription() string {
+	return "Continue after the link in the passcode email has been opened."
+}
+
+func (a VerifyPasscodeLink) Initialize(c flowpilot.InitializationContext) {
+	deps := a.GetDeps(c)
+
+	if deps.Cfg.Email.PasscodeMode != config.PasscodeModeLink {
+		c.SuspendAction()
+	}
+}
+
+func (a VerifyPasscodeLink) Execute(c flowpilot.ExecutionContext) error {
+	deps := a.GetDeps(c)
+
+	if !c.Stash().Get(shared.StashPathPasscodeID).Exists() {
+		return errors.New("passcode_id does not exist in the stash")
+	}
+
+	passcodeID := uuid.FromStringOrNil(c.Stash().Get(shared.StashPathPasscodeID).String())
+	err := deps.PasscodeService.VerifyPasscodeLink(deps.Tx, passcodeID, c.GetFlowID())
+	if err != nil {
+		if errors.Is(err, services.ErrorPasscodeLinkNotConfirmed) {
+			// the client is expected to retry, e.g. by polling, until the link has been opened
+			return c.Error(shared.ErrorPasscodeLinkNotConfirmed)
+		}
+
+		if errors.Is(err, services.ErrorPasscodeInvalid) ||
+			errors.Is(err, services.ErrorPasscodeNotFound) ||
+			errors.Is(err, services.ErrorPasscodeExpired) ||
+			errors.Is(err, services.ErrorPasscodeMaxAttemptsReached) {
+
+			if c.Stash().Get(shared.StashPathLoginMethod).Exists() {
+				userID := uuid.FromStringOrNil(c.Stash().Get(shared.StashPathUserID).String())
+				err = deps.AuditLogger.CreateWithConnection(
+					deps.Tx,
+					deps.HttpContext,
+					models.AuditLogLoginFailure,
+					&models.User{ID: userID},
+					err,
+					auditlog.Detail("login_method", "passcode"),
+					auditlog.Detail("flow_id", c.GetFlowID()))
+
+				if err != nil {
+					return fmt.Errorf("could not create audit log: %w", err)
+				}
+
+				utils.NotifyLoginFailed(deps.HttpContext, deps.Tx, &userID, webhook.LoginMethodPasscode, shared.ErrorPasscodeInvalid.Code())
+			}
+
+			return c.Error(shared.ErrorPasscodeInvalid)
+		}
+
+		return fmt.Errorf("failed to verify passcode link: %w", err)
+	}
+
+	return completePasscodeConfirmation(c)
+}
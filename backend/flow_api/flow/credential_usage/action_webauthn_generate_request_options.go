@@ -19,6 +19,12 @@ func (a WebauthnGenerateRequestOptions) GetDescription() string {
 	return "Get webauthn request options in order to sign in with a webauthn credential."
 }
 
+func (a WebauthnGenerateRequestOptions) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StateLoginPasskey},
+	}
+}
+
 func (a WebauthnGenerateRequestOptions) Initialize(c flowpilot.InitializationContext) {
 	deps := a.GetDeps(c)
 
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"github.com/gofrs/uuid"
 	auditlog "github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/dto/webhook"
 	"github.com/teamhanko/hanko/backend/flow_api/flow/shared"
 	"github.com/teamhanko/hanko/backend/flow_api/services"
 	"github.com/teamhanko/hanko/backend/flowpilot"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/webhooks/utils"
 )
 
 type WebauthnVerifyAssertionResponse struct {
@@ -23,6 +25,12 @@ func (a WebauthnVerifyAssertionResponse) GetDescription() string {
 	return "Send the result which was generated by using a webauthn credential."
 }
 
+func (a WebauthnVerifyAssertionResponse) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StateError},
+	}
+}
+
 func (a WebauthnVerifyAssertionResponse) Initialize(c flowpilot.InitializationContext) {
 	deps := a.GetDeps(c)
 
@@ -69,6 +77,12 @@ func (a WebauthnVerifyAssertionResponse) Execute(c flowpilot.ExecutionContext) e
 				return fmt.Errorf("could not create audit log: %w", err)
 			}
 
+			var userID *uuid.UUID
+			if userModel != nil {
+				userID = &userModel.ID
+			}
+			utils.NotifyLoginFailed(deps.HttpContext, deps.Tx, userID, webhook.LoginMethodPasskey, shared.ErrorPasskeyInvalid.Code())
+
 			c.SetFlowError(shared.ErrorPasskeyInvalid.Wrap(err))
 
 			return c.Continue(shared.StateError)
@@ -64,10 +64,18 @@ func (h SendPasscode) Execute(c flowpilot.HookExecutionContext) error {
 	isDifferentEmailAddress := c.Stash().Get(shared.StashPathEmail).String() != c.Stash().Get(shared.StashPathPasscodeEmail).String()
 
 	if !passcodeIsValid || isDifferentEmailAddress {
+		browserBinding, err := shared.SetPasscodeLinkCookie(deps)
+		if err != nil {
+			return err
+		}
+
+		lang := shared.EmailLanguage(deps, c.Stash().Get(shared.StashPathPreferredLanguage).String(), c.Stash().Get(shared.StashPathUserID).String())
 		sendParams := services.SendPasscodeParams{
-			Template:     c.Stash().Get(shared.StashPathPasscodeTemplate).String(),
-			EmailAddress: c.Stash().Get(shared.StashPathEmail).String(),
-			Language:     deps.HttpContext.Request().Header.Get("Accept-Language"),
+			Template:       c.Stash().Get(shared.StashPathPasscodeTemplate).String(),
+			EmailAddress:   c.Stash().Get(shared.StashPathEmail).String(),
+			Language:       lang,
+			FlowID:         c.GetFlowID(),
+			BrowserBinding: browserBinding,
 		}
 
 		passcodeResult, err := deps.PasscodeService.SendPasscode(deps.Tx, sendParams)
@@ -88,6 +96,7 @@ func (h SendPasscode) Execute(c flowpilot.HookExecutionContext) error {
 		webhookData := webhook.EmailSend{
 			Subject:          passcodeResult.Subject,
 			BodyPlain:        passcodeResult.Body,
+			Body:             passcodeResult.HTMLBody,
 			ToEmailAddress:   sendParams.EmailAddress,
 			DeliveredByHanko: deps.Cfg.EmailDelivery.Enabled,
 			AcceptLanguage:   sendParams.Language,
@@ -95,6 +104,7 @@ func (h SendPasscode) Execute(c flowpilot.HookExecutionContext) error {
 			Data: webhook.PasscodeData{
 				ServiceName: deps.Cfg.Service.Name,
 				OtpCode:     passcodeResult.Code,
+				Link:        passcodeResult.Link,
 				TTL:         deps.Cfg.Email.PasscodeTtl,
 				ValidUntil:  passcodeResult.PasscodeModel.CreatedAt.Add(time.Duration(deps.Cfg.Email.PasscodeTtl) * time.Second).UTC().Unix(),
 			},
@@ -1,6 +1,8 @@
 package flow
 
 import (
+	"fmt"
+	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/flow_api/flow/capabilities"
 	"github.com/teamhanko/hanko/backend/flow_api/flow/credential_onboarding"
 	"github.com/teamhanko/hanko/backend/flow_api/flow/credential_usage"
@@ -42,6 +44,19 @@ var CredentialUsageSubFlow = flowpilot.NewSubFlow(shared.FlowCredentialUsage).
 		credential_usage.PasswordRecovery{}).
 	State(shared.StatePasscodeConfirmation,
 		credential_usage.VerifyPasscode{},
+		credential_usage.VerifyPasscodeLink{},
+		credential_usage.ReSendPasscode{},
+		shared.Back{}).
+	BeforeState(shared.StatePasscodeConfirmation,
+		credential_usage.SendPasscode{}).
+	MustBuild()
+
+// PasscodeConfirmationSubFlow contains the passcode confirmation of the CredentialUsageSubFlow, which the registration
+// and profile flows use to verify email addresses.
+var PasscodeConfirmationSubFlow = flowpilot.NewSubFlow(shared.FlowCredentialUsage).
+	State(shared.StatePasscodeConfirmation,
+		credential_usage.VerifyPasscode{},
+		credential_usage.VerifyPasscodeLink{},
 		credential_usage.ReSendPasscode{},
 		shared.Back{}).
 	BeforeState(shared.StatePasscodeConfirmation,
@@ -67,16 +82,72 @@ var CredentialOnboardingSubFlow = flowpilot.NewSubFlow(shared.FlowCredentialOnbo
 		shared.Back{}).
 	MustBuild()
 
-var UserDetailsSubFlow = flowpilot.NewSubFlow(shared.FlowUserDetails).
+// RegistrationUserDetailsSubFlow contains the username onboarding of users registering with a third party provider.
+// The email address is entered when registering, so it is not acquired afterwards. Unlike in the login flow, the
+// onboarding steps of the registration flow do not depend on flow.login_onboarding.
+var RegistrationUserDetailsSubFlow = flowpilot.NewSubFlow(shared.FlowUserDetails).
 	State(shared.StateOnboardingUsername,
 		user_details.UsernameSet{},
 		user_details.SkipUsername{}).
-	State(shared.StateOnboardingEmail,
-		user_details.EmailAddressSet{},
-		user_details.SkipEmail{}).
 	MustBuild()
 
-func NewLoginFlow(debug bool) flowpilot.Flow {
+// newLoginCredentialOnboardingSubFlow returns the credential onboarding sub-flow of the login flow, containing only the
+// states of the configured onboarding steps.
+func newLoginCredentialOnboardingSubFlow(onboarding config.FlowOnboarding) flowpilot.SubFlowBuilder {
+	subFlow := flowpilot.NewSubFlow(shared.FlowCredentialOnboarding)
+	credentials := onboarding.HasStep(config.FlowOnboardingStepCredentials)
+
+	if credentials {
+		subFlow.State(shared.StateCredentialOnboardingChooser,
+			credential_onboarding.ContinueToPasskey{},
+			credential_onboarding.ContinueToPassword{},
+			credential_onboarding.SkipCredentialOnboardingMethodChooser{},
+			shared.Back{})
+	}
+
+	if credentials || onboarding.HasStep(config.FlowOnboardingStepPasskey) {
+		subFlow.State(shared.StateOnboardingCreatePasskey,
+			credential_onboarding.WebauthnGenerateCreationOptions{},
+			credential_onboarding.SkipPasskey{},
+			shared.Back{}).
+			State(shared.StateOnboardingVerifyPasskeyAttestation,
+				credential_onboarding.WebauthnVerifyAttestationResponse{},
+				shared.Back{})
+	}
+
+	if credentials || onboarding.HasStep(config.FlowOnboardingStepPassword) {
+		subFlow.State(shared.StatePasswordCreation,
+			credential_onboarding.RegisterPassword{},
+			credential_onboarding.SkipPassword{},
+			shared.Back{})
+	}
+
+	return subFlow
+}
+
+// newLoginUserDetailsSubFlow returns the user details sub-flow of the login flow, containing only the states of the
+// configured onboarding steps.
+func newLoginUserDetailsSubFlow(onboarding config.FlowOnboarding) flowpilot.SubFlowBuilder {
+	subFlow := flowpilot.NewSubFlow(shared.FlowUserDetails)
+
+	if onboarding.HasStep(config.FlowOnboardingStepUsername) {
+		subFlow.State(shared.StateOnboardingUsername,
+			user_details.UsernameSet{},
+			user_details.SkipUsername{})
+	}
+
+	if onboarding.HasStep(config.FlowOnboardingStepEmail) {
+		subFlow.State(shared.StateOnboardingEmail,
+			user_details.EmailAddressSet{},
+			user_details.SkipEmail{})
+	}
+
+	return subFlow
+}
+
+func NewLoginFlow(cfg config.Config) flowpilot.Flow {
+	onboarding := cfg.Flow.LoginOnboarding
+
 	return flowpilot.NewFlow(shared.FlowLogin).
 		State(shared.StateSuccess).
 		InitialState(shared.StatePreflight, shared.StateLoginInit).
@@ -94,18 +165,31 @@ func NewLoginFlow(debug bool) flowpilot.Flow {
 			shared.PasswordSave{}).
 		AfterState(shared.StateOnboardingEmail, login.CreateEmail{}).
 		AfterState(shared.StatePasscodeConfirmation, login.CreateEmail{}).
-		AfterFlow(shared.FlowCredentialUsage, login.ScheduleOnboardingStates{}).
+		AfterFlow(shared.FlowCredentialUsage, &login.ScheduleOnboardingStates{Steps: onboarding.Steps}).
 		SubFlows(
 			CapabilitiesSubFlow,
 			CredentialUsageSubFlow,
-			CredentialOnboardingSubFlow,
-			UserDetailsSubFlow).
+			newLoginCredentialOnboardingSubFlow(onboarding).MustBuild(),
+			newLoginUserDetailsSubFlow(onboarding).MustBuild()).
 		TTL(24 * time.Hour).
-		Debug(debug).
+		Debug(cfg.Debug).
 		MustBuild()
 }
 
-func NewRegistrationFlow(debug bool) flowpilot.Flow {
+// ValidateFlows checks the flows of the flow API, including the login flow resulting from the configured onboarding
+// steps, for unreachable states and dead ends.
+func ValidateFlows(cfg config.Config) error {
+	for _, flow := range []flowpilot.Flow{NewLoginFlow(cfg), NewRegistrationFlow(cfg), NewProfileFlow(cfg)} {
+		description := flow.Describe()
+		if err := description.Validate(); err != nil {
+			return fmt.Errorf("invalid %s flow: %w", description.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func NewRegistrationFlow(cfg config.Config) flowpilot.Flow {
 	return flowpilot.NewFlow(shared.FlowRegistration).
 		State(shared.StateRegistrationInit,
 			registration.RegisterLoginIdentifier{},
@@ -122,15 +206,15 @@ func NewRegistrationFlow(debug bool) flowpilot.Flow {
 			registration.CreateUser{}).
 		SubFlows(
 			CapabilitiesSubFlow,
-			CredentialUsageSubFlow,
+			PasscodeConfirmationSubFlow,
 			CredentialOnboardingSubFlow,
-			UserDetailsSubFlow).
+			RegistrationUserDetailsSubFlow).
 		TTL(24 * time.Hour).
-		Debug(debug).
+		Debug(cfg.Debug).
 		MustBuild()
 }
 
-func NewProfileFlow(debug bool) flowpilot.Flow {
+func NewProfileFlow(cfg config.Config) flowpilot.Flow {
 	return flowpilot.NewFlow(shared.FlowProfile).
 		State(shared.StateProfileInit,
 			profile.AccountDelete{},
@@ -141,6 +225,7 @@ func NewProfileFlow(debug bool) flowpilot.Flow {
 			profile.PasswordCreate{},
 			profile.PasswordUpdate{},
 			profile.PasswordDelete{},
+			profile.PreferredLanguageUpdate{},
 			profile.UsernameCreate{},
 			profile.UsernameUpdate{},
 			profile.UsernameDelete{},
@@ -161,8 +246,8 @@ func NewProfileFlow(debug bool) flowpilot.Flow {
 		AfterState(shared.StatePasscodeConfirmation, shared.EmailPersistVerifiedStatus{}).
 		SubFlows(
 			CapabilitiesSubFlow,
-			CredentialUsageSubFlow).
+			PasscodeConfirmationSubFlow).
 		TTL(24 * time.Hour).
-		Debug(debug).
+		Debug(cfg.Debug).
 		MustBuild()
 }
@@ -0,0 +1,33 @@
+package flow
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/teamhanko/hanko/backend/config"
+)
+
+func TestValidateFlows(t *testing.T) {
+	stepOrders := [][]config.FlowOnboardingStep{
+		config.DefaultConfig().Flow.LoginOnboarding.Steps,
+		{config.FlowOnboardingStepCredentials, config.FlowOnboardingStepEmail, config.FlowOnboardingStepUsername},
+		{config.FlowOnboardingStepPasskey, config.FlowOnboardingStepUsername},
+		{config.FlowOnboardingStepPassword, config.FlowOnboardingStepPasskey},
+		{config.FlowOnboardingStepEmail, config.FlowOnboardingStepPassword},
+		{config.FlowOnboardingStepUsername},
+		{},
+	}
+
+	for _, steps := range stepOrders {
+		t.Run(fmt.Sprintf("%v", steps), func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Flow.LoginOnboarding.Steps = steps
+
+			assert.NoError(t, NewLoginFlow(*cfg).Describe().Validate())
+			assert.NoError(t, NewRegistrationFlow(*cfg).Describe().Validate())
+			assert.NoError(t, NewProfileFlow(*cfg).Describe().Validate())
+			assert.NoError(t, ValidateFlows(*cfg))
+		})
+	}
+}
@@ -2,12 +2,57 @@ package login
 
 import (
 	"fmt"
+	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/flow_api/flow/shared"
 	"github.com/teamhanko/hanko/backend/flowpilot"
 )
 
+// ScheduleOnboardingStates schedules the states of the configured onboarding steps, in the configured order. It must
+// be added to the flow as a pointer, as the hooks of a flow must be comparable.
 type ScheduleOnboardingStates struct {
 	shared.Action
+	Steps []config.FlowOnboardingStep
+}
+
+func (h ScheduleOnboardingStates) GetTransitions() []flowpilot.Transition {
+	transitions := make([]flowpilot.Transition, 0)
+
+	for i, step := range h.Steps {
+		// the following steps are described by their first variant only
+		var following flowpilot.Transition
+		for _, next := range h.Steps[i+1:] {
+			following = append(following, onboardingStepStates(next)[0]...)
+		}
+
+		for _, states := range onboardingStepStates(step) {
+			transition := append(append(flowpilot.Transition{}, states...), following...)
+			transitions = append(transitions, append(transition, shared.StateSuccess))
+		}
+	}
+
+	return append(transitions, flowpilot.Transition{shared.StateSuccess})
+}
+
+// onboardingStepStates returns the variants of the states an onboarding step can schedule.
+func onboardingStepStates(step config.FlowOnboardingStep) []flowpilot.Transition {
+	switch step {
+	case config.FlowOnboardingStepUsername:
+		return []flowpilot.Transition{{shared.StateOnboardingUsername}}
+	case config.FlowOnboardingStepEmail:
+		return []flowpilot.Transition{{shared.StateOnboardingEmail}}
+	case config.FlowOnboardingStepCredentials:
+		return []flowpilot.Transition{
+			{shared.StateCredentialOnboardingChooser},
+			{shared.StateOnboardingCreatePasskey, shared.StatePasswordCreation},
+			{shared.StatePasswordCreation, shared.StateOnboardingCreatePasskey},
+		}
+	case config.FlowOnboardingStepPasskey:
+		return []flowpilot.Transition{{shared.StateOnboardingCreatePasskey}}
+	case config.FlowOnboardingStepPassword:
+		return []flowpilot.Transition{{shared.StatePasswordCreation}}
+	default:
+		return []flowpilot.Transition{{}}
+	}
 }
 
 func (h ScheduleOnboardingStates) Execute(c flowpilot.HookExecutionContext) error {
@@ -34,22 +79,66 @@ func (h ScheduleOnboardingStates) Execute(c flowpilot.HookExecutionContext) erro
 		return fmt.Errorf("failed to set user_has_webauthn_credential to the stash: %w", err)
 	}
 
-	userDetailOnboardingStates := h.determineUserDetailOnboardingStates(c, userHasUsername, userHasEmail)
-	credentialOnboardingStates := h.determineCredentialOnboardingStates(c, userHasPasskey, userHasPassword)
+	onboardingStates := h.onboardingStates(deps.Cfg, onboardingUser{
+		hasPassword:       userHasPassword,
+		hasPasskey:        userHasPasskey,
+		hasUsername:       userHasUsername,
+		hasEmail:          userHasEmail,
+		webauthnAvailable: c.Stash().Get(shared.StashPathWebauthnAvailable).Bool(),
+	})
 
-	c.ScheduleStates(append(userDetailOnboardingStates, append(credentialOnboardingStates, shared.StateSuccess)...)...)
+	c.ScheduleStates(append(onboardingStates, shared.StateSuccess)...)
 
 	return nil
 }
 
-func (h ScheduleOnboardingStates) determineCredentialOnboardingStates(c flowpilot.HookExecutionContext, hasPasskey, hasPassword bool) []flowpilot.StateName {
-	deps := h.GetDeps(c)
-	cfg := deps.Cfg
+// onboardingUser holds what the onboarding states of a user depend on besides the configuration.
+type onboardingUser struct {
+	hasPassword       bool
+	hasPasskey        bool
+	hasUsername       bool
+	hasEmail          bool
+	webauthnAvailable bool
+}
+
+// onboardingStates returns the states of the configured onboarding steps the user has to go through, in the
+// configured order. Every state must be part of the transitions described by GetTransitions.
+func (h ScheduleOnboardingStates) onboardingStates(cfg config.Config, user onboardingUser) []flowpilot.StateName {
+	onboardingStates := make([]flowpilot.StateName, 0)
+
+	for _, step := range h.Steps {
+		switch step {
+		case config.FlowOnboardingStepUsername:
+			if !user.hasUsername && cfg.Username.Enabled && cfg.Username.AcquireOnLogin {
+				onboardingStates = append(onboardingStates, shared.StateOnboardingUsername)
+			}
+		case config.FlowOnboardingStepEmail:
+			if !user.hasEmail && cfg.Email.Enabled && cfg.Email.AcquireOnLogin {
+				onboardingStates = append(onboardingStates, shared.StateOnboardingEmail)
+			}
+		case config.FlowOnboardingStepCredentials:
+			onboardingStates = append(onboardingStates, credentialOnboardingStates(cfg, user)...)
+		case config.FlowOnboardingStepPasskey:
+			if !user.hasPasskey && cfg.Passkey.Enabled && user.webauthnAvailable {
+				onboardingStates = append(onboardingStates, shared.StateOnboardingCreatePasskey)
+			}
+		case config.FlowOnboardingStepPassword:
+			if !user.hasPassword && cfg.Password.Enabled {
+				onboardingStates = append(onboardingStates, shared.StatePasswordCreation)
+			}
+		}
+	}
+
+	return onboardingStates
+}
+
+// credentialOnboardingStates returns the states of the credentials onboarding step the user has to go through.
+func credentialOnboardingStates(cfg config.Config, user onboardingUser) []flowpilot.StateName {
+	hasPasskey, hasPassword := user.hasPasskey, user.hasPassword
 	result := make([]flowpilot.StateName, 0)
 
-	webauthnAvailable := c.Stash().Get(shared.StashPathWebauthnAvailable).Bool()
-	passkeyEnabled := webauthnAvailable && deps.Cfg.Passkey.Enabled
-	passwordEnabled := deps.Cfg.Password.Enabled
+	passkeyEnabled := user.webauthnAvailable && cfg.Passkey.Enabled
+	passwordEnabled := cfg.Password.Enabled
 	passwordAndPasskeyEnabled := passkeyEnabled && passwordEnabled
 
 	alwaysAcquirePasskey := cfg.Passkey.AcquireOnLogin == "always"
@@ -117,21 +206,3 @@ func (h ScheduleOnboardingStates) determineCredentialOnboardingStates(c flowpilo
 
 	return result
 }
-
-func (h ScheduleOnboardingStates) determineUserDetailOnboardingStates(c flowpilot.HookExecutionContext, userHasUsername, userHasEmail bool) []flowpilot.StateName {
-	deps := h.GetDeps(c)
-	cfg := deps.Cfg
-	result := make([]flowpilot.StateName, 0)
-	acquireUsername := !userHasUsername && cfg.Username.Enabled && cfg.Username.AcquireOnLogin
-	acquireEmail := !userHasEmail && cfg.Email.Enabled && cfg.Email.AcquireOnLogin
-
-	if acquireUsername && acquireEmail {
-		result = append(result, shared.StateOnboardingUsername, shared.StateOnboardingEmail)
-	} else if acquireUsername {
-		result = append(result, shared.StateOnboardingUsername)
-	} else if acquireEmail {
-		result = append(result, shared.StateOnboardingEmail)
-	}
-
-	return result
-}
@@ -0,0 +1,131 @@
+package login
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/flow_api/flow/shared"
+	"github.com/teamhanko/hanko/backend/flowpilot"
+)
+
+// onboardingStepOrders are step orders the onboarding is tested with.
+var onboardingStepOrders = [][]config.FlowOnboardingStep{
+	{config.FlowOnboardingStepUsername, config.FlowOnboardingStepEmail, config.FlowOnboardingStepCredentials},
+	{config.FlowOnboardingStepCredentials, config.FlowOnboardingStepEmail, config.FlowOnboardingStepUsername},
+	{config.FlowOnboardingStepPasskey, config.FlowOnboardingStepUsername},
+	{config.FlowOnboardingStepPassword, config.FlowOnboardingStepPasskey, config.FlowOnboardingStepEmail},
+	{config.FlowOnboardingStepEmail},
+	{},
+}
+
+// onboardingConfigs returns configurations with all combinations of the settings the onboarding states depend on.
+func onboardingConfigs() []config.Config {
+	acquireOnLogin := []string{"always", "conditional", "never"}
+
+	var configs []config.Config
+	for _, enabled := range [][2]bool{{true, true}, {true, false}, {false, true}} {
+		for _, passkeyAcquire := range acquireOnLogin {
+			for _, passwordAcquire := range acquireOnLogin {
+				for _, optional := range [][2]bool{{true, true}, {true, false}, {false, true}} {
+					cfg := config.DefaultConfig()
+					cfg.Passkey.Enabled, cfg.Password.Enabled = enabled[0], enabled[1]
+					cfg.Passkey.AcquireOnLogin, cfg.Password.AcquireOnLogin = passkeyAcquire, passwordAcquire
+					cfg.Passkey.Optional, cfg.Password.Optional = optional[0], optional[1]
+					cfg.Username.Enabled, cfg.Username.AcquireOnLogin = true, true
+					cfg.Email.Enabled, cfg.Email.AcquireOnLogin = true, true
+					configs = append(configs, *cfg)
+				}
+			}
+		}
+	}
+
+	return configs
+}
+
+// onboardingUsers returns users with all combinations of existing credentials and identifiers.
+func onboardingUsers() []onboardingUser {
+	var users []onboardingUser
+	for i := 0; i < 32; i++ {
+		users = append(users, onboardingUser{
+			hasPassword:       i&1 != 0,
+			hasPasskey:        i&2 != 0,
+			hasUsername:       i&4 != 0,
+			hasEmail:          i&8 != 0,
+			webauthnAvailable: i&16 != 0,
+		})
+	}
+
+	return users
+}
+
+func TestScheduleOnboardingStates_Transitions(t *testing.T) {
+	for _, steps := range onboardingStepOrders {
+		t.Run(fmt.Sprintf("%v", steps), func(t *testing.T) {
+			hook := ScheduleOnboardingStates{Steps: steps}
+
+			described := make(map[flowpilot.StateName]bool)
+			for _, transition := range hook.GetTransitions() {
+				assert.Equal(t, shared.StateSuccess, transition[len(transition)-1], "every transition must end with the success state")
+				for _, state := range transition {
+					described[state] = true
+				}
+			}
+
+			for _, cfg := range onboardingConfigs() {
+				for _, user := range onboardingUsers() {
+					for _, state := range hook.onboardingStates(cfg, user) {
+						assert.True(t, described[state], "state %s is scheduled but not described, config: passkey %+v, password %+v, user: %+v", state, cfg.Passkey, cfg.Password, user)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestScheduleOnboardingStates_OnboardingStates(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Passkey.Enabled, cfg.Passkey.AcquireOnLogin = true, "always"
+	cfg.Password.Enabled, cfg.Password.AcquireOnLogin = true, "never"
+	cfg.Username.Enabled, cfg.Username.AcquireOnLogin = true, true
+	cfg.Email.Enabled, cfg.Email.AcquireOnLogin = true, true
+
+	tests := []struct {
+		name     string
+		steps    []config.FlowOnboardingStep
+		user     onboardingUser
+		expected []flowpilot.StateName
+	}{
+		{
+			name:     "configured order",
+			steps:    []config.FlowOnboardingStep{config.FlowOnboardingStepCredentials, config.FlowOnboardingStepEmail, config.FlowOnboardingStepUsername},
+			user:     onboardingUser{webauthnAvailable: true},
+			expected: []flowpilot.StateName{shared.StateOnboardingCreatePasskey, shared.StateOnboardingEmail, shared.StateOnboardingUsername},
+		},
+		{
+			name:     "completed steps are skipped",
+			steps:    []config.FlowOnboardingStep{config.FlowOnboardingStepUsername, config.FlowOnboardingStepEmail, config.FlowOnboardingStepPasskey},
+			user:     onboardingUser{hasUsername: true, hasPasskey: true, webauthnAvailable: true},
+			expected: []flowpilot.StateName{shared.StateOnboardingEmail},
+		},
+		{
+			name:     "passkey without webauthn",
+			steps:    []config.FlowOnboardingStep{config.FlowOnboardingStepPasskey, config.FlowOnboardingStepPassword},
+			user:     onboardingUser{},
+			expected: []flowpilot.StateName{shared.StatePasswordCreation},
+		},
+		{
+			name:     "no steps",
+			user:     onboardingUser{webauthnAvailable: true},
+			expected: []flowpilot.StateName{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := ScheduleOnboardingStates{Steps: tt.steps}
+			assert.Equal(t, tt.expected, hook.onboardingStates(*cfg, tt.user))
+		})
+	}
+}
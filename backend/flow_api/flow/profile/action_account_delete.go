@@ -23,6 +23,12 @@ func (a AccountDelete) GetDescription() string {
 	return "Delete an account."
 }
 
+func (a AccountDelete) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StateProfileAccountDeleted},
+	}
+}
+
 func (a AccountDelete) Initialize(c flowpilot.InitializationContext) {
 	deps := a.GetDeps(c)
 
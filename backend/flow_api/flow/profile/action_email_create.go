@@ -22,6 +22,13 @@ func (a EmailCreate) GetDescription() string {
 	return "Create an email address for the current session user."
 }
 
+func (a EmailCreate) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StatePasscodeConfirmation, shared.StateProfileInit},
+		{shared.StateProfileInit},
+	}
+}
+
 func (a EmailCreate) Initialize(c flowpilot.InitializationContext) {
 	deps := a.GetDeps(c)
 
@@ -57,6 +64,21 @@ func (a EmailCreate) Execute(c flowpilot.ExecutionContext) error {
 		if (existingEmailModel.UserID != nil && existingEmailModel.UserID.String() == userModel.ID.String()) || !deps.Cfg.Email.RequireVerification {
 			c.Input().SetError("email", shared.ErrorEmailAlreadyExists)
 			return c.Error(flowpilot.ErrorFormDataInvalid)
+		} else if deps.Cfg.Email.VerificationLink.Enabled {
+			rateLimited, err := shared.EmailVerificationLinkRateLimited(c, deps, newEmailAddress)
+			if err != nil {
+				return err
+			}
+			if rateLimited {
+				return c.Error(shared.ErrorRateLimitExceeded)
+			}
+
+			err = shared.SendEmailRegistrationAttempted(deps, userModel.ID, newEmailAddress)
+			if err != nil {
+				return fmt.Errorf("failed to send email registration attempted notification: %w", err)
+			}
+
+			return a.continueAfterVerificationLink(c, newEmailAddress)
 		} else {
 			err = c.CopyInputValuesToStash("email")
 			if err != nil {
@@ -75,6 +97,21 @@ func (a EmailCreate) Execute(c flowpilot.ExecutionContext) error {
 
 			return c.Continue(shared.StatePasscodeConfirmation)
 		}
+	} else if deps.Cfg.Email.RequireVerification && deps.Cfg.Email.VerificationLink.Enabled {
+		rateLimited, err := shared.EmailVerificationLinkRateLimited(c, deps, newEmailAddress)
+		if err != nil {
+			return err
+		}
+		if rateLimited {
+			return c.Error(shared.ErrorRateLimitExceeded)
+		}
+
+		err = shared.SendEmailVerificationLink(deps, userModel.ID, newEmailAddress)
+		if err != nil {
+			return fmt.Errorf("failed to send email verification link: %w", err)
+		}
+
+		return a.continueAfterVerificationLink(c, newEmailAddress)
 	} else if deps.Cfg.Email.RequireVerification {
 		err = c.CopyInputValuesToStash("email")
 		if err != nil {
@@ -131,3 +168,14 @@ func (a EmailCreate) Execute(c flowpilot.ExecutionContext) error {
 		return c.Continue(shared.StateProfileInit)
 	}
 }
+
+// continueAfterVerificationLink returns to the profile after a verification link has been sent. The email address is
+// added to the account when the link is opened.
+func (a EmailCreate) continueAfterVerificationLink(c flowpilot.ExecutionContext, emailAddress string) error {
+	err := c.Payload().Set("email_verification_link_sent", emailAddress)
+	if err != nil {
+		return fmt.Errorf("failed to set email_verification_link_sent to the payload: %w", err)
+	}
+
+	return c.Continue(shared.StateProfileInit)
+}
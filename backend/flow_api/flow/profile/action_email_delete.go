@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/gofrs/uuid"
 	auditlog "github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/dto/webhook"
 	"github.com/teamhanko/hanko/backend/flow_api/flow/shared"
 	"github.com/teamhanko/hanko/backend/flow_api/services"
 	"github.com/teamhanko/hanko/backend/flowpilot"
@@ -25,6 +26,12 @@ func (a EmailDelete) GetDescription() string {
 	return "Delete an email address."
 }
 
+func (a EmailDelete) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StateProfileInit},
+	}
+}
+
 func (a EmailDelete) Initialize(c flowpilot.InitializationContext) {
 	deps := a.GetDeps(c)
 	userModel, ok := c.Get("session_user").(*models.User)
@@ -118,7 +125,18 @@ func (a EmailDelete) Execute(c flowpilot.ExecutionContext) error {
 
 	userModel.DeleteEmail(*emailToBeDeletedModel)
 
+	if deps.Cfg.SecurityNotifications.EmailDeleted.Enabled {
+		shared.SendSecurityNotification(deps, userModel.ID, webhook.EmailTypeEmailDeleted, webhook.SecurityNotificationData{
+			EmailAddress: emailToBeDeletedModel.Address,
+		}, emailToBeDeletedModel.Address)
+	}
+
 	utils.NotifyUserChange(deps.HttpContext, deps.Tx, deps.Persister, events.UserEmailDelete, userModel.ID)
 
+	// identities are deleted along with the email they are associated with
+	if len(emailToBeDeletedModel.Identities) > 0 {
+		utils.NotifyUserChange(deps.HttpContext, deps.Tx, deps.Persister, events.UserIdentityUnlink, userModel.ID)
+	}
+
 	return c.Continue(shared.StateProfileInit)
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/gofrs/uuid"
 	auditlog "github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/dto/webhook"
 	"github.com/teamhanko/hanko/backend/flow_api/flow/shared"
 	"github.com/teamhanko/hanko/backend/flowpilot"
 	"github.com/teamhanko/hanko/backend/persistence/models"
@@ -23,6 +24,12 @@ func (a EmailSetPrimary) GetDescription() string {
 	return "Sets a an email address as the primary email address."
 }
 
+func (a EmailSetPrimary) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StateProfileInit},
+	}
+}
+
 func (a EmailSetPrimary) Initialize(c flowpilot.InitializationContext) {
 	deps := a.GetDeps(c)
 
@@ -74,8 +81,10 @@ func (a EmailSetPrimary) Execute(c flowpilot.ExecutionContext) error {
 	}
 
 	var primaryEmail *models.PrimaryEmail
+	var previousPrimaryEmailAddress string
 	if e := userModel.Emails.GetPrimary(); e != nil {
 		primaryEmail = e.PrimaryEmail
+		previousPrimaryEmailAddress = e.Address
 	}
 
 	if primaryEmail == nil {
@@ -106,6 +115,13 @@ func (a EmailSetPrimary) Execute(c flowpilot.ExecutionContext) error {
 	}
 
 	userModel.SetPrimaryEmail(primaryEmail)
+
+	if deps.Cfg.SecurityNotifications.PrimaryEmailChanged.Enabled {
+		shared.SendSecurityNotification(deps, userModel.ID, webhook.EmailTypePrimaryEmailChanged, webhook.SecurityNotificationData{
+			EmailAddress:         emailModel.Address,
+			PreviousEmailAddress: previousPrimaryEmailAddress,
+		}, previousPrimaryEmailAddress)
+	}
 	utils.NotifyUserChange(deps.HttpContext, deps.Tx, deps.Persister, events.UserEmailPrimary, userModel.ID)
 
 	return c.Continue(shared.StateProfileInit)
@@ -20,6 +20,13 @@ func (a EmailVerify) GetDescription() string {
 	return "Verify an email."
 }
 
+func (a EmailVerify) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StatePasscodeConfirmation, shared.StateProfileInit},
+		{shared.StateProfileInit},
+	}
+}
+
 func (a EmailVerify) Initialize(c flowpilot.InitializationContext) {
 	deps := a.GetDeps(c)
 
@@ -43,6 +50,8 @@ func (a EmailVerify) Initialize(c flowpilot.InitializationContext) {
 }
 
 func (a EmailVerify) Execute(c flowpilot.ExecutionContext) error {
+	deps := a.GetDeps(c)
+
 	if valid := c.ValidateInputData(); !valid {
 		return c.Error(flowpilot.ErrorFormDataInvalid)
 	}
@@ -57,6 +66,28 @@ func (a EmailVerify) Execute(c flowpilot.ExecutionContext) error {
 		return c.Error(shared.ErrorNotFound)
 	}
 
+	if deps.Cfg.Email.VerificationLink.Enabled {
+		rateLimited, err := shared.EmailVerificationLinkRateLimited(c, deps, emailModel.Address)
+		if err != nil {
+			return err
+		}
+		if rateLimited {
+			return c.Error(shared.ErrorRateLimitExceeded)
+		}
+
+		err = shared.SendEmailVerificationLink(deps, userModel.ID, emailModel.Address)
+		if err != nil {
+			return fmt.Errorf("failed to send email verification link: %w", err)
+		}
+
+		err = c.Payload().Set("email_verification_link_sent", emailModel.Address)
+		if err != nil {
+			return fmt.Errorf("failed to set email_verification_link_sent to the payload: %w", err)
+		}
+
+		return c.Continue(shared.StateProfileInit)
+	}
+
 	err := c.Stash().Set(shared.StashPathEmail, emailModel.Address)
 	if err != nil {
 		return fmt.Errorf("failed to set email address to verify to stash: %w", err)
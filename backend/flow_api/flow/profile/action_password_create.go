@@ -3,9 +3,12 @@ package profile
 import (
 	"fmt"
 	auditlog "github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/dto/webhook"
 	"github.com/teamhanko/hanko/backend/flow_api/flow/shared"
 	"github.com/teamhanko/hanko/backend/flowpilot"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/webhooks/events"
+	"github.com/teamhanko/hanko/backend/webhooks/utils"
 )
 
 type PasswordCreate struct {
@@ -20,6 +23,12 @@ func (a PasswordCreate) GetDescription() string {
 	return "Create a new password."
 }
 
+func (a PasswordCreate) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StateProfileInit},
+	}
+}
+
 func (a PasswordCreate) Initialize(c flowpilot.InitializationContext) {
 	deps := a.GetDeps(c)
 
@@ -76,7 +85,13 @@ func (a PasswordCreate) Execute(c flowpilot.ExecutionContext) error {
 		return fmt.Errorf("could not create audit log: %w", err)
 	}
 
+	if deps.Cfg.SecurityNotifications.PasswordChanged.Enabled {
+		shared.SendSecurityNotification(deps, userModel.ID, webhook.EmailTypePasswordChanged, webhook.SecurityNotificationData{})
+	}
+
 	userModel.PasswordCredential = passwordCredential
 
+	utils.NotifyUserChange(deps.HttpContext, deps.Tx, deps.Persister, events.UserPassword, userModel.ID)
+
 	return c.Continue(shared.StateProfileInit)
 }
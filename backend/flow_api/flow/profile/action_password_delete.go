@@ -7,6 +7,8 @@ import (
 	"github.com/teamhanko/hanko/backend/flow_api/services"
 	"github.com/teamhanko/hanko/backend/flowpilot"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/webhooks/events"
+	"github.com/teamhanko/hanko/backend/webhooks/utils"
 )
 
 type PasswordDelete struct {
@@ -21,6 +23,12 @@ func (a PasswordDelete) GetDescription() string {
 	return "Delete a password."
 }
 
+func (a PasswordDelete) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StateProfileInit},
+	}
+}
+
 func (a PasswordDelete) Initialize(c flowpilot.InitializationContext) {
 	if a.mustSuspend(c) {
 		c.SuspendAction()
@@ -64,6 +72,8 @@ func (a PasswordDelete) Execute(c flowpilot.ExecutionContext) error {
 
 	userModel.PasswordCredential = nil
 
+	utils.NotifyUserChange(deps.HttpContext, deps.Tx, deps.Persister, events.UserPassword, userModel.ID)
+
 	return c.Continue(shared.StateProfileInit)
 }
 
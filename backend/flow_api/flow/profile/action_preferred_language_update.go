@@ -0,0 +1,95 @@
+package profile
+
+import (
+	"fmt"
+	auditlog "github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/flow_api/flow/shared"
+	"github.com/teamhanko/hanko/backend/flowpilot"
+	"github.com/teamhanko/hanko/backend/mail"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/webhooks/events"
+	"github.com/teamhanko/hanko/backend/webhooks/utils"
+	"time"
+)
+
+type PreferredLanguageUpdate struct {
+	shared.Action
+}
+
+func (a PreferredLanguageUpdate) GetName() flowpilot.ActionName {
+	return shared.ActionPreferredLanguageUpdate
+}
+
+func (a PreferredLanguageUpdate) GetDescription() string {
+	return "Update the language used for emails. An empty value removes the preferred language."
+}
+
+func (a PreferredLanguageUpdate) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StateProfileInit},
+	}
+}
+
+func (a PreferredLanguageUpdate) Initialize(c flowpilot.InitializationContext) {
+	if _, ok := c.Get("session_user").(*models.User); !ok {
+		c.SuspendAction()
+		return
+	}
+
+	c.AddInputs(flowpilot.StringInput("preferred_language").
+		MaxLength(35).
+		TrimSpace(true))
+}
+
+func (a PreferredLanguageUpdate) Execute(c flowpilot.ExecutionContext) error {
+	deps := a.GetDeps(c)
+
+	if valid := c.ValidateInputData(); !valid {
+		return c.Error(flowpilot.ErrorFormDataInvalid)
+	}
+
+	userModel, ok := c.Get("session_user").(*models.User)
+	if !ok {
+		return c.Error(flowpilot.ErrorOperationNotPermitted)
+	}
+
+	var preferredLanguage *string
+	if value := c.Input().Get("preferred_language").String(); value != "" {
+		normalized, err := mail.NormalizeLanguage(value)
+		if err != nil {
+			c.Input().SetError("preferred_language", flowpilot.ErrorValueInvalid.Wrap(err))
+			return c.Error(flowpilot.ErrorFormDataInvalid.Wrap(err))
+		}
+		preferredLanguage = &normalized
+	}
+
+	userModel.PreferredLanguage = preferredLanguage
+	userModel.UpdatedAt = time.Now().UTC()
+
+	err := deps.Persister.GetUserPersisterWithConnection(deps.Tx).Update(*userModel)
+	if err != nil {
+		return fmt.Errorf("failed to update preferred language: %w", err)
+	}
+
+	auditLogValue := ""
+	if preferredLanguage != nil {
+		auditLogValue = *preferredLanguage
+	}
+
+	err = deps.AuditLogger.CreateWithConnection(
+		deps.Tx,
+		deps.HttpContext,
+		models.AuditLogPreferredLanguageChanged,
+		&models.User{ID: userModel.ID},
+		nil,
+		auditlog.Detail("preferred_language", auditLogValue),
+		auditlog.Detail("flow_id", c.GetFlowID()))
+
+	if err != nil {
+		return fmt.Errorf("could not create audit log: %w", err)
+	}
+
+	utils.NotifyUserChange(deps.HttpContext, deps.Tx, deps.Persister, events.UserUpdate, userModel.ID)
+
+	return c.Continue(shared.StateProfileInit)
+}
@@ -6,6 +6,8 @@ import (
 	"github.com/teamhanko/hanko/backend/flow_api/flow/shared"
 	"github.com/teamhanko/hanko/backend/flowpilot"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/webhooks/events"
+	"github.com/teamhanko/hanko/backend/webhooks/utils"
 )
 
 type SessionDelete struct {
@@ -20,6 +22,12 @@ func (a SessionDelete) GetDescription() string {
 	return "Delete a session."
 }
 
+func (a SessionDelete) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StateProfileInit},
+	}
+}
+
 func (a SessionDelete) Initialize(c flowpilot.InitializationContext) {
 	deps := a.GetDeps(c)
 	if !deps.Cfg.Session.ServerSide.Enabled {
@@ -75,6 +83,11 @@ func (a SessionDelete) Execute(c flowpilot.ExecutionContext) error {
 
 	if session != nil {
 		err = deps.Persister.GetSessionPersisterWithConnection(deps.Tx).Delete(*session)
+		if err != nil {
+			return fmt.Errorf("failed to delete session from db: %w", err)
+		}
+
+		utils.NotifySession(deps.HttpContext, deps.Tx, events.SessionRevoke, *session)
 	}
 
 	return c.Continue(shared.StateProfileInit)
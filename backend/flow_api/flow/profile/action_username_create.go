@@ -7,6 +7,8 @@ import (
 	"github.com/teamhanko/hanko/backend/flow_api/services"
 	"github.com/teamhanko/hanko/backend/flowpilot"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/webhooks/events"
+	"github.com/teamhanko/hanko/backend/webhooks/utils"
 )
 
 type UsernameCreate struct {
@@ -21,6 +23,12 @@ func (a UsernameCreate) GetDescription() string {
 	return "Create a new username."
 }
 
+func (a UsernameCreate) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StateProfileInit},
+	}
+}
+
 func (a UsernameCreate) Initialize(c flowpilot.InitializationContext) {
 	deps := a.GetDeps(c)
 
@@ -93,5 +101,7 @@ func (a UsernameCreate) Execute(c flowpilot.ExecutionContext) error {
 		return fmt.Errorf("could not create audit log: %w", err)
 	}
 
+	utils.NotifyUserChange(deps.HttpContext, deps.Tx, deps.Persister, events.UserUsername, userModel.ID)
+
 	return c.Continue(shared.StateProfileInit)
 }
@@ -6,6 +6,8 @@ import (
 	"github.com/teamhanko/hanko/backend/flow_api/flow/shared"
 	"github.com/teamhanko/hanko/backend/flowpilot"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/webhooks/events"
+	"github.com/teamhanko/hanko/backend/webhooks/utils"
 )
 
 type UsernameDelete struct {
@@ -20,6 +22,12 @@ func (a UsernameDelete) GetDescription() string {
 	return "Delete the username of a user."
 }
 
+func (a UsernameDelete) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StateProfileInit},
+	}
+}
+
 func (a UsernameDelete) Initialize(c flowpilot.InitializationContext) {
 	deps := a.GetDeps(c)
 
@@ -72,5 +80,7 @@ func (a UsernameDelete) Execute(c flowpilot.ExecutionContext) error {
 		return fmt.Errorf("could not create audit log: %w", err)
 	}
 
+	utils.NotifyUserChange(deps.HttpContext, deps.Tx, deps.Persister, events.UserUsername, userModel.ID)
+
 	return c.Continue(shared.StateProfileInit)
 }
@@ -7,6 +7,8 @@ import (
 	"github.com/teamhanko/hanko/backend/flow_api/services"
 	"github.com/teamhanko/hanko/backend/flowpilot"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/webhooks/events"
+	"github.com/teamhanko/hanko/backend/webhooks/utils"
 )
 
 type UsernameUpdate struct {
@@ -21,6 +23,12 @@ func (a UsernameUpdate) GetDescription() string {
 	return "Update an existing username."
 }
 
+func (a UsernameUpdate) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StateProfileInit},
+	}
+}
+
 func (a UsernameUpdate) Initialize(c flowpilot.InitializationContext) {
 	deps := a.GetDeps(c)
 
@@ -98,5 +106,7 @@ func (a UsernameUpdate) Execute(c flowpilot.ExecutionContext) error {
 		return fmt.Errorf("could not create audit log: %w", err)
 	}
 
+	utils.NotifyUserChange(deps.HttpContext, deps.Tx, deps.Persister, events.UserUsername, userModel.ID)
+
 	return c.Continue(shared.StateProfileInit)
 }
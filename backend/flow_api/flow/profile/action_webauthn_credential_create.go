@@ -21,6 +21,12 @@ func (a WebauthnCredentialCreate) GetDescription() string {
 	return "Create a Webauthn credential for the current session user."
 }
 
+func (a WebauthnCredentialCreate) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StateProfileWebauthnCredentialVerification},
+	}
+}
+
 func (a WebauthnCredentialCreate) Initialize(c flowpilot.InitializationContext) {
 	deps := a.GetDeps(c)
 
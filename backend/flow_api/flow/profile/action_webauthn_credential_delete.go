@@ -3,10 +3,13 @@ package profile
 import (
 	"fmt"
 	auditlog "github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/dto/webhook"
 	"github.com/teamhanko/hanko/backend/flow_api/flow/shared"
 	"github.com/teamhanko/hanko/backend/flow_api/services"
 	"github.com/teamhanko/hanko/backend/flowpilot"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/webhooks/events"
+	"github.com/teamhanko/hanko/backend/webhooks/utils"
 )
 
 type WebauthnCredentialDelete struct {
@@ -21,6 +24,12 @@ func (a WebauthnCredentialDelete) GetDescription() string {
 	return "Delete a Webauthn credential."
 }
 
+func (a WebauthnCredentialDelete) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StateProfileInit},
+	}
+}
+
 func (a WebauthnCredentialDelete) Initialize(c flowpilot.InitializationContext) {
 	if a.mustSuspend(c) {
 		c.SuspendAction()
@@ -65,8 +74,17 @@ func (a WebauthnCredentialDelete) Execute(c flowpilot.ExecutionContext) error {
 		return fmt.Errorf("could not create audit log: %w", err)
 	}
 
+	if deps.Cfg.SecurityNotifications.PasskeyDeleted.Enabled {
+		shared.SendSecurityNotification(deps, userModel.ID, webhook.EmailTypePasskeyDeleted, webhook.SecurityNotificationData{
+			PasskeyID:   webauthnCredentialModel.ID,
+			PasskeyName: shared.PasskeyDisplayName(*webauthnCredentialModel),
+		})
+	}
+
 	userModel.DeleteWebauthnCredential(webauthnCredentialModel.ID)
 
+	utils.NotifyUserChange(deps.HttpContext, deps.Tx, deps.Persister, events.UserPasskeyDelete, userModel.ID)
+
 	return c.Continue(shared.StateProfileInit)
 }
 
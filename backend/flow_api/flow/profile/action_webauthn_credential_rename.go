@@ -19,6 +19,12 @@ func (a WebauthnCredentialRename) GetDescription() string {
 	return "Rename a Webauthn credential."
 }
 
+func (a WebauthnCredentialRename) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StateProfileInit},
+	}
+}
+
 func (a WebauthnCredentialRename) Initialize(c flowpilot.InitializationContext) {
 	deps := a.GetDeps(c)
 
@@ -22,6 +22,12 @@ func (a WebauthnVerifyAttestationResponse) GetDescription() string {
 	return "Send the result which was generated by creating a webauthn credential."
 }
 
+func (a WebauthnVerifyAttestationResponse) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StateProfileInit},
+	}
+}
+
 func (a WebauthnVerifyAttestationResponse) Initialize(c flowpilot.InitializationContext) {
 	deps := a.GetDeps(c)
 
@@ -7,6 +7,7 @@ import (
 	"github.com/teamhanko/hanko/backend/flow_api/flow/shared"
 	"github.com/teamhanko/hanko/backend/flow_api/services"
 	"github.com/teamhanko/hanko/backend/flowpilot"
+	"github.com/teamhanko/hanko/backend/mail"
 	"strings"
 )
 
@@ -23,6 +24,16 @@ func (a RegisterLoginIdentifier) GetDescription() string {
 	return "Enter an identifier to register."
 }
 
+func (a RegisterLoginIdentifier) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StateThirdParty},
+		{shared.StatePasscodeConfirmation, shared.StateOnboardingCreatePasskey, shared.StatePasswordCreation, shared.StateSuccess},
+		{shared.StatePasswordCreation, shared.StateOnboardingCreatePasskey, shared.StateSuccess},
+		{shared.StateCredentialOnboardingChooser, shared.StateSuccess},
+		{shared.StateSuccess},
+	}
+}
+
 func (a RegisterLoginIdentifier) Initialize(c flowpilot.InitializationContext) {
 	deps := a.GetDeps(c)
 
@@ -57,6 +68,10 @@ func (a RegisterLoginIdentifier) Initialize(c flowpilot.InitializationContext) {
 
 		c.AddInputs(input)
 	}
+
+	c.AddInputs(flowpilot.StringInput("preferred_language").
+		MaxLength(35).
+		TrimSpace(true))
 }
 
 func (a RegisterLoginIdentifier) Execute(c flowpilot.ExecutionContext) error {
@@ -69,6 +84,16 @@ func (a RegisterLoginIdentifier) Execute(c flowpilot.ExecutionContext) error {
 	email := c.Input().Get("email").String()
 	username := c.Input().Get("username").String()
 
+	preferredLanguage := c.Input().Get("preferred_language").String()
+	if preferredLanguage != "" {
+		var err error
+		preferredLanguage, err = mail.NormalizeLanguage(preferredLanguage)
+		if err != nil {
+			c.Input().SetError("preferred_language", flowpilot.ErrorValueInvalid.Wrap(err))
+			return c.Error(flowpilot.ErrorFormDataInvalid.Wrap(err))
+		}
+	}
+
 	if deps.Cfg.Email.Optional && len(email) == 0 &&
 		deps.Cfg.Username.Optional && len(username) == 0 {
 		err := errors.New("either email or username must be provided")
@@ -144,6 +169,13 @@ func (a RegisterLoginIdentifier) Execute(c flowpilot.ExecutionContext) error {
 		return fmt.Errorf("failed to copy input values to the stash: %w", err)
 	}
 
+	if preferredLanguage != "" {
+		err = c.Stash().Set(shared.StashPathPreferredLanguage, preferredLanguage)
+		if err != nil {
+			return fmt.Errorf("failed to stash preferred_language: %w", err)
+		}
+	}
+
 	userID, err := uuid.NewV4()
 	if err != nil {
 		return fmt.Errorf("failed to generate a new user id: %w", err)
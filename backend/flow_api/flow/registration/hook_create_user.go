@@ -6,6 +6,7 @@ import (
 	webauthnLib "github.com/go-webauthn/webauthn/webauthn"
 	"github.com/gofrs/uuid"
 	auditlog "github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/before_hook"
 	"github.com/teamhanko/hanko/backend/dto/intern"
 	"github.com/teamhanko/hanko/backend/flow_api/flow/shared"
 	"github.com/teamhanko/hanko/backend/flowpilot"
@@ -52,6 +53,25 @@ func (h CreateUser) Execute(c flowpilot.HookExecutionContext) error {
 		credentialModel = intern.WebauthnCredentialToModel(&webauthnCredential, userId, false, false, deps.AuthenticatorMetadata)
 	}
 
+	hookResult, err := shared.CallBeforeHook(c, deps, before_hook.EventUserCreate, deps.Cfg.BeforeHooks.UserCreate, before_hook.User{
+		ID:          userId,
+		Email:       c.Stash().Get(shared.StashPathEmail).String(),
+		Username:    c.Stash().Get(shared.StashPathUsername).String(),
+		HasPassword: c.Stash().Get(shared.StashPathNewPassword).String() != "",
+		HasPasskey:  credentialModel != nil,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(hookResult.Claims) > 0 {
+		// Set so the issue_session hook adds the claims to the session token.
+		err = c.Stash().Set(shared.StashPathBeforeHookClaims, hookResult.Claims)
+		if err != nil {
+			return fmt.Errorf("failed to set before_hook_claims to the stash: %w", err)
+		}
+	}
+
 	err = h.createUser(
 		c,
 		userId,
@@ -60,6 +80,8 @@ func (h CreateUser) Execute(c flowpilot.HookExecutionContext) error {
 		c.Stash().Get(shared.StashPathUsername).String(),
 		credentialModel,
 		c.Stash().Get(shared.StashPathNewPassword).String(),
+		c.Stash().Get(shared.StashPathPreferredLanguage).String(),
+		hookResult.Metadata,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create user: %w", err)
@@ -70,18 +92,24 @@ func (h CreateUser) Execute(c flowpilot.HookExecutionContext) error {
 	return nil
 }
 
-func (h CreateUser) createUser(c flowpilot.HookExecutionContext, id uuid.UUID, email string, emailVerified bool, username string, passkey *models.WebauthnCredential, password string) error {
+func (h CreateUser) createUser(c flowpilot.HookExecutionContext, id uuid.UUID, email string, emailVerified bool, username string, passkey *models.WebauthnCredential, password string, preferredLanguage string, metadata map[string]interface{}) error {
 	deps := h.GetDeps(c)
 
 	now := time.Now().UTC()
 
 	var auditLogDetails []auditlog.DetailOption
 
-	err := deps.Persister.GetUserPersisterWithConnection(deps.Tx).Create(models.User{
+	userModel := models.User{
 		ID:        id,
 		CreatedAt: now,
 		UpdatedAt: now,
-	})
+	}
+	userModel.Metadata.Merge(metadata)
+	if preferredLanguage != "" {
+		userModel.PreferredLanguage = &preferredLanguage
+	}
+
+	err := deps.Persister.GetUserPersisterWithConnection(deps.Tx).Create(userModel)
 	if err != nil {
 		return err
 	}
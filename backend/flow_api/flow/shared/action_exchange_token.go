@@ -3,6 +3,7 @@ package shared
 import (
 	"errors"
 	"fmt"
+	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/flowpilot"
 	"github.com/teamhanko/hanko/backend/persistence/models"
 	"github.com/teamhanko/hanko/backend/rate_limiter"
@@ -21,6 +22,14 @@ func (a ExchangeToken) GetDescription() string {
 	return "Exchange a one time token."
 }
 
+func (a ExchangeToken) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{StatePasscodeConfirmation, StateOnboardingUsername, StateSuccess},
+		{StateOnboardingUsername, StateSuccess},
+		{StateSuccess},
+	}
+}
+
 func (a ExchangeToken) Initialize(c flowpilot.InitializationContext) {
 	c.AddInputs(flowpilot.StringInput("token").Hidden(true).Required(true))
 }
@@ -76,6 +85,14 @@ func (a ExchangeToken) Execute(c flowpilot.ExecutionContext) error {
 		return fmt.Errorf("failed to set skip_user_creation to stash: %w", err)
 	}
 
+	// Set so the issue_session hook treats the token exchange as a login, unless the thirdparty/callback endpoint
+	// registered a new user.
+	if !tokenModel.UserCreated {
+		if err := c.Stash().Set(StashPathLoginMethod, "third_party"); err != nil {
+			return fmt.Errorf("failed to set login_method to stash: %w", err)
+		}
+	}
+
 	err = deps.Persister.GetTokenPersisterWithConnection(deps.Tx).Delete(*tokenModel)
 	if err != nil {
 		return fmt.Errorf("failed to delete token from db: %w", err)
@@ -105,7 +122,10 @@ func (a ExchangeToken) determineOnboardingStates(c flowpilot.ExecutionContext, i
 		result = append(result, StatePasscodeConfirmation)
 	}
 
-	if deps.Cfg.Username.Enabled && identity.Email.User.GetUsername() == nil {
+	// the username onboarding state is not part of the login flow if the username onboarding step is disabled
+	usernameStep := !c.IsFlow(FlowLogin) || deps.Cfg.Flow.LoginOnboarding.HasStep(config.FlowOnboardingStepUsername)
+
+	if usernameStep && deps.Cfg.Username.Enabled && identity.Email.User.GetUsername() == nil {
 		if (!userCreated && deps.Cfg.Username.AcquireOnLogin) ||
 			(userCreated && deps.Cfg.Username.AcquireOnRegistration) {
 			result = append(result, StateOnboardingUsername)
@@ -22,6 +22,12 @@ func (a ThirdPartyOAuth) GetDescription() string {
 	return "Sign up/sign in with a third party provider via OAuth."
 }
 
+func (a ThirdPartyOAuth) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{StateThirdParty},
+	}
+}
+
 func (a ThirdPartyOAuth) Initialize(c flowpilot.InitializationContext) {
 	deps := a.GetDeps(c)
 
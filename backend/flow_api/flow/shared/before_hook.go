@@ -0,0 +1,47 @@
+package shared
+
+import (
+	"fmt"
+	"github.com/teamhanko/hanko/backend/before_hook"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/flowpilot"
+	"net/http"
+)
+
+// CallBeforeHook calls the given before hook for the given user. It returns a flowpilot.FlowError if the hook denies
+// the operation or if the hook fails and its failure policy is "closed". Failures of hooks with an "open" failure
+// policy are only logged.
+func CallBeforeHook(c flowpilot.HookExecutionContext, deps *Dependencies, event before_hook.Event, hookConfig config.BeforeHook, user interface{}) (*before_hook.Result, error) {
+	caller := before_hook.NewCaller(event, hookConfig)
+	if !caller.Enabled() {
+		return &before_hook.Result{}, nil
+	}
+
+	request := before_hook.Request{
+		FlowID:      c.GetFlowID().String(),
+		User:        user,
+		LoginMethod: c.Stash().Get(StashPathLoginMethod).String(),
+		Client: before_hook.Client{
+			IpAddress: deps.HttpContext.RealIP(),
+			UserAgent: deps.HttpContext.Request().UserAgent(),
+		},
+	}
+
+	result, err := caller.Call(deps.HttpContext.Request().Context(), request)
+	if err != nil {
+		if result == nil {
+			return nil, ErrorBeforeHookFailed.Wrap(err)
+		}
+		deps.HttpContext.Logger().Warn(fmt.Errorf("ignoring failed before hook: %w", err))
+	}
+
+	if result.Denied {
+		message := result.ErrorMessage
+		if message == "" {
+			message = "The request has been denied."
+		}
+		return nil, flowpilot.NewFlowError(result.ErrorCode, message, http.StatusForbidden)
+	}
+
+	return result, nil
+}
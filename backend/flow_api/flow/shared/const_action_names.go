@@ -21,6 +21,7 @@ const (
 	ActionPasswordRecovery                       flowpilot.ActionName = "password_recovery"
 	ActionPasswordCreate                         flowpilot.ActionName = "password_create"
 	ActionPasswordUpdate                         flowpilot.ActionName = "password_update"
+	ActionPreferredLanguageUpdate                flowpilot.ActionName = "preferred_language_update"
 	ActionRegisterClientCapabilities             flowpilot.ActionName = "register_client_capabilities"
 	ActionRegisterLoginIdentifier                flowpilot.ActionName = "register_login_identifier"
 	ActionRegisterPassword                       flowpilot.ActionName = "register_password"
@@ -32,6 +33,7 @@ const (
 	ActionUsernameDelete                         flowpilot.ActionName = "username_delete"
 	ActionEmailAddressSet                        flowpilot.ActionName = "email_address_set"
 	ActionVerifyPasscode                         flowpilot.ActionName = "verify_passcode"
+	ActionVerifyPasscodeLink                     flowpilot.ActionName = "verify_passcode_link"
 	ActionWebauthnCredentialCreate               flowpilot.ActionName = "webauthn_credential_create"
 	ActionWebauthnCredentialDelete               flowpilot.ActionName = "webauthn_credential_delete"
 	ActionWebauthnCredentialRename               flowpilot.ActionName = "webauthn_credential_rename"
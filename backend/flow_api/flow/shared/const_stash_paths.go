@@ -1,6 +1,7 @@
 package shared
 
 const (
+	StashPathBeforeHookClaims                      = "before_hook_claims"
 	StashPathEmail                                 = "email"
 	StashPathEmailVerified                         = "email_verified"
 	StashPathLoginMethod                           = "login_method"
@@ -8,6 +9,7 @@ const (
 	StashPathPasscodeEmail                         = "sticky.passcode_email"
 	StashPathPasscodeID                            = "sticky.passcode_id"
 	StashPathPasscodeTemplate                      = "passcode_template"
+	StashPathPreferredLanguage                     = "preferred_language"
 	StashPathSkipUserCreation                      = "skip_user_creation"
 	StashPathUserHasPassword                       = "user_has_password"
 	StashPathUserHasWebauthnCredential             = "user_has_webauthn_credential"
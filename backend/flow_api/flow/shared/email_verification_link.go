@@ -0,0 +1,84 @@
+package shared
+
+import (
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/crypto/link_token"
+	"github.com/teamhanko/hanko/backend/dto/webhook"
+	"github.com/teamhanko/hanko/backend/flowpilot"
+	"github.com/teamhanko/hanko/backend/rate_limiter"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SendEmailVerificationLink sends a link to the given email address which verifies the address for the user with the
+// given ID when opened. The address is added to the account of the user if it does not belong to it yet.
+func SendEmailVerificationLink(deps *Dependencies, userID uuid.UUID, emailAddress string) error {
+	linkCfg := deps.Cfg.Email.VerificationLink
+
+	verificationToken, err := deps.LinkTokenSigner.Sign(
+		link_token.PurposeEmailVerification,
+		userID.String(),
+		linkCfg.LinkTtl,
+		map[string]interface{}{"email": emailAddress})
+	if err != nil {
+		return fmt.Errorf("failed to create email verification token: %w", err)
+	}
+
+	verificationURL := fmt.Sprintf("%s/emails/verify?token=%s", strings.TrimSuffix(deps.Cfg.Service.ApiURL, "/"), url.QueryEscape(verificationToken))
+
+	templateData := map[string]interface{}{
+		"VerificationURL": verificationURL,
+		"TTL":             fmt.Sprintf("%.0f", linkCfg.LinkTtl.Hours()),
+	}
+
+	webhookData := webhook.EmailVerificationLinkData{
+		ServiceName:  deps.Cfg.Service.Name,
+		EmailAddress: emailAddress,
+		Link:         verificationURL,
+		ValidUntil:   time.Now().Add(linkCfg.LinkTtl).UTC().Unix(),
+	}
+
+	lang := EmailLanguage(deps, "", userID.String())
+
+	return sendSecurityNotification(deps, emailAddress, lang, webhook.EmailTypeEmailVerificationLink, templateData, webhookData)
+}
+
+// EmailVerificationLinkRateLimited applies the passcode rate limit to the given email address, so verification links
+// cannot be used to flood an inbox. The "resend_after" payload is set if the limit has been exceeded.
+func EmailVerificationLinkRateLimited(c flowpilot.ExecutionContext, deps *Dependencies, emailAddress string) (bool, error) {
+	if !deps.Cfg.RateLimiter.Enabled {
+		return false, nil
+	}
+
+	rateLimitKey := rate_limiter.CreateRateLimitPasscodeKey(deps.HttpContext.RealIP(), emailAddress)
+	resendAfterSeconds, ok, err := rate_limiter.Limit2(deps.PasscodeRateLimiter, rateLimitKey)
+	if err != nil {
+		return false, fmt.Errorf("rate limiter failed: %w", err)
+	}
+
+	if !ok {
+		err = c.Payload().Set("resend_after", resendAfterSeconds)
+		if err != nil {
+			return false, fmt.Errorf("failed to set a value for resend_after to the payload: %w", err)
+		}
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// SendEmailRegistrationAttempted notifies the owner of the given email address that someone tried to add it to
+// another account. It is sent instead of a verification link, so the response does not reveal whether the address
+// is already taken.
+func SendEmailRegistrationAttempted(deps *Dependencies, userID uuid.UUID, emailAddress string) error {
+	webhookData := webhook.SecurityNotificationData{
+		ServiceName:  deps.Cfg.Service.Name,
+		EmailAddress: emailAddress,
+	}
+
+	lang := EmailLanguage(deps, "", userID.String())
+
+	return sendSecurityNotification(deps, emailAddress, lang, webhook.EmailTypeEmailRegistrationAttempted, nil, webhookData)
+}
@@ -9,9 +9,11 @@ var (
 	ErrorPasscodeInvalid            = flowpilot.NewFlowError("passcode_invalid", "The passcode is invalid.", http.StatusBadRequest)
 	ErrorPasskeyInvalid             = flowpilot.NewFlowError("passkey_invalid", "The passkey is invalid.", http.StatusUnauthorized)
 	ErrorPasscodeMaxAttemptsReached = flowpilot.NewFlowError("passcode_max_attempts_reached", "The passcode was entered wrong too many times.", http.StatusUnauthorized)
+	ErrorPasscodeLinkNotConfirmed   = flowpilot.NewFlowError("passcode_link_not_confirmed", "The link in the passcode email has not been opened yet.", http.StatusBadRequest)
 	ErrorRateLimitExceeded          = flowpilot.NewFlowError("rate_limit_exceeded", "The rate limit has been exceeded.", http.StatusTooManyRequests)
 	ErrorNotFound                   = flowpilot.NewFlowError("not_found", "The requested resource was not found.", http.StatusNotFound)
 	ErrorUnauthorized               = flowpilot.NewFlowError("unauthorized", "The session is invalid.", http.StatusUnauthorized)
+	ErrorBeforeHookFailed           = flowpilot.NewFlowError("before_hook_failed", "The request could not be verified.", http.StatusServiceUnavailable)
 )
 
 var (
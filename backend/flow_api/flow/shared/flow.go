@@ -6,6 +6,7 @@ import (
 	"github.com/sethvargo/go-limiter"
 	auditlog "github.com/teamhanko/hanko/backend/audit_log"
 	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/crypto/link_token"
 	"github.com/teamhanko/hanko/backend/ee/saml"
 	"github.com/teamhanko/hanko/backend/flow_api/services"
 	"github.com/teamhanko/hanko/backend/flowpilot"
@@ -15,20 +16,22 @@ import (
 )
 
 type Dependencies struct {
-	Cfg                      config.Config
-	HttpContext              echo.Context
-	PasscodeService          services.Passcode
-	PasswordService          services.Password
-	WebauthnService          services.WebauthnService
-	SamlService              saml.Service
-	Persister                persistence.Persister
-	SessionManager           session.Manager
-	PasscodeRateLimiter      limiter.Store
-	PasswordRateLimiter      limiter.Store
-	TokenExchangeRateLimiter limiter.Store
-	Tx                       *pop.Connection
-	AuthenticatorMetadata    mapper.AuthenticatorMetadata
-	AuditLogger              auditlog.Logger
+	Cfg                         config.Config
+	HttpContext                 echo.Context
+	PasscodeService             services.Passcode
+	PasswordService             services.Password
+	WebauthnService             services.WebauthnService
+	SecurityNotificationService services.SecurityNotification
+	SamlService                 saml.Service
+	Persister                   persistence.Persister
+	SessionManager              session.Manager
+	LinkTokenSigner             link_token.Signer
+	PasscodeRateLimiter         limiter.Store
+	PasswordRateLimiter         limiter.Store
+	TokenExchangeRateLimiter    limiter.Store
+	Tx                          *pop.Connection
+	AuthenticatorMetadata       mapper.AuthenticatorMetadata
+	AuditLogger                 auditlog.Logger
 }
 
 type Action struct{}
@@ -5,9 +5,18 @@ import (
 	"fmt"
 	"github.com/gofrs/uuid"
 	auditlog "github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/before_hook"
+	"github.com/teamhanko/hanko/backend/crypto/link_token"
 	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/dto/admin"
+	"github.com/teamhanko/hanko/backend/dto/webhook"
 	"github.com/teamhanko/hanko/backend/flowpilot"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/session"
+	"github.com/teamhanko/hanko/backend/webhooks/utils"
+	"net/url"
+	"strings"
+	"time"
 )
 
 type IssueSession struct {
@@ -39,7 +48,12 @@ func (h IssueSession) Execute(c flowpilot.HookExecutionContext) error {
 		emailDTO = dto.JwtFromEmailModel(email)
 	}
 
-	signedSessionToken, rawToken, err := deps.SessionManager.GenerateJWT(userId, emailDTO)
+	claims, err := h.callBeforeHook(c, userId)
+	if err != nil {
+		return err
+	}
+
+	signedSessionToken, rawToken, err := deps.SessionManager.GenerateJWT(userId, emailDTO, session.WithCustomClaims(claims))
 	if err != nil {
 		return fmt.Errorf("failed to generate JWT: %w", err)
 	}
@@ -49,7 +63,20 @@ func (h IssueSession) Execute(c flowpilot.HookExecutionContext) error {
 		return fmt.Errorf("failed to list active sessions: %w", err)
 	}
 
+	var newDeviceSession *models.Session
+
 	if deps.Cfg.Session.ServerSide.Enabled {
+		isNewDevice := false
+		if deps.Cfg.SecurityNotifications.NewDeviceLogin.Enabled {
+			// must be determined before sessions exceeding the limit are removed
+			recentSessions, err := deps.Persister.GetSessionPersisterWithConnection(deps.Tx).List(userId)
+			if err != nil {
+				return fmt.Errorf("failed to list sessions: %w", err)
+			}
+
+			isNewDevice = isNewDeviceLogin(recentSessions, deps.HttpContext.Request().UserAgent(), deps.HttpContext.RealIP())
+		}
+
 		// remove all server side sessions that exceed the limit
 		if len(activeSessions) >= deps.Cfg.Session.ServerSide.Limit {
 			for i := deps.Cfg.Session.ServerSide.Limit - 1; i < len(activeSessions); i++ {
@@ -78,6 +105,10 @@ func (h IssueSession) Execute(c flowpilot.HookExecutionContext) error {
 		if err != nil {
 			return fmt.Errorf("failed to store session: %w", err)
 		}
+
+		if isNewDevice {
+			newDeviceSession = &sessionModel
+		}
 	}
 
 	cookie, err := deps.SessionManager.GenerateCookie(signedSessionToken)
@@ -93,6 +124,8 @@ func (h IssueSession) Execute(c flowpilot.HookExecutionContext) error {
 		deps.HttpContext.SetCookie(cookie)
 	}
 
+	utils.NotifySessionCreate(deps.HttpContext, deps.Tx, rawToken)
+
 	// Audit log logins only, because user creation on registration implies that the user is logged
 	// in after a registration. Only login actions should set the "login_method" stash entry.
 	if c.Stash().Get(StashPathLoginMethod).Exists() {
@@ -108,7 +141,121 @@ func (h IssueSession) Execute(c flowpilot.HookExecutionContext) error {
 		if err != nil {
 			return fmt.Errorf("could not create audit log: %w", err)
 		}
+
+		sessionID, _ := rawToken.Get("session_id")
+		sessionIDString, _ := sessionID.(string)
+		loginMethod := webhook.LoginMethod(c.Stash().Get(StashPathLoginMethod).String())
+		utils.NotifyLogin(deps.HttpContext, deps.Tx, deps.Persister, userId, loginMethod, sessionIDString)
+	}
+
+	if newDeviceSession != nil {
+		if primaryEmail := emails.GetPrimary(); primaryEmail != nil {
+			// A failing notification must not prevent the login, hence errors are only logged.
+			err = h.sendNewDeviceLoginNotification(c, primaryEmail.Address, *newDeviceSession)
+			if err != nil {
+				deps.HttpContext.Logger().Warn(fmt.Errorf("failed to send new device login notification: %w", err))
+			}
+		}
 	}
 
 	return nil
 }
+
+// callBeforeHook calls the "session_issue" before hook, merges the returned metadata into the metadata of the user and
+// returns the claims to add to the session token, including the claims returned by the "user_create" before hook.
+func (h IssueSession) callBeforeHook(c flowpilot.HookExecutionContext, userId uuid.UUID) (map[string]interface{}, error) {
+	deps := h.GetDeps(c)
+
+	claims := make(map[string]interface{})
+	if stashedClaims, ok := c.Stash().Get(StashPathBeforeHookClaims).Value().(map[string]interface{}); ok {
+		for key, value := range stashedClaims {
+			claims[key] = value
+		}
+	}
+
+	if !deps.Cfg.BeforeHooks.SessionIssue.Enabled {
+		return claims, nil
+	}
+
+	userModel, err := deps.Persister.GetUserPersisterWithConnection(deps.Tx).Get(userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user from db: %w", err)
+	}
+	if userModel == nil {
+		return nil, fmt.Errorf("user %s not found", userId)
+	}
+
+	result, err := CallBeforeHook(c, deps, before_hook.EventSessionIssue, deps.Cfg.BeforeHooks.SessionIssue, admin.FromUserModel(*userModel))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.Metadata) > 0 {
+		userModel.Metadata.Merge(result.Metadata)
+		userModel.UpdatedAt = time.Now().UTC()
+		err = deps.Persister.GetUserPersisterWithConnection(deps.Tx).Update(*userModel)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update user metadata: %w", err)
+		}
+	}
+
+	for key, value := range result.Claims {
+		claims[key] = value
+	}
+
+	return claims, nil
+}
+
+// isNewDeviceLogin returns true if none of the given sessions has been created with the given user agent and IP
+// address. Logins of users without any previous sessions (e.g. on registration) are not considered to be logins from a
+// new device.
+func isNewDeviceLogin(sessions []models.Session, userAgent string, ipAddress string) bool {
+	if len(sessions) == 0 {
+		return false
+	}
+
+	for _, session := range sessions {
+		if session.UserAgent == userAgent && session.IpAddress == ipAddress {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (h IssueSession) sendNewDeviceLoginNotification(c flowpilot.HookExecutionContext, emailAddress string, session models.Session) error {
+	deps := h.GetDeps(c)
+	notificationCfg := deps.Cfg.SecurityNotifications.NewDeviceLogin
+
+	revocationToken, err := deps.LinkTokenSigner.Sign(
+		link_token.PurposeSessionRevocation,
+		session.UserID.String(),
+		notificationCfg.RevocationLinkTtl,
+		map[string]interface{}{"session_id": session.ID.String()})
+	if err != nil {
+		return fmt.Errorf("failed to create revocation token: %w", err)
+	}
+
+	revocationURL := fmt.Sprintf("%s/sessions/revoke?token=%s", strings.TrimSuffix(deps.Cfg.Service.ApiURL, "/"), url.QueryEscape(revocationToken))
+	userAgent := dto.FromSessionModel(session, false).UserAgent
+
+	templateData := map[string]interface{}{
+		"UserAgent":     userAgent,
+		"IpAddress":     session.IpAddress,
+		"LoginTime":     session.CreatedAt.UTC().Format(time.RFC1123),
+		"RevocationURL": revocationURL,
+	}
+
+	webhookData := webhook.NewDeviceLoginData{
+		ServiceName:   deps.Cfg.Service.Name,
+		SessionID:     session.ID.String(),
+		UserAgent:     userAgent,
+		IpAddress:     session.IpAddress,
+		LoginTime:     session.CreatedAt.UTC().Unix(),
+		RevocationURL: revocationURL,
+	}
+
+	lang := EmailLanguage(deps, "", session.UserID.String())
+
+	return sendSecurityNotification(deps, emailAddress, lang, webhook.EmailTypeNewDeviceLogin, templateData, webhookData)
+}
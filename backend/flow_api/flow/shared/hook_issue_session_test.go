@@ -0,0 +1,22 @@
+package shared
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+)
+
+func TestIsNewDeviceLogin(t *testing.T) {
+	sessions := []models.Session{
+		{UserAgent: "Firefox", IpAddress: "192.0.2.1"},
+		{UserAgent: "Chrome", IpAddress: "192.0.2.2"},
+	}
+
+	assert.False(t, isNewDeviceLogin(nil, "Firefox", "192.0.2.1"), "first login must not be a new device login")
+	assert.False(t, isNewDeviceLogin(sessions, "Firefox", "192.0.2.1"))
+	assert.False(t, isNewDeviceLogin(sessions, "Chrome", "192.0.2.2"))
+	assert.True(t, isNewDeviceLogin(sessions, "Firefox", "192.0.2.2"), "known user agent from a new ip address")
+	assert.True(t, isNewDeviceLogin(sessions, "Safari", "192.0.2.1"), "new user agent from a known ip address")
+	assert.True(t, isNewDeviceLogin(sessions, "Safari", "198.51.100.1"))
+}
@@ -5,6 +5,8 @@ import (
 	"github.com/gofrs/uuid"
 	"github.com/teamhanko/hanko/backend/flowpilot"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/webhooks/events"
+	"github.com/teamhanko/hanko/backend/webhooks/utils"
 )
 
 type PasswordSave struct {
@@ -29,6 +31,8 @@ func (h PasswordSave) Execute(c flowpilot.HookExecutionContext) error {
 	if err != nil {
 		return fmt.Errorf("could not create password: %w", err)
 	}
+
+	utils.NotifyUserChange(deps.HttpContext, deps.Tx, deps.Persister, events.UserPassword, passwordCredential.UserId)
 	// TODO: add audit log?
 	return nil
 }
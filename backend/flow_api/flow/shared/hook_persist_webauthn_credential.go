@@ -7,8 +7,11 @@ import (
 	"github.com/gofrs/uuid"
 	auditlog "github.com/teamhanko/hanko/backend/audit_log"
 	"github.com/teamhanko/hanko/backend/dto/intern"
+	"github.com/teamhanko/hanko/backend/dto/webhook"
 	"github.com/teamhanko/hanko/backend/flowpilot"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/webhooks/events"
+	"github.com/teamhanko/hanko/backend/webhooks/utils"
 )
 
 type WebauthnCredentialSave struct {
@@ -58,6 +61,15 @@ func (h WebauthnCredentialSave) Execute(c flowpilot.HookExecutionContext) error
 		return fmt.Errorf("could not create audit log: %w", err)
 	}
 
+	if deps.Cfg.SecurityNotifications.PasskeyCreated.Enabled {
+		SendSecurityNotification(deps, userId, webhook.EmailTypePasskeyCreated, webhook.SecurityNotificationData{
+			PasskeyID:   credentialModel.ID,
+			PasskeyName: PasskeyDisplayName(*credentialModel),
+		})
+	}
+
+	utils.NotifyUserChange(deps.HttpContext, deps.Tx, deps.Persister, events.UserPasskeyCreate, userId)
+
 	if userModel, ok := c.Get("session_user").(*models.User); ok {
 		userModel.WebauthnCredentials = append(userModel.WebauthnCredentials, *credentialModel)
 	}
@@ -0,0 +1,39 @@
+package shared
+
+import (
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/mail"
+)
+
+// EmailLanguage returns the language list used to render emails sent within a flow. The preferred language entered
+// during the flow (e.g. on registration) takes precedence over the preferred language of the user with the given ID.
+// Both fall back to the languages of the Accept-Language header, so emails are localized for users without a
+// preferred language and for languages without translations.
+func EmailLanguage(deps *Dependencies, preferredLanguage string, userID string) string {
+	if preferredLanguage == "" && userID != "" {
+		preferredLanguage = userPreferredLanguage(deps, userID)
+	}
+
+	return mail.LanguagePreference(preferredLanguage, deps.HttpContext.Request().Header.Get("Accept-Language"))
+}
+
+func userPreferredLanguage(deps *Dependencies, userID string) string {
+	id, err := uuid.FromString(userID)
+	if err != nil {
+		return ""
+	}
+
+	userModel, err := deps.Persister.GetUserPersisterWithConnection(deps.Tx).Get(id)
+	if err != nil {
+		// the Accept-Language header is still a reasonable choice, hence the email is sent anyway
+		deps.HttpContext.Logger().Warn(fmt.Errorf("failed to fetch user for preferred language: %w", err))
+		return ""
+	}
+
+	if userModel == nil || userModel.PreferredLanguage == nil {
+		return ""
+	}
+
+	return *userModel.PreferredLanguage
+}
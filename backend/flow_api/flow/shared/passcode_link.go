@@ -0,0 +1,57 @@
+package shared
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/crypto"
+	"net/http"
+)
+
+// PasscodeLinkCookieName is the name of the cookie identifying the browser a passcode link has been requested in.
+const PasscodeLinkCookieName = "hanko_passcode_link"
+
+// PasscodeLinkBrowserBinding returns the browser binding embedded in passcode links, i.e. the hash of the value of the
+// passcode link cookie, so the cookie value itself is never part of an email.
+func PasscodeLinkBrowserBinding(cookieValue string) string {
+	hash := sha256.Sum256([]byte(cookieValue))
+	return hex.EncodeToString(hash[:])
+}
+
+// SetPasscodeLinkCookie sets the passcode link cookie, if passcode links must be opened in the same browser, and
+// returns the corresponding browser binding. The value of an existing cookie is kept, so links sent before a resend
+// remain valid. An empty binding is returned if the passcode mode is not "link" or the same browser is not required.
+func SetPasscodeLinkCookie(deps *Dependencies) (string, error) {
+	if deps.Cfg.Email.PasscodeMode != config.PasscodeModeLink || !deps.Cfg.Email.PasscodeLink.RequireSameBrowser {
+		return "", nil
+	}
+
+	var value string
+	if existing, err := deps.HttpContext.Cookie(PasscodeLinkCookieName); err == nil && existing.Value != "" {
+		value = existing.Value
+	} else {
+		value, err = crypto.GenerateRandomStringURLSafe(32)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate passcode link cookie value: %w", err)
+		}
+	}
+
+	sameSite := http.SameSiteLaxMode
+	if deps.Cfg.Session.Cookie.SameSite == "none" {
+		sameSite = http.SameSiteNoneMode
+	}
+
+	deps.HttpContext.SetCookie(&http.Cookie{
+		Name:     PasscodeLinkCookieName,
+		Value:    value,
+		Path:     "/",
+		Domain:   deps.Cfg.Session.Cookie.Domain,
+		MaxAge:   deps.Cfg.Email.PasscodeTtl,
+		Secure:   deps.Cfg.Session.Cookie.Secure,
+		HttpOnly: true,
+		SameSite: sameSite,
+	})
+
+	return PasscodeLinkBrowserBinding(value), nil
+}
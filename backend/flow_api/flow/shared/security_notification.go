@@ -0,0 +1,87 @@
+package shared
+
+import (
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/dto/webhook"
+	"github.com/teamhanko/hanko/backend/flow_api/services"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/webhooks/events"
+	"github.com/teamhanko/hanko/backend/webhooks/utils"
+	"golang.org/x/exp/slices"
+)
+
+// SendSecurityNotification sends a security notification using the template named after the given email type to the
+// primary email address of the given user (if any) and to all additional email addresses, and triggers an "email.send"
+// webhook for every recipient.
+//
+// A failing notification must not prevent the change the user is notified about, hence errors are only logged.
+func SendSecurityNotification(deps *Dependencies, userID uuid.UUID, emailType webhook.EmailType, data webhook.SecurityNotificationData, additionalEmailAddresses ...string) {
+	emails, err := deps.Persister.GetEmailPersisterWithConnection(deps.Tx).FindByUserId(userID)
+	if err != nil {
+		deps.HttpContext.Logger().Warn(fmt.Errorf("failed to fetch emails for security notification: %w", err))
+		return
+	}
+
+	var recipients []string
+	if primaryEmail := emails.GetPrimary(); primaryEmail != nil {
+		recipients = append(recipients, primaryEmail.Address)
+	}
+
+	for _, emailAddress := range additionalEmailAddresses {
+		if emailAddress != "" && !slices.Contains(recipients, emailAddress) {
+			recipients = append(recipients, emailAddress)
+		}
+	}
+
+	data.ServiceName = deps.Cfg.Service.Name
+	templateData := map[string]interface{}{
+		"EmailAddress":         data.EmailAddress,
+		"PreviousEmailAddress": data.PreviousEmailAddress,
+		"PasskeyName":          data.PasskeyName,
+	}
+
+	lang := EmailLanguage(deps, "", userID.String())
+	for _, recipient := range recipients {
+		err = sendSecurityNotification(deps, recipient, lang, emailType, templateData, data)
+		if err != nil {
+			deps.HttpContext.Logger().Warn(fmt.Errorf("failed to send %s notification: %w", emailType, err))
+		}
+	}
+}
+
+func sendSecurityNotification(deps *Dependencies, emailAddress string, lang string, emailType webhook.EmailType, templateData map[string]interface{}, webhookData interface{}) error {
+	sendParams := services.SendSecurityNotificationParams{
+		Template:     string(emailType),
+		EmailAddress: emailAddress,
+		Language:     lang,
+		Data:         templateData,
+	}
+
+	result, err := deps.SecurityNotificationService.SendNotification(deps.Tx, sendParams)
+	if err != nil {
+		return err
+	}
+
+	emailSendData := webhook.EmailSend{
+		Subject:          result.Subject,
+		BodyPlain:        result.Body,
+		Body:             result.HTMLBody,
+		ToEmailAddress:   emailAddress,
+		DeliveredByHanko: deps.Cfg.EmailDelivery.Enabled,
+		AcceptLanguage:   sendParams.Language,
+		Type:             emailType,
+		Data:             webhookData,
+	}
+
+	return utils.TriggerWebhooks(deps.HttpContext, deps.Tx, events.EmailSend, emailSendData)
+}
+
+// PasskeyDisplayName returns the name of the given passkey or its ID if it has no name.
+func PasskeyDisplayName(credential models.WebauthnCredential) string {
+	if credential.Name != nil && *credential.Name != "" {
+		return *credential.Name
+	}
+
+	return credential.ID
+}
@@ -18,6 +18,12 @@ func (a EmailAddressSet) GetDescription() string {
 	return "Set a new email address."
 }
 
+func (a EmailAddressSet) GetTransitions() []flowpilot.Transition {
+	return []flowpilot.Transition{
+		{shared.StatePasscodeConfirmation},
+	}
+}
+
 func (a EmailAddressSet) Initialize(c flowpilot.InitializationContext) {
 	deps := a.GetDeps(c)
 
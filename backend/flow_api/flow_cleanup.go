@@ -0,0 +1,94 @@
+package flow_api
+
+import (
+	"github.com/gofrs/uuid"
+	zeroLogger "github.com/rs/zerolog/log"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"time"
+)
+
+const (
+	// flowCleanupLockName is the name of the scheduler lock making sure only one instance deletes expired flows per
+	// interval.
+	flowCleanupLockName = "flow_cleanup"
+	// flowCleanupCheckInterval is the interval in which the FlowCleanupScheduler checks whether a cleanup is due.
+	flowCleanupCheckInterval = 1 * time.Minute
+)
+
+// FlowCleanupScheduler deletes expired flows from the database periodically. When multiple instances share a
+// database, the flows are deleted by only one of them per interval.
+type FlowCleanupScheduler struct {
+	persister persistence.Persister
+	cfg       config.FlowCleanup
+	// holder identifies this scheduler when acquiring the cleanup lock
+	holder string
+}
+
+func NewFlowCleanupScheduler(persister persistence.Persister, cfg config.FlowCleanup) *FlowCleanupScheduler {
+	holder, _ := uuid.NewV4()
+	return &FlowCleanupScheduler{
+		persister: persister,
+		cfg:       cfg,
+		holder:    holder.String(),
+	}
+}
+
+// Run checks periodically whether a cleanup is due and deletes the expired flows if so. Run blocks until the given
+// channel is closed.
+func (s *FlowCleanupScheduler) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(flowCleanupCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		s.cleanupIfDue(time.Now())
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *FlowCleanupScheduler) cleanupIfDue(now time.Time) {
+	acquired, err := s.persister.GetSchedulerLockPersister(nil).Acquire(flowCleanupLockName, s.holder, now, now.Add(s.cfg.Interval))
+	if err != nil {
+		zeroLogger.Error().Err(err).Msg("failed to acquire flow cleanup lock")
+		return
+	}
+
+	if !acquired {
+		// cleaned up by this or another instance within the interval
+		return
+	}
+
+	count, err := s.deleteExpired(now)
+	if err != nil {
+		zeroLogger.Error().Err(err).Msg("failed to delete expired flows")
+	}
+
+	if count > 0 {
+		zeroLogger.Info().Int("count", count).Msg("deleted expired flows")
+	}
+}
+
+// deleteExpired deletes the flows which expired more than expiredFlowRetention before the given time in batches and
+// returns the number of deleted flows.
+func (s *FlowCleanupScheduler) deleteExpired(now time.Time) (int, error) {
+	flowPersister := s.persister.GetFlowPersister(nil)
+	before := now.Add(-expiredFlowRetention)
+
+	count := 0
+	for {
+		deleted, err := flowPersister.DeleteExpired(before, s.cfg.BatchSize)
+		if err != nil {
+			return count, err
+		}
+		count += deleted
+
+		if deleted < s.cfg.BatchSize {
+			return count, nil
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package flow_api
+
+import (
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/test"
+	"testing"
+	"time"
+)
+
+// flowCleanupTestPersister is the test persister with flows.
+type flowCleanupTestPersister struct {
+	persistence.Persister
+	flowPersister persistence.FlowPersister
+}
+
+func (p *flowCleanupTestPersister) GetFlowPersister(_ *pop.Connection) persistence.FlowPersister {
+	return p.flowPersister
+}
+
+func newFlowCleanupTestPersister(now time.Time, expiresAt ...time.Time) *flowCleanupTestPersister {
+	flows := make(models.Flows, len(expiresAt))
+	for i, t := range expiresAt {
+		flows[i] = models.Flow{ID: uuid.Must(uuid.NewV4()), ExpiresAt: t, CreatedAt: now, UpdatedAt: now}
+	}
+
+	return &flowCleanupTestPersister{
+		Persister:     test.NewEmptyPersister(),
+		flowPersister: test.NewFlowPersister(flows),
+	}
+}
+
+// remainingFlows returns the number of flows which have not been deleted by the scheduler. The flows are deleted.
+func (p *flowCleanupTestPersister) remainingFlows(t *testing.T) int {
+	count, err := p.flowPersister.DeleteExpired(time.Now().Add(24*time.Hour*365), 1000)
+	require.NoError(t, err)
+	return count
+}
+
+func TestFlowCleanupScheduler(t *testing.T) {
+	now := time.Now().UTC()
+	persister := newFlowCleanupTestPersister(now,
+		now.Add(-3*time.Hour),
+		now.Add(-2*time.Hour),
+		now.Add(-90*time.Minute),
+		// expired within the retention time
+		now.Add(-30*time.Minute),
+		now.Add(time.Hour),
+	)
+
+	s := NewFlowCleanupScheduler(persister, config.FlowCleanup{Enabled: true, Interval: time.Hour, BatchSize: 2})
+
+	count, err := s.deleteExpired(now)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count, "flows are deleted in batches until none is left")
+
+	assert.Equal(t, 2, persister.remainingFlows(t))
+}
+
+func TestFlowCleanupScheduler_Interval(t *testing.T) {
+	now := time.Now().UTC()
+	persister := newFlowCleanupTestPersister(now, now.Add(-2*time.Hour), now.Add(-2*time.Hour))
+	cfg := config.FlowCleanup{Enabled: true, Interval: time.Hour, BatchSize: 1000}
+
+	s := NewFlowCleanupScheduler(persister, cfg)
+	other := NewFlowCleanupScheduler(persister, cfg)
+
+	s.cleanupIfDue(now)
+	assert.Equal(t, 0, persister.remainingFlows(t))
+
+	// flows expiring in the meantime are deleted by neither scheduler within the interval
+	persister.flowPersister = test.NewFlowPersister(models.Flows{{ID: uuid.Must(uuid.NewV4()), ExpiresAt: now.Add(-2 * time.Hour)}})
+
+	s.cleanupIfDue(now.Add(time.Minute))
+	other.cleanupIfDue(now.Add(time.Minute))
+	assert.Equal(t, 1, persister.remainingFlows(t))
+
+	// the next cleanup is due after the interval
+	persister.flowPersister = test.NewFlowPersister(models.Flows{{ID: uuid.Must(uuid.NewV4()), ExpiresAt: now.Add(-2 * time.Hour)}})
+	other.cleanupIfDue(now.Add(time.Hour + time.Minute))
+	assert.Equal(t, 0, persister.remainingFlows(t))
+}
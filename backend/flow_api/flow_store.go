@@ -0,0 +1,345 @@
+package flow_api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/gomodule/redigo/redis"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/flowpilot"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"strconv"
+	"time"
+)
+
+// expiredFlowRetention is the time expired flows are kept, so clients continuing an expired flow get a
+// "flow_expired_error" instead of the error for unknown flows.
+const expiredFlowRetention = 1 * time.Hour
+
+const redisFlowKeyPrefix = "hanko:flow:"
+
+// FlowStore provides the flowpilot.FlowDB flows are stored with.
+type FlowStore interface {
+	// FlowDB returns the FlowDB for a flow step executed within the given transaction.
+	FlowDB(tx *pop.Connection) flowpilot.FlowDB
+}
+
+// deferredFlowDB is implemented by FlowDBs which do not take part in the database transaction. Their writes are
+// applied once the transaction has been committed. Err reports an error applying the writes, Discard releases what has
+// been reserved for the writes if the transaction has been rolled back.
+type deferredFlowDB interface {
+	Err() error
+	Discard() error
+}
+
+func NewFlowStore(cfg config.FlowStore) FlowStore {
+	if cfg.Type == config.FlowStoreTypeRedis {
+		return &redisFlowStore{
+			pool: &redis.Pool{
+				MaxIdle:     10,
+				IdleTimeout: 5 * time.Minute,
+				Dial: func() (redis.Conn, error) {
+					return redis.Dial("tcp", cfg.Redis.Address,
+						redis.DialPassword(cfg.Redis.Password))
+				},
+			},
+		}
+	}
+
+	return databaseFlowStore{}
+}
+
+type databaseFlowStore struct{}
+
+func (databaseFlowStore) FlowDB(tx *pop.Connection) flowpilot.FlowDB {
+	return models.NewFlowDB(tx)
+}
+
+type redisFlowStore struct {
+	pool *redis.Pool
+}
+
+func (s *redisFlowStore) FlowDB(tx *pop.Connection) flowpilot.FlowDB {
+	return &redisFlowDB{pool: s.pool, tx: tx}
+}
+
+// redisFlowClaimTTL is the time a claim on the version of a flow is held at most, it only has to outlast the
+// transaction of a flow step.
+const redisFlowClaimTTL = 1 * time.Minute
+
+// createFlowScript stores a flow unless a flow with the same ID exists. The key expires at the given unix time in
+// milliseconds.
+var createFlowScript = redis.NewScript(1, `
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'data', ARGV[1], 'version', ARGV[2], 'csrf_token', ARGV[3], 'expires_at', ARGV[4], 'created_at', ARGV[5], 'updated_at', ARGV[6])
+redis.call('PEXPIREAT', KEYS[1], ARGV[7])
+return 1
+`)
+
+// claimFlowScript claims the expected version of a flow for an update, unless the flow has another version or the
+// version has already been claimed. The claim expires after the given number of milliseconds.
+var claimFlowScript = redis.NewScript(2, `
+if redis.call('HGET', KEYS[1], 'version') ~= ARGV[1] then
+	return 0
+end
+if not redis.call('SET', KEYS[2], ARGV[2], 'NX', 'PX', ARGV[3]) then
+	return 0
+end
+return 1
+`)
+
+// updateFlowScript releases the given claim and updates a flow if it has the expected version.
+var updateFlowScript = redis.NewScript(2, `
+if redis.call('GET', KEYS[2]) == ARGV[2] then
+	redis.call('DEL', KEYS[2])
+end
+if redis.call('HGET', KEYS[1], 'version') ~= ARGV[1] then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'data', ARGV[3], 'version', ARGV[4], 'csrf_token', ARGV[5], 'updated_at', ARGV[6])
+return 1
+`)
+
+// releaseClaimScript releases the given claim.
+var releaseClaimScript = redis.NewScript(1, `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// redisFlowDB stores flows as Redis hashes. Writes are applied after the transaction of the flow step has been
+// committed, so a flow is never ahead of the data written in the transaction. The version of a flow is claimed when it
+// is updated, so only one of concurrent steps of the same flow succeeds, like with the database.
+type redisFlowDB struct {
+	pool *redis.Pool
+	tx   *pop.Connection
+	// pending is the flow written with this FlowDB until it has been stored in Redis.
+	pending *flowpilot.FlowModel
+	// created reports whether the pending flow is a new flow.
+	created bool
+	// claimedVersion is the version of the flow claimed for the pending update and claim the value of the claim.
+	claimedVersion int
+	claim          string
+	// err is the error storing the pending flow.
+	err error
+}
+
+func (db *redisFlowDB) GetFlow(flowID uuid.UUID) (*flowpilot.FlowModel, error) {
+	if db.pending != nil && db.pending.ID == flowID {
+		flowModel := *db.pending
+		return &flowModel, nil
+	}
+
+	conn := db.pool.Get()
+	defer conn.Close()
+
+	values, err := redis.StringMap(conn.Do("HGETALL", redisFlowKey(flowID)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flow from redis: %w", err)
+	}
+
+	if len(values) == 0 {
+		// flowpilot and the passcode link handler expect the error of the database for unknown flows
+		return nil, fmt.Errorf("flow does not exist: %w", sql.ErrNoRows)
+	}
+
+	return flowModelFromRedis(flowID, values)
+}
+
+func (db *redisFlowDB) CreateFlow(flowModel flowpilot.FlowModel) error {
+	if db.pending != nil {
+		return errors.New("a flow has already been written")
+	}
+
+	conn := db.pool.Get()
+	defer conn.Close()
+
+	exists, err := redis.Bool(conn.Do("EXISTS", redisFlowKey(flowModel.ID)))
+	if err != nil {
+		return fmt.Errorf("failed to get flow from redis: %w", err)
+	}
+
+	if exists {
+		return fmt.Errorf("flow '%s' already exists", flowModel.ID)
+	}
+
+	db.pending = &flowModel
+	db.created = true
+
+	return db.storeAfterCommit()
+}
+
+func (db *redisFlowDB) UpdateFlow(flowModel flowpilot.FlowModel) error {
+	if db.pending != nil {
+		// the flow is written again within the same transaction
+		if db.pending.ID != flowModel.ID || db.pending.Version != flowModel.Version-1 {
+			return errors.New("version conflict while updating the flow")
+		}
+
+		db.pending = &flowModel
+
+		return nil
+	}
+
+	claim, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+
+	conn := db.pool.Get()
+	defer conn.Close()
+
+	claimed, err := redis.Int(claimFlowScript.Do(conn,
+		redisFlowKey(flowModel.ID),
+		redisFlowClaimKey(flowModel.ID),
+		flowModel.Version-1,
+		claim.String(),
+		redisFlowClaimTTL.Milliseconds()))
+	if err != nil {
+		return fmt.Errorf("failed to claim flow in redis: %w", err)
+	}
+
+	if claimed != 1 {
+		return errors.New("version conflict while updating the flow")
+	}
+
+	db.pending = &flowModel
+	db.claimedVersion = flowModel.Version - 1
+	db.claim = claim.String()
+
+	return db.storeAfterCommit()
+}
+
+// storeAfterCommit stores the pending flow once the transaction has been committed, or immediately if the FlowDB has
+// no transaction. The pending flow is discarded if it cannot be stored after the commit.
+func (db *redisFlowDB) storeAfterCommit() error {
+	err := persistence.AfterCommit(db.tx, db.store)
+	if err != nil {
+		return errors.Join(err, db.Discard())
+	}
+
+	return db.err
+}
+
+// store stores the pending flow in Redis.
+func (db *redisFlowDB) store() {
+	if db.pending == nil {
+		return
+	}
+
+	conn := db.pool.Get()
+	defer conn.Close()
+
+	flowModel := db.pending
+	if db.created {
+		created, err := redis.Int(createFlowScript.Do(conn,
+			redisFlowKey(flowModel.ID),
+			flowModel.Data,
+			flowModel.Version,
+			flowModel.CSRFToken,
+			formatRedisTime(flowModel.ExpiresAt),
+			formatRedisTime(flowModel.CreatedAt),
+			formatRedisTime(flowModel.UpdatedAt),
+			flowModel.ExpiresAt.Add(expiredFlowRetention).UnixMilli()))
+		if err != nil {
+			db.err = fmt.Errorf("failed to store flow in redis: %w", err)
+		} else if created != 1 {
+			db.err = fmt.Errorf("flow '%s' already exists", flowModel.ID)
+		}
+	} else {
+		updated, err := redis.Int(updateFlowScript.Do(conn,
+			redisFlowKey(flowModel.ID),
+			redisFlowClaimKey(flowModel.ID),
+			db.claimedVersion,
+			db.claim,
+			flowModel.Data,
+			flowModel.Version,
+			flowModel.CSRFToken,
+			formatRedisTime(flowModel.UpdatedAt)))
+		if err != nil {
+			db.err = fmt.Errorf("failed to update flow in redis: %w", err)
+		} else if updated != 1 {
+			db.err = errors.New("version conflict while updating the flow")
+		}
+	}
+
+	db.pending = nil
+	db.claim = ""
+}
+
+// Err returns the error storing the flow written with this FlowDB after the transaction has been committed.
+func (db *redisFlowDB) Err() error {
+	return db.err
+}
+
+// Discard drops the flow written with this FlowDB and releases the claim on its version, after the transaction has
+// been rolled back. The flow can then be continued from its previous version.
+func (db *redisFlowDB) Discard() error {
+	if db.pending == nil {
+		return nil
+	}
+
+	flowID := db.pending.ID
+	claim := db.claim
+	db.pending = nil
+	db.claim = ""
+
+	if claim == "" {
+		return nil
+	}
+
+	conn := db.pool.Get()
+	defer conn.Close()
+
+	_, err := releaseClaimScript.Do(conn, redisFlowClaimKey(flowID), claim)
+	if err != nil {
+		return fmt.Errorf("failed to release flow claim in redis: %w", err)
+	}
+
+	return nil
+}
+
+func redisFlowKey(flowID uuid.UUID) string {
+	return redisFlowKeyPrefix + flowID.String()
+}
+
+func redisFlowClaimKey(flowID uuid.UUID) string {
+	return redisFlowKey(flowID) + ":claim"
+}
+
+func formatRedisTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func flowModelFromRedis(flowID uuid.UUID, values map[string]string) (*flowpilot.FlowModel, error) {
+	version, err := strconv.Atoi(values["version"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse version of flow: %w", err)
+	}
+
+	flowModel := &flowpilot.FlowModel{
+		ID:        flowID,
+		Data:      values["data"],
+		CSRFToken: values["csrf_token"],
+		Version:   version,
+	}
+
+	for field, target := range map[string]*time.Time{
+		"expires_at": &flowModel.ExpiresAt,
+		"created_at": &flowModel.CreatedAt,
+		"updated_at": &flowModel.UpdatedAt,
+	} {
+		*target, err = time.Parse(time.RFC3339Nano, values[field])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s of flow: %w", field, err)
+		}
+	}
+
+	return flowModel, nil
+}
@@ -0,0 +1,208 @@
+package flow_api
+
+import (
+	"database/sql"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamhanko/hanko/backend/flowpilot"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"testing"
+	"time"
+)
+
+func newRedisFlowStore(t *testing.T) (*redisFlowStore, *miniredis.Miniredis) {
+	m := miniredis.RunT(t)
+	store := &redisFlowStore{
+		pool: &redis.Pool{
+			Dial: func() (redis.Conn, error) {
+				return redis.Dial("tcp", m.Addr())
+			},
+		},
+	}
+	t.Cleanup(func() {
+		_ = store.pool.Close()
+	})
+
+	return store, m
+}
+
+func newRedisTestFlow(now time.Time) flowpilot.FlowModel {
+	return flowpilot.FlowModel{
+		ID:        uuid.Must(uuid.NewV4()),
+		Data:      `{"state":"login_init"}`,
+		Version:   0,
+		CSRFToken: "csrf",
+		ExpiresAt: now.Add(time.Hour),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// uncommittedTx returns a connection which looks like an open transaction tracked by the persister, so functions
+// registered to run after the commit are only run once the transaction is finished as committed.
+func uncommittedTx(t *testing.T) *pop.Connection {
+	tx := &pop.Connection{TX: &pop.Tx{}}
+	persistence.TrackAfterCommit(tx)
+	t.Cleanup(func() {
+		persistence.FinishAfterCommit(tx, false)
+	})
+
+	return tx
+}
+
+func TestRedisFlowDB_CreateAndUpdate(t *testing.T) {
+	store, _ := newRedisFlowStore(t)
+	now := time.Now().UTC()
+	flowModel := newRedisTestFlow(now)
+
+	require.NoError(t, store.FlowDB(nil).CreateFlow(flowModel))
+	assert.Error(t, store.FlowDB(nil).CreateFlow(flowModel), "the flow already exists")
+
+	stored, err := store.FlowDB(nil).GetFlow(flowModel.ID)
+	require.NoError(t, err)
+	assert.Equal(t, flowModel.Data, stored.Data)
+	assert.Equal(t, 0, stored.Version)
+	assert.Equal(t, flowModel.ExpiresAt, stored.ExpiresAt)
+
+	stored.Data = `{"state":"login_method_chooser"}`
+	stored.Version = 1
+	require.NoError(t, store.FlowDB(nil).UpdateFlow(*stored))
+
+	updated, err := store.FlowDB(nil).GetFlow(flowModel.ID)
+	require.NoError(t, err)
+	assert.Equal(t, stored.Data, updated.Data)
+	assert.Equal(t, 1, updated.Version)
+
+	_, err = store.FlowDB(nil).GetFlow(uuid.Must(uuid.NewV4()))
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestRedisFlowDB_VersionConflict(t *testing.T) {
+	store, _ := newRedisFlowStore(t)
+	flowModel := newRedisTestFlow(time.Now().UTC())
+	require.NoError(t, store.FlowDB(nil).CreateFlow(flowModel))
+
+	// the flow does not have the version preceding the update
+	stale := flowModel
+	stale.Version = 2
+	assert.Error(t, store.FlowDB(nil).UpdateFlow(stale))
+
+	// only one of concurrent steps claims the version
+	first := flowModel
+	first.Version = 1
+	first.Data = "first"
+	second := first
+	second.Data = "second"
+
+	require.NoError(t, store.FlowDB(uncommittedTx(t)).UpdateFlow(first))
+	assert.Error(t, store.FlowDB(uncommittedTx(t)).UpdateFlow(second))
+	assert.Error(t, store.FlowDB(nil).UpdateFlow(second))
+}
+
+func TestRedisFlowDB_WriteAfterCommit(t *testing.T) {
+	store, _ := newRedisFlowStore(t)
+	flowModel := newRedisTestFlow(time.Now().UTC())
+
+	// a created flow is not stored before the transaction has been committed
+	flowDB := store.FlowDB(uncommittedTx(t))
+	require.NoError(t, flowDB.CreateFlow(flowModel))
+
+	pending, err := flowDB.GetFlow(flowModel.ID)
+	require.NoError(t, err, "the flow is read from the pending write")
+	assert.Equal(t, flowModel.Data, pending.Data)
+
+	_, err = store.FlowDB(nil).GetFlow(flowModel.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	// an updated flow is not changed before the transaction has been committed
+	require.NoError(t, store.FlowDB(nil).CreateFlow(flowModel))
+	updated := flowModel
+	updated.Version = 1
+	updated.Data = "updated"
+	tx := uncommittedTx(t)
+	require.NoError(t, store.FlowDB(tx).UpdateFlow(updated))
+
+	stored, err := store.FlowDB(nil).GetFlow(flowModel.ID)
+	require.NoError(t, err)
+	assert.Equal(t, flowModel.Data, stored.Data)
+	assert.Equal(t, 0, stored.Version)
+
+	// the updated flow is stored once the transaction has been committed
+	persistence.FinishAfterCommit(tx, true)
+
+	stored, err = store.FlowDB(nil).GetFlow(flowModel.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "updated", stored.Data)
+	assert.Equal(t, 1, stored.Version)
+}
+
+func TestRedisFlowDB_UntrackedTransaction(t *testing.T) {
+	store, m := newRedisFlowStore(t)
+	flowModel := newRedisTestFlow(time.Now().UTC())
+
+	// the flow would never be stored, because nothing runs the functions registered for the transaction
+	untracked := &pop.Connection{TX: &pop.Tx{}}
+	assert.ErrorIs(t, store.FlowDB(untracked).CreateFlow(flowModel), persistence.ErrUntrackedTransaction)
+
+	require.NoError(t, store.FlowDB(nil).CreateFlow(flowModel))
+	updated := flowModel
+	updated.Version = 1
+	assert.ErrorIs(t, store.FlowDB(untracked).UpdateFlow(updated), persistence.ErrUntrackedTransaction)
+	assert.False(t, m.Exists(redisFlowClaimKey(flowModel.ID)), "the claim is released")
+
+	// the flow can still be updated
+	require.NoError(t, store.FlowDB(nil).UpdateFlow(updated))
+}
+
+func TestRedisFlowDB_Discard(t *testing.T) {
+	store, m := newRedisFlowStore(t)
+	flowModel := newRedisTestFlow(time.Now().UTC())
+	require.NoError(t, store.FlowDB(nil).CreateFlow(flowModel))
+
+	updated := flowModel
+	updated.Version = 1
+	updated.Data = "updated"
+
+	flowDB := store.FlowDB(uncommittedTx(t))
+	require.NoError(t, flowDB.UpdateFlow(updated))
+	assert.True(t, m.Exists(redisFlowClaimKey(flowModel.ID)))
+
+	// the transaction has been rolled back
+	require.NoError(t, flowDB.(deferredFlowDB).Discard())
+	assert.False(t, m.Exists(redisFlowClaimKey(flowModel.ID)))
+
+	stored, err := flowDB.GetFlow(flowModel.ID)
+	require.NoError(t, err)
+	assert.Equal(t, flowModel.Data, stored.Data)
+	assert.Equal(t, 0, stored.Version)
+
+	// the flow can be continued from its previous version
+	require.NoError(t, store.FlowDB(nil).UpdateFlow(updated))
+
+	stored, err = store.FlowDB(nil).GetFlow(flowModel.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "updated", stored.Data)
+	assert.Equal(t, 1, stored.Version)
+	assert.False(t, m.Exists(redisFlowClaimKey(flowModel.ID)), "the claim is released by the update")
+}
+
+func TestRedisFlowDB_Expiry(t *testing.T) {
+	store, m := newRedisFlowStore(t)
+	now := time.Now().UTC()
+	m.SetTime(now)
+	flowModel := newRedisTestFlow(now)
+	require.NoError(t, store.FlowDB(nil).CreateFlow(flowModel))
+
+	// expired flows are kept for a while
+	m.FastForward(time.Hour + expiredFlowRetention - time.Minute)
+	_, err := store.FlowDB(nil).GetFlow(flowModel.ID)
+	assert.NoError(t, err)
+
+	m.FastForward(2 * time.Minute)
+	_, err = store.FlowDB(nil).GetFlow(flowModel.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
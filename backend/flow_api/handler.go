@@ -12,6 +12,7 @@ import (
 	"github.com/sethvargo/go-limiter"
 	auditlog "github.com/teamhanko/hanko/backend/audit_log"
 	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/crypto/link_token"
 	"github.com/teamhanko/hanko/backend/dto"
 	"github.com/teamhanko/hanko/backend/ee/saml"
 	"github.com/teamhanko/hanko/backend/flow_api/flow"
@@ -20,39 +21,42 @@ import (
 	"github.com/teamhanko/hanko/backend/flowpilot"
 	"github.com/teamhanko/hanko/backend/mapper"
 	"github.com/teamhanko/hanko/backend/persistence"
-	"github.com/teamhanko/hanko/backend/persistence/models"
 	"github.com/teamhanko/hanko/backend/session"
+	"github.com/teamhanko/hanko/backend/telemetry"
 	"strconv"
 	"time"
 )
 
 type FlowPilotHandler struct {
-	Persister                persistence.Persister
-	Cfg                      config.Config
-	PasscodeService          services.Passcode
-	PasswordService          services.Password
-	WebauthnService          services.WebauthnService
-	SamlService              saml.Service
-	SessionManager           session.Manager
-	PasscodeRateLimiter      limiter.Store
-	PasswordRateLimiter      limiter.Store
-	TokenExchangeRateLimiter limiter.Store
-	AuthenticatorMetadata    mapper.AuthenticatorMetadata
-	AuditLogger              auditlog.Logger
+	Persister                   persistence.Persister
+	FlowStore                   FlowStore
+	Cfg                         config.Config
+	PasscodeService             services.Passcode
+	PasswordService             services.Password
+	WebauthnService             services.WebauthnService
+	SecurityNotificationService services.SecurityNotification
+	SamlService                 saml.Service
+	SessionManager              session.Manager
+	LinkTokenSigner             link_token.Signer
+	PasscodeRateLimiter         limiter.Store
+	PasswordRateLimiter         limiter.Store
+	TokenExchangeRateLimiter    limiter.Store
+	AuthenticatorMetadata       mapper.AuthenticatorMetadata
+	AuditLogger                 auditlog.Logger
 }
 
 func (h *FlowPilotHandler) RegistrationFlowHandler(c echo.Context) error {
-	registrationFlow := flow.NewRegistrationFlow(h.Cfg.Debug)
+	registrationFlow := flow.NewRegistrationFlow(h.Cfg)
 	return h.executeFlow(c, registrationFlow)
 }
 
 func (h *FlowPilotHandler) LoginFlowHandler(c echo.Context) error {
-	loginFlow := flow.NewLoginFlow(h.Cfg.Debug)
+	loginFlow := flow.NewLoginFlow(h.Cfg)
 	return h.executeFlow(c, loginFlow)
 }
 
 func (h *FlowPilotHandler) ProfileFlowHandler(c echo.Context) error {
-	profileFlow := flow.NewProfileFlow(h.Cfg.Debug)
+	profileFlow := flow.NewProfileFlow(h.Cfg)
 
 	if err := h.validateSession(c); err != nil {
 		flowResult := profileFlow.ResultFromError(err)
@@ -135,46 +139,74 @@ func (h *FlowPilotHandler) executeFlow(c echo.Context, flow flowpilot.Flow) erro
 	var err error
 	var inputData flowpilot.InputData
 	var flowResult flowpilot.FlowResult
+	var actionEvent *flowpilot.ActionEvent
+	var flowDB flowpilot.FlowDB
+
+	tracer := telemetry.NewFlowTracer(c)
 
 	txFunc := func(tx *pop.Connection) error {
 		deps := &shared.Dependencies{
-			Cfg:                      h.Cfg,
-			PasscodeRateLimiter:      h.PasscodeRateLimiter,
-			PasswordRateLimiter:      h.PasswordRateLimiter,
-			TokenExchangeRateLimiter: h.TokenExchangeRateLimiter,
-			Tx:                       tx,
-			Persister:                h.Persister,
-			HttpContext:              c,
-			SessionManager:           h.SessionManager,
-			PasscodeService:          h.PasscodeService,
-			PasswordService:          h.PasswordService,
-			WebauthnService:          h.WebauthnService,
-			SamlService:              h.SamlService,
-			AuthenticatorMetadata:    h.AuthenticatorMetadata,
-			AuditLogger:              h.AuditLogger,
+			Cfg:                         h.Cfg,
+			PasscodeRateLimiter:         h.PasscodeRateLimiter,
+			PasswordRateLimiter:         h.PasswordRateLimiter,
+			TokenExchangeRateLimiter:    h.TokenExchangeRateLimiter,
+			Tx:                          tx,
+			Persister:                   h.Persister,
+			HttpContext:                 c,
+			SessionManager:              h.SessionManager,
+			LinkTokenSigner:             h.LinkTokenSigner,
+			PasscodeService:             h.PasscodeService,
+			PasswordService:             h.PasswordService,
+			WebauthnService:             h.WebauthnService,
+			SecurityNotificationService: h.SecurityNotificationService,
+			SamlService:                 h.SamlService,
+			AuthenticatorMetadata:       h.AuthenticatorMetadata,
+			AuditLogger:                 h.AuditLogger,
 		}
 
 		flow.Set("deps", deps)
 
-		flowResult, err = flow.Execute(models.NewFlowDB(tx),
+		// queries are traced as part of the action or hook executing them
+		telemetry.TraceConnection(tx, tracer.Context)
+
+		flowDB = h.FlowStore.FlowDB(tx)
+		flowResult, err = flow.Execute(flowDB,
 			flowpilot.WithQueryParamKey(queryParamKey),
 			flowpilot.WithQueryParamValue(c.QueryParam(queryParamKey)),
 			flowpilot.WithInputData(inputData),
-			flowpilot.UseCompression(!h.Cfg.Debug))
+			flowpilot.UseCompression(!h.Cfg.Debug),
+			flowpilot.WithTracer(tracer),
+			flowpilot.WithActionObserver(func(event flowpilot.ActionEvent) {
+				actionEvent = &event
+			}))
 
 		return err
 	}
 
+	start := time.Now()
+
 	err = c.Bind(&inputData)
 	if err != nil {
 		flowResult = flow.ResultFromError(flowpilot.ErrorTechnical.Wrap(err))
 	} else {
 		err = h.Persister.Transaction(txFunc)
+		if err == nil {
+			err = flowDBError(flowDB)
+		}
 		if err != nil {
+			h.discardFlowDB(flowDB)
 			flowResult = flow.ResultFromError(err)
 		}
 	}
 
+	if actionEvent != nil {
+		// created after the transaction, so that actions failing with a technical error are logged as well
+		h.auditFlowAction(c, *actionEvent, err)
+		observeFlowAction(flow, *actionEvent, err, time.Since(start))
+	} else if c.QueryParam(queryParamKey) == "" {
+		observeFlowStart(flow, flowResult)
+	}
+
 	log := zeroLogger.Info().
 		Str("time_unix", strconv.FormatInt(time.Now().Unix(), 10)).
 		Str("id", c.Response().Header().Get(echo.HeaderXRequestID)).
@@ -193,6 +225,25 @@ func (h *FlowPilotHandler) executeFlow(c echo.Context, flow flowpilot.Flow) erro
 	return c.JSON(flowResult.GetStatus(), flowResult.GetResponse())
 }
 
+// flowDBError returns the error of a FlowDB not taking part in the transaction storing the flow after the commit.
+func flowDBError(flowDB flowpilot.FlowDB) error {
+	if d, ok := flowDB.(deferredFlowDB); ok {
+		return d.Err()
+	}
+
+	return nil
+}
+
+// discardFlowDB drops the changes made with a FlowDB not taking part in the rolled back transaction, so the flow
+// can be continued from its previous state.
+func (h *FlowPilotHandler) discardFlowDB(flowDB flowpilot.FlowDB) {
+	if d, ok := flowDB.(deferredFlowDB); ok {
+		if err := d.Discard(); err != nil {
+			zeroLogger.Error().Err(err).Msg("failed to discard flow")
+		}
+	}
+}
+
 func init() {
 	zerolog.TimeFieldFormat = time.RFC3339Nano
 }
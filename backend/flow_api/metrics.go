@@ -0,0 +1,76 @@
+package flow_api
+
+import (
+	"github.com/teamhanko/hanko/backend/flowpilot"
+	"github.com/teamhanko/hanko/backend/telemetry"
+	"sync"
+	"time"
+)
+
+// unknownLabel replaces label values which are not known to the flow, e.g. action names of requests for actions
+// that do not exist.
+const unknownLabel = "unknown"
+
+// flowActionNames caches the action names per state of each flow, see actionNames.
+var flowActionNames sync.Map
+
+// actionNames returns the names of the actions of the flow per state, including the states of sub-flows.
+func actionNames(flow flowpilot.Flow, flowName flowpilot.FlowName) map[flowpilot.StateName]map[flowpilot.ActionName]bool {
+	if names, ok := flowActionNames.Load(flowName); ok {
+		return names.(map[flowpilot.StateName]map[flowpilot.ActionName]bool)
+	}
+
+	names := make(map[flowpilot.StateName]map[flowpilot.ActionName]bool)
+
+	var collect func(description flowpilot.FlowDescription)
+	collect = func(description flowpilot.FlowDescription) {
+		for _, state := range description.States {
+			names[state.Name] = make(map[flowpilot.ActionName]bool)
+			for _, action := range state.Actions {
+				names[state.Name][action.Name] = true
+			}
+		}
+
+		for _, subFlow := range description.SubFlows {
+			collect(subFlow)
+		}
+	}
+	collect(flow.Describe())
+
+	flowActionNames.Store(flowName, names)
+
+	return names
+}
+
+// observeFlowAction records the execution of an action. The error is the error of the transaction the action has been
+// executed in. The state and action labels are replaced with unknownLabel if the flow does not know them, so requests
+// cannot create arbitrary time series.
+func observeFlowAction(flow flowpilot.Flow, event flowpilot.ActionEvent, err error, duration time.Duration) {
+	outcome := event.Outcome
+	if err != nil {
+		// e.g. the transaction could not be committed
+		outcome = flowpilot.ActionOutcomeFailed
+	}
+
+	names := actionNames(flow, event.FlowName)
+
+	state, action := unknownLabel, unknownLabel
+	if actions, ok := names[event.StateName]; ok {
+		state = string(event.StateName)
+		if actions[event.ActionName] {
+			action = string(event.ActionName)
+		}
+	}
+
+	telemetry.ObserveFlowAction(string(event.FlowName), state, action, string(outcome), duration)
+}
+
+// observeFlowStart records the start of a flow.
+func observeFlowStart(flow flowpilot.Flow, flowResult flowpilot.FlowResult) {
+	outcome := flowpilot.ActionOutcomeSucceeded
+	if flowResult.GetResponse().Error != nil {
+		outcome = flowpilot.ActionOutcomeFailed
+	}
+
+	telemetry.ObserveFlowStart(string(flow.Describe().Name), string(outcome))
+}
@@ -0,0 +1,143 @@
+package flow_api
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/teamhanko/hanko/backend/crypto/link_token"
+	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/flow_api/flow/shared"
+	"github.com/teamhanko/hanko/backend/flow_api/services"
+	"github.com/teamhanko/hanko/backend/template"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	passcodeLinkConfirmed       = "confirmed"
+	passcodeLinkInvalid         = "invalid"
+	passcodeLinkBrowserMismatch = "browser_mismatch"
+)
+
+var errPasscodeLinkFlowInvalid = errors.New("flow does not exist or is expired")
+
+// PasscodeLinkConfirmation renders the page a passcode link leads to. The passcode is only confirmed when the user
+// submits the page (see PasscodeLinkHandler), so it is not confirmed by link scanners or prefetchers of mail gateways
+// opening the link.
+func (h *FlowPilotHandler) PasscodeLinkConfirmation(c echo.Context) error {
+	token := c.QueryParam("token")
+	_, _, result, err := h.verifyPasscodeLinkToken(c, token)
+	if err != nil {
+		return h.passcodeLinkResponse(c, result, err)
+	}
+
+	return c.Render(http.StatusOK, "confirm", template.ConfirmationPage{
+		Title:   "Confirm sign in",
+		Message: "Continue on the device you have requested the email on?",
+		Token:   token,
+		Button:  "Confirm",
+	})
+}
+
+// PasscodeLinkHandler confirms the passcode a link has been sent for. The token of the link is submitted by the page
+// rendered by PasscodeLinkConfirmation. The flow the passcode has been requested in completes the passcode
+// confirmation afterwards with the "verify_passcode_link" action, so the session is always issued to the client which
+// started the flow and never to the client opening the link.
+func (h *FlowPilotHandler) PasscodeLinkHandler(c echo.Context) error {
+	passcodeID, flowID, result, err := h.verifyPasscodeLinkToken(c, c.FormValue("token"))
+	if err != nil {
+		return h.passcodeLinkResponse(c, result, err)
+	}
+
+	err = h.Persister.Transaction(func(tx *pop.Connection) error {
+		flowModel, err := h.FlowStore.FlowDB(tx).GetFlow(flowID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return errPasscodeLinkFlowInvalid
+			}
+			return fmt.Errorf("failed to get flow from database: %w", err)
+		}
+
+		if flowModel.ExpiresAt.Before(time.Now().UTC()) {
+			return errPasscodeLinkFlowInvalid
+		}
+
+		return h.PasscodeService.ConfirmPasscodeLink(tx, passcodeID, flowID)
+	})
+	if err != nil {
+		if errors.Is(err, errPasscodeLinkFlowInvalid) ||
+			errors.Is(err, services.ErrorPasscodeInvalid) ||
+			errors.Is(err, services.ErrorPasscodeNotFound) ||
+			errors.Is(err, services.ErrorPasscodeExpired) ||
+			errors.Is(err, services.ErrorPasscodeMaxAttemptsReached) {
+			return h.passcodeLinkResponse(c, passcodeLinkInvalid, echo.NewHTTPError(http.StatusBadRequest, "invalid or expired passcode").SetInternal(err))
+		}
+
+		return h.passcodeLinkResponse(c, passcodeLinkInvalid, dto.ToHttpError(err))
+	}
+
+	return h.passcodeLinkResponse(c, passcodeLinkConfirmed, nil)
+}
+
+// verifyPasscodeLinkToken verifies the token of a passcode link and, if the link is bound to a browser, that it is
+// opened in this browser. It returns the IDs of the passcode and the flow or, if the token is not valid, the result
+// and the error to respond with.
+func (h *FlowPilotHandler) verifyPasscodeLinkToken(c echo.Context, signed string) (uuid.UUID, uuid.UUID, string, error) {
+	token, err := h.LinkTokenSigner.Verify(link_token.PurposePasscodeLink, signed)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, passcodeLinkInvalid, echo.NewHTTPError(http.StatusBadRequest, "invalid or expired token").SetInternal(err)
+	}
+
+	passcodeID, err := uuid.FromString(token.Subject())
+	if err != nil {
+		return uuid.Nil, uuid.Nil, passcodeLinkInvalid, echo.NewHTTPError(http.StatusBadRequest, "invalid token").SetInternal(err)
+	}
+
+	flowIDClaim, _ := token.Get("flow_id")
+	flowIDString, _ := flowIDClaim.(string)
+	flowID, err := uuid.FromString(flowIDString)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, passcodeLinkInvalid, echo.NewHTTPError(http.StatusBadRequest, "invalid token").SetInternal(err)
+	}
+
+	if browserClaim, ok := token.Get("browser"); ok {
+		browserBinding, _ := browserClaim.(string)
+		cookie, err := c.Cookie(shared.PasscodeLinkCookieName)
+		if err != nil || subtle.ConstantTimeCompare([]byte(shared.PasscodeLinkBrowserBinding(cookie.Value)), []byte(browserBinding)) != 1 {
+			return uuid.Nil, uuid.Nil, passcodeLinkBrowserMismatch, echo.NewHTTPError(http.StatusForbidden, "the link must be opened in the browser it has been requested in")
+		}
+	}
+
+	return passcodeID, flowID, "", nil
+}
+
+func (h *FlowPilotHandler) passcodeLinkResponse(c echo.Context, result string, httpError error) error {
+	redirectURL := h.Cfg.Email.PasscodeLink.RedirectURL
+	if redirectURL == "" {
+		if httpError != nil {
+			return httpError
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"passcode_link": result})
+	}
+
+	if httpError != nil {
+		c.Logger().Error(httpError)
+	}
+
+	location, err := url.Parse(redirectURL)
+	if err != nil {
+		return fmt.Errorf("failed to parse passcode link redirect url: %w", err)
+	}
+
+	query := location.Query()
+	query.Set("passcode_link", result)
+	location.RawQuery = query.Encode()
+
+	return c.Redirect(http.StatusSeeOther, location.String())
+}
@@ -0,0 +1,225 @@
+package flow_api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/crypto/link_token"
+	"github.com/teamhanko/hanko/backend/flow_api/flow/shared"
+	"github.com/teamhanko/hanko/backend/flow_api/services"
+	"github.com/teamhanko/hanko/backend/flowpilot"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/template"
+	"github.com/teamhanko/hanko/backend/test"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memoryFlowStore keeps flows in memory.
+type memoryFlowStore struct {
+	flows map[uuid.UUID]flowpilot.FlowModel
+}
+
+func (s *memoryFlowStore) FlowDB(_ *pop.Connection) flowpilot.FlowDB {
+	return s
+}
+
+func (s *memoryFlowStore) GetFlow(flowID uuid.UUID) (*flowpilot.FlowModel, error) {
+	flowModel, ok := s.flows[flowID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &flowModel, nil
+}
+
+func (s *memoryFlowStore) CreateFlow(flowModel flowpilot.FlowModel) error {
+	s.flows[flowModel.ID] = flowModel
+	return nil
+}
+
+func (s *memoryFlowStore) UpdateFlow(flowModel flowpilot.FlowModel) error {
+	s.flows[flowModel.ID] = flowModel
+	return nil
+}
+
+type passcodeLinkTest struct {
+	handler   *FlowPilotHandler
+	persister persistence.Persister
+	signer    link_token.Signer
+	flowID    uuid.UUID
+	passcode  models.Passcode
+}
+
+func newPasscodeLinkTest(t *testing.T, passcodeCreatedAt time.Time) *passcodeLinkTest {
+	now := time.Now().UTC()
+	flowID := uuid.Must(uuid.NewV4())
+	flowStore := &memoryFlowStore{flows: map[uuid.UUID]flowpilot.FlowModel{
+		flowID: {ID: flowID, ExpiresAt: now.Add(time.Hour), CreatedAt: now, UpdatedAt: now},
+	}}
+
+	passcode := models.Passcode{
+		ID:        uuid.Must(uuid.NewV4()),
+		FlowID:    &flowID,
+		Ttl:       300,
+		Code:      "hashed",
+		CreatedAt: passcodeCreatedAt,
+		UpdatedAt: passcodeCreatedAt,
+	}
+	persister := test.NewPersister(nil, []models.Passcode{passcode}, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	signer, err := link_token.NewSigner(test.DefaultConfig.Secrets.Keys)
+	require.NoError(t, err)
+
+	cfg := config.Config{}
+	cfg.Email.PasscodeMode = config.PasscodeModeLink
+
+	return &passcodeLinkTest{
+		handler: &FlowPilotHandler{
+			Persister:       persister,
+			FlowStore:       flowStore,
+			Cfg:             cfg,
+			PasscodeService: services.NewPasscodeService(cfg, services.Email{}, persister, signer),
+			LinkTokenSigner: signer,
+		},
+		persister: persister,
+		signer:    signer,
+		flowID:    flowID,
+		passcode:  passcode,
+	}
+}
+
+func (s *passcodeLinkTest) token(t *testing.T, flowID uuid.UUID, browserBinding string) string {
+	claims := map[string]interface{}{"flow_id": flowID.String()}
+	if browserBinding != "" {
+		claims["browser"] = browserBinding
+	}
+	token, err := s.signer.Sign(link_token.PurposePasscodeLink, s.passcode.ID.String(), time.Minute, claims)
+	require.NoError(t, err)
+	return token
+}
+
+func (s *passcodeLinkTest) confirm(token string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	form := url.Values{"token": {token}}
+	req := httptest.NewRequest(http.MethodPost, "/passcode/link", strings.NewReader(form.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+
+	return s.serve(req)
+}
+
+func (s *passcodeLinkTest) serve(req *http.Request) *httptest.ResponseRecorder {
+	e := echo.New()
+	e.Renderer = template.NewTemplateRenderer()
+	e.GET("/passcode/link", s.handler.PasscodeLinkConfirmation)
+	e.POST("/passcode/link", s.handler.PasscodeLinkHandler)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *passcodeLinkTest) linkConfirmedAt(t *testing.T) *time.Time {
+	passcode, err := s.persister.GetPasscodePersister().Get(s.passcode.ID)
+	require.NoError(t, err)
+	require.NotNil(t, passcode)
+	return passcode.LinkConfirmedAt
+}
+
+func assertPasscodeLinkResult(t *testing.T, rec *httptest.ResponseRecorder, expected string) {
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, expected, body["passcode_link"])
+}
+
+func TestPasscodeLinkConfirmation(t *testing.T) {
+	s := newPasscodeLinkTest(t, time.Now().UTC())
+	token := s.token(t, s.flowID, "")
+
+	rec := s.serve(httptest.NewRequest(http.MethodGet, "/passcode/link?token="+url.QueryEscape(token), nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `<form method="post">`)
+	assert.Contains(t, rec.Body.String(), token)
+	assert.Nil(t, s.linkConfirmedAt(t), "opening the link must not confirm the passcode")
+
+	rec = s.serve(httptest.NewRequest(http.MethodGet, "/passcode/link?token=invalid", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestPasscodeLinkHandler(t *testing.T) {
+	s := newPasscodeLinkTest(t, time.Now().UTC())
+	token := s.token(t, s.flowID, "")
+
+	rec := s.confirm(token, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assertPasscodeLinkResult(t, rec, passcodeLinkConfirmed)
+	assert.NotNil(t, s.linkConfirmedAt(t))
+
+	// confirming an already confirmed link succeeds again
+	rec = s.confirm(token, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assertPasscodeLinkResult(t, rec, passcodeLinkConfirmed)
+}
+
+func TestPasscodeLinkHandler_WrongFlow(t *testing.T) {
+	s := newPasscodeLinkTest(t, time.Now().UTC())
+
+	// the flow does not exist
+	rec := s.confirm(s.token(t, uuid.Must(uuid.NewV4()), ""), nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// the passcode has been requested in another flow
+	otherFlowID := uuid.Must(uuid.NewV4())
+	now := time.Now().UTC()
+	require.NoError(t, s.handler.FlowStore.FlowDB(nil).CreateFlow(flowpilot.FlowModel{ID: otherFlowID, ExpiresAt: now.Add(time.Hour)}))
+	rec = s.confirm(s.token(t, otherFlowID, ""), nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	assert.Nil(t, s.linkConfirmedAt(t))
+}
+
+func TestPasscodeLinkHandler_ExpiredPasscode(t *testing.T) {
+	s := newPasscodeLinkTest(t, time.Now().UTC().Add(-10*time.Minute))
+
+	rec := s.confirm(s.token(t, s.flowID, ""), nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Nil(t, s.linkConfirmedAt(t))
+}
+
+func TestPasscodeLinkHandler_BrowserMismatch(t *testing.T) {
+	s := newPasscodeLinkTest(t, time.Now().UTC())
+	token := s.token(t, s.flowID, shared.PasscodeLinkBrowserBinding("browser"))
+
+	rec := s.confirm(token, nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = s.confirm(token, &http.Cookie{Name: shared.PasscodeLinkCookieName, Value: "other-browser"})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Nil(t, s.linkConfirmedAt(t))
+
+	rec = s.confirm(token, &http.Cookie{Name: shared.PasscodeLinkCookieName, Value: "browser"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotNil(t, s.linkConfirmedAt(t))
+}
+
+func TestPasscodeLinkHandler_Redirect(t *testing.T) {
+	s := newPasscodeLinkTest(t, time.Now().UTC())
+	s.handler.Cfg.Email.PasscodeLink.RedirectURL = "https://example.com/login"
+	token := s.token(t, s.flowID, shared.PasscodeLinkBrowserBinding("browser"))
+
+	rec := s.confirm(token, nil)
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+	assert.Equal(t, "https://example.com/login?passcode_link=browser_mismatch", rec.Header().Get(echo.HeaderLocation))
+	assert.Nil(t, s.linkConfirmedAt(t))
+}
@@ -2,9 +2,9 @@ package services
 
 import (
 	"fmt"
+	"github.com/gobuffalo/pop/v6"
 	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/mail"
-	"gopkg.in/gomail.v2"
 )
 
 type Email struct {
@@ -13,15 +13,11 @@ type Email struct {
 	cfg      config.Config
 }
 
-func NewEmailService(cfg config.Config) (*Email, error) {
-	renderer, err := mail.NewRenderer()
+func NewEmailService(cfg config.Config, mailer mail.Mailer) (*Email, error) {
+	renderer, err := mail.NewRenderer(cfg.EmailDelivery.TemplateDirectory)
 	if err != nil {
 		return nil, err
 	}
-	mailer, err := mail.NewMailer(cfg.EmailDelivery.SMTP)
-	if err != nil {
-		panic(fmt.Errorf("failed to create mailer: %w", err))
-	}
 
 	return &Email{
 		renderer,
@@ -30,15 +26,18 @@ func NewEmailService(cfg config.Config) (*Email, error) {
 	}, nil
 }
 
-// SendEmail sends an email to the emailAddress with the given subject and body.
-func (s *Email) SendEmail(emailAddress, subject, body string) error {
-	message := gomail.NewMessage()
-	message.SetAddressHeader("To", emailAddress, "")
-	message.SetAddressHeader("From", s.cfg.EmailDelivery.FromAddress, s.cfg.EmailDelivery.FromName)
-	message.SetHeader("Subject", subject)
-	message.SetBody("text/plain", body)
+// SendEmail sends an email to the emailAddress with the given subject and body, or adds it to the send queue if
+// enabled. Queued emails are stored using the given connection, so they are only sent if its transaction is
+// committed. If htmlBody is not empty, it is added as an alternative to the plain text body.
+func (s *Email) SendEmail(tx *pop.Connection, emailAddress, subject, body, htmlBody string) error {
+	message := mail.Message{
+		To:      emailAddress,
+		Subject: subject,
+		Text:    body,
+		HTML:    htmlBody,
+	}
 
-	if err := s.mailer.Send(message); err != nil {
+	if err := mail.SendWithConnection(s.mailer, tx, message); err != nil {
 		return err
 	}
 
@@ -56,3 +55,13 @@ func (s *Email) RenderSubject(lang, template string, data map[string]interface{}
 func (s *Email) RenderBody(lang, template string, data map[string]interface{}) (string, error) {
 	return s.renderer.Render(fmt.Sprintf("%s_text.tmpl", template), lang, data)
 }
+
+// RenderHTMLBody renders the HTML body with the given template. The template name must be given as described for
+// RenderBody. An empty string is returned if no HTML template exists for the template name.
+func (s *Email) RenderHTMLBody(lang, template string, data map[string]interface{}) (string, error) {
+	templateName := fmt.Sprintf("%s_html.tmpl", template)
+	if !s.renderer.HasTemplate(templateName) {
+		return "", nil
+	}
+	return s.renderer.Render(templateName, lang, data)
+}
@@ -7,9 +7,13 @@ import (
 	"github.com/gofrs/uuid"
 	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/crypto"
+	"github.com/teamhanko/hanko/backend/crypto/link_token"
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/persistence/models"
 	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/exp/slices"
+	"net/url"
+	"strings"
 	"time"
 )
 
@@ -20,12 +24,22 @@ var (
 	ErrorPasscodeNotFound           = errors.New("passcode not found")
 	ErrorPasscodeExpired            = errors.New("passcode is expired")
 	ErrorPasscodeMaxAttemptsReached = errors.New("the passcode was entered wrong too many times")
+	ErrorPasscodeLinkNotConfirmed   = errors.New("the passcode link has not been opened yet")
 )
 
+// passcodeLinkTemplates are the templates a link is sent with if the passcode mode is "link". Passcodes sent with other
+// templates always contain the code only.
+var passcodeLinkTemplates = []string{"login", "email_verification", "recovery"}
+
 type SendPasscodeParams struct {
 	Template     string
 	EmailAddress string
 	Language     string
+	// FlowID is the ID of the flow the passcode is requested in. Links are only sent if it is set.
+	FlowID uuid.UUID
+	// BrowserBinding is the hashed value of the cookie identifying the browser the passcode is requested in. If set,
+	// the link can only be confirmed in this browser.
+	BrowserBinding string
 }
 
 type ValidatePasscodeParams struct {
@@ -37,27 +51,33 @@ type SendPasscodeResult struct {
 	PasscodeModel models.Passcode
 	Subject       string
 	Body          string
+	HTMLBody      string
 	Code          string
+	Link          string
 }
 
 type Passcode interface {
 	ValidatePasscode(ValidatePasscodeParams) (bool, error)
 	SendPasscode(*pop.Connection, SendPasscodeParams) (*SendPasscodeResult, error)
 	VerifyPasscodeCode(tx *pop.Connection, passcodeID uuid.UUID, passcode string) error
+	ConfirmPasscodeLink(tx *pop.Connection, passcodeID uuid.UUID, flowID uuid.UUID) error
+	VerifyPasscodeLink(tx *pop.Connection, passcodeID uuid.UUID, flowID uuid.UUID) error
 }
 
 type passcode struct {
 	emailService      Email
 	passcodeGenerator crypto.PasscodeGenerator
 	persister         persistence.Persister
+	linkTokenSigner   link_token.Signer
 	cfg               config.Config
 }
 
-func NewPasscodeService(cfg config.Config, emailService Email, persister persistence.Persister) Passcode {
+func NewPasscodeService(cfg config.Config, emailService Email, persister persistence.Persister, linkTokenSigner link_token.Signer) Passcode {
 	return &passcode{
 		emailService,
 		crypto.NewPasscodeGenerator(),
 		persister,
+		linkTokenSigner,
 		cfg,
 	}
 }
@@ -110,6 +130,56 @@ func (s *passcode) VerifyPasscodeCode(tx *pop.Connection, passcodeID uuid.UUID,
 	return nil
 }
 
+// ConfirmPasscodeLink marks the passcode as confirmed through the link sent to the user. The passcode step of the
+// flow is completed with VerifyPasscodeLink afterwards.
+func (s *passcode) ConfirmPasscodeLink(tx *pop.Connection, passcodeID uuid.UUID, flowID uuid.UUID) error {
+	passcodeModel, err := s.getPasscode(tx, passcodeID)
+	if err != nil {
+		return err
+	}
+
+	if passcodeModel.FlowID == nil || *passcodeModel.FlowID != flowID {
+		return ErrorPasscodeInvalid
+	}
+
+	if passcodeModel.LinkConfirmedAt != nil {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	passcodeModel.LinkConfirmedAt = &now
+	err = s.persister.GetPasscodePersisterWithConnection(tx).Update(*passcodeModel)
+	if err != nil {
+		return fmt.Errorf("failed to update passcode: %w", err)
+	}
+
+	return nil
+}
+
+// VerifyPasscodeLink checks that the passcode has been requested in the given flow and confirmed through the link
+// and deletes it.
+func (s *passcode) VerifyPasscodeLink(tx *pop.Connection, passcodeID uuid.UUID, flowID uuid.UUID) error {
+	passcodeModel, err := s.getPasscode(tx, passcodeID)
+	if err != nil {
+		return err
+	}
+
+	if passcodeModel.FlowID == nil || *passcodeModel.FlowID != flowID {
+		return ErrorPasscodeInvalid
+	}
+
+	if passcodeModel.LinkConfirmedAt == nil {
+		return ErrorPasscodeLinkNotConfirmed
+	}
+
+	err = s.persister.GetPasscodePersisterWithConnection(tx).Delete(*passcodeModel)
+	if err != nil {
+		return fmt.Errorf("failed to delete passcode from db: %w", err)
+	}
+
+	return nil
+}
+
 func (s *passcode) SendPasscode(tx *pop.Connection, p SendPasscodeParams) (*SendPasscodeResult, error) {
 	code, err := s.passcodeGenerator.Generate()
 	if err != nil {
@@ -134,6 +204,9 @@ func (s *passcode) SendPasscode(tx *pop.Connection, p SendPasscodeParams) (*Send
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
+	if !p.FlowID.IsNil() {
+		passcodeModel.FlowID = &p.FlowID
+	}
 
 	err = s.persister.GetPasscodePersisterWithConnection(tx).Create(passcodeModel)
 	if err != nil {
@@ -147,14 +220,35 @@ func (s *passcode) SendPasscode(tx *pop.Connection, p SendPasscodeParams) (*Send
 		"TTL":         fmt.Sprintf("%.0f", durationTTL.Minutes()),
 	}
 
-	subject := s.emailService.RenderSubject(p.Language, p.Template, data)
+	subjectTemplate := p.Template
+	link := ""
+	if s.sendsLink(p) {
+		link, err = s.createLink(passcodeModel, p, durationTTL)
+		if err != nil {
+			return nil, err
+		}
+
+		subjectTemplate = p.Template + "_link"
+		data["Link"] = link
+		data["SameBrowser"] = p.BrowserBinding != ""
+		if !s.cfg.Email.PasscodeLink.IncludeCode {
+			delete(data, "Code")
+			code = ""
+		}
+	}
+
+	subject := s.emailService.RenderSubject(p.Language, subjectTemplate, data)
 	body, err := s.emailService.RenderBody(p.Language, p.Template, data)
 	if err != nil {
 		return nil, err
 	}
+	htmlBody, err := s.emailService.RenderHTMLBody(p.Language, p.Template, data)
+	if err != nil {
+		return nil, err
+	}
 
 	if s.cfg.EmailDelivery.Enabled {
-		err = s.emailService.SendEmail(p.EmailAddress, subject, body)
+		err = s.emailService.SendEmail(tx, p.EmailAddress, subject, body, htmlBody)
 		if err != nil {
 			return nil, err
 		}
@@ -164,10 +258,34 @@ func (s *passcode) SendPasscode(tx *pop.Connection, p SendPasscodeParams) (*Send
 		PasscodeModel: passcodeModel,
 		Subject:       subject,
 		Body:          body,
+		HTMLBody:      htmlBody,
 		Code:          code,
+		Link:          link,
 	}, nil
 }
 
+func (s *passcode) sendsLink(p SendPasscodeParams) bool {
+	return s.cfg.Email.PasscodeMode == config.PasscodeModeLink &&
+		!p.FlowID.IsNil() &&
+		slices.Contains(passcodeLinkTemplates, p.Template)
+}
+
+// createLink returns a link to the passcode link endpoint containing a token which is bound to the passcode and the
+// flow it has been requested in.
+func (s *passcode) createLink(passcodeModel models.Passcode, p SendPasscodeParams, ttl time.Duration) (string, error) {
+	claims := map[string]interface{}{"flow_id": p.FlowID.String()}
+	if p.BrowserBinding != "" {
+		claims["browser"] = p.BrowserBinding
+	}
+
+	token, err := s.linkTokenSigner.Sign(link_token.PurposePasscodeLink, passcodeModel.ID.String(), ttl, claims)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/passcode/link?token=%s", strings.TrimSuffix(s.cfg.Service.ApiURL, "/"), url.QueryEscape(token)), nil
+}
+
 func (s *passcode) getPasscode(tx *pop.Connection, passcodeID uuid.UUID) (*models.Passcode, error) {
 	passcodePersister := s.persister.GetPasscodePersisterWithConnection(tx)
 
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/crypto/link_token"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/test"
+	"testing"
+	"time"
+)
+
+func newPasscodeLinkTestService(t *testing.T, passcodes ...models.Passcode) (Passcode, persistence.Persister) {
+	persister := test.NewPersister(nil, passcodes, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	signer, err := link_token.NewSigner(test.DefaultConfig.Secrets.Keys)
+	require.NoError(t, err)
+
+	return NewPasscodeService(config.Config{}, Email{}, persister, signer), persister
+}
+
+func newPasscodeLinkTestPasscode(flowID uuid.UUID, createdAt time.Time) models.Passcode {
+	return models.Passcode{
+		ID:        uuid.Must(uuid.NewV4()),
+		FlowID:    &flowID,
+		Ttl:       300,
+		Code:      "hashed",
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+}
+
+func TestPasscode_ConfirmPasscodeLink(t *testing.T) {
+	flowID := uuid.Must(uuid.NewV4())
+	passcode := newPasscodeLinkTestPasscode(flowID, time.Now().UTC())
+	service, persister := newPasscodeLinkTestService(t, passcode)
+
+	require.NoError(t, service.ConfirmPasscodeLink(nil, passcode.ID, flowID))
+
+	confirmed, err := persister.GetPasscodePersister().Get(passcode.ID)
+	require.NoError(t, err)
+	require.NotNil(t, confirmed.LinkConfirmedAt)
+	confirmedAt := *confirmed.LinkConfirmedAt
+
+	// confirming the link again keeps the passcode confirmed
+	require.NoError(t, service.ConfirmPasscodeLink(nil, passcode.ID, flowID))
+	confirmed, err = persister.GetPasscodePersister().Get(passcode.ID)
+	require.NoError(t, err)
+	assert.Equal(t, confirmedAt, *confirmed.LinkConfirmedAt)
+}
+
+func TestPasscode_ConfirmPasscodeLink_Invalid(t *testing.T) {
+	flowID := uuid.Must(uuid.NewV4())
+	passcode := newPasscodeLinkTestPasscode(flowID, time.Now().UTC())
+	expired := newPasscodeLinkTestPasscode(flowID, time.Now().UTC().Add(-10*time.Minute))
+	service, persister := newPasscodeLinkTestService(t, passcode, expired)
+
+	err := service.ConfirmPasscodeLink(nil, passcode.ID, uuid.Must(uuid.NewV4()))
+	assert.ErrorIs(t, err, ErrorPasscodeInvalid, "the passcode has been requested in another flow")
+
+	err = service.ConfirmPasscodeLink(nil, expired.ID, flowID)
+	assert.ErrorIs(t, err, ErrorPasscodeExpired)
+
+	err = service.ConfirmPasscodeLink(nil, uuid.Must(uuid.NewV4()), flowID)
+	assert.ErrorIs(t, err, ErrorPasscodeNotFound)
+
+	unconfirmed, err := persister.GetPasscodePersister().Get(passcode.ID)
+	require.NoError(t, err)
+	assert.Nil(t, unconfirmed.LinkConfirmedAt)
+}
+
+func TestPasscode_VerifyPasscodeLink(t *testing.T) {
+	flowID := uuid.Must(uuid.NewV4())
+	passcode := newPasscodeLinkTestPasscode(flowID, time.Now().UTC())
+	service, persister := newPasscodeLinkTestService(t, passcode)
+
+	err := service.VerifyPasscodeLink(nil, passcode.ID, flowID)
+	assert.ErrorIs(t, err, ErrorPasscodeLinkNotConfirmed)
+
+	require.NoError(t, service.ConfirmPasscodeLink(nil, passcode.ID, flowID))
+
+	err = service.VerifyPasscodeLink(nil, passcode.ID, uuid.Must(uuid.NewV4()))
+	assert.ErrorIs(t, err, ErrorPasscodeInvalid, "the passcode has been requested in another flow")
+
+	require.NoError(t, service.VerifyPasscodeLink(nil, passcode.ID, flowID))
+
+	deleted, err := persister.GetPasscodePersister().Get(passcode.ID)
+	require.NoError(t, err)
+	assert.Nil(t, deleted)
+
+	// the passcode can only be used once
+	err = service.VerifyPasscodeLink(nil, passcode.ID, flowID)
+	assert.ErrorIs(t, err, ErrorPasscodeNotFound)
+}
+
+func TestPasscode_VerifyPasscodeLink_Expired(t *testing.T) {
+	flowID := uuid.Must(uuid.NewV4())
+	confirmedAt := time.Now().UTC().Add(-9 * time.Minute)
+	expired := newPasscodeLinkTestPasscode(flowID, time.Now().UTC().Add(-10*time.Minute))
+	expired.LinkConfirmedAt = &confirmedAt
+	service, _ := newPasscodeLinkTestService(t, expired)
+
+	err := service.VerifyPasscodeLink(nil, expired.ID, flowID)
+	assert.ErrorIs(t, err, ErrorPasscodeExpired)
+}
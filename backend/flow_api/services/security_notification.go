@@ -0,0 +1,70 @@
+package services
+
+import (
+	"github.com/gobuffalo/pop/v6"
+	"github.com/teamhanko/hanko/backend/config"
+)
+
+type SendSecurityNotificationParams struct {
+	Template     string
+	EmailAddress string
+	Language     string
+	Data         map[string]interface{}
+}
+
+type SendSecurityNotificationResult struct {
+	Subject  string
+	Body     string
+	HTMLBody string
+}
+
+type SecurityNotification interface {
+	SendNotification(tx *pop.Connection, p SendSecurityNotificationParams) (*SendSecurityNotificationResult, error)
+}
+
+type securityNotification struct {
+	emailService Email
+	cfg          config.Config
+}
+
+func NewSecurityNotificationService(cfg config.Config, emailService Email) SecurityNotification {
+	return &securityNotification{
+		emailService,
+		cfg,
+	}
+}
+
+// SendNotification renders the given template and sends it to the given email address, provided that email delivery
+// is enabled. Queued emails are stored using the given connection. The rendered subject and body are returned in any
+// case, so they can be passed on to webhooks.
+func (s *securityNotification) SendNotification(tx *pop.Connection, p SendSecurityNotificationParams) (*SendSecurityNotificationResult, error) {
+	data := map[string]interface{}{
+		"ServiceName": s.cfg.Service.Name,
+	}
+	for key, value := range p.Data {
+		data[key] = value
+	}
+
+	subject := s.emailService.RenderSubject(p.Language, p.Template, data)
+	body, err := s.emailService.RenderBody(p.Language, p.Template, data)
+	if err != nil {
+		return nil, err
+	}
+	htmlBody, err := s.emailService.RenderHTMLBody(p.Language, p.Template, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.cfg.EmailDelivery.Enabled {
+		err = s.emailService.SendEmail(tx, p.EmailAddress, subject, body, htmlBody)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &SendSecurityNotificationResult{
+		Subject:  subject,
+		Body:     body,
+		HTMLBody: htmlBody,
+	}, nil
+}
@@ -168,9 +168,18 @@ func createAndInitializeFlow(db FlowDB, flow defaultFlow) (FlowResult, error) {
 }
 
 // executeFlowAction processes the flow and returns a Response.
-func executeFlowAction(db FlowDB, flow defaultFlow) (FlowResult, error) {
+func executeFlowAction(db FlowDB, flow defaultFlow) (result FlowResult, err error) {
 	actionName := flow.queryParam.getActionName()
 
+	event := ActionEvent{
+		FlowName:   flow.name,
+		FlowID:     flow.queryParam.getFlowID(),
+		ActionName: actionName,
+	}
+	defer func() {
+		flow.observeAction(event, result, err)
+	}()
+
 	// Retrieve the flow model from the database using the flow ID.
 	flowModel, err := db.GetFlow(flow.queryParam.getFlowID())
 	if err != nil {
@@ -193,6 +202,8 @@ func executeFlowAction(db FlowDB, flow defaultFlow) (FlowResult, error) {
 
 	s.useCompression(flow.useCompression)
 
+	event.StateName = s.getStateName()
+
 	// Initialize JSONManagers for payload and flash data.
 	p := newPayload()
 
@@ -247,15 +258,22 @@ func executeFlowAction(db FlowDB, flow defaultFlow) (FlowResult, error) {
 		return newFlowResultFromError(flow.errorStateName, ErrorOperationNotPermitted, flow.debug), nil
 	}
 
+	end := flow.tracer.StartSpan(SpanAction, map[string]string{
+		TraceAttributeState:  string(state.getName()),
+		TraceAttributeAction: string(actionName),
+	})
+
 	ad.getAction().Initialize(aic)
 
 	// Check if the action is suspended.
 	if aic.isSuspended {
+		end(nil)
 		return newFlowResultFromError(flow.errorStateName, ErrorOperationNotPermitted, flow.debug), nil
 	}
 
 	// Execute the action and handle any errors.
 	err = ad.getAction().Execute(aec)
+	end(err)
 	if err != nil {
 		return nil, fmt.Errorf("the action failed to handle the request: %w", err)
 	}
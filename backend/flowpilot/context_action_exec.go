@@ -76,7 +76,7 @@ func (aec *defaultActionExecutionContext) closeExecutionContext() error {
 func (aec *defaultActionExecutionContext) executeBeforeStateHooks(nextStateName StateName) error {
 	if actions := aec.flow.beforeStateHooks[nextStateName]; actions != nil {
 		for _, hook := range actions.reverse() {
-			if err := hook.Execute(aec); err != nil {
+			if err := aec.executeHook(hook, hookTypeBeforeState, nextStateName); err != nil {
 				return fmt.Errorf("failed to execute before state hook (state: %s): %w", nextStateName, err)
 			}
 		}
@@ -86,7 +86,7 @@ func (aec *defaultActionExecutionContext) executeBeforeStateHooks(nextStateName
 
 func (aec *defaultActionExecutionContext) executeBeforeEachActionHooks() error {
 	for _, hook := range aec.flow.beforeEachActionHooks {
-		err := hook.Execute(aec)
+		err := aec.executeHook(hook, hookTypeBeforeEachAction, aec.stash.getStateName())
 		if err != nil {
 			return fmt.Errorf("failed to execute before each action (action: %s): %w", aec.actionName, err)
 		}
@@ -107,7 +107,7 @@ func (aec *defaultActionExecutionContext) executeAfterHooks() error {
 
 	if len(nextFlowName) == 0 || currentFlowName != nextFlowName {
 		for _, hook := range aec.flow.afterFlowHooks[currentFlowName].reverse() {
-			if err := hook.Execute(aec); err != nil {
+			if err := aec.executeHook(hook, hookTypeAfterFlow, currentStateName); err != nil {
 				return fmt.Errorf("failed to execute hook after flow hook (flow: %s): %w", currentFlowName, err)
 			}
 		}
@@ -115,14 +115,14 @@ func (aec *defaultActionExecutionContext) executeAfterHooks() error {
 
 	if actions := aec.flow.afterStateHooks[currentStateName]; actions != nil {
 		for _, hook := range actions.reverse() {
-			if err := hook.Execute(aec); err != nil {
+			if err := aec.executeHook(hook, hookTypeAfterState, currentStateName); err != nil {
 				return fmt.Errorf("failed to execute after state hook (state: %s): %w", currentStateName, err)
 			}
 		}
 	}
 
 	for _, hook := range aec.flow.afterEachActionHooks {
-		err := hook.Execute(aec)
+		err := aec.executeHook(hook, hookTypeAfterEachAction, currentStateName)
 		if err != nil {
 			return fmt.Errorf("failed to execute after each action hook (action: %s): %w", aec.actionName, err)
 		}
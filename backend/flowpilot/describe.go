@@ -0,0 +1,171 @@
+package flowpilot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Transition lists the states an action passes to ExecutionContext.Continue, or a hook passes to
+// HookExecutionContext.ScheduleStates, in the order they are passed.
+type Transition []StateName
+
+// TransitionDescriber can be implemented by actions and hooks to declare the transitions they may cause. The
+// declarations are not used when a flow is executed, they only complete the description returned by Flow.Describe.
+type TransitionDescriber interface {
+	GetTransitions() []Transition
+}
+
+// FlowDescription describes the structure of a flow or a sub-flow.
+type FlowDescription struct {
+	Name                  FlowName
+	InitialStates         []StateName // Only set for the root flow.
+	ErrorState            StateName   // Only set for the root flow.
+	States                []StateDescription
+	SubFlows              []FlowDescription
+	BeforeEachActionHooks []HookDescription
+	AfterEachActionHooks  []HookDescription
+	AfterFlowHooks        []HookDescription
+}
+
+// StateDescription describes a state, its actions and the hooks running before and after the state.
+type StateDescription struct {
+	Name        StateName
+	Actions     []ActionDescription
+	BeforeHooks []HookDescription
+	AfterHooks  []HookDescription
+}
+
+// ActionDescription describes an action and the transitions it declares.
+type ActionDescription struct {
+	Name        ActionName
+	Description string
+	Transitions []Transition
+}
+
+// HookDescription describes a hook, named by its type, and the transitions it declares.
+type HookDescription struct {
+	Name        string
+	Transitions []Transition
+}
+
+// Describe returns the description of the flow and its sub-flows. States are sorted by name. A state belonging to
+// several flows is described within the flow it is resolved from when the flow is executed. Declared transitions to
+// states not belonging to the flow are omitted, as they can never be taken.
+func (f *defaultFlow) Describe() FlowDescription {
+	d := describer{flow: f, seen: make(map[StateName]bool)}
+
+	description := d.describeFlow(f)
+	description.InitialStates = d.knownStates(f.initialStateNames)
+	description.ErrorState = f.errorStateName
+	description.BeforeEachActionHooks = d.describeHooks(f.beforeEachActionHooks)
+	description.AfterEachActionHooks = d.describeHooks(f.afterEachActionHooks)
+
+	return description
+}
+
+type describer struct {
+	flow *defaultFlow
+	seen map[StateName]bool
+}
+
+func (d *describer) describeFlow(flow flowBase) FlowDescription {
+	stateNames := make([]StateName, 0, len(flow.getFlow()))
+	for stateName := range flow.getFlow() {
+		if !d.seen[stateName] {
+			d.seen[stateName] = true
+			stateNames = append(stateNames, stateName)
+		}
+	}
+
+	sort.Slice(stateNames, func(i, j int) bool {
+		return stateNames[i] < stateNames[j]
+	})
+
+	description := FlowDescription{
+		Name:           flow.getName(),
+		States:         make([]StateDescription, len(stateNames)),
+		AfterFlowHooks: d.describeHooks(d.flow.afterFlowHooks[flow.getName()]),
+	}
+
+	for i, stateName := range stateNames {
+		description.States[i] = d.describeState(stateName, flow.getFlow()[stateName])
+	}
+
+	for _, sf := range flow.getSubFlows() {
+		description.SubFlows = append(description.SubFlows, d.describeFlow(sf))
+	}
+
+	return description
+}
+
+func (d *describer) describeState(stateName StateName, actions Actions) StateDescription {
+	description := StateDescription{
+		Name:        stateName,
+		Actions:     make([]ActionDescription, len(actions)),
+		BeforeHooks: d.describeHooks(d.flow.beforeStateHooks[stateName]),
+		AfterHooks:  d.describeHooks(d.flow.afterStateHooks[stateName]),
+	}
+
+	for i, action := range actions {
+		description.Actions[i] = ActionDescription{
+			Name:        action.GetName(),
+			Description: action.GetDescription(),
+			Transitions: d.describeTransitions(action),
+		}
+	}
+
+	return description
+}
+
+func (d *describer) describeHooks(hooks hookActions) []HookDescription {
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	descriptions := make([]HookDescription, len(hooks))
+
+	for i, hook := range hooks {
+		descriptions[i] = HookDescription{
+			Name:        hookName(hook),
+			Transitions: d.describeTransitions(hook),
+		}
+	}
+
+	return descriptions
+}
+
+func (d *describer) describeTransitions(v interface{}) []Transition {
+	td, ok := v.(TransitionDescriber)
+	if !ok {
+		return nil
+	}
+
+	var transitions []Transition
+
+	for _, transition := range td.GetTransitions() {
+		if states := d.knownStates(transition); len(states) > 0 {
+			transitions = append(transitions, states)
+		}
+	}
+
+	return transitions
+}
+
+// knownStates returns the given states without the ones not belonging to the flow.
+func (d *describer) knownStates(stateNames []StateName) Transition {
+	result := make(Transition, 0, len(stateNames))
+
+	for _, stateName := range stateNames {
+		if _, ok := d.flow.stateDetails[stateName]; ok {
+			result = append(result, stateName)
+		}
+	}
+
+	return result
+}
+
+// hookName returns the name of the type implementing the hook, e.g. "shared.IssueSession".
+func hookName(hook HookAction) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", hook), "*")
+}
@@ -1,6 +1,7 @@
 package flowpilot
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"time"
@@ -162,6 +163,8 @@ type Flow interface {
 	ResultFromError(err error) FlowResult
 	// Set sets a value with the given key in the flow context.
 	Set(string, interface{})
+	// Describe returns the description of the states, actions, hooks and sub-flows of the flow.
+	Describe() FlowDescription
 	// setDefaults sets the default values for the flow.
 	setDefaults()
 	// getState retrieves the details of a specific state in the flow.
@@ -201,6 +204,8 @@ type defaultFlow struct {
 	useCompression    bool
 	queryParamKey     string
 	queryParamValue   string
+	actionObserver    ActionObserver
+	tracer            Tracer
 
 	*defaultFlowBase
 }
@@ -254,6 +259,10 @@ func (f *defaultFlow) setDefaults() {
 	if len(f.queryParamKey) == 0 {
 		f.queryParamKey = "flowpilot_action"
 	}
+
+	if f.tracer == nil {
+		f.tracer = noopTracer{}
+	}
 }
 
 // Execute handles the execution of actions for a defaultFlow.
@@ -265,9 +274,14 @@ func (f *defaultFlow) Execute(db FlowDB, opts ...func(flow *defaultFlow)) (FlowR
 	// Set default values for flow settings.
 	f.setDefaults()
 
+	db = &tracedFlowDB{FlowDB: db, tracer: f.tracer}
+
 	// If the action is empty, create a new flow.
 	if len(f.queryParamValue) == 0 {
-		return createAndInitializeFlow(db, *f)
+		end := f.tracer.StartSpan(SpanExecute, map[string]string{TraceAttributeFlowName: string(f.name)})
+		result, err := createAndInitializeFlow(db, *f)
+		end(err)
+		return result, err
 	}
 
 	// Otherwise, update an existing flow...
@@ -278,14 +292,22 @@ func (f *defaultFlow) Execute(db FlowDB, opts ...func(flow *defaultFlow)) (FlowR
 
 	f.queryParam = q
 
-	return executeFlowAction(db, *f)
+	end := f.tracer.StartSpan(SpanExecute, map[string]string{
+		TraceAttributeFlowName: string(f.name),
+		TraceAttributeAction:   string(q.getActionName()),
+	})
+	result, err := executeFlowAction(db, *f)
+	end(err)
+	return result, err
 }
 
 // ResultFromError returns an error response for the defaultFlow.
 func (f *defaultFlow) ResultFromError(err error) FlowResult {
 	flowError := ErrorTechnical
 
-	if e, ok := err.(FlowError); ok {
+	// Errors returned from hooks are wrapped, so the FlowError is searched in the error chain.
+	var e FlowError
+	if errors.As(err, &e) {
 		flowError = e
 	} else {
 		flowError = flowError.Wrap(err)
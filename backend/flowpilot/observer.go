@@ -0,0 +1,73 @@
+package flowpilot
+
+import (
+	"errors"
+	"github.com/gofrs/uuid"
+)
+
+// ActionOutcome represents the outcome of an action execution.
+type ActionOutcome string
+
+const (
+	ActionOutcomeSucceeded ActionOutcome = "succeeded"
+	ActionOutcomeFailed    ActionOutcome = "failed"
+)
+
+// ActionEvent describes the execution of an action.
+type ActionEvent struct {
+	FlowName FlowName
+	FlowID   uuid.UUID
+	// StateName is the state the action has been executed in. It is empty if the flow could not be loaded.
+	StateName StateName
+	// NextStateName is the state of the flow after the action has been executed.
+	NextStateName StateName
+	ActionName    ActionName
+	Outcome       ActionOutcome
+	// ErrorCode is the code of the flow error, if the action failed.
+	ErrorCode string
+	// Error is the cause of the failure, if known.
+	Error error
+}
+
+// ActionObserver is called once for every executed action, regardless of its outcome.
+type ActionObserver func(ActionEvent)
+
+// WithActionObserver sets the ActionObserver for flowExecutionOptions.
+func WithActionObserver(observer ActionObserver) func(*defaultFlow) {
+	return func(f *defaultFlow) {
+		f.actionObserver = observer
+	}
+}
+
+// observeAction completes the event with the outcome of the action execution and passes it to the ActionObserver.
+func (f *defaultFlow) observeAction(event ActionEvent, result FlowResult, err error) {
+	if f.actionObserver == nil {
+		return
+	}
+
+	event.Outcome = ActionOutcomeSucceeded
+
+	if err != nil {
+		event.Outcome = ActionOutcomeFailed
+		event.ErrorCode = ErrorTechnical.Code()
+		event.Error = err
+
+		var flowError FlowError
+		if errors.As(err, &flowError) {
+			event.ErrorCode = flowError.Code()
+		}
+	} else if result != nil {
+		response := result.GetResponse()
+		event.NextStateName = response.Name
+
+		if response.Error != nil {
+			event.Outcome = ActionOutcomeFailed
+			event.ErrorCode = response.Error.Code
+			if response.Error.Internal != nil {
+				event.Error = errors.New(*response.Error.Internal)
+			}
+		}
+	}
+
+	f.actionObserver(event)
+}
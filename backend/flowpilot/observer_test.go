@@ -0,0 +1,89 @@
+package flowpilot
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestDefaultFlow_ObserveAction(t *testing.T) {
+	cause := errors.New("cause")
+
+	tests := []struct {
+		name          string
+		result        FlowResult
+		err           error
+		expectedEvent ActionEvent
+	}{
+		{
+			name:   "success",
+			result: newFlowResultFromResponse(Response{Name: "success"}),
+			expectedEvent: ActionEvent{
+				NextStateName: "success",
+				Outcome:       ActionOutcomeSucceeded,
+			},
+		},
+		{
+			name:   "flow error",
+			result: newFlowResultFromError("init", ErrorFormDataInvalid, false),
+			expectedEvent: ActionEvent{
+				NextStateName: "init",
+				Outcome:       ActionOutcomeFailed,
+				ErrorCode:     ErrorFormDataInvalid.Code(),
+			},
+		},
+		{
+			name:   "flow error with cause",
+			result: newFlowResultFromError("init", ErrorFormDataInvalid.Wrap(cause), false),
+			expectedEvent: ActionEvent{
+				NextStateName: "init",
+				Outcome:       ActionOutcomeFailed,
+				ErrorCode:     ErrorFormDataInvalid.Code(),
+				Error:         cause,
+			},
+		},
+		{
+			name: "technical error",
+			err:  cause,
+			expectedEvent: ActionEvent{
+				Outcome:   ActionOutcomeFailed,
+				ErrorCode: ErrorTechnical.Code(),
+				Error:     cause,
+			},
+		},
+		{
+			name: "technical flow error",
+			err:  ErrorFlowDiscontinuity.Wrap(cause),
+			expectedEvent: ActionEvent{
+				Outcome:   ActionOutcomeFailed,
+				ErrorCode: ErrorFlowDiscontinuity.Code(),
+				Error:     ErrorFlowDiscontinuity.Wrap(cause),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var observed *ActionEvent
+			flow := &defaultFlow{actionObserver: func(event ActionEvent) {
+				observed = &event
+			}}
+
+			flow.observeAction(ActionEvent{FlowName: "login", StateName: "init", ActionName: "action"}, tt.result, tt.err)
+
+			require.NotNil(t, observed)
+			assert.Equal(t, FlowName("login"), observed.FlowName)
+			assert.Equal(t, StateName("init"), observed.StateName)
+			assert.Equal(t, ActionName("action"), observed.ActionName)
+			assert.Equal(t, tt.expectedEvent.NextStateName, observed.NextStateName)
+			assert.Equal(t, tt.expectedEvent.Outcome, observed.Outcome)
+			assert.Equal(t, tt.expectedEvent.ErrorCode, observed.ErrorCode)
+			if tt.expectedEvent.Error == nil {
+				assert.NoError(t, observed.Error)
+			} else {
+				assert.EqualError(t, observed.Error, tt.expectedEvent.Error.Error())
+			}
+		})
+	}
+}
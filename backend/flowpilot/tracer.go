@@ -0,0 +1,95 @@
+package flowpilot
+
+import (
+	"github.com/gofrs/uuid"
+)
+
+// Span names of the spans started by the flow.
+const (
+	SpanExecute      = "flowpilot.execute"
+	SpanAction       = "flowpilot.action"
+	SpanHook         = "flowpilot.hook"
+	SpanDBGetFlow    = "flowpilot.db.get_flow"
+	SpanDBCreateFlow = "flowpilot.db.create_flow"
+	SpanDBUpdateFlow = "flowpilot.db.update_flow"
+)
+
+// Attributes of the spans started by the flow.
+const (
+	TraceAttributeFlowName = "flow.name"
+	TraceAttributeFlowID   = "flow.id"
+	TraceAttributeState    = "flow.state"
+	TraceAttributeAction   = "flow.action"
+	TraceAttributeHook     = "flow.hook"
+	TraceAttributeHookType = "flow.hook_type"
+)
+
+// Types of hooks, used as value of the TraceAttributeHookType attribute.
+const (
+	hookTypeBeforeState      = "before_state"
+	hookTypeAfterState       = "after_state"
+	hookTypeAfterFlow        = "after_flow"
+	hookTypeBeforeEachAction = "before_each_action"
+	hookTypeAfterEachAction  = "after_each_action"
+)
+
+// SpanEnd ends a span. The error is recorded with the span, if not nil.
+type SpanEnd func(err error)
+
+// Tracer starts spans around the execution of the flow, its actions and hooks and the calls to the FlowDB. Spans are
+// started and ended in order, so a span started while another span has not been ended yet is a child of that span.
+type Tracer interface {
+	StartSpan(name string, attributes map[string]string) SpanEnd
+}
+
+type noopTracer struct{}
+
+func (noopTracer) StartSpan(string, map[string]string) SpanEnd {
+	return func(error) {}
+}
+
+// WithTracer sets the Tracer for flowExecutionOptions.
+func WithTracer(tracer Tracer) func(*defaultFlow) {
+	return func(f *defaultFlow) {
+		f.tracer = tracer
+	}
+}
+
+// tracedFlowDB starts a span for every call to the wrapped FlowDB.
+type tracedFlowDB struct {
+	FlowDB
+	tracer Tracer
+}
+
+func (db *tracedFlowDB) GetFlow(flowID uuid.UUID) (*FlowModel, error) {
+	end := db.tracer.StartSpan(SpanDBGetFlow, map[string]string{TraceAttributeFlowID: flowID.String()})
+	flowModel, err := db.FlowDB.GetFlow(flowID)
+	end(err)
+	return flowModel, err
+}
+
+func (db *tracedFlowDB) CreateFlow(flowModel FlowModel) error {
+	end := db.tracer.StartSpan(SpanDBCreateFlow, map[string]string{TraceAttributeFlowID: flowModel.ID.String()})
+	err := db.FlowDB.CreateFlow(flowModel)
+	end(err)
+	return err
+}
+
+func (db *tracedFlowDB) UpdateFlow(flowModel FlowModel) error {
+	end := db.tracer.StartSpan(SpanDBUpdateFlow, map[string]string{TraceAttributeFlowID: flowModel.ID.String()})
+	err := db.FlowDB.UpdateFlow(flowModel)
+	end(err)
+	return err
+}
+
+// executeHook executes the hook within a span. The state is the state the hook is executed for.
+func (aec *defaultActionExecutionContext) executeHook(hook HookAction, hookType string, stateName StateName) error {
+	end := aec.flow.tracer.StartSpan(SpanHook, map[string]string{
+		TraceAttributeState:    string(stateName),
+		TraceAttributeHook:     hookName(hook),
+		TraceAttributeHookType: hookType,
+	})
+	err := hook.Execute(aec)
+	end(err)
+	return err
+}
@@ -0,0 +1,201 @@
+package flowpilot
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Validate checks the states of a root flow description for states that cannot be reached from the initial state and
+// for dead ends, i.e. reachable states with actions from which no final state can be reached. A final state is a
+// state without actions, other than the error state.
+//
+// The check is based on the declared transitions of actions and hooks. A state continues with the states scheduled
+// after it, or, if it was the last state of a transition, with the states scheduled after the state it was entered
+// from. Additionally, a state can continue with the transitions declared by the after-flow hooks of its flow.
+func (d FlowDescription) Validate() error {
+	g := newTransitionGraph()
+	g.addFlow(d, d.BeforeEachActionHooks, d.AfterEachActionHooks)
+
+	if len(d.InitialStates) == 0 {
+		return errors.New("initial state does not belong to the flow")
+	}
+
+	g.addTransition("", d.InitialStates)
+	g.resolveScheduled()
+
+	reachable := g.reachable(d.InitialStates[0])
+
+	var unreachable []string
+	for stateName := range g.states {
+		if !reachable[stateName] && stateName != d.ErrorState {
+			unreachable = append(unreachable, string(stateName))
+		}
+	}
+
+	if len(unreachable) > 0 {
+		sort.Strings(unreachable)
+		return fmt.Errorf("states not reachable from the initial state: %s", strings.Join(unreachable, ", "))
+	}
+
+	finishing := make(stateSet)
+	for stateName, state := range g.states {
+		if len(state.Actions) == 0 && stateName != d.ErrorState {
+			finishing[stateName] = true
+		}
+	}
+
+	// iterate until no further state is found that can reach a final state
+	for changed := true; changed; {
+		changed = false
+		for stateName := range g.states {
+			if finishing[stateName] {
+				continue
+			}
+			for next := range g.successors(stateName) {
+				if finishing[next] {
+					finishing[stateName] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	var deadEnds []string
+	for stateName := range reachable {
+		if !finishing[stateName] && stateName != d.ErrorState {
+			deadEnds = append(deadEnds, string(stateName))
+		}
+	}
+
+	if len(deadEnds) > 0 {
+		sort.Strings(deadEnds)
+		return fmt.Errorf("no final state can be reached from states: %s", strings.Join(deadEnds, ", "))
+	}
+
+	return nil
+}
+
+type stateSet map[StateName]bool
+
+// transitionGraph contains the edges between states derived from the declared transitions.
+type transitionGraph struct {
+	states map[StateName]StateDescription
+	// next contains the states a state continues with directly, i.e. the first states of its transitions.
+	next map[StateName]stateSet
+	// scheduled contains the states a state continues with when no state is passed to ExecutionContext.Continue.
+	scheduled map[StateName]stateSet
+	// enteredFrom contains the states a state was entered from as the last state of a transition.
+	enteredFrom map[StateName]stateSet
+}
+
+func newTransitionGraph() *transitionGraph {
+	return &transitionGraph{
+		states:      make(map[StateName]StateDescription),
+		next:        make(map[StateName]stateSet),
+		scheduled:   make(map[StateName]stateSet),
+		enteredFrom: make(map[StateName]stateSet),
+	}
+}
+
+func (g *transitionGraph) addFlow(d FlowDescription, beforeEachAction, afterEachAction []HookDescription) {
+	for _, state := range d.States {
+		g.states[state.Name] = state
+		g.addHooks(state.Name, beforeEachAction, afterEachAction, state.BeforeHooks, state.AfterHooks, d.AfterFlowHooks)
+
+		for _, action := range state.Actions {
+			for _, transition := range action.Transitions {
+				g.addTransition(state.Name, transition)
+			}
+		}
+	}
+
+	for _, sf := range d.SubFlows {
+		g.addFlow(sf, beforeEachAction, afterEachAction)
+	}
+}
+
+func (g *transitionGraph) addHooks(stateName StateName, hooks ...[]HookDescription) {
+	for _, h := range hooks {
+		for _, hook := range h {
+			for _, transition := range hook.Transitions {
+				g.addTransition(stateName, transition)
+			}
+		}
+	}
+}
+
+func (g *transitionGraph) addTransition(from StateName, transition Transition) {
+	if len(transition) == 0 {
+		return
+	}
+
+	addToSet(g.next, from, transition[0])
+
+	for i := 0; i < len(transition)-1; i++ {
+		addToSet(g.scheduled, transition[i], transition[i+1])
+	}
+
+	addToSet(g.enteredFrom, transition[len(transition)-1], from)
+}
+
+// resolveScheduled adds the states scheduled after a state to the states it was entered from as last state of a
+// transition.
+func (g *transitionGraph) resolveScheduled() {
+	for changed := true; changed; {
+		changed = false
+		for stateName, froms := range g.enteredFrom {
+			for from := range froms {
+				for scheduled := range g.scheduled[from] {
+					if !g.scheduled[stateName][scheduled] {
+						addToSet(g.scheduled, stateName, scheduled)
+						changed = true
+					}
+				}
+			}
+		}
+	}
+}
+
+func (g *transitionGraph) successors(stateName StateName) stateSet {
+	result := make(stateSet)
+
+	for next := range g.next[stateName] {
+		result[next] = true
+	}
+
+	for scheduled := range g.scheduled[stateName] {
+		result[scheduled] = true
+	}
+
+	return result
+}
+
+func (g *transitionGraph) reachable(initialState StateName) stateSet {
+	result := stateSet{initialState: true}
+	queue := []StateName{initialState}
+
+	for len(queue) > 0 {
+		stateName := queue[0]
+		queue = queue[1:]
+
+		for next := range g.successors(stateName) {
+			if !result[next] {
+				result[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return result
+}
+
+func addToSet(m map[StateName]stateSet, key StateName, value StateName) {
+	if m[key] == nil {
+		m[key] = make(stateSet)
+	}
+
+	m[key][value] = true
+}
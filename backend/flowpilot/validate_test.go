@@ -0,0 +1,123 @@
+package flowpilot
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func describedAction(transitions ...Transition) ActionDescription {
+	return ActionDescription{Name: "action", Transitions: transitions}
+}
+
+func TestFlowDescription_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		description FlowDescription
+		expectedErr string
+	}{
+		{
+			name: "valid",
+			description: FlowDescription{
+				InitialStates: []StateName{"init"},
+				ErrorState:    "error",
+				States: []StateDescription{
+					{Name: "init", Actions: []ActionDescription{describedAction(Transition{"success"}, Transition{"error"})}},
+					{Name: "success"},
+					{Name: "error"},
+				},
+			},
+		},
+		{
+			name: "scheduled states of a sub-flow",
+			description: FlowDescription{
+				InitialStates: []StateName{"init"},
+				ErrorState:    "error",
+				States: []StateDescription{
+					{Name: "init", Actions: []ActionDescription{describedAction(Transition{"sub_flow_state", "success"})}},
+					{Name: "success"},
+					{Name: "error"},
+				},
+				SubFlows: []FlowDescription{
+					{States: []StateDescription{
+						// continues with the state scheduled after it
+						{Name: "sub_flow_state", Actions: []ActionDescription{describedAction()}},
+					}},
+				},
+			},
+		},
+		{
+			name: "final state reached by an after-flow hook",
+			description: FlowDescription{
+				InitialStates: []StateName{"init"},
+				ErrorState:    "error",
+				States: []StateDescription{
+					{Name: "init", Actions: []ActionDescription{describedAction()}},
+					{Name: "success"},
+					{Name: "error"},
+				},
+				AfterFlowHooks: []HookDescription{{Name: "hook", Transitions: []Transition{{"success"}}}},
+			},
+		},
+		{
+			name: "no initial state",
+			description: FlowDescription{
+				States: []StateDescription{{Name: "init"}},
+			},
+			expectedErr: "initial state does not belong to the flow",
+		},
+		{
+			name: "unreachable states",
+			description: FlowDescription{
+				InitialStates: []StateName{"init"},
+				ErrorState:    "error",
+				States: []StateDescription{
+					{Name: "init", Actions: []ActionDescription{describedAction(Transition{"success"})}},
+					{Name: "success"},
+					{Name: "unreachable_b", Actions: []ActionDescription{describedAction(Transition{"success"})}},
+					{Name: "unreachable_a"},
+					// the error state is reached by errors, not by transitions
+					{Name: "error"},
+				},
+			},
+			expectedErr: "states not reachable from the initial state: unreachable_a, unreachable_b",
+		},
+		{
+			name: "dead end",
+			description: FlowDescription{
+				InitialStates: []StateName{"init"},
+				ErrorState:    "error",
+				States: []StateDescription{
+					{Name: "init", Actions: []ActionDescription{describedAction(Transition{"success"}, Transition{"loop"})}},
+					{Name: "loop", Actions: []ActionDescription{describedAction(Transition{"loop"})}},
+					{Name: "success"},
+					{Name: "error"},
+				},
+			},
+			expectedErr: "no final state can be reached from states: loop",
+		},
+		{
+			name: "error state is not a final state",
+			description: FlowDescription{
+				InitialStates: []StateName{"init"},
+				ErrorState:    "error",
+				States: []StateDescription{
+					{Name: "init", Actions: []ActionDescription{describedAction(Transition{"next"})}},
+					{Name: "next", Actions: []ActionDescription{describedAction(Transition{"error"})}},
+					{Name: "error"},
+				},
+			},
+			expectedErr: "no final state can be reached from states: init, next",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.description.Validate()
+			if tt.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expectedErr)
+			}
+		})
+	}
+}
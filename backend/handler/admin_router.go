@@ -44,7 +44,7 @@ func NewAdminRouter(cfg *config.Config, persister persistence.Persister, prometh
 	health.GET("/alive", healthHandler.Alive)
 	health.GET("/ready", healthHandler.Ready)
 
-	jwkManager, err := jwk.NewDefaultManager(cfg.Secrets.Keys, persister.GetJwkPersister())
+	jwkManager, err := jwk.NewDefaultManager(cfg.Secrets.Keys, persister.GetJwkPersister(), jwk.WithKeyAlgorithm(cfg.Secrets.Jwk.Algorithm), jwk.WithGracePeriod(cfg.Secrets.Jwk.GracePeriod), jwk.WithLock(persister.GetSchedulerLockPersister(nil)))
 	if err != nil {
 		panic(fmt.Errorf("failed to create jwk manager: %w", err))
 	}
@@ -71,14 +71,19 @@ func NewAdminRouter(cfg *config.Config, persister persistence.Persister, prometh
 
 	auditLogs := g.Group("/audit_logs")
 	auditLogs.GET("", auditLogHandler.List)
+	auditLogs.GET("/export", auditLogHandler.Export)
 
-	webhookHandler := NewWebhookHandler(cfg.Webhooks, persister)
+	webhookHandler := NewWebhookHandler(cfg, persister, jwkManager)
 	webhooks := g.Group("/webhooks")
 	webhooks.GET("", webhookHandler.List)
 	webhooks.POST("", webhookHandler.Create)
 	webhooks.GET("/:id", webhookHandler.Get)
 	webhooks.DELETE("/:id", webhookHandler.Delete)
 	webhooks.PUT("/:id", webhookHandler.Update)
+	webhooks.POST("/:id/rotate_secret", webhookHandler.RotateSecret)
+	webhooks.GET("/:id/deliveries", webhookHandler.ListDeliveries)
+	webhooks.POST("/:id/deliveries/:delivery_id/redeliver", webhookHandler.Redeliver)
+	webhooks.POST("/:id/test", webhookHandler.Test)
 
 	return e
 }
@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"compress/gzip"
 	"fmt"
 	"github.com/labstack/echo/v4"
+	auditlog "github.com/teamhanko/hanko/backend/audit_log"
 	"github.com/teamhanko/hanko/backend/dto"
 	"github.com/teamhanko/hanko/backend/pagination"
 	"github.com/teamhanko/hanko/backend/persistence"
@@ -34,6 +36,18 @@ type AuditLogListRequest struct {
 	SearchString string     `query:"q"`
 }
 
+type AuditLogExportRequest struct {
+	StartTime    *time.Time `query:"start_time"`
+	EndTime      *time.Time `query:"end_time"`
+	Types        []string   `query:"type"`
+	UserId       string     `query:"actor_user_id"`
+	Email        string     `query:"actor_email"`
+	IP           string     `query:"meta_source_ip"`
+	SearchString string     `query:"q"`
+	Format       string     `query:"format"`
+	Gzip         bool       `query:"gzip"`
+}
+
 func (h AuditLogHandler) List(c echo.Context) error {
 	var request AuditLogListRequest
 	err := (&echo.DefaultBinder{}).BindQueryParams(c, &request)
@@ -66,3 +80,90 @@ func (h AuditLogHandler) List(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, auditLogs)
 }
+
+// Export streams all audit logs matching the filters as NDJSON (default) or CSV, optionally gzip compressed. Errors
+// occurring after the response has been started cannot be reported with a status code, the export is truncated then.
+func (h AuditLogHandler) Export(c echo.Context) error {
+	var request AuditLogExportRequest
+	err := (&echo.DefaultBinder{}).BindQueryParams(c, &request)
+	if err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	format := auditlog.ExportFormatNDJSON
+	if request.Format != "" {
+		format = auditlog.ExportFormat(request.Format)
+	}
+	if !format.IsValid() {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("format must be one of '%s' or '%s'", auditlog.ExportFormatNDJSON, auditlog.ExportFormatCSV))
+	}
+
+	fileName := fmt.Sprintf("audit_logs.%s", format)
+	contentType := format.ContentType()
+	if request.Gzip {
+		fileName += ".gz"
+		contentType = "application/gzip"
+	}
+
+	response := c.Response()
+	response.Header().Set(echo.HeaderContentType, contentType)
+	response.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", fileName))
+	response.WriteHeader(http.StatusOK)
+
+	writer := &auditLogExportWriter{response: response}
+	if request.Gzip {
+		writer.gzip = gzip.NewWriter(response)
+	}
+
+	filter := auditlog.ExportFilter{
+		StartTime:    request.StartTime,
+		EndTime:      request.EndTime,
+		Types:        request.Types,
+		UserId:       request.UserId,
+		Email:        request.Email,
+		IP:           request.IP,
+		SearchString: request.SearchString,
+	}
+
+	_, err = auditlog.Export(writer, h.persister.GetAuditLogPersister(), format, filter, 0)
+	if err != nil {
+		c.Logger().Error(fmt.Errorf("failed to export audit logs: %w", err))
+		return nil
+	}
+
+	if writer.gzip != nil {
+		err = writer.gzip.Close()
+		if err != nil {
+			c.Logger().Error(fmt.Errorf("failed to export audit logs: %w", err))
+		}
+	}
+
+	return nil
+}
+
+// auditLogExportWriter writes the export to the response, optionally gzip compressed, and flushes the response after
+// every batch of audit logs.
+type auditLogExportWriter struct {
+	response *echo.Response
+	gzip     *gzip.Writer
+}
+
+func (w *auditLogExportWriter) Write(p []byte) (int, error) {
+	if w.gzip != nil {
+		return w.gzip.Write(p)
+	}
+
+	return w.response.Write(p)
+}
+
+func (w *auditLogExportWriter) Flush() error {
+	if w.gzip != nil {
+		err := w.gzip.Flush()
+		if err != nil {
+			return err
+		}
+	}
+
+	w.response.Flush()
+	return nil
+}
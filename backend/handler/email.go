@@ -10,6 +10,8 @@ import (
 	auditlog "github.com/teamhanko/hanko/backend/audit_log"
 	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/dto/webhook"
+	"github.com/teamhanko/hanko/backend/flow_api/services"
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/persistence/models"
 	"github.com/teamhanko/hanko/backend/session"
@@ -20,18 +22,20 @@ import (
 )
 
 type EmailHandler struct {
-	persister      persistence.Persister
-	cfg            *config.Config
-	sessionManager session.Manager
-	auditLogger    auditlog.Logger
+	persister                   persistence.Persister
+	cfg                         *config.Config
+	sessionManager              session.Manager
+	auditLogger                 auditlog.Logger
+	securityNotificationService services.SecurityNotification
 }
 
-func NewEmailHandler(cfg *config.Config, persister persistence.Persister, sessionManager session.Manager, auditLogger auditlog.Logger) *EmailHandler {
+func NewEmailHandler(cfg *config.Config, persister persistence.Persister, sessionManager session.Manager, auditLogger auditlog.Logger, securityNotificationService services.SecurityNotification) *EmailHandler {
 	return &EmailHandler{
-		persister:      persister,
-		cfg:            cfg,
-		sessionManager: sessionManager,
-		auditLogger:    auditLogger,
+		persister:                   persister,
+		cfg:                         cfg,
+		sessionManager:              sessionManager,
+		auditLogger:                 auditLogger,
+		securityNotificationService: securityNotificationService,
 	}
 }
 
@@ -189,8 +193,10 @@ func (h *EmailHandler) SetPrimaryEmail(c echo.Context) error {
 
 	return h.persister.Transaction(func(tx *pop.Connection) error {
 		var primaryEmail *models.PrimaryEmail
+		previousPrimaryEmailAddress := ""
 		if e := user.Emails.GetPrimary(); e != nil {
 			primaryEmail = e.PrimaryEmail
+			previousPrimaryEmailAddress = e.Address
 		}
 
 		if primaryEmail == nil {
@@ -212,6 +218,13 @@ func (h *EmailHandler) SetPrimaryEmail(c echo.Context) error {
 			return fmt.Errorf("failed to create audit log: %w", err)
 		}
 
+		if h.cfg.SecurityNotifications.PrimaryEmailChanged.Enabled {
+			sendSecurityNotification(c, tx, h.persister, *h.cfg, h.securityNotificationService, userId, webhook.EmailTypePrimaryEmailChanged, webhook.SecurityNotificationData{
+				EmailAddress:         email.Address,
+				PreviousEmailAddress: previousPrimaryEmailAddress,
+			}, previousPrimaryEmailAddress)
+		}
+
 		utils.NotifyUserChange(c, tx, h.persister, events.UserEmailPrimary, userId)
 
 		return c.NoContent(http.StatusNoContent)
@@ -256,8 +269,19 @@ func (h *EmailHandler) Delete(c echo.Context) error {
 			return fmt.Errorf("failed to create audit log: %w", err)
 		}
 
+		if h.cfg.SecurityNotifications.EmailDeleted.Enabled {
+			sendSecurityNotification(c, tx, h.persister, *h.cfg, h.securityNotificationService, userId, webhook.EmailTypeEmailDeleted, webhook.SecurityNotificationData{
+				EmailAddress: emailToBeDeleted.Address,
+			}, emailToBeDeleted.Address)
+		}
+
 		utils.NotifyUserChange(c, tx, h.persister, events.UserEmailDelete, userId)
 
+		// identities are deleted along with the email they are associated with
+		if len(emailToBeDeleted.Identities) > 0 {
+			utils.NotifyUserChange(c, tx, h.persister, events.UserIdentityUnlink, userId)
+		}
+
 		return c.NoContent(http.StatusNoContent)
 	})
 }
@@ -231,6 +231,11 @@ func (h *emailAdminHandler) Delete(ctx echo.Context) error {
 
 		utils.NotifyUserChange(ctx, tx, h.persister, events.UserEmailDelete, userId)
 
+		// identities are deleted along with the email they are associated with
+		if len(emailToBeDeleted.Identities) > 0 {
+			utils.NotifyUserChange(ctx, tx, h.persister, events.UserIdentityUnlink, userId)
+		}
+
 		return ctx.NoContent(http.StatusNoContent)
 	})
 }
@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/labstack/echo/v4"
+	auditlog "github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/crypto/link_token"
+	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/template"
+	"github.com/teamhanko/hanko/backend/webhooks/events"
+	webhookUtils "github.com/teamhanko/hanko/backend/webhooks/utils"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var (
+	errEmailVerificationNotPossible = errors.New("email address cannot be verified for the user")
+	errEmailVerificationLinkUsed    = errors.New("email verification link has already been used")
+)
+
+type EmailVerificationHandler struct {
+	persister       persistence.Persister
+	linkTokenSigner link_token.Signer
+	auditLogger     auditlog.Logger
+	cfg             config.Config
+}
+
+func NewEmailVerificationHandler(persister persistence.Persister, linkTokenSigner link_token.Signer, auditLogger auditlog.Logger, cfg config.Config) *EmailVerificationHandler {
+	return &EmailVerificationHandler{
+		persister:       persister,
+		linkTokenSigner: linkTokenSigner,
+		auditLogger:     auditLogger,
+		cfg:             cfg,
+	}
+}
+
+// VerifyEmailConfirmation renders the page the link sent by the profile flow leads to. The email address is only
+// verified when the user confirms it on the page, so opening the link (e.g. by a link scanner) does not use it up.
+func (h *EmailVerificationHandler) VerifyEmailConfirmation(c echo.Context) error {
+	token := c.QueryParam("token")
+	if _, err := h.linkTokenSigner.Verify(link_token.PurposeEmailVerification, token); err != nil {
+		return h.verifyEmailResponse(c, false, echo.NewHTTPError(http.StatusBadRequest, "invalid or expired token").SetInternal(err))
+	}
+
+	return c.Render(http.StatusOK, "confirm", template.ConfirmationPage{
+		Title:   "Verify email address",
+		Message: "Verify the email address and add it to your account?",
+		Token:   token,
+		Button:  "Verify email address",
+	})
+}
+
+// VerifyEmail verifies the email address of the link sent by the profile flow. The token is submitted by the page
+// rendered by VerifyEmailConfirmation and can only be used once. An email address that does not belong to the user yet
+// is added to the account of the user, provided that it has not been taken by another user in the meantime and the
+// email limit has not been reached.
+func (h *EmailVerificationHandler) VerifyEmail(c echo.Context) error {
+	token, err := h.linkTokenSigner.Verify(link_token.PurposeEmailVerification, c.FormValue("token"))
+	if err != nil {
+		return h.verifyEmailResponse(c, false, echo.NewHTTPError(http.StatusBadRequest, "invalid or expired token").SetInternal(err))
+	}
+
+	userID, err := uuid.FromString(token.Subject())
+	if err != nil {
+		return h.verifyEmailResponse(c, false, echo.NewHTTPError(http.StatusBadRequest, "invalid token").SetInternal(err))
+	}
+
+	tokenID, err := uuid.FromString(token.JwtID())
+	if err != nil {
+		return h.verifyEmailResponse(c, false, echo.NewHTTPError(http.StatusBadRequest, "invalid token").SetInternal(err))
+	}
+
+	emailClaim, _ := token.Get("email")
+	emailAddress, _ := emailClaim.(string)
+	if emailAddress == "" {
+		return h.verifyEmailResponse(c, false, echo.NewHTTPError(http.StatusBadRequest, "invalid token"))
+	}
+
+	err = h.persister.Transaction(func(tx *pop.Connection) error {
+		now := time.Now().UTC()
+		unused, err := h.persister.GetUsedLinkTokenPersister(tx).Use(models.UsedLinkToken{
+			ID:        tokenID,
+			Purpose:   string(link_token.PurposeEmailVerification),
+			ExpiresAt: token.Expiration().UTC(),
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+		if err != nil {
+			return err
+		}
+		if !unused {
+			return errEmailVerificationLinkUsed
+		}
+
+		userModel, err := h.persister.GetUserPersisterWithConnection(tx).Get(userID)
+		if err != nil {
+			return fmt.Errorf("failed to get user from database: %w", err)
+		}
+		if userModel == nil {
+			return errEmailVerificationNotPossible
+		}
+
+		emailPersister := h.persister.GetEmailPersisterWithConnection(tx)
+		emailModel, err := emailPersister.FindByAddress(emailAddress)
+		if err != nil {
+			return fmt.Errorf("failed to get email from database: %w", err)
+		}
+
+		if emailModel != nil {
+			if emailModel.UserID == nil || *emailModel.UserID != userID {
+				return errEmailVerificationNotPossible
+			}
+
+			// the address has been verified with another link in the meantime
+			if emailModel.Verified {
+				return nil
+			}
+
+			emailModel.Verified = true
+			err = emailPersister.Update(*emailModel)
+			if err != nil {
+				return fmt.Errorf("failed to update email: %w", err)
+			}
+
+			err = h.createAuditLog(tx, c, models.AuditLogEmailVerified, userID, emailAddress)
+			if err != nil {
+				return err
+			}
+
+			webhookUtils.NotifyUserChange(c, tx, h.persister, events.UserEmail, userID)
+
+			return nil
+		}
+
+		if len(userModel.Emails) >= h.cfg.Email.Limit {
+			return errEmailVerificationNotPossible
+		}
+
+		emailModel = models.NewEmail(&userID, emailAddress)
+		emailModel.Verified = true
+		err = emailPersister.Create(*emailModel)
+		if err != nil {
+			return fmt.Errorf("failed to create email: %w", err)
+		}
+
+		if len(userModel.Emails) == 0 {
+			// the user has no other email address, so it is used as primary email address
+			err = h.persister.GetPrimaryEmailPersisterWithConnection(tx).Create(*models.NewPrimaryEmail(emailModel.ID, userID))
+			if err != nil {
+				return fmt.Errorf("failed to create primary email: %w", err)
+			}
+		}
+
+		err = h.createAuditLog(tx, c, models.AuditLogEmailCreated, userID, emailAddress)
+		if err != nil {
+			return err
+		}
+
+		err = h.createAuditLog(tx, c, models.AuditLogEmailVerified, userID, emailAddress)
+		if err != nil {
+			return err
+		}
+
+		webhookUtils.NotifyUserChange(c, tx, h.persister, events.UserEmailCreate, userID)
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errEmailVerificationLinkUsed) {
+			return h.verifyEmailResponse(c, false, echo.NewHTTPError(http.StatusBadRequest, "link has already been used").SetInternal(err))
+		}
+
+		if errors.Is(err, errEmailVerificationNotPossible) {
+			return h.verifyEmailResponse(c, false, echo.NewHTTPError(http.StatusConflict, "email address cannot be verified").SetInternal(err))
+		}
+
+		return h.verifyEmailResponse(c, false, dto.ToHttpError(err))
+	}
+
+	return h.verifyEmailResponse(c, true, nil)
+}
+
+func (h *EmailVerificationHandler) createAuditLog(tx *pop.Connection, c echo.Context, auditLogType models.AuditLogType, userID uuid.UUID, emailAddress string) error {
+	return h.auditLogger.CreateWithConnection(
+		tx,
+		c,
+		auditLogType,
+		&models.User{ID: userID},
+		nil,
+		auditlog.Detail("email", emailAddress),
+		auditlog.Detail("context", "email_verification_link"))
+}
+
+func (h *EmailVerificationHandler) verifyEmailResponse(c echo.Context, verified bool, httpError error) error {
+	redirectURL := h.cfg.Email.VerificationLink.RedirectURL
+	if redirectURL == "" {
+		if httpError != nil {
+			return httpError
+		}
+
+		return c.JSON(http.StatusOK, map[string]bool{"email_verified": true})
+	}
+
+	if httpError != nil {
+		c.Logger().Error(httpError)
+	}
+
+	location, err := url.Parse(redirectURL)
+	if err != nil {
+		return fmt.Errorf("failed to parse email verification redirect url: %w", err)
+	}
+
+	query := location.Query()
+	query.Set("email_verified", strconv.FormatBool(verified))
+	location.RawQuery = query.Encode()
+
+	return c.Redirect(http.StatusSeeOther, location.String())
+}
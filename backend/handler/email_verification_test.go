@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/suite"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/crypto/link_token"
+	"github.com/teamhanko/hanko/backend/test"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEmailVerificationSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(emailVerificationSuite))
+}
+
+type emailVerificationSuite struct {
+	test.Suite
+}
+
+func (s *emailVerificationSuite) setupConfig() *config.Config {
+	cfg := test.DefaultConfig
+	cfg.Email.Limit = 5
+	cfg.Email.VerificationLink.Enabled = true
+	return &cfg
+}
+
+func (s *emailVerificationSuite) verificationToken(cfg *config.Config, userID uuid.UUID, emailAddress string) string {
+	signer, err := link_token.NewSigner(cfg.Secrets.Keys)
+	s.Require().NoError(err)
+
+	token, err := signer.Sign(link_token.PurposeEmailVerification, userID.String(), time.Hour, map[string]interface{}{"email": emailAddress})
+	s.Require().NoError(err)
+
+	return token
+}
+
+func (s *emailVerificationSuite) verify(cfg *config.Config, token string) *httptest.ResponseRecorder {
+	form := url.Values{"token": {token}}
+	req := httptest.NewRequest(http.MethodPost, "/emails/verify", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+
+	e := NewPublicRouter(cfg, s.Storage, nil, nil, nil)
+	e.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func (s *emailVerificationSuite) assertEmailCount(userID uuid.UUID, expected int) {
+	emails, err := s.Storage.GetEmailPersister().FindByUserId(userID)
+	s.Require().NoError(err)
+	s.Len(emails, expected)
+}
+
+func (s *emailVerificationSuite) TestEmailVerification_VerifyEmailConfirmation() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+
+	err := s.LoadFixtures("../test/fixtures/email")
+	s.Require().NoError(err)
+
+	cfg := s.setupConfig()
+	userID := uuid.FromStringOrNil("d41df4b7-c055-45e6-9faf-61aa92a4032e")
+	token := s.verificationToken(cfg, userID, "new@example.com")
+
+	req := httptest.NewRequest(http.MethodGet, "/emails/verify?token="+url.QueryEscape(token), nil)
+	rec := httptest.NewRecorder()
+
+	e := NewPublicRouter(cfg, s.Storage, nil, nil, nil)
+	e.ServeHTTP(rec, req)
+
+	s.Equal(http.StatusOK, rec.Code)
+	s.Contains(rec.Body.String(), `method="post"`)
+
+	// opening the link alone must not verify the email address
+	s.assertEmailCount(userID, 0)
+}
+
+func (s *emailVerificationSuite) TestEmailVerification_VerifyEmail() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+
+	err := s.LoadFixtures("../test/fixtures/email")
+	s.Require().NoError(err)
+
+	cfg := s.setupConfig()
+	userID := uuid.FromStringOrNil("d41df4b7-c055-45e6-9faf-61aa92a4032e")
+
+	rec := s.verify(cfg, s.verificationToken(cfg, userID, "new@example.com"))
+
+	s.Equal(http.StatusOK, rec.Code)
+	s.JSONEq(`{"email_verified": true}`, rec.Body.String())
+
+	emailModel, err := s.Storage.GetEmailPersister().FindByAddress("new@example.com")
+	s.Require().NoError(err)
+	s.Require().NotNil(emailModel)
+	s.True(emailModel.Verified)
+	s.Equal(userID, *emailModel.UserID)
+
+	userModel, err := s.Storage.GetUserPersister().Get(userID)
+	s.Require().NoError(err)
+	s.Require().NotNil(userModel.Emails.GetPrimary())
+	s.Equal(emailModel.ID, userModel.Emails.GetPrimary().ID)
+}
+
+func (s *emailVerificationSuite) TestEmailVerification_VerifyEmail_RepeatedUse() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+
+	err := s.LoadFixtures("../test/fixtures/email")
+	s.Require().NoError(err)
+
+	cfg := s.setupConfig()
+	userID := uuid.FromStringOrNil("d41df4b7-c055-45e6-9faf-61aa92a4032e")
+	token := s.verificationToken(cfg, userID, "new@example.com")
+
+	rec := s.verify(cfg, token)
+	s.Require().Equal(http.StatusOK, rec.Code)
+
+	rec = s.verify(cfg, token)
+	s.Equal(http.StatusBadRequest, rec.Code)
+
+	s.assertEmailCount(userID, 1)
+}
+
+func (s *emailVerificationSuite) TestEmailVerification_VerifyEmail_InvalidToken() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+
+	rec := s.verify(s.setupConfig(), "invalid")
+
+	s.Equal(http.StatusBadRequest, rec.Code)
+}
+
+func (s *emailVerificationSuite) TestEmailVerification_VerifyEmail_OtherUser() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+
+	err := s.LoadFixtures("../test/fixtures/email")
+	s.Require().NoError(err)
+
+	cfg := s.setupConfig()
+
+	// the user does not exist
+	rec := s.verify(cfg, s.verificationToken(cfg, uuid.Must(uuid.NewV4()), "new@example.com"))
+	s.Equal(http.StatusConflict, rec.Code)
+
+	emailModel, err := s.Storage.GetEmailPersister().FindByAddress("new@example.com")
+	s.Require().NoError(err)
+	s.Nil(emailModel)
+
+	// the email address belongs to another user
+	userID := uuid.FromStringOrNil("d41df4b7-c055-45e6-9faf-61aa92a4032e")
+	rec = s.verify(cfg, s.verificationToken(cfg, userID, "john.doe@example.com"))
+	s.Equal(http.StatusConflict, rec.Code)
+
+	s.assertEmailCount(userID, 0)
+}
+
+func (s *emailVerificationSuite) TestEmailVerification_VerifyEmail_AddressTaken() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+
+	err := s.LoadFixtures("../test/fixtures/email")
+	s.Require().NoError(err)
+
+	cfg := s.setupConfig()
+	userID := uuid.FromStringOrNil("b5dd5267-b462-48be-b70d-bcd6f1bbe7a5")
+
+	// the address has been taken by another user after the link was sent
+	rec := s.verify(cfg, s.verificationToken(cfg, userID, "john.doe+1@example.com"))
+	s.Equal(http.StatusConflict, rec.Code)
+
+	emailModel, err := s.Storage.GetEmailPersister().FindByAddress("john.doe+1@example.com")
+	s.Require().NoError(err)
+	s.Require().NotNil(emailModel)
+	s.Equal(uuid.FromStringOrNil("38bf5a00-d7ea-40a5-a5de-48722c148925"), *emailModel.UserID)
+}
+
+func (s *emailVerificationSuite) TestEmailVerification_VerifyEmail_LimitReached() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+
+	err := s.LoadFixtures("../test/fixtures/email")
+	s.Require().NoError(err)
+
+	cfg := s.setupConfig()
+	cfg.Email.Limit = 3
+	userID := uuid.FromStringOrNil("b5dd5267-b462-48be-b70d-bcd6f1bbe7a5")
+
+	rec := s.verify(cfg, s.verificationToken(cfg, userID, "new@example.com"))
+	s.Equal(http.StatusConflict, rec.Code)
+
+	s.assertEmailCount(userID, 3)
+}
+
+func (s *emailVerificationSuite) TestEmailVerification_VerifyEmail_RedirectURL() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+
+	err := s.LoadFixtures("../test/fixtures/email")
+	s.Require().NoError(err)
+
+	cfg := s.setupConfig()
+	cfg.Email.VerificationLink.RedirectURL = "https://example.com/profile"
+	userID := uuid.FromStringOrNil("d41df4b7-c055-45e6-9faf-61aa92a4032e")
+	token := s.verificationToken(cfg, userID, "new@example.com")
+
+	rec := s.verify(cfg, token)
+	s.Equal(http.StatusSeeOther, rec.Code)
+	s.Equal("https://example.com/profile?email_verified=true", rec.Header().Get("Location"))
+
+	rec = s.verify(cfg, token)
+	s.Equal(http.StatusSeeOther, rec.Code)
+	s.Equal("https://example.com/profile?email_verified=false", rec.Header().Get("Location"))
+}
@@ -22,7 +22,6 @@ import (
 	"github.com/teamhanko/hanko/backend/webhooks/events"
 	"github.com/teamhanko/hanko/backend/webhooks/utils"
 	"golang.org/x/crypto/bcrypt"
-	"gopkg.in/gomail.v2"
 	"net/http"
 	"strings"
 	"time"
@@ -45,7 +44,7 @@ type PasscodeHandler struct {
 var maxPasscodeTries = 3
 
 func NewPasscodeHandler(cfg *config.Config, persister persistence.Persister, sessionManager session.Manager, mailer mail.Mailer, auditLogger auditlog.Logger) (*PasscodeHandler, error) {
-	renderer, err := mail.NewRenderer()
+	renderer, err := mail.NewRenderer(cfg.EmailDelivery.TemplateDirectory)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create new renderer: %w", err)
 	}
@@ -190,7 +189,11 @@ func (h *PasscodeHandler) Init(c echo.Context) error {
 		"TTL":         fmt.Sprintf("%.0f", durationTTL.Minutes()),
 	}
 
-	lang := c.Request().Header.Get("Accept-Language")
+	preferredLanguage := ""
+	if user.PreferredLanguage != nil {
+		preferredLanguage = *user.PreferredLanguage
+	}
+	lang := mail.LanguagePreference(preferredLanguage, c.Request().Header.Get("Accept-Language"))
 
 	subject := h.renderer.Translate(lang, "email_subject_login", data)
 
@@ -199,9 +202,15 @@ func (h *PasscodeHandler) Init(c echo.Context) error {
 		return fmt.Errorf("failed to render email template: %w", err)
 	}
 
+	htmlBody, err := h.renderer.Render("login_html.tmpl", lang, data)
+	if err != nil {
+		return fmt.Errorf("failed to render email template: %w", err)
+	}
+
 	webhookData := webhook.EmailSend{
 		Subject:          subject,
 		BodyPlain:        body,
+		Body:             htmlBody,
 		ToEmailAddress:   email.Address,
 		DeliveredByHanko: true,
 		AcceptLanguage:   lang,
@@ -215,13 +224,12 @@ func (h *PasscodeHandler) Init(c echo.Context) error {
 	}
 
 	if h.cfg.EmailDelivery.Enabled {
-		message := gomail.NewMessage()
-		message.SetAddressHeader("To", email.Address, "")
-		message.SetAddressHeader("From", h.emailConfig.FromAddress, h.emailConfig.FromName)
-
-		message.SetHeader("Subject", subject)
-
-		message.SetBody("text/plain", body)
+		message := mail.Message{
+			To:      email.Address,
+			Subject: subject,
+			Text:    body,
+			HTML:    htmlBody,
+		}
 
 		err = h.mailer.Send(message)
 		if err != nil {
@@ -301,6 +309,7 @@ func (h *PasscodeHandler) Finish(c echo.Context) error {
 			if err != nil {
 				return fmt.Errorf("failed to create audit log: %w", err)
 			}
+			utils.NotifyLoginFailed(c, tx, &userModel.ID, webhook.LoginMethodPasscode, "passcode_expired")
 			businessError = echo.NewHTTPError(http.StatusRequestTimeout, "passcode request timed out").SetInternal(errors.New(fmt.Sprintf("createdAt: %s -> lastVerificationTime: %s", passcode.CreatedAt, lastVerificationTime))) // TODO: maybe we should use BadRequest, because RequestTimeout might be to technical and can refer to different error
 			return nil
 		}
@@ -318,6 +327,7 @@ func (h *PasscodeHandler) Finish(c echo.Context) error {
 				if err != nil {
 					return fmt.Errorf("failed to create audit log: %w", err)
 				}
+				utils.NotifyLoginFailed(c, tx, &userModel.ID, webhook.LoginMethodPasscode, "passcode_max_attempts_reached")
 				businessError = echo.NewHTTPError(http.StatusGone, "max attempts reached")
 				return nil
 			}
@@ -331,6 +341,7 @@ func (h *PasscodeHandler) Finish(c echo.Context) error {
 			if err != nil {
 				return fmt.Errorf("failed to create audit log: %w", err)
 			}
+			utils.NotifyLoginFailed(c, tx, &userModel.ID, webhook.LoginMethodPasscode, "passcode_invalid")
 			businessError = echo.NewHTTPError(http.StatusUnauthorized).SetInternal(errors.New("passcode invalid"))
 			return nil
 		}
@@ -401,7 +412,7 @@ func (h *PasscodeHandler) Finish(c echo.Context) error {
 			emailJwt = dto.JwtFromEmailModel(e)
 		}
 
-		token, _, err := h.sessionManager.GenerateJWT(*passcode.UserId, emailJwt)
+		token, rawToken, err := h.sessionManager.GenerateJWT(*passcode.UserId, emailJwt)
 		if err != nil {
 			return fmt.Errorf("failed to generate jwt: %w", err)
 		}
@@ -437,6 +448,12 @@ func (h *PasscodeHandler) Finish(c echo.Context) error {
 			utils.NotifyUserChange(c, tx, h.persister, evt, userModel.ID)
 		}
 
+		if existingSessionToken == nil && emailExistsForUser {
+			utils.NotifyLoginSession(c, tx, h.persister, rawToken, webhook.LoginMethodPasscode)
+		} else {
+			utils.NotifySessionCreate(c, tx, rawToken)
+		}
+
 		return c.JSON(http.StatusOK, dto.PasscodeReturn{
 			Id:        passcode.ID.String(),
 			TTL:       passcode.Ttl,
@@ -11,34 +11,40 @@ import (
 	"github.com/teamhanko/hanko/backend/audit_log"
 	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/dto/webhook"
+	"github.com/teamhanko/hanko/backend/flow_api/services"
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/persistence/models"
 	"github.com/teamhanko/hanko/backend/rate_limiter"
 	"github.com/teamhanko/hanko/backend/session"
+	"github.com/teamhanko/hanko/backend/webhooks/events"
+	webhookUtils "github.com/teamhanko/hanko/backend/webhooks/utils"
 	"golang.org/x/crypto/bcrypt"
 	"net/http"
 	"unicode/utf8"
 )
 
 type PasswordHandler struct {
-	persister      persistence.Persister
-	sessionManager session.Manager
-	cfg            *config.Config
-	auditLogger    auditlog.Logger
-	rateLimiter    limiter.Store
+	persister                   persistence.Persister
+	sessionManager              session.Manager
+	cfg                         *config.Config
+	auditLogger                 auditlog.Logger
+	rateLimiter                 limiter.Store
+	securityNotificationService services.SecurityNotification
 }
 
-func NewPasswordHandler(persister persistence.Persister, sessionManager session.Manager, cfg *config.Config, auditLogger auditlog.Logger) *PasswordHandler {
+func NewPasswordHandler(persister persistence.Persister, sessionManager session.Manager, cfg *config.Config, auditLogger auditlog.Logger, securityNotificationService services.SecurityNotification) *PasswordHandler {
 	var rateLimiter limiter.Store
 	if cfg.RateLimiter.Enabled {
 		rateLimiter = rate_limiter.NewRateLimiter(cfg.RateLimiter, cfg.RateLimiter.PasswordLimits)
 	}
 	return &PasswordHandler{
-		persister:      persister,
-		sessionManager: sessionManager,
-		cfg:            cfg,
-		auditLogger:    auditLogger,
-		rateLimiter:    rateLimiter,
+		persister:                   persister,
+		sessionManager:              sessionManager,
+		cfg:                         cfg,
+		auditLogger:                 auditLogger,
+		rateLimiter:                 rateLimiter,
+		securityNotificationService: securityNotificationService,
 	}
 }
 
@@ -131,6 +137,10 @@ func (h *PasswordHandler) Set(c echo.Context) error {
 				if err != nil {
 					return fmt.Errorf("failed to create audit log: %w", err)
 				}
+				if h.cfg.SecurityNotifications.PasswordChanged.Enabled {
+					sendSecurityNotification(c, tx, h.persister, *h.cfg, h.securityNotificationService, user.ID, webhook.EmailTypePasswordChanged, webhook.SecurityNotificationData{})
+				}
+				webhookUtils.NotifyUserChange(c, tx, h.persister, events.UserPassword, user.ID)
 				return c.JSON(http.StatusCreated, nil)
 			}
 		} else {
@@ -143,6 +153,10 @@ func (h *PasswordHandler) Set(c echo.Context) error {
 				if err != nil {
 					return fmt.Errorf("failed to create audit log: %w", err)
 				}
+				if h.cfg.SecurityNotifications.PasswordChanged.Enabled {
+					sendSecurityNotification(c, tx, h.persister, *h.cfg, h.securityNotificationService, user.ID, webhook.EmailTypePasswordChanged, webhook.SecurityNotificationData{})
+				}
+				webhookUtils.NotifyUserChange(c, tx, h.persister, events.UserPassword, user.ID)
 				return c.JSON(http.StatusOK, nil)
 			}
 		}
@@ -185,6 +199,7 @@ func (h *PasswordHandler) Login(c echo.Context) error {
 		if err != nil {
 			return fmt.Errorf("failed to create audit log: %w", err)
 		}
+		webhookUtils.NotifyLoginFailed(c, h.persister.GetConnection(), nil, webhook.LoginMethodPassword, "unknown_user")
 		return echo.NewHTTPError(http.StatusUnauthorized).SetInternal(errors.New("user not found"))
 	}
 
@@ -194,6 +209,7 @@ func (h *PasswordHandler) Login(c echo.Context) error {
 		if err != nil {
 			return fmt.Errorf("failed to create audit log: %w", err)
 		}
+		webhookUtils.NotifyLoginFailed(c, h.persister.GetConnection(), &user.ID, webhook.LoginMethodPassword, "password_too_long")
 		return echo.NewHTTPError(http.StatusBadRequest, "password must not be longer than 72 bytes")
 	}
 
@@ -203,6 +219,7 @@ func (h *PasswordHandler) Login(c echo.Context) error {
 		if err != nil {
 			return fmt.Errorf("failed to create audit log: %w", err)
 		}
+		webhookUtils.NotifyLoginFailed(c, h.persister.GetConnection(), &user.ID, webhook.LoginMethodPassword, "password_not_set")
 		return echo.NewHTTPError(http.StatusUnauthorized).SetInternal(errors.New(fmt.Sprintf("no password credential found for: %s", body.UserId)))
 	}
 
@@ -215,6 +232,7 @@ func (h *PasswordHandler) Login(c echo.Context) error {
 		if err != nil {
 			return fmt.Errorf("failed to create audit log: %w", err)
 		}
+		webhookUtils.NotifyLoginFailed(c, h.persister.GetConnection(), &user.ID, webhook.LoginMethodPassword, "password_invalid")
 		return echo.NewHTTPError(http.StatusUnauthorized).SetInternal(err)
 	}
 
@@ -223,7 +241,7 @@ func (h *PasswordHandler) Login(c echo.Context) error {
 		emailJwt = dto.JwtFromEmailModel(e)
 	}
 
-	token, _, err := h.sessionManager.GenerateJWT(pw.UserId, emailJwt)
+	token, rawToken, err := h.sessionManager.GenerateJWT(pw.UserId, emailJwt)
 	if err != nil {
 		return fmt.Errorf("failed to generate jwt: %w", err)
 	}
@@ -246,5 +264,7 @@ func (h *PasswordHandler) Login(c echo.Context) error {
 		return fmt.Errorf("failed to create audit log: %w", err)
 	}
 
+	webhookUtils.NotifyLoginSession(c, h.persister.GetConnection(), h.persister, rawToken, webhook.LoginMethodPassword)
+
 	return c.JSON(http.StatusOK, nil)
 }
@@ -101,7 +101,7 @@ func (s *passwordSuite) TestPasswordHandler_Set_Create() {
 			req.AddCookie(cookie)
 			rec := httptest.NewRecorder()
 
-			e := NewPublicRouter(cfg, s.Storage, nil, nil)
+			e := NewPublicRouter(cfg, s.Storage, nil, nil, nil)
 			e.ServeHTTP(rec, req)
 
 			s.Equal(currentTest.expectedCode, rec.Code)
@@ -171,7 +171,7 @@ func (s *passwordSuite) TestPasswordHandler_Login() {
 			req.Header.Set("Content-Type", "application/json")
 			rec := httptest.NewRecorder()
 
-			e := NewPublicRouter(currentTest.cfg(), s.Storage, nil, nil)
+			e := NewPublicRouter(currentTest.cfg(), s.Storage, nil, nil, nil)
 			e.ServeHTTP(rec, req)
 
 			if s.Equal(currentTest.expectedCode, rec.Code) {
@@ -9,9 +9,11 @@ import (
 	"github.com/teamhanko/hanko/backend/audit_log"
 	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/crypto/jwk"
+	"github.com/teamhanko/hanko/backend/crypto/link_token"
 	"github.com/teamhanko/hanko/backend/dto"
 	"github.com/teamhanko/hanko/backend/ee/saml"
 	"github.com/teamhanko/hanko/backend/flow_api"
+	flowAPI "github.com/teamhanko/hanko/backend/flow_api/flow"
 	"github.com/teamhanko/hanko/backend/flow_api/services"
 	"github.com/teamhanko/hanko/backend/mail"
 	"github.com/teamhanko/hanko/backend/mapper"
@@ -19,22 +21,33 @@ import (
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/rate_limiter"
 	"github.com/teamhanko/hanko/backend/session"
+	"github.com/teamhanko/hanko/backend/telemetry"
 	"github.com/teamhanko/hanko/backend/template"
 )
 
-func NewPublicRouter(cfg *config.Config, persister persistence.Persister, prometheus echo.MiddlewareFunc, authenticatorMetadata mapper.AuthenticatorMetadata) *echo.Echo {
+// NewPublicRouter returns the router of the public API. The given audit logger is used for all audit logs of the
+// public API. If it is nil, an audit logger is created from the config, which is never closed.
+func NewPublicRouter(cfg *config.Config, persister persistence.Persister, prometheus echo.MiddlewareFunc, authenticatorMetadata mapper.AuthenticatorMetadata, auditLogger auditlog.Logger) *echo.Echo {
 	e := echo.New()
 
 	e.Renderer = template.NewTemplateRenderer()
 
 	e.Static("/flowpilot", "flow_api/static") // TODO: remove!
 
-	emailService, err := services.NewEmailService(*cfg)
-	passcodeService := services.NewPasscodeService(*cfg, *emailService, persister)
+	mailer, err := mail.NewDeliveryMailer(cfg.EmailDelivery, cfg.Secrets.Keys, persister)
+	if err != nil {
+		panic(fmt.Errorf("failed to create mailer: %w", err))
+	}
+
+	emailService, err := services.NewEmailService(*cfg, mailer)
+	if err != nil {
+		panic(fmt.Errorf("failed to create email service: %w", err))
+	}
 	passwordService := services.NewPasswordService(*cfg, persister)
 	webauthnService := services.NewWebauthnService(*cfg, persister)
+	securityNotificationService := services.NewSecurityNotificationService(*cfg, *emailService)
 
-	jwkManager, err := jwk.NewDefaultManager(cfg.Secrets.Keys, persister.GetJwkPersister())
+	jwkManager, err := jwk.NewDefaultManager(cfg.Secrets.Keys, persister.GetJwkPersister(), jwk.WithKeyAlgorithm(cfg.Secrets.Jwk.Algorithm), jwk.WithGracePeriod(cfg.Secrets.Jwk.GracePeriod), jwk.WithLock(persister.GetSchedulerLockPersister(nil)), jwk.WithRetainedKeys(persister.GetAuditLogChainPersister(nil).ListCheckpointKeyIDs))
 	if err != nil {
 		panic(fmt.Errorf("failed to create jwk manager: %w", err))
 	}
@@ -42,6 +55,11 @@ func NewPublicRouter(cfg *config.Config, persister persistence.Persister, promet
 	if err != nil {
 		panic(fmt.Errorf("failed to create session generator: %w", err))
 	}
+	linkTokenSigner, err := link_token.NewSigner(cfg.Secrets.Keys)
+	if err != nil {
+		panic(fmt.Errorf("failed to create link token signer: %w", err))
+	}
+	passcodeService := services.NewPasscodeService(*cfg, *emailService, persister, linkTokenSigner)
 
 	var passcodeRateLimiter limiter.Store
 	var passwordRateLimiter limiter.Store
@@ -52,33 +70,45 @@ func NewPublicRouter(cfg *config.Config, persister persistence.Persister, promet
 		tokenExchangeRateLimiter = rate_limiter.NewRateLimiter(cfg.RateLimiter, cfg.RateLimiter.TokenLimits)
 	}
 
-	auditLogger := auditlog.NewLogger(persister, cfg.AuditLog)
+	if auditLogger == nil {
+		auditLogger, err = auditlog.NewLogger(persister, cfg.AuditLog)
+		if err != nil {
+			panic(fmt.Errorf("failed to create audit logger: %w", err))
+		}
+	}
 
 	samlService := saml.NewSamlService(cfg, persister)
 
-	flowAPIHandler := flow_api.FlowPilotHandler{
-		Persister:                persister,
-		Cfg:                      *cfg,
-		PasscodeService:          passcodeService,
-		PasswordService:          passwordService,
-		WebauthnService:          webauthnService,
-		SessionManager:           sessionManager,
-		PasscodeRateLimiter:      passcodeRateLimiter,
-		PasswordRateLimiter:      passwordRateLimiter,
-		TokenExchangeRateLimiter: tokenExchangeRateLimiter,
-		AuthenticatorMetadata:    authenticatorMetadata,
-		AuditLogger:              auditLogger,
-		SamlService:              samlService,
+	if err := flowAPI.ValidateFlows(*cfg); err != nil {
+		panic(err)
 	}
 
-	if cfg.Saml.Enabled {
-		saml.CreateSamlRoutes(e, sessionManager, auditLogger, samlService)
+	flowAPIHandler := flow_api.FlowPilotHandler{
+		Persister:                   persister,
+		FlowStore:                   flow_api.NewFlowStore(cfg.Flow.Store),
+		Cfg:                         *cfg,
+		PasscodeService:             passcodeService,
+		PasswordService:             passwordService,
+		WebauthnService:             webauthnService,
+		SecurityNotificationService: securityNotificationService,
+		SessionManager:              sessionManager,
+		LinkTokenSigner:             linkTokenSigner,
+		PasscodeRateLimiter:         passcodeRateLimiter,
+		PasswordRateLimiter:         passwordRateLimiter,
+		TokenExchangeRateLimiter:    tokenExchangeRateLimiter,
+		AuthenticatorMetadata:       authenticatorMetadata,
+		AuditLogger:                 auditLogger,
+		SamlService:                 samlService,
 	}
 
 	sessionMiddleware := hankoMiddleware.Session(cfg, sessionManager)
 
 	webhookMiddleware := hankoMiddleware.WebhookMiddleware(cfg, jwkManager, persister)
 
+	if cfg.Saml.Enabled {
+		saml.CreateSamlRoutes(e, sessionManager, auditLogger, samlService, webhookMiddleware)
+	}
+
 	e.POST("/registration", flowAPIHandler.RegistrationFlowHandler, webhookMiddleware)
 	e.POST("/login", flowAPIHandler.LoginFlowHandler, webhookMiddleware)
 	e.POST("/profile", flowAPIHandler.ProfileFlowHandler, webhookMiddleware)
@@ -119,19 +149,18 @@ func NewPublicRouter(cfg *config.Config, persister persistence.Persister, promet
 		e.Use(prometheus)
 	}
 
-	e.Validator = dto.NewCustomValidator()
-
-	mailer, err := mail.NewMailer(cfg.EmailDelivery.SMTP)
-	if err != nil {
-		panic(fmt.Errorf("failed to create mailer: %w", err))
+	if cfg.Tracing.Enabled {
+		e.Use(telemetry.Middleware())
 	}
 
+	e.Validator = dto.NewCustomValidator()
+
 	if cfg.Password.Enabled {
-		passwordHandler := NewPasswordHandler(persister, sessionManager, cfg, auditLogger)
+		passwordHandler := NewPasswordHandler(persister, sessionManager, cfg, auditLogger, securityNotificationService)
 
 		password := g.Group("/password")
-		password.PUT("", passwordHandler.Set, sessionMiddleware)
-		password.POST("/login", passwordHandler.Login)
+		password.PUT("", passwordHandler.Set, sessionMiddleware, webhookMiddleware)
+		password.POST("/login", passwordHandler.Login, webhookMiddleware)
 	}
 
 	userHandler := NewUserHandler(cfg, persister, sessionManager, auditLogger)
@@ -145,7 +174,7 @@ func NewPublicRouter(cfg *config.Config, persister persistence.Persister, promet
 	user.GET("/:id", userHandler.Get, sessionMiddleware)
 
 	g.POST("/user", userHandler.GetUserIdByEmail)
-	g.POST("/logout", userHandler.Logout, sessionMiddleware)
+	g.POST("/logout", userHandler.Logout, sessionMiddleware, webhookMiddleware)
 
 	if cfg.Account.AllowDeletion {
 		g.DELETE("/user", userHandler.Delete, sessionMiddleware, webhookMiddleware)
@@ -165,26 +194,26 @@ func NewPublicRouter(cfg *config.Config, persister persistence.Persister, promet
 	wellKnown.GET("/jwks.json", wellKnownHandler.GetPublicKeys)
 	wellKnown.GET("/config", wellKnownHandler.GetConfig)
 
-	emailHandler := NewEmailHandler(cfg, persister, sessionManager, auditLogger)
+	emailHandler := NewEmailHandler(cfg, persister, sessionManager, auditLogger, securityNotificationService)
 
 	if cfg.Passkey.Enabled {
-		webauthnHandler, err := NewWebauthnHandler(cfg, persister, sessionManager, auditLogger, authenticatorMetadata)
+		webauthnHandler, err := NewWebauthnHandler(cfg, persister, sessionManager, auditLogger, authenticatorMetadata, securityNotificationService)
 		if err != nil {
 			panic(fmt.Errorf("failed to create public webauthn handler: %w", err))
 		}
 		webauthn := g.Group("/webauthn")
-		webauthnRegistration := webauthn.Group("/registration", sessionMiddleware)
+		webauthnRegistration := webauthn.Group("/registration", sessionMiddleware, webhookMiddleware)
 		webauthnRegistration.POST("/initialize", webauthnHandler.BeginRegistration)
 		webauthnRegistration.POST("/finalize", webauthnHandler.FinishRegistration)
 
-		webauthnLogin := webauthn.Group("/login")
+		webauthnLogin := webauthn.Group("/login", webhookMiddleware)
 		webauthnLogin.POST("/initialize", webauthnHandler.BeginAuthentication)
 		webauthnLogin.POST("/finalize", webauthnHandler.FinishAuthentication)
 
 		webauthnCredentials := webauthn.Group("/credentials", sessionMiddleware)
 		webauthnCredentials.GET("", webauthnHandler.ListCredentials)
 		webauthnCredentials.PATCH("/:id", webauthnHandler.UpdateCredential)
-		webauthnCredentials.DELETE("/:id", webauthnHandler.DeleteCredential)
+		webauthnCredentials.DELETE("/:id", webauthnHandler.DeleteCredential, webhookMiddleware)
 	}
 
 	if cfg.Email.Enabled && cfg.Email.UseForAuthentication {
@@ -204,6 +233,12 @@ func NewPublicRouter(cfg *config.Config, persister persistence.Persister, promet
 	email.DELETE("/:id", emailHandler.Delete)
 	email.POST("/:id/set_primary", emailHandler.SetPrimaryEmail)
 
+	if cfg.Email.VerificationLink.Enabled {
+		emailVerificationHandler := NewEmailVerificationHandler(persister, linkTokenSigner, auditLogger, *cfg)
+		g.GET("/emails/verify", emailVerificationHandler.VerifyEmailConfirmation)
+		g.POST("/emails/verify", emailVerificationHandler.VerifyEmail, webhookMiddleware)
+	}
+
 	thirdPartyHandler := NewThirdPartyHandler(cfg, persister, sessionManager, auditLogger)
 	thirdparty := g.Group("thirdparty")
 	thirdparty.GET("/auth", thirdPartyHandler.Auth)
@@ -211,12 +246,21 @@ func NewPublicRouter(cfg *config.Config, persister persistence.Persister, promet
 	thirdparty.POST("/callback", thirdPartyHandler.CallbackPost, webhookMiddleware)
 
 	tokenHandler := NewTokenHandler(cfg, persister, sessionManager, auditLogger)
-	g.POST("/token", tokenHandler.Validate)
+	g.POST("/token", tokenHandler.Validate, webhookMiddleware)
 
-	sessionHandler := NewSessionHandler(persister, sessionManager, *cfg)
+	sessionHandler := NewSessionHandler(persister, sessionManager, linkTokenSigner, auditLogger, *cfg)
 	sessions := g.Group("sessions")
 	sessions.GET("/validate", sessionHandler.ValidateSession)
 	sessions.POST("/validate", sessionHandler.ValidateSessionFromBody)
+	if cfg.SecurityNotifications.NewDeviceLogin.Enabled {
+		sessions.GET("/revoke", sessionHandler.RevokeSessionConfirmation)
+		sessions.POST("/revoke", sessionHandler.RevokeSession, webhookMiddleware)
+	}
+
+	if cfg.Email.PasscodeMode == config.PasscodeModeLink {
+		g.GET("/passcode/link", flowAPIHandler.PasscodeLinkConfirmation)
+		g.POST("/passcode/link", flowAPIHandler.PasscodeLinkHandler)
+	}
 
 	return e
 }
@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/dto/webhook"
+	"github.com/teamhanko/hanko/backend/flow_api/flow/shared"
+	"github.com/teamhanko/hanko/backend/flow_api/services"
+	"github.com/teamhanko/hanko/backend/persistence"
+)
+
+// sendSecurityNotification sends the security notification for a change made through the legacy API, the same way the
+// profile flow does for changes made in the flow. Errors are only logged.
+func sendSecurityNotification(c echo.Context, tx *pop.Connection, persister persistence.Persister, cfg config.Config, notificationService services.SecurityNotification, userID uuid.UUID, emailType webhook.EmailType, data webhook.SecurityNotificationData, additionalEmailAddresses ...string) {
+	deps := &shared.Dependencies{
+		Cfg:                         cfg,
+		HttpContext:                 c,
+		Persister:                   persister,
+		Tx:                          tx,
+		SecurityNotificationService: notificationService,
+	}
+
+	shared.SendSecurityNotification(deps, userID, emailType, data, additionalEmailAddresses...)
+}
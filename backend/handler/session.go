@@ -2,29 +2,42 @@ package handler
 
 import (
 	"fmt"
+	"github.com/gobuffalo/pop/v6"
 	"github.com/gofrs/uuid"
 	echojwt "github.com/labstack/echo-jwt/v4"
 	"github.com/labstack/echo/v4"
 	"github.com/lestrrat-go/jwx/v2/jwt"
+	auditlog "github.com/teamhanko/hanko/backend/audit_log"
 	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/crypto/link_token"
 	"github.com/teamhanko/hanko/backend/dto"
 	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
 	"github.com/teamhanko/hanko/backend/session"
+	"github.com/teamhanko/hanko/backend/template"
+	"github.com/teamhanko/hanko/backend/webhooks/events"
+	webhookUtils "github.com/teamhanko/hanko/backend/webhooks/utils"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 )
 
 type SessionHandler struct {
-	persister      persistence.Persister
-	sessionManager session.Manager
-	cfg            config.Config
+	persister       persistence.Persister
+	sessionManager  session.Manager
+	linkTokenSigner link_token.Signer
+	auditLogger     auditlog.Logger
+	cfg             config.Config
 }
 
-func NewSessionHandler(persister persistence.Persister, sessionManager session.Manager, cfg config.Config) *SessionHandler {
+func NewSessionHandler(persister persistence.Persister, sessionManager session.Manager, linkTokenSigner link_token.Signer, auditLogger auditlog.Logger, cfg config.Config) *SessionHandler {
 	return &SessionHandler{
-		persister:      persister,
-		sessionManager: sessionManager,
-		cfg:            cfg,
+		persister:       persister,
+		sessionManager:  sessionManager,
+		linkTokenSigner: linkTokenSigner,
+		auditLogger:     auditLogger,
+		cfg:             cfg,
 	}
 }
 
@@ -146,3 +159,106 @@ func (h *SessionHandler) ValidateSessionFromBody(c echo.Context) error {
 		UserID:         &userID,
 	})
 }
+
+// RevokeSessionConfirmation renders the page the link of a new device login notification leads to. The session is only
+// revoked when the user confirms the revocation on the page.
+func (h *SessionHandler) RevokeSessionConfirmation(c echo.Context) error {
+	token := c.QueryParam("token")
+	if _, err := h.linkTokenSigner.Verify(link_token.PurposeSessionRevocation, token); err != nil {
+		return h.revokeSessionResponse(c, false, echo.NewHTTPError(http.StatusBadRequest, "invalid or expired token").SetInternal(err))
+	}
+
+	return c.Render(http.StatusOK, "confirm", template.ConfirmationPage{
+		Title:   "Revoke session",
+		Message: "Sign out the device of the login you have been notified about?",
+		Token:   token,
+		Button:  "Revoke session",
+	})
+}
+
+// RevokeSession revokes the server-side session referenced by the signed token contained in the link of a new device
+// login notification. The token is submitted by the page rendered by RevokeSessionConfirmation.
+func (h *SessionHandler) RevokeSession(c echo.Context) error {
+	token, err := h.linkTokenSigner.Verify(link_token.PurposeSessionRevocation, c.FormValue("token"))
+	if err != nil {
+		return h.revokeSessionResponse(c, false, echo.NewHTTPError(http.StatusBadRequest, "invalid or expired token").SetInternal(err))
+	}
+
+	userID, err := uuid.FromString(token.Subject())
+	if err != nil {
+		return h.revokeSessionResponse(c, false, echo.NewHTTPError(http.StatusBadRequest, "invalid token").SetInternal(err))
+	}
+
+	sessionIDClaim, _ := token.Get("session_id")
+	sessionIDString, _ := sessionIDClaim.(string)
+	sessionID, err := uuid.FromString(sessionIDString)
+	if err != nil {
+		return h.revokeSessionResponse(c, false, echo.NewHTTPError(http.StatusBadRequest, "invalid token").SetInternal(err))
+	}
+
+	err = h.persister.Transaction(func(tx *pop.Connection) error {
+		sessionPersister := h.persister.GetSessionPersisterWithConnection(tx)
+		sessionModel, err := sessionPersister.Get(sessionID)
+		if err != nil {
+			return fmt.Errorf("failed to get session from database: %w", err)
+		}
+
+		// The session might have been removed already, e.g. due to a logout. The link has then served its purpose,
+		// so this is not considered an error.
+		if sessionModel == nil || sessionModel.UserID != userID {
+			return nil
+		}
+
+		err = sessionPersister.Delete(*sessionModel)
+		if err != nil {
+			return err
+		}
+
+		err = h.auditLogger.CreateWithConnection(
+			tx,
+			c,
+			models.AuditLogSessionRevoked,
+			&models.User{ID: userID},
+			nil,
+			auditlog.Detail("session_id", sessionID.String()),
+			auditlog.Detail("context", "new_device_login_notification"))
+		if err != nil {
+			return err
+		}
+
+		webhookUtils.NotifySession(c, tx, events.SessionRevoke, *sessionModel)
+
+		return nil
+	})
+	if err != nil {
+		return h.revokeSessionResponse(c, false, dto.ToHttpError(err))
+	}
+
+	return h.revokeSessionResponse(c, true, nil)
+}
+
+func (h *SessionHandler) revokeSessionResponse(c echo.Context, revoked bool, httpError error) error {
+	redirectURL := h.cfg.SecurityNotifications.NewDeviceLogin.RevocationRedirectURL
+	if redirectURL == "" {
+		if httpError != nil {
+			return httpError
+		}
+
+		return c.JSON(http.StatusOK, map[string]bool{"session_revoked": true})
+	}
+
+	if httpError != nil {
+		c.Logger().Error(httpError)
+	}
+
+	location, err := url.Parse(redirectURL)
+	if err != nil {
+		return fmt.Errorf("failed to parse revocation redirect url: %w", err)
+	}
+
+	query := location.Query()
+	query.Set("session_revoked", strconv.FormatBool(revoked))
+	location.RawQuery = query.Encode()
+
+	return c.Redirect(http.StatusSeeOther, location.String())
+}
@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/suite"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/crypto/link_token"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/test"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(sessionSuite))
+}
+
+type sessionSuite struct {
+	test.Suite
+}
+
+func (s *sessionSuite) setupConfig() *config.Config {
+	cfg := test.DefaultConfig
+	cfg.SecurityNotifications.NewDeviceLogin.Enabled = true
+	return &cfg
+}
+
+func (s *sessionSuite) createSession(userID uuid.UUID) models.Session {
+	now := time.Now().UTC()
+	sessionModel := models.Session{
+		ID:        uuid.Must(uuid.NewV4()),
+		UserID:    userID,
+		UserAgent: "test",
+		IpAddress: "127.0.0.1",
+		CreatedAt: now,
+		UpdatedAt: now,
+		LastUsed:  now,
+	}
+
+	s.Require().NoError(s.Storage.GetSessionPersister().Create(sessionModel))
+
+	return sessionModel
+}
+
+func (s *sessionSuite) revocationToken(cfg *config.Config, userID uuid.UUID, sessionID uuid.UUID) string {
+	signer, err := link_token.NewSigner(cfg.Secrets.Keys)
+	s.Require().NoError(err)
+
+	token, err := signer.Sign(link_token.PurposeSessionRevocation, userID.String(), time.Hour, map[string]interface{}{"session_id": sessionID.String()})
+	s.Require().NoError(err)
+
+	return token
+}
+
+func (s *sessionSuite) revoke(cfg *config.Config, token string) *httptest.ResponseRecorder {
+	form := url.Values{"token": {token}}
+	req := httptest.NewRequest(http.MethodPost, "/sessions/revoke", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+
+	e := NewPublicRouter(cfg, s.Storage, nil, nil, nil)
+	e.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func (s *sessionSuite) TestSession_RevokeSessionConfirmation() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+
+	err := s.LoadFixtures("../test/fixtures/token")
+	s.Require().NoError(err)
+
+	cfg := s.setupConfig()
+	userID := uuid.FromStringOrNil("b5dd5267-b462-48be-b70d-bcd6f1bbe7a5")
+	sessionModel := s.createSession(userID)
+	token := s.revocationToken(cfg, userID, sessionModel.ID)
+
+	req := httptest.NewRequest(http.MethodGet, "/sessions/revoke?token="+url.QueryEscape(token), nil)
+	rec := httptest.NewRecorder()
+
+	e := NewPublicRouter(cfg, s.Storage, nil, nil, nil)
+	e.ServeHTTP(rec, req)
+
+	s.Equal(http.StatusOK, rec.Code)
+	s.Contains(rec.Body.String(), `method="post"`)
+
+	// opening the link alone must not revoke the session
+	stored, err := s.Storage.GetSessionPersister().Get(sessionModel.ID)
+	s.NoError(err)
+	s.NotNil(stored)
+}
+
+func (s *sessionSuite) TestSession_RevokeSessionConfirmation_InvalidToken() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/sessions/revoke?token=invalid", nil)
+	rec := httptest.NewRecorder()
+
+	e := NewPublicRouter(s.setupConfig(), s.Storage, nil, nil, nil)
+	e.ServeHTTP(rec, req)
+
+	s.Equal(http.StatusBadRequest, rec.Code)
+}
+
+func (s *sessionSuite) TestSession_RevokeSession() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+
+	err := s.LoadFixtures("../test/fixtures/token")
+	s.Require().NoError(err)
+
+	cfg := s.setupConfig()
+	userID := uuid.FromStringOrNil("b5dd5267-b462-48be-b70d-bcd6f1bbe7a5")
+	sessionModel := s.createSession(userID)
+	otherSession := s.createSession(userID)
+
+	rec := s.revoke(cfg, s.revocationToken(cfg, userID, sessionModel.ID))
+
+	s.Equal(http.StatusOK, rec.Code)
+	s.JSONEq(`{"session_revoked": true}`, rec.Body.String())
+
+	stored, err := s.Storage.GetSessionPersister().Get(sessionModel.ID)
+	s.NoError(err)
+	s.Nil(stored)
+
+	stored, err = s.Storage.GetSessionPersister().Get(otherSession.ID)
+	s.NoError(err)
+	s.NotNil(stored)
+}
+
+func (s *sessionSuite) TestSession_RevokeSession_InvalidToken() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+
+	rec := s.revoke(s.setupConfig(), "invalid")
+
+	s.Equal(http.StatusBadRequest, rec.Code)
+}
+
+func (s *sessionSuite) TestSession_RevokeSession_OtherUser() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+
+	err := s.LoadFixtures("../test/fixtures/token")
+	s.Require().NoError(err)
+
+	cfg := s.setupConfig()
+	userID := uuid.FromStringOrNil("b5dd5267-b462-48be-b70d-bcd6f1bbe7a5")
+	sessionModel := s.createSession(userID)
+
+	rec := s.revoke(cfg, s.revocationToken(cfg, uuid.Must(uuid.NewV4()), sessionModel.ID))
+
+	s.Equal(http.StatusOK, rec.Code)
+
+	stored, err := s.Storage.GetSessionPersister().Get(sessionModel.ID)
+	s.NoError(err)
+	s.NotNil(stored)
+}
+
+func (s *sessionSuite) TestSession_RevokeSession_RedirectURL() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+
+	err := s.LoadFixtures("../test/fixtures/token")
+	s.Require().NoError(err)
+
+	cfg := s.setupConfig()
+	cfg.SecurityNotifications.NewDeviceLogin.RevocationRedirectURL = "https://example.com/revoked"
+	userID := uuid.FromStringOrNil("b5dd5267-b462-48be-b70d-bcd6f1bbe7a5")
+	sessionModel := s.createSession(userID)
+
+	rec := s.revoke(cfg, s.revocationToken(cfg, userID, sessionModel.ID))
+
+	s.Equal(http.StatusSeeOther, rec.Code)
+	s.Equal("https://example.com/revoked?session_revoked=true", rec.Header().Get("Location"))
+}
@@ -11,9 +11,11 @@ import (
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/persistence/models"
 	"github.com/teamhanko/hanko/backend/session"
+	"github.com/teamhanko/hanko/backend/telemetry"
 	"github.com/teamhanko/hanko/backend/thirdparty"
 	"github.com/teamhanko/hanko/backend/utils"
 	webhookUtils "github.com/teamhanko/hanko/backend/webhooks/utils"
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/oauth2"
 	"net/http"
 	"net/url"
@@ -133,12 +135,16 @@ func (h *ThirdPartyHandler) Callback(c echo.Context) error {
 			return thirdparty.ErrorInvalidRequest("auth code missing from request")
 		}
 
+		_, span := telemetry.StartSpan(c.Request().Context(), "oauth.exchange_token", attribute.String("oauth.provider", provider.Name()))
 		oAuthToken, terr := provider.GetOAuthToken(callback.AuthCode)
+		telemetry.EndSpan(span, terr)
 		if terr != nil {
 			return thirdparty.ErrorInvalidRequest("could not exchange authorization code for access token").WithCause(terr)
 		}
 
+		_, span = telemetry.StartSpan(c.Request().Context(), "oauth.get_user_data", attribute.String("oauth.provider", provider.Name()))
 		userData, terr := provider.GetUserData(oAuthToken)
+		telemetry.EndSpan(span, terr)
 		if terr != nil {
 			return thirdparty.ErrorInvalidRequest("could not retrieve user data from provider").WithCause(terr)
 		}
@@ -42,7 +42,8 @@ func (s *thirdPartySuite) setUpContext(request *http.Request) (echo.Context, *ht
 
 func (s *thirdPartySuite) setUpHandler(cfg *config.Config) *ThirdPartyHandler {
 	s.T().Helper()
-	auditLogger := auditlog.NewLogger(s.Storage, cfg.AuditLog)
+	auditLogger, err := auditlog.NewLogger(s.Storage, cfg.AuditLog)
+	s.Require().NoError(err)
 
 	jwkMngr, err := jwk.NewDefaultManager(cfg.Secrets.Keys, s.Storage.GetJwkPersister())
 	s.Require().NoError(err)
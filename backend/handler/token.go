@@ -10,10 +10,12 @@ import (
 	auditlog "github.com/teamhanko/hanko/backend/audit_log"
 	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/dto/webhook"
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/persistence/models"
 	rateLimit "github.com/teamhanko/hanko/backend/rate_limiter"
 	"github.com/teamhanko/hanko/backend/session"
+	webhookUtils "github.com/teamhanko/hanko/backend/webhooks/utils"
 	"net/http"
 	"time"
 )
@@ -92,7 +94,7 @@ func (h TokenHandler) Validate(c echo.Context) error {
 			emailJwt = dto.JwtFromEmailModel(e)
 		}
 
-		jwtToken, _, err := h.sessionManager.GenerateJWT(token.UserID, emailJwt)
+		jwtToken, rawToken, err := h.sessionManager.GenerateJWT(token.UserID, emailJwt)
 		if err != nil {
 			return fmt.Errorf("failed to generate jwt: %w", err)
 		}
@@ -112,6 +114,12 @@ func (h TokenHandler) Validate(c echo.Context) error {
 
 		userID = token.UserID
 
+		if token.UserCreated {
+			webhookUtils.NotifySessionCreate(c, tx, rawToken)
+		} else {
+			webhookUtils.NotifyLoginSession(c, tx, h.persister, rawToken, webhook.LoginMethodThirdParty)
+		}
+
 		return nil
 	})
 
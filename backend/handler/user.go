@@ -98,6 +98,7 @@ func (h *UserHandler) Create(c echo.Context) error {
 			}
 		}
 
+		var sessionToken jwt.Token
 		if !h.cfg.Email.RequireVerification {
 			primaryEmail := models.NewPrimaryEmail(email.ID, newUser.ID)
 			err = h.persister.GetPrimaryEmailPersisterWithConnection(tx).Create(*primaryEmail)
@@ -115,11 +116,12 @@ func (h *UserHandler) Create(c echo.Context) error {
 				emailJwt = dto.JwtFromEmailModel(e)
 			}
 
-			token, _, err := h.sessionManager.GenerateJWT(newUser.ID, emailJwt)
+			token, rawToken, err := h.sessionManager.GenerateJWT(newUser.ID, emailJwt)
 
 			if err != nil {
 				return fmt.Errorf("failed to generate jwt: %w", err)
 			}
+			sessionToken = rawToken
 
 			cookie, err := h.sessionManager.GenerateCookie(token)
 			if err != nil {
@@ -162,6 +164,8 @@ func (h *UserHandler) Create(c echo.Context) error {
 			if err != nil {
 				c.Logger().Warn(err)
 			}
+
+			utils.NotifySessionCreate(c, tx, sessionToken)
 		}
 
 		return c.JSON(http.StatusOK, newUserDto)
@@ -326,7 +330,18 @@ func (h *UserHandler) Logout(c echo.Context) error {
 			if err != nil {
 				return fmt.Errorf("failed to delete session from database: %w", err)
 			}
+		} else {
+			// server side sessions are disabled, the session only exists as token
+			expiresAt := sessionToken.Expiration()
+			sessionModel = &models.Session{
+				ID:        sessionID,
+				UserID:    userId,
+				CreatedAt: sessionToken.IssuedAt(),
+				ExpiresAt: &expiresAt,
+			}
 		}
+
+		utils.NotifySession(c, h.persister.GetConnection(), events.SessionRevoke, *sessionModel)
 	}
 
 	err = h.auditLogger.Create(c, models.AuditLogUserLoggedOut, user, nil)
@@ -186,7 +186,7 @@ func (h *UserHandlerAdmin) Create(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "only one primary email is allowed")
 	}
 
-	err := h.persister.GetConnection().Transaction(func(tx *pop.Connection) error {
+	err := h.persister.Transaction(func(tx *pop.Connection) error {
 		u := models.User{
 			ID:        body.ID,
 			CreatedAt: body.CreatedAt,
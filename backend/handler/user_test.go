@@ -34,7 +34,7 @@ func (s *userSuite) TestUserHandler_Create_TokenInCookie() {
 	}
 
 	cfg := test.DefaultConfig
-	e := NewPublicRouter(&cfg, s.Storage, nil, nil)
+	e := NewPublicRouter(&cfg, s.Storage, nil, nil, nil)
 
 	body := UserCreateBody{Email: "jane.doe@example.com"}
 	bodyJson, err := json.Marshal(body)
@@ -79,7 +79,7 @@ func (s *userSuite) TestUserHandler_Create_TokenInHeader() {
 
 	cfg := test.DefaultConfig
 	cfg.Session.EnableAuthTokenHeader = true
-	e := NewPublicRouter(&cfg, s.Storage, nil, nil)
+	e := NewPublicRouter(&cfg, s.Storage, nil, nil, nil)
 
 	body := UserCreateBody{Email: "jane.doe@example.com"}
 	bodyJson, err := json.Marshal(body)
@@ -119,7 +119,7 @@ func (s *userSuite) TestUserHandler_Create_CaseInsensitive() {
 	if testing.Short() {
 		s.T().Skip("skipping test in short mode.")
 	}
-	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil)
+	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil, nil)
 
 	body := UserCreateBody{Email: "JANE.DOE@EXAMPLE.COM"}
 	bodyJson, err := json.Marshal(body)
@@ -154,7 +154,7 @@ func (s *userSuite) TestUserHandler_Create_UserExists() {
 	err := s.LoadFixtures("../test/fixtures/user")
 	s.Require().NoError(err)
 
-	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil)
+	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil, nil)
 
 	body := UserCreateBody{Email: "john.doe@example.com"}
 	bodyJson, err := json.Marshal(body)
@@ -176,7 +176,7 @@ func (s *userSuite) TestUserHandler_Create_UserExists_CaseInsensitive() {
 	err := s.LoadFixtures("../test/fixtures/user")
 	s.Require().NoError(err)
 
-	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil)
+	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil, nil)
 
 	body := UserCreateBody{Email: "JOHN.DOE@EXAMPLE.COM"}
 	bodyJson, err := json.Marshal(body)
@@ -195,7 +195,7 @@ func (s *userSuite) TestUserHandler_Create_InvalidEmail() {
 	if testing.Short() {
 		s.T().Skip("skipping test in short mode.")
 	}
-	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil)
+	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil, nil)
 
 	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"email": 123"}`))
 	req.Header.Set("Content-Type", "application/json")
@@ -210,7 +210,7 @@ func (s *userSuite) TestUserHandler_Create_EmailMissing() {
 	if testing.Short() {
 		s.T().Skip("skipping test in short mode.")
 	}
-	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil)
+	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil, nil)
 
 	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"bogus": 123}`))
 	req.Header.Set("Content-Type", "application/json")
@@ -227,7 +227,7 @@ func (s *userSuite) TestUserHandler_Create_AccountCreationDisabled() {
 	}
 	testConfig := test.DefaultConfig
 	testConfig.Account.AllowSignup = false
-	e := NewPublicRouter(&testConfig, s.Storage, nil, nil)
+	e := NewPublicRouter(&testConfig, s.Storage, nil, nil, nil)
 
 	body := UserCreateBody{Email: "jane.doe@example.com"}
 	bodyJson, err := json.Marshal(body)
@@ -251,7 +251,7 @@ func (s *userSuite) TestUserHandler_Get() {
 
 	userId := "b5dd5267-b462-48be-b70d-bcd6f1bbe7a5"
 
-	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil)
+	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil, nil)
 
 	jwkManager, err := jwk.NewDefaultManager(test.DefaultConfig.Secrets.Keys, s.Storage.GetJwkPersister())
 	if err != nil {
@@ -291,7 +291,7 @@ func (s *userSuite) TestUserHandler_GetUserWithWebAuthnCredential() {
 
 	userId := "b5dd5267-b462-48be-b70d-bcd6f1bbe7a5"
 
-	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil)
+	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil, nil)
 
 	jwkManager, err := jwk.NewDefaultManager(test.DefaultConfig.Secrets.Keys, s.Storage.GetJwkPersister())
 	if err != nil {
@@ -326,7 +326,7 @@ func (s *userSuite) TestUserHandler_Get_InvalidUserId() {
 	if testing.Short() {
 		s.T().Skip("skipping test in short mode.")
 	}
-	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil)
+	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil, nil)
 
 	userId := "b5dd5267-b462-48be-b70d-bcd6f1bbe7a5"
 
@@ -357,7 +357,7 @@ func (s *userSuite) TestUserHandler_GetUserIdByEmail_InvalidEmail() {
 	if testing.Short() {
 		s.T().Skip("skipping test in short mode.")
 	}
-	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil)
+	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil, nil)
 
 	req := httptest.NewRequest(http.MethodPost, "/user", strings.NewReader(`{"email": "123"}`))
 	req.Header.Set("Content-Type", "application/json")
@@ -372,7 +372,7 @@ func (s *userSuite) TestUserHandler_GetUserIdByEmail_InvalidJson() {
 	if testing.Short() {
 		s.T().Skip("skipping test in short mode.")
 	}
-	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil)
+	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil, nil)
 
 	req := httptest.NewRequest(http.MethodPost, "/user", strings.NewReader(`"email": "123}`))
 	req.Header.Set("Content-Type", "application/json")
@@ -387,7 +387,7 @@ func (s *userSuite) TestUserHandler_GetUserIdByEmail_UserNotFound() {
 	if testing.Short() {
 		s.T().Skip("skipping test in short mode.")
 	}
-	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil)
+	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil, nil)
 
 	req := httptest.NewRequest(http.MethodPost, "/user", strings.NewReader(`{"email": "unknownAddress@example.com"}`))
 	req.Header.Set("Content-Type", "application/json")
@@ -407,7 +407,7 @@ func (s *userSuite) TestUserHandler_GetUserIdByEmail() {
 
 	userId := "b5dd5267-b462-48be-b70d-bcd6f1bbe7a5"
 
-	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil)
+	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil, nil)
 
 	req := httptest.NewRequest(http.MethodPost, "/user", strings.NewReader(`{"email": "john.doe@example.com"}`))
 	req.Header.Set("Content-Type", "application/json")
@@ -436,7 +436,7 @@ func (s *userSuite) TestUserHandler_GetUserIdByEmail_CaseInsensitive() {
 
 	userId := "b5dd5267-b462-48be-b70d-bcd6f1bbe7a5"
 
-	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil)
+	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil, nil)
 
 	req := httptest.NewRequest(http.MethodPost, "/user", strings.NewReader(`{"email": "JOHN.DOE@EXAMPLE.COM"}`))
 	req.Header.Set("Content-Type", "application/json")
@@ -465,7 +465,7 @@ func (s *userSuite) TestUserHandler_Me() {
 
 	userId := "b5dd5267-b462-48be-b70d-bcd6f1bbe7a5"
 
-	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil)
+	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil, nil)
 
 	jwkManager, err := jwk.NewDefaultManager(test.DefaultConfig.Secrets.Keys, s.Storage.GetJwkPersister())
 	if err != nil {
@@ -502,7 +502,7 @@ func (s *userSuite) TestUserHandler_Logout() {
 		s.T().Skip("skipping test in short mode.")
 	}
 	userId, _ := uuid.NewV4()
-	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil)
+	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil, nil)
 
 	jwkManager, err := jwk.NewDefaultManager(test.DefaultConfig.Secrets.Keys, s.Storage.GetJwkPersister())
 	if err != nil {
@@ -543,7 +543,7 @@ func (s *userSuite) TestUserHandler_Delete() {
 	userId, _ := uuid.FromString("b5dd5267-b462-48be-b70d-bcd6f1bbe7a5")
 	cfg := test.DefaultConfig
 	cfg.Account.AllowDeletion = true
-	e := NewPublicRouter(&cfg, s.Storage, nil, nil)
+	e := NewPublicRouter(&cfg, s.Storage, nil, nil, nil)
 
 	jwkManager, err := jwk.NewDefaultManager(test.DefaultConfig.Secrets.Keys, s.Storage.GetJwkPersister())
 	if err != nil {
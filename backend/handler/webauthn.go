@@ -14,22 +14,28 @@ import (
 	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/dto"
 	"github.com/teamhanko/hanko/backend/dto/intern"
+	"github.com/teamhanko/hanko/backend/dto/webhook"
+	"github.com/teamhanko/hanko/backend/flow_api/flow/shared"
+	"github.com/teamhanko/hanko/backend/flow_api/services"
 	"github.com/teamhanko/hanko/backend/mapper"
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/persistence/models"
 	"github.com/teamhanko/hanko/backend/session"
+	"github.com/teamhanko/hanko/backend/webhooks/events"
+	webhookUtils "github.com/teamhanko/hanko/backend/webhooks/utils"
 	"net/http"
 	"strings"
 	"time"
 )
 
 type WebauthnHandler struct {
-	persister             persistence.Persister
-	webauthn              *webauthn.WebAuthn
-	sessionManager        session.Manager
-	cfg                   *config.Config
-	auditLogger           auditlog.Logger
-	authenticatorMetadata mapper.AuthenticatorMetadata
+	persister                   persistence.Persister
+	webauthn                    *webauthn.WebAuthn
+	sessionManager              session.Manager
+	cfg                         *config.Config
+	auditLogger                 auditlog.Logger
+	authenticatorMetadata       mapper.AuthenticatorMetadata
+	securityNotificationService services.SecurityNotification
 }
 
 const (
@@ -44,7 +50,7 @@ const (
 )
 
 // NewWebauthnHandler creates a new handler which handles all webauthn related routes
-func NewWebauthnHandler(cfg *config.Config, persister persistence.Persister, sessionManager session.Manager, auditLogger auditlog.Logger, authenticatorMetadata mapper.AuthenticatorMetadata) (*WebauthnHandler, error) {
+func NewWebauthnHandler(cfg *config.Config, persister persistence.Persister, sessionManager session.Manager, auditLogger auditlog.Logger, authenticatorMetadata mapper.AuthenticatorMetadata, securityNotificationService services.SecurityNotification) (*WebauthnHandler, error) {
 	f := false
 	wa, err := webauthn.New(&webauthn.Config{
 		RPDisplayName:         cfg.Webauthn.RelyingParty.DisplayName,
@@ -74,12 +80,13 @@ func NewWebauthnHandler(cfg *config.Config, persister persistence.Persister, ses
 	}
 
 	return &WebauthnHandler{
-		persister:             persister,
-		webauthn:              wa,
-		sessionManager:        sessionManager,
-		cfg:                   cfg,
-		auditLogger:           auditLogger,
-		authenticatorMetadata: authenticatorMetadata,
+		persister:                   persister,
+		webauthn:                    wa,
+		sessionManager:              sessionManager,
+		cfg:                         cfg,
+		auditLogger:                 auditLogger,
+		authenticatorMetadata:       authenticatorMetadata,
+		securityNotificationService: securityNotificationService,
 	}, nil
 }
 
@@ -227,6 +234,15 @@ func (h *WebauthnHandler) FinishRegistration(c echo.Context) error {
 			return fmt.Errorf(CreateAuditLogFailureMessage, err)
 		}
 
+		if h.cfg.SecurityNotifications.PasskeyCreated.Enabled {
+			sendSecurityNotification(c, tx, h.persister, *h.cfg, h.securityNotificationService, user.ID, webhook.EmailTypePasskeyCreated, webhook.SecurityNotificationData{
+				PasskeyID:   model.ID,
+				PasskeyName: shared.PasskeyDisplayName(*model),
+			})
+		}
+
+		webhookUtils.NotifyUserChange(c, tx, h.persister, events.UserPasskeyCreate, user.ID)
+
 		return c.JSON(http.StatusOK, map[string]string{"credential_id": model.ID, "user_id": webauthnUser.UserId.String()})
 	})
 }
@@ -331,6 +347,7 @@ func (h *WebauthnHandler) FinishAuthentication(c echo.Context) error {
 			if err != nil {
 				return fmt.Errorf(CreateAuditLogFailureMessage, err)
 			}
+			webhookUtils.NotifyLoginFailed(c, h.persister.GetConnection(), nil, webhook.LoginMethodPasskey, "passkey_invalid")
 			return echo.NewHTTPError(http.StatusUnauthorized, StoredChallengeMismatchMessage).SetInternal(errors.New("sessionData not found"))
 		}
 
@@ -355,6 +372,7 @@ func (h *WebauthnHandler) FinishAuthentication(c echo.Context) error {
 				if err != nil {
 					return fmt.Errorf(CreateAuditLogFailureMessage, err)
 				}
+				webhookUtils.NotifyLoginFailed(c, h.persister.GetConnection(), nil, webhook.LoginMethodPasskey, "unknown_user")
 				return echo.NewHTTPError(http.StatusUnauthorized).SetInternal(errors.New(UserNotFoundMessage))
 			}
 
@@ -366,6 +384,7 @@ func (h *WebauthnHandler) FinishAuthentication(c echo.Context) error {
 				if logErr != nil {
 					return fmt.Errorf(CreateAuditLogFailureMessage, err)
 				}
+				webhookUtils.NotifyLoginFailed(c, h.persister.GetConnection(), &user.ID, webhook.LoginMethodPasskey, "passkey_invalid")
 				return echo.NewHTTPError(http.StatusUnauthorized, "failed to validate assertion").SetInternal(err)
 			}
 		} else {
@@ -379,6 +398,7 @@ func (h *WebauthnHandler) FinishAuthentication(c echo.Context) error {
 				if err != nil {
 					return fmt.Errorf(CreateAuditLogFailureMessage, err)
 				}
+				webhookUtils.NotifyLoginFailed(c, h.persister.GetConnection(), nil, webhook.LoginMethodPasskey, "unknown_user")
 				return echo.NewHTTPError(http.StatusUnauthorized).SetInternal(errors.New(UserNotFoundMessage))
 			}
 			credential, err = h.webauthn.ValidateLogin(webauthnUser, *model, request)
@@ -387,6 +407,7 @@ func (h *WebauthnHandler) FinishAuthentication(c echo.Context) error {
 				if logErr != nil {
 					return fmt.Errorf(CreateAuditLogFailureMessage, err)
 				}
+				webhookUtils.NotifyLoginFailed(c, h.persister.GetConnection(), &user.ID, webhook.LoginMethodPasskey, "passkey_invalid")
 				return echo.NewHTTPError(http.StatusUnauthorized, "failed to validate assertion").SetInternal(err)
 			}
 		}
@@ -423,7 +444,7 @@ func (h *WebauthnHandler) FinishAuthentication(c echo.Context) error {
 			emailJwt = dto.JwtFromEmailModel(e)
 		}
 
-		token, _, err := h.sessionManager.GenerateJWT(webauthnUser.UserId, emailJwt)
+		token, rawToken, err := h.sessionManager.GenerateJWT(webauthnUser.UserId, emailJwt)
 		if err != nil {
 			return fmt.Errorf("failed to generate jwt: %w", err)
 		}
@@ -446,6 +467,8 @@ func (h *WebauthnHandler) FinishAuthentication(c echo.Context) error {
 			return fmt.Errorf(CreateAuditLogFailureMessage, err)
 		}
 
+		webhookUtils.NotifyLoginSession(c, tx, h.persister, rawToken, webhook.LoginMethodPasskey)
+
 		return c.JSON(http.StatusOK, map[string]string{"credential_id": base64.RawURLEncoding.EncodeToString(credential.ID), "user_id": webauthnUser.UserId.String()})
 	})
 }
@@ -564,6 +587,15 @@ func (h *WebauthnHandler) DeleteCredential(c echo.Context) error {
 			return fmt.Errorf(CreateAuditLogFailureMessage, err)
 		}
 
+		if h.cfg.SecurityNotifications.PasskeyDeleted.Enabled {
+			sendSecurityNotification(c, tx, h.persister, *h.cfg, h.securityNotificationService, user.ID, webhook.EmailTypePasskeyDeleted, webhook.SecurityNotificationData{
+				PasskeyID:   credential.ID,
+				PasskeyName: shared.PasskeyDisplayName(*credential),
+			})
+		}
+
+		webhookUtils.NotifyUserChange(c, tx, h.persister, events.UserPasskeyDelete, user.ID)
+
 		return c.NoContent(http.StatusNoContent)
 	})
 }
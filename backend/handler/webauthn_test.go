@@ -32,7 +32,7 @@ func (s *webauthnSuite) TestWebauthnHandler_NewHandler() {
 	if testing.Short() {
 		s.T().Skip("skipping test in short mode")
 	}
-	handler, err := NewWebauthnHandler(&test.DefaultConfig, s.Storage, s.GetDefaultSessionManager(), test.NewAuditLogger(), nil)
+	handler, err := NewWebauthnHandler(&test.DefaultConfig, s.Storage, s.GetDefaultSessionManager(), test.NewAuditLogger(), nil, nil)
 	s.NoError(err)
 	s.NotEmpty(handler)
 }
@@ -47,7 +47,7 @@ func (s *webauthnSuite) TestWebauthnHandler_BeginRegistration() {
 
 	userId := "ec4ef049-5b88-4321-a173-21b0eff06a04"
 
-	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil)
+	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil, nil)
 
 	sessionManager := s.GetDefaultSessionManager()
 	token, _, err := sessionManager.GenerateJWT(uuid.FromStringOrNil(userId), nil)
@@ -88,7 +88,7 @@ func (s *webauthnSuite) TestWebauthnHandler_FinalizeRegistration() {
 
 	userId := "ec4ef049-5b88-4321-a173-21b0eff06a04"
 
-	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil)
+	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil, nil)
 
 	sessionManager := s.GetDefaultSessionManager()
 	token, _, err := sessionManager.GenerateJWT(uuid.FromStringOrNil(userId), nil)
@@ -134,7 +134,7 @@ func (s *webauthnSuite) TestWebauthnHandler_FinalizeRegistration_SessionDataExpi
 
 	userId := "ec4ef049-5b88-4321-a173-21b0eff06a04"
 
-	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil)
+	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil, nil)
 
 	sessionManager := s.GetDefaultSessionManager()
 	token, _, err := sessionManager.GenerateJWT(uuid.FromStringOrNil(userId), nil)
@@ -169,7 +169,7 @@ func (s *webauthnSuite) TestWebauthnHandler_BeginAuthentication() {
 	err := s.LoadFixtures("../test/fixtures/webauthn")
 	s.Require().NoError(err)
 
-	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil)
+	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil, nil)
 	req := httptest.NewRequest(http.MethodPost, "/webauthn/login/initialize", nil)
 	rec := httptest.NewRecorder()
 
@@ -193,7 +193,7 @@ func (s *webauthnSuite) TestWebauthnHandler_FinalizeAuthentication() {
 	err := s.LoadFixtures("../test/fixtures/webauthn")
 	s.Require().NoError(err)
 
-	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil)
+	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil, nil)
 
 	body := `{
 "id": "AaFdkcD4SuPjF-jwUoRwH8-ZHuY5RW46fsZmEvBX6RNKHaGtVzpATs06KQVheIOjYz-YneG4cmQOedzl0e0jF951ukx17Hl9jeGgWz5_DKZCO12p2-2LlzjH",
@@ -246,7 +246,7 @@ func (s *webauthnSuite) TestWebauthnHandler_FinalizeAuthentication_SessionDataEx
 	err := s.LoadFixtures("../test/fixtures/webauthn")
 	s.Require().NoError(err)
 
-	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil)
+	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil, nil, nil)
 
 	body := `{
 "id": "4iVZGFN_jktXJmwmBmaSq0Qr4T62T0jX7PS7XcgAWlM",
@@ -278,7 +278,7 @@ func (s *webauthnSuite) TestWebauthnHandler_FinalizeAuthentication_TokenInHeader
 
 	cfg := test.DefaultConfig
 	cfg.Session.EnableAuthTokenHeader = true
-	e := NewPublicRouter(&cfg, s.Storage, nil, nil)
+	e := NewPublicRouter(&cfg, s.Storage, nil, nil, nil)
 
 	body := `{
 "id": "AaFdkcD4SuPjF-jwUoRwH8-ZHuY5RW46fsZmEvBX6RNKHaGtVzpATs06KQVheIOjYz-YneG4cmQOedzl0e0jF951ukx17Hl9jeGgWz5_DKZCO12p2-2LlzjH",
@@ -332,7 +332,7 @@ var userId = "ec4ef049-5b88-4321-a173-21b0eff06a04"
 type sessionManager struct {
 }
 
-func (s sessionManager) GenerateJWT(_ uuid.UUID, _ *dto.EmailJwt) (string, jwt.Token, error) {
+func (s sessionManager) GenerateJWT(_ uuid.UUID, _ *dto.EmailJwt, _ ...session.JWTOption) (string, jwt.Token, error) {
 	return userId, nil, nil
 }
 
@@ -6,12 +6,16 @@ import (
 	"github.com/gofrs/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/crypto/jwk"
 	"github.com/teamhanko/hanko/backend/dto/admin"
+	"github.com/teamhanko/hanko/backend/pagination"
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/persistence/models"
 	"github.com/teamhanko/hanko/backend/webhooks"
 	"github.com/teamhanko/hanko/backend/webhooks/events"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 )
 
@@ -21,6 +25,10 @@ type WebhookHandler interface {
 	Get(ctx echo.Context) error
 	Delete(ctx echo.Context) error
 	Update(ctx echo.Context) error
+	RotateSecret(ctx echo.Context) error
+	ListDeliveries(ctx echo.Context) error
+	Redeliver(ctx echo.Context) error
+	Test(ctx echo.Context) error
 }
 
 const (
@@ -28,14 +36,16 @@ const (
 )
 
 type webhookHandler struct {
-	cfg       config.WebhookSettings
-	persister persistence.Persister
+	cfg        *config.Config
+	persister  persistence.Persister
+	jwkManager jwk.Manager
 }
 
-func NewWebhookHandler(cfg config.WebhookSettings, persister persistence.Persister) WebhookHandler {
+func NewWebhookHandler(cfg *config.Config, persister persistence.Persister, jwkManager jwk.Manager) WebhookHandler {
 	return &webhookHandler{
-		cfg:       cfg,
-		persister: persister,
+		cfg:        cfg,
+		persister:  persister,
+		jwkManager: jwkManager,
 	}
 }
 
@@ -47,9 +57,16 @@ func (w *webhookHandler) List(ctx echo.Context) error {
 		return fmt.Errorf("failed to list users: %w", err)
 	}
 
+	// never hand out the secrets of config hooks
+	configHooks := make(config.Webhooks, len(w.cfg.Webhooks.Hooks))
+	for i, hook := range w.cfg.Webhooks.Hooks {
+		hook.Secret = ""
+		configHooks[i] = hook
+	}
+
 	listDto := admin.WebhookListResponseDto{
 		Database: dbHooks,
-		Config:   w.cfg.Hooks,
+		Config:   configHooks,
 	}
 
 	return ctx.JSON(http.StatusOK, listDto)
@@ -71,21 +88,40 @@ func (w *webhookHandler) Create(ctx echo.Context) error {
 
 	now := time.Now()
 
+	format := dto.Format
+	if format == "" {
+		format = string(config.WebhookFormatHankoJWT)
+	}
+
 	newUuid, err := uuid.NewV4()
 	if err != nil {
 		ctx.Logger().Error(err)
 		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Errorf(uuidErrorFormat, err))
 	}
 
+	secret, err := webhooks.GenerateSecret()
+	if err != nil {
+		ctx.Logger().Error(err)
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+
+	encryptedSecret, err := models.EncryptWebhookSecret(secret, w.cfg.Secrets.Keys)
+	if err != nil {
+		ctx.Logger().Error(err)
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+
 	model := models.Webhook{
 		ID:            newUuid,
 		Callback:      dto.Callback,
 		Enabled:       true,
+		Format:        format,
 		Failures:      0,
 		ExpiresAt:     now.Add(webhooks.WebhookExpireDuration), // 30 Days from now
 		WebhookEvents: nil,
 		CreatedAt:     now,
 		UpdatedAt:     now,
+		Secret:        encryptedSecret,
 	}
 
 	dbEvents, err := w.createWebhookEvents(dto.Events, model, now)
@@ -103,7 +139,10 @@ func (w *webhookHandler) Create(ctx echo.Context) error {
 
 	model.WebhookEvents = dbEvents
 
-	return ctx.JSON(http.StatusCreated, model)
+	return ctx.JSON(http.StatusCreated, admin.WebhookWithSecretResponseDto{
+		Webhook: model,
+		Secret:  secret,
+	})
 }
 
 func (w *webhookHandler) createWebhookEvents(evts events.Events, webhook models.Webhook, now time.Time) (models.WebhookEvents, error) {
@@ -228,6 +267,14 @@ func (w *webhookHandler) Update(ctx echo.Context) error {
 		webhook.Callback = dto.Callback
 		webhook.UpdatedAt = now
 		webhook.Enabled = dto.Enabled
+		if dto.Format != "" {
+			// cloudevents requests are signed with the secret of the webhook, which webhooks created before secrets
+			// were introduced do not have
+			if dto.Format == string(config.WebhookFormatCloudEvents) && (webhook.Secret == nil || *webhook.Secret == "") {
+				return echo.NewHTTPError(http.StatusBadRequest, "the webhook has no signing secret, rotate the secret before switching to the cloudevents format")
+			}
+			webhook.Format = dto.Format
+		}
 		webhook.Failures = 0
 		webhook.ExpiresAt = now.Add(webhooks.WebhookExpireDuration)
 
@@ -241,6 +288,227 @@ func (w *webhookHandler) Update(ctx echo.Context) error {
 	})
 }
 
+// RotateSecret generates a new signing secret for the webhook. The previous secret is still used for signing for
+// the webhooks.SecretRotationGracePeriod, so receivers can switch to the new secret without missing any requests.
+func (w *webhookHandler) RotateSecret(ctx echo.Context) error {
+	var dto admin.GetWebhookRequestDto
+	err := ctx.Bind(&dto)
+	if err != nil {
+		ctx.Logger().Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+
+	err = ctx.Validate(dto)
+	if err != nil {
+		ctx.Logger().Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+
+	return w.persister.Transaction(func(tx *pop.Connection) error {
+		persister := w.persister.GetWebhookPersister(tx)
+
+		webhookId, _ := uuid.FromString(dto.ID)
+		webhook, err := w.getWebhook(webhookId, persister)
+		if err != nil {
+			ctx.Logger().Error(err)
+			return err
+		}
+
+		secret, err := webhooks.GenerateSecret()
+		if err != nil {
+			ctx.Logger().Error(err)
+			return echo.NewHTTPError(http.StatusInternalServerError, err)
+		}
+
+		encryptedSecret, err := models.EncryptWebhookSecret(secret, w.cfg.Secrets.Keys)
+		if err != nil {
+			ctx.Logger().Error(err)
+			return echo.NewHTTPError(http.StatusInternalServerError, err)
+		}
+
+		now := time.Now()
+		if webhook.Secret != nil {
+			previousSecretExpiresAt := now.Add(webhooks.SecretRotationGracePeriod)
+			webhook.PreviousSecret = webhook.Secret
+			webhook.PreviousSecretExpiresAt = &previousSecretExpiresAt
+		}
+		webhook.Secret = encryptedSecret
+		webhook.UpdatedAt = now
+
+		err = persister.Update(*webhook)
+		if err != nil {
+			ctx.Logger().Error(err)
+			return fmt.Errorf("unable to update webhook: %w", err)
+		}
+
+		return ctx.JSON(http.StatusOK, admin.WebhookWithSecretResponseDto{
+			Webhook: *webhook,
+			Secret:  secret,
+		})
+	})
+}
+
+func (w *webhookHandler) ListDeliveries(ctx echo.Context) error {
+	var dto admin.ListWebhookDeliveriesRequestDto
+	err := ctx.Bind(&dto)
+	if err != nil {
+		ctx.Logger().Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+
+	err = ctx.Validate(dto)
+	if err != nil {
+		ctx.Logger().Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+
+	if dto.Page == 0 {
+		dto.Page = 1
+	}
+
+	if dto.PerPage == 0 {
+		dto.PerPage = 20
+	}
+
+	webhookId, _ := uuid.FromString(dto.ID)
+	_, err = w.getWebhook(webhookId, w.persister.GetWebhookPersister(nil))
+	if err != nil {
+		ctx.Logger().Error(err)
+		return err
+	}
+
+	deliveryPersister := w.persister.GetWebhookDeliveryPersister(nil)
+	deliveries, err := deliveryPersister.List(webhookId, dto.Page, dto.PerPage)
+	if err != nil {
+		return fmt.Errorf("failed to get list of webhook deliveries: %w", err)
+	}
+
+	deliveryCount, err := deliveryPersister.Count(webhookId)
+	if err != nil {
+		return fmt.Errorf("failed to get total count of webhook deliveries: %w", err)
+	}
+
+	u, _ := url.Parse(fmt.Sprintf("%s://%s%s", ctx.Scheme(), ctx.Request().Host, ctx.Request().RequestURI))
+
+	ctx.Response().Header().Set("Link", pagination.CreateHeader(u, deliveryCount, dto.Page, dto.PerPage))
+	ctx.Response().Header().Set("X-Total-Count", strconv.FormatInt(int64(deliveryCount), 10))
+
+	return ctx.JSON(http.StatusOK, deliveries)
+}
+
+// Redeliver queues the message of the given delivery for another delivery to the webhook. The message keeps its ID,
+// so receivers can detect duplicates.
+func (w *webhookHandler) Redeliver(ctx echo.Context) error {
+	var dto admin.RedeliverWebhookRequestDto
+	err := ctx.Bind(&dto)
+	if err != nil {
+		ctx.Logger().Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+
+	err = ctx.Validate(dto)
+	if err != nil {
+		ctx.Logger().Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+
+	return w.persister.Transaction(func(tx *pop.Connection) error {
+		webhookId, _ := uuid.FromString(dto.ID)
+		webhook, err := w.getWebhook(webhookId, w.persister.GetWebhookPersister(tx))
+		if err != nil {
+			ctx.Logger().Error(err)
+			return err
+		}
+
+		deliveryId, _ := uuid.FromString(dto.DeliveryID)
+		delivery, err := w.persister.GetWebhookDeliveryPersister(tx).Get(deliveryId)
+		if err != nil {
+			return fmt.Errorf("unable to fetch webhook delivery from database: %w", err)
+		}
+
+		if delivery == nil || delivery.WebhookID == nil || *delivery.WebhookID != webhook.ID {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("unable to find delivery with id: %s", deliveryId))
+		}
+
+		jobPersister := w.persister.GetWebhookJobPersister(tx)
+		var originalJob *models.WebhookJob
+		if delivery.WebhookJobID != nil {
+			originalJob, err = jobPersister.Get(*delivery.WebhookJobID)
+			if err != nil {
+				return fmt.Errorf("unable to fetch webhook job from database: %w", err)
+			}
+		}
+
+		if originalJob == nil {
+			return echo.NewHTTPError(http.StatusGone, "the payload of the delivery is not available anymore")
+		}
+
+		jobId, err := uuid.NewV4()
+		if err != nil {
+			ctx.Logger().Error(err)
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Errorf(uuidErrorFormat, err))
+		}
+
+		now := time.Now()
+		job := models.WebhookJob{
+			ID:            jobId,
+			MessageID:     originalJob.MessageID,
+			WebhookID:     &webhook.ID,
+			Callback:      webhook.Callback,
+			Event:         originalJob.Event,
+			Data:          originalJob.Data,
+			Status:        models.WebhookJobStatusPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+
+		err = jobPersister.Create(job)
+		if err != nil {
+			ctx.Logger().Error(err)
+			return fmt.Errorf("unable to save webhook job: %w", err)
+		}
+
+		return ctx.JSON(http.StatusAccepted, job)
+	})
+}
+
+// Test synchronously sends a synthetic "webhook.test" event to the webhook and returns the recorded delivery.
+func (w *webhookHandler) Test(ctx echo.Context) error {
+	var dto admin.GetWebhookRequestDto
+	err := ctx.Bind(&dto)
+	if err != nil {
+		ctx.Logger().Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+
+	err = ctx.Validate(dto)
+	if err != nil {
+		ctx.Logger().Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+
+	webhookId, _ := uuid.FromString(dto.ID)
+	webhook, err := w.getWebhook(webhookId, w.persister.GetWebhookPersister(nil))
+	if err != nil {
+		ctx.Logger().Error(err)
+		return err
+	}
+
+	manager, err := webhooks.NewManager(w.cfg, w.persister, w.jwkManager, ctx.Logger())
+	if err != nil {
+		return fmt.Errorf("unable to create webhook manager: %w", err)
+	}
+
+	delivery, err := webhooks.SendTest(manager, w.persister, *webhook, webhooks.EventSource(w.cfg), w.cfg.Secrets.Keys, ctx.Logger())
+	if err != nil {
+		ctx.Logger().Error(err)
+		return fmt.Errorf("unable to send test event: %w", err)
+	}
+
+	return ctx.JSON(http.StatusOK, delivery)
+}
+
 func (w *webhookHandler) getWebhook(id uuid.UUID, persister persistence.WebhookPersister) (*models.Webhook, error) {
 	webhook, err := persister.Get(id)
 	if err != nil {
@@ -10,9 +10,11 @@ import (
 	"github.com/teamhanko/hanko/backend/dto/admin"
 	"github.com/teamhanko/hanko/backend/persistence/models"
 	"github.com/teamhanko/hanko/backend/test"
+	"github.com/teamhanko/hanko/backend/webhooks"
 	"github.com/teamhanko/hanko/backend/webhooks/events"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -100,9 +102,15 @@ func (s *webhookSuite) TestWebhookHandler_Create() {
 	s.Equal(testBody.Callback, result.Callback)
 	s.Equal(string(testBody.Events[0]), result.WebhookEvents[0].Event)
 	s.Equal(1, len(result.WebhookEvents))
+	s.Equal(string(config.WebhookFormatHankoJWT), result.Format)
 	s.Require().NotNil(result.ID)
 	s.Require().NotNil(result.WebhookEvents[0].ID)
 
+	secretResult := admin.WebhookWithSecretResponseDto{}
+	err = json.Unmarshal(rec.Body.Bytes(), &secretResult)
+	s.Require().NoError(err)
+	s.True(strings.HasPrefix(secretResult.Secret, webhooks.SecretPrefix))
+
 	err = e.Close()
 	s.Require().NoError(err)
 }
@@ -116,6 +124,7 @@ func (s *webhookSuite) TestWebhookHandler_CreateWithParams() {
 		name           string
 		callback       string
 		events         events.Events
+		format         string
 		expectedStatus int
 	}{
 		{
@@ -124,6 +133,20 @@ func (s *webhookSuite) TestWebhookHandler_CreateWithParams() {
 			events:         events.Events{events.UserDelete},
 			expectedStatus: http.StatusCreated,
 		},
+		{
+			name:           "cloudevents format",
+			callback:       "http://lorem.ipsum",
+			events:         events.Events{events.UserDelete},
+			format:         string(config.WebhookFormatCloudEvents),
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "unknown format",
+			callback:       "http://lorem.ipsum",
+			events:         events.Events{events.UserDelete},
+			format:         "xml",
+			expectedStatus: http.StatusBadRequest,
+		},
 		{
 			name:           "empty callback",
 			callback:       "",
@@ -173,6 +196,7 @@ func (s *webhookSuite) TestWebhookHandler_CreateWithParams() {
 			testBody := admin.CreateWebhookRequestDto{
 				Callback: currentTest.callback,
 				Events:   currentTest.events,
+				Format:   currentTest.format,
 			}
 			testBodyJson, err := json.Marshal(testBody)
 			s.Require().NoError(err)
@@ -608,3 +632,262 @@ func (s *webhookSuite) TestWebhookHandler_UpdateWithParams() {
 		})
 	}
 }
+
+func (s *webhookSuite) TestWebhookHandler_UpdateFormatWithoutSecret() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/webhooks")
+	s.Require().NoError(err)
+
+	e := NewAdminRouter(&test.DefaultConfig, s.Storage, nil)
+
+	// the fixture webhook has been created before webhooks had a secret
+	testId := "8b00da9a-cacf-45ea-b25d-c1ce0f0d7da4"
+	testUuid, err := uuid.FromString(testId)
+	s.Require().NoError(err)
+
+	update := func() int {
+		updateDto := admin.UpdateWebhookRequestDto{
+			GetWebhookRequestDto: admin.GetWebhookRequestDto{
+				ID: testId,
+			},
+			CreateWebhookRequestDto: admin.CreateWebhookRequestDto{
+				Callback: "https://lorem.ipsum.et",
+				Events: events.Events{
+					events.UserDelete,
+				},
+				Format: string(config.WebhookFormatCloudEvents),
+			},
+			Enabled: true,
+		}
+		updateJson, err := json.Marshal(updateDto)
+		s.Require().NoError(err)
+
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/webhooks/%s", testId), bytes.NewReader(updateJson))
+		req.Header.Add("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		return rec.Code
+	}
+
+	s.Equal(http.StatusBadRequest, update())
+
+	dbHook, err := s.Storage.GetWebhookPersister(nil).Get(testUuid)
+	s.Require().NoError(err)
+	s.Equal("http://localhost", dbHook.Callback, "the webhook must not be changed")
+	s.NotEqual(string(config.WebhookFormatCloudEvents), dbHook.Format)
+
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/webhooks/%s/rotate_secret", testId), nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Require().Equal(http.StatusOK, rec.Code)
+
+	s.Equal(http.StatusOK, update())
+
+	dbHook, err = s.Storage.GetWebhookPersister(nil).Get(testUuid)
+	s.Require().NoError(err)
+	s.Equal(string(config.WebhookFormatCloudEvents), dbHook.Format)
+}
+
+func (s *webhookSuite) TestWebhookHandler_RotateSecret() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/webhooks")
+	s.Require().NoError(err)
+
+	e := NewAdminRouter(&test.DefaultConfig, s.Storage, nil)
+
+	testId := "a47fe92a-1e4b-4119-8653-55ad82737c88"
+	testUuid, err := uuid.FromString(testId)
+	s.Require().NoError(err)
+
+	rotate := func() string {
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/webhooks/%s/rotate_secret", testId), nil)
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+		s.Require().Equal(http.StatusOK, rec.Code)
+
+		var result admin.WebhookWithSecretResponseDto
+		err = json.Unmarshal(rec.Body.Bytes(), &result)
+		s.Require().NoError(err)
+		s.Equal(testId, result.ID.String())
+		s.True(strings.HasPrefix(result.Secret, webhooks.SecretPrefix))
+
+		return result.Secret
+	}
+
+	firstSecret := rotate()
+	secondSecret := rotate()
+	s.NotEqual(firstSecret, secondSecret)
+
+	dbHook, err := s.Storage.GetWebhookPersister(nil).Get(testUuid)
+	s.Require().NoError(err)
+	s.NotEqual(secondSecret, *dbHook.Secret, "secrets must be stored encrypted")
+
+	secrets, err := dbHook.SigningSecrets(test.DefaultConfig.Secrets.Keys, time.Now())
+	s.Require().NoError(err)
+	s.Equal([]string{secondSecret, firstSecret}, secrets)
+
+	secrets, err = dbHook.SigningSecrets(test.DefaultConfig.Secrets.Keys, time.Now().Add(webhooks.SecretRotationGracePeriod+time.Minute))
+	s.Require().NoError(err)
+	s.Equal([]string{secondSecret}, secrets)
+}
+
+func (s *webhookSuite) TestWebhookHandler_RotateSecretUnknownWebhook() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/webhooks")
+	s.Require().NoError(err)
+
+	e := NewAdminRouter(&test.DefaultConfig, s.Storage, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/6f6b4b2f-1b2a-4a2e-9d6f-2d6f1f0b3c4d/rotate_secret", nil)
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	s.Equal(http.StatusNotFound, rec.Code)
+}
+
+func (s *webhookSuite) TestWebhookHandler_ListDeliveries() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/webhooks")
+	s.Require().NoError(err)
+
+	e := NewAdminRouter(&test.DefaultConfig, s.Storage, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/webhooks/a47fe92a-1e4b-4119-8653-55ad82737c88/deliveries?per_page=2", nil)
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	s.Require().Equal(http.StatusOK, rec.Code)
+	s.Equal("3", rec.Header().Get("X-Total-Count"))
+
+	var deliveries models.WebhookDeliveries
+	err = json.Unmarshal(rec.Body.Bytes(), &deliveries)
+	s.Require().NoError(err)
+	s.Require().Len(deliveries, 2)
+	s.Equal("5b0f6f0e-2c1d-4f3a-8e4b-7a6c5d4e3f02", deliveries[0].ID.String())
+	s.Equal(200, *deliveries[0].StatusCode)
+	s.Equal("5b0f6f0e-2c1d-4f3a-8e4b-7a6c5d4e3f01", deliveries[1].ID.String())
+	s.Equal("internal server error", *deliveries[1].ResponseBody)
+}
+
+func (s *webhookSuite) TestWebhookHandler_Redeliver() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/webhooks")
+	s.Require().NoError(err)
+
+	e := NewAdminRouter(&test.DefaultConfig, s.Storage, nil)
+
+	tests := []struct {
+		name         string
+		webhookId    string
+		deliveryId   string
+		expectedCode int
+	}{
+		{
+			name:         "success",
+			webhookId:    "a47fe92a-1e4b-4119-8653-55ad82737c88",
+			deliveryId:   "5b0f6f0e-2c1d-4f3a-8e4b-7a6c5d4e3f01",
+			expectedCode: http.StatusAccepted,
+		},
+		{
+			name:         "payload not available anymore",
+			webhookId:    "a47fe92a-1e4b-4119-8653-55ad82737c88",
+			deliveryId:   "5b0f6f0e-2c1d-4f3a-8e4b-7a6c5d4e3f03",
+			expectedCode: http.StatusGone,
+		},
+		{
+			name:         "delivery of another webhook",
+			webhookId:    "279beae1-8a6d-4eaf-a791-1fa79d21d37a",
+			deliveryId:   "5b0f6f0e-2c1d-4f3a-8e4b-7a6c5d4e3f01",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "unknown delivery",
+			webhookId:    "a47fe92a-1e4b-4119-8653-55ad82737c88",
+			deliveryId:   "6f6b4b2f-1b2a-4a2e-9d6f-2d6f1f0b3c4d",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, currentTest := range tests {
+		s.Run(currentTest.name, func() {
+			req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/webhooks/%s/deliveries/%s/redeliver", currentTest.webhookId, currentTest.deliveryId), nil)
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			s.Require().Equal(currentTest.expectedCode, rec.Code)
+
+			if currentTest.expectedCode == http.StatusAccepted {
+				var job models.WebhookJob
+				err = json.Unmarshal(rec.Body.Bytes(), &job)
+				s.Require().NoError(err)
+				s.Equal("6c1e2f3a-4b5d-4e6f-8a7b-9c0d1e2f3a01", job.MessageID.String())
+				s.Equal(models.WebhookJobStatusPending, job.Status)
+
+				persistedJob, err := s.Storage.GetWebhookJobPersister(nil).Get(job.ID)
+				s.Require().NoError(err)
+				s.Require().NotNil(persistedJob)
+				s.Equal(`{"id":"b5dd5267-b462-48be-b70d-bcd6f1bbe7a5"}`, persistedJob.Data)
+			}
+		})
+	}
+}
+
+func (s *webhookSuite) TestWebhookHandler_Test() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/webhooks")
+	s.Require().NoError(err)
+
+	var receivedEvent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var data webhooks.JobData
+		_ = json.NewDecoder(r.Body).Decode(&data)
+		receivedEvent = string(data.Event)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	testUuid := uuid.FromStringOrNil("a47fe92a-1e4b-4119-8653-55ad82737c88")
+	webhookPersister := s.Storage.GetWebhookPersister(nil)
+	dbHook, err := webhookPersister.Get(testUuid)
+	s.Require().NoError(err)
+	dbHook.Callback = server.URL
+	s.Require().NoError(webhookPersister.Update(*dbHook))
+
+	e := NewAdminRouter(&test.DefaultConfig, s.Storage, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/a47fe92a-1e4b-4119-8653-55ad82737c88/test", nil)
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	s.Require().Equal(http.StatusOK, rec.Code)
+	s.Equal(string(events.WebhookTest), receivedEvent)
+
+	var delivery models.WebhookDelivery
+	err = json.Unmarshal(rec.Body.Bytes(), &delivery)
+	s.Require().NoError(err)
+	s.Equal(http.StatusNoContent, *delivery.StatusCode)
+	s.Nil(delivery.Error)
+
+	count, err := s.Storage.GetWebhookDeliveryPersister(nil).Count(testUuid)
+	s.Require().NoError(err)
+	s.Equal(4, count)
+}
@@ -0,0 +1,207 @@
+package mail
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/teamhanko/hanko/backend/config"
+	"os"
+	"strings"
+	"time"
+)
+
+// dkimSignedHeaders are the headers signed if present in a message. From is always present and must be signed.
+var dkimSignedHeaders = []string{"From", "To", "Subject", "Date", "Message-Id", "Mime-Version", "Content-Type", "Content-Transfer-Encoding"}
+
+// DKIMSigner signs messages according to RFC 6376 using the "relaxed/relaxed" canonicalization and either
+// "rsa-sha256" or "ed25519-sha256" (RFC 8463) as algorithm.
+type DKIMSigner struct {
+	domain    string
+	selector  string
+	signer    crypto.Signer
+	algorithm string
+	now       func() time.Time
+}
+
+// NewDKIMSigner creates a DKIMSigner from the given config. It returns nil if DKIM signing is disabled.
+func NewDKIMSigner(cfg config.DKIM) (*DKIMSigner, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	keyPEM := []byte(cfg.PrivateKey)
+	if cfg.PrivateKeyFile != "" {
+		var err error
+		keyPEM, err = os.ReadFile(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read DKIM private key: %w", err)
+		}
+	}
+
+	signer, err := parseDKIMPrivateKey(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm := "rsa-sha256"
+	if _, ok := signer.(ed25519.PrivateKey); ok {
+		algorithm = "ed25519-sha256"
+	}
+
+	return &DKIMSigner{
+		domain:    cfg.Domain,
+		selector:  cfg.Selector,
+		signer:    signer,
+		algorithm: algorithm,
+		now:       time.Now,
+	}, nil
+}
+
+func parseDKIMPrivateKey(keyPEM []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("failed to decode DKIM private key: no PEM block found")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse DKIM private key: %w", err)
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, nil
+	case ed25519.PrivateKey:
+		return k, nil
+	default:
+		return nil, fmt.Errorf("unsupported DKIM private key type %T, must be RSA or Ed25519", key)
+	}
+}
+
+// Sign returns the given message (with CRLF line endings) with a prepended DKIM-Signature header.
+func (s *DKIMSigner) Sign(message []byte) ([]byte, error) {
+	headerBlock, body, found := bytes.Cut(message, []byte("\r\n\r\n"))
+	if !found {
+		headerBlock, body = message, nil
+	}
+
+	bodyHash := sha256.Sum256(canonicalizeBodyRelaxed(body))
+	headers := splitHeaders(headerBlock)
+
+	var signedNames []string
+	hash := sha256.New()
+	used := make(map[int]bool)
+	for _, name := range dkimSignedHeaders {
+		// headers occurring multiple times are signed from the bottom up (RFC 6376, section 5.4.2)
+		for i := len(headers) - 1; i >= 0; i-- {
+			if used[i] || !strings.EqualFold(headerName(headers[i]), name) {
+				continue
+			}
+			used[i] = true
+			signedNames = append(signedNames, strings.ToLower(name))
+			hash.Write([]byte(canonicalizeHeaderRelaxed(headers[i]) + "\r\n"))
+			break
+		}
+	}
+
+	if len(signedNames) == 0 || signedNames[0] != "from" {
+		return nil, errors.New("failed to sign message: message has no From header")
+	}
+
+	signatureHeader := fmt.Sprintf(
+		"DKIM-Signature: v=1; a=%s; c=relaxed/relaxed; d=%s; s=%s; t=%d; h=%s; bh=%s; b=",
+		s.algorithm, s.domain, s.selector, s.now().Unix(), strings.Join(signedNames, ":"),
+		base64.StdEncoding.EncodeToString(bodyHash[:]),
+	)
+	hash.Write([]byte(canonicalizeHeaderRelaxed(signatureHeader)))
+	digest := hash.Sum(nil)
+
+	var signature []byte
+	var err error
+	if s.algorithm == "ed25519-sha256" {
+		// Ed25519 signs the SHA-256 hash of the canonicalized headers (RFC 8463, section 3)
+		signature, err = s.signer.Sign(rand.Reader, digest, crypto.Hash(0))
+	} else {
+		signature, err = s.signer.Sign(rand.Reader, digest, crypto.SHA256)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign message: %w", err)
+	}
+
+	signed := bytes.Buffer{}
+	signed.WriteString(signatureHeader)
+	signed.WriteString(base64.StdEncoding.EncodeToString(signature))
+	signed.WriteString("\r\n")
+	signed.Write(message)
+
+	return signed.Bytes(), nil
+}
+
+// splitHeaders splits a header block into its (possibly folded) header fields.
+func splitHeaders(headerBlock []byte) []string {
+	var headers []string
+	for _, line := range strings.Split(string(headerBlock), "\r\n") {
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(headers) > 0 {
+			headers[len(headers)-1] += "\r\n" + line
+			continue
+		}
+		headers = append(headers, line)
+	}
+	return headers
+}
+
+func headerName(header string) string {
+	name, _, _ := strings.Cut(header, ":")
+	return strings.TrimSpace(name)
+}
+
+// canonicalizeHeaderRelaxed implements the "relaxed" header canonicalization (RFC 6376, section 3.4.2). The result
+// does not contain the trailing CRLF.
+func canonicalizeHeaderRelaxed(header string) string {
+	name, value, _ := strings.Cut(header, ":")
+	value = strings.ReplaceAll(value, "\r\n", "")
+	value = strings.Join(strings.FieldsFunc(value, isWSP), " ")
+	return strings.ToLower(strings.TrimSpace(name)) + ":" + value
+}
+
+// canonicalizeBodyRelaxed implements the "relaxed" body canonicalization (RFC 6376, section 3.4.4).
+func canonicalizeBodyRelaxed(body []byte) []byte {
+	lines := strings.Split(string(body), "\r\n")
+	for i, line := range lines {
+		fields := strings.FieldsFunc(line, isWSP)
+		line = strings.Join(fields, " ")
+		if len(fields) > 0 && isWSP(rune(lines[i][0])) {
+			line = " " + line
+		}
+		lines[i] = line
+	}
+
+	// ignore all empty lines at the end of the body
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
+
+func isWSP(r rune) bool {
+	return r == ' ' || r == '\t'
+}
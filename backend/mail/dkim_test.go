@@ -0,0 +1,114 @@
+package mail
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamhanko/hanko/backend/config"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCanonicalizeHeaderRelaxed(t *testing.T) {
+	// examples from RFC 6376, section 3.4.5
+	assert.Equal(t, "a:X", canonicalizeHeaderRelaxed("A: X"))
+	assert.Equal(t, "b:Y Z", canonicalizeHeaderRelaxed("B : Y\t\r\n\tZ  "))
+}
+
+func TestCanonicalizeBodyRelaxed(t *testing.T) {
+	// example from RFC 6376, section 3.4.5
+	body := " C \r\nD \t E\r\n\r\n\r\n"
+	assert.Equal(t, " C\r\nD E\r\n", string(canonicalizeBodyRelaxed([]byte(body))))
+
+	assert.Empty(t, canonicalizeBodyRelaxed([]byte("\r\n\r\n")))
+}
+
+func TestNewDKIMSigner(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(ed25519Key)
+	require.NoError(t, err)
+
+	signer, err := NewDKIMSigner(config.DKIM{Enabled: false})
+	assert.NoError(t, err)
+	assert.Nil(t, signer)
+
+	signer, err = NewDKIMSigner(dkimConfig(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})))
+	assert.NoError(t, err)
+	assert.Equal(t, "rsa-sha256", signer.algorithm)
+
+	signer, err = NewDKIMSigner(dkimConfig(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})))
+	assert.NoError(t, err)
+	assert.Equal(t, "ed25519-sha256", signer.algorithm)
+
+	_, err = NewDKIMSigner(dkimConfig([]byte("not a key")))
+	assert.Error(t, err)
+}
+
+func TestDKIMSigner_Sign(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	signer, err := NewDKIMSigner(dkimConfig(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})))
+	require.NoError(t, err)
+	signer.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	message := "From: Hanko <noreply@example.com>\r\n" +
+		"To: john.doe@example.com\r\n" +
+		"Subject: Your  passcode\r\n" +
+		"X-Unsigned: value\r\n" +
+		"\r\n" +
+		"Your passcode is 123456.  \r\n\r\n"
+
+	signed, err := signer.Sign([]byte(message))
+	require.NoError(t, err)
+
+	signatureHeader, rest, found := strings.Cut(string(signed), "\r\n")
+	require.True(t, found)
+	assert.Equal(t, message, rest)
+	assert.Contains(t, signatureHeader, "a=rsa-sha256; c=relaxed/relaxed; d=example.com; s=hanko; t=1700000000; h=from:to:subject;")
+
+	bodyHash := sha256.Sum256([]byte("Your passcode is 123456.\r\n"))
+	assert.Contains(t, signatureHeader, "bh="+base64.StdEncoding.EncodeToString(bodyHash[:])+";")
+
+	index := strings.LastIndex(signatureHeader, "; b=") + len("; b=")
+	unsignedHeader, encodedSignature := signatureHeader[:index], signatureHeader[index:]
+	signature, err := base64.StdEncoding.DecodeString(encodedSignature)
+	require.NoError(t, err)
+
+	digest := sha256.Sum256([]byte("from:Hanko <noreply@example.com>\r\n" +
+		"to:john.doe@example.com\r\n" +
+		"subject:Your passcode\r\n" +
+		canonicalizeHeaderRelaxed(unsignedHeader)))
+	assert.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature))
+}
+
+func TestDKIMSigner_SignWithoutFrom(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	signer, err := NewDKIMSigner(dkimConfig(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})))
+	require.NoError(t, err)
+
+	_, err = signer.Sign([]byte("To: john.doe@example.com\r\n\r\nbody\r\n"))
+	assert.Error(t, err)
+}
+
+func dkimConfig(privateKey []byte) config.DKIM {
+	return config.DKIM{
+		Enabled:    true,
+		Domain:     "example.com",
+		Selector:   "hanko",
+		PrivateKey: string(privateKey),
+	}
+}
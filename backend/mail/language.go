@@ -0,0 +1,45 @@
+package mail
+
+import (
+	"errors"
+	"golang.org/x/text/language"
+	"strings"
+)
+
+// maxLanguageLength is the maximum length of a preferred language, matching the size of the users.preferred_language
+// column.
+const maxLanguageLength = 35
+
+// NormalizeLanguage parses the given BCP 47 language tag (e.g. "de" or "pt-BR") and returns it in its canonical form.
+// Languages without bundled translations are accepted, because translations can be added with locale overrides.
+// Emails are sent in the default language if no translation exists.
+func NormalizeLanguage(lang string) (string, error) {
+	lang = strings.TrimSpace(lang)
+	if lang == "" || len(lang) > maxLanguageLength {
+		return "", errors.New("language must be a BCP 47 language tag")
+	}
+
+	tag, err := language.Parse(lang)
+	if err != nil || tag == language.Und {
+		return "", errors.New("language must be a BCP 47 language tag")
+	}
+
+	return tag.String(), nil
+}
+
+// LanguagePreference combines the preferred language of a user with the value of an Accept-Language header into a
+// single language list accepted by Render and Translate. The preferred language takes precedence, the languages of the
+// header are used if no translation exists for the preferred language. Either value may be empty.
+func LanguagePreference(preferredLanguage string, acceptLanguage string) string {
+	preferredLanguage = strings.TrimSpace(preferredLanguage)
+	acceptLanguage = strings.TrimSpace(acceptLanguage)
+
+	switch {
+	case preferredLanguage == "":
+		return acceptLanguage
+	case acceptLanguage == "":
+		return preferredLanguage
+	default:
+		return preferredLanguage + ", " + acceptLanguage
+	}
+}
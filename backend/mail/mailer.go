@@ -1,31 +1,84 @@
 package mail
 
 import (
+	"errors"
 	"fmt"
+	"github.com/gofrs/uuid"
 	"github.com/teamhanko/hanko/backend/config"
 	"gopkg.in/gomail.v2"
-	"strconv"
+	"strings"
 )
 
+// Message is an email sent to a single recipient. The sender is taken from the email delivery config.
+type Message struct {
+	To      string
+	Subject string
+	// Text is the plain text body.
+	Text string
+	// HTML is the optional HTML body. If set, the email is sent as multipart message with the plain text body as
+	// alternative.
+	HTML string
+}
+
 type Mailer interface {
-	Send(message *gomail.Message) error
+	Send(message Message) error
+}
+
+// PermanentError marks delivery errors which will not be resolved by retrying, e.g. because the provider rejected the
+// message.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
 }
 
-type mailer struct {
-	dialer *gomail.Dialer
+// IsPermanent reports whether the given delivery error is permanent.
+func IsPermanent(err error) bool {
+	var permanentErr *PermanentError
+	return errors.As(err, &permanentErr)
 }
 
-func NewMailer(config config.SMTP) (Mailer, error) {
-	port, err := strconv.Atoi(config.Port)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse SMTP port: %w", err)
+// NewMailer creates the Mailer for the configured provider. Messages are sent immediately, see NewDeliveryMailer for
+// the mailer respecting the send queue.
+func NewMailer(cfg config.EmailDelivery) (Mailer, error) {
+	switch cfg.Provider {
+	case "", config.EmailDeliveryProviderSMTP:
+		return newSMTPMailer(cfg)
+	case config.EmailDeliveryProviderHTTP:
+		return newHTTPMailer(cfg), nil
+	case config.EmailDeliveryProviderFile:
+		return newFileMailer(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown email delivery provider '%s'", cfg.Provider)
 	}
-	d := gomail.NewDialer(config.Host, port, config.User, config.Password)
-	return &mailer{
-		dialer: d,
-	}, nil
 }
 
-func (m *mailer) Send(message *gomail.Message) error {
-	return m.dialer.DialAndSend(message)
+func newGomailMessage(message Message, fromAddress string, fromName string) *gomail.Message {
+	gomailMessage := gomail.NewMessage()
+	gomailMessage.SetAddressHeader("To", message.To, "")
+	gomailMessage.SetAddressHeader("From", fromAddress, fromName)
+	gomailMessage.SetHeader("Subject", message.Subject)
+	gomailMessage.SetHeader("Message-ID", newMessageID(fromAddress))
+	gomailMessage.SetBody("text/plain", message.Text)
+	if message.HTML != "" {
+		gomailMessage.AddAlternative("text/html", message.HTML)
+	}
+
+	return gomailMessage
+}
+
+// newMessageID returns a unique Message-ID using the domain of the sender address.
+func newMessageID(fromAddress string) string {
+	domain := "localhost"
+	if _, d, found := strings.Cut(fromAddress, "@"); found && d != "" {
+		domain = d
+	}
+
+	return fmt.Sprintf("<%s@%s>", uuid.Must(uuid.NewV4()), domain)
 }
@@ -0,0 +1,78 @@
+package mail
+
+import (
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/config"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// fileMailer writes messages to a local directory, either as separate .eml files or in the Maildir format.
+type fileMailer struct {
+	cfg         config.EmailDeliveryFile
+	fromAddress string
+	fromName    string
+}
+
+func newFileMailer(cfg config.EmailDelivery) Mailer {
+	return &fileMailer{
+		cfg:         cfg.File,
+		fromAddress: cfg.FromAddress,
+		fromName:    cfg.FromName,
+	}
+}
+
+func (m *fileMailer) Send(message Message) error {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d.%s", time.Now().UnixNano(), id)
+
+	if !m.cfg.Maildir {
+		return m.write(m.cfg.Directory, name+".eml", message)
+	}
+
+	// Maildir: the message is written to "tmp" and moved to "new" afterwards, so mail clients never see incomplete
+	// messages
+	for _, dir := range []string{"tmp", "new", "cur"} {
+		err = os.MkdirAll(filepath.Join(m.cfg.Directory, dir), 0700)
+		if err != nil {
+			return fmt.Errorf("failed to create maildir: %w", err)
+		}
+	}
+
+	err = m.write(filepath.Join(m.cfg.Directory, "tmp"), name, message)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(filepath.Join(m.cfg.Directory, "tmp", name), filepath.Join(m.cfg.Directory, "new", name))
+	if err != nil {
+		return fmt.Errorf("failed to move message to maildir: %w", err)
+	}
+
+	return nil
+}
+
+func (m *fileMailer) write(dir string, name string, message Message) error {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create message file: %w", err)
+	}
+
+	_, err = newGomailMessage(message, m.fromAddress, m.fromName).WriteTo(file)
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+
+	return file.Close()
+}
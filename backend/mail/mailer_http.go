@@ -0,0 +1,89 @@
+package mail
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/telemetry"
+	"io"
+	"net/http"
+)
+
+// maxErrorBodyLength is the maximum number of bytes of an error response included in the delivery error.
+const maxErrorBodyLength = 512
+
+type httpAddress struct {
+	Address string `json:"address"`
+	Name    string `json:"name,omitempty"`
+}
+
+// httpMessage is the request body sent by the http provider.
+type httpMessage struct {
+	From    httpAddress `json:"from"`
+	To      string      `json:"to"`
+	Subject string      `json:"subject"`
+	Text    string      `json:"text"`
+	HTML    string      `json:"html,omitempty"`
+}
+
+// httpMailer posts messages as JSON to an email API.
+type httpMailer struct {
+	client      *http.Client
+	cfg         config.EmailDeliveryHTTP
+	fromAddress string
+	fromName    string
+}
+
+func newHTTPMailer(cfg config.EmailDelivery) Mailer {
+	return &httpMailer{
+		client:      &http.Client{Timeout: cfg.HTTP.Timeout, Transport: telemetry.NewTransport(nil)},
+		cfg:         cfg.HTTP,
+		fromAddress: cfg.FromAddress,
+		fromName:    cfg.FromName,
+	}
+}
+
+// Send posts the message to the configured URL. Responses with a 4xx status code, except for 408 and 429, are
+// returned as PermanentError.
+func (m *httpMailer) Send(message Message) error {
+	body, err := json.Marshal(httpMessage{
+		From:    httpAddress{Address: m.fromAddress, Name: m.fromName},
+		To:      message.To,
+		Subject: message.Subject,
+		Text:    message.Text,
+		HTML:    message.HTML,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	request, err := http.NewRequest(http.MethodPost, m.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for name, value := range m.cfg.Headers {
+		request.Header.Set(name, value)
+	}
+
+	response, err := m.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, response.Body)
+		return nil
+	}
+
+	responseBody, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorBodyLength))
+	err = fmt.Errorf("email API responded with status %d: %s", response.StatusCode, bytes.TrimSpace(responseBody))
+	if response.StatusCode >= 400 && response.StatusCode < 500 &&
+		response.StatusCode != http.StatusRequestTimeout && response.StatusCode != http.StatusTooManyRequests {
+		return &PermanentError{Err: err}
+	}
+
+	return err
+}
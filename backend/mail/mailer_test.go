@@ -1,34 +1,68 @@
 package mail
 
 import (
+	"encoding/json"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/teamhanko/hanko/backend/config"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
 func TestNewMailer(t *testing.T) {
 	tests := []struct {
 		Name      string
-		Input     config.SMTP
+		Input     config.EmailDelivery
 		WantError bool
 	}{
 		{
 			Name: "create mailer successful",
-			Input: config.SMTP{
-				Host:     "mail.example.com",
-				Port:     "123",
-				User:     "example",
-				Password: "example",
+			Input: config.EmailDelivery{
+				SMTP: config.SMTP{
+					Host:     "mail.example.com",
+					Port:     "123",
+					User:     "example",
+					Password: "example",
+				},
 			},
 			WantError: false,
 		},
 		{
 			Name: "create mailer with incompatible port",
-			Input: config.SMTP{
-				Host:     "mail.example.com",
-				Port:     "abc",
-				User:     "example",
-				Password: "example",
+			Input: config.EmailDelivery{
+				SMTP: config.SMTP{
+					Host:     "mail.example.com",
+					Port:     "abc",
+					User:     "example",
+					Password: "example",
+				},
+			},
+			WantError: true,
+		},
+		{
+			Name: "create http mailer successful",
+			Input: config.EmailDelivery{
+				Provider: config.EmailDeliveryProviderHTTP,
+				HTTP:     config.EmailDeliveryHTTP{URL: "https://api.example.com/emails"},
+			},
+			WantError: false,
+		},
+		{
+			Name: "create file mailer successful",
+			Input: config.EmailDelivery{
+				Provider: config.EmailDeliveryProviderFile,
+				File:     config.EmailDeliveryFile{Directory: "./emails"},
+			},
+			WantError: false,
+		},
+		{
+			Name: "create mailer with unknown provider",
+			Input: config.EmailDelivery{
+				Provider: "carrier-pigeon",
 			},
 			WantError: true,
 		},
@@ -48,3 +82,105 @@ func TestNewMailer(t *testing.T) {
 		})
 	}
 }
+
+func TestHTTPMailer_Send(t *testing.T) {
+	tests := []struct {
+		Name          string
+		StatusCode    int
+		WantError     bool
+		WantPermanent bool
+	}{
+		{Name: "accepted", StatusCode: http.StatusAccepted},
+		{Name: "rejected", StatusCode: http.StatusUnprocessableEntity, WantError: true, WantPermanent: true},
+		{Name: "rate limited", StatusCode: http.StatusTooManyRequests, WantError: true},
+		{Name: "unavailable", StatusCode: http.StatusServiceUnavailable, WantError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var received httpMessage
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+				assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+				w.WriteHeader(test.StatusCode)
+			}))
+			defer server.Close()
+
+			mailer, err := NewMailer(config.EmailDelivery{
+				Provider:    config.EmailDeliveryProviderHTTP,
+				FromAddress: "noreply@example.com",
+				FromName:    "Example",
+				HTTP: config.EmailDeliveryHTTP{
+					URL:     server.URL,
+					Headers: map[string]string{"Authorization": "Bearer secret"},
+				},
+			})
+			require.NoError(t, err)
+
+			err = mailer.Send(Message{To: "user@example.com", Subject: "Subject", Text: "Text", HTML: "<p>HTML</p>"})
+			if test.WantError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.WantPermanent, IsPermanent(err))
+
+			assert.Equal(t, httpMessage{
+				From:    httpAddress{Address: "noreply@example.com", Name: "Example"},
+				To:      "user@example.com",
+				Subject: "Subject",
+				Text:    "Text",
+				HTML:    "<p>HTML</p>",
+			}, received)
+		})
+	}
+}
+
+func TestFileMailer_Send(t *testing.T) {
+	message := Message{To: "user@example.com", Subject: "Subject", Text: "Text", HTML: "<p>HTML</p>"}
+
+	t.Run("eml", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "emails")
+		mailer, err := NewMailer(config.EmailDelivery{
+			Provider:    config.EmailDeliveryProviderFile,
+			FromAddress: "noreply@example.com",
+			File:        config.EmailDeliveryFile{Directory: dir},
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, mailer.Send(message))
+		require.NoError(t, mailer.Send(message))
+
+		files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+		require.NoError(t, err)
+		require.Len(t, files, 2)
+
+		content, err := os.ReadFile(files[0])
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "To: user@example.com")
+		assert.Contains(t, string(content), "Subject: Subject")
+		assert.Contains(t, string(content), "multipart/alternative")
+	})
+
+	t.Run("maildir", func(t *testing.T) {
+		dir := t.TempDir()
+		mailer, err := NewMailer(config.EmailDelivery{
+			Provider:    config.EmailDeliveryProviderFile,
+			FromAddress: "noreply@example.com",
+			File:        config.EmailDeliveryFile{Directory: dir, Maildir: true},
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, mailer.Send(message))
+
+		newMessages, err := os.ReadDir(filepath.Join(dir, "new"))
+		require.NoError(t, err)
+		require.Len(t, newMessages, 1)
+		assert.False(t, strings.HasSuffix(newMessages[0].Name(), ".eml"))
+
+		tmpMessages, err := os.ReadDir(filepath.Join(dir, "tmp"))
+		require.NoError(t, err)
+		assert.Empty(t, tmpMessages)
+	})
+}
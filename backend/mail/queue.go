@@ -0,0 +1,306 @@
+package mail
+
+import (
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/crypto/aes_gcm"
+	"github.com/teamhanko/hanko/backend/outbox"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
+)
+
+const (
+	// dispatchInterval is the interval in which the dispatcher polls for due messages.
+	dispatchInterval = 1 * time.Second
+	// dispatchBatchSize is the maximum number of messages listed per poll.
+	dispatchBatchSize = 50
+	// messageLockDuration is the time a message is locked for a single dispatcher. Messages are locked right before
+	// they are sent, so it must exceed the time needed to send a single message, otherwise messages might be sent
+	// twice.
+	messageLockDuration = 2 * time.Minute
+	// pruneInterval is the interval in which finished messages are pruned.
+	pruneInterval = 1 * time.Hour
+)
+
+// retryBackoff is the delay before the next delivery attempt of a failed message, starting at 5 seconds and capped at
+// 15 minutes, because emails like passcodes are only useful for a short time.
+var retryBackoff = outbox.Backoff{BaseDelay: 5 * time.Second, MaxDelay: 15 * time.Minute}
+
+// NewDeliveryMailer creates the Mailer used to deliver emails. If the send queue is enabled, messages are added to the
+// queue and sent by the Dispatcher, otherwise they are sent immediately using the configured provider.
+// The content of queued messages is encrypted with the given keys (see config.Secrets).
+func NewDeliveryMailer(cfg config.EmailDelivery, keys []string, persister persistence.Persister) (Mailer, error) {
+	if cfg.Queue.Enabled {
+		return NewQueueMailer(persister, keys)
+	}
+
+	return NewMailer(cfg)
+}
+
+// ConnectionMailer is implemented by mailers which store messages in the database instead of sending them
+// immediately, so messages can be stored in the transaction of the caller.
+type ConnectionMailer interface {
+	Mailer
+	SendWithConnection(tx *pop.Connection, message Message) error
+}
+
+// SendWithConnection sends the message with the given mailer. If the mailer is a ConnectionMailer, the message is
+// stored using the given connection and therefore only sent if the transaction of the connection is committed.
+func SendWithConnection(mailer Mailer, tx *pop.Connection, message Message) error {
+	if connectionMailer, ok := mailer.(ConnectionMailer); ok {
+		return connectionMailer.SendWithConnection(tx, message)
+	}
+
+	return mailer.Send(message)
+}
+
+// queueMailer adds messages to the send queue. The subject and bodies of the messages are stored encrypted, because
+// they may contain passcodes or login links.
+type queueMailer struct {
+	persister persistence.Persister
+	encrypter *aes_gcm.AESGCM
+}
+
+func NewQueueMailer(persister persistence.Persister, keys []string) (Mailer, error) {
+	encrypter, err := aes_gcm.NewAESGCM(keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create encrypter for queued emails: %w", err)
+	}
+
+	return &queueMailer{persister: persister, encrypter: encrypter}, nil
+}
+
+func (m *queueMailer) Send(message Message) error {
+	return m.SendWithConnection(nil, message)
+}
+
+// SendWithConnection adds the message to the send queue using the given connection, so the message is only sent if
+// the transaction of the connection is committed.
+func (m *queueMailer) SendWithConnection(tx *pop.Connection, message Message) error {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	emailMessage := models.EmailMessage{
+		ID:            id,
+		ToAddress:     message.To,
+		Status:        models.EmailMessageStatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	err = encryptContent(m.encrypter, message, &emailMessage)
+	if err != nil {
+		return fmt.Errorf("failed to queue email: %w", err)
+	}
+
+	err = m.persister.GetEmailMessagePersister(tx).Create(emailMessage)
+	if err != nil {
+		return fmt.Errorf("failed to queue email: %w", err)
+	}
+
+	return nil
+}
+
+// encryptContent stores the subject and bodies of the message encrypted in the email message.
+func encryptContent(encrypter *aes_gcm.AESGCM, message Message, emailMessage *models.EmailMessage) error {
+	var err error
+	emailMessage.Subject, err = encrypter.Encrypt([]byte(message.Subject))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt subject: %w", err)
+	}
+
+	emailMessage.BodyText, err = encrypter.Encrypt([]byte(message.Text))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt text body: %w", err)
+	}
+
+	emailMessage.BodyHTML = nil
+	if message.HTML != "" {
+		html, err := encrypter.Encrypt([]byte(message.HTML))
+		if err != nil {
+			return fmt.Errorf("failed to encrypt html body: %w", err)
+		}
+		emailMessage.BodyHTML = &html
+	}
+
+	return nil
+}
+
+// decryptContent returns the message to send for the given email message.
+func decryptContent(encrypter *aes_gcm.AESGCM, emailMessage models.EmailMessage) (Message, error) {
+	message := Message{To: emailMessage.ToAddress}
+
+	subject, err := encrypter.Decrypt(emailMessage.Subject)
+	if err != nil {
+		return message, fmt.Errorf("failed to decrypt subject: %w", err)
+	}
+	message.Subject = string(subject)
+
+	text, err := encrypter.Decrypt(emailMessage.BodyText)
+	if err != nil {
+		return message, fmt.Errorf("failed to decrypt text body: %w", err)
+	}
+	message.Text = string(text)
+
+	if emailMessage.BodyHTML != nil {
+		html, err := encrypter.Decrypt(*emailMessage.BodyHTML)
+		if err != nil {
+			return message, fmt.Errorf("failed to decrypt html body: %w", err)
+		}
+		message.HTML = string(html)
+	}
+
+	return message, nil
+}
+
+// FailureHandler is called with every message which has failed permanently and its decrypted content, before the
+// content is removed. The content only contains the recipient if it could not be decrypted.
+type FailureHandler func(emailMessage models.EmailMessage, message Message)
+
+// Dispatcher sends the messages in the send queue. Each message is locked right before it is sent and its result is
+// only recorded while the lock is held, so multiple dispatchers (e.g. of multiple Hanko instances) can run
+// concurrently without sending a message twice. Failed deliveries are retried with an exponential backoff until the
+// configured maximum number of attempts is reached or the provider rejects the message permanently.
+type Dispatcher struct {
+	cfg       config.EmailQueue
+	persister persistence.Persister
+	encrypter *aes_gcm.AESGCM
+	mailer    Mailer
+	onFailure FailureHandler
+	logger    echo.Logger
+}
+
+// NewDispatcher creates a Dispatcher sending the queued messages with the given mailer. The keys must be the ones
+// the messages have been queued with (see NewQueueMailer).
+func NewDispatcher(cfg config.EmailQueue, keys []string, persister persistence.Persister, mailer Mailer, onFailure FailureHandler, logger echo.Logger) (*Dispatcher, error) {
+	encrypter, err := aes_gcm.NewAESGCM(keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create decrypter for queued emails: %w", err)
+	}
+
+	return &Dispatcher{
+		cfg:       cfg,
+		persister: persister,
+		encrypter: encrypter,
+		mailer:    mailer,
+		onFailure: onFailure,
+		logger:    logger,
+	}, nil
+}
+
+// Run dispatches due messages until the stop channel is closed.
+func (d *Dispatcher) Run(stop <-chan struct{}) {
+	outbox.Run(d, dispatchInterval, pruneInterval, d.logger, stop)
+}
+
+// Dispatch sends all due messages. It returns once all listed messages have been processed.
+func (d *Dispatcher) Dispatch() error {
+	dueMessages, err := d.persister.GetEmailMessagePersister(nil).ListDue(time.Now().UTC(), dispatchBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, dueMessage := range dueMessages {
+		d.send(dueMessage)
+	}
+
+	return nil
+}
+
+// send locks the given message and sends it. Messages which have been claimed by another dispatcher in the meantime
+// are skipped.
+func (d *Dispatcher) send(dueMessage models.EmailMessage) {
+	now := time.Now().UTC()
+	lockedMessage, err := d.persister.GetEmailMessagePersister(nil).Lock(dueMessage, now, now.Add(messageLockDuration))
+	if err != nil {
+		d.logger.Error(fmt.Errorf("unable to lock email message: %w", err))
+		return
+	}
+	if lockedMessage == nil {
+		// claimed by another dispatcher
+		return
+	}
+
+	message, err := decryptContent(d.encrypter, *lockedMessage)
+	if err != nil {
+		// the message cannot be sent with the configured keys, retrying does not help
+		d.finish(*lockedMessage, message, &PermanentError{Err: err})
+		return
+	}
+
+	d.finish(*lockedMessage, message, d.mailer.Send(message))
+}
+
+// finish records the result of a delivery attempt of the given locked message. Failed messages are rescheduled until
+// the maximum number of attempts is reached, unless the error is permanent.
+func (d *Dispatcher) finish(lockedMessage models.EmailMessage, message Message, sendErr error) {
+	emailMessage := lockedMessage
+	now := time.Now().UTC()
+	emailMessage.Attempts++
+	emailMessage.LockedUntil = nil
+	emailMessage.UpdatedAt = now
+
+	if sendErr == nil {
+		emailMessage.Status = models.EmailMessageStatusSent
+		emailMessage.LastError = nil
+		emailMessage.SentAt = &now
+		clearContent(&emailMessage)
+	} else {
+		lastError := sendErr.Error()
+		emailMessage.LastError = &lastError
+		if emailMessage.Attempts >= outbox.MaxAttempts(d.cfg.MaxAttempts) || IsPermanent(sendErr) {
+			emailMessage.Status = models.EmailMessageStatusFailed
+			d.logger.Warnf("email %s failed permanently after %d attempts: %s", emailMessage.ID, emailMessage.Attempts, lastError)
+			if d.onFailure != nil {
+				d.onFailure(emailMessage, message)
+			}
+			clearContent(&emailMessage)
+		} else {
+			emailMessage.NextAttemptAt = now.Add(retryBackoff.Delay(emailMessage.Attempts))
+		}
+	}
+
+	d.update(lockedMessage, emailMessage)
+}
+
+// update stores the given message, unless the lock of the locked message has been lost in the meantime.
+func (d *Dispatcher) update(lockedMessage models.EmailMessage, emailMessage models.EmailMessage) {
+	if lockedMessage.LockedUntil == nil {
+		d.logger.Error(fmt.Errorf("unable to update email message %s: the message is not locked", emailMessage.ID))
+		return
+	}
+
+	updated, err := d.persister.GetEmailMessagePersister(nil).UpdateLocked(emailMessage, *lockedMessage.LockedUntil, lockedMessage.Attempts)
+	if err != nil {
+		d.logger.Error(fmt.Errorf("unable to update email message: %w", err))
+		return
+	}
+
+	if !updated {
+		d.logger.Warnf("email %s has been claimed by another dispatcher, the result of the attempt is not recorded", emailMessage.ID)
+	}
+}
+
+// Prune deletes the finished messages which are older than the configured retention.
+func (d *Dispatcher) Prune(now time.Time) {
+	_, err := d.persister.GetEmailMessagePersister(nil).DeleteFinishedBefore(now.Add(-d.cfg.Retention))
+	if err != nil {
+		d.logger.Error(fmt.Errorf("unable to prune email messages: %w", err))
+	}
+}
+
+// clearContent removes the subject and bodies of a message which is not pending anymore, because they may contain
+// passcodes.
+func clearContent(message *models.EmailMessage) {
+	message.Subject = ""
+	message.BodyText = ""
+	message.BodyHTML = nil
+}
@@ -0,0 +1,335 @@
+package mail
+
+import (
+	"errors"
+	"github.com/labstack/gommon/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/test"
+	"testing"
+	"time"
+)
+
+type testMailer struct {
+	messages []Message
+	err      error
+}
+
+func (m *testMailer) Send(message Message) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+var testKeys = []string{"needsToBeAtLeast16"}
+
+func newTestQueueMailer(t *testing.T, persister persistence.Persister) Mailer {
+	mailer, err := NewQueueMailer(persister, testKeys)
+	require.NoError(t, err)
+	return mailer
+}
+
+func newTestDispatcher(t *testing.T, maxAttempts int, persister persistence.Persister, mailer Mailer, onFailure FailureHandler) *Dispatcher {
+	dispatcher, err := NewDispatcher(config.EmailQueue{Enabled: true, MaxAttempts: maxAttempts}, testKeys, persister, mailer, onFailure, log.New("test"))
+	require.NoError(t, err)
+	return dispatcher
+}
+
+func TestNewDeliveryMailer(t *testing.T) {
+	persister := test.NewEmptyPersister()
+
+	mailer, err := NewDeliveryMailer(config.EmailDelivery{Queue: config.EmailQueue{Enabled: true}}, testKeys, persister)
+	require.NoError(t, err)
+	assert.IsType(t, &queueMailer{}, mailer)
+
+	mailer, err = NewDeliveryMailer(config.EmailDelivery{SMTP: config.SMTP{Host: "localhost", Port: "465"}}, testKeys, persister)
+	require.NoError(t, err)
+	assert.IsType(t, &smtpMailer{}, mailer)
+}
+
+func TestSendWithConnection(t *testing.T) {
+	persister := test.NewEmptyPersister()
+	message := Message{To: "user@example.com", Subject: "Subject", Text: "Text"}
+
+	// the queue mailer stores the message using the connection
+	require.NoError(t, SendWithConnection(newTestQueueMailer(t, persister), nil, message))
+	queued := test.OnlyDue(t, persister.GetEmailMessagePersister(nil).ListDue)
+	assert.Equal(t, message.To, queued.ToAddress)
+
+	// other mailers send the message immediately
+	provider := &testMailer{}
+	require.NoError(t, SendWithConnection(provider, nil, message))
+	assert.Equal(t, []Message{message}, provider.messages)
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	persister := test.NewEmptyPersister()
+	provider := &testMailer{}
+
+	err := newTestQueueMailer(t, persister).Send(Message{To: "user@example.com", Subject: "Subject", Text: "Text", HTML: "<p>HTML</p>"})
+	require.NoError(t, err)
+
+	queued := test.OnlyDue(t, persister.GetEmailMessagePersister(nil).ListDue)
+	assert.Equal(t, models.EmailMessageStatusPending, queued.Status)
+	assert.Equal(t, "user@example.com", queued.ToAddress)
+
+	dispatcher := newTestDispatcher(t, 3, persister, provider, nil)
+	require.NoError(t, dispatcher.Dispatch())
+
+	require.Len(t, provider.messages, 1)
+	assert.Equal(t, Message{To: "user@example.com", Subject: "Subject", Text: "Text", HTML: "<p>HTML</p>"}, provider.messages[0])
+
+	sent, err := persister.GetEmailMessagePersister(nil).Get(queued.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.EmailMessageStatusSent, sent.Status)
+	assert.Equal(t, 1, sent.Attempts)
+	assert.NotNil(t, sent.SentAt)
+	assert.Empty(t, sent.Subject)
+	assert.Empty(t, sent.BodyText)
+	assert.Nil(t, sent.BodyHTML)
+
+	// sent messages are not sent again
+	require.NoError(t, dispatcher.Dispatch())
+	assert.Len(t, provider.messages, 1)
+}
+
+func TestDispatcher_DispatchRetries(t *testing.T) {
+	persister := test.NewEmptyPersister()
+	provider := &testMailer{err: errors.New("connection refused")}
+
+	var failed []Message
+	onFailure := func(_ models.EmailMessage, message Message) {
+		failed = append(failed, message)
+	}
+
+	err := newTestQueueMailer(t, persister).Send(Message{To: "user@example.com", Subject: "Subject", Text: "Text"})
+	require.NoError(t, err)
+	queued := test.OnlyDue(t, persister.GetEmailMessagePersister(nil).ListDue)
+
+	dispatcher := newTestDispatcher(t, 2, persister, provider, onFailure)
+	require.NoError(t, dispatcher.Dispatch())
+
+	retried, err := persister.GetEmailMessagePersister(nil).Get(queued.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.EmailMessageStatusPending, retried.Status)
+	assert.Equal(t, 1, retried.Attempts)
+	assert.Equal(t, "connection refused", *retried.LastError)
+	assert.True(t, retried.NextAttemptAt.After(time.Now()))
+	content, err := decryptContent(dispatcher.encrypter, *retried)
+	require.NoError(t, err)
+	assert.Equal(t, "Subject", content.Subject, "the content must be kept for the retry")
+	assert.Empty(t, failed)
+
+	// the retry is not due yet
+	require.NoError(t, dispatcher.Dispatch())
+	retried, err = persister.GetEmailMessagePersister(nil).Get(queued.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, retried.Attempts)
+
+	retried.NextAttemptAt = time.Now().Add(-time.Second)
+	require.NoError(t, persister.GetEmailMessagePersister(nil).Update(*retried))
+	require.NoError(t, dispatcher.Dispatch())
+
+	failedMessage, err := persister.GetEmailMessagePersister(nil).Get(queued.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.EmailMessageStatusFailed, failedMessage.Status)
+	assert.Equal(t, 2, failedMessage.Attempts)
+	assert.Empty(t, failedMessage.Subject)
+
+	require.Len(t, failed, 1)
+	assert.Equal(t, Message{To: "user@example.com", Subject: "Subject", Text: "Text"}, failed[0])
+}
+
+func TestDispatcher_DispatchPermanentError(t *testing.T) {
+	persister := test.NewEmptyPersister()
+	provider := &testMailer{err: &PermanentError{Err: errors.New("recipient rejected")}}
+
+	var failed []Message
+	onFailure := func(_ models.EmailMessage, message Message) {
+		failed = append(failed, message)
+	}
+
+	err := newTestQueueMailer(t, persister).Send(Message{To: "user@example.com", Subject: "Subject", Text: "Text"})
+	require.NoError(t, err)
+	queued := test.OnlyDue(t, persister.GetEmailMessagePersister(nil).ListDue)
+
+	dispatcher := newTestDispatcher(t, 5, persister, provider, onFailure)
+	require.NoError(t, dispatcher.Dispatch())
+
+	failedMessage, err := persister.GetEmailMessagePersister(nil).Get(queued.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.EmailMessageStatusFailed, failedMessage.Status)
+	assert.Equal(t, 1, failedMessage.Attempts)
+	assert.Len(t, failed, 1)
+}
+
+func TestDispatcher_DispatchLocked(t *testing.T) {
+	persister := test.NewEmptyPersister()
+	provider := &testMailer{}
+
+	err := newTestQueueMailer(t, persister).Send(Message{To: "user@example.com", Subject: "Subject", Text: "Text"})
+	require.NoError(t, err)
+	queued := test.OnlyDue(t, persister.GetEmailMessagePersister(nil).ListDue)
+
+	// claimed by another dispatcher
+	now := time.Now()
+	locked, err := persister.GetEmailMessagePersister(nil).Lock(queued, now, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.NotNil(t, locked)
+
+	dispatcher := newTestDispatcher(t, 5, persister, provider, nil)
+	require.NoError(t, dispatcher.Dispatch())
+	assert.Empty(t, provider.messages)
+}
+
+func TestDispatcher_Lock_StaleMessage(t *testing.T) {
+	persister := test.NewEmptyPersister()
+
+	err := newTestQueueMailer(t, persister).Send(Message{To: "user@example.com", Subject: "Subject", Text: "Text"})
+	require.NoError(t, err)
+
+	messagePersister := persister.GetEmailMessagePersister(nil)
+	now := time.Now()
+	dueMessages, err := messagePersister.ListDue(now, dispatchBatchSize)
+	require.NoError(t, err)
+	require.Len(t, dueMessages, 1)
+	stale := dueMessages[0]
+
+	// another dispatcher attempts the message and its lock expires before the listed message is locked
+	attempted := stale
+	attempted.Attempts++
+	require.NoError(t, messagePersister.Update(attempted))
+
+	locked, err := messagePersister.Lock(stale, now, now.Add(messageLockDuration))
+	require.NoError(t, err)
+	assert.Nil(t, locked)
+
+	// a message rescheduled by another dispatcher is not due yet
+	rescheduled := attempted
+	rescheduled.NextAttemptAt = now.Add(time.Minute)
+	require.NoError(t, messagePersister.Update(rescheduled))
+
+	locked, err = messagePersister.Lock(attempted, now, now.Add(messageLockDuration))
+	require.NoError(t, err)
+	assert.Nil(t, locked)
+
+	locked, err = messagePersister.Lock(rescheduled, now.Add(time.Minute), now.Add(messageLockDuration))
+	require.NoError(t, err)
+	require.NotNil(t, locked)
+	assert.Equal(t, 1, locked.Attempts)
+	assert.NotNil(t, locked.LockedUntil)
+}
+
+func TestQueueMailer_EncryptsContent(t *testing.T) {
+	persister := test.NewEmptyPersister()
+
+	err := newTestQueueMailer(t, persister).Send(Message{To: "user@example.com", Subject: "Your passcode", Text: "123456", HTML: "<p>123456</p>"})
+	require.NoError(t, err)
+
+	queued := test.OnlyDue(t, persister.GetEmailMessagePersister(nil).ListDue)
+	assert.Equal(t, "user@example.com", queued.ToAddress)
+	assert.NotContains(t, queued.Subject, "passcode")
+	assert.NotContains(t, queued.BodyText, "123456")
+	require.NotNil(t, queued.BodyHTML)
+	assert.NotContains(t, *queued.BodyHTML, "123456")
+
+	_, err = NewQueueMailer(persister, nil)
+	assert.Error(t, err)
+}
+
+func TestDispatcher_DispatchUndecryptable(t *testing.T) {
+	persister := test.NewEmptyPersister()
+	provider := &testMailer{}
+
+	var failed []Message
+	onFailure := func(_ models.EmailMessage, message Message) {
+		failed = append(failed, message)
+	}
+
+	// queued with a key which has been removed from the configuration since
+	mailer, err := NewQueueMailer(persister, []string{"removedKeyWithMin16Chars"})
+	require.NoError(t, err)
+	require.NoError(t, mailer.Send(Message{To: "user@example.com", Subject: "Subject", Text: "Text"}))
+	queued := test.OnlyDue(t, persister.GetEmailMessagePersister(nil).ListDue)
+
+	dispatcher := newTestDispatcher(t, 5, persister, provider, onFailure)
+	require.NoError(t, dispatcher.Dispatch())
+	assert.Empty(t, provider.messages)
+
+	failedMessage, err := persister.GetEmailMessagePersister(nil).Get(queued.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.EmailMessageStatusFailed, failedMessage.Status)
+	assert.Equal(t, 1, failedMessage.Attempts)
+	assert.Empty(t, failedMessage.Subject)
+	assert.Equal(t, []Message{{To: "user@example.com"}}, failed)
+}
+
+type listingMailer struct {
+	persister         persistence.Persister
+	dueDuringDelivery []int
+}
+
+func (m *listingMailer) Send(_ Message) error {
+	dueMessages, err := m.persister.GetEmailMessagePersister(nil).ListDue(time.Now().UTC(), dispatchBatchSize)
+	if err != nil {
+		return err
+	}
+	m.dueDuringDelivery = append(m.dueDuringDelivery, len(dueMessages))
+	return nil
+}
+
+func TestDispatcher_Dispatch_LockBeforeDelivery(t *testing.T) {
+	persister := test.NewEmptyPersister()
+	provider := &listingMailer{persister: persister}
+
+	mailer := newTestQueueMailer(t, persister)
+	require.NoError(t, mailer.Send(Message{To: "first@example.com", Subject: "Subject", Text: "Text"}))
+	require.NoError(t, mailer.Send(Message{To: "second@example.com", Subject: "Subject", Text: "Text"}))
+
+	dispatcher := newTestDispatcher(t, 3, persister, provider, nil)
+	require.NoError(t, dispatcher.Dispatch())
+
+	// the second message is only locked once the first one has been sent
+	assert.Equal(t, []int{1, 0}, provider.dueDuringDelivery)
+}
+
+func TestDispatcher_Finish_LostLock(t *testing.T) {
+	persister := test.NewEmptyPersister()
+
+	require.NoError(t, newTestQueueMailer(t, persister).Send(Message{To: "user@example.com", Subject: "Subject", Text: "Text"}))
+
+	messagePersister := persister.GetEmailMessagePersister(nil)
+	queued := test.OnlyDue(t, messagePersister.ListDue)
+
+	now := time.Now()
+	staleLock, err := messagePersister.Lock(queued, now, now.Add(messageLockDuration))
+	require.NoError(t, err)
+	require.NotNil(t, staleLock)
+
+	// the lock expires while the message is sent and another dispatcher locks the message
+	later := now.Add(messageLockDuration + time.Second)
+	currentLock, err := messagePersister.Lock(queued, later, later.Add(messageLockDuration))
+	require.NoError(t, err)
+	require.NotNil(t, currentLock)
+
+	dispatcher := newTestDispatcher(t, 3, persister, &testMailer{}, nil)
+	dispatcher.finish(*staleLock, Message{}, nil)
+
+	stored, err := messagePersister.Get(queued.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.EmailMessageStatusPending, stored.Status, "the stale dispatcher must not overwrite the message")
+	assert.Equal(t, 0, stored.Attempts)
+
+	dispatcher.finish(*currentLock, Message{}, nil)
+
+	stored, err = messagePersister.Get(queued.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.EmailMessageStatusSent, stored.Status)
+	assert.Equal(t, 1, stored.Attempts)
+	assert.Empty(t, stored.Subject)
+}
@@ -3,25 +3,47 @@ package mail
 import (
 	"bytes"
 	"embed"
+	"errors"
 	"fmt"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"golang.org/x/exp/slices"
 	"golang.org/x/text/language"
 	"gopkg.in/yaml.v3"
 	"html/template"
+	"io/fs"
+	"os"
+	"path"
 	"strings"
+	textTemplate "text/template"
+	"text/template/parse"
 )
 
 //go:embed templates/* locales/*
 var mailFS embed.FS
 
+const (
+	textTemplateSuffix = "_text.tmpl"
+	htmlTemplateSuffix = "_html.tmpl"
+)
+
 type Renderer struct {
-	template  *template.Template
-	bundle    *i18n.Bundle
-	localizer *i18n.Localizer
+	textTemplate *textTemplate.Template
+	htmlTemplate *template.Template
+	bundle       *i18n.Bundle
+	localizer    *i18n.Localizer
 }
 
-// NewRenderer creates an instance of Renderer, which renders the templates (located in mail/templates) with locales (located in mail/locales)
-func NewRenderer() (*Renderer, error) {
+// NewRenderer creates an instance of Renderer, which renders the templates (located in mail/templates) with locales
+// (located in mail/locales). Text templates must be named "[template_name]_text.tmpl", HTML templates must be named
+// "[template_name]_html.tmpl".
+//
+// If overrideDirectory is not empty, templates in its "templates" subdirectory and locale files in its "locales"
+// subdirectory are loaded after the embedded ones. A template replaces the embedded template with the same file name,
+// a message replaces the embedded message with the same ID and language.
+//
+// An error is returned if a template references a message ID or has no subject ("subject_[template_name]") in the
+// default language.
+func NewRenderer(overrideDirectory string) (*Renderer, error) {
 	r := &Renderer{}
 	bundle := i18n.NewBundle(language.English)
 	dir, err := mailFS.ReadDir("locales")
@@ -34,18 +56,180 @@ func NewRenderer() (*Renderer, error) {
 	}
 	r.bundle = bundle
 
-	// add the translate function to the template, so it can be used inside
-	funcMap := template.FuncMap{"t": r.translate}
-	t := template.New("root").Funcs(funcMap)
-	_, err = t.ParseFS(mailFS, "templates/*")
+	// add the translate function to the templates, so it can be used inside
+	textTmpl := textTemplate.New("root").Funcs(textTemplate.FuncMap{"t": r.translate})
+	_, err = textTmpl.ParseFS(mailFS, "templates/*"+textTemplateSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load text templates: %w", err)
+	}
+	r.textTemplate = textTmpl
+
+	htmlTmpl := template.New("root").Funcs(template.FuncMap{"t": r.translate})
+	_, err = htmlTmpl.ParseFS(mailFS, "templates/*"+htmlTemplateSuffix)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load templates: %w", err)
+		return nil, fmt.Errorf("failed to load html templates: %w", err)
+	}
+	r.htmlTemplate = htmlTmpl
+
+	if overrideDirectory != "" {
+		err = r.loadOverrides(os.DirFS(overrideDirectory))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load overrides from '%s': %w", overrideDirectory, err)
+		}
+	}
+
+	err = r.validateMessageIDs()
+	if err != nil {
+		return nil, err
 	}
-	r.template = t
 
 	return r, nil
 }
 
+// loadOverrides loads the templates and locale files of the given file system. Both the "templates" and the "locales"
+// directory are optional.
+func (r *Renderer) loadOverrides(fsys fs.FS) error {
+	if _, err := fs.Stat(fsys, "."); err != nil {
+		return err
+	}
+
+	locales, err := fs.ReadDir(fsys, "locales")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read locales directory: %w", err)
+	}
+	for _, entry := range locales {
+		if entry.IsDir() {
+			continue
+		}
+		_, err = r.bundle.LoadMessageFileFS(fsys, path.Join("locales", entry.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to load locale file '%s': %w", entry.Name(), err)
+		}
+	}
+
+	templates, err := fs.ReadDir(fsys, "templates")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read templates directory: %w", err)
+	}
+	for _, entry := range templates {
+		name := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join("templates", name))
+		if err != nil {
+			return fmt.Errorf("failed to read template '%s': %w", name, err)
+		}
+
+		switch {
+		case strings.HasSuffix(name, textTemplateSuffix):
+			_, err = r.textTemplate.New(name).Parse(string(content))
+		case strings.HasSuffix(name, htmlTemplateSuffix):
+			_, err = r.htmlTemplate.New(name).Parse(string(content))
+		default:
+			return fmt.Errorf("template '%s' must end with '%s' or '%s'", name, textTemplateSuffix, htmlTemplateSuffix)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse template '%s': %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// validateMessageIDs checks that every message ID used as a literal argument of the translate function in a template
+// and the subject of every template exist in the default language, so a missing translation is noticed at startup and
+// not when an email is sent.
+func (r *Renderer) validateMessageIDs() error {
+	var trees []*parse.Tree
+	for _, t := range r.textTemplate.Templates() {
+		trees = append(trees, t.Tree)
+	}
+	for _, t := range r.htmlTemplate.Templates() {
+		trees = append(trees, t.Tree)
+	}
+
+	localizer := i18n.NewLocalizer(r.bundle, language.English.String())
+	var errs []error
+	for _, tree := range trees {
+		if tree == nil || tree.Root == nil {
+			continue
+		}
+		for _, messageID := range referencedMessageIDs(tree.Root) {
+			_, err := localizer.Localize(&i18n.LocalizeConfig{MessageID: messageID})
+			var notFoundErr *i18n.MessageNotFoundErr
+			if errors.As(err, &notFoundErr) {
+				errs = append(errs, fmt.Errorf("template '%s' references unknown message ID '%s'", tree.ParseName, messageID))
+			}
+		}
+	}
+
+	// the subject of an email is looked up by the name of its template
+	for _, name := range r.TemplateNames() {
+		messageID := fmt.Sprintf("subject_%s", name)
+		_, err := localizer.Localize(&i18n.LocalizeConfig{MessageID: messageID})
+		var notFoundErr *i18n.MessageNotFoundErr
+		if errors.As(err, &notFoundErr) {
+			errs = append(errs, fmt.Errorf("template '%s%s' has no subject, message ID '%s' is missing", name, textTemplateSuffix, messageID))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// referencedMessageIDs returns all message IDs passed as string literals to the translate function within the node.
+func referencedMessageIDs(node parse.Node) []string {
+	var ids []string
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			ids = append(ids, referencedMessageIDs(child)...)
+		}
+	case *parse.ActionNode:
+		ids = append(ids, referencedMessageIDs(n.Pipe)...)
+	case *parse.PipeNode:
+		if n == nil {
+			return nil
+		}
+		for _, cmd := range n.Cmds {
+			ids = append(ids, referencedMessageIDs(cmd)...)
+		}
+	case *parse.CommandNode:
+		if len(n.Args) > 1 {
+			if identifier, ok := n.Args[0].(*parse.IdentifierNode); ok && identifier.Ident == "t" {
+				if messageID, ok := n.Args[1].(*parse.StringNode); ok {
+					ids = append(ids, messageID.Text)
+				}
+			}
+		}
+		for _, arg := range n.Args {
+			ids = append(ids, referencedMessageIDs(arg)...)
+		}
+	case *parse.IfNode:
+		ids = append(ids, referencedBranchMessageIDs(&n.BranchNode)...)
+	case *parse.RangeNode:
+		ids = append(ids, referencedBranchMessageIDs(&n.BranchNode)...)
+	case *parse.WithNode:
+		ids = append(ids, referencedBranchMessageIDs(&n.BranchNode)...)
+	case *parse.TemplateNode:
+		ids = append(ids, referencedMessageIDs(n.Pipe)...)
+	}
+	return ids
+}
+
+func referencedBranchMessageIDs(n *parse.BranchNode) []string {
+	ids := referencedMessageIDs(n.Pipe)
+	ids = append(ids, referencedMessageIDs(n.List)...)
+	if n.ElseList != nil {
+		ids = append(ids, referencedMessageIDs(n.ElseList)...)
+	}
+	return ids
+}
+
 // translate is a helper function to translate texts in a template
 func (r *Renderer) translate(messageID string, templateData map[string]interface{}) string {
 	localizer := i18n.NewLocalizer(r.bundle, templateData["renderer_lang"].(string))
@@ -55,19 +239,47 @@ func (r *Renderer) translate(messageID string, templateData map[string]interface
 	})
 }
 
-// Render renders a template with the given data and lang.
+// Render renders a template with the given data and lang. Templates ending with "_html.tmpl" are rendered as HTML,
+// all others as plain text.
 // The lang can be the contents of Accept-Language headers as defined in http://www.ietf.org/rfc/rfc2616.txt.
 func (r *Renderer) Render(templateName string, lang string, data map[string]interface{}) (string, error) {
 	r.localizer = i18n.NewLocalizer(r.bundle, lang) // set the localizer, so the test will be translated to the given language
 	data["renderer_lang"] = lang
 	templateBuffer := &bytes.Buffer{}
-	err := r.template.ExecuteTemplate(templateBuffer, templateName, data)
+	var err error
+	if strings.HasSuffix(templateName, htmlTemplateSuffix) {
+		err = r.htmlTemplate.ExecuteTemplate(templateBuffer, templateName, data)
+	} else {
+		err = r.textTemplate.ExecuteTemplate(templateBuffer, templateName, data)
+	}
 	if err != nil {
 		return "", fmt.Errorf("failed to fill template with data: %w", err)
 	}
 	return strings.TrimSpace(templateBuffer.String()), nil
 }
 
+// HasTemplate reports whether a template with the given name exists.
+func (r *Renderer) HasTemplate(templateName string) bool {
+	if strings.HasSuffix(templateName, htmlTemplateSuffix) {
+		return r.htmlTemplate.Lookup(templateName) != nil
+	}
+	return r.textTemplate.Lookup(templateName) != nil
+}
+
+// TemplateNames returns the sorted names of all templates without the content type and the file ending, e.g.
+// "email_verification" for "email_verification_text.tmpl". Only templates with a text variant are returned, because
+// every email needs a plain text body.
+func (r *Renderer) TemplateNames() []string {
+	var names []string
+	for _, t := range r.textTemplate.Templates() {
+		if name, found := strings.CutSuffix(t.Name(), textTemplateSuffix); found {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
 func (r *Renderer) Translate(lang string, messageID string, data map[string]interface{}) string {
 	loc := i18n.NewLocalizer(r.bundle, lang)
 	return loc.MustLocalize(&i18n.LocalizeConfig{
@@ -1,19 +1,25 @@
 package mail
 
 import (
+	"fmt"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
 func TestNewRenderer(t *testing.T) {
-	renderer, err := NewRenderer()
+	renderer, err := NewRenderer("")
 
 	assert.NoError(t, err)
 	assert.NotEmpty(t, renderer)
 }
 
 func TestRenderer_Render(t *testing.T) {
-	renderer, err := NewRenderer()
+	renderer, err := NewRenderer("")
 
 	assert.NoError(t, err)
 	assert.NotEmpty(t, renderer)
@@ -54,7 +60,7 @@ func TestRenderer_Render(t *testing.T) {
 		{
 			Name:     "Login text template without translations for language",
 			Template: "login_text.tmpl",
-			Lang:     "es",
+			Lang:     "ko",
 			Expected: "Enter the following passcode to verify your identity:\n\n123456\n\nThe passcode is valid for 5 minutes.",
 			WantErr:  false,
 		},
@@ -75,7 +81,7 @@ func TestRenderer_Render(t *testing.T) {
 }
 
 func TestRenderer_Translate(t *testing.T) {
-	renderer, err := NewRenderer()
+	renderer, err := NewRenderer("")
 
 	assert.NoError(t, err)
 	assert.NotEmpty(t, renderer)
@@ -97,6 +103,26 @@ func TestRenderer_Translate(t *testing.T) {
 			},
 			Expected: "123456 is your passcode for Test Service",
 		},
+		{
+			Name:      "Translate email_subject_login with preferred language",
+			MessageID: "email_subject_login",
+			Lang:      LanguagePreference("de", "fr-CH, fr;q=0.9, en;q=0.8"),
+			Data: map[string]interface{}{
+				"ServiceName": "Test Service",
+				"Code":        "123456",
+			},
+			Expected: "123456 ist dein Code für Test Service",
+		},
+		{
+			Name:      "Translate email_subject_login with unsupported preferred language",
+			MessageID: "email_subject_login",
+			Lang:      LanguagePreference("ko", "fr-CH, fr;q=0.9, en;q=0.8"),
+			Data: map[string]interface{}{
+				"ServiceName": "Test Service",
+				"Code":        "123456",
+			},
+			Expected: "123456 est votre code pour Test Service",
+		},
 	}
 
 	for _, test := range tests {
@@ -106,3 +132,279 @@ func TestRenderer_Translate(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderer_RenderNewDeviceLogin(t *testing.T) {
+	renderer, err := NewRenderer("")
+	assert.NoError(t, err)
+
+	templateData := map[string]interface{}{
+		"ServiceName":   "Test Service",
+		"UserAgent":     "Linux (Firefox)",
+		"IpAddress":     "127.0.0.1",
+		"LoginTime":     "2024-01-01 12:00:00 UTC",
+		"RevocationURL": "https://auth.example.com/sessions/revoke?token=abc",
+	}
+
+	result, err := renderer.Render("new_device_login_text.tmpl", "en", templateData)
+	assert.NoError(t, err)
+	assert.Contains(t, result, "Your Test Service account was just signed in from a new device or location.")
+	assert.Contains(t, result, "Device: Linux (Firefox), IP address: 127.0.0.1, time: 2024-01-01 12:00:00 UTC")
+	assert.Contains(t, result, "https://auth.example.com/sessions/revoke?token=abc")
+}
+
+func TestRenderer_RenderSecurityNotifications(t *testing.T) {
+	renderer, err := NewRenderer("")
+	assert.NoError(t, err)
+
+	templates := []string{
+		"password_changed_text.tmpl",
+		"passkey_created_text.tmpl",
+		"passkey_deleted_text.tmpl",
+		"email_deleted_text.tmpl",
+		"primary_email_changed_text.tmpl",
+	}
+
+	for _, lang := range []string{"en", "zh-CN"} {
+		for _, template := range templates {
+			t.Run(template+" "+lang, func(t *testing.T) {
+				templateData := map[string]interface{}{
+					"ServiceName":          "Test Service",
+					"EmailAddress":         "new@example.com",
+					"PreviousEmailAddress": "old@example.com",
+					"PasskeyName":          "iCloud Keychain",
+				}
+
+				result, err := renderer.Render(template, lang, templateData)
+				assert.NoError(t, err)
+				assert.Contains(t, result, "Test Service")
+			})
+		}
+	}
+}
+
+func TestRenderer_RenderHTML(t *testing.T) {
+	renderer, err := NewRenderer("")
+	assert.NoError(t, err)
+
+	templateData := map[string]interface{}{
+		"ServiceName":   "Test <Service>",
+		"UserAgent":     "Linux (Firefox)",
+		"IpAddress":     "127.0.0.1",
+		"LoginTime":     "2024-01-01 12:00:00 UTC",
+		"RevocationURL": "https://auth.example.com/sessions/revoke?token=abc&lang=en",
+	}
+
+	result, err := renderer.Render("new_device_login_html.tmpl", "en", templateData)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(result, "<!DOCTYPE html>"))
+	assert.Contains(t, result, "Your Test &lt;Service&gt; account was just signed in from a new device or location.")
+	assert.Contains(t, result, `href="https://auth.example.com/sessions/revoke?token=abc&amp;lang=en"`)
+
+	text, err := renderer.Render("new_device_login_text.tmpl", "en", templateData)
+	assert.NoError(t, err)
+	assert.Contains(t, text, "Your Test <Service> account")
+	assert.Contains(t, text, "https://auth.example.com/sessions/revoke?token=abc&lang=en")
+}
+
+func TestRenderer_RenderPasscodeLink(t *testing.T) {
+	renderer, err := NewRenderer("")
+	assert.NoError(t, err)
+
+	templateData := map[string]interface{}{
+		"TTL":         5,
+		"Code":        "123456",
+		"Link":        "https://auth.example.com/passcode/link?token=abc",
+		"SameBrowser": true,
+	}
+
+	result, err := renderer.Render("login_text.tmpl", "en", templateData)
+	assert.NoError(t, err)
+	assert.Equal(t, "Open the following link to sign in:\n\n"+
+		"https://auth.example.com/passcode/link?token=abc\n\n"+
+		"Open the link in the same browser you requested it in.\n\n"+
+		"Alternatively, enter the following passcode:\n\n"+
+		"123456\n\n"+
+		"The link is valid for 5 minutes.", result)
+
+	delete(templateData, "Code")
+	templateData["SameBrowser"] = false
+	result, err = renderer.Render("recovery_text.tmpl", "en", templateData)
+	assert.NoError(t, err)
+	assert.Equal(t, "Open the following link to recover your account:\n\n"+
+		"https://auth.example.com/passcode/link?token=abc\n\n"+
+		"The link is valid for 5 minutes.", result)
+
+	result, err = renderer.Render("email_verification_html.tmpl", "de", templateData)
+	assert.NoError(t, err)
+	assert.Contains(t, result, `href="https://auth.example.com/passcode/link?token=abc"`)
+	assert.NotContains(t, result, "123456")
+
+	assert.Equal(t, "Your sign in link for Test Service", renderer.Translate("en", "subject_login_link", map[string]interface{}{"ServiceName": "Test Service"}))
+}
+
+func TestRenderer_TemplateNames(t *testing.T) {
+	renderer, err := NewRenderer("")
+	assert.NoError(t, err)
+
+	names := renderer.TemplateNames()
+	assert.Contains(t, names, "login")
+	assert.Contains(t, names, "new_device_login")
+	assert.NotContains(t, names, "layout")
+
+	for _, name := range names {
+		assert.True(t, renderer.HasTemplate(name+"_text.tmpl"))
+		assert.True(t, renderer.HasTemplate(name+"_html.tmpl"), name)
+	}
+	assert.False(t, renderer.HasTemplate("not_existing_html.tmpl"))
+}
+
+func writeOverride(t *testing.T, dir string, name string, content string) {
+	err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	require.NoError(t, err)
+}
+
+func TestNewRenderer_Overrides(t *testing.T) {
+	dir := t.TempDir()
+	writeOverride(t, dir, "templates/login_text.tmpl", `{{t "custom_login_text" .}} {{ .Code }}`)
+	writeOverride(t, dir, "templates/welcome_text.tmpl", `{{t "welcome_text" .}}`)
+	writeOverride(t, dir, "locales/custom.en.yaml", `
+custom_login_text:
+  other: "Your code:"
+ttl_text:
+  other: "Valid for {{ .TTL }} minutes only."
+welcome_text:
+  other: "Welcome to {{ .ServiceName }}"
+subject_welcome:
+  other: "Welcome"
+`)
+	writeOverride(t, dir, "locales/custom.de.yaml", `
+custom_login_text:
+  other: "Ihr Code:"
+`)
+
+	renderer, err := NewRenderer(dir)
+	require.NoError(t, err)
+
+	result, err := renderer.Render("login_text.tmpl", "en", map[string]interface{}{"Code": "123456"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Your code: 123456", result)
+
+	result, err = renderer.Render("login_text.tmpl", "de", map[string]interface{}{"Code": "123456"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Ihr Code: 123456", result)
+
+	// embedded templates which are not overridden use the overridden messages
+	result, err = renderer.Render("recovery_text.tmpl", "en", map[string]interface{}{"Code": "123456", "TTL": 5})
+	assert.NoError(t, err)
+	assert.Contains(t, result, "Valid for 5 minutes only.")
+
+	// the embedded html template is kept when only the text template is overridden
+	result, err = renderer.Render("login_html.tmpl", "en", map[string]interface{}{"Code": "123456", "TTL": 5})
+	assert.NoError(t, err)
+	assert.Contains(t, result, "Enter the following passcode to verify your identity:")
+
+	assert.Contains(t, renderer.TemplateNames(), "welcome")
+	assert.False(t, renderer.HasTemplate("welcome_html.tmpl"))
+}
+
+func TestNewRenderer_OverrideErrors(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Files         map[string]string
+		ExpectedError string
+	}{
+		{
+			Name:          "unknown message id",
+			Files:         map[string]string{"templates/login_html.tmpl": `{{if .Code}}{{t "not_existing" .}}{{end}}`},
+			ExpectedError: "template 'login_html.tmpl' references unknown message ID 'not_existing'",
+		},
+		{
+			Name:          "missing subject",
+			Files:         map[string]string{"templates/welcome_text.tmpl": `{{t "login_text" .}}`},
+			ExpectedError: "message ID 'subject_welcome' is missing",
+		},
+		{
+			Name:          "invalid template name",
+			Files:         map[string]string{"templates/welcome.tmpl": `Welcome`},
+			ExpectedError: "template 'welcome.tmpl' must end with",
+		},
+		{
+			Name:          "invalid template",
+			Files:         map[string]string{"templates/login_text.tmpl": `{{t "login_text" .}`},
+			ExpectedError: "failed to parse template 'login_text.tmpl'",
+		},
+		{
+			Name:          "invalid locale file",
+			Files:         map[string]string{"locales/custom.en.yaml": `login_text: [`},
+			ExpectedError: "failed to load locale file 'custom.en.yaml'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range test.Files {
+				writeOverride(t, dir, name, content)
+			}
+
+			_, err := NewRenderer(dir)
+			assert.ErrorContains(t, err, test.ExpectedError)
+		})
+	}
+
+	t.Run("not existing directory", func(t *testing.T) {
+		_, err := NewRenderer(filepath.Join(t.TempDir(), "not_existing"))
+		assert.Error(t, err)
+	})
+}
+
+func TestLocales_Complete(t *testing.T) {
+	entries, err := mailFS.ReadDir("locales")
+	require.NoError(t, err)
+
+	for _, entry := range entries {
+		name, lang, _ := strings.Cut(strings.TrimSuffix(entry.Name(), ".yaml"), ".")
+		if lang == "en" {
+			continue
+		}
+
+		t.Run(entry.Name(), func(t *testing.T) {
+			expected := readLocaleMessageIDs(t, fmt.Sprintf("locales/%s.en.yaml", name))
+			actual := readLocaleMessageIDs(t, "locales/"+entry.Name())
+			assert.ElementsMatch(t, expected, actual)
+		})
+	}
+}
+
+func readLocaleMessageIDs(t *testing.T, name string) []string {
+	content, err := mailFS.ReadFile(name)
+	require.NoError(t, err)
+
+	messages := map[string]interface{}{}
+	require.NoError(t, yaml.Unmarshal(content, &messages))
+
+	var ids []string
+	for id := range messages {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func TestNormalizeLanguage(t *testing.T) {
+	lang, err := NormalizeLanguage(" pt-br ")
+	assert.NoError(t, err)
+	assert.Equal(t, "pt-BR", lang)
+
+	for _, invalid := range []string{"", "not a language", "de-DE-" + strings.Repeat("x", 40)} {
+		_, err = NormalizeLanguage(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestLanguagePreference(t *testing.T) {
+	assert.Equal(t, "de, en;q=0.8", LanguagePreference("de", "en;q=0.8"))
+	assert.Equal(t, "de", LanguagePreference("de", ""))
+	assert.Equal(t, "en;q=0.8", LanguagePreference("", "en;q=0.8"))
+}
@@ -0,0 +1,401 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/telemetry"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// smtpDialTimeout is the maximum time to establish a connection to the SMTP server.
+	smtpDialTimeout = 10 * time.Second
+	// smtpCommandTimeout is the maximum time for the handshake of a new connection and for sending a single message.
+	smtpCommandTimeout = 30 * time.Second
+)
+
+// smtpMailer sends messages to an SMTP server. Connections are reused if the pool is enabled.
+type smtpMailer struct {
+	host        string
+	addr        string
+	mode        config.SMTPTLSMode
+	tlsConfig   *tls.Config
+	user        string
+	password    string
+	pool        *smtpPool
+	dkim        *DKIMSigner
+	fromAddress string
+	fromName    string
+}
+
+func newSMTPMailer(cfg config.EmailDelivery) (Mailer, error) {
+	port, err := strconv.Atoi(cfg.SMTP.Port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SMTP port: %w", err)
+	}
+
+	tlsConfig, err := newSMTPTLSConfig(cfg.SMTP)
+	if err != nil {
+		return nil, err
+	}
+
+	dkim, err := NewDKIMSigner(cfg.SMTP.DKIM)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := cfg.SMTP.TLS.Mode
+	if mode == "" && port == 465 {
+		mode = config.SMTPTLSModeImplicit
+	}
+
+	m := &smtpMailer{
+		host:        cfg.SMTP.Host,
+		addr:        net.JoinHostPort(cfg.SMTP.Host, strconv.Itoa(port)),
+		mode:        mode,
+		tlsConfig:   tlsConfig,
+		user:        cfg.SMTP.User,
+		password:    cfg.SMTP.Password,
+		dkim:        dkim,
+		fromAddress: cfg.FromAddress,
+		fromName:    cfg.FromName,
+	}
+	if cfg.SMTP.Pool.Enabled {
+		m.pool = newSMTPPool(cfg.SMTP.Pool)
+	}
+
+	return m, nil
+}
+
+func newSMTPTLSConfig(cfg config.SMTP) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName: cfg.Host,
+		MinVersion: tls.VersionTLS12,
+	}
+	if cfg.TLS.ServerName != "" {
+		tlsConfig.ServerName = cfg.TLS.ServerName
+	}
+
+	if cfg.TLS.CAFile != "" {
+		caPEM, err := os.ReadFile(cfg.TLS.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SMTP CA file: %w", err)
+		}
+		rootCAs := x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(caPEM) {
+			return nil, errors.New("failed to parse SMTP CA file: no certificates found")
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	if cfg.TLS.CertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load SMTP client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
+
+func (m *smtpMailer) Send(message Message) (err error) {
+	_, span := telemetry.StartSpan(context.Background(), "smtp.send", semconv.NetPeerName(m.host))
+	defer func() {
+		telemetry.EndSpan(span, err)
+	}()
+
+	buffer := bytes.Buffer{}
+	_, err = newGomailMessage(message, m.fromAddress, m.fromName).WriteTo(&buffer)
+	if err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+
+	data := buffer.Bytes()
+	if m.dkim != nil {
+		data, err = m.dkim.Sign(data)
+		if err != nil {
+			return err
+		}
+	}
+
+	if m.pool == nil {
+		conn, err := m.dial()
+		if err != nil {
+			return err
+		}
+		err = conn.send(m.fromAddress, message.To, data)
+		if err != nil {
+			conn.close()
+			return err
+		}
+		// the message has been accepted by the server, so a failing QUIT must not fail the delivery, which would lead
+		// to the message being sent again by the send queue
+		conn.quit()
+		return nil
+	}
+
+	return m.pool.do(m.dial, func(conn *smtpConn) error {
+		return conn.send(m.fromAddress, message.To, data)
+	})
+}
+
+// dial connects to the SMTP server, negotiates TLS according to the configured mode and authenticates if
+// credentials are configured.
+func (m *smtpMailer) dial() (*smtpConn, error) {
+	dialer := &net.Dialer{Timeout: smtpDialTimeout}
+
+	var netConn net.Conn
+	var err error
+	if m.mode == config.SMTPTLSModeImplicit {
+		netConn, err = tls.DialWithDialer(dialer, "tcp", m.addr, m.tlsConfig)
+	} else {
+		netConn, err = dialer.Dial("tcp", m.addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	_ = netConn.SetDeadline(time.Now().Add(smtpCommandTimeout))
+
+	client, err := smtp.NewClient(netConn, m.host)
+	if err != nil {
+		_ = netConn.Close()
+		return nil, fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	conn := &smtpConn{client: client, netConn: netConn}
+
+	err = m.handshake(client)
+	if err != nil {
+		conn.close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func (m *smtpMailer) handshake(client *smtp.Client) error {
+	if m.mode != config.SMTPTLSModeNone && m.mode != config.SMTPTLSModeImplicit {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			err := client.StartTLS(m.tlsConfig)
+			if err != nil {
+				return fmt.Errorf("failed to start TLS: %w", err)
+			}
+		} else if m.mode == config.SMTPTLSModeStartTLS {
+			return errors.New("SMTP server does not support STARTTLS")
+		}
+	}
+
+	if m.user == "" {
+		return nil
+	}
+
+	ok, mechanisms := client.Extension("AUTH")
+	if !ok {
+		return errors.New("SMTP server does not support authentication")
+	}
+
+	var auth smtp.Auth
+	if strings.Contains(mechanisms, "PLAIN") {
+		auth = smtp.PlainAuth("", m.user, m.password, m.host)
+	} else if strings.Contains(mechanisms, "LOGIN") {
+		auth = &loginAuth{username: m.user, password: m.password, host: m.host}
+	} else {
+		return fmt.Errorf("SMTP server does not support a known authentication mechanism: %s", mechanisms)
+	}
+
+	err := client.Auth(auth)
+	if err != nil {
+		return fmt.Errorf("failed to authenticate: %w", err)
+	}
+
+	return nil
+}
+
+// smtpConn is an established and authenticated connection to the SMTP server.
+type smtpConn struct {
+	client   *smtp.Client
+	netConn  net.Conn
+	lastUsed time.Time
+}
+
+// send sends a single message. Rejections by the server (5xx replies) are returned as PermanentError.
+func (c *smtpConn) send(from string, to string, data []byte) error {
+	_ = c.netConn.SetDeadline(time.Now().Add(smtpCommandTimeout))
+
+	err := c.client.Mail(from)
+	if err != nil {
+		return smtpError("sender rejected", err)
+	}
+	err = c.client.Rcpt(to)
+	if err != nil {
+		return smtpError("recipient rejected", err)
+	}
+	writer, err := c.client.Data()
+	if err != nil {
+		return smtpError("failed to start data", err)
+	}
+	_, err = writer.Write(data)
+	if err != nil {
+		_ = writer.Close()
+		return smtpError("failed to write message", err)
+	}
+	err = writer.Close()
+	if err != nil {
+		return smtpError("message rejected", err)
+	}
+
+	c.lastUsed = time.Now()
+	return nil
+}
+
+func (c *smtpConn) close() {
+	_ = c.client.Close()
+}
+
+// quit ends the session and closes the connection, it is closed even if the server does not acknowledge the QUIT.
+func (c *smtpConn) quit() {
+	err := c.client.Quit()
+	if err != nil {
+		c.close()
+	}
+}
+
+func smtpError(message string, err error) error {
+	err = fmt.Errorf("%s: %w", message, err)
+
+	var protocolErr *textproto.Error
+	if errors.As(err, &protocolErr) && protocolErr.Code >= 500 {
+		return &PermanentError{Err: err}
+	}
+
+	return err
+}
+
+// smtpPool keeps connections to the SMTP server open for reuse. At most maxConnections connections are used
+// concurrently, further sends wait for a free connection.
+type smtpPool struct {
+	mu          sync.Mutex
+	idle        []*smtpConn
+	slots       chan struct{}
+	idleTimeout time.Duration
+}
+
+func newSMTPPool(cfg config.SMTPPool) *smtpPool {
+	return &smtpPool{
+		slots:       make(chan struct{}, cfg.MaxConnections),
+		idleTimeout: cfg.IdleTimeout,
+	}
+}
+
+// do calls fn with an idle connection or a new connection created by dial. The connection is returned to the pool if
+// fn succeeds, otherwise it is closed.
+func (p *smtpPool) do(dial func() (*smtpConn, error), fn func(conn *smtpConn) error) error {
+	p.slots <- struct{}{}
+	defer func() { <-p.slots }()
+
+	conn := p.get()
+	if conn == nil {
+		var err error
+		conn, err = dial()
+		if err != nil {
+			return err
+		}
+	}
+
+	err := fn(conn)
+	if err != nil {
+		conn.close()
+		return err
+	}
+
+	p.put(conn)
+	return nil
+}
+
+// get returns the most recently used idle connection which is still alive. Connections idle for longer than the idle
+// timeout are closed.
+func (p *smtpPool) get() *smtpConn {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+
+	var conn *smtpConn
+	for i := len(idle) - 1; i >= 0; i-- {
+		candidate := idle[i]
+		if conn != nil {
+			p.put(candidate)
+			continue
+		}
+		if time.Since(candidate.lastUsed) > p.idleTimeout {
+			candidate.close()
+			continue
+		}
+
+		// the server might have closed the connection in the meantime
+		_ = candidate.netConn.SetDeadline(time.Now().Add(smtpCommandTimeout))
+		if candidate.client.Reset() != nil {
+			candidate.close()
+			continue
+		}
+		conn = candidate
+	}
+
+	return conn
+}
+
+func (p *smtpPool) put(conn *smtpConn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.idle = append(p.idle, conn)
+}
+
+// loginAuth implements the LOGIN authentication mechanism, which is not supported by net/smtp but still used by some
+// servers. Like smtp.PlainAuth, it only sends credentials over TLS connections or to localhost.
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(string(fromServer))) {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected server challenge: %s", fromServer)
+	}
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
@@ -0,0 +1,214 @@
+package mail
+
+import (
+	"bufio"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamhanko/hanko/backend/config"
+	"net"
+	"net/smtp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSMTPServer is a minimal SMTP server without TLS support recording connections and received messages.
+type fakeSMTPServer struct {
+	listener    net.Listener
+	rejectRcpt  string
+	rejectQuit  bool
+	mu          sync.Mutex
+	connections int
+	messages    []string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := &fakeSMTPServer{listener: listener}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server.mu.Lock()
+			server.connections++
+			server.mu.Unlock()
+			go server.serve(conn)
+		}
+	}()
+
+	return server
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	write := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+	write("220 localhost ESMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"):
+			write("250-localhost")
+			write("250 8BITMIME")
+		case strings.HasPrefix(command, "RCPT TO:") && s.rejectRcpt != "" && strings.Contains(command, strings.ToUpper(s.rejectRcpt)):
+			write("550 mailbox unavailable")
+		case command == "DATA":
+			write("354 go ahead")
+			message := strings.Builder{}
+			for {
+				line, err = reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				message.WriteString(line)
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, message.String())
+			s.mu.Unlock()
+			write("250 queued")
+		case command == "QUIT" && s.rejectQuit:
+			write("421 service not available")
+			return
+		case command == "QUIT":
+			write("221 bye")
+			return
+		default:
+			write("250 ok")
+		}
+	}
+}
+
+func (s *fakeSMTPServer) config(tlsMode config.SMTPTLSMode, pool bool) config.EmailDelivery {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return config.EmailDelivery{
+		FromAddress: "noreply@example.com",
+		SMTP: config.SMTP{
+			Host: host,
+			Port: port,
+			TLS:  config.SMTPTLS{Mode: tlsMode},
+			Pool: config.SMTPPool{Enabled: pool, MaxConnections: 1, IdleTimeout: time.Minute},
+		},
+	}
+}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	tests := []struct {
+		Name            string
+		Pool            bool
+		WantConnections int
+	}{
+		{Name: "without pool", Pool: false, WantConnections: 2},
+		{Name: "with pool", Pool: true, WantConnections: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			server := newFakeSMTPServer(t)
+			mailer, err := NewMailer(server.config(config.SMTPTLSModeNone, test.Pool))
+			require.NoError(t, err)
+
+			for i := 0; i < 2; i++ {
+				err = mailer.Send(Message{To: "john.doe@example.com", Subject: "Hello", Text: "Hello John"})
+				require.NoError(t, err)
+			}
+
+			server.mu.Lock()
+			defer server.mu.Unlock()
+			assert.Equal(t, test.WantConnections, server.connections)
+			require.Len(t, server.messages, 2)
+			assert.Contains(t, server.messages[0], "Subject: Hello")
+			assert.Contains(t, server.messages[0], "Message-ID: <")
+			assert.Contains(t, server.messages[0], "@example.com>")
+		})
+	}
+}
+
+func TestSMTPMailer_SendRejected(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	server.rejectRcpt = "unknown@example.com"
+	mailer, err := NewMailer(server.config(config.SMTPTLSModeNone, true))
+	require.NoError(t, err)
+
+	err = mailer.Send(Message{To: "unknown@example.com", Subject: "Hello", Text: "Hello"})
+	assert.Error(t, err)
+	assert.True(t, IsPermanent(err))
+
+	err = mailer.Send(Message{To: "john.doe@example.com", Subject: "Hello", Text: "Hello"})
+	assert.NoError(t, err)
+}
+
+func TestSMTPMailer_SendQuitRejected(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	server.rejectQuit = true
+	mailer, err := NewMailer(server.config(config.SMTPTLSModeNone, false))
+	require.NoError(t, err)
+
+	// the message has been accepted, so the rejected QUIT is ignored
+	err = mailer.Send(Message{To: "john.doe@example.com", Subject: "Hello", Text: "Hello"})
+	assert.NoError(t, err)
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Len(t, server.messages, 1)
+}
+
+func TestSMTPMailer_StartTLSRequired(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	mailer, err := NewMailer(server.config(config.SMTPTLSModeStartTLS, false))
+	require.NoError(t, err)
+
+	err = mailer.Send(Message{To: "john.doe@example.com", Subject: "Hello", Text: "Hello"})
+	assert.ErrorContains(t, err, "STARTTLS")
+	assert.False(t, IsPermanent(err))
+}
+
+func TestNewSMTPTLSConfig(t *testing.T) {
+	tlsConfig, err := newSMTPTLSConfig(config.SMTP{Host: "smtp.example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, "smtp.example.com", tlsConfig.ServerName)
+	assert.Nil(t, tlsConfig.RootCAs)
+
+	tlsConfig, err = newSMTPTLSConfig(config.SMTP{Host: "10.0.0.1", TLS: config.SMTPTLS{ServerName: "smtp.example.com"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "smtp.example.com", tlsConfig.ServerName)
+
+	_, err = newSMTPTLSConfig(config.SMTP{Host: "smtp.example.com", TLS: config.SMTPTLS{CAFile: "./does-not-exist.pem"}})
+	assert.Error(t, err)
+
+	_, err = newSMTPTLSConfig(config.SMTP{Host: "smtp.example.com", TLS: config.SMTPTLS{CertFile: "./does-not-exist.pem", KeyFile: "./does-not-exist.key"}})
+	assert.Error(t, err)
+}
+
+func TestLoginAuth(t *testing.T) {
+	auth := &loginAuth{username: "user", password: "secret", host: "smtp.example.com"}
+
+	_, _, err := auth.Start(&smtp.ServerInfo{Name: "smtp.example.com", TLS: false})
+	assert.Error(t, err)
+
+	mechanism, _, err := auth.Start(&smtp.ServerInfo{Name: "smtp.example.com", TLS: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "LOGIN", mechanism)
+
+	response, err := auth.Next([]byte("Username:"), true)
+	assert.NoError(t, err)
+	assert.Equal(t, "user", string(response))
+	response, err = auth.Next([]byte("Password:"), true)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", string(response))
+	_, err = auth.Next([]byte("Something:"), true)
+	assert.Error(t, err)
+}
@@ -0,0 +1,75 @@
+// Package outbox contains the parts shared by the dispatchers of persisted outboxes, i.e. queues of messages (like
+// emails or webhook jobs) which are stored in the database and delivered in the background.
+package outbox
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"math/rand"
+	"time"
+)
+
+// Dispatcher delivers the due entries of an outbox.
+type Dispatcher interface {
+	// Dispatch delivers all due entries. It returns once all listed entries have been processed.
+	Dispatch() error
+	// Prune deletes the finished entries which are older than the retention of the outbox.
+	Prune(now time.Time)
+}
+
+// Run calls Dispatch of the given dispatcher every interval and Prune every pruneInterval until the stop channel is
+// closed. Errors returned by Dispatch are logged with the given logger.
+func Run(dispatcher Dispatcher, interval time.Duration, pruneInterval time.Duration, logger echo.Logger, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastPrune time.Time
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			err := dispatcher.Dispatch()
+			if err != nil {
+				logger.Error(fmt.Errorf("failed to dispatch outbox: %w", err))
+			}
+
+			if time.Since(lastPrune) > pruneInterval {
+				lastPrune = time.Now()
+				dispatcher.Prune(lastPrune)
+			}
+		}
+	}
+}
+
+// Backoff computes the delay before the next delivery attempt of an entry.
+type Backoff struct {
+	// BaseDelay is the delay after the first failed attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay.
+	MaxDelay time.Duration
+}
+
+// Delay returns the delay after the given number of failed attempts. The delay doubles with every attempt (starting at
+// the base delay, capped at the max delay) and is randomized between 50% and 100% of that value, so retries of entries
+// which failed at the same time are spread.
+func (b Backoff) Delay(attempts int) time.Duration {
+	delay := b.MaxDelay
+	if attempts < 1 {
+		attempts = 1
+	}
+	if attempts <= 20 && b.BaseDelay<<(attempts-1) < b.MaxDelay {
+		delay = b.BaseDelay << (attempts - 1)
+	}
+
+	half := int64(delay / 2)
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// MaxAttempts returns the configured maximum number of delivery attempts, which is at least one.
+func MaxAttempts(configured int) int {
+	if configured < 1 {
+		return 1
+	}
+	return configured
+}
@@ -0,0 +1,68 @@
+package outbox
+
+import (
+	"errors"
+	"github.com/labstack/gommon/log"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackoff_Delay(t *testing.T) {
+	backoff := Backoff{BaseDelay: 10 * time.Second, MaxDelay: time.Hour}
+
+	for attempts := 0; attempts <= 30; attempts++ {
+		expected := backoff.BaseDelay
+		if attempts > 1 {
+			expected = backoff.BaseDelay << (attempts - 1)
+		}
+		if attempts > 20 || expected > backoff.MaxDelay {
+			expected = backoff.MaxDelay
+		}
+
+		delay := backoff.Delay(attempts)
+		assert.GreaterOrEqual(t, delay, expected/2, "attempt %d", attempts)
+		assert.LessOrEqual(t, delay, expected, "attempt %d", attempts)
+	}
+}
+
+func TestMaxAttempts(t *testing.T) {
+	assert.Equal(t, 1, MaxAttempts(-1))
+	assert.Equal(t, 1, MaxAttempts(0))
+	assert.Equal(t, 5, MaxAttempts(5))
+}
+
+type testDispatcher struct {
+	dispatched atomic.Int32
+	pruned     atomic.Int32
+}
+
+func (d *testDispatcher) Dispatch() error {
+	d.dispatched.Add(1)
+	return errors.New("dispatch failed")
+}
+
+func (d *testDispatcher) Prune(_ time.Time) {
+	d.pruned.Add(1)
+}
+
+func TestRun(t *testing.T) {
+	dispatcher := &testDispatcher{}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		Run(dispatcher, time.Millisecond, time.Hour, log.New("test"), stop)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return dispatcher.dispatched.Load() >= 3
+	}, time.Second, time.Millisecond, "failed dispatches must not stop the loop")
+
+	close(stop)
+	<-done
+
+	assert.Equal(t, int32(1), dispatcher.pruned.Load(), "the outbox must only be pruned once per prune interval")
+}
@@ -0,0 +1,71 @@
+package persistence
+
+import (
+	"errors"
+	"github.com/gobuffalo/pop/v6"
+	"sync"
+)
+
+// ErrUntrackedTransaction is returned by AfterCommit for transactions which have not been begun by
+// Persister.Transaction (e.g. by pop.Connection.Transaction), because nothing would run the registered function.
+var ErrUntrackedTransaction = errors.New("functions can only be run after the commit of transactions begun by Persister.Transaction")
+
+// afterCommit holds the functions registered with AfterCommit per tracked transaction.
+var afterCommit = struct {
+	sync.Mutex
+	callbacks map[*pop.Tx][]func()
+}{callbacks: make(map[*pop.Tx][]func())}
+
+// AfterCommit runs fn once the transaction of the given connection has been committed by Persister.Transaction, fn is
+// discarded if the transaction is rolled back. fn is run immediately if the connection is not a transaction.
+// ErrUntrackedTransaction is returned if the transaction is not tracked (see TrackAfterCommit).
+func AfterCommit(tx *pop.Connection, fn func()) error {
+	if tx == nil || tx.TX == nil {
+		fn()
+		return nil
+	}
+
+	afterCommit.Lock()
+	defer afterCommit.Unlock()
+
+	callbacks, ok := afterCommit.callbacks[tx.TX]
+	if !ok {
+		return ErrUntrackedTransaction
+	}
+	afterCommit.callbacks[tx.TX] = append(callbacks, fn)
+
+	return nil
+}
+
+// TrackAfterCommit allows to register functions for the transaction of the given connection with AfterCommit. It must
+// be called by the owner of the transaction once it has been begun, followed by FinishAfterCommit once it has ended.
+func TrackAfterCommit(tx *pop.Connection) {
+	afterCommit.Lock()
+	defer afterCommit.Unlock()
+
+	if _, ok := afterCommit.callbacks[tx.TX]; !ok {
+		afterCommit.callbacks[tx.TX] = []func(){}
+	}
+}
+
+// FinishAfterCommit stops tracking the transaction of the given connection. The functions registered for it are run
+// if the transaction has been committed and discarded otherwise. Calling it again for the same transaction or for a
+// nil connection has no effect.
+func FinishAfterCommit(tx *pop.Connection, committed bool) {
+	if tx == nil || tx.TX == nil {
+		return
+	}
+
+	afterCommit.Lock()
+	callbacks := afterCommit.callbacks[tx.TX]
+	delete(afterCommit.callbacks, tx.TX)
+	afterCommit.Unlock()
+
+	if !committed {
+		return
+	}
+
+	for _, callback := range callbacks {
+		callback()
+	}
+}
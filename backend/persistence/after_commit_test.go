@@ -0,0 +1,57 @@
+package persistence
+
+import (
+	"github.com/gobuffalo/pop/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestAfterCommit(t *testing.T) {
+	tests := []struct {
+		name      string
+		committed bool
+		expected  []string
+	}{
+		{name: "committed", committed: true, expected: []string{"first", "second"}},
+		{name: "rolled back", committed: false, expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &pop.Connection{TX: &pop.Tx{}}
+			TrackAfterCommit(tx)
+
+			var run []string
+			require.NoError(t, AfterCommit(tx, func() { run = append(run, "first") }))
+			// copies of the connection share the transaction
+			require.NoError(t, AfterCommit(&pop.Connection{TX: tx.TX}, func() { run = append(run, "second") }))
+			assert.Empty(t, run, "the functions must not run before the transaction has ended")
+
+			FinishAfterCommit(tx, tt.committed)
+			assert.Equal(t, tt.expected, run)
+
+			// the transaction is not tracked anymore
+			FinishAfterCommit(tx, true)
+			assert.Equal(t, tt.expected, run)
+			assert.ErrorIs(t, AfterCommit(tx, func() {}), ErrUntrackedTransaction)
+			assert.Empty(t, afterCommit.callbacks)
+		})
+	}
+}
+
+func TestAfterCommit_NoTransaction(t *testing.T) {
+	for _, tx := range []*pop.Connection{nil, {}} {
+		run := false
+		require.NoError(t, AfterCommit(tx, func() { run = true }))
+		assert.True(t, run)
+	}
+}
+
+func TestAfterCommit_UntrackedTransaction(t *testing.T) {
+	run := false
+	err := AfterCommit(&pop.Connection{TX: &pop.Tx{}}, func() { run = true })
+	assert.ErrorIs(t, err, ErrUntrackedTransaction)
+	assert.False(t, run)
+	assert.Empty(t, afterCommit.callbacks, "functions of untracked transactions must not be kept")
+}
@@ -0,0 +1,130 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"golang.org/x/exp/slices"
+	"time"
+)
+
+type AuditLogChainPersister interface {
+	// GetHead returns the head of the hash chain. If lock is set, the head is locked until the end of the transaction
+	// of the persister, so that only one transaction at a time can append to the chain.
+	GetHead(lock bool) (*models.AuditLogChainHead, error)
+	UpdateHead(head models.AuditLogChainHead) error
+	CreateCheckpoint(checkpoint models.AuditLogCheckpoint) error
+	GetLatestCheckpoint() (*models.AuditLogCheckpoint, error)
+	// ListCheckpoints returns all checkpoints ordered by chain sequence.
+	ListCheckpoints() ([]models.AuditLogCheckpoint, error)
+	// ListCheckpointKeyIDs returns the IDs of all keys checkpoints have been signed with.
+	ListCheckpointKeyIDs() ([]string, error)
+}
+
+type auditLogChainPersister struct {
+	db *pop.Connection
+}
+
+func NewAuditLogChainPersister(db *pop.Connection) AuditLogChainPersister {
+	return &auditLogChainPersister{db: db}
+}
+
+func (p *auditLogChainPersister) GetHead(lock bool) (*models.AuditLogChainHead, error) {
+	query := "SELECT * FROM audit_log_chain_heads WHERE id = ?"
+	if lock {
+		query += " FOR UPDATE"
+	}
+
+	head := models.AuditLogChainHead{}
+	err := p.db.RawQuery(query, models.AuditLogChainHeadID).First(&head)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		// the head is created by the migration, an empty chain is assumed if it has been deleted
+		return &models.AuditLogChainHead{ID: models.AuditLogChainHeadID}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit log chain head: %w", err)
+	}
+
+	return &head, nil
+}
+
+func (p *auditLogChainPersister) UpdateHead(head models.AuditLogChainHead) error {
+	now := time.Now().UTC()
+	count, err := p.db.RawQuery(
+		"UPDATE audit_log_chain_heads SET chain_sequence = ?, hash = ?, updated_at = ? WHERE id = ?",
+		head.ChainSequence, head.Hash, now, models.AuditLogChainHeadID,
+	).ExecWithCount()
+	if err != nil {
+		return fmt.Errorf("failed to update audit log chain head: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	err = p.db.RawQuery(
+		"INSERT INTO audit_log_chain_heads (id, chain_sequence, hash, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
+		models.AuditLogChainHeadID, head.ChainSequence, head.Hash, now, now,
+	).Exec()
+	if err != nil {
+		return fmt.Errorf("failed to create audit log chain head: %w", err)
+	}
+
+	return nil
+}
+
+func (p *auditLogChainPersister) CreateCheckpoint(checkpoint models.AuditLogCheckpoint) error {
+	vErr, err := p.db.ValidateAndCreate(&checkpoint)
+	if err != nil {
+		return fmt.Errorf("failed to store audit log checkpoint: %w", err)
+	}
+	if vErr != nil && vErr.HasAny() {
+		return fmt.Errorf("audit log checkpoint object validation failed: %w", vErr)
+	}
+
+	return nil
+}
+
+func (p *auditLogChainPersister) GetLatestCheckpoint() (*models.AuditLogCheckpoint, error) {
+	checkpoint := models.AuditLogCheckpoint{}
+	err := p.db.Order("chain_sequence desc").First(&checkpoint)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest audit log checkpoint: %w", err)
+	}
+
+	return &checkpoint, nil
+}
+
+func (p *auditLogChainPersister) ListCheckpoints() ([]models.AuditLogCheckpoint, error) {
+	checkpoints := []models.AuditLogCheckpoint{}
+	err := p.db.Order("chain_sequence asc").All(&checkpoints)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return checkpoints, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit log checkpoints: %w", err)
+	}
+
+	return checkpoints, nil
+}
+
+func (p *auditLogChainPersister) ListCheckpointKeyIDs() ([]string, error) {
+	checkpoints := []models.AuditLogCheckpoint{}
+	err := p.db.Select("key_id").All(&checkpoints)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to list audit log checkpoint key ids: %w", err)
+	}
+
+	keyIDs := []string{}
+	for _, checkpoint := range checkpoints {
+		if !slices.Contains(keyIDs, checkpoint.KeyID) {
+			keyIDs = append(keyIDs, checkpoint.KeyID)
+		}
+	}
+
+	return keyIDs, nil
+}
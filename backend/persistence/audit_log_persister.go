@@ -15,8 +15,30 @@ type AuditLogPersister interface {
 	Create(auditLog models.AuditLog) error
 	Get(id uuid.UUID) (*models.AuditLog, error)
 	List(page int, perPage int, startTime *time.Time, endTime *time.Time, types []string, userId string, email string, ip string, searchString string) ([]models.AuditLog, error)
+	// ListAfter returns at most limit audit logs ordered by creation time (oldest first) which come after the given
+	// cursor, i.e. the last audit log of the previous call. The first audit logs are returned if the cursor is nil.
+	// Unlike List, it uses keyset pagination, so iterating over all audit logs has constant cost per call.
+	ListAfter(cursor *models.AuditLog, limit int, startTime *time.Time, endTime *time.Time, types []string, userId string, email string, ip string, searchString string) ([]models.AuditLog, error)
+	// ListChained returns at most limit audit logs of the hash chain whose chain sequence is greater than the given
+	// one, ordered by chain sequence. Audit logs which are not part of the hash chain are not returned.
+	ListChained(afterSequence int64, limit int) ([]models.AuditLog, error)
+	// ListChainPending returns at most limit audit logs created before the given time which are still waiting to be
+	// appended to the hash chain (see models.AuditLog.ChainPending), oldest first.
+	ListChainPending(createdBefore time.Time, limit int) ([]models.AuditLog, error)
+	// CountChainPending counts the audit logs created before the given time which are still waiting to be appended to
+	// the hash chain.
+	CountChainPending(createdBefore time.Time) (int, error)
+	// UpdateChain stores the chain sequence, previous hash and hash of an audit log which has been appended to the
+	// hash chain after it has been created. The audit log is no longer pending.
+	UpdateChain(auditLog models.AuditLog) error
 	Delete(auditLog models.AuditLog) error
 	Count(startTime *time.Time, endTime *time.Time, types []string, userId string, email string, ip string, searchString string) (int, error)
+	// CountBefore counts the audit logs created before the given time. If types is not empty, only logs of the given
+	// types are counted, logs of the excluded types are never counted.
+	CountBefore(before time.Time, types []string, excludedTypes []string) (int, error)
+	// DeleteBefore deletes at most limit audit logs created before the given time, oldest first. The types are
+	// applied like in CountBefore. It returns the number of deleted logs.
+	DeleteBefore(before time.Time, types []string, excludedTypes []string, limit int) (int, error)
 }
 
 type auditLogPersister struct {
@@ -69,6 +91,89 @@ func (p *auditLogPersister) List(page int, perPage int, startTime *time.Time, en
 	return auditLogs, nil
 }
 
+func (p *auditLogPersister) ListAfter(cursor *models.AuditLog, limit int, startTime *time.Time, endTime *time.Time, types []string, userId string, email string, ip string, searchString string) ([]models.AuditLog, error) {
+	auditLogs := []models.AuditLog{}
+
+	query := p.db.Q()
+	query = p.addQueryParamsToSqlQuery(query, startTime, endTime, types, userId, email, ip, searchString)
+	if cursor != nil {
+		query = query.Where("(created_at > ? OR (created_at = ? AND id > ?))", cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+	}
+
+	err := query.Order("created_at asc, id asc").Limit(limit).All(&auditLogs)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return auditLogs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch auditLogs: %w", err)
+	}
+
+	return auditLogs, nil
+}
+
+func (p *auditLogPersister) ListChained(afterSequence int64, limit int) ([]models.AuditLog, error) {
+	auditLogs := []models.AuditLog{}
+
+	err := p.db.Q().
+		Where("chain_sequence > ?", afterSequence).
+		Order("chain_sequence asc").
+		Limit(limit).
+		All(&auditLogs)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return auditLogs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch chained auditLogs: %w", err)
+	}
+
+	return auditLogs, nil
+}
+
+func (p *auditLogPersister) ListChainPending(createdBefore time.Time, limit int) ([]models.AuditLog, error) {
+	auditLogs := []models.AuditLog{}
+
+	err := p.chainPendingQuery(createdBefore).
+		Order("created_at asc").
+		Limit(limit).
+		All(&auditLogs)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return auditLogs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pending auditLogs: %w", err)
+	}
+
+	return auditLogs, nil
+}
+
+func (p *auditLogPersister) CountChainPending(createdBefore time.Time) (int, error) {
+	count, err := p.chainPendingQuery(createdBefore).Count(&models.AuditLog{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pending auditLog count: %w", err)
+	}
+
+	return count, nil
+}
+
+func (p *auditLogPersister) chainPendingQuery(createdBefore time.Time) *pop.Query {
+	return p.db.Where("chain_pending = ? AND chain_sequence IS NULL AND created_at < ?", true, createdBefore)
+}
+
+func (p *auditLogPersister) UpdateChain(auditLog models.AuditLog) error {
+	count, err := p.db.RawQuery(
+		"UPDATE audit_logs SET chain_sequence = ?, previous_hash = ?, hash = ?, chain_pending = false WHERE id = ?",
+		auditLog.ChainSequence, auditLog.PreviousHash, auditLog.Hash, auditLog.ID,
+	).ExecWithCount()
+	if err != nil {
+		return fmt.Errorf("failed to update auditlog chain: %w", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("failed to update auditlog chain: auditlog %s not found", auditLog.ID)
+	}
+
+	return nil
+}
+
 func (p *auditLogPersister) Delete(auditLog models.AuditLog) error {
 	err := p.db.Eager().Destroy(&auditLog)
 	if err != nil {
@@ -89,6 +194,56 @@ func (p *auditLogPersister) Count(startTime *time.Time, endTime *time.Time, type
 	return count, nil
 }
 
+func (p *auditLogPersister) CountBefore(before time.Time, types []string, excludedTypes []string) (int, error) {
+	count, err := p.beforeQuery(before, types, excludedTypes).Count(&models.AuditLog{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get auditLog count: %w", err)
+	}
+
+	return count, nil
+}
+
+func (p *auditLogPersister) DeleteBefore(before time.Time, types []string, excludedTypes []string, limit int) (int, error) {
+	// DELETE with LIMIT is not supported by all dialects, so the IDs of the chunk are selected first
+	var auditLogs []models.AuditLog
+	err := p.beforeQuery(before, types, excludedTypes).
+		Select("id").
+		Order("created_at asc").
+		Limit(limit).
+		All(&auditLogs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch auditLogs: %w", err)
+	}
+
+	if len(auditLogs) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]uuid.UUID, len(auditLogs))
+	for i, auditLog := range auditLogs {
+		ids[i] = auditLog.ID
+	}
+
+	err = p.db.Where("id IN (?)", ids).Delete(&models.AuditLog{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete auditLogs: %w", err)
+	}
+
+	return len(ids), nil
+}
+
+func (p *auditLogPersister) beforeQuery(before time.Time, types []string, excludedTypes []string) *pop.Query {
+	query := p.db.Where("created_at < ?", before)
+	if len(types) > 0 {
+		query = query.Where("type IN (?)", types)
+	}
+	if len(excludedTypes) > 0 {
+		query = query.Where("type NOT IN (?)", excludedTypes)
+	}
+
+	return query
+}
+
 func (p *auditLogPersister) addQueryParamsToSqlQuery(query *pop.Query, startTime *time.Time, endTime *time.Time, types []string, userId string, email string, ip string, searchString string) *pop.Query {
 	if startTime != nil {
 		query = query.Where("created_at > ?", startTime)
@@ -0,0 +1,168 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
+)
+
+type EmailMessagePersister interface {
+	Create(message models.EmailMessage) error
+	Update(message models.EmailMessage) error
+	Get(id uuid.UUID) (*models.EmailMessage, error)
+	// ListDue returns pending messages whose next attempt is due and which are not locked by a dispatcher.
+	ListDue(now time.Time, limit int) (models.EmailMessages, error)
+	// Lock locks the given message until lockedUntil, unless it has been locked, attempted or rescheduled by another
+	// dispatcher since it has been listed. It returns the message as reloaded after locking it, or nil if the lock has
+	// not been acquired.
+	Lock(message models.EmailMessage, now time.Time, lockedUntil time.Time) (*models.EmailMessage, error)
+	// UpdateLocked updates the given message, unless the lock acquired with Lock has been lost, i.e. the message has
+	// been locked again or attempted by another dispatcher since. lockedUntil and attempts are the values of the
+	// message when it was locked. It reports whether the message has been updated.
+	UpdateLocked(message models.EmailMessage, lockedUntil time.Time, attempts int) (bool, error)
+	// ListPending returns all pending messages, including locked ones.
+	ListPending() (models.EmailMessages, error)
+	// UpdateContent updates the subject and bodies of the given message, if it is still pending. Other columns are
+	// not changed, so the content can be updated while the message is dispatched.
+	UpdateContent(message models.EmailMessage) error
+	// DeleteFinishedBefore deletes all messages which are not pending anymore and have been updated before the given
+	// time.
+	DeleteFinishedBefore(t time.Time) (int, error)
+}
+
+type emailMessagePersister struct {
+	db *pop.Connection
+}
+
+func NewEmailMessagePersister(db *pop.Connection) EmailMessagePersister {
+	return &emailMessagePersister{db: db}
+}
+
+func (p *emailMessagePersister) Create(message models.EmailMessage) error {
+	vErr, err := p.db.ValidateAndCreate(&message)
+	if err != nil {
+		return fmt.Errorf("failed to create email message: %w", err)
+	}
+
+	if vErr != nil && vErr.HasAny() {
+		return fmt.Errorf("email message object validation failed: %w", vErr)
+	}
+
+	return nil
+}
+
+func (p *emailMessagePersister) Update(message models.EmailMessage) error {
+	vErr, err := p.db.ValidateAndUpdate(&message)
+	if err != nil {
+		return fmt.Errorf("failed to update email message: %w", err)
+	}
+
+	if vErr != nil && vErr.HasAny() {
+		return fmt.Errorf("email message object validation failed: %w", vErr)
+	}
+
+	return nil
+}
+
+func (p *emailMessagePersister) Get(id uuid.UUID) (*models.EmailMessage, error) {
+	message := models.EmailMessage{}
+	err := p.db.Find(&message, id)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email message: %w", err)
+	}
+
+	return &message, nil
+}
+
+func (p *emailMessagePersister) ListDue(now time.Time, limit int) (models.EmailMessages, error) {
+	messages := models.EmailMessages{}
+	err := p.db.
+		Where("status = ?", models.EmailMessageStatusPending).
+		Where("next_attempt_at <= ?", now).
+		Where("locked_until IS NULL OR locked_until < ?", now).
+		Order("next_attempt_at asc").
+		Limit(limit).
+		All(&messages)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return messages, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due email messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+func (p *emailMessagePersister) Lock(message models.EmailMessage, now time.Time, lockedUntil time.Time) (*models.EmailMessage, error) {
+	// Checking the attempts and the next attempt makes sure the message has not been attempted or rescheduled by
+	// another dispatcher since it has been listed, even if that dispatcher's lock has expired already.
+	count, err := p.db.RawQuery(
+		"UPDATE email_messages SET locked_until = ? WHERE id = ? AND status = ? AND attempts = ? AND next_attempt_at <= ? AND (locked_until IS NULL OR locked_until < ?)",
+		lockedUntil, message.ID, models.EmailMessageStatusPending, message.Attempts, now, now,
+	).ExecWithCount()
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock email message: %w", err)
+	}
+
+	if count != 1 {
+		return nil, nil
+	}
+
+	return p.Get(message.ID)
+}
+
+func (p *emailMessagePersister) UpdateLocked(message models.EmailMessage, lockedUntil time.Time, attempts int) (bool, error) {
+	count, err := p.db.RawQuery(
+		"UPDATE email_messages SET subject = ?, body_text = ?, body_html = ?, status = ?, attempts = ?, next_attempt_at = ?, locked_until = ?, last_error = ?, sent_at = ?, updated_at = ? WHERE id = ? AND attempts = ? AND locked_until = ?",
+		message.Subject, message.BodyText, message.BodyHTML, message.Status, message.Attempts, message.NextAttemptAt, message.LockedUntil, message.LastError, message.SentAt, message.UpdatedAt, message.ID, attempts, lockedUntil,
+	).ExecWithCount()
+	if err != nil {
+		return false, fmt.Errorf("failed to update email message: %w", err)
+	}
+
+	return count == 1, nil
+}
+
+func (p *emailMessagePersister) ListPending() (models.EmailMessages, error) {
+	messages := models.EmailMessages{}
+	err := p.db.Where("status = ?", models.EmailMessageStatusPending).Order("created_at asc").All(&messages)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return messages, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending email messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+func (p *emailMessagePersister) UpdateContent(message models.EmailMessage) error {
+	err := p.db.RawQuery(
+		"UPDATE email_messages SET subject = ?, body_text = ?, body_html = ? WHERE id = ? AND status = ?",
+		message.Subject, message.BodyText, message.BodyHTML, message.ID, models.EmailMessageStatusPending,
+	).Exec()
+	if err != nil {
+		return fmt.Errorf("failed to update email message content: %w", err)
+	}
+
+	return nil
+}
+
+func (p *emailMessagePersister) DeleteFinishedBefore(t time.Time) (int, error) {
+	count, err := p.db.RawQuery(
+		"DELETE FROM email_messages WHERE status <> ? AND updated_at < ?",
+		models.EmailMessageStatusPending, t,
+	).ExecWithCount()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete finished email messages: %w", err)
+	}
+
+	return count, nil
+}
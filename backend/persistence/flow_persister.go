@@ -0,0 +1,52 @@
+package persistence
+
+import (
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
+)
+
+type FlowPersister interface {
+	// DeleteExpired deletes up to limit flows which expired before the given time and returns the number of deleted
+	// flows.
+	DeleteExpired(before time.Time, limit int) (int, error)
+}
+
+type flowPersister struct {
+	db *pop.Connection
+}
+
+func NewFlowPersister(db *pop.Connection) FlowPersister {
+	return &flowPersister{db: db}
+}
+
+func (p *flowPersister) DeleteExpired(before time.Time, limit int) (int, error) {
+	// DELETE with LIMIT is not supported by all dialects, so the IDs of the chunk are selected first
+	var flows []models.Flow
+	err := p.db.Where("expires_at < ?", before).
+		Select("id").
+		Order("expires_at asc").
+		Limit(limit).
+		All(&flows)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch expired flows: %w", err)
+	}
+
+	if len(flows) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]uuid.UUID, len(flows))
+	for i, flow := range flows {
+		ids[i] = flow.ID
+	}
+
+	err = p.db.Where("id IN (?)", ids).Delete(&models.Flow{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired flows: %w", err)
+	}
+
+	return len(ids), nil
+}
@@ -13,6 +13,8 @@ type JwkPersister interface {
 	GetAll() ([]models.Jwk, error)
 	GetLast() (*models.Jwk, error)
 	Create(models.Jwk) error
+	Update(models.Jwk) error
+	Delete(models.Jwk) error
 }
 
 type jwkPersister struct {
@@ -37,7 +39,7 @@ func (p *jwkPersister) Get(id int) (*models.Jwk, error) {
 
 func (p *jwkPersister) GetAll() ([]models.Jwk, error) {
 	jwks := []models.Jwk{}
-	err := p.db.All(&jwks)
+	err := p.db.Order("id asc").All(&jwks)
 	if err != nil && errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
@@ -71,3 +73,25 @@ func (p *jwkPersister) Create(jwk models.Jwk) error {
 
 	return nil
 }
+
+func (p *jwkPersister) Update(jwk models.Jwk) error {
+	vErr, err := p.db.ValidateAndUpdate(&jwk)
+	if err != nil {
+		return fmt.Errorf("failed to update jwk: %w", err)
+	}
+
+	if vErr != nil && vErr.HasAny() {
+		return fmt.Errorf("jwk object validation failed: %w", vErr)
+	}
+
+	return nil
+}
+
+func (p *jwkPersister) Delete(jwk models.Jwk) error {
+	err := p.db.Destroy(&jwk)
+	if err != nil {
+		return fmt.Errorf("failed to delete jwk: %w", err)
+	}
+
+	return nil
+}
@@ -17,8 +17,17 @@ type AuditLog struct {
 	ActorUserId       *uuid.UUID   `db:"actor_user_id" json:"actor_user_id,omitempty"`
 	ActorEmail        *string      `db:"actor_email" json:"actor_email,omitempty" mask:"email"`
 	Details           slices.Map   `db:"details" json:"details"`
-	CreatedAt         time.Time    `db:"created_at" json:"created_at"`
-	UpdatedAt         time.Time    `db:"updated_at" json:"updated_at"`
+	// ChainSequence is the position of the audit log in the hash chain. It is nil if the audit log has been created
+	// while the hash chain was disabled or if it has not been appended to the chain yet (see ChainPending).
+	ChainSequence *int64 `db:"chain_sequence" json:"chain_sequence,omitempty"`
+	// PreviousHash is the hash of the audit log preceding this one in the hash chain.
+	PreviousHash *string `db:"previous_hash" json:"previous_hash,omitempty"`
+	Hash         *string `db:"hash" json:"hash,omitempty"`
+	// ChainPending is true if the audit log has been stored to be appended to the hash chain afterwards, see
+	// persistence.AuditLogPersister.ListChainPending.
+	ChainPending bool      `db:"chain_pending" json:"-"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
 }
 
 type Details map[string]interface{}
@@ -120,4 +129,11 @@ var (
 	AuditLogUsernameDeleted AuditLogType = "username_deleted"
 	AuditLogPasswordChanged AuditLogType = "password_changed"
 	AuditLogPasswordDeleted AuditLogType = "password_deleted"
+	AuditLogSessionRevoked  AuditLogType = "session_revoked"
+
+	AuditLogPreferredLanguageChanged AuditLogType = "preferred_language_changed"
+
+	// Flow action types, see config.AuditLogFlowActions
+	AuditLogFlowActionSucceeded AuditLogType = "flow_action_succeeded"
+	AuditLogFlowActionFailed    AuditLogType = "flow_action_failed"
 )
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gobuffalo/validate/v3"
+	"github.com/gobuffalo/validate/v3/validators"
+	"github.com/gofrs/uuid"
+	"time"
+)
+
+// AuditLogChainHeadID is the ID of the single AuditLogChainHead.
+var AuditLogChainHeadID = uuid.Nil
+
+// AuditLogChainHead points to the most recently chained audit log. Its row is locked while an audit log is chained,
+// which makes sure each audit log is chained to exactly one predecessor when multiple instances share a database.
+type AuditLogChainHead struct {
+	ID            uuid.UUID `json:"id" db:"id"`
+	ChainSequence int64     `json:"chain_sequence" db:"chain_sequence"`
+	Hash          string    `json:"hash" db:"hash"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// AuditLogCheckpoint is a signed statement about the head of the hash chain at the time it was created.
+type AuditLogCheckpoint struct {
+	ID            uuid.UUID `json:"id" db:"id"`
+	ChainSequence int64     `json:"chain_sequence" db:"chain_sequence"`
+	Hash          string    `json:"hash" db:"hash"`
+	// KeyID is the ID of the JWK the checkpoint has been signed with.
+	KeyID string `json:"key_id" db:"key_id"`
+	// Signature is a JWS in compact serialization whose payload contains the chain sequence, hash and creation time
+	// of the checkpoint.
+	Signature string    `json:"signature" db:"signature"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+func (checkpoint *AuditLogCheckpoint) Validate(tx *pop.Connection) (*validate.Errors, error) {
+	return validate.Validate(
+		&validators.UUIDIsPresent{Name: "ID", Field: checkpoint.ID},
+		&validators.IntIsGreaterThan{Name: "ChainSequence", Field: int(checkpoint.ChainSequence), Compared: 0},
+		&validators.StringIsPresent{Name: "Hash", Field: checkpoint.Hash},
+		&validators.StringIsPresent{Name: "Signature", Field: checkpoint.Signature},
+		&validators.TimeIsPresent{Name: "CreatedAt", Field: checkpoint.CreatedAt},
+		&validators.TimeIsPresent{Name: "UpdatedAt", Field: checkpoint.UpdatedAt},
+	), nil
+}
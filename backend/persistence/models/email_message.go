@@ -0,0 +1,53 @@
+package models
+
+import (
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gobuffalo/validate/v3"
+	"github.com/gobuffalo/validate/v3/validators"
+	"github.com/gofrs/uuid"
+	"time"
+)
+
+type EmailMessageStatus string
+
+const (
+	// EmailMessageStatusPending marks messages which are waiting for their (next) delivery attempt.
+	EmailMessageStatusPending EmailMessageStatus = "pending"
+	// EmailMessageStatusSent marks messages which have been handed over to the email provider successfully.
+	EmailMessageStatusSent EmailMessageStatus = "sent"
+	// EmailMessageStatusFailed marks messages which could not be delivered within the maximum number of attempts or
+	// which have been rejected permanently by the email provider.
+	EmailMessageStatusFailed EmailMessageStatus = "failed"
+)
+
+// EmailMessage is an email in the send queue. Subject, BodyText and BodyHTML are stored encrypted with the configured
+// secrets.keys, because they may contain passcodes or login links. The content of a message is removed once it is
+// not pending anymore.
+type EmailMessage struct {
+	ID            uuid.UUID          `json:"id" db:"id"`
+	ToAddress     string             `json:"to_address" db:"to_address"`
+	Subject       string             `json:"-" db:"subject"`
+	BodyText      string             `json:"-" db:"body_text"`
+	BodyHTML      *string            `json:"-" db:"body_html"`
+	Status        EmailMessageStatus `json:"status" db:"status"`
+	Attempts      int                `json:"attempts" db:"attempts"`
+	NextAttemptAt time.Time          `json:"next_attempt_at" db:"next_attempt_at"`
+	LockedUntil   *time.Time         `json:"-" db:"locked_until"`
+	LastError     *string            `json:"last_error,omitempty" db:"last_error"`
+	SentAt        *time.Time         `json:"sent_at,omitempty" db:"sent_at"`
+	CreatedAt     time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at" db:"updated_at"`
+}
+
+type EmailMessages []EmailMessage
+
+func (message *EmailMessage) Validate(tx *pop.Connection) (*validate.Errors, error) {
+	return validate.Validate(
+		&validators.UUIDIsPresent{Name: "ID", Field: message.ID},
+		&validators.StringIsPresent{Name: "ToAddress", Field: message.ToAddress},
+		&validators.StringIsPresent{Name: "Status", Field: string(message.Status)},
+		&validators.TimeIsPresent{Name: "NextAttemptAt", Field: message.NextAttemptAt},
+		&validators.TimeIsPresent{Name: "CreatedAt", Field: message.CreatedAt},
+		&validators.TimeIsPresent{Name: "UpdatedAt", Field: message.UpdatedAt},
+	), nil
+}
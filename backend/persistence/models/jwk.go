@@ -8,9 +8,16 @@ import (
 )
 
 type Jwk struct {
-	ID        int       `db:"id"`
-	KeyData   string    `db:"key_data"`
-	CreatedAt time.Time `db:"created_at"`
+	ID        int        `db:"id"`
+	KeyData   string     `db:"key_data"`
+	CreatedAt time.Time  `db:"created_at"`
+	RetiredAt *time.Time `db:"retired_at"`
+}
+
+// IsPublished reports whether the public part of the key is still published, i.e. whether the key has not been
+// retired or has been retired less than the given grace period ago.
+func (jwk *Jwk) IsPublished(gracePeriod time.Duration, now time.Time) bool {
+	return jwk.RetiredAt == nil || jwk.RetiredAt.Add(gracePeriod).After(now)
 }
 
 // Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
@@ -10,15 +10,17 @@ import (
 
 // Passcode is used by pop to map your passcodes database table to your go code.
 type Passcode struct {
-	ID        uuid.UUID  `db:"id"`
-	UserId    *uuid.UUID `db:"user_id"`
-	EmailID   *uuid.UUID `db:"email_id"`
-	Ttl       int        `db:"ttl"` // in seconds
-	Code      string     `db:"code"`
-	TryCount  int        `db:"try_count"`
-	CreatedAt time.Time  `db:"created_at"`
-	UpdatedAt time.Time  `db:"updated_at"`
-	Email     Email      `belongs_to:"email"`
+	ID              uuid.UUID  `db:"id"`
+	UserId          *uuid.UUID `db:"user_id"`
+	EmailID         *uuid.UUID `db:"email_id"`
+	FlowID          *uuid.UUID `db:"flow_id"`
+	Ttl             int        `db:"ttl"` // in seconds
+	Code            string     `db:"code"`
+	TryCount        int        `db:"try_count"`
+	LinkConfirmedAt *time.Time `db:"link_confirmed_at"`
+	CreatedAt       time.Time  `db:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at"`
+	Email           Email      `belongs_to:"email"`
 }
 
 // Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
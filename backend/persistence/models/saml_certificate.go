@@ -125,6 +125,30 @@ func (s *SamlCertificate) DecryptCertKey() ([]byte, error) {
 	return encryptedKey, nil
 }
 
+// RotateEncryptionKey re-encrypts the private key of the certificate with a newly generated encryption key.
+func (s *SamlCertificate) RotateEncryptionKey() error {
+	privateKey, err := s.DecryptCertKey()
+	if err != nil {
+		return fmt.Errorf("unable to decrypt private key: %w", err)
+	}
+
+	encryptionKey, err := crypto.GenerateRandomStringURLSafe(32)
+	if err != nil {
+		return fmt.Errorf("unable to create encryptionKey: %w", err)
+	}
+
+	encryptedPrivateKey, err := encryptPrivateKey(privateKey, encryptionKey)
+	if err != nil {
+		return fmt.Errorf("unable to encrypt private key: %w", err)
+	}
+
+	s.CertKey = encryptedPrivateKey
+	s.EncryptionKey = encryptionKey
+	s.UpdatedAt = time.Now()
+
+	return nil
+}
+
 // Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
 // This method is not required and may be deleted.
 func (s *SamlCertificate) Validate(_ *pop.Connection) (*validate.Errors, error) {
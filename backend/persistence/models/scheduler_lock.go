@@ -0,0 +1,32 @@
+package models
+
+import (
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gobuffalo/validate/v3"
+	"github.com/gobuffalo/validate/v3/validators"
+	"github.com/gofrs/uuid"
+	"time"
+)
+
+// SchedulerLock is a named lease used to make sure a periodic background task (e.g. pruning) is only run by a single
+// instance at a time when multiple Hanko instances share a database.
+type SchedulerLock struct {
+	ID   uuid.UUID `json:"id" db:"id"`
+	Name string    `json:"name" db:"name"`
+	// Holder identifies the instance holding the lock.
+	Holder      string    `json:"holder" db:"holder"`
+	LockedUntil time.Time `json:"locked_until" db:"locked_until"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+func (lock *SchedulerLock) Validate(tx *pop.Connection) (*validate.Errors, error) {
+	return validate.Validate(
+		&validators.UUIDIsPresent{Name: "ID", Field: lock.ID},
+		&validators.StringIsPresent{Name: "Name", Field: lock.Name},
+		&validators.StringIsPresent{Name: "Holder", Field: lock.Holder},
+		&validators.TimeIsPresent{Name: "LockedUntil", Field: lock.LockedUntil},
+		&validators.TimeIsPresent{Name: "CreatedAt", Field: lock.CreatedAt},
+		&validators.TimeIsPresent{Name: "UpdatedAt", Field: lock.UpdatedAt},
+	), nil
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gobuffalo/validate/v3"
+	"github.com/gobuffalo/validate/v3/validators"
+	"github.com/gofrs/uuid"
+	"time"
+)
+
+// UsedLinkToken records a link token (identified by its "jti" claim) that has been used, so links which may only be
+// used once are rejected when they are opened again. The record is kept until the token expires.
+type UsedLinkToken struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	Purpose   string    `json:"purpose" db:"purpose"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+func (token *UsedLinkToken) Validate(tx *pop.Connection) (*validate.Errors, error) {
+	return validate.Validate(
+		&validators.UUIDIsPresent{Name: "ID", Field: token.ID},
+		&validators.StringIsPresent{Name: "Purpose", Field: token.Purpose},
+		&validators.TimeIsPresent{Name: "ExpiresAt", Field: token.ExpiresAt},
+		&validators.TimeIsPresent{Name: "CreatedAt", Field: token.CreatedAt},
+		&validators.TimeIsPresent{Name: "UpdatedAt", Field: token.UpdatedAt},
+	), nil
+}
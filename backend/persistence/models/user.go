@@ -1,7 +1,10 @@
 package models
 
 import (
+	"database/sql/driver"
 	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"github.com/go-webauthn/webauthn/protocol"
 	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/gobuffalo/pop/v6"
@@ -21,6 +24,67 @@ type User struct {
 	UpdatedAt           time.Time           `db:"updated_at" json:"updated_at"`
 	Username            *Username           `has_one:"username" json:"username,omitempty"`
 	PasswordCredential  *PasswordCredential `has_one:"password_credentials" json:"-"`
+	Metadata            UserMetadata        `db:"metadata" json:"metadata,omitempty"`
+	PreferredLanguage   *string             `db:"preferred_language" json:"preferred_language,omitempty"`
+}
+
+// UserMetadata contains arbitrary data stored with a user, e.g. metadata returned by a before hook.
+type UserMetadata map[string]interface{}
+
+// Merge merges the given metadata into the existing metadata. Keys with a nil value are removed.
+func (m *UserMetadata) Merge(metadata map[string]interface{}) {
+	if len(metadata) == 0 {
+		return
+	}
+
+	if *m == nil {
+		*m = UserMetadata{}
+	}
+
+	for key, value := range metadata {
+		if value == nil {
+			delete(*m, key)
+		} else {
+			(*m)[key] = value
+		}
+	}
+}
+
+// Value implements the driver.Valuer interface. Empty metadata is stored as NULL.
+func (m UserMetadata) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+// Scan implements the sql.Scanner interface.
+func (m *UserMetadata) Scan(src interface{}) error {
+	var b []byte
+	switch value := src.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		b = value
+	case string:
+		b = []byte(value)
+	default:
+		return fmt.Errorf("unsupported type for user metadata: %T", src)
+	}
+
+	if len(b) == 0 {
+		*m = nil
+		return nil
+	}
+
+	return json.Unmarshal(b, m)
 }
 
 type WebauthnCredentials []WebauthnCredential
@@ -1,7 +1,9 @@
 package models
 
 import (
+	"fmt"
 	"github.com/gobuffalo/validate/v3/validators"
+	"github.com/teamhanko/hanko/backend/crypto/aes_gcm"
 	"time"
 
 	"github.com/gobuffalo/pop/v6"
@@ -14,11 +16,63 @@ type Webhook struct {
 	ID            uuid.UUID     `json:"id" db:"id"`
 	Callback      string        `json:"callback" db:"callback"`
 	Enabled       bool          `json:"enabled" db:"enabled"`
+	Format        string        `json:"format" db:"format"`
 	Failures      int           `json:"failures" db:"failures"`
 	ExpiresAt     time.Time     `json:"expires_at" db:"expires_at"`
 	WebhookEvents WebhookEvents `json:"events" has_many:"webhook_events"`
 	CreatedAt     time.Time     `json:"created_at" db:"created_at"`
 	UpdatedAt     time.Time     `json:"updated_at" db:"updated_at"`
+	// Secret is used to sign the requests of the webhook. It is never serialized, it is only handed out once when
+	// the webhook is created or the secret is rotated. Secret and PreviousSecret are stored encrypted with the
+	// configured secrets.keys (see EncryptWebhookSecret).
+	Secret                  *string    `json:"-" db:"secret"`
+	PreviousSecret          *string    `json:"-" db:"previous_secret"`
+	PreviousSecretExpiresAt *time.Time `json:"-" db:"previous_secret_expires_at"`
+}
+
+// EncryptWebhookSecret encrypts the given webhook secret with the given keys, so it can be stored as Secret of a
+// Webhook.
+func EncryptWebhookSecret(secret string, keys []string) (*string, error) {
+	encrypter, err := aes_gcm.NewAESGCM(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := encrypter.Encrypt([]byte(secret))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt webhook secret: %w", err)
+	}
+
+	return &encrypted, nil
+}
+
+// SigningSecrets decrypts the secrets which must be used to sign requests of the webhook at the given time with the
+// given keys: the current secret and, after a rotation, the previous secret until it expires.
+func (w *Webhook) SigningSecrets(keys []string, now time.Time) ([]string, error) {
+	decrypter, err := aes_gcm.NewAESGCM(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted := make([]string, 0, 2)
+	if w.Secret != nil && *w.Secret != "" {
+		encrypted = append(encrypted, *w.Secret)
+	}
+
+	if w.PreviousSecret != nil && *w.PreviousSecret != "" && w.PreviousSecretExpiresAt != nil && w.PreviousSecretExpiresAt.After(now) {
+		encrypted = append(encrypted, *w.PreviousSecret)
+	}
+
+	secrets := make([]string, 0, len(encrypted))
+	for _, e := range encrypted {
+		secret, err := decrypter.Decrypt(e)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt secret of webhook %s: %w", w.ID, err)
+		}
+		secrets = append(secrets, string(secret))
+	}
+
+	return secrets, nil
 }
 
 // Webhooks are not required by pop and may be deleted
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gobuffalo/validate/v3"
+	"github.com/gobuffalo/validate/v3/validators"
+	"github.com/gofrs/uuid"
+	"time"
+)
+
+// WebhookDelivery records a single attempt to deliver a webhook message.
+type WebhookDelivery struct {
+	ID uuid.UUID `json:"id" db:"id"`
+	// WebhookJobID references the job the delivery belongs to. It is nil for test deliveries and for deliveries
+	// whose job has been pruned.
+	WebhookJobID *uuid.UUID `json:"webhook_job_id,omitempty" db:"webhook_job_id"`
+	// WebhookID references the database webhook the message was delivered to, it is nil for webhooks from the config.
+	WebhookID *uuid.UUID `json:"webhook_id,omitempty" db:"webhook_id"`
+	MessageID uuid.UUID  `json:"message_id" db:"message_id"`
+	Event     string     `json:"event" db:"event"`
+	// PayloadHash is the hex encoded SHA-256 hash of the event data.
+	PayloadHash string `json:"payload_hash" db:"payload_hash"`
+	Attempt     int    `json:"attempt" db:"attempt"`
+	// StatusCode is the HTTP status code of the response, it is nil if no response has been received.
+	StatusCode *int `json:"status_code,omitempty" db:"status_code"`
+	// LatencyMs is the time in milliseconds until the response has been received.
+	LatencyMs int64 `json:"latency_ms" db:"latency_ms"`
+	// ResponseBody contains the beginning of the response body.
+	ResponseBody *string   `json:"response_body,omitempty" db:"response_body"`
+	Error        *string   `json:"error,omitempty" db:"error"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+type WebhookDeliveries []WebhookDelivery
+
+// Succeeded reports whether the webhook message has been accepted by the receiver.
+func (delivery *WebhookDelivery) Succeeded() bool {
+	return delivery.Error == nil && delivery.StatusCode != nil && *delivery.StatusCode < 400
+}
+
+func (delivery *WebhookDelivery) Validate(tx *pop.Connection) (*validate.Errors, error) {
+	return validate.Validate(
+		&validators.UUIDIsPresent{Name: "ID", Field: delivery.ID},
+		&validators.UUIDIsPresent{Name: "MessageID", Field: delivery.MessageID},
+		&validators.StringIsPresent{Name: "Event", Field: delivery.Event},
+		&validators.StringIsPresent{Name: "PayloadHash", Field: delivery.PayloadHash},
+		&validators.TimeIsPresent{Name: "CreatedAt", Field: delivery.CreatedAt},
+	), nil
+}
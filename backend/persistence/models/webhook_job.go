@@ -0,0 +1,58 @@
+package models
+
+import (
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gobuffalo/validate/v3"
+	"github.com/gobuffalo/validate/v3/validators"
+	"github.com/gofrs/uuid"
+	"time"
+)
+
+type WebhookJobStatus string
+
+const (
+	// WebhookJobStatusPending marks jobs which are waiting for their (next) delivery attempt.
+	WebhookJobStatusPending WebhookJobStatus = "pending"
+	// WebhookJobStatusDelivered marks jobs which have been delivered successfully.
+	WebhookJobStatusDelivered WebhookJobStatus = "delivered"
+	// WebhookJobStatusFailed marks jobs which could not be delivered within the maximum number of attempts
+	// (dead letter).
+	WebhookJobStatusFailed WebhookJobStatus = "failed"
+	// WebhookJobStatusDiscarded marks jobs which have not been delivered because their webhook has been disabled or
+	// removed in the meantime.
+	WebhookJobStatusDiscarded WebhookJobStatus = "discarded"
+)
+
+// WebhookJob is a webhook message to be delivered to a single webhook. Jobs are written in the same transaction as
+// the change triggering the webhook (transactional outbox) and are delivered asynchronously.
+type WebhookJob struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	MessageID uuid.UUID `json:"message_id" db:"message_id"`
+	// WebhookID references the database webhook the job is delivered to, it is nil for webhooks from the config.
+	WebhookID     *uuid.UUID       `json:"webhook_id,omitempty" db:"webhook_id"`
+	Callback      string           `json:"callback" db:"callback"`
+	Event         string           `json:"event" db:"event"`
+	Data          string           `json:"-" db:"data"`
+	Status        WebhookJobStatus `json:"status" db:"status"`
+	Attempts      int              `json:"attempts" db:"attempts"`
+	NextAttemptAt time.Time        `json:"next_attempt_at" db:"next_attempt_at"`
+	LockedUntil   *time.Time       `json:"-" db:"locked_until"`
+	LastError     *string          `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt     time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at" db:"updated_at"`
+}
+
+type WebhookJobs []WebhookJob
+
+func (job *WebhookJob) Validate(tx *pop.Connection) (*validate.Errors, error) {
+	return validate.Validate(
+		&validators.UUIDIsPresent{Name: "ID", Field: job.ID},
+		&validators.UUIDIsPresent{Name: "MessageID", Field: job.MessageID},
+		&validators.StringIsPresent{Name: "Callback", Field: job.Callback},
+		&validators.StringIsPresent{Name: "Event", Field: job.Event},
+		&validators.StringIsPresent{Name: "Status", Field: string(job.Status)},
+		&validators.TimeIsPresent{Name: "NextAttemptAt", Field: job.NextAttemptAt},
+		&validators.TimeIsPresent{Name: "CreatedAt", Field: job.CreatedAt},
+		&validators.TimeIsPresent{Name: "UpdatedAt", Field: job.UpdatedAt},
+	), nil
+}
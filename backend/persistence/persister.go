@@ -45,6 +45,13 @@ type Persister interface {
 	GetSamlCertificatePersister() SamlCertificatePersister
 	GetSamlCertificatePersisterWithConnection(tx *pop.Connection) SamlCertificatePersister
 	GetWebhookPersister(tx *pop.Connection) WebhookPersister
+	GetWebhookJobPersister(tx *pop.Connection) WebhookJobPersister
+	GetEmailMessagePersister(tx *pop.Connection) EmailMessagePersister
+	GetWebhookDeliveryPersister(tx *pop.Connection) WebhookDeliveryPersister
+	GetSchedulerLockPersister(tx *pop.Connection) SchedulerLockPersister
+	GetFlowPersister(tx *pop.Connection) FlowPersister
+	GetAuditLogChainPersister(tx *pop.Connection) AuditLogChainPersister
+	GetUsedLinkTokenPersister(tx *pop.Connection) UsedLinkTokenPersister
 	GetUsernamePersister() UsernamePersister
 	GetUsernamePersisterWithConnection(tx *pop.Connection) UsernamePersister
 	GetSessionPersister() SessionPersister
@@ -213,8 +220,27 @@ func (p *persister) GetPrimaryEmailPersisterWithConnection(tx *pop.Connection) P
 	return NewPrimaryEmailPersister(tx)
 }
 
+// Transaction runs fn in a transaction. The functions registered with AfterCommit for the transaction are run once it
+// has been committed.
 func (p *persister) Transaction(fn func(tx *pop.Connection) error) error {
-	return p.DB.Transaction(fn)
+	var conn *pop.Connection
+	defer func() {
+		// discard the functions of a transaction which has been rolled back
+		FinishAfterCommit(conn, false)
+	}()
+
+	err := p.DB.Transaction(func(tx *pop.Connection) error {
+		conn = tx
+		TrackAfterCommit(tx)
+		return fn(tx)
+	})
+	if err != nil {
+		return err
+	}
+
+	FinishAfterCommit(conn, true)
+
+	return nil
 }
 
 func (p *persister) GetTokenPersister() TokenPersister {
@@ -249,6 +275,62 @@ func (p *persister) GetWebhookPersister(tx *pop.Connection) WebhookPersister {
 	return NewWebhookPersister(p.DB)
 }
 
+func (p *persister) GetWebhookJobPersister(tx *pop.Connection) WebhookJobPersister {
+	if tx != nil {
+		return NewWebhookJobPersister(tx)
+	}
+
+	return NewWebhookJobPersister(p.DB)
+}
+
+func (p *persister) GetEmailMessagePersister(tx *pop.Connection) EmailMessagePersister {
+	if tx != nil {
+		return NewEmailMessagePersister(tx)
+	}
+
+	return NewEmailMessagePersister(p.DB)
+}
+
+func (p *persister) GetWebhookDeliveryPersister(tx *pop.Connection) WebhookDeliveryPersister {
+	if tx != nil {
+		return NewWebhookDeliveryPersister(tx)
+	}
+
+	return NewWebhookDeliveryPersister(p.DB)
+}
+
+func (p *persister) GetSchedulerLockPersister(tx *pop.Connection) SchedulerLockPersister {
+	if tx != nil {
+		return NewSchedulerLockPersister(tx)
+	}
+
+	return NewSchedulerLockPersister(p.DB)
+}
+
+func (p *persister) GetFlowPersister(tx *pop.Connection) FlowPersister {
+	if tx != nil {
+		return NewFlowPersister(tx)
+	}
+
+	return NewFlowPersister(p.DB)
+}
+
+func (p *persister) GetAuditLogChainPersister(tx *pop.Connection) AuditLogChainPersister {
+	if tx != nil {
+		return NewAuditLogChainPersister(tx)
+	}
+
+	return NewAuditLogChainPersister(p.DB)
+}
+
+func (p *persister) GetUsedLinkTokenPersister(tx *pop.Connection) UsedLinkTokenPersister {
+	if tx != nil {
+		return NewUsedLinkTokenPersister(tx)
+	}
+
+	return NewUsedLinkTokenPersister(p.DB)
+}
+
 func (p *persister) GetSessionPersister() SessionPersister {
 	return NewSessionPersister(p.DB)
 }
@@ -15,6 +15,8 @@ import (
 type SamlCertificatePersister interface {
 	Create(cert *models.SamlCertificate) error
 	GetFirst() (*models.SamlCertificate, error)
+	GetAll() ([]*models.SamlCertificate, error)
+	Update(cert *models.SamlCertificate) error
 	Renew(cert *models.SamlCertificate, serviceName string) error
 	Delete(cert *models.SamlCertificate) error
 }
@@ -41,6 +43,33 @@ func (s samlCertificatePersister) GetFirst() (*models.SamlCertificate, error) {
 	return &cert, nil
 }
 
+func (s samlCertificatePersister) GetAll() ([]*models.SamlCertificate, error) {
+	var certs []*models.SamlCertificate
+
+	err := s.db.Order("created_at asc").All(&certs)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return certs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get certificates: %w", err)
+	}
+
+	return certs, nil
+}
+
+func (s samlCertificatePersister) Update(cert *models.SamlCertificate) error {
+	validationError, err := s.db.ValidateAndUpdate(cert)
+	if err != nil {
+		return fmt.Errorf("unable to update certificate: %w", err)
+	}
+
+	if validationError != nil && validationError.HasAny() {
+		return fmt.Errorf("saml certificate validation failed: %w", validationError)
+	}
+
+	return nil
+}
+
 func (s samlCertificatePersister) Create(cert *models.SamlCertificate) error {
 	validationError, err := s.db.ValidateAndCreate(cert)
 	if err != nil {
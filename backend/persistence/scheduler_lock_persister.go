@@ -0,0 +1,77 @@
+package persistence
+
+import (
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
+)
+
+type SchedulerLockPersister interface {
+	// Acquire acquires the lock with the given name for the given holder until lockedUntil, unless it is held
+	// (by any holder) until after now. It reports whether the lock has been acquired. Locks are not released, they
+	// expire, so a lock acquired for the interval of a periodic task makes sure the task is run once per interval
+	// across all instances.
+	Acquire(name string, holder string, now time.Time, lockedUntil time.Time) (bool, error)
+}
+
+type schedulerLockPersister struct {
+	db *pop.Connection
+}
+
+func NewSchedulerLockPersister(db *pop.Connection) SchedulerLockPersister {
+	return &schedulerLockPersister{db: db}
+}
+
+func (p *schedulerLockPersister) Acquire(name string, holder string, now time.Time, lockedUntil time.Time) (bool, error) {
+	count, err := p.db.RawQuery(
+		"UPDATE scheduler_locks SET holder = ?, locked_until = ?, updated_at = ? WHERE name = ? AND locked_until < ?",
+		holder, lockedUntil, now, name, now,
+	).ExecWithCount()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire scheduler lock: %w", err)
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	exists, err := p.exists(name)
+	if err != nil || exists {
+		return false, err
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return false, err
+	}
+
+	lock := models.SchedulerLock{
+		ID:          id,
+		Name:        name,
+		Holder:      holder,
+		LockedUntil: lockedUntil,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	err = p.db.Create(&lock)
+	if err != nil {
+		// the lock might have been created by another instance in the meantime
+		exists, existsErr := p.exists(name)
+		if existsErr == nil && exists {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to create scheduler lock: %w", err)
+	}
+
+	return true, nil
+}
+
+func (p *schedulerLockPersister) exists(name string) (bool, error) {
+	exists, err := p.db.Where("name = ?", name).Exists(&models.SchedulerLock{})
+	if err != nil {
+		return false, fmt.Errorf("failed to get scheduler lock: %w", err)
+	}
+
+	return exists, nil
+}
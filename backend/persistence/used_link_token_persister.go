@@ -0,0 +1,46 @@
+package persistence
+
+import (
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+)
+
+type UsedLinkTokenPersister interface {
+	// Use records the given link token as used. It reports whether the token has not been used before. Records of
+	// tokens that expired before the given token has been created are removed.
+	Use(token models.UsedLinkToken) (bool, error)
+}
+
+type usedLinkTokenPersister struct {
+	db *pop.Connection
+}
+
+func NewUsedLinkTokenPersister(db *pop.Connection) UsedLinkTokenPersister {
+	return &usedLinkTokenPersister{db: db}
+}
+
+func (p *usedLinkTokenPersister) Use(token models.UsedLinkToken) (bool, error) {
+	err := p.db.RawQuery("DELETE FROM used_link_tokens WHERE expires_at < ?", token.CreatedAt).Exec()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete expired link tokens: %w", err)
+	}
+
+	exists, err := p.db.Where("id = ?", token.ID).Exists(&models.UsedLinkToken{})
+	if err != nil {
+		return false, fmt.Errorf("failed to get used link token: %w", err)
+	}
+	if exists {
+		return false, nil
+	}
+
+	vErr, err := p.db.ValidateAndCreate(&token)
+	if err != nil {
+		return false, fmt.Errorf("failed to store used link token: %w", err)
+	}
+	if vErr != nil && vErr.HasAny() {
+		return false, fmt.Errorf("used link token object validation failed: %w", vErr)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,90 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
+)
+
+type WebhookDeliveryPersister interface {
+	Create(delivery models.WebhookDelivery) error
+	Get(id uuid.UUID) (*models.WebhookDelivery, error)
+	// List returns the deliveries to the given webhook, most recent first.
+	List(webhookID uuid.UUID, page int, perPage int) (models.WebhookDeliveries, error)
+	Count(webhookID uuid.UUID) (int, error)
+	// DeleteBefore deletes all deliveries created before the given time.
+	DeleteBefore(t time.Time) (int, error)
+}
+
+type webhookDeliveryPersister struct {
+	db *pop.Connection
+}
+
+func NewWebhookDeliveryPersister(db *pop.Connection) WebhookDeliveryPersister {
+	return &webhookDeliveryPersister{db: db}
+}
+
+func (p *webhookDeliveryPersister) Create(delivery models.WebhookDelivery) error {
+	vErr, err := p.db.ValidateAndCreate(&delivery)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+
+	if vErr != nil && vErr.HasAny() {
+		return fmt.Errorf("webhook delivery object validation failed: %w", vErr)
+	}
+
+	return nil
+}
+
+func (p *webhookDeliveryPersister) Get(id uuid.UUID) (*models.WebhookDelivery, error) {
+	delivery := models.WebhookDelivery{}
+	err := p.db.Find(&delivery, id)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	return &delivery, nil
+}
+
+func (p *webhookDeliveryPersister) List(webhookID uuid.UUID, page int, perPage int) (models.WebhookDeliveries, error) {
+	deliveries := models.WebhookDeliveries{}
+	err := p.db.
+		Where("webhook_id = ?", webhookID).
+		Order("created_at desc").
+		Paginate(page, perPage).
+		All(&deliveries)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return deliveries, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (p *webhookDeliveryPersister) Count(webhookID uuid.UUID) (int, error) {
+	count, err := p.db.Where("webhook_id = ?", webhookID).Count(&models.WebhookDelivery{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get webhook delivery count: %w", err)
+	}
+
+	return count, nil
+}
+
+func (p *webhookDeliveryPersister) DeleteBefore(t time.Time) (int, error) {
+	count, err := p.db.RawQuery("DELETE FROM webhook_deliveries WHERE created_at < ?", t).ExecWithCount()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete webhook deliveries: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,137 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
+)
+
+type WebhookJobPersister interface {
+	Create(job models.WebhookJob) error
+	Update(job models.WebhookJob) error
+	Get(id uuid.UUID) (*models.WebhookJob, error)
+	// ListDue returns pending jobs whose next attempt is due and which are not locked by a worker.
+	ListDue(now time.Time, limit int) (models.WebhookJobs, error)
+	// Lock locks the given job until lockedUntil, unless it has been locked, attempted or rescheduled by another
+	// worker since it has been listed. It returns the job as reloaded after locking it, or nil if the lock has not been
+	// acquired.
+	Lock(job models.WebhookJob, now time.Time, lockedUntil time.Time) (*models.WebhookJob, error)
+	// UpdateLocked updates the given job, unless the lock acquired with Lock has been lost, i.e. the job has been
+	// locked again or attempted by another worker since. lockedUntil and attempts are the values of the job when it
+	// was locked. It reports whether the job has been updated.
+	UpdateLocked(job models.WebhookJob, lockedUntil time.Time, attempts int) (bool, error)
+	// DeleteFinishedBefore deletes all jobs which are not pending anymore and have been updated before the given time.
+	DeleteFinishedBefore(t time.Time) (int, error)
+}
+
+type webhookJobPersister struct {
+	db *pop.Connection
+}
+
+func NewWebhookJobPersister(db *pop.Connection) WebhookJobPersister {
+	return &webhookJobPersister{db: db}
+}
+
+func (p *webhookJobPersister) Create(job models.WebhookJob) error {
+	vErr, err := p.db.ValidateAndCreate(&job)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook job: %w", err)
+	}
+
+	if vErr != nil && vErr.HasAny() {
+		return fmt.Errorf("webhook job object validation failed: %w", vErr)
+	}
+
+	return nil
+}
+
+func (p *webhookJobPersister) Update(job models.WebhookJob) error {
+	vErr, err := p.db.ValidateAndUpdate(&job)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook job: %w", err)
+	}
+
+	if vErr != nil && vErr.HasAny() {
+		return fmt.Errorf("webhook job object validation failed: %w", vErr)
+	}
+
+	return nil
+}
+
+func (p *webhookJobPersister) Get(id uuid.UUID) (*models.WebhookJob, error) {
+	job := models.WebhookJob{}
+	err := p.db.Find(&job, id)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook job: %w", err)
+	}
+
+	return &job, nil
+}
+
+func (p *webhookJobPersister) ListDue(now time.Time, limit int) (models.WebhookJobs, error) {
+	jobs := models.WebhookJobs{}
+	err := p.db.
+		Where("status = ?", models.WebhookJobStatusPending).
+		Where("next_attempt_at <= ?", now).
+		Where("locked_until IS NULL OR locked_until < ?", now).
+		Order("next_attempt_at asc").
+		Limit(limit).
+		All(&jobs)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return jobs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due webhook jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+func (p *webhookJobPersister) Lock(job models.WebhookJob, now time.Time, lockedUntil time.Time) (*models.WebhookJob, error) {
+	// Checking the attempts and the next attempt makes sure the job has not been attempted or rescheduled by another
+	// worker since it has been listed, even if that worker's lock has expired already.
+	count, err := p.db.RawQuery(
+		"UPDATE webhook_jobs SET locked_until = ? WHERE id = ? AND status = ? AND attempts = ? AND next_attempt_at <= ? AND (locked_until IS NULL OR locked_until < ?)",
+		lockedUntil, job.ID, models.WebhookJobStatusPending, job.Attempts, now, now,
+	).ExecWithCount()
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock webhook job: %w", err)
+	}
+
+	if count != 1 {
+		return nil, nil
+	}
+
+	return p.Get(job.ID)
+}
+
+func (p *webhookJobPersister) UpdateLocked(job models.WebhookJob, lockedUntil time.Time, attempts int) (bool, error) {
+	count, err := p.db.RawQuery(
+		"UPDATE webhook_jobs SET status = ?, attempts = ?, next_attempt_at = ?, locked_until = ?, last_error = ?, updated_at = ? WHERE id = ? AND attempts = ? AND locked_until = ?",
+		job.Status, job.Attempts, job.NextAttemptAt, job.LockedUntil, job.LastError, job.UpdatedAt, job.ID, attempts, lockedUntil,
+	).ExecWithCount()
+	if err != nil {
+		return false, fmt.Errorf("failed to update webhook job: %w", err)
+	}
+
+	return count == 1, nil
+}
+
+func (p *webhookJobPersister) DeleteFinishedBefore(t time.Time) (int, error) {
+	count, err := p.db.RawQuery(
+		"DELETE FROM webhook_jobs WHERE status <> ? AND updated_at < ?",
+		models.WebhookJobStatusPending, t,
+	).ExecWithCount()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete finished webhook jobs: %w", err)
+	}
+
+	return count, nil
+}
@@ -1,22 +1,176 @@
 package server
 
 import (
+	"context"
+	"fmt"
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+	auditlog "github.com/teamhanko/hanko/backend/audit_log"
 	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/crypto/jwk"
+	"github.com/teamhanko/hanko/backend/dto/webhook"
+	"github.com/teamhanko/hanko/backend/flow_api"
 	"github.com/teamhanko/hanko/backend/handler"
+	"github.com/teamhanko/hanko/backend/mail"
 	"github.com/teamhanko/hanko/backend/mapper"
 	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/webhooks"
+	"github.com/teamhanko/hanko/backend/webhooks/events"
 	"sync"
+	"time"
 )
 
-func StartPublic(cfg *config.Config, wg *sync.WaitGroup, persister persistence.Persister, prometheus echo.MiddlewareFunc, authenticatorMetadata mapper.AuthenticatorMetadata) {
+// shutdownTimeout is the time in-flight requests are given to complete when a server is shut down.
+const shutdownTimeout = 10 * time.Second
+
+// StartPublic starts the public API and shuts it down gracefully once the context is done.
+func StartPublic(ctx context.Context, cfg *config.Config, wg *sync.WaitGroup, persister persistence.Persister, auditLogger auditlog.Logger, prometheus echo.MiddlewareFunc, authenticatorMetadata mapper.AuthenticatorMetadata) {
 	defer wg.Done()
-	router := handler.NewPublicRouter(cfg, persister, prometheus, authenticatorMetadata)
-	router.Logger.Fatal(router.Start(cfg.Server.Public.Address))
+	router := handler.NewPublicRouter(cfg, persister, prometheus, authenticatorMetadata, auditLogger)
+	if cfg.Secrets.Jwk.RotationInterval > 0 {
+		go startJwkRotation(ctx, cfg, persister)
+	}
+	run(ctx, router, cfg.Server.Public.Address)
+}
+
+// startJwkRotation rotates the JWKs once the signing key is older than the configured rotation interval, until the
+// context is done.
+func startJwkRotation(ctx context.Context, cfg *config.Config, persister persistence.Persister) {
+	jwkManager, err := jwk.NewDefaultManager(cfg.Secrets.Keys, persister.GetJwkPersister(), jwk.WithKeyAlgorithm(cfg.Secrets.Jwk.Algorithm), jwk.WithGracePeriod(cfg.Secrets.Jwk.GracePeriod), jwk.WithLock(persister.GetSchedulerLockPersister(nil)), jwk.WithRetainedKeys(persister.GetAuditLogChainPersister(nil).ListCheckpointKeyIDs))
+	if err != nil {
+		log.New("jwk").Error(fmt.Errorf("failed to create jwk manager, jwks are not rotated: %w", err))
+		return
+	}
+
+	jwk.NewRotationScheduler(jwkManager, cfg.Secrets.Jwk.RotationInterval).Run(ctx.Done())
 }
 
-func StartAdmin(cfg *config.Config, wg *sync.WaitGroup, persister persistence.Persister, prometheus echo.MiddlewareFunc) {
+// StartAdmin starts the admin API and shuts it down gracefully once the context is done.
+func StartAdmin(ctx context.Context, cfg *config.Config, wg *sync.WaitGroup, persister persistence.Persister, prometheus echo.MiddlewareFunc) {
 	defer wg.Done()
 	router := handler.NewAdminRouter(cfg, persister, prometheus)
-	router.Logger.Fatal(router.Start(cfg.Server.Admin.Address))
+	run(ctx, router, cfg.Server.Admin.Address)
+}
+
+// run starts the router and blocks until the context is done and the router has been shut down, i.e. until all
+// in-flight requests have been completed or the shutdownTimeout has passed.
+func run(ctx context.Context, router *echo.Echo, address string) {
+	errs := make(chan error, 1)
+	go func() {
+		errs <- router.Start(address)
+	}()
+
+	select {
+	case err := <-errs:
+		router.Logger.Fatal(err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	err := router.Shutdown(shutdownCtx)
+	if err != nil {
+		router.Logger.Error(fmt.Errorf("failed to shut down server: %w", err))
+	}
+}
+
+// StartAuditLogPruner prunes the persisted audit logs according to the configured retention, if enabled.
+func StartAuditLogPruner(cfg *config.Config, persister persistence.Persister) {
+	if !cfg.AuditLog.Storage.Enabled || !cfg.AuditLog.Retention.Enabled {
+		return
+	}
+
+	auditlog.NewPruneScheduler(persister, cfg.AuditLog.Retention).Run(nil)
+}
+
+// StartFlowCleanup deletes expired flows from the database periodically, if enabled and flows are stored in the
+// database.
+func StartFlowCleanup(cfg *config.Config, persister persistence.Persister) {
+	if cfg.Flow.Store.Type != config.FlowStoreTypeDatabase || !cfg.Flow.Cleanup.Enabled {
+		return
+	}
+
+	flow_api.NewFlowCleanupScheduler(persister, cfg.Flow.Cleanup).Run(nil)
+}
+
+// StartAuditLogCheckpointer periodically signs the head of the audit log hash chain, if enabled.
+func StartAuditLogCheckpointer(cfg *config.Config, persister persistence.Persister) {
+	integrity := cfg.AuditLog.Integrity
+	if !cfg.AuditLog.Storage.Enabled || !integrity.Enabled || !integrity.Checkpoints.Enabled {
+		return
+	}
+
+	jwkManager, err := jwk.NewDefaultManager(cfg.Secrets.Keys, persister.GetJwkPersister(), jwk.WithKeyAlgorithm(cfg.Secrets.Jwk.Algorithm), jwk.WithGracePeriod(cfg.Secrets.Jwk.GracePeriod), jwk.WithLock(persister.GetSchedulerLockPersister(nil)))
+	if err != nil {
+		log.New("audit_log").Fatal(fmt.Errorf("failed to create jwk manager: %w", err))
+	}
+
+	auditlog.NewCheckpointScheduler(persister, jwkManager, integrity.Checkpoints).Run(nil)
+}
+
+// StartAuditLogChainer appends audit logs to the hash chain which have not been appended after their creation, if
+// the hash chain is enabled.
+func StartAuditLogChainer(cfg *config.Config, persister persistence.Persister) {
+	if !cfg.AuditLog.Storage.Enabled || !cfg.AuditLog.Integrity.Enabled {
+		return
+	}
+
+	auditlog.NewPendingChainer(persister).Run(nil)
+}
+
+// StartWebhookDispatcher delivers the persisted webhook jobs. It must only be started once per process.
+func StartWebhookDispatcher(cfg *config.Config, persister persistence.Persister) {
+	logger := log.New("webhooks")
+	jwkManager, err := jwk.NewDefaultManager(cfg.Secrets.Keys, persister.GetJwkPersister(), jwk.WithKeyAlgorithm(cfg.Secrets.Jwk.Algorithm), jwk.WithGracePeriod(cfg.Secrets.Jwk.GracePeriod), jwk.WithLock(persister.GetSchedulerLockPersister(nil)))
+	if err != nil {
+		logger.Fatal(fmt.Errorf("failed to create jwk manager: %w", err))
+	}
+
+	webhooks.NewDispatcher(cfg, persister, jwkManager, logger).Run(nil)
+}
+
+// StartEmailDispatcher sends the emails in the send queue, if enabled. Emails which could not be delivered trigger the
+// "email.failed" webhook.
+func StartEmailDispatcher(cfg *config.Config, persister persistence.Persister) {
+	if !cfg.EmailDelivery.Enabled || !cfg.EmailDelivery.Queue.Enabled {
+		return
+	}
+
+	logger := log.New("email")
+	mailer, err := mail.NewMailer(cfg.EmailDelivery)
+	if err != nil {
+		logger.Fatal(fmt.Errorf("failed to create mailer: %w", err))
+	}
+
+	jwkManager, err := jwk.NewDefaultManager(cfg.Secrets.Keys, persister.GetJwkPersister(), jwk.WithKeyAlgorithm(cfg.Secrets.Jwk.Algorithm), jwk.WithGracePeriod(cfg.Secrets.Jwk.GracePeriod), jwk.WithLock(persister.GetSchedulerLockPersister(nil)))
+	if err != nil {
+		logger.Fatal(fmt.Errorf("failed to create jwk manager: %w", err))
+	}
+	webhookManager, err := webhooks.NewManager(cfg, persister, jwkManager, logger)
+	if err != nil {
+		logger.Fatal(fmt.Errorf("failed to create webhook manager: %w", err))
+	}
+
+	onFailure := func(emailMessage models.EmailMessage, message mail.Message) {
+		data := webhook.EmailFailed{
+			MessageID:      emailMessage.ID.String(),
+			ToEmailAddress: emailMessage.ToAddress,
+			Subject:        message.Subject,
+			Attempts:       emailMessage.Attempts,
+			CreatedAt:      emailMessage.CreatedAt,
+		}
+		if emailMessage.LastError != nil {
+			data.Error = *emailMessage.LastError
+		}
+		webhookManager.Trigger(nil, events.EmailFailed, data)
+	}
+
+	dispatcher, err := mail.NewDispatcher(cfg.EmailDelivery.Queue, cfg.Secrets.Keys, persister, mailer, onFailure, logger)
+	if err != nil {
+		logger.Fatal(fmt.Errorf("failed to create email dispatcher: %w", err))
+	}
+
+	dispatcher.Run(nil)
 }
@@ -13,7 +13,7 @@ import (
 )
 
 type Manager interface {
-	GenerateJWT(userId uuid.UUID, userDto *dto.EmailJwt) (string, jwt.Token, error)
+	GenerateJWT(userId uuid.UUID, userDto *dto.EmailJwt, opts ...JWTOption) (string, jwt.Token, error)
 	Verify(string) (jwt.Token, error)
 	GenerateCookie(token string) (*http.Cookie, error)
 	DeleteCookie() (*http.Cookie, error)
@@ -21,7 +21,7 @@ type Manager interface {
 
 // Manager is used to create and verify session JWTs
 type manager struct {
-	jwtGenerator  hankoJwt.Generator
+	jwkManager    hankoJwk.Manager
 	sessionLength time.Duration
 	cookieConfig  cookieConfig
 	issuer        string
@@ -40,17 +40,47 @@ const (
 	GeneratorCreateFailure = "failed to create session generator: %w"
 )
 
-// NewManager returns a new Manager which will be used to create and verify sessions JWTs
-func NewManager(jwkManager hankoJwk.Manager, config config.Config) (Manager, error) {
-	signatureKey, err := jwkManager.GetSigningKey()
-	if err != nil {
-		return nil, fmt.Errorf(GeneratorCreateFailure, err)
+// reservedClaims are the claims set by the Manager, they cannot be overridden by custom claims.
+var reservedClaims = []string{
+	jwt.SubjectKey,
+	jwt.IssuedAtKey,
+	jwt.ExpirationKey,
+	jwt.AudienceKey,
+	jwt.IssuerKey,
+	jwt.NotBeforeKey,
+	jwt.JwtIDKey,
+	"session_id",
+	"email",
+}
+
+// JWTOption customizes a session JWT generated by the Manager.
+type JWTOption func(token jwt.Token)
+
+// WithCustomClaims adds the given claims to the session JWT. Reserved claims (e.g. `sub`, `exp` or `session_id`) are
+// ignored.
+func WithCustomClaims(claims map[string]interface{}) JWTOption {
+	return func(token jwt.Token) {
+		for key, value := range claims {
+			if !IsReservedClaim(key) {
+				_ = token.Set(key, value)
+			}
+		}
 	}
-	verificationKeys, err := jwkManager.GetPublicKeys()
-	if err != nil {
-		return nil, fmt.Errorf(GeneratorCreateFailure, err)
+}
+
+// IsReservedClaim reports whether the given claim is set by the Manager and therefore cannot be used as custom claim.
+func IsReservedClaim(claim string) bool {
+	for _, reservedClaim := range reservedClaims {
+		if claim == reservedClaim {
+			return true
+		}
 	}
-	g, err := hankoJwt.NewGenerator(signatureKey, verificationKeys)
+	return false
+}
+
+// NewManager returns a new Manager which will be used to create and verify sessions JWTs
+func NewManager(jwkManager hankoJwk.Manager, config config.Config) (Manager, error) {
+	_, err := newJwtGenerator(jwkManager)
 	if err != nil {
 		return nil, fmt.Errorf(GeneratorCreateFailure, err)
 	}
@@ -75,7 +105,7 @@ func NewManager(jwkManager hankoJwk.Manager, config config.Config) (Manager, err
 	}
 
 	return &manager{
-		jwtGenerator:  g,
+		jwkManager:    jwkManager,
 		sessionLength: duration,
 		issuer:        config.Session.Issuer,
 		cookieConfig: cookieConfig{
@@ -89,8 +119,22 @@ func NewManager(jwkManager hankoJwk.Manager, config config.Config) (Manager, err
 	}, nil
 }
 
+// newJwtGenerator returns a jwt generator using the current keys of the given jwk manager. Generators are not reused,
+// so rotated keys are picked up without restarting.
+func newJwtGenerator(jwkManager hankoJwk.Manager) (hankoJwt.Generator, error) {
+	signatureKey, err := jwkManager.GetSigningKey()
+	if err != nil {
+		return nil, err
+	}
+	verificationKeys, err := jwkManager.GetPublicKeys()
+	if err != nil {
+		return nil, err
+	}
+	return hankoJwt.NewGenerator(signatureKey, verificationKeys)
+}
+
 // GenerateJWT creates a new session JWT for the given user
-func (m *manager) GenerateJWT(userId uuid.UUID, email *dto.EmailJwt) (string, jwt.Token, error) {
+func (m *manager) GenerateJWT(userId uuid.UUID, email *dto.EmailJwt, opts ...JWTOption) (string, jwt.Token, error) {
 	sessionID, err := uuid.NewV4()
 	if err != nil {
 		return "", nil, err
@@ -99,6 +143,10 @@ func (m *manager) GenerateJWT(userId uuid.UUID, email *dto.EmailJwt) (string, jw
 	expiration := issuedAt.Add(m.sessionLength)
 
 	token := jwt.New()
+	for _, opt := range opts {
+		opt(token)
+	}
+
 	_ = token.Set(jwt.SubjectKey, userId.String())
 	_ = token.Set(jwt.IssuedAtKey, issuedAt)
 	_ = token.Set(jwt.ExpirationKey, expiration)
@@ -113,7 +161,12 @@ func (m *manager) GenerateJWT(userId uuid.UUID, email *dto.EmailJwt) (string, jw
 		_ = token.Set(jwt.IssuerKey, m.issuer)
 	}
 
-	signed, err := m.jwtGenerator.Sign(token)
+	jwtGenerator, err := newJwtGenerator(m.jwkManager)
+	if err != nil {
+		return "", nil, fmt.Errorf(GeneratorCreateFailure, err)
+	}
+
+	signed, err := jwtGenerator.Sign(token)
 	if err != nil {
 		return "", nil, err
 	}
@@ -123,7 +176,12 @@ func (m *manager) GenerateJWT(userId uuid.UUID, email *dto.EmailJwt) (string, jw
 
 // Verify verifies the given JWT and returns a parsed one if verification was successful
 func (m *manager) Verify(token string) (jwt.Token, error) {
-	parsedToken, err := m.jwtGenerator.Verify([]byte(token))
+	jwtGenerator, err := newJwtGenerator(m.jwkManager)
+	if err != nil {
+		return nil, fmt.Errorf(GeneratorCreateFailure, err)
+	}
+
+	parsedToken, err := jwtGenerator.Verify([]byte(token))
 	if err != nil {
 		return nil, fmt.Errorf("failed to verify session token: %w", err)
 	}
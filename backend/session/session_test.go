@@ -146,6 +146,40 @@ func TestManager_GenerateJWT_AdditionalAudiences(t *testing.T) {
 	assert.Equal(t, "hanko", token.Issuer())
 }
 
+func TestManager_GenerateJWT_CustomClaims(t *testing.T) {
+	manager := test.JwkManager{}
+	cfg := config.Config{
+		Session: config.Session{
+			Issuer:   "hanko",
+			Lifespan: "5m",
+		},
+		Webauthn: config.WebauthnSettings{
+			RelyingParty: config.RelyingParty{
+				Id: "test.hanko.io",
+			},
+		},
+	}
+	sessionGenerator, err := NewManager(&manager, cfg)
+	assert.NoError(t, err)
+	require.NotEmpty(t, sessionGenerator)
+
+	userId, _ := uuid.NewV4()
+	j, _, err := sessionGenerator.GenerateJWT(userId, nil, WithCustomClaims(map[string]interface{}{
+		"customer_id": "cus_123",
+		"sub":         "someone-else",
+		"iss":         "evil",
+	}))
+	assert.NoError(t, err)
+
+	token, err := jwt.ParseString(j, jwt.WithVerify(false))
+	assert.NoError(t, err)
+	customerId, ok := token.Get("customer_id")
+	assert.True(t, ok)
+	assert.Equal(t, "cus_123", customerId)
+	assert.Equal(t, userId.String(), token.Subject())
+	assert.Equal(t, "hanko", token.Issuer())
+}
+
 func TestGenerator_Verify_Error(t *testing.T) {
 	manager := test.JwkManager{}
 	cfg := config.Config{}
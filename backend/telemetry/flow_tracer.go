@@ -0,0 +1,63 @@
+package telemetry
+
+import (
+	"context"
+	"github.com/labstack/echo/v4"
+	"github.com/teamhanko/hanko/backend/flowpilot"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// FlowTracer implements flowpilot.Tracer. Flowpilot starts and ends its spans in order, so the spans are kept on a
+// stack and every span is started as child of the span on top of it.
+type FlowTracer struct {
+	httpContext echo.Context
+	root        context.Context
+	// stack holds the contexts of the spans which have not been ended yet.
+	stack []context.Context
+}
+
+// NewFlowTracer returns a FlowTracer starting the spans of a flow execution as children of the span of the request.
+// While a span is active, it is set as the span of the request context, so calls made with the request context, e.g.
+// to before hooks, are traced as part of the action or hook making them.
+func NewFlowTracer(c echo.Context) *FlowTracer {
+	return &FlowTracer{httpContext: c, root: c.Request().Context()}
+}
+
+// Context returns the context of the innermost span which has not been ended yet, so spans of e.g. database queries
+// are started as children of the action or hook being executed.
+func (t *FlowTracer) Context() context.Context {
+	if len(t.stack) == 0 {
+		return t.root
+	}
+
+	return t.stack[len(t.stack)-1]
+}
+
+func (t *FlowTracer) StartSpan(name string, attributes map[string]string) flowpilot.SpanEnd {
+	kvs := make([]attribute.KeyValue, 0, len(attributes))
+	for key, value := range attributes {
+		if value != "" {
+			kvs = append(kvs, attribute.String(key, value))
+		}
+	}
+
+	ctx, span := StartSpan(t.Context(), name, kvs...)
+
+	t.stack = append(t.stack, ctx)
+	t.updateRequestContext()
+	depth := len(t.stack)
+
+	return func(err error) {
+		// spans started within this span and not ended are dropped from the stack as well
+		if len(t.stack) >= depth {
+			t.stack = t.stack[:depth-1]
+			t.updateRequestContext()
+		}
+
+		EndSpan(span, err)
+	}
+}
+
+func (t *FlowTracer) updateRequestContext() {
+	t.httpContext.SetRequest(t.httpContext.Request().WithContext(t.Context()))
+}
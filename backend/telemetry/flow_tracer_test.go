@@ -0,0 +1,105 @@
+package telemetry
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamhanko/hanko/backend/flowpilot"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func setupRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+	})
+
+	return recorder
+}
+
+func attributeValue(span sdktrace.ReadOnlySpan, key string) string {
+	for _, kv := range span.Attributes() {
+		if kv.Key == attribute.Key(key) {
+			return kv.Value.AsString()
+		}
+	}
+	return ""
+}
+
+func TestFlowTracer(t *testing.T) {
+	recorder := setupRecorder(t)
+
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/login", nil), httptest.NewRecorder())
+	tracer := NewFlowTracer(c)
+
+	endExecute := tracer.StartSpan(flowpilot.SpanExecute, map[string]string{
+		flowpilot.TraceAttributeFlowName: "login",
+		flowpilot.TraceAttributeAction:   "continue_with_login_identifier",
+	})
+	endAction := tracer.StartSpan(flowpilot.SpanAction, map[string]string{flowpilot.TraceAttributeState: "login_init"})
+
+	actionSpanContext := trace.SpanContextFromContext(c.Request().Context())
+	assert.True(t, actionSpanContext.IsValid())
+	assert.Equal(t, actionSpanContext, trace.SpanContextFromContext(tracer.Context()))
+
+	endHook := tracer.StartSpan(flowpilot.SpanHook, map[string]string{flowpilot.TraceAttributeHook: "shared.IssueSession"})
+	endHook(errors.New("hook failed"))
+
+	assert.Equal(t, actionSpanContext, trace.SpanContextFromContext(c.Request().Context()))
+
+	endAction(nil)
+	endExecute(nil)
+
+	assert.False(t, trace.SpanContextFromContext(c.Request().Context()).IsValid())
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	hook, action, execute := spans[0], spans[1], spans[2]
+
+	assert.Equal(t, flowpilot.SpanHook, hook.Name())
+	assert.Equal(t, action.SpanContext().SpanID(), hook.Parent().SpanID())
+	assert.Equal(t, execute.SpanContext().SpanID(), action.Parent().SpanID())
+
+	assert.Equal(t, "login", attributeValue(hook, flowpilot.TraceAttributeFlowName))
+	assert.Equal(t, "login_init", attributeValue(hook, flowpilot.TraceAttributeState))
+	assert.Equal(t, "continue_with_login_identifier", attributeValue(hook, flowpilot.TraceAttributeAction))
+	assert.Equal(t, "shared.IssueSession", attributeValue(hook, flowpilot.TraceAttributeHook))
+	assert.Equal(t, codes.Error, hook.Status().Code)
+
+	assert.Equal(t, "", attributeValue(execute, flowpilot.TraceAttributeState))
+	assert.Equal(t, codes.Unset, execute.Status().Code)
+}
+
+func TestFlowTracer_UnendedSpans(t *testing.T) {
+	setupRecorder(t)
+
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/login", nil), httptest.NewRecorder())
+	tracer := NewFlowTracer(c)
+
+	endExecute := tracer.StartSpan(flowpilot.SpanExecute, nil)
+	executeSpanContext := trace.SpanContextFromContext(tracer.Context())
+
+	endAction := tracer.StartSpan(flowpilot.SpanAction, nil)
+	tracer.StartSpan(flowpilot.SpanHook, nil)
+
+	endAction(nil)
+	assert.Equal(t, executeSpanContext, trace.SpanContextFromContext(tracer.Context()))
+
+	endExecute(nil)
+	assert.False(t, trace.SpanContextFromContext(tracer.Context()).IsValid())
+}
@@ -0,0 +1,100 @@
+package telemetry
+
+import (
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+)
+
+// Middleware starts a span for every request, continuing the trace of the caller if the request carries a trace
+// context. The span is passed on with the context of the request.
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			request := c.Request()
+			ctx := otel.GetTextMapPropagator().Extract(request.Context(), propagation.HeaderCarrier(request.Header))
+
+			// the attributes of the request are not inherited by the spans started with StartSpan
+			ctx, span := tracer().Start(ctx, fmt.Sprintf("%s %s", request.Method, c.Path()),
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPMethod(request.Method),
+					semconv.HTTPRoute(c.Path()),
+					semconv.HTTPTarget(request.URL.Path),
+					semconv.HTTPClientIP(c.RealIP()),
+				))
+			defer span.End()
+
+			c.SetRequest(request.WithContext(ctx))
+
+			err := next(c)
+
+			status := c.Response().Status
+			if err != nil {
+				// the error response is written by the error handler after the middlewares have returned
+				status = http.StatusInternalServerError
+				var httpError *echo.HTTPError
+				if errors.As(err, &httpError) {
+					status = httpError.Code
+				}
+			}
+
+			span.SetAttributes(semconv.HTTPStatusCode(status))
+			if status >= http.StatusInternalServerError {
+				if err != nil {
+					span.RecordError(err)
+				}
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+
+			return err
+		}
+	}
+}
+
+// NewTransport returns a http.RoundTripper starting a span for every request sent with the given RoundTripper and
+// passing the trace context to the receiver. http.DefaultTransport is used if base is nil.
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &transport{base: base}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(request *http.Request) (*http.Response, error) {
+	ctx, span := startSpan(request.Context(), fmt.Sprintf("HTTP %s", request.Method), trace.SpanKindClient, nil)
+	span.SetAttributes(
+		semconv.HTTPMethod(request.Method),
+		semconv.HTTPURL(request.URL.Redacted()),
+		semconv.NetPeerName(request.URL.Hostname()),
+	)
+
+	// the request must not be modified by a RoundTripper
+	request = request.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(request.Header))
+
+	response, err := t.base.RoundTrip(request)
+	if err != nil {
+		EndSpan(span, err)
+		return nil, err
+	}
+
+	span.SetAttributes(semconv.HTTPStatusCode(response.StatusCode))
+	if response.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, http.StatusText(response.StatusCode))
+	}
+	span.End()
+
+	return response, nil
+}
@@ -0,0 +1,44 @@
+package telemetry
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"time"
+)
+
+// The metrics are registered with the default registry, which is exposed on the "/metrics" endpoint of the admin API.
+var (
+	flowStarts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "hanko",
+		Subsystem: "flow",
+		Name:      "starts_total",
+		Help:      "Number of started flows, partitioned by flow and outcome.",
+	}, []string{"flow", "outcome"})
+
+	flowActions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "hanko",
+		Subsystem: "flow",
+		Name:      "actions_total",
+		Help:      "Number of executed flow actions, partitioned by flow, state, action and outcome.",
+	}, []string{"flow", "state", "action", "outcome"})
+
+	flowActionDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "hanko",
+		Subsystem: "flow",
+		Name:      "action_duration_seconds",
+		Help:      "Duration of flow action executions in seconds, partitioned by flow, state, action and outcome.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"flow", "state", "action", "outcome"})
+)
+
+// ObserveFlowStart counts the start of a flow.
+func ObserveFlowStart(flow string, outcome string) {
+	flowStarts.WithLabelValues(flow, outcome).Inc()
+}
+
+// ObserveFlowAction counts the execution of an action and records its duration. The label values must be known
+// names, e.g. not the unvalidated action name of a request, to keep the number of time series bounded.
+func ObserveFlowAction(flow string, state string, action string, outcome string, duration time.Duration) {
+	flowActions.WithLabelValues(flow, state, action, outcome).Inc()
+	flowActionDuration.WithLabelValues(flow, state, action, outcome).Observe(duration.Seconds())
+}
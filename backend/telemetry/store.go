@@ -0,0 +1,135 @@
+package telemetry
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/jmoiron/sqlx"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+)
+
+// popStore has the method set of the unexported store interface of pop, so the store of a connection can be wrapped.
+type popStore interface {
+	Select(interface{}, string, ...interface{}) error
+	Get(interface{}, string, ...interface{}) error
+	NamedExec(string, interface{}) (sql.Result, error)
+	Exec(string, ...interface{}) (sql.Result, error)
+	PrepareNamed(string) (*sqlx.NamedStmt, error)
+	Transaction() (*pop.Tx, error)
+	Rollback() error
+	Commit() error
+	Close() error
+
+	SelectContext(context.Context, interface{}, string, ...interface{}) error
+	GetContext(context.Context, interface{}, string, ...interface{}) error
+	NamedExecContext(context.Context, string, interface{}) (sql.Result, error)
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	PrepareNamedContext(context.Context, string) (*sqlx.NamedStmt, error)
+	TransactionContext(context.Context) (*pop.Tx, error)
+	TransactionContextOptions(context.Context, *sql.TxOptions) (*pop.Tx, error)
+}
+
+// TraceConnection starts a span for every query executed with the connection. The spans are started as children of
+// the span in the context returned by ctx at the time of the query. The context of the queries themselves is not
+// changed.
+func TraceConnection(tx *pop.Connection, ctx func() context.Context) {
+	tx.Store = &tracedStore{popStore: tx.Store, ctx: ctx, system: tx.Dialect.Name()}
+}
+
+type tracedStore struct {
+	popStore
+	ctx    func() context.Context
+	system string
+}
+
+func (s *tracedStore) startSpan(query string) trace.Span {
+	operation, _, _ := strings.Cut(strings.TrimSpace(query), " ")
+	operation = strings.ToUpper(operation)
+
+	_, span := startSpan(s.ctx(), "db."+strings.ToLower(operation), trace.SpanKindClient, nil)
+	span.SetAttributes(
+		semconv.DBSystemKey.String(s.system),
+		semconv.DBOperation(operation),
+		semconv.DBStatement(query),
+	)
+
+	return span
+}
+
+// Context returns the context of the wrapped store, which pop passes to models and transactions.
+func (s *tracedStore) Context() context.Context {
+	if c, ok := s.popStore.(interface{ Context() context.Context }); ok {
+		return c.Context()
+	}
+
+	return context.TODO()
+}
+
+func (s *tracedStore) Select(dest interface{}, query string, args ...interface{}) error {
+	span := s.startSpan(query)
+	err := s.popStore.Select(dest, query, args...)
+	EndSpan(span, err)
+	return err
+}
+
+func (s *tracedStore) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	span := s.startSpan(query)
+	err := s.popStore.SelectContext(ctx, dest, query, args...)
+	EndSpan(span, err)
+	return err
+}
+
+func (s *tracedStore) Get(dest interface{}, query string, args ...interface{}) error {
+	span := s.startSpan(query)
+	err := s.popStore.Get(dest, query, args...)
+	endGetSpan(span, err)
+	return err
+}
+
+func (s *tracedStore) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	span := s.startSpan(query)
+	err := s.popStore.GetContext(ctx, dest, query, args...)
+	endGetSpan(span, err)
+	return err
+}
+
+// endGetSpan ends the span of a query for a single row. Not finding a row is an expected result and not recorded as
+// error.
+func endGetSpan(span trace.Span, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+	}
+
+	EndSpan(span, err)
+}
+
+func (s *tracedStore) NamedExec(query string, arg interface{}) (sql.Result, error) {
+	span := s.startSpan(query)
+	result, err := s.popStore.NamedExec(query, arg)
+	EndSpan(span, err)
+	return result, err
+}
+
+func (s *tracedStore) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	span := s.startSpan(query)
+	result, err := s.popStore.NamedExecContext(ctx, query, arg)
+	EndSpan(span, err)
+	return result, err
+}
+
+func (s *tracedStore) Exec(query string, args ...interface{}) (sql.Result, error) {
+	span := s.startSpan(query)
+	result, err := s.popStore.Exec(query, args...)
+	EndSpan(span, err)
+	return result, err
+}
+
+func (s *tracedStore) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	span := s.startSpan(query)
+	result, err := s.popStore.ExecContext(ctx, query, args...)
+	EndSpan(span, err)
+	return result, err
+}
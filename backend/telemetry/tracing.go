@@ -0,0 +1,104 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"github.com/teamhanko/hanko/backend/build_info"
+	"github.com/teamhanko/hanko/backend/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/teamhanko/hanko/backend"
+
+// InitTracing registers the tracer provider exporting the traces to the configured collector, if tracing is enabled.
+// Otherwise, spans are not recorded. The returned function flushes the buffered spans and stops the export.
+func InitTracing(cfg config.Tracing) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	if len(cfg.Headers) > 0 {
+		options = append(options, otlptracehttp.WithHeaders(cfg.Headers))
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceName(cfg.ServiceName),
+			semconv.ServiceVersion(build_info.GetVersion()),
+		)),
+	)
+
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+type attributesKey struct{}
+
+// StartSpan starts a span as child of the span in the context. The span carries the given attributes and the
+// attributes of its ancestors started with StartSpan, e.g. the flow name, state and action for spans started during
+// the execution of a flow. The returned context contains the span.
+func StartSpan(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return startSpan(ctx, name, trace.SpanKindInternal, attributes)
+}
+
+func startSpan(ctx context.Context, name string, kind trace.SpanKind, attributes []attribute.KeyValue) (context.Context, trace.Span) {
+	inherited, _ := ctx.Value(attributesKey{}).([]attribute.KeyValue)
+
+	// attributes of the span replace inherited attributes with the same key
+	merged := make([]attribute.KeyValue, 0, len(inherited)+len(attributes))
+	for _, kv := range inherited {
+		if !containsKey(attributes, kv.Key) {
+			merged = append(merged, kv)
+		}
+	}
+	merged = append(merged, attributes...)
+
+	ctx = context.WithValue(ctx, attributesKey{}, merged)
+
+	return tracer().Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(merged...))
+}
+
+func containsKey(attributes []attribute.KeyValue, key attribute.Key) bool {
+	for _, kv := range attributes {
+		if kv.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// EndSpan ends the span, recording the error if it is not nil.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
@@ -23,3 +23,13 @@ func NewTemplateRenderer() *Template {
 		templates: template.Must(template.ParseFS(templateFS, "templates/*.tmpl")),
 	}
 }
+
+// ConfirmationPage is the data of the "confirm" template. The page asks the user to confirm the action of a link sent
+// by email and submits the token of the link with a POST request, so the action is not performed by link scanners or
+// prefetchers of mail gateways opening the link. The form is submitted to the URL of the page.
+type ConfirmationPage struct {
+	Title   string
+	Message string
+	Token   string
+	Button  string
+}
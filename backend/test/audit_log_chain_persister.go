@@ -0,0 +1,81 @@
+package test
+
+import (
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"golang.org/x/exp/slices"
+	"sort"
+	"sync"
+)
+
+func NewAuditLogChainPersister(checkpoints []models.AuditLogCheckpoint) persistence.AuditLogChainPersister {
+	return &auditLogChainPersister{
+		head:        models.AuditLogChainHead{ID: models.AuditLogChainHeadID},
+		checkpoints: append([]models.AuditLogCheckpoint{}, checkpoints...),
+	}
+}
+
+type auditLogChainPersister struct {
+	mutex       sync.Mutex
+	head        models.AuditLogChainHead
+	checkpoints []models.AuditLogCheckpoint
+}
+
+func (p *auditLogChainPersister) GetHead(_ bool) (*models.AuditLogChainHead, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	head := p.head
+	return &head, nil
+}
+
+func (p *auditLogChainPersister) UpdateHead(head models.AuditLogChainHead) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.head = head
+	return nil
+}
+
+func (p *auditLogChainPersister) CreateCheckpoint(checkpoint models.AuditLogCheckpoint) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.checkpoints = append(p.checkpoints, checkpoint)
+	sort.Slice(p.checkpoints, func(i, j int) bool {
+		return p.checkpoints[i].ChainSequence < p.checkpoints[j].ChainSequence
+	})
+	return nil
+}
+
+func (p *auditLogChainPersister) GetLatestCheckpoint() (*models.AuditLogCheckpoint, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if len(p.checkpoints) == 0 {
+		return nil, nil
+	}
+
+	checkpoint := p.checkpoints[len(p.checkpoints)-1]
+	return &checkpoint, nil
+}
+
+func (p *auditLogChainPersister) ListCheckpoints() ([]models.AuditLogCheckpoint, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return append([]models.AuditLogCheckpoint{}, p.checkpoints...), nil
+}
+
+func (p *auditLogChainPersister) ListCheckpointKeyIDs() ([]string, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	keyIDs := []string{}
+	for _, checkpoint := range p.checkpoints {
+		if !slices.Contains(keyIDs, checkpoint.KeyID) {
+			keyIDs = append(keyIDs, checkpoint.KeyID)
+		}
+	}
+	return keyIDs, nil
+}
@@ -1,9 +1,12 @@
 package test
 
 import (
+	"fmt"
 	"github.com/gofrs/uuid"
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"golang.org/x/exp/slices"
+	"sort"
 	"time"
 )
 
@@ -62,6 +65,104 @@ func (p *auditLogPersister) List(page int, perPage int, startTime *time.Time, en
 	return result[page-1], nil
 }
 
+func (p *auditLogPersister) ListAfter(cursor *models.AuditLog, limit int, startTime *time.Time, endTime *time.Time, types []string, userId string, email string, ip string, searchString string) ([]models.AuditLog, error) {
+	sorted := append([]models.AuditLog{}, p.logs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].CreatedAt.Equal(sorted[j].CreatedAt) {
+			return sorted[i].ID.String() < sorted[j].ID.String()
+		}
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+	})
+
+	result := []models.AuditLog{}
+	for _, log := range sorted {
+		if len(result) >= limit {
+			break
+		}
+		if cursor != nil && !log.CreatedAt.After(cursor.CreatedAt) && !(log.CreatedAt.Equal(cursor.CreatedAt) && log.ID.String() > cursor.ID.String()) {
+			continue
+		}
+		if startTime != nil && !log.CreatedAt.After(*startTime) {
+			continue
+		}
+		if endTime != nil && !log.CreatedAt.Before(*endTime) {
+			continue
+		}
+		if len(types) > 0 && !slices.Contains(types, string(log.Type)) {
+			continue
+		}
+		result = append(result, log)
+	}
+
+	return result, nil
+}
+
+func (p *auditLogPersister) ListChained(afterSequence int64, limit int) ([]models.AuditLog, error) {
+	var chained []models.AuditLog
+	for _, auditLog := range p.logs {
+		if auditLog.ChainSequence != nil && *auditLog.ChainSequence > afterSequence {
+			chained = append(chained, auditLog)
+		}
+	}
+
+	sort.Slice(chained, func(i, j int) bool {
+		return *chained[i].ChainSequence < *chained[j].ChainSequence
+	})
+
+	if len(chained) > limit {
+		chained = chained[:limit]
+	}
+
+	return chained, nil
+}
+
+func (p *auditLogPersister) ListChainPending(createdBefore time.Time, limit int) ([]models.AuditLog, error) {
+	var pending []models.AuditLog
+	for _, auditLog := range p.logs {
+		if isChainPending(auditLog, createdBefore) {
+			pending = append(pending, auditLog)
+		}
+	}
+
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].CreatedAt.Before(pending[j].CreatedAt)
+	})
+
+	if len(pending) > limit {
+		pending = pending[:limit]
+	}
+
+	return pending, nil
+}
+
+func (p *auditLogPersister) CountChainPending(createdBefore time.Time) (int, error) {
+	count := 0
+	for _, auditLog := range p.logs {
+		if isChainPending(auditLog, createdBefore) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func isChainPending(auditLog models.AuditLog, createdBefore time.Time) bool {
+	return auditLog.ChainPending && auditLog.ChainSequence == nil && auditLog.CreatedAt.Before(createdBefore)
+}
+
+func (p *auditLogPersister) UpdateChain(auditLog models.AuditLog) error {
+	for i := range p.logs {
+		if p.logs[i].ID == auditLog.ID {
+			p.logs[i].ChainSequence = auditLog.ChainSequence
+			p.logs[i].PreviousHash = auditLog.PreviousHash
+			p.logs[i].Hash = auditLog.Hash
+			p.logs[i].ChainPending = false
+			return nil
+		}
+	}
+	return fmt.Errorf("auditlog %s not found", auditLog.ID)
+}
+
 func (p *auditLogPersister) Delete(auditLog models.AuditLog) error {
 	index := -1
 	for i, log := range p.logs {
@@ -79,3 +180,41 @@ func (p *auditLogPersister) Delete(auditLog models.AuditLog) error {
 func (p *auditLogPersister) Count(startTime *time.Time, endTime *time.Time, types []string, userId string, email string, ip string, searchString string) (int, error) {
 	return len(p.logs), nil
 }
+
+func (p *auditLogPersister) CountBefore(before time.Time, types []string, excludedTypes []string) (int, error) {
+	count := 0
+	for _, log := range p.logs {
+		if matchesBefore(log, before, types, excludedTypes) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (p *auditLogPersister) DeleteBefore(before time.Time, types []string, excludedTypes []string, limit int) (int, error) {
+	var kept []models.AuditLog
+	deleted := 0
+	for _, log := range p.logs {
+		if deleted < limit && matchesBefore(log, before, types, excludedTypes) {
+			deleted++
+			continue
+		}
+		kept = append(kept, log)
+	}
+	p.logs = kept
+
+	return deleted, nil
+}
+
+func matchesBefore(log models.AuditLog, before time.Time, types []string, excludedTypes []string) bool {
+	if !log.CreatedAt.Before(before) {
+		return false
+	}
+
+	if len(types) > 0 && !slices.Contains(types, string(log.Type)) {
+		return false
+	}
+
+	return !slices.Contains(excludedTypes, string(log.Type))
+}
@@ -21,3 +21,7 @@ func (a *auditLogger) Create(context echo.Context, logType models.AuditLogType,
 func (a *auditLogger) CreateWithConnection(tx *pop.Connection, context echo.Context, logType models.AuditLogType, user *models.User, err error, opts ...auditlog.DetailOption) error {
 	return nil
 }
+
+func (a *auditLogger) Close() error {
+	return nil
+}
@@ -0,0 +1,142 @@
+package test
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+func NewEmailMessagePersister(init models.EmailMessages) persistence.EmailMessagePersister {
+	return &emailMessagePersister{messages: append(models.EmailMessages{}, init...)}
+}
+
+type emailMessagePersister struct {
+	mutex    sync.Mutex
+	messages models.EmailMessages
+}
+
+func (p *emailMessagePersister) Create(message models.EmailMessage) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.messages = append(p.messages, message)
+	return nil
+}
+
+func (p *emailMessagePersister) Update(message models.EmailMessage) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for i, existing := range p.messages {
+		if existing.ID == message.ID {
+			p.messages[i] = message
+		}
+	}
+	return nil
+}
+
+func (p *emailMessagePersister) Get(id uuid.UUID) (*models.EmailMessage, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for _, message := range p.messages {
+		if message.ID == id {
+			j := message
+			return &j, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *emailMessagePersister) ListDue(now time.Time, limit int) (models.EmailMessages, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	messages := models.EmailMessages{}
+	for _, message := range p.messages {
+		if message.Status == models.EmailMessageStatusPending && !message.NextAttemptAt.After(now) && (message.LockedUntil == nil || message.LockedUntil.Before(now)) {
+			messages = append(messages, message)
+		}
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].NextAttemptAt.Before(messages[j].NextAttemptAt)
+	})
+
+	if len(messages) > limit {
+		messages = messages[:limit]
+	}
+
+	return messages, nil
+}
+
+func (p *emailMessagePersister) Lock(message models.EmailMessage, now time.Time, lockedUntil time.Time) (*models.EmailMessage, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for i, existing := range p.messages {
+		if existing.ID == message.ID &&
+			existing.Status == models.EmailMessageStatusPending &&
+			existing.Attempts == message.Attempts &&
+			!existing.NextAttemptAt.After(now) &&
+			(existing.LockedUntil == nil || existing.LockedUntil.Before(now)) {
+			p.messages[i].LockedUntil = &lockedUntil
+			locked := p.messages[i]
+			return &locked, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *emailMessagePersister) UpdateLocked(message models.EmailMessage, lockedUntil time.Time, attempts int) (bool, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for i, existing := range p.messages {
+		if existing.ID == message.ID &&
+			existing.Attempts == attempts &&
+			existing.LockedUntil != nil && existing.LockedUntil.Equal(lockedUntil) {
+			p.messages[i] = message
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (p *emailMessagePersister) ListPending() (models.EmailMessages, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	messages := models.EmailMessages{}
+	for _, message := range p.messages {
+		if message.Status == models.EmailMessageStatusPending {
+			messages = append(messages, message)
+		}
+	}
+	return messages, nil
+}
+
+func (p *emailMessagePersister) UpdateContent(message models.EmailMessage) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for i, existing := range p.messages {
+		if existing.ID == message.ID && existing.Status == models.EmailMessageStatusPending {
+			p.messages[i].Subject = message.Subject
+			p.messages[i].BodyText = message.BodyText
+			p.messages[i].BodyHTML = message.BodyHTML
+		}
+	}
+	return nil
+}
+
+func (p *emailMessagePersister) DeleteFinishedBefore(t time.Time) (int, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	messages := models.EmailMessages{}
+	deleted := 0
+	for _, message := range p.messages {
+		if message.Status != models.EmailMessageStatusPending && message.UpdatedAt.Before(t) {
+			deleted++
+			continue
+		}
+		messages = append(messages, message)
+	}
+	p.messages = messages
+	return deleted, nil
+}
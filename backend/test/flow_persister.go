@@ -0,0 +1,40 @@
+package test
+
+import (
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+func NewFlowPersister(init models.Flows) persistence.FlowPersister {
+	return &flowPersister{flows: append(models.Flows{}, init...)}
+}
+
+type flowPersister struct {
+	mutex sync.Mutex
+	flows models.Flows
+}
+
+func (p *flowPersister) DeleteExpired(before time.Time, limit int) (int, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	sort.SliceStable(p.flows, func(i, j int) bool {
+		return p.flows[i].ExpiresAt.Before(p.flows[j].ExpiresAt)
+	})
+
+	var remaining models.Flows
+	deleted := 0
+	for _, flow := range p.flows {
+		if deleted < limit && flow.ExpiresAt.Before(before) {
+			deleted++
+			continue
+		}
+		remaining = append(remaining, flow)
+	}
+	p.flows = remaining
+
+	return deleted, nil
+}
@@ -3,20 +3,25 @@ package test
 import (
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"sync"
 )
 
 func NewJwkPersister(init []models.Jwk) persistence.JwkPersister {
 	if init == nil {
-		return &jwkPersister{[]models.Jwk{}}
+		return &jwkPersister{keys: []models.Jwk{}}
 	}
-	return &jwkPersister{append([]models.Jwk{}, init...)}
+	return &jwkPersister{keys: append([]models.Jwk{}, init...)}
 }
 
 type jwkPersister struct {
-	keys []models.Jwk
+	mutex sync.Mutex
+	keys  []models.Jwk
 }
 
 func (j *jwkPersister) Get(id int) (*models.Jwk, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
 	var found *models.Jwk
 	for _, data := range j.keys {
 		if data.ID == id {
@@ -28,25 +33,63 @@ func (j *jwkPersister) Get(id int) (*models.Jwk, error) {
 }
 
 func (j *jwkPersister) GetAll() ([]models.Jwk, error) {
-	return j.keys, nil
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return append([]models.Jwk{}, j.keys...), nil
 }
 
 func (j *jwkPersister) GetLast() (*models.Jwk, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
 	l := len(j.keys)
 	if l == 0 {
 		return nil, nil
 	}
-	return &j.keys[l-1], nil
+	last := j.keys[l-1]
+	return &last, nil
 }
 
 func (j *jwkPersister) Create(jwk models.Jwk) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
 	lastId := 0
 	for _, key := range j.keys {
 		if key.ID > lastId {
 			lastId = key.ID
 		}
 	}
-	jwk.ID = lastId
+	jwk.ID = lastId + 1
 	j.keys = append(j.keys, jwk)
 	return nil
 }
+
+func (j *jwkPersister) Update(jwk models.Jwk) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	for i, data := range j.keys {
+		if data.ID == jwk.ID {
+			j.keys[i] = jwk
+		}
+	}
+	return nil
+}
+
+func (j *jwkPersister) Delete(jwk models.Jwk) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	index := -1
+	for i, data := range j.keys {
+		if data.ID == jwk.ID {
+			index = i
+		}
+	}
+	if index > -1 {
+		j.keys = append(j.keys[:index], j.keys[index+1:]...)
+	}
+	return nil
+}
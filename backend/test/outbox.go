@@ -0,0 +1,17 @@
+package test
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// OnlyDue returns the only entry of an outbox which is due within the next day, as listed by the given ListDue
+// function (e.g. of the persistence.EmailMessagePersister). The test fails unless there is exactly one.
+func OnlyDue[S ~[]E, E any](t *testing.T, listDue func(now time.Time, limit int) (S, error)) E {
+	t.Helper()
+	entries, err := listDue(time.Now().Add(24*time.Hour), 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	return entries[0]
+}
@@ -41,10 +41,22 @@ func NewPersister(
 		samlStatePersister:           NewSamlStatePersister(samlStates),
 		samlCertificatePersister:     NewSamlCertificatePersister(samlCertificates),
 		webhookPersister:             NewWebhookPersister(webhooks, webhookEvents),
+		webhookJobPersister:          NewWebhookJobPersister(nil),
+		emailMessagePersister:        NewEmailMessagePersister(nil),
+		webhookDeliveryPersister:     NewWebhookDeliveryPersister(nil),
+		schedulerLockPersister:       NewSchedulerLockPersister(),
+		flowPersister:                NewFlowPersister(nil),
+		auditLogChainPersister:       NewAuditLogChainPersister(nil),
+		usedLinkTokenPersister:       NewUsedLinkTokenPersister(),
 		sessionPersister:             NewSessionPersister(sessions),
 	}
 }
 
+// NewEmptyPersister returns a persister without any records.
+func NewEmptyPersister() persistence.Persister {
+	return NewPersister(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+}
+
 type persister struct {
 	userPersister                persistence.UserPersister
 	passcodePersister            persistence.PasscodePersister
@@ -61,6 +73,13 @@ type persister struct {
 	samlStatePersister           persistence.SamlStatePersister
 	samlCertificatePersister     persistence.SamlCertificatePersister
 	webhookPersister             persistence.WebhookPersister
+	webhookJobPersister          persistence.WebhookJobPersister
+	emailMessagePersister        persistence.EmailMessagePersister
+	webhookDeliveryPersister     persistence.WebhookDeliveryPersister
+	schedulerLockPersister       persistence.SchedulerLockPersister
+	flowPersister                persistence.FlowPersister
+	auditLogChainPersister       persistence.AuditLogChainPersister
+	usedLinkTokenPersister       persistence.UsedLinkTokenPersister
 	sessionPersister             persistence.SessionPersister
 }
 
@@ -189,6 +208,34 @@ func (p *persister) GetWebhookPersister(_ *pop.Connection) persistence.WebhookPe
 	return p.webhookPersister
 }
 
+func (p *persister) GetWebhookJobPersister(_ *pop.Connection) persistence.WebhookJobPersister {
+	return p.webhookJobPersister
+}
+
+func (p *persister) GetEmailMessagePersister(_ *pop.Connection) persistence.EmailMessagePersister {
+	return p.emailMessagePersister
+}
+
+func (p *persister) GetWebhookDeliveryPersister(_ *pop.Connection) persistence.WebhookDeliveryPersister {
+	return p.webhookDeliveryPersister
+}
+
+func (p *persister) GetSchedulerLockPersister(_ *pop.Connection) persistence.SchedulerLockPersister {
+	return p.schedulerLockPersister
+}
+
+func (p *persister) GetFlowPersister(_ *pop.Connection) persistence.FlowPersister {
+	return p.flowPersister
+}
+
+func (p *persister) GetAuditLogChainPersister(_ *pop.Connection) persistence.AuditLogChainPersister {
+	return p.auditLogChainPersister
+}
+
+func (p *persister) GetUsedLinkTokenPersister(_ *pop.Connection) persistence.UsedLinkTokenPersister {
+	return p.usedLinkTokenPersister
+}
+
 func (p *persister) GetSessionPersister() persistence.SessionPersister {
 	return p.sessionPersister
 }
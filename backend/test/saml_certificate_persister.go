@@ -63,6 +63,20 @@ func (s samlCertificatePersister) GetFirst() (*models.SamlCertificate, error) {
 	return nil, errors.New("failed to get first cert")
 }
 
+func (s samlCertificatePersister) GetAll() ([]*models.SamlCertificate, error) {
+	return s.samlCertificates, nil
+}
+
+func (s samlCertificatePersister) Update(cert *models.SamlCertificate) error {
+	for i, existingCertificate := range s.samlCertificates {
+		if existingCertificate.ID == cert.ID {
+			s.samlCertificates[i] = cert
+		}
+	}
+
+	return nil
+}
+
 func (s samlCertificatePersister) Delete(cert *models.SamlCertificate) error {
 	index := -1
 	for i, existingCertificate := range s.samlCertificates {
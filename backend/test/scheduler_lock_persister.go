@@ -0,0 +1,36 @@
+package test
+
+import (
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"sync"
+	"time"
+)
+
+func NewSchedulerLockPersister() persistence.SchedulerLockPersister {
+	return &schedulerLockPersister{locks: make(map[string]models.SchedulerLock)}
+}
+
+type schedulerLockPersister struct {
+	mutex sync.Mutex
+	locks map[string]models.SchedulerLock
+}
+
+func (p *schedulerLockPersister) Acquire(name string, holder string, now time.Time, lockedUntil time.Time) (bool, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	lock, ok := p.locks[name]
+	if ok && !lock.LockedUntil.Before(now) {
+		return false, nil
+	}
+
+	p.locks[name] = models.SchedulerLock{
+		Name:        name,
+		Holder:      holder,
+		LockedUntil: lockedUntil,
+		UpdatedAt:   now,
+	}
+
+	return true, nil
+}
@@ -0,0 +1,36 @@
+package test
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"sync"
+)
+
+func NewUsedLinkTokenPersister() persistence.UsedLinkTokenPersister {
+	return &usedLinkTokenPersister{tokens: make(map[uuid.UUID]models.UsedLinkToken)}
+}
+
+type usedLinkTokenPersister struct {
+	mutex  sync.Mutex
+	tokens map[uuid.UUID]models.UsedLinkToken
+}
+
+func (p *usedLinkTokenPersister) Use(token models.UsedLinkToken) (bool, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for id, used := range p.tokens {
+		if used.ExpiresAt.Before(token.CreatedAt) {
+			delete(p.tokens, id)
+		}
+	}
+
+	if _, ok := p.tokens[token.ID]; ok {
+		return false, nil
+	}
+
+	p.tokens[token.ID] = token
+
+	return true, nil
+}
@@ -0,0 +1,92 @@
+package test
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+func NewWebhookDeliveryPersister(init models.WebhookDeliveries) persistence.WebhookDeliveryPersister {
+	return &webhookDeliveryPersister{deliveries: append(models.WebhookDeliveries{}, init...)}
+}
+
+type webhookDeliveryPersister struct {
+	mutex      sync.Mutex
+	deliveries models.WebhookDeliveries
+}
+
+func (p *webhookDeliveryPersister) Create(delivery models.WebhookDelivery) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.deliveries = append(p.deliveries, delivery)
+	return nil
+}
+
+func (p *webhookDeliveryPersister) Get(id uuid.UUID) (*models.WebhookDelivery, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for _, delivery := range p.deliveries {
+		if delivery.ID == id {
+			d := delivery
+			return &d, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *webhookDeliveryPersister) List(webhookID uuid.UUID, page int, perPage int) (models.WebhookDeliveries, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	deliveries := models.WebhookDeliveries{}
+	for _, delivery := range p.deliveries {
+		if delivery.WebhookID != nil && *delivery.WebhookID == webhookID {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+	})
+
+	start := (page - 1) * perPage
+	if start >= len(deliveries) {
+		return models.WebhookDeliveries{}, nil
+	}
+	end := start + perPage
+	if end > len(deliveries) {
+		end = len(deliveries)
+	}
+
+	return deliveries[start:end], nil
+}
+
+func (p *webhookDeliveryPersister) Count(webhookID uuid.UUID) (int, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	count := 0
+	for _, delivery := range p.deliveries {
+		if delivery.WebhookID != nil && *delivery.WebhookID == webhookID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (p *webhookDeliveryPersister) DeleteBefore(t time.Time) (int, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	deliveries := models.WebhookDeliveries{}
+	deleted := 0
+	for _, delivery := range p.deliveries {
+		if delivery.CreatedAt.Before(t) {
+			deleted++
+			continue
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	p.deliveries = deliveries
+	return deleted, nil
+}
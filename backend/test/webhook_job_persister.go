@@ -0,0 +1,117 @@
+package test
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+func NewWebhookJobPersister(init models.WebhookJobs) persistence.WebhookJobPersister {
+	return &webhookJobPersister{jobs: append(models.WebhookJobs{}, init...)}
+}
+
+type webhookJobPersister struct {
+	mutex sync.Mutex
+	jobs  models.WebhookJobs
+}
+
+func (p *webhookJobPersister) Create(job models.WebhookJob) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.jobs = append(p.jobs, job)
+	return nil
+}
+
+func (p *webhookJobPersister) Update(job models.WebhookJob) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for i, existing := range p.jobs {
+		if existing.ID == job.ID {
+			p.jobs[i] = job
+		}
+	}
+	return nil
+}
+
+func (p *webhookJobPersister) Get(id uuid.UUID) (*models.WebhookJob, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for _, job := range p.jobs {
+		if job.ID == id {
+			j := job
+			return &j, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *webhookJobPersister) ListDue(now time.Time, limit int) (models.WebhookJobs, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	jobs := models.WebhookJobs{}
+	for _, job := range p.jobs {
+		if job.Status == models.WebhookJobStatusPending && !job.NextAttemptAt.After(now) && (job.LockedUntil == nil || job.LockedUntil.Before(now)) {
+			jobs = append(jobs, job)
+		}
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].NextAttemptAt.Before(jobs[j].NextAttemptAt)
+	})
+
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+
+	return jobs, nil
+}
+
+func (p *webhookJobPersister) Lock(job models.WebhookJob, now time.Time, lockedUntil time.Time) (*models.WebhookJob, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for i, existing := range p.jobs {
+		if existing.ID == job.ID &&
+			existing.Status == models.WebhookJobStatusPending &&
+			existing.Attempts == job.Attempts &&
+			!existing.NextAttemptAt.After(now) &&
+			(existing.LockedUntil == nil || existing.LockedUntil.Before(now)) {
+			p.jobs[i].LockedUntil = &lockedUntil
+			locked := p.jobs[i]
+			return &locked, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *webhookJobPersister) UpdateLocked(job models.WebhookJob, lockedUntil time.Time, attempts int) (bool, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for i, existing := range p.jobs {
+		if existing.ID == job.ID &&
+			existing.Attempts == attempts &&
+			existing.LockedUntil != nil && existing.LockedUntil.Equal(lockedUntil) {
+			p.jobs[i] = job
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (p *webhookJobPersister) DeleteFinishedBefore(t time.Time) (int, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	jobs := models.WebhookJobs{}
+	deleted := 0
+	for _, job := range p.jobs {
+		if job.Status != models.WebhookJobStatusPending && job.UpdatedAt.Before(t) {
+			deleted++
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	p.jobs = jobs
+	return deleted, nil
+}
@@ -69,10 +69,11 @@ func link(tx *pop.Connection, cfg *config.Config, p persistence.Persister, userD
 		return nil, ErrorServer("could not get user").WithCause(terr)
 	}
 
+	evt := events.UserIdentityLink
 	return &AccountLinkingResult{
 		Type:         models.AuditLogThirdPartyLinkingSucceeded,
 		User:         u,
-		WebhookEvent: nil,
+		WebhookEvent: &evt,
 		UserCreated:  false,
 	}, nil
 }
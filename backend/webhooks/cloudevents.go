@@ -0,0 +1,87 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/webhooks/events"
+	"time"
+)
+
+const (
+	// CloudEventsSpecVersion is the version of the CloudEvents specification events are sent in.
+	CloudEventsSpecVersion = "1.0"
+	// CloudEventsContentType is the content type of requests of hooks using the CloudEvents format.
+	CloudEventsContentType = "application/cloudevents+json"
+	// CloudEventsTypePrefix is prepended to the Hanko event to build the CloudEvents type, e.g. "io.hanko.user.create".
+	CloudEventsTypePrefix = "io.hanko."
+	// defaultCloudEventsSource is used as the source of events if no public API URL is configured.
+	defaultCloudEventsSource = "hanko"
+)
+
+// CloudEvent is a CloudEvents 1.0 event in structured content mode (https://github.com/cloudevents/spec).
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// NewCloudEvent creates the CloudEvent for the given message.
+func NewCloudEvent(messageID string, data JobData) CloudEvent {
+	source := data.Source
+	if source == "" {
+		source = defaultCloudEventsSource
+	}
+
+	eventData := data.Data
+	if len(eventData) == 0 {
+		eventData = json.RawMessage("null")
+	}
+
+	return CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              messageID,
+		Source:          source,
+		Type:            CloudEventsTypePrefix + string(data.Event),
+		Time:            data.Time.UTC(),
+		DataContentType: "application/json",
+		Data:            eventData,
+	}
+}
+
+// EventSource returns the CloudEvents source of the events of this Hanko instance: the public API URL if it is
+// configured, "hanko" otherwise.
+func EventSource(cfg *config.Config) string {
+	if cfg.Service.ApiURL != "" {
+		return cfg.Service.ApiURL
+	}
+
+	return defaultCloudEventsSource
+}
+
+// NewJobData creates the data of a request of a hook using the given format. The JWT containing the event data is
+// only generated for the "hanko-jwt" format.
+func NewJobData(manager Manager, format config.WebhookFormat, evt events.Event, data json.RawMessage, occurredAt time.Time, source string) (JobData, error) {
+	jobData := JobData{
+		Event:  evt,
+		Format: format,
+		Data:   data,
+		Time:   occurredAt,
+		Source: source,
+	}
+
+	if format == config.WebhookFormatCloudEvents {
+		return jobData, nil
+	}
+
+	token, err := manager.GenerateJWT(data, evt)
+	if err != nil {
+		return jobData, err
+	}
+	jobData.Token = token
+
+	return jobData, nil
+}
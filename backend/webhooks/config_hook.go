@@ -16,6 +16,7 @@ func NewConfigHook(cfgHook config.Webhook, logger echo.Logger) Webhook {
 			Logger:   logger,
 			Callback: cfgHook.Callback,
 			Events:   cfgHook.Events,
+			Secrets:  cfgHook.SigningSecrets(),
 		},
 	}
 }
@@ -19,16 +19,24 @@ type DatabaseHook struct {
 	rawHook   models.Webhook
 }
 
-func NewDatabaseHook(dbHook models.Webhook, persister persistence.WebhookPersister, logger echo.Logger) Webhook {
+// NewDatabaseHook returns the Webhook for the given database webhook. The signing secrets of the webhook are decrypted
+// with the given secrets.keys.
+func NewDatabaseHook(dbHook models.Webhook, persister persistence.WebhookPersister, keys []string, logger echo.Logger) (Webhook, error) {
+	secrets, err := dbHook.SigningSecrets(keys, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
 	return &DatabaseHook{
 		BaseWebhook{
 			Logger:   logger,
 			Callback: dbHook.Callback,
 			Events:   events.ConvertFromDbList(dbHook.WebhookEvents),
+			Secrets:  secrets,
 		},
 		persister,
 		dbHook,
-	}
+	}, nil
 }
 
 func (dh *DatabaseHook) DisableOnExpiryDate(now time.Time) error {
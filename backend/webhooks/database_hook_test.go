@@ -30,16 +30,18 @@ func (s *databaseHookSuite) TestNewDatabaseHook() {
 		ExpiresAt: time.Now().Add(WebhookExpireDuration),
 	}
 
-	dbHook := NewDatabaseHook(hook, s.Storage.GetWebhookPersister(nil), nil)
+	dbHook, err := NewDatabaseHook(hook, s.Storage.GetWebhookPersister(nil), test.DefaultConfig.Secrets.Keys, nil)
+	s.Require().NoError(err)
 	s.NotEmpty(dbHook)
 }
 
 func (s *databaseHookSuite) TestDatabaseHook_DisableOnExpiryDate() {
 	hook, whPersister := s.loadWebhook("8b00da9a-cacf-45ea-b25d-c1ce0f0d7da3")
-	dbHook := NewDatabaseHook(hook, whPersister, nil)
+	dbHook, err := NewDatabaseHook(hook, whPersister, test.DefaultConfig.Secrets.Keys, nil)
+	s.Require().NoError(err)
 
 	now := time.Now()
-	err := dbHook.DisableOnExpiryDate(now)
+	err = dbHook.DisableOnExpiryDate(now)
 	s.NoError(err)
 
 	updatedHook, err := whPersister.Get(hook.ID)
@@ -50,10 +52,11 @@ func (s *databaseHookSuite) TestDatabaseHook_DisableOnExpiryDate() {
 func (s *databaseHookSuite) TestDatabaseHook_DoNotDisableOnExpiryDate() {
 	hook, whPersister := s.loadWebhook("a47fe92a-1e4b-4119-8653-55ad82737c88")
 
-	dbHook := NewDatabaseHook(hook, whPersister, nil)
+	dbHook, err := NewDatabaseHook(hook, whPersister, test.DefaultConfig.Secrets.Keys, nil)
+	s.Require().NoError(err)
 
 	now := time.Now()
-	err := dbHook.DisableOnExpiryDate(now)
+	err = dbHook.DisableOnExpiryDate(now)
 	s.NoError(err)
 
 	updatedHook, err := whPersister.Get(hook.ID)
@@ -65,8 +68,9 @@ func (s *databaseHookSuite) TestDatabaseHook_DoNotDisableOnExpiryDate() {
 func (s *databaseHookSuite) TestDatabaseHook_DisableOnFailure() {
 	hook, whPersister := s.loadWebhook("8b00da9a-cacf-45ea-b25d-c1ce0f0d7da2")
 
-	dbHook := NewDatabaseHook(hook, whPersister, nil)
-	err := dbHook.DisableOnFailure()
+	dbHook, err := NewDatabaseHook(hook, whPersister, test.DefaultConfig.Secrets.Keys, nil)
+	s.Require().NoError(err)
+	err = dbHook.DisableOnFailure()
 	s.Require().NoError(err)
 
 	updatedHook, err := whPersister.Get(hook.ID)
@@ -78,8 +82,9 @@ func (s *databaseHookSuite) TestDatabaseHook_DisableOnFailure() {
 func (s *databaseHookSuite) TestDatabaseHook_DoNotDisableOnFailure() {
 	hook, whPersister := s.loadWebhook("8b00da9a-cacf-45ea-b25d-c1ce0f0d7da3")
 
-	dbHook := NewDatabaseHook(hook, whPersister, nil)
-	err := dbHook.DisableOnFailure()
+	dbHook, err := NewDatabaseHook(hook, whPersister, test.DefaultConfig.Secrets.Keys, nil)
+	s.Require().NoError(err)
+	err = dbHook.DisableOnFailure()
 	s.NoError(err)
 
 	updatedHook, err := whPersister.Get(hook.ID)
@@ -93,8 +98,9 @@ func (s *databaseHookSuite) TestDatabaseHook_Reset() {
 
 	now := time.Now()
 
-	dbHook := NewDatabaseHook(hook, whPersister, nil)
-	err := dbHook.Reset()
+	dbHook, err := NewDatabaseHook(hook, whPersister, test.DefaultConfig.Secrets.Keys, nil)
+	s.Require().NoError(err)
+	err = dbHook.Reset()
 	s.NoError(err)
 
 	updatedHook, err := whPersister.Get(hook.ID)
@@ -110,7 +116,8 @@ func (s *databaseHookSuite) TestDatabaseHook_Reset() {
 func (s *databaseHookSuite) TestDatabaseHook_IsEnabled() {
 	hook, whPersister := s.loadWebhook("a47fe92a-1e4b-4119-8653-55ad82737c88")
 
-	dbHook := NewDatabaseHook(hook, whPersister, nil)
+	dbHook, err := NewDatabaseHook(hook, whPersister, test.DefaultConfig.Secrets.Keys, nil)
+	s.Require().NoError(err)
 
 	s.True(dbHook.IsEnabled())
 }
@@ -118,7 +125,8 @@ func (s *databaseHookSuite) TestDatabaseHook_IsEnabled() {
 func (s *databaseHookSuite) TestDatabaseHook_IsDisabled() {
 	hook, whPersister := s.loadWebhook("279beae1-8a6d-4eaf-a791-1fa79d21d37a")
 
-	dbHook := NewDatabaseHook(hook, whPersister, nil)
+	dbHook, err := NewDatabaseHook(hook, whPersister, test.DefaultConfig.Secrets.Keys, nil)
+	s.Require().NoError(err)
 
 	s.False(dbHook.IsEnabled())
 }
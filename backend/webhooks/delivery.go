@@ -0,0 +1,112 @@
+package webhooks
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/webhooks/events"
+	"time"
+)
+
+// TestData is the data of the synthetic event sent by SendTest.
+type TestData struct {
+	WebhookID uuid.UUID `json:"webhook_id"`
+	Message   string    `json:"message"`
+}
+
+// PayloadHash returns the hex encoded SHA-256 hash of the given event data.
+func PayloadHash(data string) string {
+	hash := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(hash[:])
+}
+
+// NewDelivery creates the delivery log entry for an attempt to deliver the given job. The job does not need to be
+// persisted, e.g. for test deliveries.
+func NewDelivery(job models.WebhookJob, response *Response, deliveryErr error) models.WebhookDelivery {
+	id, _ := uuid.NewV4()
+	delivery := models.WebhookDelivery{
+		ID:          id,
+		WebhookID:   job.WebhookID,
+		MessageID:   job.MessageID,
+		Event:       job.Event,
+		PayloadHash: PayloadHash(job.Data),
+		Attempt:     job.Attempts + 1,
+		CreatedAt:   time.Now(),
+	}
+
+	if !job.ID.IsNil() {
+		jobID := job.ID
+		delivery.WebhookJobID = &jobID
+	}
+
+	if response != nil {
+		statusCode := response.StatusCode
+		delivery.StatusCode = &statusCode
+		delivery.LatencyMs = response.Latency.Milliseconds()
+		if response.Body != "" {
+			body := response.Body
+			delivery.ResponseBody = &body
+		}
+	}
+
+	if deliveryErr != nil {
+		errorMessage := deliveryErr.Error()
+		delivery.Error = &errorMessage
+	}
+
+	return delivery
+}
+
+// SendTest synchronously sends a synthetic "webhook.test" event to the given database webhook, regardless of the
+// events the webhook is subscribed to. The event is sent in the format of the webhook, with the given CloudEvents
+// source and signed with the secrets of the webhook, which are decrypted with the given secrets.keys. The delivery is
+// recorded in the delivery log and returned.
+func SendTest(manager Manager, persister persistence.Persister, dbHook models.Webhook, source string, keys []string, logger echo.Logger) (*models.WebhookDelivery, error) {
+	data, err := json.Marshal(TestData{
+		WebhookID: dbHook.ID,
+		Message:   "This is a test event sent by Hanko.",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	messageID, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	webhookID := dbHook.ID
+	job := models.WebhookJob{
+		MessageID: messageID,
+		WebhookID: &webhookID,
+		Callback:  dbHook.Callback,
+		Event:     string(events.WebhookTest),
+		Data:      string(data),
+	}
+
+	jobData, err := NewJobData(manager, config.WebhookFormat(dbHook.Format), events.WebhookTest, data, now, source)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate JWT for webhook data: %w", err)
+	}
+
+	hook, err := NewDatabaseHook(dbHook, persister.GetWebhookPersister(nil), keys, logger)
+	if err != nil {
+		return nil, err
+	}
+	response, deliveryErr := hook.Trigger(messageID.String(), jobData)
+
+	delivery := NewDelivery(job, response, deliveryErr)
+	err = persister.GetWebhookDeliveryPersister(nil).Create(delivery)
+	if err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
@@ -0,0 +1,63 @@
+package webhooks
+
+import (
+	"errors"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewDelivery(t *testing.T) {
+	webhookID := uuid.Must(uuid.NewV4())
+	job := models.WebhookJob{
+		ID:        uuid.Must(uuid.NewV4()),
+		MessageID: uuid.Must(uuid.NewV4()),
+		WebhookID: &webhookID,
+		Event:     "user.create",
+		Data:      `{"id":"lorem"}`,
+		Attempts:  2,
+	}
+
+	delivery := NewDelivery(job, &Response{
+		StatusCode: http.StatusBadGateway,
+		Body:       "bad gateway",
+		Latency:    1500 * time.Millisecond,
+	}, errors.New("request failed due to status code: 502"))
+
+	assert.False(t, delivery.ID.IsNil())
+	require.NotNil(t, delivery.WebhookJobID)
+	assert.Equal(t, job.ID, *delivery.WebhookJobID)
+	assert.Equal(t, &webhookID, delivery.WebhookID)
+	assert.Equal(t, job.MessageID, delivery.MessageID)
+	assert.Equal(t, PayloadHash(job.Data), delivery.PayloadHash)
+	assert.Equal(t, 3, delivery.Attempt)
+	assert.Equal(t, http.StatusBadGateway, *delivery.StatusCode)
+	assert.Equal(t, int64(1500), delivery.LatencyMs)
+	assert.Equal(t, "bad gateway", *delivery.ResponseBody)
+	assert.Equal(t, "request failed due to status code: 502", *delivery.Error)
+	assert.False(t, delivery.Succeeded())
+}
+
+func TestNewDelivery_WithoutResponse(t *testing.T) {
+	job := models.WebhookJob{
+		MessageID: uuid.Must(uuid.NewV4()),
+		Event:     "webhook.test",
+		Data:      "{}",
+	}
+
+	delivery := NewDelivery(job, nil, errors.New("connection refused"))
+
+	assert.Nil(t, delivery.WebhookJobID)
+	assert.Nil(t, delivery.StatusCode)
+	assert.Nil(t, delivery.ResponseBody)
+	assert.Equal(t, 1, delivery.Attempt)
+	assert.Equal(t, "connection refused", *delivery.Error)
+}
+
+func TestPayloadHash(t *testing.T) {
+	assert.Equal(t, "44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a", PayloadHash("{}"))
+}
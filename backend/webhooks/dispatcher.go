@@ -0,0 +1,272 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/teamhanko/hanko/backend/config"
+	hankoJwk "github.com/teamhanko/hanko/backend/crypto/jwk"
+	"github.com/teamhanko/hanko/backend/outbox"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/webhooks/events"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// dispatchInterval is the interval in which the dispatcher polls for due jobs.
+	dispatchInterval = 1 * time.Second
+	// dispatchBatchSize is the maximum number of jobs listed per poll.
+	dispatchBatchSize = 100
+	// jobLockDuration is the time a job is locked for a single dispatcher. Jobs are locked right before they are
+	// delivered, so it must exceed the time needed to deliver a single job (see requestTimeout), otherwise jobs might
+	// be delivered twice.
+	jobLockDuration = 2 * time.Minute
+	// pruneInterval is the interval in which finished jobs are pruned.
+	pruneInterval = 1 * time.Hour
+	// jobRetention is the time finished jobs and delivery log entries are kept before they are pruned.
+	jobRetention = 7 * 24 * time.Hour
+)
+
+// retryBackoff is the delay before the next delivery attempt of a failed job, starting at 10 seconds and capped at
+// one hour.
+var retryBackoff = outbox.Backoff{BaseDelay: 10 * time.Second, MaxDelay: 1 * time.Hour}
+
+// Dispatcher delivers the webhook jobs persisted by the Manager. Each job is locked right before its delivery and its
+// result is only recorded while the lock is held, so multiple dispatchers (e.g. of multiple Hanko instances) can run
+// concurrently without delivering a job twice. Failed
+// deliveries are retried with an exponential backoff until the configured maximum number of attempts is reached.
+type Dispatcher struct {
+	cfg        *config.Config
+	persister  persistence.Persister
+	jwkManager hankoJwk.Manager
+	logger     echo.Logger
+}
+
+func NewDispatcher(cfg *config.Config, persister persistence.Persister, jwkManager hankoJwk.Manager, logger echo.Logger) *Dispatcher {
+	return &Dispatcher{
+		cfg:        cfg,
+		persister:  persister,
+		jwkManager: jwkManager,
+		logger:     logger,
+	}
+}
+
+// Run dispatches due jobs until the stop channel is closed.
+func (d *Dispatcher) Run(stop <-chan struct{}) {
+	outbox.Run(d, dispatchInterval, pruneInterval, d.logger, stop)
+}
+
+// Dispatch delivers all due jobs. It returns once all listed jobs have been processed.
+func (d *Dispatcher) Dispatch() error {
+	dueJobs, err := d.persister.GetWebhookJobPersister(nil).ListDue(time.Now(), dispatchBatchSize)
+	if err != nil {
+		return err
+	}
+
+	if len(dueJobs) == 0 {
+		return nil
+	}
+
+	manager, err := NewManager(d.cfg, d.persister, d.jwkManager, d.logger)
+	if err != nil {
+		return err
+	}
+
+	jobChannel := make(chan models.WebhookJob, len(dueJobs))
+	for _, dueJob := range dueJobs {
+		jobChannel <- dueJob
+	}
+	close(jobChannel)
+
+	var wg sync.WaitGroup
+	for i := 0; i < d.workers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			worker := NewWorker(nil, d.logger)
+			for dueJob := range jobChannel {
+				d.deliver(manager, worker, dueJob)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// deliver locks the given job and delivers it. Jobs which have been claimed by another dispatcher in the meantime are
+// skipped.
+func (d *Dispatcher) deliver(manager Manager, worker Worker, dueJob models.WebhookJob) {
+	now := time.Now()
+	lockedJob, err := d.persister.GetWebhookJobPersister(nil).Lock(dueJob, now, now.Add(jobLockDuration))
+	if err != nil {
+		d.logger.Error(fmt.Errorf("unable to lock webhook job: %w", err))
+		return
+	}
+	if lockedJob == nil {
+		// claimed by another dispatcher
+		return
+	}
+
+	job, err := d.prepare(manager, *lockedJob)
+	if err != nil {
+		d.finish(*lockedJob, err)
+		return
+	}
+	if job == nil {
+		d.discard(*lockedJob)
+		return
+	}
+
+	worker.Process(*job)
+}
+
+// prepare creates the Job delivering the given webhook job. It returns nil if the webhook the job belongs to does
+// not exist anymore or has been disabled.
+func (d *Dispatcher) prepare(manager Manager, webhookJob models.WebhookJob) (*Job, error) {
+	var hook Webhook
+	var format config.WebhookFormat
+	if webhookJob.WebhookID != nil {
+		dbHook, err := d.persister.GetWebhookPersister(nil).Get(*webhookJob.WebhookID)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get database webhook: %w", err)
+		}
+		if dbHook == nil || !dbHook.Enabled {
+			return nil, nil
+		}
+		hook, err = NewDatabaseHook(*dbHook, d.persister.GetWebhookPersister(nil), d.cfg.Secrets.Keys, d.logger)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create database webhook: %w", err)
+		}
+		format = config.WebhookFormat(dbHook.Format)
+	} else {
+		if !d.cfg.Webhooks.Enabled {
+			return nil, nil
+		}
+		for _, cfgHook := range d.cfg.Webhooks.Hooks {
+			configHook := NewConfigHook(cfgHook, d.logger)
+			if cfgHook.Callback == webhookJob.Callback && configHook.HasEvent(events.Event(webhookJob.Event)) {
+				hook = configHook
+				format = cfgHook.Format
+				break
+			}
+		}
+		if hook == nil {
+			return nil, nil
+		}
+	}
+
+	evt := events.Event(webhookJob.Event)
+	jobData, err := NewJobData(manager, format, evt, json.RawMessage(webhookJob.Data), webhookJob.CreatedAt, EventSource(d.cfg))
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate JWT for webhook data: %w", err)
+	}
+
+	attempt := webhookJob.Attempts + 1
+	return &Job{
+		ID:              webhookJob.MessageID.String(),
+		Data:            jobData,
+		Hook:            hook,
+		CanExpireAtTime: d.cfg.Webhooks.AllowTimeExpiration,
+		// the failure counter of the hook is only increased once the job is finally failed
+		RetryOnFailure: attempt < outbox.MaxAttempts(d.cfg.Webhooks.MaxAttempts),
+		Complete: func(response *Response, err error) {
+			if response == nil && err == nil {
+				// the hook has expired instead of being triggered
+				d.discard(webhookJob)
+				return
+			}
+
+			d.record(webhookJob, response, err)
+			if response != nil && response.StatusCode < http.StatusBadRequest {
+				// the message has been delivered, even if updating the hook afterwards failed
+				err = nil
+			}
+			d.finish(webhookJob, err)
+		},
+	}, nil
+}
+
+// finish records the result of a delivery attempt of the given locked job. Failed jobs are rescheduled until the
+// maximum number of attempts is reached.
+func (d *Dispatcher) finish(lockedJob models.WebhookJob, deliveryErr error) {
+	job := lockedJob
+	now := time.Now()
+	job.Attempts++
+	job.LockedUntil = nil
+	job.UpdatedAt = now
+
+	if deliveryErr == nil {
+		job.Status = models.WebhookJobStatusDelivered
+		job.LastError = nil
+	} else {
+		lastError := deliveryErr.Error()
+		job.LastError = &lastError
+		if job.Attempts >= outbox.MaxAttempts(d.cfg.Webhooks.MaxAttempts) {
+			job.Status = models.WebhookJobStatusFailed
+			d.logger.Warnf("webhook job %s failed permanently after %d attempts", job.ID, job.Attempts)
+		} else {
+			job.NextAttemptAt = now.Add(retryBackoff.Delay(job.Attempts))
+		}
+	}
+
+	d.update(lockedJob, job)
+}
+
+// record adds the delivery attempt to the delivery log.
+func (d *Dispatcher) record(job models.WebhookJob, response *Response, deliveryErr error) {
+	err := d.persister.GetWebhookDeliveryPersister(nil).Create(NewDelivery(job, response, deliveryErr))
+	if err != nil {
+		d.logger.Error(fmt.Errorf("unable to record webhook delivery: %w", err))
+	}
+}
+
+func (d *Dispatcher) discard(lockedJob models.WebhookJob) {
+	job := lockedJob
+	job.Status = models.WebhookJobStatusDiscarded
+	job.LockedUntil = nil
+	job.UpdatedAt = time.Now()
+
+	d.update(lockedJob, job)
+}
+
+// update stores the given job, unless the lock of the locked job has been lost in the meantime.
+func (d *Dispatcher) update(lockedJob models.WebhookJob, job models.WebhookJob) {
+	if lockedJob.LockedUntil == nil {
+		d.logger.Error(fmt.Errorf("unable to update webhook job %s: the job is not locked", job.ID))
+		return
+	}
+
+	updated, err := d.persister.GetWebhookJobPersister(nil).UpdateLocked(job, *lockedJob.LockedUntil, lockedJob.Attempts)
+	if err != nil {
+		d.logger.Error(fmt.Errorf("unable to update webhook job: %w", err))
+		return
+	}
+
+	if !updated {
+		d.logger.Warnf("webhook job %s has been claimed by another dispatcher, the result of the attempt is not recorded", job.ID)
+	}
+}
+
+// Prune deletes the finished jobs and delivery log entries which are older than the retention.
+func (d *Dispatcher) Prune(now time.Time) {
+	_, err := d.persister.GetWebhookDeliveryPersister(nil).DeleteBefore(now.Add(-jobRetention))
+	if err != nil {
+		d.logger.Error(fmt.Errorf("unable to prune webhook deliveries: %w", err))
+	}
+
+	_, err = d.persister.GetWebhookJobPersister(nil).DeleteFinishedBefore(now.Add(-jobRetention))
+	if err != nil {
+		d.logger.Error(fmt.Errorf("unable to prune webhook jobs: %w", err))
+	}
+}
+
+func (d *Dispatcher) workers() int {
+	if d.cfg.Webhooks.Workers < 1 {
+		return 1
+	}
+	return d.cfg.Webhooks.Workers
+}
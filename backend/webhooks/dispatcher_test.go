@@ -0,0 +1,231 @@
+package webhooks
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/labstack/gommon/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/test"
+	"github.com/teamhanko/hanko/backend/webhooks/events"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newDispatcherTestConfig(callback string, maxAttempts int) *config.Config {
+	return &config.Config{
+		Webhooks: config.WebhookSettings{
+			Enabled:     true,
+			MaxAttempts: maxAttempts,
+			Workers:     2,
+			Hooks: config.Webhooks{config.Webhook{
+				Callback: callback,
+				Events:   events.Events{events.UserCreate},
+			}},
+		},
+	}
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		assert.NotEmpty(t, r.Header.Get(HeaderWebhookID))
+	}))
+	defer server.Close()
+
+	cfg := newDispatcherTestConfig(server.URL, 3)
+	persister := test.NewEmptyPersister()
+	jwkManager := test.JwkManager{}
+
+	manager, err := NewManager(cfg, persister, jwkManager, log.New("test"))
+	require.NoError(t, err)
+
+	manager.Trigger(nil, events.UserCreate, map[string]string{"user_id": "lorem-ipsum"})
+	manager.Trigger(nil, events.UserDelete, map[string]string{"user_id": "lorem-ipsum"})
+
+	job := test.OnlyDue(t, persister.GetWebhookJobPersister(nil).ListDue)
+	assert.Equal(t, models.WebhookJobStatusPending, job.Status)
+	assert.Equal(t, string(events.UserCreate), job.Event)
+	assert.Nil(t, job.WebhookID)
+
+	dispatcher := NewDispatcher(cfg, persister, jwkManager, log.New("test"))
+	require.NoError(t, dispatcher.Dispatch())
+
+	delivered, err := persister.GetWebhookJobPersister(nil).Get(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WebhookJobStatusDelivered, delivered.Status)
+	assert.Equal(t, 1, delivered.Attempts)
+	assert.Nil(t, delivered.LockedUntil)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// delivered jobs are not dispatched again
+	require.NoError(t, dispatcher.Dispatch())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestDispatcher_Dispatch_Retry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	cfg := newDispatcherTestConfig(server.URL, 2)
+	persister := test.NewEmptyPersister()
+	jwkManager := test.JwkManager{}
+
+	manager, err := NewManager(cfg, persister, jwkManager, log.New("test"))
+	require.NoError(t, err)
+	manager.Trigger(nil, events.UserCreate, "lorem-ipsum")
+	job := test.OnlyDue(t, persister.GetWebhookJobPersister(nil).ListDue)
+
+	dispatcher := NewDispatcher(cfg, persister, jwkManager, log.New("test"))
+	require.NoError(t, dispatcher.Dispatch())
+
+	retried, err := persister.GetWebhookJobPersister(nil).Get(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WebhookJobStatusPending, retried.Status)
+	assert.Equal(t, 1, retried.Attempts)
+	assert.NotNil(t, retried.LastError)
+	assert.True(t, retried.NextAttemptAt.After(time.Now()))
+
+	// make the retry due
+	retried.NextAttemptAt = time.Now()
+	require.NoError(t, persister.GetWebhookJobPersister(nil).Update(*retried))
+	require.NoError(t, dispatcher.Dispatch())
+
+	failed, err := persister.GetWebhookJobPersister(nil).Get(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WebhookJobStatusFailed, failed.Status)
+	assert.Equal(t, 2, failed.Attempts)
+}
+
+func TestDispatcher_Dispatch_DiscardRemovedHook(t *testing.T) {
+	persister := test.NewEmptyPersister()
+	now := time.Now()
+	jobID, _ := uuid.NewV4()
+	messageID, _ := uuid.NewV4()
+	webhookID, _ := uuid.NewV4()
+	err := persister.GetWebhookJobPersister(nil).Create(models.WebhookJob{
+		ID:            jobID,
+		MessageID:     messageID,
+		WebhookID:     &webhookID,
+		Callback:      "http://localhost/removed",
+		Event:         string(events.UserCreate),
+		Data:          "{}",
+		Status:        models.WebhookJobStatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	})
+	require.NoError(t, err)
+
+	cfg := newDispatcherTestConfig("http://localhost/config", 3)
+	dispatcher := NewDispatcher(cfg, persister, test.JwkManager{}, log.New("test"))
+	require.NoError(t, dispatcher.Dispatch())
+
+	job, err := persister.GetWebhookJobPersister(nil).Get(jobID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WebhookJobStatusDiscarded, job.Status)
+	assert.Equal(t, 0, job.Attempts)
+}
+
+func TestDispatcher_Lock_StaleJob(t *testing.T) {
+	cfg := newDispatcherTestConfig("http://localhost/config", 3)
+	persister := test.NewEmptyPersister()
+
+	manager, err := NewManager(cfg, persister, test.JwkManager{}, log.New("test"))
+	require.NoError(t, err)
+	manager.Trigger(nil, events.UserCreate, "lorem-ipsum")
+
+	jobPersister := persister.GetWebhookJobPersister(nil)
+	now := time.Now()
+	dueJobs, err := jobPersister.ListDue(now, dispatchBatchSize)
+	require.NoError(t, err)
+	require.Len(t, dueJobs, 1)
+	stale := dueJobs[0]
+
+	// another dispatcher attempts the job and its lock expires before the listed job is locked
+	attempted := stale
+	attempted.Attempts++
+	require.NoError(t, jobPersister.Update(attempted))
+
+	locked, err := jobPersister.Lock(stale, now, now.Add(jobLockDuration))
+	require.NoError(t, err)
+	assert.Nil(t, locked)
+
+	locked, err = jobPersister.Lock(attempted, now, now.Add(jobLockDuration))
+	require.NoError(t, err)
+	require.NotNil(t, locked)
+	assert.Equal(t, 1, locked.Attempts)
+	assert.NotNil(t, locked.LockedUntil)
+}
+
+func TestDispatcher_Dispatch_LockBeforeDelivery(t *testing.T) {
+	var persister persistence.Persister
+	var dueDuringDelivery []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dueJobs, err := persister.GetWebhookJobPersister(nil).ListDue(time.Now(), dispatchBatchSize)
+		require.NoError(t, err)
+		dueDuringDelivery = append(dueDuringDelivery, len(dueJobs))
+	}))
+	defer server.Close()
+
+	cfg := newDispatcherTestConfig(server.URL, 3)
+	cfg.Webhooks.Workers = 1
+	persister = test.NewEmptyPersister()
+
+	manager, err := NewManager(cfg, persister, test.JwkManager{}, log.New("test"))
+	require.NoError(t, err)
+	manager.Trigger(nil, events.UserCreate, "lorem-ipsum")
+	manager.Trigger(nil, events.UserCreate, "dolor-sit")
+
+	dispatcher := NewDispatcher(cfg, persister, test.JwkManager{}, log.New("test"))
+	require.NoError(t, dispatcher.Dispatch())
+
+	// the second job is only locked once the first one has been delivered
+	assert.Equal(t, []int{1, 0}, dueDuringDelivery)
+}
+
+func TestDispatcher_Finish_LostLock(t *testing.T) {
+	cfg := newDispatcherTestConfig("http://localhost/config", 3)
+	persister := test.NewEmptyPersister()
+
+	manager, err := NewManager(cfg, persister, test.JwkManager{}, log.New("test"))
+	require.NoError(t, err)
+	manager.Trigger(nil, events.UserCreate, "lorem-ipsum")
+
+	jobPersister := persister.GetWebhookJobPersister(nil)
+	job := test.OnlyDue(t, persister.GetWebhookJobPersister(nil).ListDue)
+
+	now := time.Now()
+	staleLock, err := jobPersister.Lock(job, now, now.Add(jobLockDuration))
+	require.NoError(t, err)
+	require.NotNil(t, staleLock)
+
+	// the lock expires while the job is delivered and another dispatcher locks the job
+	later := now.Add(jobLockDuration + time.Second)
+	currentLock, err := jobPersister.Lock(job, later, later.Add(jobLockDuration))
+	require.NoError(t, err)
+	require.NotNil(t, currentLock)
+
+	dispatcher := NewDispatcher(cfg, persister, test.JwkManager{}, log.New("test"))
+	dispatcher.finish(*staleLock, nil)
+
+	stored, err := jobPersister.Get(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WebhookJobStatusPending, stored.Status, "the stale dispatcher must not overwrite the job")
+	assert.Equal(t, 0, stored.Attempts)
+
+	dispatcher.finish(*currentLock, nil)
+
+	stored, err = jobPersister.Get(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WebhookJobStatusDelivered, stored.Status)
+	assert.Equal(t, 1, stored.Attempts)
+}
@@ -1,22 +1,63 @@
 package events
 
-import "github.com/teamhanko/hanko/backend/persistence/models"
+import (
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"strings"
+)
 
 type Event string
 
 const (
-	User             Event = "user"
-	UserCreate       Event = "user.create"
-	UserUpdate       Event = "user.update"
-	UserDelete       Event = "user.delete"
-	UserEmail        Event = "user.update.email"
-	UserEmailCreate  Event = "user.update.email.create"
-	UserEmailPrimary Event = "user.update.email.primary"
-	UserEmailDelete  Event = "user.update.email.delete"
-
-	EmailSend Event = "email.send"
+	User               Event = "user"
+	UserCreate         Event = "user.create"
+	UserUpdate         Event = "user.update"
+	UserDelete         Event = "user.delete"
+	UserEmail          Event = "user.update.email"
+	UserEmailCreate    Event = "user.update.email.create"
+	UserEmailPrimary   Event = "user.update.email.primary"
+	UserEmailDelete    Event = "user.update.email.delete"
+	UserPassword       Event = "user.update.password"
+	UserPasskey        Event = "user.update.passkey"
+	UserPasskeyCreate  Event = "user.update.passkey.create"
+	UserPasskeyDelete  Event = "user.update.passkey.delete"
+	UserUsername       Event = "user.update.username"
+	UserIdentity       Event = "user.update.identity"
+	UserIdentityLink   Event = "user.update.identity.link"
+	UserIdentityUnlink Event = "user.update.identity.unlink"
+	UserLogin          Event = "user.login"
+	UserLoginFailed    Event = "user.login.failed"
+
+	Session       Event = "session"
+	SessionCreate Event = "session.create"
+	SessionRevoke Event = "session.revoke"
+
+	EmailSend   Event = "email.send"
+	EmailFailed Event = "email.failed"
+
+	// WebhookTest is the synthetic event sent to test a webhook. Webhooks cannot subscribe to it.
+	WebhookTest Event = "webhook.test"
 )
 
+// detachedEvents are events which do not describe a change of their parent event, e.g. a login is not a change of the
+// user. Subscribing to a parent event does not include them, only subscribing to the event itself does.
+var detachedEvents = []Event{UserLogin}
+
+// IsSubscribedBy reports whether a webhook subscribed to the given event receives the event. Events are matched by
+// prefix, hence subscribing to an event includes its subevents, except for detached events (see detachedEvents).
+func (evt Event) IsSubscribedBy(subscribed Event) bool {
+	if !strings.HasPrefix(string(evt), string(subscribed)) {
+		return false
+	}
+
+	for _, detached := range detachedEvents {
+		if strings.HasPrefix(string(evt), string(detached)) && len(subscribed) < len(detached) {
+			return false
+		}
+	}
+
+	return true
+}
+
 func StringIsValidEvent(value string) bool {
 	evt := Event(value)
 	return IsValidEvent(evt)
@@ -25,7 +66,9 @@ func StringIsValidEvent(value string) bool {
 func IsValidEvent(evt Event) bool {
 	var isValid bool
 	switch evt {
-	case User, UserCreate, UserUpdate, UserDelete, UserEmail, UserEmailCreate, UserEmailPrimary, UserEmailDelete, EmailSend:
+	case User, UserCreate, UserUpdate, UserDelete, UserEmail, UserEmailCreate, UserEmailPrimary, UserEmailDelete,
+		UserPassword, UserPasskey, UserPasskeyCreate, UserPasskeyDelete, UserUsername, UserIdentity, UserIdentityLink,
+		UserIdentityUnlink, UserLogin, UserLoginFailed, Session, SessionCreate, SessionRevoke, EmailSend, EmailFailed:
 		isValid = true
 	default:
 		isValid = false
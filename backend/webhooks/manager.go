@@ -1,14 +1,17 @@
 package webhooks
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/teamhanko/hanko/backend/config"
 	hankoJwk "github.com/teamhanko/hanko/backend/crypto/jwk"
 	hankoJwt "github.com/teamhanko/hanko/backend/crypto/jwt"
 	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
 	"github.com/teamhanko/hanko/backend/webhooks/events"
 	"time"
 )
@@ -19,21 +22,19 @@ type Manager interface {
 }
 
 type manager struct {
-	logger          echo.Logger
-	webhooks        Webhooks
-	jwtGenerator    hankoJwt.Generator
-	audience        []string
-	persister       persistence.Persister
-	canExpireAtTime bool
+	logger       echo.Logger
+	configHooks  config.Webhooks
+	jwtGenerator hankoJwt.Generator
+	audience     []string
+	persister    persistence.Persister
+	keys         []string
 }
 
 func NewManager(cfg *config.Config, persister persistence.Persister, jwkManager hankoJwk.Manager, logger echo.Logger) (Manager, error) {
-	hooks := make(Webhooks, 0)
+	configHooks := make(config.Webhooks, 0)
 
 	if cfg.Webhooks.Enabled {
-		for _, cfgHook := range cfg.Webhooks.Hooks {
-			hooks = append(hooks, NewConfigHook(cfgHook, logger))
-		}
+		configHooks = append(configHooks, cfg.Webhooks.Hooks...)
 	}
 
 	const generateFailureMessage = "failed to create webhook jwt generator: %w"
@@ -65,15 +66,17 @@ func NewManager(cfg *config.Config, persister persistence.Persister, jwkManager
 	}
 
 	return &manager{
-		logger:          logger,
-		webhooks:        hooks,
-		jwtGenerator:    g,
-		audience:        audience,
-		persister:       persister,
-		canExpireAtTime: cfg.Webhooks.AllowTimeExpiration,
+		logger:       logger,
+		configHooks:  configHooks,
+		jwtGenerator: g,
+		audience:     audience,
+		persister:    persister,
+		keys:         cfg.Secrets.Keys,
 	}, nil
 }
 
+// Trigger persists a job for every enabled hook listening to the given event. The jobs are created with the given
+// connection, so they are only delivered (see Dispatcher) if the transaction triggering the event is committed.
 func (m *manager) Trigger(tx *pop.Connection, evt events.Event, data interface{}) {
 	// add db hooks - Done here to prevent a restart in case a hook is added or removed from the database
 	dbHooks, err := m.persister.GetWebhookPersister(tx).List(false)
@@ -82,37 +85,60 @@ func (m *manager) Trigger(tx *pop.Connection, evt events.Event, data interface{}
 		return
 	}
 
-	hooks := m.webhooks
-	for _, dbHook := range dbHooks {
-		hooks = append(hooks, NewDatabaseHook(dbHook, m.persister.GetWebhookPersister(nil), m.logger))
+	marshalledData, err := json.Marshal(data)
+	if err != nil {
+		m.logger.Error(fmt.Errorf("unable to marshal webhook data: %w", err))
+		return
 	}
 
-	dataToken, err := m.GenerateJWT(data, evt)
+	messageID, err := uuid.NewV4()
 	if err != nil {
-		m.logger.Error(fmt.Errorf("unable to generate JWT for webhook data: %w", err))
+		m.logger.Error(fmt.Errorf("unable to generate webhook message id: %w", err))
 		return
 	}
 
-	jobData := JobData{
-		Token: dataToken,
-		Event: evt,
+	now := time.Now()
+	newJob := func(callback string, webhookID *uuid.UUID) models.WebhookJob {
+		jobID, _ := uuid.NewV4()
+		return models.WebhookJob{
+			ID:            jobID,
+			MessageID:     messageID,
+			WebhookID:     webhookID,
+			Callback:      callback,
+			Event:         string(evt),
+			Data:          string(marshalledData),
+			Status:        models.WebhookJobStatusPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
 	}
 
-	hookChannel := make(chan Job, len(hooks))
-	for _, hook := range hooks {
-		if hook.HasEvent(evt) {
-			job := Job{
-				Data:            jobData,
-				Hook:            hook,
-				CanExpireAtTime: m.canExpireAtTime,
-			}
-			hookChannel <- job
+	var jobs models.WebhookJobs
+	for _, cfgHook := range m.configHooks {
+		if NewConfigHook(cfgHook, m.logger).HasEvent(evt) {
+			jobs = append(jobs, newJob(cfgHook.Callback, nil))
+		}
+	}
+	for _, dbHook := range dbHooks {
+		hook, err := NewDatabaseHook(dbHook, m.persister.GetWebhookPersister(tx), m.keys, m.logger)
+		if err != nil {
+			m.logger.Error(fmt.Errorf("unable to create database webhook: %w", err))
+			continue
+		}
+		if hook.IsEnabled() && hook.HasEvent(evt) {
+			webhookID := dbHook.ID
+			jobs = append(jobs, newJob(dbHook.Callback, &webhookID))
 		}
 	}
-	close(hookChannel)
 
-	worker := NewWorker(hookChannel, m.logger)
-	go worker.Run()
+	jobPersister := m.persister.GetWebhookJobPersister(tx)
+	for _, job := range jobs {
+		err = jobPersister.Create(job)
+		if err != nil {
+			m.logger.Error(fmt.Errorf("unable to create webhook job: %w", err))
+		}
+	}
 }
 
 func (m *manager) GenerateJWT(data interface{}, event events.Event) (string, error) {
@@ -60,8 +60,8 @@ func (s *managerSuite) TestManager_TriggerWithoutHook() {
 
 	manager.Trigger(s.Storage.GetConnection(), events.UserCreate, "lorem-ipsum")
 
-	// give it 1 sec to trigger
-	time.Sleep(1 * time.Second)
+	err = NewDispatcher(&cfg, s.Storage, jwkManager, nil).Dispatch()
+	s.Require().NoError(err)
 
 	s.False(triggered)
 }
@@ -92,8 +92,8 @@ func (s *managerSuite) TestManager_TriggerWithConfigHook() {
 
 	manager.Trigger(s.Storage.GetConnection(), events.UserCreate, "lorem-ipsum")
 
-	// give it 1 sec to trigger
-	time.Sleep(1 * time.Second)
+	err = NewDispatcher(&cfg, s.Storage, jwkManager, nil).Dispatch()
+	s.Require().NoError(err)
 
 	s.True(triggered)
 }
@@ -125,8 +125,8 @@ func (s *managerSuite) TestManager_TriggerWithDisabledConfigHook() {
 
 	manager.Trigger(s.Storage.GetConnection(), events.UserCreate, "lorem-ipsum")
 
-	// give it 1 sec to trigger
-	time.Sleep(1 * time.Second)
+	err = NewDispatcher(&cfg, s.Storage, jwkManager, nil).Dispatch()
+	s.Require().NoError(err)
 
 	s.False(triggered)
 }
@@ -150,8 +150,8 @@ func (s *managerSuite) TestManager_TriggerWithDbHook() {
 
 	manager.Trigger(s.Storage.GetConnection(), events.UserCreate, "lorem-ipsum")
 
-	// give it 1 sec to trigger
-	time.Sleep(1 * time.Second)
+	err = NewDispatcher(&cfg, s.Storage, jwkManager, nil).Dispatch()
+	s.Require().NoError(err)
 
 	s.True(triggered)
 }
@@ -174,8 +174,8 @@ func (s *managerSuite) TestManager_TriggerWithDisabledDbHook() {
 
 	manager.Trigger(s.Storage.GetConnection(), events.UserCreate, "lorem-ipsum")
 
-	// give it 1 sec to trigger
-	time.Sleep(1 * time.Second)
+	err = NewDispatcher(&cfg, s.Storage, jwkManager, nil).Dispatch()
+	s.Require().NoError(err)
 
 	s.False(triggered)
 }
@@ -0,0 +1,73 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers and secret format of the Standard Webhooks specification (https://www.standardwebhooks.com)
+const (
+	HeaderWebhookID        = "Webhook-Id"
+	HeaderWebhookTimestamp = "Webhook-Timestamp"
+	HeaderWebhookSignature = "Webhook-Signature"
+
+	SecretPrefix = "whsec_"
+
+	signatureVersion = "v1"
+	secretLength     = 32
+)
+
+// SecretRotationGracePeriod is the time the previous secret of a webhook is still used for signing after the secret
+// has been rotated, so receivers can switch to the new secret without missing any webhooks.
+const SecretRotationGracePeriod = 24 * time.Hour
+
+// GenerateSecret generates a new random webhook signing secret in the "whsec_<base64>" format.
+func GenerateSecret() (string, error) {
+	secret := make([]byte, secretLength)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	return SecretPrefix + base64.StdEncoding.EncodeToString(secret), nil
+}
+
+// Sign computes the signature of the given payload for the given secrets and returns the value of the
+// "Webhook-Signature" header, i.e. a space delimited list of "v1,<base64 signature>" entries, one per secret.
+func Sign(secrets []string, messageID string, timestamp time.Time, payload []byte) (string, error) {
+	signedContent := fmt.Sprintf("%s.%s.%s", messageID, strconv.FormatInt(timestamp.Unix(), 10), payload)
+
+	signatures := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		key, err := decodeSecret(secret)
+		if err != nil {
+			return "", err
+		}
+
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(signedContent))
+		signatures = append(signatures, signatureVersion+","+base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	}
+
+	return strings.Join(signatures, " "), nil
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(secret, SecretPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode webhook secret: %w", err)
+	}
+
+	if len(key) == 0 {
+		return nil, errors.New("webhook secret must not be empty")
+	}
+
+	return key, nil
+}
@@ -0,0 +1,51 @@
+package webhooks
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(secret, SecretPrefix))
+
+	key, err := decodeSecret(secret)
+	require.NoError(t, err)
+	assert.Len(t, key, secretLength)
+}
+
+func TestSign(t *testing.T) {
+	// test vector of the Standard Webhooks specification
+	secret := "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw"
+	timestamp := time.Unix(1614265330, 0)
+	payload := []byte(`{"test": 2432232314}`)
+
+	signature, err := Sign([]string{secret}, "msg_p5jXN8AQM9LWM0D4loKWxJek", timestamp, payload)
+	require.NoError(t, err)
+	assert.Equal(t, "v1,g0hM9SsE+OTPJTGt/tmIKtSyZlE3uFJELVlNIOLJ1OE=", signature)
+}
+
+func TestSign_MultipleSecrets(t *testing.T) {
+	secret1, err := GenerateSecret()
+	require.NoError(t, err)
+	secret2, err := GenerateSecret()
+	require.NoError(t, err)
+
+	signature, err := Sign([]string{secret1, secret2}, "msg_id", time.Now(), []byte("{}"))
+	require.NoError(t, err)
+
+	signatures := strings.Split(signature, " ")
+	require.Len(t, signatures, 2)
+	assert.True(t, strings.HasPrefix(signatures[0], "v1,"))
+	assert.True(t, strings.HasPrefix(signatures[1], "v1,"))
+	assert.NotEqual(t, signatures[0], signatures[1])
+}
+
+func TestSign_InvalidSecret(t *testing.T) {
+	_, err := Sign([]string{"whsec_not base64"}, "msg_id", time.Now(), []byte("{}"))
+	assert.Error(t, err)
+}
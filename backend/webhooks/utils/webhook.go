@@ -5,8 +5,11 @@ import (
 	"github.com/gobuffalo/pop/v6"
 	"github.com/gofrs/uuid"
 	"github.com/labstack/echo/v4"
+	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/teamhanko/hanko/backend/dto/admin"
+	"github.com/teamhanko/hanko/backend/dto/webhook"
 	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
 	"github.com/teamhanko/hanko/backend/webhooks"
 	"github.com/teamhanko/hanko/backend/webhooks/events"
 )
@@ -30,8 +33,89 @@ func NotifyUserChange(ctx echo.Context, tx *pop.Connection, persister persistenc
 		return
 	}
 
+	if updatedUser == nil {
+		ctx.Logger().Warn(fmt.Errorf("failed to fetch updated user: user %s not found", userId))
+		return
+	}
+
 	err = TriggerWebhooks(ctx, tx, event, admin.FromUserModel(*updatedUser))
 	if err != nil {
 		ctx.Logger().Warn(err)
 	}
 }
+
+// NotifyLogin triggers the "user.login" webhook for the given user.
+func NotifyLogin(ctx echo.Context, tx *pop.Connection, persister persistence.Persister, userId uuid.UUID, method webhook.LoginMethod, sessionID string) {
+	user, err := persister.GetUserPersisterWithConnection(tx).Get(userId)
+	if err != nil {
+		ctx.Logger().Warn(fmt.Errorf("failed to fetch user: %w", err))
+		return
+	}
+
+	if user == nil {
+		ctx.Logger().Warn(fmt.Errorf("failed to fetch user: user %s not found", userId))
+		return
+	}
+
+	err = TriggerWebhooks(ctx, tx, events.UserLogin, webhook.Login{
+		User:      admin.FromUserModel(*user),
+		Method:    method,
+		SessionID: sessionID,
+	})
+	if err != nil {
+		ctx.Logger().Warn(err)
+	}
+}
+
+// NotifyLoginFailed triggers the "user.login.failed" webhook. The user ID is nil if the user is unknown.
+func NotifyLoginFailed(ctx echo.Context, tx *pop.Connection, userId *uuid.UUID, method webhook.LoginMethod, reason string) {
+	err := TriggerWebhooks(ctx, tx, events.UserLoginFailed, webhook.LoginFailed{
+		UserID: userId,
+		Method: method,
+		Reason: reason,
+	})
+	if err != nil {
+		ctx.Logger().Warn(err)
+	}
+}
+
+// NotifySessionCreate triggers the "session.create" webhook for the session represented by the given session token.
+func NotifySessionCreate(ctx echo.Context, tx *pop.Connection, token jwt.Token) {
+	sessionID, _ := token.Get("session_id")
+	sessionIDString, _ := sessionID.(string)
+	expiresAt := token.Expiration()
+
+	NotifySession(ctx, tx, events.SessionCreate, models.Session{
+		ID:        uuid.FromStringOrNil(sessionIDString),
+		UserID:    uuid.FromStringOrNil(token.Subject()),
+		UserAgent: ctx.Request().UserAgent(),
+		IpAddress: ctx.RealIP(),
+		CreatedAt: token.IssuedAt(),
+		ExpiresAt: &expiresAt,
+	})
+}
+
+// NotifyLoginSession triggers the "session.create" and "user.login" webhooks for a login which issued the given
+// session token.
+func NotifyLoginSession(ctx echo.Context, tx *pop.Connection, persister persistence.Persister, token jwt.Token, method webhook.LoginMethod) {
+	NotifySessionCreate(ctx, tx, token)
+
+	sessionID, _ := token.Get("session_id")
+	sessionIDString, _ := sessionID.(string)
+	NotifyLogin(ctx, tx, persister, uuid.FromStringOrNil(token.Subject()), method, sessionIDString)
+}
+
+// NotifySession triggers a session webhook, e.g. "session.revoke", for the given session.
+func NotifySession(ctx echo.Context, tx *pop.Connection, event events.Event, session models.Session) {
+	err := TriggerWebhooks(ctx, tx, event, webhook.Session{
+		ID:        session.ID,
+		UserID:    session.UserID,
+		UserAgent: session.UserAgent,
+		IpAddress: session.IpAddress,
+		CreatedAt: session.CreatedAt,
+		ExpiresAt: session.ExpiresAt,
+	})
+	if err != nil {
+		ctx.Logger().Warn(err)
+	}
+}
@@ -2,12 +2,19 @@ package utils
 
 import (
 	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
 	"github.com/labstack/echo/v4"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/teamhanko/hanko/backend/dto/webhook"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/test"
 	"github.com/teamhanko/hanko/backend/webhooks/events"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 type testManager struct {
@@ -54,3 +61,85 @@ func TestWebhook_Trigger(t *testing.T) {
 	err = e.Close()
 	require.NoError(t, err)
 }
+
+type recordingManager struct {
+	events []events.Event
+	data   []interface{}
+}
+
+func (rm *recordingManager) Trigger(tx *pop.Connection, evt events.Event, data interface{}) {
+	rm.events = append(rm.events, evt)
+	rm.data = append(rm.data, data)
+}
+
+func (rm *recordingManager) GenerateJWT(data interface{}, event events.Event) (string, error) {
+	return "", nil
+}
+
+func newRecordingContext() (echo.Context, *recordingManager) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/path", nil)
+	req.Header.Set("User-Agent", "test-agent")
+	rec := httptest.NewRecorder()
+
+	rm := &recordingManager{}
+	ctx := e.NewContext(req, rec)
+	ctx.Set("webhook_manager", rm)
+
+	return ctx, rm
+}
+
+func TestWebhook_NotifyUserChange_UnknownUser(t *testing.T) {
+	ctx, rm := newRecordingContext()
+	persister := test.NewEmptyPersister()
+
+	NotifyUserChange(ctx, nil, persister, events.UserPassword, uuid.Must(uuid.NewV4()))
+
+	assert.Empty(t, rm.events)
+}
+
+func TestWebhook_NotifyLoginSession(t *testing.T) {
+	ctx, rm := newRecordingContext()
+	userID := uuid.Must(uuid.NewV4())
+	sessionID := uuid.Must(uuid.NewV4())
+	persister := test.NewPersister([]models.User{{ID: userID}}, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	token := jwt.New()
+	require.NoError(t, token.Set(jwt.SubjectKey, userID.String()))
+	require.NoError(t, token.Set(jwt.IssuedAtKey, now))
+	require.NoError(t, token.Set(jwt.ExpirationKey, now.Add(time.Hour)))
+	require.NoError(t, token.Set("session_id", sessionID.String()))
+
+	NotifyLoginSession(ctx, nil, persister, token, webhook.LoginMethodPasskey)
+
+	require.Equal(t, []events.Event{events.SessionCreate, events.UserLogin}, rm.events)
+
+	session, ok := rm.data[0].(webhook.Session)
+	require.True(t, ok)
+	assert.Equal(t, sessionID, session.ID)
+	assert.Equal(t, userID, session.UserID)
+	assert.Equal(t, "test-agent", session.UserAgent)
+	assert.Equal(t, now, session.CreatedAt)
+	require.NotNil(t, session.ExpiresAt)
+	assert.Equal(t, now.Add(time.Hour), *session.ExpiresAt)
+
+	login, ok := rm.data[1].(webhook.Login)
+	require.True(t, ok)
+	assert.Equal(t, userID, login.User.ID)
+	assert.Equal(t, webhook.LoginMethodPasskey, login.Method)
+	assert.Equal(t, sessionID.String(), login.SessionID)
+}
+
+func TestWebhook_NotifyLoginFailed(t *testing.T) {
+	ctx, rm := newRecordingContext()
+
+	NotifyLoginFailed(ctx, nil, nil, webhook.LoginMethodPassword, "password_invalid")
+
+	require.Equal(t, []events.Event{events.UserLoginFailed}, rm.events)
+	loginFailed, ok := rm.data[0].(webhook.LoginFailed)
+	require.True(t, ok)
+	assert.Nil(t, loginFailed.UserID)
+	assert.Equal(t, webhook.LoginMethodPassword, loginFailed.Method)
+	assert.Equal(t, "password_invalid", loginFailed.Reason)
+}
@@ -5,14 +5,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/labstack/echo/v4"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/telemetry"
 	"github.com/teamhanko/hanko/backend/webhooks/events"
+	"io"
 	"net/http"
-	"strings"
+	"strconv"
 	"time"
 )
 
 type Webhook interface {
-	Trigger(data JobData) error
+	Trigger(messageID string, data JobData) (*Response, error)
 	DisableOnExpiryDate(now time.Time) error
 	DisableOnFailure() error
 	Reset() error
@@ -24,17 +27,32 @@ type Webhooks []Webhook
 
 const (
 	WebhookExpireDuration = 30 * 24 * time.Hour // 30 Days
+	requestTimeout        = 30 * time.Second
+	// MaxResponseBodyLength is the maximum number of bytes of a response body kept for the delivery log.
+	MaxResponseBodyLength = 1024
 )
 
+// Response describes the response of a webhook receiver.
+type Response struct {
+	StatusCode int
+	// Body contains at most MaxResponseBodyLength bytes of the response body.
+	Body    string
+	Latency time.Duration
+}
+
 type BaseWebhook struct {
 	Logger   echo.Logger
 	Callback string
 	Events   events.Events
+	// Secrets are used to sign the webhook requests. The request carries one signature per secret, so a previous
+	// secret can still be used for verification for a while after a secret rotation. Requests are not signed if
+	// there are no secrets.
+	Secrets []string
 }
 
 func (bh *BaseWebhook) HasEvent(evt events.Event) bool {
 	for _, event := range bh.Events {
-		if strings.HasPrefix(string(evt), string(event)) {
+		if evt.IsSubscribedBy(event) {
 			return true
 		}
 	}
@@ -42,34 +60,68 @@ func (bh *BaseWebhook) HasEvent(evt events.Event) bool {
 	return false
 }
 
-func (bh *BaseWebhook) Trigger(data JobData) error {
+// Trigger sends the given data to the callback of the webhook. The request carries the "Webhook-Id",
+// "Webhook-Timestamp" and, if the webhook has secrets, the "Webhook-Signature" headers as defined by the Standard
+// Webhooks specification. The response is returned if one has been received, even if the request failed due to its
+// status code.
+func (bh *BaseWebhook) Trigger(messageID string, data JobData) (*Response, error) {
 	// create request
-	dataJson, err := json.Marshal(data)
+	contentType := "application/json"
+	var payload interface{} = data
+	if data.Format == config.WebhookFormatCloudEvents {
+		contentType = CloudEventsContentType
+		payload = NewCloudEvent(messageID, data)
+	}
+
+	dataJson, err := json.Marshal(payload)
 	if err != nil {
 		bh.Logger.Error(fmt.Errorf("unable to convert JobData to json: %w", err))
-		return err
+		return nil, err
 	}
 
 	request, err := http.NewRequest(http.MethodPost, bh.Callback, bytes.NewReader(dataJson))
 	if err != nil {
 		bh.Logger.Error(fmt.Errorf("unable to create request for webhook: %w", err))
-		return err
+		return nil, err
+	}
+	request.Header.Set("Content-Type", contentType)
+
+	timestamp := time.Now()
+	request.Header.Set(HeaderWebhookID, messageID)
+	request.Header.Set(HeaderWebhookTimestamp, strconv.FormatInt(timestamp.Unix(), 10))
+
+	if len(bh.Secrets) > 0 {
+		signature, err := Sign(bh.Secrets, messageID, timestamp, dataJson)
+		if err != nil {
+			bh.Logger.Error(fmt.Errorf("unable to sign webhook request: %w", err))
+			return nil, err
+		}
+		request.Header.Set(HeaderWebhookSignature, signature)
 	}
-	request.Header.Set("Content-Type", "application/json")
 
-	client := http.Client{}
+	client := http.Client{Timeout: requestTimeout, Transport: telemetry.NewTransport(nil)}
+	start := time.Now()
 	response, err := client.Do(request)
 	if err != nil {
 		bh.Logger.Error(fmt.Errorf("unable to execute webhook request: %w", err))
-		return err
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	// a failure to read the body is irrelevant for the delivery itself
+	body, _ := io.ReadAll(io.LimitReader(response.Body, MaxResponseBodyLength))
+	result := &Response{
+		StatusCode: response.StatusCode,
+		Body:       string(body),
+		Latency:    time.Since(start),
 	}
 
 	if response.StatusCode >= http.StatusBadRequest {
 		err := fmt.Errorf("request failed due to status code: %d", response.StatusCode)
 		bh.Logger.Error(err)
 
-		return err
+		return result, err
 	}
 
-	return nil
+	return result, nil
 }
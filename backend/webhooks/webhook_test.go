@@ -1,11 +1,16 @@
 package webhooks
 
 import (
+	"encoding/json"
 	"github.com/labstack/gommon/log"
 	"github.com/stretchr/testify/require"
+	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/webhooks/events"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -60,9 +65,68 @@ func TestBaseWebhook_DoesNotHaveEvent(t *testing.T) {
 	require.False(t, baseHook.HasEvent("user"))
 }
 
+func TestBaseWebhook_HasEvent_Login(t *testing.T) {
+	userHook := BaseWebhook{
+		Callback: "http://ipsum.lorem",
+		Events:   events.Events{events.User},
+	}
+
+	require.True(t, userHook.HasEvent(events.UserCreate))
+	require.False(t, userHook.HasEvent(events.UserLogin))
+	require.False(t, userHook.HasEvent(events.UserLoginFailed))
+
+	loginHook := BaseWebhook{
+		Callback: "http://ipsum.lorem",
+		Events:   events.Events{events.UserLogin},
+	}
+
+	require.True(t, loginHook.HasEvent(events.UserLogin))
+	require.True(t, loginHook.HasEvent(events.UserLoginFailed))
+	require.False(t, loginHook.HasEvent(events.UserCreate))
+
+	loginFailedHook := BaseWebhook{
+		Callback: "http://ipsum.lorem",
+		Events:   events.Events{events.UserLoginFailed},
+	}
+
+	require.True(t, loginFailedHook.HasEvent(events.UserLoginFailed))
+	require.False(t, loginFailedHook.HasEvent(events.UserLogin))
+}
+
 func TestBaseWebhook_Trigger(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(strings.Repeat("a", MaxResponseBodyLength+1)))
+	}))
+	defer server.Close()
+
+	baseHook := BaseWebhook{
+		Logger:   nil,
+		Callback: server.URL,
+		Events:   events.Events{events.UserCreate},
+	}
+
+	data := JobData{
+		Token: "test-token",
+		Event: "user",
+	}
+
+	response, err := baseHook.Trigger("msg_test", data)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Len(t, response.Body, MaxResponseBodyLength)
+}
+
+func TestBaseWebhook_TriggerWithSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+
+	var header http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
 	}))
 	defer server.Close()
 
@@ -70,6 +134,7 @@ func TestBaseWebhook_Trigger(t *testing.T) {
 		Logger:   nil,
 		Callback: server.URL,
 		Events:   events.Events{events.UserCreate},
+		Secrets:  []string{secret},
 	}
 
 	data := JobData{
@@ -77,8 +142,67 @@ func TestBaseWebhook_Trigger(t *testing.T) {
 		Event: "user",
 	}
 
-	err := baseHook.Trigger(data)
+	_, err = baseHook.Trigger("msg_test", data)
+	require.NoError(t, err)
+
+	require.Equal(t, "msg_test", header.Get(HeaderWebhookID))
+	timestamp, err := strconv.ParseInt(header.Get(HeaderWebhookTimestamp), 10, 64)
+	require.NoError(t, err)
+
+	expectedSignature, err := Sign([]string{secret}, "msg_test", time.Unix(timestamp, 0), body)
+	require.NoError(t, err)
+	require.Equal(t, expectedSignature, header.Get(HeaderWebhookSignature))
+}
+
+func TestBaseWebhook_TriggerCloudEvents(t *testing.T) {
+	var header http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+
+	baseHook := BaseWebhook{
+		Logger:   nil,
+		Callback: server.URL,
+		Events:   events.Events{events.UserCreate},
+		Secrets:  []string{secret},
+	}
+
+	occurredAt := time.Date(2024, 10, 24, 9, 0, 0, 0, time.UTC)
+	data := JobData{
+		Event:  events.UserCreate,
+		Format: config.WebhookFormatCloudEvents,
+		Data:   json.RawMessage(`{"id":"lorem-ipsum"}`),
+		Time:   occurredAt,
+		Source: "https://auth.example.com",
+	}
+
+	_, err = baseHook.Trigger("msg_test", data)
+	require.NoError(t, err)
+
+	require.Equal(t, CloudEventsContentType, header.Get("Content-Type"))
+
+	var cloudEvent map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &cloudEvent))
+	require.Equal(t, "1.0", cloudEvent["specversion"])
+	require.Equal(t, "msg_test", cloudEvent["id"])
+	require.Equal(t, "https://auth.example.com", cloudEvent["source"])
+	require.Equal(t, "io.hanko.user.create", cloudEvent["type"])
+	require.Equal(t, "2024-10-24T09:00:00Z", cloudEvent["time"])
+	require.Equal(t, map[string]interface{}{"id": "lorem-ipsum"}, cloudEvent["data"])
+	require.NotContains(t, cloudEvent, "token")
+
+	timestamp, err := strconv.ParseInt(header.Get(HeaderWebhookTimestamp), 10, 64)
+	require.NoError(t, err)
+	expectedSignature, err := Sign([]string{secret}, "msg_test", time.Unix(timestamp, 0), body)
 	require.NoError(t, err)
+	require.Equal(t, expectedSignature, header.Get(HeaderWebhookSignature))
 }
 
 func TestBaseWebhook_TriggerWithWrongUrl(t *testing.T) {
@@ -93,8 +217,9 @@ func TestBaseWebhook_TriggerWithWrongUrl(t *testing.T) {
 		Event: "user",
 	}
 
-	err := baseHook.Trigger(data)
+	response, err := baseHook.Trigger("msg_test", data)
 	require.Error(t, err)
+	require.Nil(t, response)
 	require.Contains(t, err.Error(), "dial tcp: lookup broken!: no such host")
 }
 
@@ -115,10 +240,11 @@ func TestBaseWebhook_TriggerWithBadStatusCode(t *testing.T) {
 		Event: "user",
 	}
 
-	err := baseHook.Trigger(data)
+	response, err := baseHook.Trigger("msg_test", data)
 
 	require.Error(t, err)
 	require.ErrorContains(t, err, "request failed due to status code")
+	require.Equal(t, http.StatusBadRequest, response.StatusCode)
 }
 
 func TestBaseWebhook_TriggerWithBadServer(t *testing.T) {
@@ -140,7 +266,7 @@ func TestBaseWebhook_TriggerWithBadServer(t *testing.T) {
 		Event: "user",
 	}
 
-	err := baseHook.Trigger(data)
+	_, err := baseHook.Trigger("msg_test", data)
 
 	require.Error(t, err)
 	require.ErrorContains(t, err, "EOF")
@@ -1,21 +1,42 @@
 package webhooks
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/labstack/echo/v4"
+	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/webhooks/events"
 	"time"
 )
 
 type Job struct {
+	// ID identifies the message. It is sent in the "Webhook-Id" header and stays the same for every hook and
+	// delivery attempt of the message.
+	ID              string
 	Data            JobData
 	Hook            Webhook
 	CanExpireAtTime bool
+	// RetryOnFailure indicates that the job is retried if the delivery fails. The failure counter of the hook is
+	// only increased for failed deliveries which are not retried.
+	RetryOnFailure bool
+	// Complete, if set, is called with the result of the job once it has been processed. The response is nil if
+	// the hook has not been triggered or no response has been received.
+	Complete func(response *Response, err error)
 }
 
+// JobData is the data of a webhook request. Hooks using the "hanko-jwt" format send the serialized JobData, hooks
+// using the "cloudevents" format send a CloudEvent built from it (see NewCloudEvent).
 type JobData struct {
 	Token string       `json:"token"`
 	Event events.Event `json:"event"`
+	// Format is the format of the request body.
+	Format config.WebhookFormat `json:"-"`
+	// Data is the plain event data.
+	Data json.RawMessage `json:"-"`
+	// Time is the time the event occurred.
+	Time time.Time `json:"-"`
+	// Source is the CloudEvents source of the event.
+	Source string `json:"-"`
 }
 
 type Worker struct {
@@ -37,42 +58,53 @@ func (w *Worker) Run() {
 			break
 		}
 
-		err := w.triggerWebhook(job)
-		if err != nil {
-			w.logger.Error(fmt.Errorf("unable to trigger webhook: %w", err))
-			continue
-		}
+		w.Process(job)
 	}
 }
 
-func (w *Worker) triggerWebhook(job Job) error {
+// Process triggers the webhook of the given job and completes the job with the result.
+func (w *Worker) Process(job Job) {
+	response, err := w.triggerWebhook(job)
+	if job.Complete != nil {
+		job.Complete(response, err)
+	}
+	if err != nil {
+		w.logger.Error(fmt.Errorf("unable to trigger webhook: %w", err))
+	}
+}
+
+func (w *Worker) triggerWebhook(job Job) (*Response, error) {
 	now := time.Now()
 	// only if jobs are allowed to expire
 	if job.CanExpireAtTime {
 		// check for expire date
 		err := job.Hook.DisableOnExpiryDate(now)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
 	if job.Hook.IsEnabled() {
-		err := job.Hook.Trigger(job.Data)
+		response, err := job.Hook.Trigger(job.ID, job.Data)
 		if err != nil {
-			// expire after failure (if failure counter > FailureExpireRate)
-			disableErr := job.Hook.DisableOnFailure()
-			if disableErr != nil {
-				return disableErr
+			if !job.RetryOnFailure {
+				// expire after failure (if failure counter > FailureExpireRate)
+				disableErr := job.Hook.DisableOnFailure()
+				if disableErr != nil {
+					return response, disableErr
+				}
 			}
 
-			return err
+			return response, err
 		}
 
 		err = job.Hook.Reset()
 		if err != nil {
-			return err
+			return response, err
 		}
+
+		return response, nil
 	}
 
-	return nil
+	return nil, nil
 }
@@ -39,8 +39,8 @@ func (th *TestHook) IsEnabled() bool {
 	return th.IsEnabledFunc()
 }
 
-func (th *TestHook) Trigger(_ JobData) error {
-	return th.TriggerFunc()
+func (th *TestHook) Trigger(_ string, _ JobData) (*Response, error) {
+	return nil, th.TriggerFunc()
 }
 
 func (th *TestHook) DisableOnFailure() error {
@@ -156,7 +156,7 @@ func TestWorker_TriggerWebhook(t *testing.T) {
 	}
 
 	worker := TestWorker{NewWorker(nil, log.New("test"))}
-	err := worker.triggerWebhook(job)
+	_, err := worker.triggerWebhook(job)
 	require.NoError(t, err)
 }
 
@@ -177,7 +177,7 @@ func TestWorker_TriggerWebhookWithExpireError(t *testing.T) {
 	}
 
 	worker := TestWorker{NewWorker(nil, log.New("test"))}
-	err := worker.triggerWebhook(job)
+	_, err := worker.triggerWebhook(job)
 	require.ErrorContains(t, err, "expired error")
 }
 
@@ -202,7 +202,7 @@ func TestWorker_TriggerWebhookIgnoreDisabledJob(t *testing.T) {
 	}
 
 	worker := TestWorker{NewWorker(nil, log.New("test"))}
-	err := worker.triggerWebhook(job)
+	_, err := worker.triggerWebhook(job)
 	require.NoError(t, err)
 }
 
@@ -235,7 +235,7 @@ func TestWorker_TriggerWebhookTriggerWithError(t *testing.T) {
 	}
 
 	worker := TestWorker{NewWorker(nil, log.New("test"))}
-	err := worker.triggerWebhook(job)
+	_, err := worker.triggerWebhook(job)
 	require.ErrorContains(t, err, "trigger error")
 }
 
@@ -268,7 +268,7 @@ func TestWorker_TriggerWebhookDisableOnFailure(t *testing.T) {
 	}
 
 	worker := TestWorker{NewWorker(nil, log.New("test"))}
-	err := worker.triggerWebhook(job)
+	_, err := worker.triggerWebhook(job)
 	require.ErrorContains(t, err, "failure error")
 }
 func TestWorker_TriggerWebhookResetError(t *testing.T) {
@@ -304,7 +304,7 @@ func TestWorker_TriggerWebhookResetError(t *testing.T) {
 	}
 
 	worker := TestWorker{NewWorker(nil, log.New("test"))}
-	err := worker.triggerWebhook(job)
+	_, err := worker.triggerWebhook(job)
 	require.ErrorContains(t, err, "disable error")
 }
 func TestWorker_TriggerWebhookWithDisabledTimeExpire(t *testing.T) {
@@ -340,6 +340,6 @@ func TestWorker_TriggerWebhookWithDisabledTimeExpire(t *testing.T) {
 	}
 
 	worker := TestWorker{NewWorker(nil, log.New("test"))}
-	err := worker.triggerWebhook(job)
+	_, err := worker.triggerWebhook(job)
 	require.ErrorContains(t, err, "disable error")
 }